@@ -0,0 +1,139 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gotmc/keysight/tracemath"
+)
+
+// logAverageCorrection is the amount in dB that log averaging under-reports
+// the power of Gaussian noise.
+const logAverageCorrection = 2.51
+
+// noiseWidthFraction is the default fraction of the trace span averaged by
+// the noise marker, which matches the instrument's noise marker.
+const noiseWidthFraction = 0.05
+
+// Detector is the detector and averaging used to measure a trace, which
+// determines the correction needed to measure noise.
+type Detector int
+
+// Available detectors.
+const (
+	// DetectorSample is a sample detected trace without video averaging.
+	DetectorSample Detector = iota
+	// DetectorRMS is a trace measured with the RMS (power average) detector
+	// or averaged on a power scale.
+	DetectorRMS
+	// DetectorLogAverage is a trace averaged on a log scale, such as with
+	// video averaging of a trace in dBm, which reads noise 2.51 dB low.
+	DetectorLogAverage
+)
+
+// String implements the Stringer interface for Detector.
+func (d Detector) String() string {
+	switch d {
+	case DetectorSample:
+		return "Sample"
+	case DetectorRMS:
+		return "RMS"
+	case DetectorLogAverage:
+		return "Log Average"
+	}
+	return fmt.Sprintf("Detector(%d)", int(d))
+}
+
+// WithDetector sets the detector used to measure the trace for the noise
+// measurements. The default is DetectorSample.
+func WithDetector(d Detector) PowerOption {
+	return func(cfg *powerConfig) {
+		cfg.detector = d
+	}
+}
+
+// WithAveragingWidth sets the width in Hz of the trace around the marker that
+// is averaged by the noise measurements. The default is 5% of the trace
+// span.
+func WithAveragingWidth(width float64) PowerOption {
+	return func(cfg *powerConfig) {
+		cfg.width = width
+	}
+}
+
+// PhaseNoiseResult is the result of a phase noise measurement.
+type PhaseNoiseResult struct {
+	Carrier Marker
+	// Offset is the offset in Hz from the carrier of the noise measurement.
+	Offset float64
+	// Density is the noise density at the offset in dBm/Hz.
+	Density float64
+	// PhaseNoise is the noise density relative to the carrier in dBc/Hz.
+	PhaseNoise float64
+}
+
+// NoiseDensity returns the noise density in dBm/Hz at the given frequency,
+// which mirrors the instrument's noise marker. The trace points within the
+// averaging width around the frequency are averaged as power, normalized
+// from the noise bandwidth of the given RBW to 1 Hz, and corrected for the
+// detector.
+func NoiseDensity(trace tracemath.Trace, freq, rbw float64, opts ...PowerOption) (float64, error) {
+	if rbw <= 0 {
+		return 0, fmt.Errorf("invalid rbw: %g", rbw)
+	}
+	if err := check(trace); err != nil {
+		return 0, err
+	}
+	cfg := newPowerConfig(opts)
+	n := len(trace.Frequency)
+	width := cfg.width
+	if width <= 0 {
+		width = noiseWidthFraction * (trace.Frequency[n-1] - trace.Frequency[0])
+	}
+	lo, hi := freq-width/2, freq+width/2
+	if freq < trace.Frequency[0] || freq > trace.Frequency[n-1] {
+		return 0, fmt.Errorf("frequency %g Hz is outside the trace", freq)
+	}
+	sum := 0.0
+	count := 0
+	for i, f := range trace.Frequency {
+		if f >= lo && f <= hi {
+			sum += math.Pow(10, trace.Values[i]/10)
+			count++
+		}
+	}
+	if count == 0 {
+		m, _ := MarkerAt(trace, freq)
+		sum, count = math.Pow(10, m.Amplitude/10), 1
+	}
+	density := toDBm(sum/float64(count)) - 10*math.Log10(rbw*cfg.nbwFactor)
+	if cfg.detector == DetectorLogAverage {
+		density += logAverageCorrection
+	}
+	return density, nil
+}
+
+// PhaseNoise returns the noise density at the offset from the highest peak
+// of the trace relative to the peak. A negative offset measures below the
+// carrier.
+func PhaseNoise(trace tracemath.Trace, offset, rbw float64, opts ...PowerOption) (PhaseNoiseResult, error) {
+	carrier, err := MaxPeak(trace)
+	if err != nil {
+		return PhaseNoiseResult{}, err
+	}
+	density, err := NoiseDensity(trace, carrier.Frequency+offset, rbw, opts...)
+	if err != nil {
+		return PhaseNoiseResult{}, err
+	}
+	return PhaseNoiseResult{
+		Carrier:    carrier,
+		Offset:     offset,
+		Density:    density,
+		PhaseNoise: density - carrier.Amplitude,
+	}, nil
+}
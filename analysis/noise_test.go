@@ -0,0 +1,68 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNoiseDensity(t *testing.T) {
+	trace := bandTrace(-60, -100)
+	// Vary the noise around its mean power between 90 and 100 kHz, where the
+	// average of each pair of points is the mean power.
+	for i := 90; i <= 100; i++ {
+		if i%2 == 0 {
+			trace.Values[i] = -100 + 10*math.Log10(1.5)
+		} else {
+			trace.Values[i] = -100 + 10*math.Log10(0.5)
+		}
+	}
+	var tests = []struct {
+		name string
+		freq float64
+		opts []PowerOption
+		want float64
+	}{
+		{"default", 80e3, nil, -130 - 10*math.Log10(DefaultNoiseBandwidthFactor)},
+		{"nbw factor", 80e3, []PowerOption{WithNoiseBandwidthFactor(1)}, -130},
+		{"log average", 80e3, []PowerOption{WithNoiseBandwidthFactor(1), WithDetector(DetectorLogAverage)}, -127.49},
+		{"width", 95.5e3, []PowerOption{WithNoiseBandwidthFactor(1), WithAveragingWidth(4e3)}, -130},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NoiseDensity(trace, test.freq, 1e3, test.opts...)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assertFloat64(t, "density", got, test.want, 1e-9)
+		})
+	}
+	if _, err := NoiseDensity(trace, 200e3, 1e3); err == nil {
+		t.Errorf("expected error for frequency outside the trace")
+	}
+	if _, err := NoiseDensity(trace, 85e3, 0); err == nil {
+		t.Errorf("expected error for zero rbw")
+	}
+	assert(t, "detector string", DetectorLogAverage.String(), "Log Average")
+}
+
+func TestPhaseNoise(t *testing.T) {
+	trace := bandTrace(-100, -100)
+	trace.Values[50] = -10
+	got, err := PhaseNoise(trace, 20e3, 1e3, WithNoiseBandwidthFactor(1))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "carrier freq", got.Carrier.Frequency, 50e3, 1e-6)
+	assertFloat64(t, "density", got.Density, -130, 1e-9)
+	assertFloat64(t, "phase noise", got.PhaseNoise, -120, 1e-9)
+	got, err = PhaseNoise(trace, -20e3, 1e3, WithNoiseBandwidthFactor(1))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "lower phase noise", got.PhaseNoise, -120, 1e-9)
+}
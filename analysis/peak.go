@@ -0,0 +1,251 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package analysis performs spectrum analyzer marker functions and
+// measurements on saved trace data, so that traces can be re-analyzed
+// without the instrument. Traces are given as a tracemath.Trace with the
+// frequencies in Hz and the amplitudes in dB units, such as dBm. The power
+// measurements take the amplitudes to be in dBm unless other units are given
+// using WithUnits.
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gotmc/keysight/tracemath"
+)
+
+// DefaultPeakExcursion is the default minimum amount in dB that a signal must
+// rise and fall to be considered a peak, which matches the instrument
+// default.
+const DefaultPeakExcursion = 6.0
+
+// ErrNoPeak is returned when a peak search doesn't find a peak.
+var ErrNoPeak = errors.New("no peak found")
+
+// Marker is a point on a trace.
+type Marker struct {
+	Index     int
+	Frequency float64
+	Amplitude float64
+}
+
+// Delta is the difference between a marker and a reference marker.
+type Delta struct {
+	Reference Marker
+	Marker    Marker
+	// Frequency is the frequency of the marker minus the frequency of the
+	// reference in Hz.
+	Frequency float64
+	// Amplitude is the amplitude of the marker minus the amplitude of the
+	// reference in dB.
+	Amplitude float64
+}
+
+// PeakOption configures the peak search.
+type PeakOption func(*peakConfig)
+
+type peakConfig struct {
+	excursion float64
+	threshold float64
+	maxPeaks  int
+}
+
+func newPeakConfig(opts []PeakOption) peakConfig {
+	cfg := peakConfig{
+		excursion: DefaultPeakExcursion,
+		threshold: math.Inf(-1),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithPeakExcursion sets the minimum amount in dB that a signal must rise and
+// fall on both sides to be considered a peak.
+func WithPeakExcursion(excursion float64) PeakOption {
+	return func(cfg *peakConfig) {
+		cfg.excursion = math.Abs(excursion)
+	}
+}
+
+// WithPeakThreshold ignores peaks below the given amplitude.
+func WithPeakThreshold(threshold float64) PeakOption {
+	return func(cfg *peakConfig) {
+		cfg.threshold = threshold
+	}
+}
+
+// WithMaxPeaks limits the number of peaks returned by PeakSearch. Zero, the
+// default, returns every peak.
+func WithMaxPeaks(n int) PeakOption {
+	return func(cfg *peakConfig) {
+		if n >= 0 {
+			cfg.maxPeaks = n
+		}
+	}
+}
+
+// MarkerAt returns the marker on the trace point closest to the given
+// frequency.
+func MarkerAt(trace tracemath.Trace, freq float64) (Marker, error) {
+	if err := check(trace); err != nil {
+		return Marker{}, err
+	}
+	best := 0
+	for i, f := range trace.Frequency {
+		if math.Abs(f-freq) < math.Abs(trace.Frequency[best]-freq) {
+			best = i
+		}
+	}
+	return marker(trace, best), nil
+}
+
+// DeltaMarker returns the difference between the markers closest to the
+// given frequencies.
+func DeltaMarker(trace tracemath.Trace, refFreq, freq float64) (Delta, error) {
+	ref, err := MarkerAt(trace, refFreq)
+	if err != nil {
+		return Delta{}, err
+	}
+	m, err := MarkerAt(trace, freq)
+	if err != nil {
+		return Delta{}, err
+	}
+	return Delta{
+		Reference: ref,
+		Marker:    m,
+		Frequency: m.Frequency - ref.Frequency,
+		Amplitude: m.Amplitude - ref.Amplitude,
+	}, nil
+}
+
+// MaxPeak returns the marker on the highest point of the trace, which is
+// the instrument's Peak Search. Points that aren't numbers, such as those
+// masked by esa.Trace.Mask, are skipped.
+func MaxPeak(trace tracemath.Trace) (Marker, error) {
+	if err := check(trace); err != nil {
+		return Marker{}, err
+	}
+	best := -1
+	for i, v := range trace.Values {
+		if !math.IsNaN(v) && (best < 0 || v > trace.Values[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Marker{}, ErrNoPeak
+	}
+	return marker(trace, best), nil
+}
+
+// PeakSearch returns the peaks of the trace sorted from the highest to the
+// lowest amplitude. A point is a peak if the trace falls by at least the peak
+// excursion on both sides before rising above the point again, or before the
+// end of the trace.
+func PeakSearch(trace tracemath.Trace, opts ...PeakOption) ([]Marker, error) {
+	if err := check(trace); err != nil {
+		return nil, err
+	}
+	cfg := newPeakConfig(opts)
+	peaks := findPeaks(trace, cfg)
+	sort.SliceStable(peaks, func(i, j int) bool {
+		return peaks[i].Amplitude > peaks[j].Amplitude
+	})
+	if cfg.maxPeaks > 0 && len(peaks) > cfg.maxPeaks {
+		peaks = peaks[:cfg.maxPeaks]
+	}
+	return peaks, nil
+}
+
+// NextPeak returns the highest peak lower in amplitude than the given
+// marker, which is the instrument's Next Peak.
+func NextPeak(trace tracemath.Trace, from Marker, opts ...PeakOption) (Marker, error) {
+	peaks, err := PeakSearch(trace, opts...)
+	if err != nil {
+		return Marker{}, err
+	}
+	for _, p := range peaks {
+		if p.Amplitude < from.Amplitude {
+			return p, nil
+		}
+	}
+	return Marker{}, ErrNoPeak
+}
+
+// NextPeakRight returns the closest peak above the frequency of the given
+// marker.
+func NextPeakRight(trace tracemath.Trace, from Marker, opts ...PeakOption) (Marker, error) {
+	if err := check(trace); err != nil {
+		return Marker{}, err
+	}
+	for _, p := range findPeaks(trace, newPeakConfig(opts)) {
+		if p.Frequency > from.Frequency {
+			return p, nil
+		}
+	}
+	return Marker{}, ErrNoPeak
+}
+
+// NextPeakLeft returns the closest peak below the frequency of the given
+// marker.
+func NextPeakLeft(trace tracemath.Trace, from Marker, opts ...PeakOption) (Marker, error) {
+	if err := check(trace); err != nil {
+		return Marker{}, err
+	}
+	peaks := findPeaks(trace, newPeakConfig(opts))
+	for i := len(peaks) - 1; i >= 0; i-- {
+		if peaks[i].Frequency < from.Frequency {
+			return peaks[i], nil
+		}
+	}
+	return Marker{}, ErrNoPeak
+}
+
+// findPeaks returns the peaks in order of increasing frequency.
+func findPeaks(trace tracemath.Trace, cfg peakConfig) []Marker {
+	v := trace.Values
+	n := len(v)
+	var peaks []Marker
+	for i := 0; i < n; i++ {
+		if v[i] < cfg.threshold {
+			continue
+		}
+		// Only the last point of a flat top is a candidate.
+		if (i > 0 && v[i-1] > v[i]) || (i < n-1 && v[i+1] >= v[i]) {
+			continue
+		}
+		left := v[i]
+		for j := i - 1; j >= 0 && v[j] <= v[i]; j-- {
+			left = math.Min(left, v[j])
+		}
+		right := v[i]
+		for j := i + 1; j < n && v[j] <= v[i]; j++ {
+			right = math.Min(right, v[j])
+		}
+		if v[i]-left >= cfg.excursion && v[i]-right >= cfg.excursion {
+			peaks = append(peaks, marker(trace, i))
+		}
+	}
+	return peaks
+}
+
+func marker(trace tracemath.Trace, i int) Marker {
+	return Marker{Index: i, Frequency: trace.Frequency[i], Amplitude: trace.Values[i]}
+}
+
+func check(trace tracemath.Trace) error {
+	if len(trace.Frequency) != len(trace.Values) {
+		return fmt.Errorf("mismatched lengths / freq %d / values %d", len(trace.Frequency), len(trace.Values))
+	}
+	if len(trace.Values) == 0 {
+		return fmt.Errorf("trace is empty")
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/tracemath"
+)
+
+func peakTrace() tracemath.Trace {
+	values := []float64{-80, -60, -80, -78, -75, -78, -90, -40, -40, -70, -85, -50, -85}
+	freqs := make([]float64, len(values))
+	for i := range freqs {
+		freqs[i] = float64(i) * 1e6
+	}
+	return tracemath.Trace{Frequency: freqs, Values: values}
+}
+
+func TestPeakSearch(t *testing.T) {
+	trace := peakTrace()
+	var tests = []struct {
+		name string
+		opts []PeakOption
+		want []int
+	}{
+		{"default", nil, []int{8, 11, 1}},
+		{"excursion", []PeakOption{WithPeakExcursion(3)}, []int{8, 11, 1, 4}},
+		{"threshold", []PeakOption{WithPeakThreshold(-55)}, []int{8, 11}},
+		{"max peaks", []PeakOption{WithMaxPeaks(1)}, []int{8}},
+		{"large excursion", []PeakOption{WithPeakExcursion(50)}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			peaks, err := PeakSearch(trace, test.opts...)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "num peaks", len(peaks), len(test.want))
+			for i := 0; i < len(peaks) && i < len(test.want); i++ {
+				assert(t, "peak index", peaks[i].Index, test.want[i])
+			}
+		})
+	}
+}
+
+func TestMarkerFunctions(t *testing.T) {
+	trace := peakTrace()
+	peak, err := MaxPeak(trace)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "max peak index", peak.Index, 7)
+	assertFloat64(t, "max peak freq", peak.Frequency, 7e6, 1e-6)
+
+	next, err := NextPeak(trace, peak)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "next peak", next.Index, 11)
+	next, _ = NextPeak(trace, next)
+	assert(t, "next next peak", next.Index, 1)
+	if _, err := NextPeak(trace, next); err != ErrNoPeak {
+		t.Errorf("expected ErrNoPeak, got %v", err)
+	}
+
+	right, err := NextPeakRight(trace, Marker{Frequency: 8e6})
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "next peak right", right.Index, 11)
+	left, err := NextPeakLeft(trace, Marker{Frequency: 8e6})
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "next peak left", left.Index, 1)
+	if _, err := NextPeakRight(trace, right); err != ErrNoPeak {
+		t.Errorf("expected ErrNoPeak, got %v", err)
+	}
+
+	m, err := MarkerAt(trace, 4.4e6)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "marker index", m.Index, 4)
+	delta, err := DeltaMarker(trace, 1e6, 11.2e6)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "delta freq", delta.Frequency, 10e6, 1e-6)
+	assertFloat64(t, "delta amplitude", delta.Amplitude, 10, 1e-9)
+
+	if _, err := MaxPeak(tracemath.Trace{}); err == nil {
+		t.Errorf("expected error for empty trace")
+	}
+	if _, err := PeakSearch(tracemath.Trace{Frequency: []float64{1}}); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+}
+
+func TestMaskedPeaks(t *testing.T) {
+	trace := peakTrace()
+	trace.Values[7], trace.Values[8] = math.NaN(), math.NaN()
+	peak, err := MaxPeak(trace)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "max peak index", peak.Index, 11)
+	peaks, err := PeakSearch(trace)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num peaks", len(peaks), 2)
+	assert(t, "first peak", peaks[0].Index, 11)
+
+	for i := range trace.Values {
+		trace.Values[i] = math.NaN()
+	}
+	if _, err := MaxPeak(trace); err != ErrNoPeak {
+		t.Errorf("expected ErrNoPeak, got %v", err)
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gotmc/keysight/tracemath"
+	"github.com/gotmc/keysight/units"
+)
+
+// DefaultNoiseBandwidthFactor is the ratio of the noise bandwidth to the
+// resolution bandwidth of the Gaussian RBW filters used by the swept
+// analyzers.
+const DefaultNoiseBandwidthFactor = 1.056
+
+// PowerOption configures the power measurements.
+type PowerOption func(*powerConfig)
+
+type powerConfig struct {
+	rbw       float64
+	nbwFactor float64
+	detector  Detector
+	width     float64
+	units     units.Unit
+	impedance float64
+}
+
+func newPowerConfig(opts []PowerOption) powerConfig {
+	cfg := powerConfig{
+		nbwFactor: DefaultNoiseBandwidthFactor,
+		units:     units.DBm,
+		impedance: units.DefaultImpedance,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithRBW sets the resolution bandwidth in Hz used to measure the trace. Each
+// trace point is the power within the noise bandwidth of the RBW filter, so
+// the power is scaled by the ratio of the point spacing to the noise
+// bandwidth. Without the RBW, each trace point is taken as the power within
+// the point spacing.
+func WithRBW(rbw float64) PowerOption {
+	return func(cfg *powerConfig) {
+		cfg.rbw = rbw
+	}
+}
+
+// WithNoiseBandwidthFactor sets the ratio of the noise bandwidth to the RBW.
+// The default is DefaultNoiseBandwidthFactor.
+func WithNoiseBandwidthFactor(factor float64) PowerOption {
+	return func(cfg *powerConfig) {
+		if factor > 0 {
+			cfg.nbwFactor = factor
+		}
+	}
+}
+
+// WithUnits sets the amplitude units of the trace values, such as dBuV, which
+// are converted to dBm using the given reference impedance in ohms. The
+// default is dBm.
+func WithUnits(u units.Unit, impedance float64) PowerOption {
+	return func(cfg *powerConfig) {
+		cfg.units = u
+		cfg.impedance = impedance
+	}
+}
+
+// ChannelPowerResult is the result of a channel power measurement.
+type ChannelPowerResult struct {
+	// Power is the total power within the integration bandwidth in dBm.
+	Power float64
+	// Density is the power spectral density in dBm/Hz.
+	Density float64
+}
+
+// OBWResult is the result of an occupied bandwidth measurement.
+type OBWResult struct {
+	Bandwidth float64
+	LowerFreq float64
+	UpperFreq float64
+	// TotalPower is the power of the whole trace in dBm.
+	TotalPower float64
+}
+
+// ACPROffset is an adjacent channel centered at the given offset from the
+// carrier, which is measured on both sides of the carrier.
+type ACPROffset struct {
+	Offset  float64
+	IntegBW float64
+}
+
+// ACPROffsetResult is the power of the adjacent channels at an offset.
+type ACPROffsetResult struct {
+	Offset float64
+	// LowerPower and UpperPower are the channel powers in dBm.
+	LowerPower float64
+	UpperPower float64
+	// LowerRatio and UpperRatio are relative to the carrier power in dBc.
+	LowerRatio float64
+	UpperRatio float64
+}
+
+// ACPRResult is the result of an adjacent channel power ratio measurement.
+type ACPRResult struct {
+	CarrierPower float64
+	Offsets      []ACPROffsetResult
+}
+
+// spectrum is the linear power in mW of each trace point along with the
+// point spacing in Hz.
+type spectrum struct {
+	freqs   []float64
+	power   []float64
+	spacing float64
+}
+
+func newSpectrum(trace tracemath.Trace, cfg powerConfig) (spectrum, error) {
+	if err := check(trace); err != nil {
+		return spectrum{}, err
+	}
+	n := len(trace.Frequency)
+	if n < 2 {
+		return spectrum{}, fmt.Errorf("trace needs at least 2 points / got %d", n)
+	}
+	s := spectrum{
+		freqs:   trace.Frequency,
+		power:   make([]float64, n),
+		spacing: (trace.Frequency[n-1] - trace.Frequency[0]) / float64(n-1),
+	}
+	if s.spacing <= 0 {
+		return s, fmt.Errorf("frequencies aren't increasing")
+	}
+	values, err := units.ConvertSlice(trace.Values, cfg.units, units.DBm, cfg.impedance)
+	if err != nil {
+		return s, err
+	}
+	scale := 1.0
+	if cfg.rbw > 0 {
+		scale = s.spacing / (cfg.rbw * cfg.nbwFactor)
+	}
+	for i, v := range values {
+		s.power[i] = math.Pow(10, v/10) * scale
+	}
+	return s, nil
+}
+
+// band returns the total power in mW of the points within the band.
+func (s spectrum) band(center, bw float64) (float64, error) {
+	lo, hi := center-bw/2, center+bw/2
+	eps := 1e-6 * s.spacing
+	if bw <= 0 {
+		return 0, fmt.Errorf("invalid bandwidth: %g", bw)
+	}
+	if lo < s.freqs[0]-eps || hi > s.freqs[len(s.freqs)-1]+eps {
+		return 0, fmt.Errorf("band %g to %g Hz is outside the trace", lo, hi)
+	}
+	total := 0.0
+	for i, f := range s.freqs {
+		if f >= lo-eps && f <= hi+eps {
+			total += s.power[i]
+		}
+	}
+	return total, nil
+}
+
+// ChannelPower returns the power within the integration bandwidth centered
+// at the given frequency.
+func ChannelPower(trace tracemath.Trace, centerFreq, integBW float64, opts ...PowerOption) (ChannelPowerResult, error) {
+	s, err := newSpectrum(trace, newPowerConfig(opts))
+	if err != nil {
+		return ChannelPowerResult{}, err
+	}
+	power, err := s.band(centerFreq, integBW)
+	if err != nil {
+		return ChannelPowerResult{}, err
+	}
+	return ChannelPowerResult{
+		Power:   toDBm(power),
+		Density: toDBm(power / integBW),
+	}, nil
+}
+
+// OccupiedBandwidth returns the bandwidth containing the given percentage of
+// the total power of the trace, such as 99. The power of each trace point is
+// taken as spread evenly over the point spacing.
+func OccupiedBandwidth(trace tracemath.Trace, percent float64, opts ...PowerOption) (OBWResult, error) {
+	if percent <= 0 || percent >= 100 {
+		return OBWResult{}, fmt.Errorf("invalid percent: %g", percent)
+	}
+	s, err := newSpectrum(trace, newPowerConfig(opts))
+	if err != nil {
+		return OBWResult{}, err
+	}
+	total := 0.0
+	for _, p := range s.power {
+		total += p
+	}
+	if total <= 0 {
+		return OBWResult{}, fmt.Errorf("trace doesn't contain any power")
+	}
+	frac := percent / 100
+	lower := s.crossing(total * (1 - frac) / 2)
+	upper := s.crossing(total * (1 + frac) / 2)
+	return OBWResult{
+		Bandwidth:  upper - lower,
+		LowerFreq:  lower,
+		UpperFreq:  upper,
+		TotalPower: toDBm(total),
+	}, nil
+}
+
+// crossing returns the frequency at which the cumulative power reaches the
+// target.
+func (s spectrum) crossing(target float64) float64 {
+	cum := 0.0
+	for i, p := range s.power {
+		if p > 0 && cum+p >= target {
+			return s.freqs[i] - s.spacing/2 + (target-cum)/p*s.spacing
+		}
+		cum += p
+	}
+	return s.freqs[len(s.freqs)-1] + s.spacing/2
+}
+
+// ACPR returns the power of the carrier channel and of the adjacent channels
+// on both sides of the carrier at each offset.
+func ACPR(trace tracemath.Trace, centerFreq, carrierBW float64, offsets []ACPROffset, opts ...PowerOption) (ACPRResult, error) {
+	s, err := newSpectrum(trace, newPowerConfig(opts))
+	if err != nil {
+		return ACPRResult{}, err
+	}
+	carrier, err := s.band(centerFreq, carrierBW)
+	if err != nil {
+		return ACPRResult{}, fmt.Errorf("error measuring carrier: %s", err)
+	}
+	result := ACPRResult{CarrierPower: toDBm(carrier)}
+	for _, offset := range offsets {
+		lower, err := s.band(centerFreq-offset.Offset, offset.IntegBW)
+		if err != nil {
+			return result, fmt.Errorf("error measuring lower channel at offset %g Hz: %s", offset.Offset, err)
+		}
+		upper, err := s.band(centerFreq+offset.Offset, offset.IntegBW)
+		if err != nil {
+			return result, fmt.Errorf("error measuring upper channel at offset %g Hz: %s", offset.Offset, err)
+		}
+		result.Offsets = append(result.Offsets, ACPROffsetResult{
+			Offset:     offset.Offset,
+			LowerPower: toDBm(lower),
+			UpperPower: toDBm(upper),
+			LowerRatio: toDBm(lower) - result.CarrierPower,
+			UpperRatio: toDBm(upper) - result.CarrierPower,
+		})
+	}
+	return result, nil
+}
+
+func toDBm(mw float64) float64 {
+	if mw <= 0 {
+		return math.Inf(-1)
+	}
+	return 10 * math.Log10(mw)
+}
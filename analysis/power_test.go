@@ -0,0 +1,113 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/tracemath"
+	"github.com/gotmc/keysight/units"
+)
+
+// bandTrace returns a trace from 0 to 100 kHz with 1 kHz spacing that is at
+// the signal level from 40 to 60 kHz and at the noise level elsewhere.
+func bandTrace(signal, noise float64) tracemath.Trace {
+	trace := tracemath.Trace{}
+	for i := 0; i <= 100; i++ {
+		trace.Frequency = append(trace.Frequency, float64(i)*1e3)
+		v := noise
+		if i >= 40 && i <= 60 {
+			v = signal
+		}
+		trace.Values = append(trace.Values, v)
+	}
+	return trace
+}
+
+func TestChannelPower(t *testing.T) {
+	trace := bandTrace(-60, -100)
+	want := -60 + 10*math.Log10(21)
+	got, err := ChannelPower(trace, 50e3, 20e3)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "power", got.Power, want, 1e-9)
+	assertFloat64(t, "density", got.Density, want-10*math.Log10(20e3), 1e-9)
+
+	got, err = ChannelPower(trace, 50e3, 20e3, WithRBW(2e3), WithNoiseBandwidthFactor(1))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "rbw corrected power", got.Power, want-10*math.Log10(2), 1e-9)
+	got, _ = ChannelPower(trace, 50e3, 20e3, WithRBW(1e3))
+	assertFloat64(t, "nbw corrected power", got.Power, want-10*math.Log10(DefaultNoiseBandwidthFactor), 1e-9)
+
+	if _, err := ChannelPower(trace, 95e3, 20e3); err == nil {
+		t.Errorf("expected error for channel outside the trace")
+	}
+	if _, err := ChannelPower(trace, 50e3, 0); err == nil {
+		t.Errorf("expected error for zero bandwidth")
+	}
+}
+
+func TestChannelPowerUnits(t *testing.T) {
+	// 0 dBm is 106.99 dBuV at 50 ohms.
+	offset := 10*math.Log10(50e-3) + 120
+	trace := bandTrace(-60+offset, -100+offset)
+	want := -60 + 10*math.Log10(21)
+	got, err := ChannelPower(trace, 50e3, 20e3, WithUnits(units.DBuV, 50))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "power", got.Power, want, 1e-9)
+	acpr, err := ACPR(trace, 50e3, 20e3, []ACPROffset{{Offset: 30e3, IntegBW: 10e3}}, WithUnits(units.DBuV, 50))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "carrier power", acpr.CarrierPower, want, 1e-9)
+
+	if _, err := ChannelPower(trace, 50e3, 20e3, WithUnits("furlongs", 50)); err == nil {
+		t.Errorf("expected error for unknown units")
+	}
+}
+
+func TestOccupiedBandwidth(t *testing.T) {
+	trace := bandTrace(-60, -200)
+	got, err := OccupiedBandwidth(trace, 99)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "bandwidth", got.Bandwidth, 20.79e3, 1e-3)
+	assertFloat64(t, "lower freq", got.LowerFreq, 39.605e3, 1e-3)
+	assertFloat64(t, "upper freq", got.UpperFreq, 60.395e3, 1e-3)
+	assertFloat64(t, "total power", got.TotalPower, -60+10*math.Log10(21), 1e-9)
+
+	if _, err := OccupiedBandwidth(trace, 100); err == nil {
+		t.Errorf("expected error for invalid percent")
+	}
+}
+
+func TestACPR(t *testing.T) {
+	trace := bandTrace(-60, -100)
+	offsets := []ACPROffset{{Offset: 20e3, IntegBW: 10e3}, {Offset: 40e3, IntegBW: 10e3}}
+	got, err := ACPR(trace, 50e3, 20e3, offsets)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	carrier := -60 + 10*math.Log10(21)
+	adjacent := -100 + 10*math.Log10(11)
+	assertFloat64(t, "carrier", got.CarrierPower, carrier, 1e-9)
+	assert(t, "num offsets", len(got.Offsets), 2)
+	assertFloat64(t, "lower power", got.Offsets[0].LowerPower, adjacent, 1e-9)
+	assertFloat64(t, "upper ratio", got.Offsets[0].UpperRatio, adjacent-carrier, 1e-9)
+	assertFloat64(t, "lower ratio 2", got.Offsets[1].LowerRatio, adjacent-carrier, 1e-9)
+
+	offsets = append(offsets, ACPROffset{Offset: 60e3, IntegBW: 10e3})
+	if _, err := ACPR(trace, 50e3, 20e3, offsets); err == nil {
+		t.Errorf("expected error for offset outside the trace")
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package arb has the ability to read and write the arbitrary waveform (.arb)
+// and waveform sequence (.seq) files used by the Keysight 33500 and 33600
+// series Trueform waveform generators.
+package arb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MaxDAC is the largest magnitude of a sample, which corresponds to the high
+// or low level.
+const MaxDAC = 32767
+
+// DefaultFileFormat is the file format version written when none is given.
+const DefaultFileFormat = "1.10"
+
+// maxChannels limits the channel count in the header, which is much larger
+// than the number of channels of any generator, so that a corrupt count
+// can't allocate gigabytes.
+const maxChannels = 64
+
+// Filter is the output filter used when playing the waveform.
+type Filter string
+
+// Available filters.
+const (
+	FilterNormal Filter = "normal"
+	FilterStep   Filter = "step"
+	FilterOff    Filter = "off"
+)
+
+// Waveform is an arbitrary waveform. Samples contains one slice of int16
+// samples per channel, where -32767 corresponds to the LowLevel and 32767 to
+// the HighLevel.
+type Waveform struct {
+	FileFormat  string
+	Checksum    int
+	SampleRate  float64
+	HighLevel   float64
+	LowLevel    float64
+	MarkerPoint int
+	DataType    string
+	Filter      Filter
+	Samples     [][]int16
+	// Extra contains the header lines that aren't otherwise parsed keyed by
+	// label.
+	Extra map[string]string
+}
+
+// NewWaveform creates a single channel waveform from the given values in
+// volts. The high and low levels are set to the maximum and minimum values.
+func NewWaveform(values []float64, sampleRate float64) Waveform {
+	wfm := Waveform{
+		FileFormat:  DefaultFileFormat,
+		SampleRate:  sampleRate,
+		MarkerPoint: 1,
+		DataType:    "short",
+		Filter:      FilterNormal,
+	}
+	if len(values) == 0 {
+		return wfm
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	if lo == hi {
+		// A constant waveform still needs a non-zero amplitude.
+		lo, hi = lo-0.5, hi+0.5
+	}
+	wfm.LowLevel, wfm.HighLevel = lo, hi
+	samples := make([]int16, len(values))
+	for i, v := range values {
+		samples[i] = int16(math.Round((2*(v-lo)/(hi-lo) - 1) * MaxDAC))
+	}
+	wfm.Samples = [][]int16{samples}
+	return wfm
+}
+
+// ChannelCount returns the number of channels in the waveform.
+func (wfm Waveform) ChannelCount() int {
+	return len(wfm.Samples)
+}
+
+// NumPoints returns the number of samples per channel.
+func (wfm Waveform) NumPoints() int {
+	if len(wfm.Samples) == 0 {
+		return 0
+	}
+	return len(wfm.Samples[0])
+}
+
+// Values returns the samples of the given zero-based channel in volts.
+func (wfm Waveform) Values(channel int) []float64 {
+	if channel < 0 || channel >= len(wfm.Samples) {
+		return nil
+	}
+	values := make([]float64, len(wfm.Samples[channel]))
+	for i, s := range wfm.Samples[channel] {
+		values[i] = wfm.LowLevel + (float64(s)+MaxDAC)/(2*MaxDAC)*(wfm.HighLevel-wfm.LowLevel)
+	}
+	return values
+}
+
+// ReadFile reads the .arb file with the given filename.
+func ReadFile(filename string) (Waveform, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Waveform{}, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads an .arb file from the io.Reader. The header consists of
+// "Label:value" lines up to the "Data:" line, which is followed by one line
+// per sample. Each sample line contains one value per channel.
+func Read(r io.Reader) (Waveform, error) {
+	wfm := Waveform{Extra: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	channels, numPoints := 1, -1
+	inData := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if inData {
+			fields := strings.FieldsFunc(line, func(r rune) bool {
+				return r == ',' || r == '\t' || r == ' '
+			})
+			if len(fields) != channels {
+				return wfm, fmt.Errorf("expected %d values in line %d / got %d", channels, lineNum, len(fields))
+			}
+			for i, field := range fields {
+				v, err := strconv.ParseInt(field, 10, 16)
+				if err != nil {
+					return wfm, fmt.Errorf("error parsing sample in line %d: %s", lineNum, err)
+				}
+				wfm.Samples[i] = append(wfm.Samples[i], int16(v))
+			}
+			continue
+		}
+		label, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return wfm, fmt.Errorf("invalid header line %d: %s", lineNum, line)
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		var err error
+		switch strings.ToLower(strings.TrimSpace(label)) {
+		case "file format":
+			wfm.FileFormat = value
+		case "checksum":
+			wfm.Checksum, err = strconv.Atoi(value)
+		case "channel count":
+			channels, err = strconv.Atoi(value)
+			if err == nil && (channels < 1 || channels > maxChannels) {
+				err = fmt.Errorf("invalid channel count: %d", channels)
+			}
+		case "sample rate":
+			wfm.SampleRate, err = strconv.ParseFloat(value, 64)
+		case "high level":
+			wfm.HighLevel, err = strconv.ParseFloat(value, 64)
+		case "low level":
+			wfm.LowLevel, err = strconv.ParseFloat(value, 64)
+		case "marker point":
+			wfm.MarkerPoint, err = strconv.Atoi(value)
+		case "data type":
+			wfm.DataType = value
+		case "filter":
+			wfm.Filter = Filter(strings.ToLower(value))
+		case "data points":
+			numPoints, err = strconv.Atoi(value)
+		case "data":
+			inData = true
+			wfm.Samples = make([][]int16, channels)
+		default:
+			wfm.Extra[strings.TrimSpace(label)] = value
+		}
+		if err != nil {
+			return wfm, fmt.Errorf("error parsing %s in line %d: %s", label, lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return wfm, err
+	}
+	if !inData {
+		return wfm, fmt.Errorf("missing data")
+	}
+	if numPoints >= 0 && wfm.NumPoints() != numPoints {
+		return wfm, fmt.Errorf("expected %d data points / got %d", numPoints, wfm.NumPoints())
+	}
+	return wfm, nil
+}
+
+// WriteFile writes the waveform to an .arb file with the given filename.
+func (wfm Waveform) WriteFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := wfm.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write writes the waveform in the .arb file format to the io.Writer.
+func (wfm Waveform) Write(w io.Writer) error {
+	if len(wfm.Samples) == 0 {
+		return fmt.Errorf("waveform doesn't contain any channels")
+	}
+	n := wfm.NumPoints()
+	for i, samples := range wfm.Samples {
+		if len(samples) != n {
+			return fmt.Errorf("channel %d has %d points / expected %d", i+1, len(samples), n)
+		}
+	}
+	fileFormat := wfm.FileFormat
+	if fileFormat == "" {
+		fileFormat = DefaultFileFormat
+	}
+	dataType := wfm.DataType
+	if dataType == "" {
+		dataType = "short"
+	}
+	filter := wfm.Filter
+	if filter == "" {
+		filter = FilterNormal
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "File Format:%s\r\n", fileFormat)
+	fmt.Fprintf(bw, "Checksum:%d\r\n", wfm.Checksum)
+	fmt.Fprintf(bw, "Channel Count:%d\r\n", len(wfm.Samples))
+	fmt.Fprintf(bw, "Sample Rate:%f\r\n", wfm.SampleRate)
+	fmt.Fprintf(bw, "High Level:%f\r\n", wfm.HighLevel)
+	fmt.Fprintf(bw, "Low Level:%f\r\n", wfm.LowLevel)
+	fmt.Fprintf(bw, "Marker Point:%d\r\n", wfm.MarkerPoint)
+	fmt.Fprintf(bw, "Data Type:\"%s\"\r\n", dataType)
+	fmt.Fprintf(bw, "Filter:\"%s\"\r\n", filter)
+	fmt.Fprintf(bw, "Data Points:%d\r\n", n)
+	fmt.Fprint(bw, "Data:\r\n")
+	values := make([]string, len(wfm.Samples))
+	for i := 0; i < n; i++ {
+		for ch := range wfm.Samples {
+			values[ch] = strconv.Itoa(int(wfm.Samples[ch][i]))
+		}
+		fmt.Fprintf(bw, "%s\r\n", strings.Join(values, ","))
+	}
+	return bw.Flush()
+}
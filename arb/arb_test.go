@@ -0,0 +1,149 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package arb
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadFile(t *testing.T) {
+	var tests = []struct {
+		filename    string
+		channels    int
+		numPoints   int
+		sampleRate  float64
+		highLevel   float64
+		lowLevel    float64
+		markerPoint int
+		filter      Filter
+		channel     int
+		index       int
+		sample      int16
+	}{
+		{"./testdata/sine8.arb", 1, 8, 1e6, 0.5, -0.5, 4, FilterStep, 0, 6, -32767},
+		{"./testdata/two_channel.arb", 2, 4, 250e6, 1, -1, 1, FilterNormal, 1, 2, -32767},
+	}
+	for _, test := range tests {
+		wfm, err := ReadFile(test.filename)
+		if err != nil {
+			t.Errorf("error reading %s: %s", test.filename, err)
+			continue
+		}
+		assert(t, "file format", wfm.FileFormat, "1.10")
+		assert(t, "channel count", wfm.ChannelCount(), test.channels)
+		assert(t, "num points", wfm.NumPoints(), test.numPoints)
+		assertFloat64(t, "sample rate", wfm.SampleRate, test.sampleRate, 1e-6)
+		assertFloat64(t, "high level", wfm.HighLevel, test.highLevel, 1e-9)
+		assertFloat64(t, "low level", wfm.LowLevel, test.lowLevel, 1e-9)
+		assert(t, "marker point", wfm.MarkerPoint, test.markerPoint)
+		assert(t, "data type", wfm.DataType, "short")
+		assert(t, "filter", wfm.Filter, test.filter)
+		assert(t, "sample", wfm.Samples[test.channel][test.index], test.sample)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, filename := range []string{"./testdata/sine8.arb", "./testdata/two_channel.arb"} {
+		want, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("error reading %s: %s", filename, err)
+		}
+		wfm, err := Read(bytes.NewReader(want))
+		if err != nil {
+			t.Fatalf("error parsing %s: %s", filename, err)
+		}
+		var buf bytes.Buffer
+		if err := wfm.Write(&buf); err != nil {
+			t.Fatalf("error writing %s: %s", filename, err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("round trip of %s doesn't match\ngot:\n%s\nwant:\n%s", filename, buf.Bytes(), want)
+		}
+	}
+}
+
+func TestNewWaveform(t *testing.T) {
+	values := []float64{0, 1, 2, 1, 0, -2}
+	wfm := NewWaveform(values, 1e3)
+	assertFloat64(t, "high level", wfm.HighLevel, 2, 1e-9)
+	assertFloat64(t, "low level", wfm.LowLevel, -2, 1e-9)
+	assert(t, "max sample", wfm.Samples[0][2], int16(MaxDAC))
+	assert(t, "min sample", wfm.Samples[0][5], int16(-MaxDAC))
+	for i, v := range wfm.Values(0) {
+		assertFloat64(t, "value", v, values[i], 1e-4)
+	}
+	if wfm.Values(1) != nil {
+		t.Errorf("expected nil values for missing channel")
+	}
+
+	filename := filepath.Join(t.TempDir(), "new.arb")
+	if err := wfm.WriteFile(filename); err != nil {
+		t.Fatalf("error writing file: %s", err)
+	}
+	got, err := ReadFile(filename)
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "num points", got.NumPoints(), len(values))
+	assert(t, "sample", got.Samples[0][1], wfm.Samples[0][1])
+	assert(t, "filter", got.Filter, FilterNormal)
+
+	constant := NewWaveform([]float64{1, 1}, 1e3)
+	assertFloat64(t, "constant value", constant.Values(0)[0], 1, 1e-4)
+}
+
+func TestErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing data", "File Format:1.10\n"},
+		{"invalid header", "File Format 1.10\nData:\n"},
+		{"invalid sample rate", "Sample Rate:fast\nData:\n"},
+		{"wrong number of points", "Data Points:2\nData:\n0\n"},
+		{"wrong number of channels", "Channel Count:2\nData:\n0\n"},
+		{"huge number of channels", "Channel Count:2000000000\nData:\n0\n"},
+		{"sample out of range", "Data:\n40000\n"},
+	}
+	for _, test := range tests {
+		if _, err := Read(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+	if err := (Waveform{}).Write(&bytes.Buffer{}); err == nil {
+		t.Errorf("expected error writing empty waveform")
+	}
+	mismatched := Waveform{Samples: [][]int16{{0, 1}, {0}}}
+	if err := mismatched.Write(&bytes.Buffer{}); err == nil {
+		t.Errorf("expected error writing mismatched channels")
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.arb")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Read(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package arb
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PlayControl is the advance mode of a sequence segment.
+type PlayControl string
+
+// Available play controls.
+const (
+	PlayOnce               PlayControl = "once"
+	PlayOnceWaitTrigger    PlayControl = "onceWaitTrig"
+	PlayRepeat             PlayControl = "repeat"
+	PlayRepeatInfinite     PlayControl = "repeatInf"
+	PlayRepeatUntilTrigger PlayControl = "repeatTilTrig"
+)
+
+// MarkerMode is the marker behavior of a sequence segment.
+type MarkerMode string
+
+// Available marker modes.
+const (
+	MarkerMaintain         MarkerMode = "maintain"
+	MarkerLowAtStart       MarkerMode = "lowAtStart"
+	MarkerHighAtStart      MarkerMode = "highAtStart"
+	MarkerHighAtStartGoLow MarkerMode = "highAtStartGoLow"
+)
+
+// seqHeader is the column header line of the sequence segments.
+const seqHeader = "arb name, repeat count, play control, marker mode, marker point"
+
+// Segment is a single segment of a sequence, which plays the named arbitrary
+// waveform, such as "INT:\BUILTIN\SINC.ARB".
+type Segment struct {
+	Name        string
+	RepeatCount int
+	PlayControl PlayControl
+	MarkerMode  MarkerMode
+	MarkerPoint int
+}
+
+// Sequence is a waveform sequence (.seq) file, which combines multiple
+// arbitrary waveform segments.
+type Sequence struct {
+	FileFormat   string
+	ChannelCount int
+	SampleRate   float64
+	HighLevel    float64
+	LowLevel     float64
+	Filter       Filter
+	Segments     []Segment
+	// Extra contains the header lines that aren't otherwise parsed keyed by
+	// label.
+	Extra map[string]string
+}
+
+// ReadSequenceFile reads the .seq file with the given filename.
+func ReadSequenceFile(filename string) (Sequence, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Sequence{}, err
+	}
+	defer file.Close()
+	return ReadSequence(file)
+}
+
+// ReadSequence reads a .seq file from the io.Reader. The header consists of
+// "Label:value" lines up to the "Header:" line, which is followed by one line
+// per segment.
+func ReadSequence(r io.Reader) (Sequence, error) {
+	seq := Sequence{ChannelCount: 1, Extra: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	inSegments := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if inSegments {
+			seg, err := parseSegment(line)
+			if err != nil {
+				return seq, fmt.Errorf("error parsing segment in line %d: %s", lineNum, err)
+			}
+			seq.Segments = append(seq.Segments, seg)
+			continue
+		}
+		label, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return seq, fmt.Errorf("invalid header line %d: %s", lineNum, line)
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		var err error
+		switch strings.ToLower(strings.TrimSpace(label)) {
+		case "file format":
+			seq.FileFormat = value
+		case "channel count":
+			seq.ChannelCount, err = strconv.Atoi(value)
+		case "sample rate":
+			seq.SampleRate, err = strconv.ParseFloat(value, 64)
+		case "high level":
+			seq.HighLevel, err = strconv.ParseFloat(value, 64)
+		case "low level":
+			seq.LowLevel, err = strconv.ParseFloat(value, 64)
+		case "filter":
+			seq.Filter = Filter(strings.ToLower(value))
+		case "header":
+			inSegments = true
+		default:
+			seq.Extra[strings.TrimSpace(label)] = value
+		}
+		if err != nil {
+			return seq, fmt.Errorf("error parsing %s in line %d: %s", label, lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return seq, err
+	}
+	if !inSegments {
+		return seq, fmt.Errorf("missing segments header")
+	}
+	return seq, nil
+}
+
+var playControls = map[string]PlayControl{
+	"once":          PlayOnce,
+	"oncewaittrig":  PlayOnceWaitTrigger,
+	"repeat":        PlayRepeat,
+	"repeatinf":     PlayRepeatInfinite,
+	"repeattiltrig": PlayRepeatUntilTrigger,
+}
+
+var markerModes = map[string]MarkerMode{
+	"maintain":         MarkerMaintain,
+	"lowatstart":       MarkerLowAtStart,
+	"highatstart":      MarkerHighAtStart,
+	"highatstartgolow": MarkerHighAtStartGoLow,
+}
+
+// parseSegment parses a segment line, such as
+// `"INT:\BUILTIN\SINC.ARB",0,"once","highAtStart",5`.
+func parseSegment(line string) (Segment, error) {
+	r := csv.NewReader(strings.NewReader(line))
+	r.TrimLeadingSpace = true
+	fields, err := r.Read()
+	if err != nil {
+		return Segment{}, err
+	}
+	if len(fields) != 5 {
+		return Segment{}, fmt.Errorf("expected 5 fields / got %d", len(fields))
+	}
+	seg := Segment{Name: fields[0]}
+	if seg.RepeatCount, err = strconv.Atoi(fields[1]); err != nil {
+		return seg, fmt.Errorf("error parsing repeat count: %s", err)
+	}
+	var ok bool
+	if seg.PlayControl, ok = playControls[strings.ToLower(fields[2])]; !ok {
+		return seg, fmt.Errorf("unknown play control: %s", fields[2])
+	}
+	if seg.MarkerMode, ok = markerModes[strings.ToLower(fields[3])]; !ok {
+		return seg, fmt.Errorf("unknown marker mode: %s", fields[3])
+	}
+	if seg.MarkerPoint, err = strconv.Atoi(fields[4]); err != nil {
+		return seg, fmt.Errorf("error parsing marker point: %s", err)
+	}
+	return seg, nil
+}
+
+// WriteFile writes the sequence to a .seq file with the given filename.
+func (seq Sequence) WriteFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := seq.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write writes the sequence in the .seq file format to the io.Writer.
+func (seq Sequence) Write(w io.Writer) error {
+	if len(seq.Segments) == 0 {
+		return fmt.Errorf("sequence doesn't contain any segments")
+	}
+	fileFormat := seq.FileFormat
+	if fileFormat == "" {
+		fileFormat = DefaultFileFormat
+	}
+	channels := seq.ChannelCount
+	if channels == 0 {
+		channels = 1
+	}
+	filter := seq.Filter
+	if filter == "" {
+		filter = FilterNormal
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "File Format:%s\r\n", fileFormat)
+	fmt.Fprintf(bw, "Channel Count:%d\r\n", channels)
+	fmt.Fprintf(bw, "Sample Rate:%f\r\n", seq.SampleRate)
+	fmt.Fprintf(bw, "High Level:%f\r\n", seq.HighLevel)
+	fmt.Fprintf(bw, "Low Level:%f\r\n", seq.LowLevel)
+	fmt.Fprintf(bw, "Filter:\"%s\"\r\n", filter)
+	fmt.Fprintf(bw, "Header:%s\r\n", seqHeader)
+	for i, seg := range seq.Segments {
+		if seg.Name == "" {
+			return fmt.Errorf("segment %d is missing the arb name", i+1)
+		}
+		play := seg.PlayControl
+		if play == "" {
+			play = PlayOnce
+		}
+		marker := seg.MarkerMode
+		if marker == "" {
+			marker = MarkerMaintain
+		}
+		fmt.Fprintf(bw, "\"%s\",%d,\"%s\",\"%s\",%d\r\n", seg.Name, seg.RepeatCount, play, marker, seg.MarkerPoint)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package arb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadSequenceFile(t *testing.T) {
+	seq, err := ReadSequenceFile("./testdata/burst.seq")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "file format", seq.FileFormat, "1.10")
+	assert(t, "channel count", seq.ChannelCount, 1)
+	assertFloat64(t, "sample rate", seq.SampleRate, 1e6, 1e-6)
+	assertFloat64(t, "high level", seq.HighLevel, 1, 1e-9)
+	assertFloat64(t, "low level", seq.LowLevel, -1, 1e-9)
+	assert(t, "filter", seq.Filter, FilterStep)
+	want := []Segment{
+		{`INT:\BUILTIN\SINC.ARB`, 0, PlayOnce, MarkerHighAtStart, 5},
+		{`USB:\WAVEFORMS\SINE8.ARB`, 10, PlayRepeat, MarkerMaintain, 4},
+		{`INT:\BUILTIN\HAVERSINE.ARB`, 0, PlayRepeatUntilTrigger, MarkerLowAtStart, 1},
+	}
+	assert(t, "num segments", len(seq.Segments), len(want))
+	for i, seg := range want {
+		assert(t, "segment", seq.Segments[i], seg)
+	}
+}
+
+func TestSequenceRoundTrip(t *testing.T) {
+	want, err := os.ReadFile("./testdata/burst.seq")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	seq, err := ReadSequence(bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("error parsing file: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := seq.Write(&buf); err != nil {
+		t.Fatalf("error writing sequence: %s", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("round trip doesn't match\ngot:\n%s\nwant:\n%s", buf.Bytes(), want)
+	}
+}
+
+func TestWriteSequenceFile(t *testing.T) {
+	seq := Sequence{
+		SampleRate: 10e6,
+		HighLevel:  0.5,
+		LowLevel:   -0.5,
+		Segments: []Segment{
+			{Name: `INT:\PULSE.ARB`, RepeatCount: 3, PlayControl: PlayRepeat},
+			{Name: `INT:\IDLE.ARB`, PlayControl: PlayRepeatInfinite, MarkerMode: MarkerHighAtStartGoLow},
+		},
+	}
+	filename := filepath.Join(t.TempDir(), "composed.seq")
+	if err := seq.WriteFile(filename); err != nil {
+		t.Fatalf("error writing file: %s", err)
+	}
+	got, err := ReadSequenceFile(filename)
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "file format", got.FileFormat, DefaultFileFormat)
+	assert(t, "filter", got.Filter, FilterNormal)
+	assert(t, "first segment", got.Segments[0], Segment{`INT:\PULSE.ARB`, 3, PlayRepeat, MarkerMaintain, 0})
+	assert(t, "second segment", got.Segments[1], Segment{`INT:\IDLE.ARB`, 0, PlayRepeatInfinite, MarkerHighAtStartGoLow, 0})
+}
+
+func TestSequenceErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing header", "File Format:1.10\n"},
+		{"invalid header line", "File Format 1.10\n"},
+		{"invalid repeat count", "Header:x\n\"A.ARB\",many,\"once\",\"maintain\",1\n"},
+		{"unknown play control", "Header:x\n\"A.ARB\",0,\"twice\",\"maintain\",1\n"},
+		{"unknown marker mode", "Header:x\n\"A.ARB\",0,\"once\",\"blink\",1\n"},
+		{"wrong number of fields", "Header:x\n\"A.ARB\",0,\"once\"\n"},
+	}
+	for _, test := range tests {
+		if _, err := ReadSequence(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+	if err := (Sequence{}).Write(&bytes.Buffer{}); err == nil {
+		t.Errorf("expected error writing empty sequence")
+	}
+	if err := (Sequence{Segments: []Segment{{}}}).Write(&bytes.Buffer{}); err == nil {
+		t.Errorf("expected error writing segment without name")
+	}
+}
+
+func FuzzReadSequence(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.seq")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadSequence(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package cal has the ability to parse the calibration kit definitions and
+// calibration error terms exported by the Keysight network analyzers, so
+// that calibrations can be applied to or verified against raw measurement
+// data.
+//
+// Calibration kits are read from the XML kit files exported by the PNA and
+// ENA, which use the .xkt extension. The binary kit files of older
+// firmware, which also use the .ckt extension, are undocumented and aren't
+// supported. Error terms are read from cal sets saved as CITIfiles, whose
+// data is named E[1] through E[12] in the order of the HP 8753.
+package cal
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"strings"
+)
+
+// StandardType is the type of a calibration standard, which is the name of
+// its element in the kit file without the Standard suffix, except for fixed
+// loads, whose type is Load.
+type StandardType string
+
+// Common standard types.
+const (
+	Open  StandardType = "Open"
+	Short StandardType = "Short"
+	Load  StandardType = "Load"
+	Thru  StandardType = "Thru"
+)
+
+// Offset is the transmission line between the reference plane and the
+// termination of a standard.
+type Offset struct {
+	// Delay is the one-way delay in seconds.
+	Delay float64
+	// Loss is in ohms per second at 1 GHz.
+	Loss float64
+	// Z0 is the characteristic impedance in ohms.
+	Z0 float64
+}
+
+// Standard is the definition of a calibration standard. All values are in
+// SI units.
+type Standard struct {
+	Number       int
+	Type         StandardType
+	Label        string
+	Description  string
+	MinFrequency float64
+	MaxFrequency float64
+	// C contains the coefficients of the fringing capacitance of an open,
+	// which is C[0] + C[1] f + C[2] f² + C[3] f³ in farads.
+	C [4]float64
+	// L contains the coefficients of the inductance of a short, which is
+	// L[0] + L[1] f + L[2] f² + L[3] f³ in henries.
+	L [4]float64
+	// Impedance is the terminal impedance of a load in ohms, which is zero
+	// for a load matched to the system impedance.
+	Impedance float64
+	Offset    Offset
+}
+
+// Connector is a connector of the standards of a calibration kit.
+type Connector struct {
+	Family       string
+	Gender       string
+	MinFrequency float64
+	MaxFrequency float64
+	// Z0 is the system impedance in ohms.
+	Z0 float64
+}
+
+// Kit is a calibration kit definition.
+type Kit struct {
+	Label       string
+	Description string
+	Version     string
+	Connectors  []Connector
+	Standards   []Standard
+}
+
+// Standard returns the standard with the given label, ignoring case.
+func (k Kit) Standard(label string) (Standard, bool) {
+	for _, s := range k.Standards {
+		if strings.EqualFold(s.Label, label) {
+			return s, true
+		}
+	}
+	return Standard{}, false
+}
+
+// element is an XML element whose type is its name, such as the standards
+// in the standard list.
+type element struct {
+	XMLName      xml.Name
+	Label        string    `xml:"Label"`
+	Description  string    `xml:"Description"`
+	Number       int       `xml:"StandardNumber"`
+	Family       string    `xml:"Family"`
+	Gender       string    `xml:"Gender"`
+	MinFrequency float64   `xml:"MinimumFrequencyHz"`
+	MaxFrequency float64   `xml:"MaximumFrequencyHz"`
+	SystemZ0     float64   `xml:"SystemZ0"`
+	C0           float64   `xml:"C0"`
+	C1           float64   `xml:"C1"`
+	C2           float64   `xml:"C2"`
+	C3           float64   `xml:"C3"`
+	L0           float64   `xml:"L0"`
+	L1           float64   `xml:"L1"`
+	L2           float64   `xml:"L2"`
+	L3           float64   `xml:"L3"`
+	Impedance    float64   `xml:"TerminalImpedance"`
+	Offset       xmlOffset `xml:"Offset"`
+}
+
+type xmlOffset struct {
+	Delay float64 `xml:"OffsetDelay"`
+	Loss  float64 `xml:"OffsetLoss"`
+	Z0    float64 `xml:"OffsetZ0"`
+}
+
+type xmlKit struct {
+	XMLName     xml.Name  `xml:"CalKit"`
+	Label       string    `xml:"CalKitLabel"`
+	Description string    `xml:"CalKitDescription"`
+	Version     string    `xml:"CalKitVersion"`
+	Connectors  []element `xml:"ConnectorList>Coaxial"`
+	Standards   struct {
+		Elements []element `xml:",any"`
+	} `xml:"StandardList"`
+}
+
+// ReadKitFile reads the calibration kit definition in the XML kit file with
+// the given filename.
+func ReadKitFile(filename string) (Kit, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Kit{}, err
+	}
+	defer file.Close()
+	return ReadKit(file)
+}
+
+// ReadKit reads an XML calibration kit definition from the io.Reader.
+func ReadKit(r io.Reader) (Kit, error) {
+	var x xmlKit
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return Kit{}, fmt.Errorf("error parsing cal kit: %s", err)
+	}
+	kit := Kit{Label: x.Label, Description: x.Description, Version: x.Version}
+	for _, c := range x.Connectors {
+		kit.Connectors = append(kit.Connectors, Connector{
+			Family:       c.Family,
+			Gender:       c.Gender,
+			MinFrequency: c.MinFrequency,
+			MaxFrequency: c.MaxFrequency,
+			Z0:           c.SystemZ0,
+		})
+	}
+	for _, e := range x.Standards.Elements {
+		name := strings.TrimSuffix(e.XMLName.Local, "Standard")
+		if name == "FixedLoad" {
+			name = string(Load)
+		}
+		kit.Standards = append(kit.Standards, Standard{
+			Number:       e.Number,
+			Type:         StandardType(name),
+			Label:        e.Label,
+			Description:  e.Description,
+			MinFrequency: e.MinFrequency,
+			MaxFrequency: e.MaxFrequency,
+			C:            [4]float64{e.C0, e.C1, e.C2, e.C3},
+			L:            [4]float64{e.L0, e.L1, e.L2, e.L3},
+			Impedance:    e.Impedance,
+			Offset:       Offset(e.Offset),
+		})
+	}
+	if len(kit.Standards) == 0 {
+		return kit, errors.New("cal kit has no standards")
+	}
+	return kit, nil
+}
+
+// Reflection returns the modeled reflection coefficient of the standard at
+// the given frequency in Hz, which must be positive, relative to the system
+// impedance z0 in ohms. The termination is transformed by the lossy offset
+// using the Keysight offset model, in which the loss and the change of the
+// characteristic impedance are proportional to the square root of the
+// frequency. A thru is modeled as its offset terminated by z0.
+func (s Standard) Reflection(freq, z0 float64) complex128 {
+	w := 2 * math.Pi * freq
+	var zt complex128
+	switch s.Type {
+	case Open:
+		c := s.C[0] + freq*(s.C[1]+freq*(s.C[2]+freq*s.C[3]))
+		zt = complex(0, -1/(w*c))
+		if c == 0 {
+			zt = cmplx.Inf()
+		}
+	case Short:
+		l := s.L[0] + freq*(s.L[1]+freq*(s.L[2]+freq*s.L[3]))
+		zt = complex(0, w*l)
+	default:
+		zt = complex(s.Impedance, 0)
+		if s.Impedance == 0 {
+			zt = complex(z0, 0)
+		}
+	}
+	zin := zt
+	if off := s.Offset; off.Delay != 0 && off.Z0 > 0 {
+		root := math.Sqrt(freq / 1e9)
+		alpha := off.Loss * off.Delay / (2 * off.Z0) * root
+		gl := complex(alpha, w*off.Delay+alpha)
+		zc := complex(off.Z0, 0) + complex(1, -1)*complex(off.Loss/(2*w)*root, 0)
+		t := cmplx.Tanh(gl)
+		if cmplx.IsInf(zt) {
+			zin = zc / t
+		} else {
+			zin = zc * (zt + zc*t) / (zc + zt*t)
+		}
+	}
+	if cmplx.IsInf(zin) {
+		return 1
+	}
+	return (zin - complex(z0, 0)) / (zin + complex(z0, 0))
+}
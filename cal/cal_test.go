@@ -0,0 +1,132 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package cal
+
+import (
+	"bytes"
+	"math"
+	"math/cmplx"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadKitFile(t *testing.T) {
+	kit, err := ReadKitFile("./testdata/85033e.xkt")
+	if err != nil {
+		t.Fatalf("received error reading cal kit: %s", err)
+	}
+	assert(t, "label", kit.Label, "85033D/E")
+	assert(t, "description", kit.Description, "3.5 mm Calibration Kit")
+	assert(t, "version", kit.Version, "1.0")
+	assert(t, "num connectors", len(kit.Connectors), 2)
+	assert(t, "gender", kit.Connectors[1].Gender, "Female")
+	assertFloat64(t, "system z0", kit.Connectors[0].Z0, 50, 1e-12)
+	assert(t, "num standards", len(kit.Standards), 5)
+	var tests = []struct {
+		label  string
+		number int
+		typ    StandardType
+	}{
+		{"OPEN -M-", 1, Open},
+		{"short -m-", 2, Short},
+		{"BROADBAND", 3, Load},
+		{"THRU", 4, Thru},
+		{"SLIDING", 5, StandardType("SlidingLoad")},
+	}
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			s, ok := kit.Standard(test.label)
+			if !ok {
+				t.Fatalf("missing standard %s", test.label)
+			}
+			assert(t, "number", s.Number, test.number)
+			assert(t, "type", s.Type, test.typ)
+		})
+	}
+	open, _ := kit.Standard("OPEN -M-")
+	assertFloat64(t, "c0", open.C[0], 49.433e-15, 1e-27)
+	assertFloat64(t, "c3", open.C[3], -0.15966e-45, 1e-57)
+	assertFloat64(t, "delay", open.Offset.Delay, 29.243e-12, 1e-24)
+	assertFloat64(t, "loss", open.Offset.Loss, 2.2e9, 1e-3)
+	sliding, _ := kit.Standard("SLIDING")
+	assertFloat64(t, "min freq", sliding.MinFrequency, 2e9, 1e-3)
+	if _, ok := kit.Standard("missing"); ok {
+		t.Errorf("found missing standard")
+	}
+}
+
+func TestReadKitErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"not xml", "not a cal kit"},
+		{"wrong root", "<Setup></Setup>"},
+		{"no standards", "<CalKit><CalKitLabel>X</CalKitLabel></CalKit>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadKit(strings.NewReader(test.data)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestReflection(t *testing.T) {
+	kit, err := ReadKitFile("./testdata/85033e.xkt")
+	if err != nil {
+		t.Fatalf("received error reading cal kit: %s", err)
+	}
+	open, _ := kit.Standard("OPEN -M-")
+	short, _ := kit.Standard("SHORT -M-")
+	load, _ := kit.Standard("BROADBAND")
+	for _, f := range []float64{1e9, 6e9} {
+		w := 2 * math.Pi * f
+		// The lossless phase of the terminations rotated by the offsets.
+		c := open.C[0] + f*(open.C[1]+f*(open.C[2]+f*open.C[3]))
+		g := open.Reflection(f, 50)
+		assertFloat64(t, "open mag", cmplx.Abs(g), 1, 0.005)
+		assertFloat64(t, "open phase", cmplx.Phase(g), math.Remainder(-2*w*open.Offset.Delay-2*math.Atan(w*c*50), 2*math.Pi), 0.01)
+
+		l := short.L[0] + f*(short.L[1]+f*(short.L[2]+f*short.L[3]))
+		g = short.Reflection(f, 50)
+		assertFloat64(t, "short mag", cmplx.Abs(g), 1, 0.005)
+		assertFloat64(t, "short phase", cmplx.Phase(g), math.Remainder(math.Pi-2*math.Atan(w*l/50)-2*w*short.Offset.Delay, 2*math.Pi), 0.01)
+
+		assertFloat64(t, "load", cmplx.Abs(load.Reflection(f, 50)), 0, 1e-12)
+		assertFloat64(t, "load 75", real(Standard{Type: Load, Impedance: 75}.Reflection(f, 50)), 0.2, 1e-12)
+	}
+	// An ideal open without an offset.
+	assertFloat64(t, "ideal open", real(Standard{Type: Open}.Reflection(1e9, 50)), 1, 1e-12)
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func assertComplex(t *testing.T, label string, got, want complex128, tolerance float64) {
+	if diff := cmplx.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %v \t\nwant %s = %v", label, got, label, want)
+	}
+}
+
+func FuzzReadKit(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.xkt")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadKit(bytes.NewReader(data))
+	})
+}
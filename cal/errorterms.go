@@ -0,0 +1,257 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package cal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/citifile"
+	"github.com/gotmc/keysight/rf"
+)
+
+// Term is a calibration error term.
+type Term int
+
+// Available error terms of the 12-term model in the order of the HP 8753
+// cal set arrays. A one-port calibration only has the first three, which
+// are the directivity, source match, and reflection tracking of the port.
+const (
+	EDF Term = iota // Forward directivity
+	ESF             // Forward source match
+	ERF             // Forward reflection tracking
+	EXF             // Forward isolation
+	ELF             // Forward load match
+	ETF             // Forward transmission tracking
+	EDR             // Reverse directivity
+	ESR             // Reverse source match
+	ERR             // Reverse reflection tracking
+	EXR             // Reverse isolation
+	ELR             // Reverse load match
+	ETR             // Reverse transmission tracking
+)
+
+var termNames = []string{"EDF", "ESF", "ERF", "EXF", "ELF", "ETF", "EDR", "ESR", "ERR", "EXR", "ELR", "ETR"}
+
+// String implements the Stringer interface for Term.
+func (t Term) String() string {
+	if t < EDF || t > ETR {
+		return fmt.Sprintf("Term(%d)", int(t))
+	}
+	return termNames[t]
+}
+
+// ErrorTerms contains the error terms of a calibration at each frequency.
+type ErrorTerms struct {
+	// Frequency is in Hz.
+	Frequency []float64
+	Terms     map[Term][]complex128
+}
+
+// IsTwoPort reports whether the error terms contain all 12 terms of a full
+// two-port calibration.
+func (e ErrorTerms) IsTwoPort() bool {
+	for t := EDF; t <= ETR; t++ {
+		if _, ok := e.Terms[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayName matches the error term arrays of a CITIfile cal set, such as
+// E[1].
+var arrayName = regexp.MustCompile(`(?i)^E\[(\d+)\]$`)
+
+// ReadErrorTermsFile reads the error terms of the first package of the
+// CITIfile cal set with the given filename that contains them.
+func ReadErrorTermsFile(filename string) (ErrorTerms, error) {
+	pkgs, err := citifile.ReadFile(filename)
+	if err != nil {
+		return ErrorTerms{}, err
+	}
+	for _, pkg := range pkgs {
+		if e, err := FromCITI(pkg); err == nil {
+			return e, nil
+		}
+	}
+	return ErrorTerms{}, errors.New("CITIfile contains no error terms")
+}
+
+// FromCITI returns the error terms of the CITIfile package, whose
+// frequencies are given by the FREQ variable. The terms are either the E[1]
+// through E[3] arrays of a one-port calibration, or the E[1] through E[12]
+// arrays of a two-port calibration, and data named after a Term is also
+// accepted.
+func FromCITI(p citifile.Package) (ErrorTerms, error) {
+	freq, ok := p.Var("FREQ")
+	if !ok {
+		return ErrorTerms{}, errors.New("missing FREQ variable")
+	}
+	e := ErrorTerms{Frequency: freq.Values, Terms: make(map[Term][]complex128)}
+	arrays := make(map[int][]complex128)
+	for _, name := range p.DataNames {
+		values := p.Data[name]
+		if m := arrayName.FindStringSubmatch(name); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			arrays[n] = values
+			continue
+		}
+		for t := EDF; t <= ETR; t++ {
+			if strings.EqualFold(name, t.String()) {
+				e.Terms[t] = values
+			}
+		}
+	}
+	switch {
+	case len(arrays) == 0:
+	case len(arrays) == 3 || len(arrays) == 12:
+		for n := 1; n <= len(arrays); n++ {
+			values, ok := arrays[n]
+			if !ok {
+				return ErrorTerms{}, fmt.Errorf("missing E[%d]", n)
+			}
+			e.Terms[Term(n-1)] = values
+		}
+	default:
+		return ErrorTerms{}, fmt.Errorf("wrong number of error term arrays / got %d / expected 3 or 12", len(arrays))
+	}
+	if len(e.Terms) == 0 {
+		return ErrorTerms{}, errors.New("package has no error terms")
+	}
+	for t, values := range e.Terms {
+		if len(values) != len(freq.Values) {
+			return ErrorTerms{}, fmt.Errorf("wrong number of %s values / got %d / expected %d", t, len(values), len(freq.Values))
+		}
+	}
+	return e, nil
+}
+
+// terms returns the values of the given terms indexed by Term, checking
+// that they're present.
+func (e ErrorTerms) terms(ts ...Term) ([][]complex128, error) {
+	values := make([][]complex128, ETR+1)
+	for _, t := range ts {
+		v, ok := e.Terms[t]
+		if !ok {
+			return nil, fmt.Errorf("missing error term %s", t)
+		}
+		if len(v) != len(e.Frequency) {
+			return nil, fmt.Errorf("wrong number of %s values / got %d / expected %d", t, len(v), len(e.Frequency))
+		}
+		values[t] = v
+	}
+	return values, nil
+}
+
+// CorrectOnePort returns the actual reflection coefficients of the raw
+// reflection measurements at each frequency using the forward one-port
+// error terms, which are (M − EDF) / (ERF + ESF (M − EDF)).
+func (e ErrorTerms) CorrectOnePort(raw []complex128) ([]complex128, error) {
+	v, err := e.terms(EDF, ESF, ERF)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != len(e.Frequency) {
+		return nil, fmt.Errorf("wrong number of measurements / got %d / expected %d", len(raw), len(e.Frequency))
+	}
+	actual := make([]complex128, len(raw))
+	for k, m := range raw {
+		d := m - v[EDF][k]
+		actual[k] = d / (v[ERF][k] + v[ESF][k]*d)
+	}
+	return actual, nil
+}
+
+// RawOnePort returns the raw reflection measurements that the forward
+// one-port error terms produce for the actual reflection coefficients at
+// each frequency, which are EDF + ERF Γ / (1 − ESF Γ). Comparing them to the
+// measurements of standards modeled by Standard.Reflection verifies the
+// calibration.
+func (e ErrorTerms) RawOnePort(actual []complex128) ([]complex128, error) {
+	v, err := e.terms(EDF, ESF, ERF)
+	if err != nil {
+		return nil, err
+	}
+	if len(actual) != len(e.Frequency) {
+		return nil, fmt.Errorf("wrong number of values / got %d / expected %d", len(actual), len(e.Frequency))
+	}
+	raw := make([]complex128, len(actual))
+	for k, g := range actual {
+		raw[k] = v[EDF][k] + v[ERF][k]*g/(1-v[ESF][k]*g)
+	}
+	return raw, nil
+}
+
+// Correct returns the actual S-parameters of the raw two-port measurement
+// using the 12-term error model. The measurement must have the frequencies
+// of the error terms.
+func (e ErrorTerms) Correct(raw rf.Network) (rf.Network, error) {
+	v, err := e.twoPort(raw)
+	if err != nil {
+		return rf.Network{}, err
+	}
+	actual := rf.Network{Frequency: raw.Frequency, Z0: raw.Z0, S: make([]rf.Matrix, len(raw.S))}
+	for k, m := range raw.S {
+		a := (m[0][0] - v[EDF][k]) / v[ERF][k]
+		b := (m[1][0] - v[EXF][k]) / v[ETF][k]
+		c := (m[0][1] - v[EXR][k]) / v[ETR][k]
+		d := (m[1][1] - v[EDR][k]) / v[ERR][k]
+		esf, elf, esr, elr := v[ESF][k], v[ELF][k], v[ESR][k], v[ELR][k]
+		den := (1+a*esf)*(1+d*esr) - b*c*elf*elr
+		actual.S[k] = rf.Matrix{
+			{(a*(1+d*esr) - elf*b*c) / den, c * (1 + a*(esf-elr)) / den},
+			{b * (1 + d*(esr-elf)) / den, (d*(1+a*esf) - elr*b*c) / den},
+		}
+	}
+	return actual, nil
+}
+
+// Raw returns the raw two-port measurement that the 12-term error model
+// produces for the actual S-parameters, which is the inverse of Correct.
+func (e ErrorTerms) Raw(actual rf.Network) (rf.Network, error) {
+	v, err := e.twoPort(actual)
+	if err != nil {
+		return rf.Network{}, err
+	}
+	raw := rf.Network{Frequency: actual.Frequency, Z0: actual.Z0, S: make([]rf.Matrix, len(actual.S))}
+	for k, s := range actual.S {
+		s11, s12, s21, s22 := s[0][0], s[0][1], s[1][0], s[1][1]
+		det := s11*s22 - s21*s12
+		fwd := 1 - v[ESF][k]*s11 - v[ELF][k]*s22 + v[ESF][k]*v[ELF][k]*det
+		rev := 1 - v[ESR][k]*s22 - v[ELR][k]*s11 + v[ESR][k]*v[ELR][k]*det
+		raw.S[k] = rf.Matrix{
+			{v[EDF][k] + v[ERF][k]*(s11-v[ELF][k]*det)/fwd, v[EXR][k] + v[ETR][k]*s12/rev},
+			{v[EXF][k] + v[ETF][k]*s21/fwd, v[EDR][k] + v[ERR][k]*(s22-v[ELR][k]*det)/rev},
+		}
+	}
+	return raw, nil
+}
+
+// twoPort returns the 12 error terms indexed by Term, checking that the
+// network is a two-port with the frequencies of the error terms.
+func (e ErrorTerms) twoPort(n rf.Network) ([][]complex128, error) {
+	v, err := e.terms(EDF, ESF, ERF, EXF, ELF, ETF, EDR, ESR, ERR, EXR, ELR, ETR)
+	if err != nil {
+		return nil, err
+	}
+	if n.Ports() != 2 {
+		return nil, fmt.Errorf("wrong number of ports / got %d / expected 2", n.Ports())
+	}
+	if len(n.S) != len(e.Frequency) || len(n.Frequency) != len(e.Frequency) {
+		return nil, fmt.Errorf("wrong number of frequencies / got %d / expected %d", len(n.S), len(e.Frequency))
+	}
+	for k, f := range n.Frequency {
+		if math.Abs(f-e.Frequency[k]) > 1e-9*math.Abs(f) {
+			return nil, fmt.Errorf("mismatched frequencies / got %g / expected %g", f, e.Frequency[k])
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package cal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gotmc/keysight/citifile"
+	"github.com/gotmc/keysight/internal/fuzztest"
+	"github.com/gotmc/keysight/rf"
+)
+
+func TestReadErrorTermsFile(t *testing.T) {
+	e, err := ReadErrorTermsFile("./testdata/n5230c_cal_set.cti")
+	if err != nil {
+		t.Fatalf("received error reading error terms: %s", err)
+	}
+	assert(t, "two-port", e.IsTwoPort(), true)
+	assert(t, "num freqs", len(e.Frequency), 2)
+	assertFloat64(t, "freq", e.Frequency[1], 2e9, 1e-3)
+	assertComplex(t, "erf", e.Terms[ERF][1], complex(0.9, -0.2), 1e-12)
+	assertComplex(t, "etr", e.Terms[ETR][0], complex(0.97*1.1, -0.12*0.9), 1e-12)
+	assert(t, "term name", ELR.String(), "ELR")
+	assert(t, "unknown term", Term(12).String(), "Term(12)")
+
+	if _, err := ReadErrorTermsFile("../citifile/testdata/n5230c_two_port.cti"); err == nil {
+		t.Errorf("expected error for a file without error terms")
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	e, err := ReadErrorTermsFile("./testdata/n5230c_cal_set.cti")
+	if err != nil {
+		t.Fatalf("received error reading error terms: %s", err)
+	}
+	dut := rf.Network{
+		Frequency: e.Frequency,
+		Z0:        50,
+		S: []rf.Matrix{
+			{{0.1 + 0.05i, 0.7 - 0.3i}, {0.7 - 0.3i, -0.2 + 0.1i}},
+			{{-0.15 + 0.2i, 0.2 + 0.6i}, {0.25 + 0.55i, 0.05 - 0.3i}},
+		},
+	}
+	raw, err := e.Raw(dut)
+	if err != nil {
+		t.Fatalf("received error computing raw measurement: %s", err)
+	}
+	// A reflectionless thru measures the tracking and isolation.
+	thru := rf.Network{Frequency: e.Frequency, Z0: 50, S: []rf.Matrix{{{0, 1}, {1, 0}}, {{0, 1}, {1, 0}}}}
+	rawThru, err := e.Raw(thru)
+	if err != nil {
+		t.Fatalf("received error computing raw thru: %s", err)
+	}
+	want := e.Terms[EXF][0] + e.Terms[ETF][0]/(1-e.Terms[ESF][0]*e.Terms[ELF][0])
+	assertComplex(t, "thru s21", rawThru.S[0][1][0], want, 1e-12)
+
+	got, err := e.Correct(raw)
+	if err != nil {
+		t.Fatalf("received error correcting: %s", err)
+	}
+	for k := range dut.S {
+		for i := 0; i < 2; i++ {
+			for j := 0; j < 2; j++ {
+				assertComplex(t, "s", got.S[k][i][j], dut.S[k][i][j], 1e-12)
+			}
+		}
+	}
+
+	other := dut
+	other.Frequency = []float64{1e9, 3e9}
+	if _, err := e.Correct(other); err == nil {
+		t.Errorf("expected error for mismatched frequencies")
+	}
+}
+
+func TestCorrectOnePort(t *testing.T) {
+	kit, err := ReadKitFile("./testdata/85033e.xkt")
+	if err != nil {
+		t.Fatalf("received error reading cal kit: %s", err)
+	}
+	short, _ := kit.Standard("SHORT -M-")
+	pkg := citifile.Package{
+		Vars:      []citifile.Var{{Name: "FREQ", Values: []float64{1e9, 2e9}}},
+		DataNames: []string{"E[1]", "E[2]", "E[3]"},
+		Data: map[string][]complex128{
+			"E[1]": {0.02 - 0.01i, 0.03 + 0.015i},
+			"E[2]": {0.05 + 0.02i, -0.04 + 0.06i},
+			"E[3]": {0.95 - 0.1i, 0.9 - 0.2i},
+		},
+	}
+	e, err := FromCITI(pkg)
+	if err != nil {
+		t.Fatalf("received error converting error terms: %s", err)
+	}
+	assert(t, "two-port", e.IsTwoPort(), false)
+	model := []complex128{short.Reflection(1e9, 50), short.Reflection(2e9, 50)}
+	raw, err := e.RawOnePort(model)
+	if err != nil {
+		t.Fatalf("received error computing raw measurement: %s", err)
+	}
+	got, err := e.CorrectOnePort(raw)
+	if err != nil {
+		t.Fatalf("received error correcting: %s", err)
+	}
+	for k := range model {
+		assertComplex(t, "short", got[k], model[k], 1e-12)
+	}
+	if _, err := e.Correct(rf.Network{}); err == nil {
+		t.Errorf("expected error for two-port correction with one-port terms")
+	}
+	if _, err := e.CorrectOnePort(raw[:1]); err == nil {
+		t.Errorf("expected error for wrong number of measurements")
+	}
+
+	pkg.DataNames = append(pkg.DataNames, "E[4]")
+	pkg.Data["E[4]"] = []complex128{0, 0}
+	if _, err := FromCITI(pkg); err == nil {
+		t.Errorf("expected error for 4 error term arrays")
+	}
+}
+
+func FuzzFromCITI(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.cti")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		packages, err := citifile.Read(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		for _, p := range packages {
+			FromCITI(p)
+		}
+	})
+}
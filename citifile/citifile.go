@@ -0,0 +1,300 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package citifile has the ability to parse Common Instrumentation Transfer
+// and Interchange files (CITIfiles), such as the .cti files saved by Keysight
+// PNA and ENA network analyzers.
+package citifile
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Var is an independent variable of a CITIfile package, such as the
+// frequency.
+type Var struct {
+	Name   string
+	Format string
+	Values []float64
+}
+
+// Package is a single CITIfile package, which starts with the CITIFILE
+// keyword. The data arrays are keyed by name, such as "S[2,1]". Data arrays
+// stored in a real-valued format, such as MAG, are returned as complex values
+// with a zero imaginary part.
+type Package struct {
+	Version   string
+	Name      string
+	Vars      []Var
+	DataNames []string
+	Formats   map[string]string
+	Data      map[string][]complex128
+	Constants map[string]string
+	// Device contains the device-specific keyword lines, such as
+	// "#NA VERSION HP8510B.05.00", without the leading hash mark.
+	Device   []string
+	Comments []string
+}
+
+// MarshalJSON implements the json.Marshaler interface. JSON doesn't have
+// complex numbers, so each data value is encoded as a [real, imaginary]
+// pair.
+func (p Package) MarshalJSON() ([]byte, error) {
+	type pkg Package
+	v := struct {
+		pkg
+		Data map[string][][2]float64
+	}{pkg: pkg(p), Data: make(map[string][][2]float64, len(p.Data))}
+	for name, values := range p.Data {
+		pairs := make([][2]float64, len(values))
+		for i, c := range values {
+			pairs[i] = [2]float64{real(c), imag(c)}
+		}
+		v.Data[name] = pairs
+	}
+	return json.Marshal(v)
+}
+
+// Var returns the independent variable with the given name, such as "FREQ".
+func (p Package) Var(name string) (Var, bool) {
+	for _, v := range p.Vars {
+		if strings.EqualFold(v.Name, name) {
+			return v, true
+		}
+	}
+	return Var{}, false
+}
+
+// ReadFile reads the packages in the CITIfile with the given filename.
+func ReadFile(filename string) ([]Package, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads the packages of a CITIfile from the io.Reader.
+func Read(r io.Reader) ([]Package, error) {
+	var pkgs []Package
+	var pkg *Package
+	var numVarLists int
+	var block []string
+	var listValues []float64
+	dataIndex := 0
+	state := ""
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		keyword, rest, _ := strings.Cut(line, " ")
+		keyword = strings.ToUpper(keyword)
+		rest = strings.TrimSpace(rest)
+		if keyword == "CITIFILE" {
+			if state != "" {
+				return pkgs, fmt.Errorf("unterminated block before line %d", lineNum)
+			}
+			if pkg != nil {
+				pkgs = append(pkgs, *pkg)
+			}
+			pkg = &Package{
+				Version:   rest,
+				Formats:   make(map[string]string),
+				Data:      make(map[string][]complex128),
+				Constants: make(map[string]string),
+			}
+			numVarLists = 0
+			dataIndex = 0
+			continue
+		}
+		if pkg == nil {
+			return pkgs, fmt.Errorf("missing CITIFILE keyword before line %d", lineNum)
+		}
+		if strings.HasPrefix(line, "!") {
+			pkg.Comments = append(pkg.Comments, strings.TrimSpace(line[1:]))
+			continue
+		}
+		switch state {
+		case "BEGIN":
+			if keyword == "END" {
+				if err := pkg.addData(dataIndex, block); err != nil {
+					return pkgs, fmt.Errorf("error in data block ending on line %d: %s", lineNum, err)
+				}
+				dataIndex++
+				block = nil
+				state = ""
+				continue
+			}
+			block = append(block, line)
+			continue
+		case "VAR_LIST_BEGIN":
+			if keyword == "VAR_LIST_END" {
+				if err := pkg.setVarValues(numVarLists, listValues); err != nil {
+					return pkgs, err
+				}
+				numVarLists++
+				listValues = nil
+				state = ""
+				continue
+			}
+			v, err := strconv.ParseFloat(line, 64)
+			if err != nil {
+				return pkgs, fmt.Errorf("error parsing var value in line %d: %s", lineNum, err)
+			}
+			listValues = append(listValues, v)
+			continue
+		case "SEG_LIST_BEGIN":
+			if keyword == "SEG_LIST_END" {
+				if err := pkg.setVarValues(numVarLists, listValues); err != nil {
+					return pkgs, err
+				}
+				numVarLists++
+				listValues = nil
+				state = ""
+				continue
+			}
+			values, err := parseSegment(line)
+			if err != nil {
+				return pkgs, fmt.Errorf("error parsing segment in line %d: %s", lineNum, err)
+			}
+			if len(listValues)+len(values) > maxSegmentPoints {
+				return pkgs, fmt.Errorf("more than %d points in segment list in line %d", maxSegmentPoints, lineNum)
+			}
+			listValues = append(listValues, values...)
+			continue
+		}
+		switch {
+		case strings.HasPrefix(keyword, "#"):
+			pkg.Device = append(pkg.Device, strings.TrimSpace(line[1:]))
+		case keyword == "COMMENT":
+			pkg.Comments = append(pkg.Comments, rest)
+		case keyword == "NAME":
+			pkg.Name = rest
+		case keyword == "VAR":
+			fields := strings.Fields(rest)
+			if len(fields) < 2 {
+				return pkgs, fmt.Errorf("invalid VAR keyword in line %d", lineNum)
+			}
+			pkg.Vars = append(pkg.Vars, Var{Name: fields[0], Format: fields[1]})
+		case keyword == "DATA":
+			fields := strings.Fields(rest)
+			if len(fields) < 2 {
+				return pkgs, fmt.Errorf("invalid DATA keyword in line %d", lineNum)
+			}
+			pkg.DataNames = append(pkg.DataNames, fields[0])
+			pkg.Formats[fields[0]] = strings.ToUpper(fields[1])
+		case keyword == "CONSTANT":
+			name, value, _ := strings.Cut(rest, " ")
+			pkg.Constants[name] = strings.TrimSpace(value)
+		case keyword == "BEGIN" || keyword == "VAR_LIST_BEGIN" || keyword == "SEG_LIST_BEGIN":
+			state = keyword
+		default:
+			return pkgs, fmt.Errorf("unknown keyword %s in line %d", keyword, lineNum)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return pkgs, err
+	}
+	if state != "" {
+		return pkgs, fmt.Errorf("missing end of %s block", state)
+	}
+	if pkg == nil {
+		return pkgs, fmt.Errorf("missing CITIFILE keyword")
+	}
+	return append(pkgs, *pkg), nil
+}
+
+// setVarValues sets the values of the nth independent variable.
+func (p *Package) setVarValues(n int, values []float64) error {
+	if n >= len(p.Vars) {
+		return fmt.Errorf("var list %d doesn't have a matching VAR keyword", n+1)
+	}
+	p.Vars[n].Values = values
+	return nil
+}
+
+// addData parses the lines of the nth data block. The data blocks are in the
+// same order as the DATA keywords.
+func (p *Package) addData(n int, lines []string) error {
+	if n >= len(p.DataNames) {
+		return fmt.Errorf("data block %d doesn't have a matching DATA keyword", n+1)
+	}
+	name := p.DataNames[n]
+	values := make([]complex128, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Split(line, ",")
+		switch len(fields) {
+		case 1:
+			v, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+			if err != nil {
+				return fmt.Errorf("error parsing %s value: %s", name, err)
+			}
+			values = append(values, complex(v, 0))
+		case 2:
+			re, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+			if err != nil {
+				return fmt.Errorf("error parsing %s value: %s", name, err)
+			}
+			im, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+			if err != nil {
+				return fmt.Errorf("error parsing %s value: %s", name, err)
+			}
+			values = append(values, complex(re, im))
+		default:
+			return fmt.Errorf("invalid %s value: %s", name, line)
+		}
+	}
+	p.Data[name] = values
+	return nil
+}
+
+// maxSegmentPoints limits the number of points of a segment list, which is
+// much larger than the 100001 points of a network analyzer sweep, so that a
+// corrupt segment can't allocate gigabytes.
+const maxSegmentPoints = 1 << 20
+
+// parseSegment returns the linearly spaced values of a segment, which is
+// given as "SEG <start> <stop> <number of points>".
+func parseSegment(line string) ([]float64, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || strings.ToUpper(fields[0]) != "SEG" {
+		return nil, fmt.Errorf("invalid segment: %s", line)
+	}
+	start, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > maxSegmentPoints {
+		return nil, fmt.Errorf("invalid number of points: %d", n)
+	}
+	values := make([]float64, n)
+	for i := range values {
+		if n == 1 {
+			values[i] = start
+			continue
+		}
+		values[i] = start + float64(i)*(stop-start)/float64(n-1)
+	}
+	return values, nil
+}
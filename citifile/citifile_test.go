@@ -0,0 +1,133 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package citifile
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/cmplx"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadFile(t *testing.T) {
+	pkgs, err := ReadFile("./testdata/n5230c_two_port.cti")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "num packages", len(pkgs), 1)
+	pkg := pkgs[0]
+	assert(t, "version", pkg.Version, "A.01.01")
+	assert(t, "name", pkg.Name, "DATA")
+	assert(t, "device keyword", pkg.Device[0], "NA VERSION N5230C.09.42.01")
+	assert(t, "comment", pkg.Comments[0], "Keysight PNA N5230C")
+	assert(t, "constant", pkg.Constants["TIME"], "2024-03-14T10:21:07")
+	freq, ok := pkg.Var("FREQ")
+	assert(t, "found freq", ok, true)
+	assert(t, "num freq", len(freq.Values), 3)
+	for i, want := range []float64{1e9, 2e9, 3e9} {
+		assertFloat64(t, "freq", freq.Values[i], want, 1e-3)
+	}
+	assert(t, "data names", strings.Join(pkg.DataNames, " "), "S[1,1] S[2,1]")
+	assert(t, "S21 format", pkg.Formats["S[2,1]"], "RI")
+	assertComplex(t, "S11", pkg.Data["S[1,1]"][1], complex(-2.13412e-2, 4.51210e-2), 1e-12)
+	assertComplex(t, "S21", pkg.Data["S[2,1]"][2], complex(0.7, -0.5), 1e-12)
+}
+
+func TestReadMultiplePackages(t *testing.T) {
+	pkgs, err := ReadFile("./testdata/multi_package.cti")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "num packages", len(pkgs), 2)
+	cal := pkgs[0]
+	assert(t, "cal name", cal.Name, "CAL_SET")
+	assertFloat64(t, "var list value", cal.Vars[0].Values[1], 2.5e9, 1e-3)
+	assertComplex(t, "E[1]", cal.Data["E[1]"][1], complex(0.5, 0.5), 1e-12)
+	assert(t, "delay format", cal.Formats["DELAY"], "MAG")
+	assertComplex(t, "delay", cal.Data["DELAY"][1], complex(1.6e-9, 0), 1e-18)
+
+	mem := pkgs[1]
+	assert(t, "memory name", mem.Name, "MEMORY")
+	assert(t, "memory comment", mem.Comments[0], "Saved from memory trace")
+	freq, _ := mem.Var("freq")
+	assert(t, "num segmented freq", len(freq.Values), 4)
+	for i, want := range []float64{1e9, 2e9, 3e9, 4e9} {
+		assertFloat64(t, "segmented freq", freq.Values[i], want, 1e-3)
+	}
+	assert(t, "num S11", len(mem.Data["S[1,1]"]), 4)
+}
+
+func TestMarshalJSON(t *testing.T) {
+	pkg := Package{
+		Name:      "DATA",
+		DataNames: []string{"S[2,1]"},
+		Data:      map[string][]complex128{"S[2,1]": {complex(0.7, -0.5)}},
+	}
+	b, err := json.Marshal(pkg)
+	if err != nil {
+		t.Fatalf("error marshaling: %s", err)
+	}
+	var got struct {
+		Name string
+		Data map[string][][2]float64
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("error unmarshaling: %s", err)
+	}
+	assert(t, "name", got.Name, "DATA")
+	assert(t, "S21", got.Data["S[2,1]"][0], [2]float64{0.7, -0.5})
+}
+
+func TestReadErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing CITIFILE", "NAME DATA\n"},
+		{"unterminated block", "CITIFILE A.01.00\nDATA S[1,1] RI\nBEGIN\n1,0\n"},
+		{"extra data block", "CITIFILE A.01.00\nBEGIN\n1,0\nEND\n"},
+		{"invalid value", "CITIFILE A.01.00\nDATA S[1,1] RI\nBEGIN\n1,a\nEND\n"},
+		{"invalid segment", "CITIFILE A.01.00\nVAR FREQ MAG 2\nSEG_LIST_BEGIN\nSEG 1 2\nSEG_LIST_END\n"},
+		{"huge segment", "CITIFILE A.01.00\nVAR FREQ MAG 2\nSEG_LIST_BEGIN\nSEG 1 2 2000000000\nSEG_LIST_END\n"},
+		{"huge segment list", "CITIFILE A.01.00\nVAR FREQ MAG 2\nSEG_LIST_BEGIN\n" +
+			strings.Repeat("SEG 1 2 1000000\n", 2) + "SEG_LIST_END\n"},
+		{"unknown keyword", "CITIFILE A.01.00\nFOO BAR\n"},
+	}
+	for _, test := range tests {
+		if _, err := Read(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func assertComplex(t *testing.T, label string, got, want complex128, tolerance float64) {
+	if diff := cmplx.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %v \t\nwant %s = %v", label, got, label, want)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.cti")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Read(bytes.NewReader(data))
+	})
+}
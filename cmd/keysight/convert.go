@@ -0,0 +1,262 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/export"
+	"github.com/gotmc/keysight/scope"
+	"github.com/gotmc/keysight/units"
+)
+
+// runConvert converts files to standard CSV, JSON, or Parquet. Spectrum
+// analyzer traces support every format along with amplitude unit conversion
+// and trace selection, oscilloscope waveforms support CSV and JSON, and every
+// other file supports JSON.
+func runConvert(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("convert", "<file>...", stderr)
+	format := fs.String("format", "", "output `format`: csv, json, or parquet (default from the -o extension, or csv)")
+	output := fs.String("o", "", "output `file` (default standard output)")
+	toUnits := fs.String("units", "", "convert trace amplitudes to the `units`, such as dBm, dBuV, mW, or uV")
+	impedance := fs.Float64("impedance", 50, "`ohms` used to convert between power and voltage units")
+	traces := fs.String("traces", "", "comma separated trace `numbers` to include, such as 1,3 (default all)")
+	metadata := fs.Bool("metadata", true, "write the trace settings as comment lines in CSV output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError(fs, "no input files")
+	}
+	if *format == "" {
+		*format = "csv"
+		if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(*output), ".")); ext != "" {
+			*format = ext
+		}
+	}
+	if *format != "csv" && *format != "json" && *format != "parquet" {
+		return usageError(fs, "unknown output format %q", *format)
+	}
+	if *format == "csv" && fs.NArg() > 1 {
+		return usageError(fs, "CSV output takes a single input file")
+	}
+	var target esa.AmplitudeUnits
+	if *toUnits != "" {
+		u, err := units.Parse(*toUnits)
+		if err != nil {
+			return usageError(fs, "%s", err)
+		}
+		target = esa.AmplitudeUnits(u)
+	}
+	selection, err := parseTraceNumbers(*traces)
+	if err != nil {
+		return usageError(fs, "%s", err)
+	}
+
+	values := make([]interface{}, fs.NArg())
+	for i, filename := range fs.Args() {
+		v, err := keysight.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		trace, ok := keysight.SpectrumTrace(v)
+		if !ok {
+			if target != "" || selection != nil {
+				return fmt.Errorf("%s: -units and -traces only apply to spectrum analyzer traces", filename)
+			}
+			values[i] = v
+			continue
+		}
+		if target != "" {
+			if trace, err = trace.ConvertAmplitudeUnitsImpedance(target, *impedance); err != nil {
+				return fmt.Errorf("%s: %s", filename, err)
+			}
+		}
+		if trace, err = selectTraces(trace, selection); err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+		values[i] = trace
+	}
+
+	return writeOutput(*output, stdout, func(w io.Writer) error {
+		if len(values) == 1 {
+			return encodeValue(w, *format, fs.Arg(0), values[0], *metadata)
+		}
+		if *format == "json" {
+			return writeJSON(w, values)
+		}
+		traces := make([]esa.Trace, len(values))
+		for i, v := range values {
+			trace, ok := v.(esa.Trace)
+			if !ok {
+				return fmt.Errorf("%s: Parquet output only supports spectrum analyzer traces", fs.Arg(i))
+			}
+			traces[i] = trace
+		}
+		return export.WriteParquet(w, traces...)
+	})
+}
+
+// encodeValue writes the value read from the named file using the output
+// format, which is csv, json, or parquet.
+func encodeValue(w io.Writer, format, filename string, v interface{}, metadata bool) error {
+	switch format {
+	case "json":
+		return writeJSON(w, v)
+	case "parquet":
+		trace, ok := v.(esa.Trace)
+		if !ok {
+			return fmt.Errorf("%s: Parquet output only supports spectrum analyzer traces", filename)
+		}
+		return export.WriteParquet(w, trace)
+	}
+	return writeCSV(w, filename, v, metadata)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeOutput calls write with the named file, or with stdout if the
+// filename is empty or "-".
+func writeOutput(filename string, stdout io.Writer, write func(io.Writer) error) error {
+	if filename == "" || filename == "-" {
+		return write(stdout)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// parseTraceNumbers parses a comma separated list of trace numbers starting
+// from 1. An empty list returns nil.
+func parseTraceNumbers(s string) ([]int, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var numbers []int
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid trace number %q", field)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// selectTraces returns the trace with only the selected trace columns in the
+// given order, or every column if the selection is nil.
+func selectTraces(trace esa.Trace, selection []int) (esa.Trace, error) {
+	if selection == nil {
+		return trace, nil
+	}
+	all := trace.Traces()
+	selected := make([]esa.TraceData, len(selection))
+	for i, n := range selection {
+		if n > len(all) || len(all[n-1].Values) == 0 {
+			return trace, fmt.Errorf("trace %d has no data", n)
+		}
+		selected[i] = all[n-1]
+	}
+	trace.SetTraces(selected)
+	return trace, nil
+}
+
+// writeCSV writes the value read from the named file as a standard CSV file
+// with a header row followed by one row per point.
+func writeCSV(w io.Writer, filename string, v interface{}, metadata bool) error {
+	switch v := v.(type) {
+	case esa.Trace:
+		mode := esa.MetadataNone
+		if metadata {
+			mode = esa.MetadataComments
+		}
+		return v.WriteStandardCSV(w, esa.WithMetadata(mode))
+	case scope.BinFile:
+		return writeWaveformsCSV(w, v.Waveforms)
+	case []scope.Waveform:
+		return writeWaveformsCSV(w, v)
+	case scope.CSVFile:
+		labels := []string{columnLabel(v.XLabel, v.XUnits)}
+		columns := [][]float64{v.X}
+		for _, ch := range v.Channels {
+			labels = append(labels, columnLabel(ch.Name, ch.Units))
+			columns = append(columns, ch.Data)
+		}
+		return writeColumns(w, labels, columns)
+	}
+	return fmt.Errorf("%s: CSV output isn't supported for %T; use -format json", filename, v)
+}
+
+// writeWaveformsCSV writes the time of each point of the first waveform
+// followed by the samples of each waveform.
+func writeWaveformsCSV(w io.Writer, wfms []scope.Waveform) error {
+	if len(wfms) == 0 {
+		return fmt.Errorf("no waveforms")
+	}
+	labels := []string{columnLabel("Time", wfms[0].XUnits.String())}
+	columns := [][]float64{wfms[0].Times()}
+	for i, wfm := range wfms {
+		name := wfm.Label
+		if name == "" {
+			name = fmt.Sprintf("Waveform %d", i+1)
+		}
+		labels = append(labels, columnLabel(name, wfm.YUnits.String()))
+		columns = append(columns, wfm.Samples())
+	}
+	return writeColumns(w, labels, columns)
+}
+
+func columnLabel(name, units string) string {
+	if units == "" {
+		return name
+	}
+	return name + " (" + units + ")"
+}
+
+// writeColumns writes the labels followed by one row per value of the
+// columns, which must have the same length.
+func writeColumns(w io.Writer, labels []string, columns [][]float64) error {
+	n := len(columns[0])
+	for i, c := range columns {
+		if len(c) != n {
+			return fmt.Errorf("mismatched lengths / %s %d / %s %d", labels[0], n, labels[i], len(c))
+		}
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(labels); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for i := 0; i < n; i++ {
+		for j, c := range columns {
+			record[j] = strconv.FormatFloat(c[i], 'g', -1, 64)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
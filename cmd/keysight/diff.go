@@ -0,0 +1,108 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/esa"
+)
+
+// runDiff compares two spectrum analyzer traces, such as before and after a
+// modification or a unit against a golden unit, printing the settings that
+// differ and the statistics of the amplitude difference. With -threshold, it
+// exits with status 1 if the largest difference exceeds the threshold.
+func runDiff(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("diff", "<a> <b>", stderr)
+	traceNumber := fs.Int("trace", 1, "trace `number` to compare")
+	points := fs.Bool("points", false, "print the difference at every point")
+	threshold := fs.Float64("threshold", 0, "fail if the largest difference exceeds the `dB` (default no check)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usageError(fs, "expected two files")
+	}
+	if *threshold < 0 {
+		return usageError(fs, "invalid threshold %g", *threshold)
+	}
+	a, err := readTrace(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := readTrace(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	cmp, err := esa.Compare(a, b, esa.WithCompareTrace(*traceNumber))
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	if len(cmp.Settings) == 0 {
+		fmt.Fprintln(tw, "Settings: identical")
+	} else {
+		fmt.Fprintln(tw, "Setting\tA\tB")
+		for _, s := range cmp.Settings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Setting, s.A, s.B)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	units := deltaUnits(cmp.Units)
+	resampled := ""
+	if cmp.Resampled {
+		resampled = " (b resampled onto the frequencies of a)"
+	}
+	fmt.Fprintf(stdout, "\nPoints compared: %d%s\n", len(cmp.Points), resampled)
+	fmt.Fprintf(stdout, "Max delta:  %+.2f %s at %s\n", cmp.MaxDelta, units, formatSI(cmp.MaxDeltaFreq, "Hz"))
+	fmt.Fprintf(stdout, "Mean delta: %+.2f %s\n", cmp.MeanDelta, units)
+	fmt.Fprintf(stdout, "RMS delta:  %.2f %s\n", cmp.RMSDelta, units)
+	if *points {
+		fmt.Fprintln(stdout)
+		fmt.Fprintln(tw, "Frequency\tA\tB\tDelta")
+		for _, p := range cmp.Points {
+			fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%+.2f\n", formatSI(p.Frequency, "Hz"), p.A, p.B, p.Delta)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if *threshold > 0 && math.Abs(cmp.MaxDelta) > *threshold {
+		fmt.Fprintf(stdout, "\nmax delta exceeds the threshold of %g %s\n", *threshold, units)
+		return exitError(1)
+	}
+	return nil
+}
+
+// readTrace reads the spectrum analyzer trace from the named file.
+func readTrace(filename string) (esa.Trace, error) {
+	v, err := keysight.ReadFile(filename)
+	if err != nil {
+		return esa.Trace{}, err
+	}
+	trace, ok := keysight.SpectrumTrace(v)
+	if !ok {
+		return esa.Trace{}, fmt.Errorf("%s isn't a spectrum analyzer trace", filename)
+	}
+	return trace, nil
+}
+
+// deltaUnits returns the units of the difference of two values in the
+// units, which is dB for logarithmic units.
+func deltaUnits(units string) string {
+	if units == "" || strings.HasPrefix(strings.ToLower(units), "db") {
+		return "dB"
+	}
+	return units
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gotmc/keysight"
+)
+
+// runFormats prints the names of the file formats detected by the command,
+// including those added using keysight.Register by the packages linked into
+// it.
+func runFormats(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("formats", "", stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments")
+	}
+	for _, format := range keysight.Formats() {
+		if _, err := fmt.Fprintln(stdout, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/arb"
+	"github.com/gotmc/keysight/dmm"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/scope"
+	"github.com/gotmc/keysight/touchstone"
+)
+
+// field is a labeled value printed by the info command.
+type field struct {
+	label string
+	value string
+}
+
+// runInfo prints a summary of each file, such as the instrument model, the
+// sweep settings, and the range of the values. Files that can't be read are
+// reported and skipped.
+func runInfo(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("info", "<file>...", stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError(fs, "no input files")
+	}
+	failed := 0
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for i, filename := range fs.Args() {
+		fields, err := fileInfo(filename)
+		if err != nil {
+			failed++
+			fmt.Fprintf(stderr, "keysight info: %s\n", err)
+			continue
+		}
+		if i > failed {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, filename)
+		for _, f := range fields {
+			fmt.Fprintf(tw, "  %s\t%s\n", f.label, f.value)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files couldn't be read", failed, fs.NArg())
+	}
+	return nil
+}
+
+// fileInfo detects the format of the file, reads it, and returns its
+// summary.
+func fileInfo(filename string) ([]field, error) {
+	format, v, err := readFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+	return append([]field{{"Format", string(format)}}, summary(v)...), nil
+}
+
+// summary returns the fields describing a value returned by
+// keysight.ReadFile.
+func summary(v interface{}) []field {
+	if trace, ok := keysight.SpectrumTrace(v); ok {
+		return traceSummary(trace)
+	}
+	switch v := v.(type) {
+	case esa.LimitLine:
+		return limitLineSummary(v)
+	case esa.Correction:
+		fields := []field{
+			{"Type", string(v.Type)},
+			{"Description", v.Description},
+			{"Points", strconv.Itoa(len(v.Points))},
+		}
+		if n := len(v.Points); n > 0 {
+			fields = append(fields, field{"Frequency",
+				formatSI(v.Points[0].Frequency, "Hz") + " to " + formatSI(v.Points[n-1].Frequency, "Hz")})
+		}
+		return fields
+	case scope.BinFile:
+		return waveformsSummary(v.Waveforms)
+	case []scope.Waveform:
+		return waveformsSummary(v)
+	case scope.CSVFile:
+		fields := []field{
+			{"Points", strconv.Itoa(len(v.X))},
+			{"Sample rate", formatSI(v.SampleRate, "Sa/s")},
+		}
+		for _, ch := range v.Channels {
+			fields = append(fields, field{ch.Name, formatRange(ch.Data, ch.Units)})
+		}
+		return fields
+	case touchstone.SParameters:
+		fields := []field{
+			{"Ports", strconv.Itoa(v.Ports)},
+			{"Parameter", v.Parameter},
+			{"Reference", fmt.Sprintf("%g ohm", v.R)},
+			{"Points", strconv.Itoa(len(v.Frequency))},
+		}
+		if n := len(v.Frequency); n > 0 {
+			fields = append(fields, field{"Frequency", formatSI(v.Frequency[0], "Hz") + " to " + formatSI(v.Frequency[n-1], "Hz")})
+		}
+		return fields
+	case arb.Waveform:
+		return []field{
+			{"Channels", strconv.Itoa(v.ChannelCount())},
+			{"Points", strconv.Itoa(v.NumPoints())},
+			{"Sample rate", formatSI(v.SampleRate, "Sa/s")},
+			{"Levels", fmt.Sprintf("%g V to %g V", v.LowLevel, v.HighLevel)},
+		}
+	case dmm.DataLog:
+		return []field{
+			{"Model", v.Model},
+			{"Serial", v.SerialNum},
+			{"Start", formatTime(v.StartTime)},
+			{"Function", v.Function},
+			{"Interval", v.SampleInterval.String()},
+			{"Readings", strconv.Itoa(len(v.Readings))},
+			{"Values", formatRange(v.Values(), v.Units)},
+		}
+	}
+	return []field{{"Type", fmt.Sprintf("%T", v)}}
+}
+
+// traceSummary returns the instrument, sweep settings, and the minimum and
+// maximum amplitude of each trace column of a spectrum analyzer trace.
+func traceSummary(t esa.Trace) []field {
+	fields := []field{
+		{"Model", t.Model},
+		{"Serial", t.SerialNum},
+		{"Timestamp", formatTime(t.Timestamp)},
+	}
+	if t.Title != "" {
+		fields = append(fields, field{"Title", t.Title})
+	}
+	fields = append(fields,
+		field{"Center", formatFreq(t.CenterFreq, t.CenterFreqUnits)},
+		field{"Span", formatFreq(t.Span, t.SpanUnits)},
+		field{"RBW", formatFreq(t.RBW, t.RBWUnits)},
+		field{"VBW", formatFreq(t.VBW, t.VBWUnits)},
+		field{"Ref level", formatValue(t.RefLevel, string(t.RefLevelUnits))},
+	)
+	if t.SweepTimeUnits == esa.Seconds {
+		fields = append(fields, field{"Sweep time", formatSI(t.SweepTime, "s")})
+	} else if t.SweepTimeUnits != "" {
+		fields = append(fields, field{"Sweep time", formatValue(t.SweepTime, string(t.SweepTimeUnits))})
+	}
+	fields = append(fields, field{"Points", strconv.Itoa(len(t.Frequency))})
+	for i, td := range t.Traces() {
+		if len(td.Values) == 0 {
+			continue
+		}
+		label := td.Label
+		if label == "" {
+			label = fmt.Sprintf("Trace %d", i+1)
+		}
+		lo, hi := extremes(td.Values)
+		value := formatRange(td.Values, td.Units)
+		if lo < len(t.Frequency) && hi < len(t.Frequency) {
+			value = fmt.Sprintf("min %s at %s, max %s at %s",
+				formatValue(td.Values[lo], td.Units), formatSI(t.Frequency[lo], "Hz"),
+				formatValue(td.Values[hi], td.Units), formatSI(t.Frequency[hi], "Hz"))
+		}
+		fields = append(fields, field{label, value})
+	}
+	return fields
+}
+
+func limitLineSummary(l esa.LimitLine) []field {
+	fields := []field{
+		{"Type", string(l.Type)},
+		{"Description", l.Description},
+		{"Units", string(l.Units)},
+		{"Points", strconv.Itoa(len(l.Points))},
+	}
+	if n := len(l.Points); n > 0 {
+		fields = append(fields, field{"Frequency",
+			formatSI(l.Points[0].Frequency, "Hz") + " to " + formatSI(l.Points[n-1].Frequency, "Hz")})
+	}
+	return fields
+}
+
+// waveformsSummary returns the timing of the first oscilloscope waveform
+// and the range of the samples of each.
+func waveformsSummary(wfms []scope.Waveform) []field {
+	if len(wfms) == 0 {
+		return []field{{"Waveforms", "0"}}
+	}
+	first := wfms[0]
+	fields := []field{
+		{"Waveforms", strconv.Itoa(len(wfms))},
+		{"Date", first.Date + " " + first.Time},
+		{"Points", strconv.Itoa(first.NumPoints)},
+	}
+	if first.XIncrement > 0 {
+		fields = append(fields, field{"Sample rate", formatSI(1/first.XIncrement, "Sa/s")})
+	}
+	for i, wfm := range wfms {
+		label := wfm.Label
+		if label == "" {
+			label = fmt.Sprintf("Waveform %d", i+1)
+		}
+		fields = append(fields, field{label, formatRange(wfm.Samples(), wfm.YUnits.String())})
+	}
+	return fields
+}
+
+// extremes returns the indices of the minimum and maximum values, ignoring
+// NaNs. The values must not be empty.
+func extremes(values []float64) (lo, hi int) {
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if v < values[lo] || math.IsNaN(values[lo]) {
+			lo = i
+		}
+		if v > values[hi] || math.IsNaN(values[hi]) {
+			hi = i
+		}
+	}
+	return lo, hi
+}
+
+func formatRange(values []float64, units string) string {
+	if len(values) == 0 {
+		return "no values"
+	}
+	lo, hi := extremes(values)
+	return "min " + formatValue(values[lo], units) + ", max " + formatValue(values[hi], units)
+}
+
+// formatValue formats the value followed by the units, if any.
+func formatValue(v float64, units string) string {
+	if units == "" {
+		return fmt.Sprintf("%.6g", v)
+	}
+	return fmt.Sprintf("%.6g %s", v, units)
+}
+
+// formatFreq formats a frequency using an SI prefix if it's in Hz.
+func formatFreq(v float64, units esa.FrequencyUnits) string {
+	if units == esa.Hertz {
+		return formatSI(v, "Hz")
+	}
+	return formatValue(v, string(units))
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// siPrefixes are the prefixes used by formatSI from 1e-12 to 1e12.
+var siPrefixes = []string{"p", "n", "µ", "m", "", "k", "M", "G", "T"}
+
+// formatSI formats the value using an SI prefix for the unit, such as
+// "1.5 GHz" for 1.5e9 Hz.
+func formatSI(v float64, unit string) string {
+	exp := 0
+	if v != 0 && !math.IsNaN(v) && !math.IsInf(v, 0) {
+		exp = int(math.Floor(math.Log10(math.Abs(v)) / 3))
+	}
+	if exp < -4 {
+		exp = -4
+	} else if exp > 4 {
+		exp = 4
+	}
+	return fmt.Sprintf("%.6g %s%s", v/math.Pow(1000, float64(exp)), siPrefixes[exp+4], unit)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/emc"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/xseries"
+)
+
+// loadLimit returns the limit line saved in the named file, or the standard
+// limit with the name for the detector if the file doesn't exist. The
+// distance in meters applies to radiated standard limits.
+func loadLimit(name string, detector emc.Detector, distance float64) (esa.LimitLine, error) {
+	if _, err := os.Stat(name); err != nil {
+		line, stdErr := emc.StandardLimit(name, detector, distance)
+		if stdErr != nil {
+			return esa.LimitLine{}, fmt.Errorf("%s isn't a file or a standard limit", name)
+		}
+		return line, nil
+	}
+	v, err := keysight.ReadFile(name)
+	if err != nil {
+		return esa.LimitLine{}, err
+	}
+	switch line := v.(type) {
+	case esa.LimitLine:
+		return line, nil
+	case xseries.LimitLine:
+		return fromXSeriesLimit(line), nil
+	}
+	return esa.LimitLine{}, fmt.Errorf("%s isn't a limit line", name)
+}
+
+func fromXSeriesLimit(line xseries.LimitLine) esa.LimitLine {
+	l := esa.LimitLine{
+		Number:       line.Number,
+		Type:         esa.UpperLimit,
+		Description:  line.Description,
+		Units:        esa.AmplitudeUnits(line.YAxisUnit),
+		LogFrequency: line.LogFrequency,
+		Margin:       line.Margin,
+		MarginOn:     line.MarginOn,
+		Points:       make([]esa.LimitPoint, len(line.Points)),
+	}
+	if line.Type == xseries.LowerLimit {
+		l.Type = esa.LowerLimit
+	}
+	for i, p := range line.Points {
+		l.Points[i] = esa.LimitPoint(p)
+	}
+	return l
+}
+
+// parseDetector parses an EMI detector name, such as "qp" or "Quasi-Peak".
+func parseDetector(s string) (emc.Detector, error) {
+	switch strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(s)) {
+	case "peak", "pk":
+		return emc.Peak, nil
+	case "quasipeak", "qp":
+		return emc.QuasiPeak, nil
+	case "average", "avg", "av":
+		return emc.Average, nil
+	}
+	return "", fmt.Errorf("unknown detector %q", s)
+}
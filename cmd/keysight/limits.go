@@ -0,0 +1,141 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/emc"
+	"github.com/gotmc/keysight/esa"
+)
+
+// runLimits checks each spectrum analyzer trace against a limit line and
+// prints the worst emission within each band of the limit. It exits with
+// status 1 if any trace fails the limit, so it can gate automated tests.
+func runLimits(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("limits", "-limit <limit> <file>...", stderr)
+	limitName := fs.String("limit", "", "limit line `file` or standard limit name, such as \"CISPR 32 Class B Conducted\"")
+	detectorName := fs.String("detector", string(emc.QuasiPeak), "`detector` of the standard limit: peak, qp, or average")
+	distance := fs.Float64("distance", 0, "measurement distance in `meters` for radiated standard limits (default the standard's)")
+	traceNumber := fs.Int("trace", 1, "trace `number` to check")
+	correctionNames := fs.String("corrections", "", "comma separated correction `files`, such as LISN and cable factors")
+	impedance := fs.Float64("impedance", 50, "`ohms` used to convert the traces to the units of the limit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *limitName == "" {
+		return usageError(fs, "no limit given")
+	}
+	if fs.NArg() == 0 {
+		return usageError(fs, "no input files")
+	}
+	detector, err := parseDetector(*detectorName)
+	if err != nil {
+		return usageError(fs, "%s", err)
+	}
+	line, err := loadLimit(*limitName, detector, *distance)
+	if err != nil {
+		return err
+	}
+	var corrections []esa.Correction
+	if *correctionNames != "" {
+		for _, name := range strings.Split(*correctionNames, ",") {
+			v, err := keysight.ReadFile(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			corr, ok := v.(esa.Correction)
+			if !ok {
+				return fmt.Errorf("%s isn't a correction file", name)
+			}
+			corrections = append(corrections, corr)
+		}
+	}
+
+	failed := 0
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for i, filename := range fs.Args() {
+		report, err := checkLimit(filename, line, corrections, *traceNumber, *impedance)
+		if err != nil {
+			return err
+		}
+		if !report.Pass {
+			failed++
+		}
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "%s: %s, worst margin %.2f dB\n", filename, result(report.Pass), report.WorstMargin)
+		fmt.Fprintln(tw, "  Band\tFrequency\tLevel\tLimit\tMargin\tResult")
+		for _, e := range report.Emissions {
+			fmt.Fprintf(tw, "  %s\t%s\t%.2f\t%.2f\t%.2f\t%s\n",
+				e.Band.Name, formatSI(e.Frequency, "Hz"), e.Level, e.Limit, e.Margin, result(e.Pass))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(stdout, "\n%d of %d traces failed %s\n", failed, fs.NArg(), limitLabel(line, *limitName))
+		return exitError(1)
+	}
+	return nil
+}
+
+// checkLimit reads the trace from the named file, converts it to the units of
+// the limit line, and compares the numbered trace against the limit.
+func checkLimit(filename string, line esa.LimitLine, corrections []esa.Correction, number int, impedance float64) (emc.Report, error) {
+	v, err := keysight.ReadFile(filename)
+	if err != nil {
+		return emc.Report{}, err
+	}
+	trace, ok := keysight.SpectrumTrace(v)
+	if !ok {
+		return emc.Report{}, fmt.Errorf("%s: only spectrum analyzer traces can be checked", filename)
+	}
+	if units := traceUnits(trace, number); line.Units != "" && units != "" && !strings.EqualFold(string(line.Units), units) {
+		if trace, err = trace.ConvertAmplitudeUnitsImpedance(line.Units, impedance); err != nil {
+			return emc.Report{}, fmt.Errorf("%s: %s", filename, err)
+		}
+	}
+	report, err := emc.NewReport([]esa.Trace{trace}, line, corrections, emc.WithTrace(number))
+	if err != nil {
+		return emc.Report{}, fmt.Errorf("%s: %s", filename, err)
+	}
+	if len(report.Emissions) == 0 {
+		return emc.Report{}, fmt.Errorf("%s: no trace points within the frequency range of the limit", filename)
+	}
+	return report, nil
+}
+
+// traceUnits returns the units of the numbered trace, or the reference level
+// units if the trace doesn't have any.
+func traceUnits(trace esa.Trace, number int) string {
+	if traces := trace.Traces(); number >= 1 && number <= len(traces) {
+		if units := strings.TrimSpace(traces[number-1].Units); units != "" {
+			return units
+		}
+	}
+	return string(trace.RefLevelUnits)
+}
+
+func limitLabel(line esa.LimitLine, name string) string {
+	if line.Description != "" {
+		return line.Description
+	}
+	return name
+}
+
+func result(pass bool) string {
+	if pass {
+		return "PASS"
+	}
+	return "FAIL"
+}
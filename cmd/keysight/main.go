@@ -0,0 +1,157 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Command keysight works with the files saved by Keysight/Agilent/HP test
+// equipment without writing Go code. The format of each file is detected
+// automatically.
+//
+// Usage:
+//
+//	keysight <command> [flags] <file>...
+//
+// The commands are:
+//
+//	convert    convert files to standard CSV, JSON, or Parquet
+//	diff       compare two spectrum analyzer traces
+//	formats    list the supported file formats
+//	info       print a summary of files
+//	limits     check spectrum analyzer traces against a limit line
+//	plot       render spectrum analyzer traces as PNG or SVG images
+//	serve      run an HTTP API for parsing files and browsing a trace archive
+//	watch      process new files saved to a directory
+//
+// Run "keysight <command> -h" for the flags of a command.
+//
+// The watch and serve commands archive traces in an SQLite database using the
+// tracedb package, which needs an SQLite driver. Build the command with the sqlite
+// tag to link the modernc.org/sqlite driver, or use -driver to select
+// another database/sql driver linked into the command. The driver isn't a
+// requirement of the module, since it's large and only used by these
+// commands, so add it before building:
+//
+//	go get modernc.org/sqlite
+//	go build -tags sqlite ./cmd/keysight
+//
+// The command detects the formats built into the keysight package. To add
+// other formats, build a copy of the command that imports the packages that
+// register them using keysight.Register.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/gotmc/keysight"
+)
+
+// command is a subcommand of the keysight command.
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = map[string]command{
+	"convert": {"convert files to standard CSV, JSON, or Parquet", runConvert},
+	"diff":    {"compare two spectrum analyzer traces", runDiff},
+	"formats": {"list the supported file formats", runFormats},
+	"info":    {"print a summary of files", runInfo},
+	"limits":  {"check spectrum analyzer traces against a limit line", runLimits},
+	"plot":    {"render spectrum analyzer traces as PNG or SVG images", runPlot},
+	"serve":   {"run an HTTP API for parsing files and browsing a trace archive", runServe},
+	"watch":   {"process new files saved to a directory", runWatch},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command given by the arguments and returns the exit status,
+// which is 2 for usage errors and 1 for other errors.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "keysight: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	err := cmd.run(args[1:], stdout, stderr)
+	var exit exitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp), errors.Is(err, errUsage):
+		return 2
+	case errors.As(err, &exit):
+		return int(exit)
+	}
+	fmt.Fprintf(stderr, "keysight %s: %s\n", args[0], err)
+	return 1
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: keysight <command> [flags] <file>...")
+	fmt.Fprintln(w, "\nThe commands are:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// readFormat detects the format of the file and reads it like
+// keysight.ReadFile.
+func readFormat(filename string) (keysight.Format, interface{}, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", nil, err
+	}
+	format, err := keysight.Detect(filename, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	v, err := keysight.Read(filename, data)
+	return format, v, err
+}
+
+// errUsage is returned by a command after reporting a usage error.
+var errUsage = errors.New("usage error")
+
+// exitError is returned by a command to exit with the status without
+// printing an error, such as after reporting a failed check.
+type exitError int
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// newFlagSet returns a flag set for the command that writes its usage to
+// stderr.
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: keysight %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// usageError reports the usage error for the flag set and returns errUsage.
+func usageError(fs *flag.FlagSet, format string, a ...interface{}) error {
+	fmt.Fprintf(fs.Output(), "keysight %s: %s\n", fs.Name(), fmt.Sprintf(format, a...))
+	fs.Usage()
+	return errUsage
+}
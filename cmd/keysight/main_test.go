@@ -0,0 +1,668 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/esa"
+)
+
+func TestRunUsage(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{"no command", nil},
+		{"unknown command", []string{"frobnicate"}},
+		{"no files", []string{"convert"}},
+		{"formats with arguments", []string{"formats", "trace.csv"}},
+		{"unknown format", []string{"convert", "-format", "xml", "../../esa/testdata/e4402b_trace924.csv"}},
+		{"bad trace number", []string{"convert", "-traces", "0", "../../esa/testdata/e4402b_trace924.csv"}},
+		{"bad units", []string{"convert", "-units", "furlongs", "../../esa/testdata/e4402b_trace924.csv"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert(t, "status", run(test.args, &stdout, &stderr), 2)
+			if stderr.Len() == 0 {
+				t.Errorf("no usage written to stderr")
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	var tests = []struct {
+		name   string
+		args   []string
+		header string
+	}{
+		{
+			"esa trace",
+			[]string{"convert", "-metadata=false", "../../esa/testdata/e4402b_trace924.csv"},
+			"Frequency (Hz),Trace 1 (dBuV),Trace 2 (dBuV),Trace 3 (dBuV)",
+		},
+		{
+			"selected traces in dBm",
+			[]string{"convert", "-metadata=false", "-traces", "3,1", "-units", "dBm", "../../esa/testdata/e4402b_trace924.csv"},
+			"Frequency (Hz),Trace 3 (dBm),Trace 1 (dBm)",
+		},
+		{
+			"trace in mW",
+			[]string{"convert", "-metadata=false", "-traces", "1", "-units", "mW", "../../esa/testdata/e4402b_trace924.csv"},
+			"Frequency (Hz),Trace 1 (mW)",
+		},
+		{
+			"psa trace",
+			[]string{"convert", "-metadata=false", "../../psa/testdata/e4440a_trace001.csv"},
+			"Frequency (Hz),",
+		},
+		{
+			"xseries trace",
+			[]string{"convert", "-metadata=false", "../../xseries/testdata/n9020a_trace.csv"},
+			"Frequency (Hz),",
+		},
+		{
+			"scope binary",
+			[]string{"convert", "../../scope/testdata/dsox3034t_two_channels.bin"},
+			"Time (s),",
+		},
+		{
+			"scope hdf5",
+			[]string{"convert", "../../scope/testdata/infiniium_two_channels.h5"},
+			"Time (s),",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if status := run(test.args, &stdout, &stderr); status != 0 {
+				t.Fatalf("status %d: %s", status, stderr.String())
+			}
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			if !strings.HasPrefix(lines[0], test.header) {
+				t.Errorf("header = %q, want prefix %q", lines[0], test.header)
+			}
+			if len(lines) < 2 {
+				t.Errorf("no rows written")
+			}
+		})
+	}
+}
+
+func TestConvertJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"convert", "-format", "json",
+		"../../esa/testdata/e4402b_trace924.csv", "../../arb/testdata/sine8.arb"}
+	if status := run(args, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(stdout.Bytes(), &values); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+	assert(t, "values", len(values), 2)
+}
+
+func TestConvertJSONTestdata(t *testing.T) {
+	filenames, err := filepath.Glob("../../*/testdata/*.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Skip the files that are only read by their packages, such as VSA
+		// text recordings.
+		if _, err := keysight.Detect(filename, data); errors.Is(err, keysight.ErrUnknownFormat) {
+			continue
+		}
+		t.Run(filename, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if status := run([]string{"convert", "-format", "json", filename}, &stdout, &stderr); status != 0 {
+				t.Fatalf("status %d: %s", status, stderr.String())
+			}
+			if !json.Valid(stdout.Bytes()) {
+				t.Errorf("invalid JSON")
+			}
+		})
+	}
+}
+
+func TestConvertParquet(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "trace.parquet")
+	var stdout, stderr bytes.Buffer
+	args := []string{"convert", "-o", output, "-units", "dBm", "-traces", "1",
+		"../../esa/testdata/e4402b_trace924.csv"}
+	if status := run(args, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("PAR1")) {
+		t.Errorf("output isn't a Parquet file")
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{"missing file", []string{"convert", "testdata/missing.csv"}},
+		{"csv of arb", []string{"convert", "../../arb/testdata/sine8.arb"}},
+		{"parquet of arb", []string{"convert", "-format", "parquet", "../../arb/testdata/sine8.arb"}},
+		{"units of arb", []string{"convert", "-format", "json", "-units", "dBuV", "../../arb/testdata/sine8.arb"}},
+		{"missing trace", []string{"convert", "-traces", "4", "../../esa/testdata/e4402b_trace924.csv"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert(t, "status", run(test.args, &stdout, &stderr), 1)
+			if !strings.HasPrefix(stderr.String(), "keysight convert: ") {
+				t.Errorf("stderr = %q", stderr.String())
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	var tests = []struct {
+		filename string
+		want     []string
+	}{
+		{
+			"../../esa/testdata/e4402b_trace924.csv",
+			[]string{"ESA trace", "E4402B", "MY45104598", "2021-11-16 10:50:45", "34 kHz", "Points      401",
+				"Trace 1     min 56.6147 dBuV at 49.625 kHz, max 69.5907 dBuV at 41.875 kHz"},
+		},
+		{
+			"../../xseries/testdata/n9020a_trace.csv",
+			[]string{"X-Series trace", "N9020A", "1 GHz", "1.2 ms", "min -80 dBm at 995 MHz"},
+		},
+		{
+			"../../scope/testdata/dsox3034t_two_channels.bin",
+			[]string{"scope binary waveform", "Waveforms    2", "1 MSa/s", "max 0.5 V"},
+		},
+		{
+			"../../arb/testdata/sine8.arb",
+			[]string{"arbitrary waveform", "Points       8", "-0.5 V to 0.5 V"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if status := run([]string{"info", test.filename}, &stdout, &stderr); status != 0 {
+				t.Fatalf("status %d: %s", status, stderr.String())
+			}
+			for _, want := range test.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("output doesn't contain %q:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestInfoSkipsUnreadableFiles(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"info", "testdata/missing.csv", "../../arb/testdata/sine8.arb"}
+	assert(t, "status", run(args, &stdout, &stderr), 1)
+	if !strings.HasPrefix(stdout.String(), "../../arb/testdata/sine8.arb\n") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "1 of 2 files couldn't be read") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+// labNotes is a format registered by the tests.
+type labNotes struct{}
+
+func init() {
+	keysight.Register(labNotes{})
+}
+
+func (labNotes) Name() keysight.Format { return "lab notes" }
+
+func (labNotes) Detect(filename string, data []byte) bool {
+	return strings.HasSuffix(filename, ".notes")
+}
+
+func (labNotes) Parse(filename string, data []byte) (interface{}, error) {
+	return string(data), nil
+}
+
+func TestFormats(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"formats"}, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	assert(t, "first format", lines[0], "ESA trace")
+	assert(t, "last format", lines[len(lines)-1], "lab notes")
+}
+
+func TestFormatSI(t *testing.T) {
+	var tests = []struct {
+		value float64
+		unit  string
+		want  string
+	}{
+		{0, "Hz", "0 Hz"},
+		{9000, "Hz", "9 kHz"},
+		{2.4e9, "Hz", "2.4 GHz"},
+		{0.0012, "s", "1.2 ms"},
+		{-5e-7, "s", "-500 ns"},
+		{1e15, "Hz", "1000 THz"},
+	}
+	for _, test := range tests {
+		assert(t, fmt.Sprint(test.value), formatSI(test.value, test.unit), test.want)
+	}
+}
+
+func TestPlot(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	args := []string{"plot", "-dir", dir, "-peaks", "3", "-limit", "../../esa/testdata/cispr_limit.csv",
+		"../../esa/testdata/e4402b_trace924.csv", "../../xseries/testdata/n9020a_trace.csv"}
+	if status := run(args, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	for _, name := range []string{"e4402b_trace924.png", "n9020a_trace.png"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+			t.Errorf("%s isn't a PNG image", name)
+		}
+	}
+
+	output := filepath.Join(dir, "trace.svg")
+	stdout.Reset()
+	args = []string{"plot", "-o", output, "-traces", "1", "-limit", "CISPR 32 Class B Radiated", "-distance", "3",
+		"../../xseries/testdata/n9020a_trace.csv"}
+	if status := run(args, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	assert(t, "stdout", stdout.String(), output+"\n")
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("<svg")) {
+		t.Errorf("output isn't an SVG image")
+	}
+}
+
+func TestPlotErrors(t *testing.T) {
+	dir := t.TempDir()
+	var tests = []struct {
+		name   string
+		args   []string
+		status int
+	}{
+		{"several files with -o", []string{"plot", "-o", "x.png", "a.csv", "b.csv"}, 2},
+		{"unknown image format", []string{"plot", "-format", "gif", "a.csv"}, 2},
+		{"unknown detector", []string{"plot", "-detector", "rms", "a.csv"}, 2},
+		{"unknown limit", []string{"plot", "-dir", dir, "-limit", "CISPR 99", "../../esa/testdata/e4402b_trace924.csv"}, 1},
+		{"not a limit line", []string{"plot", "-dir", dir, "-limit", "../../arb/testdata/sine8.arb", "../../esa/testdata/e4402b_trace924.csv"}, 1},
+		{"not a trace", []string{"plot", "-dir", dir, "../../arb/testdata/sine8.arb"}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert(t, "status", run(test.args, &stdout, &stderr), test.status)
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	var tests = []struct {
+		name   string
+		args   []string
+		status int
+		want   []string
+	}{
+		{
+			"esa pass",
+			[]string{"limits", "-limit", "../../esa/testdata/cispr_limit.csv", "../../esa/testdata/e4402b_trace924.csv"},
+			0,
+			[]string{"PASS, worst margin 7.82 dB", "9 kHz - 50 kHz    41.875 kHz  69.59  81.03  11.44   PASS"},
+		},
+		{
+			"xseries lower limit fail",
+			[]string{"limits", "-limit", "../../xseries/testdata/n9020a_limit.csv", "../../xseries/testdata/n9020a_trace.csv"},
+			1,
+			[]string{"FAIL, worst margin -4.65 dB", "1 of 1 traces failed Carrier floor"},
+		},
+		{
+			"units converted to the limit",
+			[]string{"limits", "-limit", "CISPR 32 Class B Radiated", "-distance", "3", "../../xseries/testdata/n9020a_trace.csv"},
+			0,
+			[]string{"PASS, worst margin 10.47 dB", "230 MHz - 1 GHz  1 GHz      36.99  47.46  10.47   PASS"},
+		},
+		{
+			"corrections",
+			[]string{"limits", "-limit", "../../esa/testdata/cispr_limit.csv", "-corrections", "../../esa/testdata/LISN.CBL",
+				"../../esa/testdata/e4402b_trace924.csv"},
+			0,
+			[]string{"PASS"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert(t, "status", run(test.args, &stdout, &stderr), test.status)
+			for _, want := range test.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("output doesn't contain %q:\n%s", want, stdout.String())
+				}
+			}
+			if test.status != 1 && stderr.Len() > 0 {
+				t.Errorf("stderr = %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestLimitsTraceUnits(t *testing.T) {
+	// Trace 1 is in dBm while the reference level is in the dBuV of the limit.
+	data, err := os.ReadFile("../../esa/testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "TRACE924.CSV")
+	data = bytes.Replace(data, []byte("Hz,dBuV,dBuV,dBuV"), []byte("Hz,dBm,dBuV,dBuV"), 1)
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	args := []string{"limits", "-limit", "../../esa/testdata/cispr_limit.csv", filename}
+	assert(t, "status", run(args, &stdout, &stderr), 1)
+	want := "9 kHz - 50 kHz    41.875 kHz  176.58  81.03  -95.55  FAIL"
+	if !strings.Contains(stdout.String(), want) {
+		t.Errorf("output doesn't contain %q:\n%s", want, stdout.String())
+	}
+
+	// Trace 2 is still in dBuV.
+	stdout.Reset()
+	args = []string{"limits", "-limit", "../../esa/testdata/cispr_limit.csv", "-trace", "2", filename}
+	assert(t, "trace 2 status", run(args, &stdout, &stderr), 0)
+}
+
+func TestLimitsErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		args   []string
+		status int
+	}{
+		{"no limit", []string{"limits", "a.csv"}, 2},
+		{"no files", []string{"limits", "-limit", "CISPR 32 Class B Conducted"}, 2},
+		{"outside the limit", []string{"limits", "-limit", "CISPR 32 Class B Conducted", "../../esa/testdata/e4402b_trace924.csv"}, 1},
+		{"missing trace", []string{"limits", "-limit", "../../esa/testdata/cispr_limit.csv", "-trace", "4", "../../esa/testdata/e4402b_trace924.csv"}, 1},
+		{"not a correction", []string{"limits", "-limit", "../../esa/testdata/cispr_limit.csv", "-corrections", "../../arb/testdata/sine8.arb",
+			"../../esa/testdata/e4402b_trace924.csv"}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert(t, "status", run(test.args, &stdout, &stderr), test.status)
+			if stderr.Len() == 0 {
+				t.Errorf("no error written to stderr")
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	const golden = "../../esa/testdata/e4402b_trace924.csv"
+	trace, err := esa.ReadCSVFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace.RBW *= 3
+	trace.Trace1[200] -= 2.5
+	modified := filepath.Join(t.TempDir(), "modified.csv")
+	if err := trace.WriteCSVFile(modified); err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name   string
+		args   []string
+		status int
+		want   []string
+	}{
+		{
+			"identical",
+			[]string{"diff", golden, golden},
+			0,
+			[]string{"Settings: identical", "Points compared: 401", "RMS delta:  0.00 dB"},
+		},
+		{
+			"modified",
+			[]string{"diff", "-points", golden, modified},
+			0,
+			[]string{"Resolution Bandwidth  1000 Hz  3000 Hz", "Max delta:  -2.50 dB at 34 kHz", "34 kHz      62.05  59.55  -2.50"},
+		},
+		{
+			"threshold exceeded",
+			[]string{"diff", "-threshold", "1", golden, modified},
+			1,
+			[]string{"max delta exceeds the threshold of 1 dB"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert(t, "status", run(test.args, &stdout, &stderr), test.status)
+			for _, want := range test.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("output doesn't contain %q:\n%s", want, stdout.String())
+				}
+			}
+			if stderr.Len() > 0 {
+				t.Errorf("stderr = %q", stderr.String())
+			}
+		})
+	}
+
+	for _, args := range [][]string{
+		{"diff", golden},
+		{"diff", "-threshold", "-1", golden, golden},
+	} {
+		var stdout, stderr bytes.Buffer
+		assert(t, strings.Join(args, " "), run(args, &stdout, &stderr), 2)
+	}
+	for _, args := range [][]string{
+		{"diff", golden, "../../esa/testdata/e4411b_trace080.csv"},
+		{"diff", golden, "../../arb/testdata/sine8.arb"},
+	} {
+		var stdout, stderr bytes.Buffer
+		assert(t, strings.Join(args, " "), run(args, &stdout, &stderr), 1)
+	}
+}
+
+func TestWatchOnce(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"../../esa/testdata/e4402b_trace924.csv", "../../arb/testdata/sine8.arb"} {
+		copyFile(t, name, filepath.Join(dir, filepath.Base(name)))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not an instrument file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var posted []upload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var u upload
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			t.Errorf("error decoding upload: %s", err)
+		}
+		posted = append(posted, u)
+	}))
+	defer server.Close()
+
+	out := filepath.Join(t.TempDir(), "converted")
+	var stdout, stderr bytes.Buffer
+	args := []string{"watch", "-once", "-convert", out, "-format", "json", "-post", server.URL, dir}
+	if status := run(args, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	for _, name := range []string{"e4402b_trace924.json", "sine8.json"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("not converted: %s", err)
+		}
+	}
+	assert(t, "posted", len(posted), 2)
+	if len(posted) == 2 {
+		assert(t, "filename", posted[0].Filename, "e4402b_trace924.csv")
+		assert(t, "format", posted[0].Format, keysight.ESATrace)
+	}
+	if !strings.Contains(stdout.String(), "sine8.arb: arbitrary waveform, converted to ") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "notes.txt") {
+		t.Errorf("unreadable file not reported: %q", stderr.String())
+	}
+}
+
+func TestWatchPost(t *testing.T) {
+	// The values of these files, such as overloaded readings and complex
+	// samples, need custom JSON encoding.
+	names := []string{
+		"../../dmm/testdata/34465a_datalog.csv",
+		"../../dmm/testdata/u1282a_log.csv",
+		"../../iq/testdata/n9030a_iq.bin",
+		"../../vsa/testdata/n9030a_iq.mat",
+	}
+	dir := t.TempDir()
+	for _, name := range names {
+		copyFile(t, name, filepath.Join(dir, filepath.Base(name)))
+	}
+	var posted []upload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var u upload
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			t.Errorf("error decoding upload: %s", err)
+		}
+		posted = append(posted, u)
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"watch", "-once", "-post", server.URL, dir}, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	assert(t, "posted", len(posted), len(names))
+	if stderr.Len() > 0 {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+func TestWatcherWaitsForFilesToSettle(t *testing.T) {
+	dir := t.TempDir()
+	copyFile(t, "../../arb/testdata/sine8.arb", filepath.Join(dir, "old.arb"))
+	var stdout, stderr bytes.Buffer
+	var processed []string
+	w := &watcher{dir: dir, seen: make(map[string]fileState), stdout: &stdout, stderr: &stderr}
+	w.actions = append(w.actions, func(ctx context.Context, filename string, format keysight.Format, v interface{}) (string, error) {
+		processed = append(processed, filepath.Base(filename))
+		return "ok", nil
+	})
+	if err := w.skipExisting(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	scan := func() {
+		t.Helper()
+		if err := w.scan(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	copyFile(t, "../../arb/testdata/sine8.arb", filepath.Join(dir, "new.arb"))
+	scan()
+	assert(t, "processed after first scan", len(processed), 0)
+	scan()
+	assert(t, "processed after second scan", strings.Join(processed, ","), "new.arb")
+	scan()
+	assert(t, "processed after third scan", strings.Join(processed, ","), "new.arb")
+}
+
+func TestWatchErrors(t *testing.T) {
+	dir := t.TempDir()
+	var tests = []struct {
+		name   string
+		args   []string
+		status int
+	}{
+		{"no directory", []string{"watch", "-post", "http://localhost"}, 2},
+		{"no actions", []string{"watch", dir}, 2},
+		{"unknown format", []string{"watch", "-format", "xml", "-convert", dir, dir}, 2},
+		{"missing directory", []string{"watch", "-once", "-post", "http://localhost", filepath.Join(dir, "missing")}, 1},
+		{"unknown driver", []string{"watch", "-once", "-driver", "nosuchdriver", "-db", "traces.db", dir}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert(t, "status", run(test.args, &stdout, &stderr), test.status)
+		})
+	}
+}
+
+func copyFile(t *testing.T, from, to string) {
+	t.Helper()
+	data, err := os.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(to, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		args   []string
+		status int
+	}{
+		{"unexpected arguments", []string{"serve", "traces.db"}, 2},
+		{"invalid upload size", []string{"serve", "-max-upload", "0"}, 2},
+		{"certificate without key", []string{"serve", "-cert", "server.crt"}, 2},
+		{"unknown driver", []string{"serve", "-driver", "nosuchdriver", "-db", "traces.db"}, 1},
+		{"invalid address", []string{"serve", "-addr", "localhost:-1"}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert(t, "status", run(test.args, &stdout, &stderr), test.status)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/analysis"
+	"github.com/gotmc/keysight/emc"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/plot"
+	"github.com/gotmc/keysight/tracemath"
+)
+
+// runPlot renders spectrum analyzer traces as PNG or SVG images. A single
+// trace is written to the -o file, and several traces are written to the
+// -dir directory using the name of each file with the image extension.
+func runPlot(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("plot", "<file>...", stderr)
+	format := fs.String("format", "", "image `format`: png or svg (default from the -o extension, or png)")
+	output := fs.String("o", "", "output `file` for a single trace")
+	dir := fs.String("dir", ".", "output `directory` for images named after the trace files")
+	title := fs.String("title", "", "plot `title` (default the trace title or model)")
+	traces := fs.String("traces", "", "comma separated trace `numbers` to plot, such as 1,3 (default all)")
+	scale := fs.Float64("scale", plot.DefaultScale, "amplitude scale in `dB` per division")
+	width := fs.Int("width", plot.DefaultWidth, "image `width` in pixels")
+	height := fs.Int("height", plot.DefaultHeight, "image `height` in pixels")
+	limitName := fs.String("limit", "", "limit line `file` or standard limit name, such as \"CISPR 32 Class B Conducted\"")
+	detectorName := fs.String("detector", string(emc.QuasiPeak), "`detector` of the standard limit: peak, qp, or average")
+	distance := fs.Float64("distance", 0, "measurement distance in `meters` for radiated standard limits (default the standard's)")
+	peaks := fs.Int("peaks", 0, "annotate the `n` highest peaks of the first plotted trace")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError(fs, "no input files")
+	}
+	if *output != "" && fs.NArg() > 1 {
+		return usageError(fs, "-o takes a single input file; use -dir for several")
+	}
+	if *format == "" {
+		*format = "png"
+		if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(*output), ".")); ext != "" {
+			*format = ext
+		}
+	}
+	if *format != "png" && *format != "svg" {
+		return usageError(fs, "unknown image format %q", *format)
+	}
+	numbers, err := parseTraceNumbers(*traces)
+	if err != nil {
+		return usageError(fs, "%s", err)
+	}
+	detector, err := parseDetector(*detectorName)
+	if err != nil {
+		return usageError(fs, "%s", err)
+	}
+
+	opts := []plot.Option{plot.WithSize(*width, *height), plot.WithScale(*scale)}
+	if *title != "" {
+		opts = append(opts, plot.WithTitle(*title))
+	}
+	if numbers != nil {
+		opts = append(opts, plot.WithTraces(numbers...))
+	}
+	if *limitName != "" {
+		line, err := loadLimit(*limitName, detector, *distance)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, plot.WithLimitLines(line))
+	}
+
+	for _, filename := range fs.Args() {
+		v, err := keysight.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		trace, ok := keysight.SpectrumTrace(v)
+		if !ok {
+			return fmt.Errorf("%s: only spectrum analyzer traces can be plotted", filename)
+		}
+		traceOpts := opts
+		if *peaks > 0 {
+			markers, err := findPeaks(trace, numbers, *peaks)
+			if err != nil {
+				return fmt.Errorf("%s: %s", filename, err)
+			}
+			traceOpts = append(traceOpts[:len(traceOpts):len(traceOpts)], plot.WithMarkers(markers...))
+		}
+		name := *output
+		if name == "" {
+			base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+			name = filepath.Join(*dir, base+"."+*format)
+		}
+		if *format == "svg" {
+			err = plot.WriteSVGFile(name, trace, traceOpts...)
+		} else {
+			err = plot.WritePNGFile(name, trace, traceOpts...)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+		fmt.Fprintln(stdout, name)
+	}
+	return nil
+}
+
+// findPeaks returns up to n peaks of the first of the trace numbers, or of
+// the first trace containing data if the numbers are nil.
+func findPeaks(trace esa.Trace, numbers []int, n int) ([]analysis.Marker, error) {
+	all := trace.Traces()
+	number := 0
+	for i, td := range all {
+		if len(td.Values) > 0 {
+			number = i + 1
+			break
+		}
+	}
+	if len(numbers) > 0 {
+		number = numbers[0]
+	}
+	if number == 0 || number > len(all) {
+		return nil, fmt.Errorf("trace %d has no data", number)
+	}
+	t, err := tracemath.New(trace.Frequency, all[number-1].Values)
+	if err != nil {
+		return nil, err
+	}
+	markers, err := analysis.PeakSearch(t, analysis.WithMaxPeaks(n))
+	if err == analysis.ErrNoPeak {
+		return nil, nil
+	}
+	return markers, err
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/gotmc/keysight/rpc"
+	"github.com/gotmc/keysight/server"
+	"github.com/gotmc/keysight/tracedb"
+)
+
+// runServe runs the HTTP API of the server package and the gRPC service of
+// the rpc package until interrupted. gRPC clients need HTTP/2, which is only
+// available when serving over TLS.
+func runServe(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("serve", "", stderr)
+	addr := fs.String("addr", "localhost:8080", "`address` to listen on")
+	dsn := fs.String("db", "", "SQLite `database` of the trace archive")
+	driver := fs.String("driver", "sqlite", "database/sql `driver` used to open the database")
+	maxUpload := fs.Int64("max-upload", server.DefaultMaxUploadSize, "maximum size of an uploaded file in `bytes`")
+	certFile := fs.String("cert", "", "serve over TLS using the certificate `file`")
+	keyFile := fs.String("key", "", "private key `file` of the TLS certificate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usageError(fs, "unexpected arguments")
+	}
+	if *maxUpload <= 0 {
+		return usageError(fs, "invalid maximum upload size %d", *maxUpload)
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		return usageError(fs, "-cert and -key must be given together")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts := []server.Option{server.WithMaxUploadSize(*maxUpload)}
+	if *dsn != "" {
+		db, err := sql.Open(*driver, *dsn)
+		if err != nil {
+			return fmt.Errorf("error opening database: %s", err)
+		}
+		defer db.Close()
+		archive, err := tracedb.New(ctx, db)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, server.WithArchive(archive))
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", server.New(opts...))
+	mux.Handle(rpc.ServicePath, rpc.New(rpc.WithMaxMessageSize(*maxUpload)))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	scheme := "http"
+	if *certFile != "" {
+		scheme = "https"
+		go func() {
+			errc <- srv.ServeTLS(ln, *certFile, *keyFile)
+		}()
+	} else {
+		go func() {
+			errc <- srv.Serve(ln)
+		}()
+	}
+	fmt.Fprintf(stdout, "serving on %s://%s\n", scheme, ln.Addr())
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
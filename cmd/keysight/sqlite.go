@@ -0,0 +1,16 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+//go:build sqlite
+
+package main
+
+// The tracedb package doesn't import an SQLite driver, so the archive used by
+// watch -db needs one linked into the command. Building with the sqlite tag
+// links the pure Go driver, which registers itself as "sqlite":
+//
+//	go get modernc.org/sqlite
+//	go build -tags sqlite ./cmd/keysight
+import _ "modernc.org/sqlite"
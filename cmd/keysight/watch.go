@@ -0,0 +1,289 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/tracedb"
+)
+
+// runWatch polls a directory, such as a network share the instruments save
+// to, and runs the configured actions on each new file once it has stopped
+// changing. Files that can't be read or processed are reported and skipped.
+func runWatch(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("watch", "<directory>", stderr)
+	interval := fs.Duration("interval", 2*time.Second, "`time` between scans of the directory")
+	existing := fs.Bool("existing", false, "also process the files already in the directory")
+	once := fs.Bool("once", false, "process the files in the directory once and exit")
+	convertDir := fs.String("convert", "", "convert each file into the `directory`")
+	format := fs.String("format", "csv", "conversion `format`: csv, json, or parquet")
+	dsn := fs.String("db", "", "archive spectrum analyzer traces in the SQLite `database`")
+	driver := fs.String("driver", "sqlite", "database/sql `driver` used to open the database")
+	postURL := fs.String("post", "", "POST each file as JSON to the `url`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError(fs, "expected a single directory")
+	}
+	if *format != "csv" && *format != "json" && *format != "parquet" {
+		return usageError(fs, "unknown output format %q", *format)
+	}
+	if *interval <= 0 {
+		return usageError(fs, "invalid interval %s", *interval)
+	}
+	if info, err := os.Stat(fs.Arg(0)); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s isn't a directory", fs.Arg(0))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	w := &watcher{dir: fs.Arg(0), seen: make(map[string]fileState), stdout: stdout, stderr: stderr}
+	if *convertDir != "" {
+		if err := os.MkdirAll(*convertDir, 0o755); err != nil {
+			return err
+		}
+		w.actions = append(w.actions, convertAction(*convertDir, *format))
+	}
+	if *dsn != "" {
+		db, err := sql.Open(*driver, *dsn)
+		if err != nil {
+			return fmt.Errorf("error opening database: %s", err)
+		}
+		defer db.Close()
+		archive, err := tracedb.New(ctx, db)
+		if err != nil {
+			return err
+		}
+		w.actions = append(w.actions, archiveAction(archive))
+	}
+	if *postURL != "" {
+		w.actions = append(w.actions, postAction(http.DefaultClient, *postURL))
+	}
+	if len(w.actions) == 0 {
+		return usageError(fs, "no actions given; use -convert, -db, or -post")
+	}
+
+	if *once {
+		return w.processAll(ctx)
+	}
+	if !*existing {
+		if err := w.skipExisting(); err != nil {
+			return err
+		}
+	}
+	for {
+		if err := w.scan(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// action processes a file read by the watcher, returning a short description
+// of the result.
+type action func(ctx context.Context, filename string, format keysight.Format, v interface{}) (string, error)
+
+// fileState is the size and modification time of a file when the directory
+// was last scanned.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	done    bool
+}
+
+// watcher processes the new files in a directory.
+type watcher struct {
+	dir     string
+	actions []action
+	seen    map[string]fileState
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// scan processes the files that haven't changed since the previous scan and
+// haven't already been processed. A file that changes after it has been
+// processed, such as when an instrument overwrites it, is processed again.
+func (w *watcher) scan(ctx context.Context) error {
+	files, err := w.files()
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		prev, ok := w.seen[name]
+		if !ok || prev.size != state.size || !prev.modTime.Equal(state.modTime) {
+			w.seen[name] = state
+			continue
+		}
+		if prev.done {
+			continue
+		}
+		w.process(ctx, name)
+		state.done = true
+		w.seen[name] = state
+	}
+	return nil
+}
+
+// processAll processes every file in the directory.
+func (w *watcher) processAll(ctx context.Context) error {
+	files, err := w.files()
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		w.process(ctx, name)
+	}
+	return nil
+}
+
+// skipExisting marks the files in the directory as processed.
+func (w *watcher) skipExisting() error {
+	files, err := w.files()
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if info, err := os.Stat(name); err == nil {
+			w.seen[name] = fileState{size: info.Size(), modTime: info.ModTime(), done: true}
+		}
+	}
+	return nil
+}
+
+// files returns the paths of the regular files in the directory, skipping
+// hidden files, such as the temporary files of some file shares.
+func (w *watcher) files() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		files = append(files, filepath.Join(w.dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// process reads the file and runs each action, reporting the results.
+func (w *watcher) process(ctx context.Context, filename string) {
+	format, v, err := readFormat(filename)
+	if err != nil {
+		fmt.Fprintf(w.stderr, "keysight watch: %s\n", err)
+		return
+	}
+	results := []string{string(format)}
+	for _, act := range w.actions {
+		result, err := act(ctx, filename, format, v)
+		if err != nil {
+			fmt.Fprintf(w.stderr, "keysight watch: %s: %s\n", filename, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	fmt.Fprintf(w.stdout, "%s: %s\n", filename, strings.Join(results, ", "))
+}
+
+// convertAction writes each file into the directory using the format and
+// the name of the file with the format's extension.
+func convertAction(dir, format string) action {
+	return func(ctx context.Context, filename string, _ keysight.Format, v interface{}) (string, error) {
+		if trace, ok := keysight.SpectrumTrace(v); ok {
+			v = trace
+		}
+		base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+		output := filepath.Join(dir, base+"."+format)
+		err := writeOutput(output, nil, func(w io.Writer) error {
+			return encodeValue(w, format, filename, v, true)
+		})
+		if err != nil {
+			os.Remove(output)
+			return "", err
+		}
+		return "converted to " + output, nil
+	}
+}
+
+// archiveAction inserts each spectrum analyzer trace into the archive.
+// Other files are left out of the archive.
+func archiveAction(archive *tracedb.DB) action {
+	return func(ctx context.Context, filename string, _ keysight.Format, v interface{}) (string, error) {
+		trace, ok := keysight.SpectrumTrace(v)
+		if !ok {
+			return "not archived", nil
+		}
+		if trace.OriginalFilename == "" {
+			trace.OriginalFilename = filepath.Base(filename)
+		}
+		id, err := archive.Insert(ctx, trace)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("archived as %d", id), nil
+	}
+}
+
+// upload is the JSON body posted by postAction.
+type upload struct {
+	Filename string          `json:"filename"`
+	Format   keysight.Format `json:"format"`
+	Data     interface{}     `json:"data"`
+}
+
+// postAction posts each file as JSON containing the filename, the detected
+// format, and the parsed data.
+func postAction(client *http.Client, url string) action {
+	return func(ctx context.Context, filename string, format keysight.Format, v interface{}) (string, error) {
+		body, err := json.Marshal(upload{filepath.Base(filename), format, v})
+		if err != nil {
+			return "", err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return "", fmt.Errorf("POST %s: %s", url, resp.Status)
+		}
+		return "posted", nil
+	}
+}
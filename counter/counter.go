@@ -0,0 +1,326 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package counter has the ability to parse the data logging CSV files saved by
+// the Keysight 53220A and 53230A universal frequency counters.
+package counter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// Reading is a single counter reading. Timestamp is the gap-free timestamp in
+// seconds relative to the first reading, and Time is the absolute time
+// reconstructed from the start time of the log.
+type Reading struct {
+	Number    int
+	Timestamp float64
+	Time      time.Time
+	Value     float64
+}
+
+// Statistics are the summary statistics of the readings. The AllanDeviation is
+// the fractional (unitless) Allan deviation at the gate time.
+type Statistics struct {
+	Mean           float64
+	StdDev         float64
+	AllanDeviation float64
+	Min            float64
+	Max            float64
+	Count          int
+}
+
+// Log contains the instrument metadata, readings, and statistics of a counter
+// data log.
+type Log struct {
+	Manufacturer    string
+	Model           string
+	SerialNum       string
+	FirmwareVersion string
+	Function        string
+	Channel         int
+	Units           string
+	// GateTime is the gate time in seconds.
+	GateTime   float64
+	GateSource string
+	// GapFree reports whether the readings were taken with gap-free
+	// timestamping.
+	GapFree   bool
+	StartTime time.Time
+	// Header contains every header line keyed by its label.
+	Header   map[string][]string
+	Readings []Reading
+	// Statistics contains the statistics block from the footer of the file
+	// and is nil if the file doesn't contain one.
+	Statistics *Statistics
+}
+
+// Values returns the value of every reading.
+func (l Log) Values() []float64 {
+	values := make([]float64, len(l.Readings))
+	for i, r := range l.Readings {
+		values[i] = r.Value
+	}
+	return values
+}
+
+// ComputeStatistics computes the statistics of the readings. The Allan
+// deviation is computed from the fractional deviation of adjacent readings
+// from the mean, so it's only meaningful for frequency and period readings
+// taken gap-free at a fixed gate time.
+func (l Log) ComputeStatistics() Statistics {
+	values := l.Values()
+	stats := Statistics{Count: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+	stats.Min, stats.Max = values[0], values[0]
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+		stats.Min = math.Min(stats.Min, v)
+		stats.Max = math.Max(stats.Max, v)
+	}
+	stats.Mean = sum / float64(len(values))
+	if len(values) < 2 {
+		return stats
+	}
+	sumSq, sumDiffSq := 0.0, 0.0
+	for i, v := range values {
+		sumSq += (v - stats.Mean) * (v - stats.Mean)
+		if i > 0 {
+			d := (v - values[i-1]) / stats.Mean
+			sumDiffSq += d * d
+		}
+	}
+	stats.StdDev = math.Sqrt(sumSq / float64(len(values)-1))
+	stats.AllanDeviation = math.Sqrt(sumDiffSq / (2 * float64(len(values)-1)))
+	return stats
+}
+
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"01/02/2006 15:04:05.999999999",
+}
+
+// ReadCSVFile reads the counter data log CSV file with the given filename.
+func ReadCSVFile(filename string) (Log, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Log{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads a counter data log CSV from the io.Reader. The header consists
+// of an optional identification line followed by "Label,value[,units]"
+// lines. The readings start after the column labels line, whose first column
+// is labeled Reading, and may be followed by a Statistics block of
+// "Label,value[,units]" lines. Times without a time zone are parsed as UTC.
+func ReadCSV(r io.Reader) (Log, error) {
+	l := Log{Header: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	section := "header"
+	var cols columns
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return l, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		first := strings.ToLower(fields[0])
+		switch {
+		case section == "header" && (first == "reading" || first == "reading #"):
+			cols, err = l.parseLabels(fields)
+			if err != nil {
+				return l, fmt.Errorf("error parsing column labels in line %d: %s", lineNum, err)
+			}
+			section = "readings"
+			continue
+		case section == "readings" && first == "statistics":
+			l.Statistics = &Statistics{}
+			section = "statistics"
+			continue
+		}
+		switch section {
+		case "header":
+			err = l.parseHeader(fields)
+		case "readings":
+			var reading Reading
+			reading, err = l.parseReading(fields, cols)
+			l.Readings = append(l.Readings, reading)
+		case "statistics":
+			err = l.Statistics.parse(fields)
+		}
+		if err != nil {
+			return l, fmt.Errorf("error parsing %s line %d: %s", section, lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return l, err
+	}
+	if section == "header" {
+		return l, fmt.Errorf("missing column labels")
+	}
+	return l, nil
+}
+
+func (l *Log) parseHeader(fields []string) error {
+	label := strings.TrimSuffix(fields[0], ":")
+	values := fields[1:]
+	l.Header[label] = values
+	value, units := "", ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	if len(values) > 1 {
+		units = values[1]
+	}
+	var err error
+	switch strings.ToLower(label) {
+	case "keysight technologies", "agilent technologies":
+		l.Manufacturer = fields[0]
+		if len(fields) == 4 {
+			l.Model, l.SerialNum, l.FirmwareVersion = fields[1], fields[2], fields[3]
+		}
+	case "function":
+		l.Function = value
+		if units != "" {
+			l.Channel, err = strconv.Atoi(units)
+		}
+	case "units":
+		l.Units = value
+	case "gate time":
+		l.GateTime, err = parseSeconds(value, units)
+	case "gate source":
+		l.GateSource = value
+	case "timestamping", "timestamp":
+		l.GapFree = strings.EqualFold(strings.ReplaceAll(value, " ", "-"), "gap-free")
+	case "start time":
+		l.StartTime, err = parseTimestamp(strings.Join(values, " "))
+	}
+	return err
+}
+
+// columns contains the indices of the reading columns. A negative index means
+// the column doesn't exist.
+type columns struct {
+	number    int
+	timestamp int
+	value     int
+}
+
+// parseLabels determines the reading columns from the column labels, such as
+// "Reading,Timestamp (s),Value (Hz)".
+func (l *Log) parseLabels(labels []string) (columns, error) {
+	cols := columns{number: 0, timestamp: -1, value: -1}
+	for i, label := range labels[1:] {
+		i++
+		name, units, _ := strings.Cut(label, "(")
+		switch lower := strings.ToLower(strings.TrimSpace(name)); {
+		case strings.HasPrefix(lower, "time"):
+			cols.timestamp = i
+		case strings.HasPrefix(lower, "value"):
+			cols.value = i
+			if units = strings.TrimSuffix(strings.TrimSpace(units), ")"); units != "" && l.Units == "" {
+				l.Units = units
+			}
+		}
+	}
+	if cols.value < 0 {
+		return cols, fmt.Errorf("missing value column")
+	}
+	return cols, nil
+}
+
+func (l *Log) parseReading(fields []string, cols columns) (Reading, error) {
+	reading := Reading{}
+	if len(fields) <= cols.value || len(fields) <= cols.timestamp {
+		return reading, fmt.Errorf("missing columns")
+	}
+	var err error
+	if reading.Number, err = strconv.Atoi(fields[cols.number]); err != nil {
+		return reading, err
+	}
+	if reading.Value, err = strconv.ParseFloat(fields[cols.value], 64); err != nil {
+		return reading, err
+	}
+	if cols.timestamp >= 0 {
+		if reading.Timestamp, err = strconv.ParseFloat(fields[cols.timestamp], 64); err != nil {
+			return reading, err
+		}
+	} else {
+		// Without timestamps, assume back-to-back readings at the gate time.
+		reading.Timestamp = float64(len(l.Readings)) * l.GateTime
+	}
+	reading.Time = l.StartTime.Add(time.Duration(math.Round(reading.Timestamp * float64(time.Second))))
+	return reading, nil
+}
+
+// parse parses a single line of the statistics block.
+func (s *Statistics) parse(fields []string) error {
+	if len(fields) < 2 {
+		return fmt.Errorf("missing statistics value")
+	}
+	var err error
+	value := fields[1]
+	switch strings.ToLower(strings.TrimSuffix(fields[0], ":")) {
+	case "mean", "average":
+		s.Mean, err = strconv.ParseFloat(value, 64)
+	case "std dev", "standard deviation", "sdev":
+		s.StdDev, err = strconv.ParseFloat(value, 64)
+	case "allan dev", "allan deviation", "adev":
+		s.AllanDeviation, err = strconv.ParseFloat(value, 64)
+	case "min", "minimum":
+		s.Min, err = strconv.ParseFloat(value, 64)
+	case "max", "maximum":
+		s.Max, err = strconv.ParseFloat(value, 64)
+	case "count":
+		s.Count, err = strconv.Atoi(value)
+	}
+	return err
+}
+
+var secondsMultipliers = map[string]float64{
+	"":   1,
+	"s":  1,
+	"ms": 1e-3,
+	"us": 1e-6,
+}
+
+func parseSeconds(value, units string) (float64, error) {
+	mult, ok := secondsMultipliers[strings.ToLower(units)]
+	if !ok {
+		return 0, fmt.Errorf("unknown time units: %s", units)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	return v * mult, err
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown timestamp format: %s", s)
+}
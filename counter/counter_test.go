@@ -0,0 +1,118 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package counter
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	l, err := ReadCSVFile("./testdata/53230a_gapfree.csv")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "model", l.Model, "53230A")
+	assert(t, "serial number", l.SerialNum, "MY50001234")
+	assert(t, "function", l.Function, "Frequency")
+	assert(t, "channel", l.Channel, 1)
+	assert(t, "units", l.Units, "Hz")
+	assertFloat64(t, "gate time", l.GateTime, 0.1, 1e-12)
+	assert(t, "gate source", l.GateSource, "Time")
+	assert(t, "gap free", l.GapFree, true)
+	assert(t, "num readings", len(l.Readings), 5)
+	reading := l.Readings[1]
+	assert(t, "reading number", reading.Number, 2)
+	assertFloat64(t, "reading timestamp", reading.Timestamp, 0.10000000012, 1e-15)
+	assert(t, "reading time", reading.Time, time.Date(2024, time.March, 14, 10, 21, 7, 100000000, time.UTC))
+	assertFloat64(t, "reading value", reading.Value, 10000000.12, 1e-6)
+
+	if l.Statistics == nil {
+		t.Fatalf("missing statistics")
+	}
+	want := Statistics{
+		Mean:           10000000.11,
+		StdDev:         1.581138854e-2,
+		AllanDeviation: 1.620185149e-9,
+		Min:            10000000.09,
+		Max:            10000000.13,
+		Count:          5,
+	}
+	assertStatistics(t, "file", *l.Statistics, want)
+	assertStatistics(t, "computed", l.ComputeStatistics(), want)
+}
+
+func TestReadCSVWithoutTimestamps(t *testing.T) {
+	data := `Gate Time,10,ms
+Start Time,2024-03-14 08:00:00
+Reading,Value
+1,1.5E+06
+2,1.6E+06`
+	l, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("error reading data: %s", err)
+	}
+	assert(t, "gap free", l.GapFree, false)
+	assert(t, "statistics", l.Statistics == nil, true)
+	assertFloat64(t, "timestamp", l.Readings[1].Timestamp, 0.01, 1e-15)
+	assert(t, "reading time", l.Readings[1].Time, time.Date(2024, time.March, 14, 8, 0, 0, 10000000, time.UTC))
+	stats := l.ComputeStatistics()
+	assert(t, "count", stats.Count, 2)
+	empty := Log{}.ComputeStatistics()
+	assert(t, "empty count", empty.Count, 0)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing labels", "Function,Frequency,1\n"},
+		{"missing value column", "Reading,Timestamp (s)\n"},
+		{"invalid gate time units", "Gate Time,1,fortnights\nReading,Value\n"},
+		{"invalid reading", "Reading,Value\n1,abc\n"},
+		{"missing columns", "Reading,Timestamp (s),Value\n1,0\n"},
+		{"invalid statistics", "Reading,Value\n1,1\nStatistics\nMean,abc\n"},
+	}
+	for _, test := range tests {
+		if _, err := ReadCSV(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
+
+func assertStatistics(t *testing.T, label string, got, want Statistics) {
+	assertFloat64(t, label+" mean", got.Mean, want.Mean, 1e-6)
+	assertFloat64(t, label+" std dev", got.StdDev, want.StdDev, 1e-9)
+	assertFloat64(t, label+" allan deviation", got.AllanDeviation, want.AllanDeviation, 1e-17)
+	assertFloat64(t, label+" min", got.Min, want.Min, 1e-6)
+	assertFloat64(t, label+" max", got.Max, want.Max, 1e-6)
+	assert(t, label+" count", got.Count, want.Count)
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
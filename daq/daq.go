@@ -0,0 +1,379 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package daq has the ability to parse the scan logs exported by BenchVue and
+// BenchLink Data Logger for the Keysight 34970A, 34972A, and DAQ970A data
+// acquisition units.
+package daq
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// Scaling is the Mx+B scaling of a channel.
+type Scaling struct {
+	Enabled bool
+	Gain    float64
+	Offset  float64
+	Label   string
+}
+
+// Alarm is the alarm configuration of a channel.
+type Alarm struct {
+	// Mode is the alarm mode, such as "Hi+Lo", "Hi", "Lo", or "Off".
+	Mode    string
+	High    float64
+	HasHigh bool
+	Low     float64
+	HasLow  bool
+	Output  string
+}
+
+// Channel is the configuration of a scanned channel.
+type Channel struct {
+	Number     int
+	Name       string
+	Function   string
+	Range      string
+	Resolution string
+	Scaling    Scaling
+	Alarm      Alarm
+	// Extra contains the configuration columns that aren't otherwise parsed
+	// keyed by the column label.
+	Extra map[string]string
+}
+
+// AlarmState is the alarm state of a reading.
+type AlarmState int
+
+// Available alarm states.
+const (
+	AlarmNone AlarmState = iota
+	AlarmLow
+	AlarmHigh
+)
+
+// Scan is a single scan of every channel. The Values and Alarms are in the
+// same order as the channels of the ScanLog.
+type Scan struct {
+	Number int
+	Time   time.Time
+	Values []float64
+	Alarms []AlarmState
+}
+
+// ScanLog contains the header, channel configuration, and scans of a data
+// logger export.
+type ScanLog struct {
+	// Header contains the header lines keyed by label.
+	Header          map[string]string
+	Instrument      string
+	AcquisitionDate time.Time
+	Channels        []Channel
+	Scans           []Scan
+}
+
+// Channel returns the configuration of the channel with the given number.
+func (l ScanLog) Channel(number int) (Channel, bool) {
+	for _, ch := range l.Channels {
+		if ch.Number == number {
+			return ch, true
+		}
+	}
+	return Channel{}, false
+}
+
+// Values returns the readings of the channel with the given number in scan
+// order.
+func (l ScanLog) Values(number int) []float64 {
+	idx := l.channelIndex(number)
+	if idx < 0 {
+		return nil
+	}
+	values := make([]float64, len(l.Scans))
+	for i, scan := range l.Scans {
+		values[i] = scan.Values[idx]
+	}
+	return values
+}
+
+func (l ScanLog) channelIndex(number int) int {
+	for i, ch := range l.Channels {
+		if ch.Number == number {
+			return i
+		}
+	}
+	return -1
+}
+
+var timestampLayouts = []string{
+	"1/2/2006 15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"1/2/2006 3:04:05 PM",
+}
+
+// ReadCSVFile reads the data logger CSV export with the given filename.
+func ReadCSVFile(filename string) (ScanLog, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return ScanLog{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads a data logger CSV export from the io.Reader. The export starts
+// with "Label,value" header lines followed by a channel configuration table,
+// whose first column is labeled Channel, and a scan table, whose first column
+// is labeled Scan. Times without a time zone are parsed as UTC.
+func ReadCSV(r io.Reader) (ScanLog, error) {
+	l := ScanLog{Header: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	section := "header"
+	var labels []string
+	var cols []scanColumn
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			if section == "channels" {
+				section = "header"
+			}
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return l, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		switch first := strings.ToLower(fields[0]); {
+		case section != "scans" && first == "channel":
+			section = "channels"
+			labels = fields
+			continue
+		case section != "scans" && first == "scan":
+			section = "scans"
+			cols, err = l.scanColumns(fields)
+			if err != nil {
+				return l, fmt.Errorf("error parsing scan labels in line %d: %s", lineNum, err)
+			}
+			continue
+		}
+		switch section {
+		case "header":
+			if err := l.parseHeader(fields); err != nil {
+				return l, fmt.Errorf("error parsing header line %d: %s", lineNum, err)
+			}
+		case "channels":
+			ch, err := parseChannel(labels, fields)
+			if err != nil {
+				return l, fmt.Errorf("error parsing channel in line %d: %s", lineNum, err)
+			}
+			l.Channels = append(l.Channels, ch)
+		case "scans":
+			scan, err := l.parseScan(cols, fields)
+			if err != nil {
+				return l, fmt.Errorf("error parsing scan in line %d: %s", lineNum, err)
+			}
+			l.Scans = append(l.Scans, scan)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return l, err
+	}
+	if section != "scans" {
+		return l, fmt.Errorf("missing scan table")
+	}
+	return l, nil
+}
+
+func (l *ScanLog) parseHeader(fields []string) error {
+	label := strings.TrimSuffix(fields[0], ":")
+	value := strings.Join(nonEmpty(fields[1:]), ",")
+	l.Header[label] = value
+	switch strings.ToLower(label) {
+	case "instrument":
+		l.Instrument = value
+	case "acquisition date", "start time", "date":
+		if value == "" {
+			return nil
+		}
+		t, err := parseTimestamp(value)
+		if err != nil {
+			return err
+		}
+		l.AcquisitionDate = t
+	}
+	return nil
+}
+
+// parseChannel parses a row of the channel configuration table.
+func parseChannel(labels, fields []string) (Channel, error) {
+	ch := Channel{Extra: make(map[string]string)}
+	var err error
+	for i, field := range fields {
+		if i >= len(labels) || field == "" {
+			continue
+		}
+		switch strings.ToLower(labels[i]) {
+		case "channel":
+			ch.Number, err = strconv.Atoi(field)
+		case "name":
+			ch.Name = field
+		case "function":
+			ch.Function = field
+		case "range":
+			ch.Range = field
+		case "resolution":
+			ch.Resolution = field
+		case "scale", "scaling":
+			ch.Scaling.Enabled = strings.EqualFold(field, "on")
+		case "gain":
+			ch.Scaling.Gain, err = strconv.ParseFloat(field, 64)
+		case "offset":
+			ch.Scaling.Offset, err = strconv.ParseFloat(field, 64)
+		case "label", "units":
+			ch.Scaling.Label = field
+		case "alarm":
+			ch.Alarm.Mode = field
+		case "high limit", "hi limit":
+			ch.Alarm.High, err = strconv.ParseFloat(field, 64)
+			ch.Alarm.HasHigh = true
+		case "low limit", "lo limit":
+			ch.Alarm.Low, err = strconv.ParseFloat(field, 64)
+			ch.Alarm.HasLow = true
+		case "alarm output":
+			ch.Alarm.Output = field
+		default:
+			ch.Extra[labels[i]] = field
+		}
+		if err != nil {
+			return ch, fmt.Errorf("error parsing %s: %s", labels[i], err)
+		}
+	}
+	return ch, nil
+}
+
+// scanColumn describes a column of the scan table.
+type scanColumn struct {
+	kind    string
+	channel int
+}
+
+// scanColumns determines the meaning of each scan table column. Reading
+// columns are labeled with the channel number, such as "101 (VDC)", and alarm
+// columns are labeled "Alarm 101". Channels that aren't in the channel
+// configuration table are added to the scan log.
+func (l *ScanLog) scanColumns(labels []string) ([]scanColumn, error) {
+	cols := make([]scanColumn, len(labels))
+	for i, label := range labels {
+		lower := strings.ToLower(label)
+		switch {
+		case lower == "scan":
+			cols[i].kind = "scan"
+		case lower == "time":
+			cols[i].kind = "time"
+		case strings.HasPrefix(lower, "alarm"):
+			num, err := channelNumber(strings.TrimSpace(label[len("alarm"):]))
+			if err != nil {
+				return nil, err
+			}
+			cols[i] = scanColumn{"alarm", num}
+		default:
+			num, err := channelNumber(label)
+			if err != nil {
+				return nil, err
+			}
+			cols[i] = scanColumn{"value", num}
+			if l.channelIndex(num) < 0 {
+				l.Channels = append(l.Channels, Channel{Number: num, Name: label})
+			}
+		}
+	}
+	return cols, nil
+}
+
+func (l *ScanLog) parseScan(cols []scanColumn, fields []string) (Scan, error) {
+	scan := Scan{
+		Number: len(l.Scans) + 1,
+		Values: make([]float64, len(l.Channels)),
+		Alarms: make([]AlarmState, len(l.Channels)),
+	}
+	var err error
+	for i, col := range cols {
+		if i >= len(fields) {
+			return scan, fmt.Errorf("missing column %d", i+1)
+		}
+		field := fields[i]
+		switch col.kind {
+		case "scan":
+			scan.Number, err = strconv.Atoi(field)
+		case "time":
+			scan.Time, err = parseTimestamp(field)
+		case "value":
+			scan.Values[l.channelIndex(col.channel)], err = strconv.ParseFloat(field, 64)
+		case "alarm":
+			idx := l.channelIndex(col.channel)
+			if idx < 0 || field == "" {
+				continue
+			}
+			var state int
+			state, err = strconv.Atoi(field)
+			scan.Alarms[idx] = AlarmState(state)
+		}
+		if err != nil {
+			return scan, err
+		}
+	}
+	return scan, nil
+}
+
+// channelNumber parses the channel number at the start of a label, such as
+// "101 (VDC)".
+func channelNumber(label string) (int, error) {
+	end := 0
+	for end < len(label) && label[end] >= '0' && label[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, fmt.Errorf("missing channel number in %s", label)
+	}
+	return strconv.Atoi(label[:end])
+}
+
+// benchLinkMillis matches the milliseconds of BenchLink timestamps, which are
+// separated from the seconds by a colon, such as "10:21:07:250".
+var benchLinkMillis = regexp.MustCompile(`(\d+:\d+:\d+):(\d+)$`)
+
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	s = benchLinkMillis.ReplaceAllString(s, "$1.$2")
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown timestamp format: %s", s)
+}
+
+func nonEmpty(fields []string) []string {
+	var out []string
+	for _, f := range fields {
+		if f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
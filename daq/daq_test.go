@@ -0,0 +1,139 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package daq
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	l, err := ReadCSVFile("./testdata/34972a_scan.csv")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "instrument", l.Instrument, "34972A (MY49012345)")
+	assert(t, "name", l.Header["Name"], "Thermal Chamber Test")
+	assert(t, "acquisition date", l.AcquisitionDate, time.Date(2024, time.March, 14, 10, 21, 7, 0, time.UTC))
+	assert(t, "num channels", len(l.Channels), 2)
+
+	var channels = []struct {
+		number int
+		want   Channel
+	}{
+		{
+			number: 101,
+			want: Channel{
+				Number:     101,
+				Name:       "Supply",
+				Function:   "DC Voltage",
+				Range:      "10 V",
+				Resolution: "5 1/2 digits",
+				Scaling:    Scaling{Enabled: true, Gain: 2, Offset: 0.5, Label: "V"},
+				Alarm:      Alarm{Mode: "Hi+Lo", High: 5, HasHigh: true, Low: -5, HasLow: true, Output: "Alarm 1"},
+			},
+		},
+		{
+			number: 102,
+			want: Channel{
+				Number:     102,
+				Name:       "Chamber",
+				Function:   "Temperature",
+				Range:      "Type J",
+				Resolution: "5 1/2 digits",
+				Scaling:    Scaling{Enabled: false, Gain: 1, Offset: 0, Label: "C"},
+				Alarm:      Alarm{Mode: "Hi", High: 85, HasHigh: true, Output: "Alarm 2"},
+			},
+		},
+	}
+	for _, test := range channels {
+		ch, ok := l.Channel(test.number)
+		if !ok {
+			t.Errorf("missing channel %d", test.number)
+			continue
+		}
+		assert(t, "number", ch.Number, test.want.Number)
+		assert(t, "name", ch.Name, test.want.Name)
+		assert(t, "function", ch.Function, test.want.Function)
+		assert(t, "range", ch.Range, test.want.Range)
+		assert(t, "resolution", ch.Resolution, test.want.Resolution)
+		assert(t, "scaling", ch.Scaling, test.want.Scaling)
+		assert(t, "alarm", ch.Alarm, test.want.Alarm)
+		assert(t, "integration", ch.Extra["Integration"], "1 PLC")
+	}
+
+	assert(t, "num scans", len(l.Scans), 3)
+	scan := l.Scans[1]
+	assert(t, "scan number", scan.Number, 2)
+	assert(t, "scan time", scan.Time, time.Date(2024, time.March, 14, 10, 21, 17, 250000000, time.UTC))
+	assert(t, "scan alarm 101", scan.Alarms[0], AlarmHigh)
+	assert(t, "scan alarm 102", l.Scans[2].Alarms[1], AlarmHigh)
+	assert(t, "scan alarm low", l.Scans[2].Alarms[0], AlarmLow)
+	values := l.Values(102)
+	for i, want := range []float64{23.45, 24.10, 86.20} {
+		assertFloat64(t, "channel 102 value", values[i], want, 1e-9)
+	}
+	if l.Values(999) != nil {
+		t.Errorf("expected nil values for unknown channel")
+	}
+}
+
+func TestReadCSVWithoutConfig(t *testing.T) {
+	data := `Acquisition Date,2024-03-14 10:21:07
+Scan,Time,201 (VDC),202 (OHM)
+1,2024-03-14 10:21:07,1.5,1000
+2,2024-03-14 10:21:08,1.6,1001`
+	l, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("error reading data: %s", err)
+	}
+	assert(t, "num channels", len(l.Channels), 2)
+	assert(t, "channel name", l.Channels[1].Name, "202 (OHM)")
+	assertFloat64(t, "channel 202 value", l.Values(202)[1], 1001, 1e-9)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing scan table", "Name,Test\n"},
+		{"invalid channel number", "Channel,Name\nabc,Supply\nScan,Time\n"},
+		{"invalid scan label", "Scan,Time,Voltage\n"},
+		{"invalid value", "Scan,Time,101 (V)\n1,2024-03-14 10:21:07,abc\n"},
+		{"invalid time", "Scan,Time,101 (V)\n1,yesterday,1.0\n"},
+		{"missing column", "Scan,Time,101 (V)\n1,2024-03-14 10:21:07\n"},
+	}
+	for _, test := range tests {
+		if _, err := ReadCSV(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package decode decodes the UART, SPI, and I2C serial protocols from the
+// channels of saved oscilloscope captures, like the serial decode of the
+// InfiniiVision and Infiniium oscilloscopes, for offline analysis. The
+// channels are first converted to a Signal, either from a digital channel
+// of a mixed signal oscilloscope or from an analog waveform using a
+// threshold, and the decoders return the frames found in the signals.
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gotmc/keysight/scope"
+)
+
+// Signal is a logic signal sampled at a constant interval, where true is a
+// high level.
+type Signal struct {
+	XOrigin    float64
+	XIncrement float64
+	Bits       []bool
+}
+
+// FromDigital returns the signal of a digital channel.
+func FromDigital(dc scope.DigitalChannel) Signal {
+	return Signal{XOrigin: dc.XOrigin, XIncrement: dc.XIncrement, Bits: dc.Bits}
+}
+
+// FromWaveform returns the signal of an analog waveform, which is high when
+// the sample rises above the threshold plus half the hysteresis and low when
+// it falls below the threshold minus half the hysteresis, like the trigger
+// and decode thresholds of the oscilloscope.
+func FromWaveform(wfm scope.Waveform, threshold, hysteresis float64) Signal {
+	samples := wfm.Samples()
+	s := Signal{XOrigin: wfm.XOrigin, XIncrement: wfm.XIncrement, Bits: make([]bool, len(samples))}
+	high := len(samples) > 0 && samples[0] >= threshold
+	for i, v := range samples {
+		switch {
+		case v >= threshold+hysteresis/2:
+			high = true
+		case v <= threshold-hysteresis/2:
+			high = false
+		}
+		s.Bits[i] = high
+	}
+	return s
+}
+
+// Time returns the time of the i-th sample.
+func (s Signal) Time(i int) float64 {
+	return s.XOrigin + float64(i)*s.XIncrement
+}
+
+// index returns the index of the sample nearest the given time.
+func (s Signal) index(t float64) int {
+	return int(math.Round((t - s.XOrigin) / s.XIncrement))
+}
+
+// FrameError is a set of the errors detected while decoding a frame.
+type FrameError uint
+
+// Available frame errors.
+const (
+	// FramingError is a UART frame whose stop bit is low.
+	FramingError FrameError = 1 << iota
+	// ParityError is a UART frame whose parity bit doesn't match the data.
+	ParityError
+	// IncompleteError is a frame that ends before all its bits are received,
+	// such as at the end of the capture or when the SPI chip select is
+	// deasserted in the middle of a word.
+	IncompleteError
+)
+
+var frameErrorNames = []string{"framing", "parity", "incomplete"}
+
+// String implements the Stringer interface for FrameError and returns the
+// names of the errors separated by commas.
+func (e FrameError) String() string {
+	var names []string
+	for i, name := range frameErrorNames {
+		if e&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Frame is a decoded frame, which is a UART character, the words sent while
+// the SPI chip select is asserted, or an I2C transfer from a start to the
+// following stop or repeated start. Times are in seconds.
+type Frame struct {
+	Start float64
+	End   float64
+	// Data contains the UART character, the bytes sent on the SPI MOSI line,
+	// or the I2C data bytes following the address.
+	Data []byte
+	// MISO contains the bytes received on the SPI MISO line.
+	MISO []byte
+	// Address and Read are the 7-bit address and read/write bit of an I2C
+	// transfer.
+	Address int
+	Read    bool
+	// Acks contains whether each byte of an I2C transfer was acknowledged,
+	// starting with the address byte.
+	Acks   []bool
+	Errors FrameError
+}
+
+// BitOrder is the order in which the bits of each byte are sent.
+type BitOrder int
+
+// Available bit orders.
+const (
+	LSBFirst BitOrder = iota
+	MSBFirst
+)
+
+// assemble returns the byte of the given bits in the given order.
+func assemble(bits []bool, order BitOrder) byte {
+	var b byte
+	for i, bit := range bits {
+		if !bit {
+			continue
+		}
+		if order == LSBFirst {
+			b |= 1 << i
+		} else {
+			b |= 1 << (len(bits) - 1 - i)
+		}
+	}
+	return b
+}
+
+// checkSignals returns an error if the required signals are empty or the
+// signals don't share the same time base. Signals without bits are
+// optional and skipped.
+func checkSignals(required int, signals ...Signal) error {
+	var ref *Signal
+	for i := range signals {
+		s := &signals[i]
+		if len(s.Bits) == 0 {
+			if i < required {
+				return errors.New("signal has no samples")
+			}
+			continue
+		}
+		if s.XIncrement <= 0 {
+			return fmt.Errorf("invalid x increment: %g", s.XIncrement)
+		}
+		if ref == nil {
+			ref = s
+			continue
+		}
+		if len(s.Bits) != len(ref.Bits) || s.XIncrement != ref.XIncrement || s.XOrigin != ref.XOrigin {
+			return errors.New("signals don't have the same time base")
+		}
+	}
+	return nil
+}
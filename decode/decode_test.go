@@ -0,0 +1,89 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package decode
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/scope"
+)
+
+func TestFromWaveform(t *testing.T) {
+	wfm := scope.Waveform{
+		XOrigin:    -1e-6,
+		XIncrement: 1e-7,
+		Buffers: []scope.Buffer{{
+			Type:   scope.BufferNormal,
+			Values: []float32{0, 1.6, 1.4, 1.7, 2.1, 1.5, 1.3, 0.2},
+		}},
+	}
+	got := FromWaveform(wfm, 1.5, 0.4)
+	want := []bool{false, false, false, true, true, true, false, false}
+	assert(t, "num bits", len(got.Bits), len(want))
+	for i, bit := range want {
+		assert(t, "bit", got.Bits[i], bit)
+	}
+	assertFloat64(t, "time[2]", got.Time(2), -0.8e-6, 1e-15)
+}
+
+func TestFromDigitalSPI(t *testing.T) {
+	bf, err := scope.ReadBinFile("../scope/testdata/msox3034t_digital.bin")
+	if err != nil {
+		t.Fatalf("received error reading bin file: %s", err)
+	}
+	channels := bf.DigitalChannels()
+	// D0 toggles every sample and D1 every other sample, so sampling D1 on
+	// the rising edges of D0 gives alternating bits.
+	frames, err := SPI(FromDigital(channels[0]), FromDigital(channels[1]), Signal{}, Signal{})
+	if err != nil {
+		t.Fatalf("received error decoding SPI: %s", err)
+	}
+	assert(t, "num frames", len(frames), 1)
+	assert(t, "data", frames[0].Data[0], byte(0x55))
+	assertFloat64(t, "start", frames[0].Start, -7e-6, 1e-12)
+}
+
+func TestFrameErrorString(t *testing.T) {
+	assert(t, "none", FrameError(0).String(), "")
+	assert(t, "parity", ParityError.String(), "parity")
+	assert(t, "both", (FramingError | IncompleteError).String(), "framing,incomplete")
+}
+
+func TestCheckSignals(t *testing.T) {
+	a := Signal{XIncrement: 1, Bits: make([]bool, 4)}
+	var tests = []struct {
+		name    string
+		signals []Signal
+	}{
+		{"missing required", []Signal{a, {}}},
+		{"bad increment", []Signal{a, {Bits: make([]bool, 4)}}},
+		{"different length", []Signal{a, {XIncrement: 1, Bits: make([]bool, 3)}}},
+		{"different origin", []Signal{a, {XOrigin: 1, XIncrement: 1, Bits: make([]bool, 4)}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkSignals(2, test.signals...); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+	if err := checkSignals(1, a, Signal{}); err != nil {
+		t.Errorf("received error for optional signal: %s", err)
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package decode
+
+// I2C decodes the transfers of the I2C bus with the given clock (SCL) and
+// data (SDA) signals, which must have the same time base. A transfer starts
+// at a start condition, where SDA falls while SCL is high, and ends at the
+// following stop condition, where SDA rises while SCL is high, or at a
+// repeated start. SDA is sampled on the rising edge of SCL, and the ninth
+// bit of each byte is the acknowledge, which is low for an ACK. Only 7-bit
+// addresses are decoded, so the first byte after a 10-bit address header is
+// returned as data.
+func I2C(scl, sda Signal) ([]Frame, error) {
+	if err := checkSignals(2, scl, sda); err != nil {
+		return nil, err
+	}
+	var frames []Frame
+	var frame *Frame
+	var bits []bool
+	numBytes := 0
+	// finish ends the frame at a start or stop condition, which follows the
+	// rising edge of SCL that would be the first bit of the next byte.
+	finish := func(end float64) {
+		if len(bits) > 1 {
+			frame.Errors |= IncompleteError
+		}
+		frame.End = end
+		frames = append(frames, *frame)
+		frame = nil
+	}
+	for i := 1; i < len(scl.Bits); i++ {
+		sclHigh := scl.Bits[i-1] && scl.Bits[i]
+		switch {
+		case sclHigh && sda.Bits[i-1] && !sda.Bits[i]:
+			// A start or repeated start.
+			if frame != nil {
+				finish(scl.Time(i))
+			}
+			frame = &Frame{Start: scl.Time(i)}
+			bits = bits[:0]
+			numBytes = 0
+		case sclHigh && !sda.Bits[i-1] && sda.Bits[i]:
+			if frame != nil {
+				finish(scl.Time(i))
+			}
+		case frame != nil && !scl.Bits[i-1] && scl.Bits[i]:
+			bits = append(bits, sda.Bits[i])
+			if len(bits) < 9 {
+				continue
+			}
+			b := assemble(bits[:8], MSBFirst)
+			if numBytes == 0 {
+				frame.Address = int(b >> 1)
+				frame.Read = b&1 != 0
+			} else {
+				frame.Data = append(frame.Data, b)
+			}
+			frame.Acks = append(frame.Acks, !bits[8])
+			bits = bits[:0]
+			numBytes++
+		}
+	}
+	if frame != nil {
+		// The capture ended before the stop condition.
+		frame.Errors |= IncompleteError
+		finish(scl.Time(len(scl.Bits) - 1))
+	}
+	return frames, nil
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package decode
+
+import "testing"
+
+// i2cBus builds the SCL and SDA signals of I2C transfers, with 2 samples
+// per step.
+type i2cBus struct {
+	scl, sda []bool
+}
+
+func (bus *i2cBus) step(scl, sda bool) {
+	bus.scl = append(bus.scl, scl, scl)
+	bus.sda = append(bus.sda, sda, sda)
+}
+
+func (bus *i2cBus) start() {
+	bus.step(true, true)
+	bus.step(true, false)
+	bus.step(false, false)
+}
+
+// write sends the byte followed by the acknowledge bit.
+func (bus *i2cBus) write(b byte, ack bool) {
+	for _, bit := range append(msbBits(b), !ack) {
+		bus.step(false, bit)
+		bus.step(true, bit)
+		bus.step(false, bit)
+	}
+}
+
+func (bus *i2cBus) stop() {
+	bus.step(false, false)
+	bus.step(true, false)
+	bus.step(true, true)
+}
+
+func (bus *i2cBus) signals() (Signal, Signal) {
+	return Signal{XIncrement: 1e-6, Bits: bus.scl}, Signal{XIncrement: 1e-6, Bits: bus.sda}
+}
+
+func TestI2C(t *testing.T) {
+	bus := &i2cBus{}
+	// Write register 0x10 then read two bytes using a repeated start.
+	bus.start()
+	bus.write(0x50<<1, true)
+	bus.write(0x10, true)
+	bus.step(false, true)
+	bus.start()
+	bus.write(0x50<<1|1, true)
+	bus.write(0xde, true)
+	bus.write(0xad, false)
+	bus.stop()
+	// Address a missing device.
+	bus.start()
+	bus.write(0x21<<1, false)
+	bus.stop()
+	// The capture ends in the middle of a transfer.
+	bus.start()
+	bus.write(0x50<<1, true)
+	bus.step(false, true)
+	bus.step(true, true)
+	bus.step(false, true)
+	frames, err := I2C(bus.signals())
+	if err != nil {
+		t.Fatalf("received error decoding I2C: %s", err)
+	}
+	var tests = []struct {
+		address int
+		read    bool
+		data    []byte
+		acks    []bool
+		errors  FrameError
+	}{
+		{0x50, false, []byte{0x10}, []bool{true, true}, 0},
+		{0x50, true, []byte{0xde, 0xad}, []bool{true, true, false}, 0},
+		{0x21, false, nil, []bool{false}, 0},
+		{0x50, false, nil, []bool{true}, IncompleteError},
+	}
+	assert(t, "num frames", len(frames), len(tests))
+	for i, test := range tests {
+		frame := frames[i]
+		assert(t, "address", frame.Address, test.address)
+		assert(t, "read", frame.Read, test.read)
+		assert(t, "errors", frame.Errors, test.errors)
+		assert(t, "num bytes", len(frame.Data), len(test.data))
+		for j := range test.data {
+			assert(t, "data", frame.Data[j], test.data[j])
+		}
+		assert(t, "num acks", len(frame.Acks), len(test.acks))
+		for j := range test.acks {
+			assert(t, "ack", frame.Acks[j], test.acks[j])
+		}
+	}
+	assertFloat64(t, "start", frames[0].Start, 2e-6, 1e-12)
+	assert(t, "repeated start", frames[0].End, frames[1].Start)
+}
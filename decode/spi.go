@@ -0,0 +1,133 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package decode
+
+import "fmt"
+
+// SPIOption configures the SPI decoder.
+type SPIOption func(*spiConfig)
+
+type spiConfig struct {
+	mode       int
+	wordSize   int
+	order      BitOrder
+	activeHigh bool
+}
+
+func newSPIConfig(opts []SPIOption) spiConfig {
+	cfg := spiConfig{wordSize: 8, order: MSBFirst}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithMode sets the SPI mode from 0 to 3, which gives the clock polarity
+// (CPOL) and phase (CPHA). Data is sampled on the rising clock edge in modes
+// 0 and 3 and on the falling edge in modes 1 and 2. The default is 0.
+func WithMode(mode int) SPIOption {
+	return func(cfg *spiConfig) {
+		cfg.mode = mode
+	}
+}
+
+// WithWordSize sets the number of bits in each word from 1 to 8. The
+// default is 8.
+func WithWordSize(n int) SPIOption {
+	return func(cfg *spiConfig) {
+		cfg.wordSize = n
+	}
+}
+
+// WithSPIBitOrder sets the bit order. The default is MSBFirst.
+func WithSPIBitOrder(order BitOrder) SPIOption {
+	return func(cfg *spiConfig) {
+		cfg.order = order
+	}
+}
+
+// WithChipSelectActiveHigh sets the chip select to be asserted when high.
+// The default is active low.
+func WithChipSelectActiveHigh() SPIOption {
+	return func(cfg *spiConfig) {
+		cfg.activeHigh = true
+	}
+}
+
+// SPI decodes the words of the SPI bus with the given clock, MOSI, MISO,
+// and chip select signals, which must have the same time base. The MISO and
+// chip select signals are optional and omitted by passing a Signal without
+// bits. With a chip select, a frame contains the words sent while it's
+// asserted; without one, each word is a frame.
+func SPI(clk, mosi, miso, cs Signal, opts ...SPIOption) ([]Frame, error) {
+	cfg := newSPIConfig(opts)
+	if cfg.mode < 0 || cfg.mode > 3 {
+		return nil, fmt.Errorf("invalid SPI mode: %d", cfg.mode)
+	}
+	if cfg.wordSize < 1 || cfg.wordSize > 8 {
+		return nil, fmt.Errorf("invalid word size: %d", cfg.wordSize)
+	}
+	if err := checkSignals(2, clk, mosi, miso, cs); err != nil {
+		return nil, err
+	}
+	hasMISO, hasCS := len(miso.Bits) > 0, len(cs.Bits) > 0
+	// Sample on the rising edge when CPOL equals CPHA.
+	risingEdge := cfg.mode == 0 || cfg.mode == 3
+	asserted := func(i int) bool {
+		return !hasCS || cs.Bits[i] == cfg.activeHigh
+	}
+
+	var frames []Frame
+	var frame *Frame
+	var mosiBits, misoBits []bool
+	finish := func(end float64) {
+		if len(mosiBits) > 0 {
+			frame.Errors |= IncompleteError
+		}
+		frame.End = end
+		frames = append(frames, *frame)
+		frame = nil
+		mosiBits, misoBits = mosiBits[:0], misoBits[:0]
+	}
+	if hasCS && asserted(0) {
+		frame = &Frame{Start: clk.Time(0)}
+	}
+	for i := 1; i < len(clk.Bits); i++ {
+		if hasCS {
+			switch {
+			case frame == nil && asserted(i):
+				frame = &Frame{Start: clk.Time(i)}
+			case frame != nil && !asserted(i):
+				finish(clk.Time(i))
+			}
+		}
+		if !asserted(i) || clk.Bits[i-1] == clk.Bits[i] || clk.Bits[i] != risingEdge {
+			continue
+		}
+		if frame == nil {
+			frame = &Frame{Start: clk.Time(i)}
+		}
+		mosiBits = append(mosiBits, mosi.Bits[i])
+		if hasMISO {
+			misoBits = append(misoBits, miso.Bits[i])
+		}
+		if len(mosiBits) < cfg.wordSize {
+			continue
+		}
+		frame.Data = append(frame.Data, assemble(mosiBits, cfg.order))
+		if hasMISO {
+			frame.MISO = append(frame.MISO, assemble(misoBits, cfg.order))
+		}
+		mosiBits, misoBits = mosiBits[:0], misoBits[:0]
+		if !hasCS {
+			finish(clk.Time(i))
+		}
+	}
+	if frame != nil {
+		finish(clk.Time(len(clk.Bits) - 1))
+	}
+	return frames, nil
+}
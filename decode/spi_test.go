@@ -0,0 +1,125 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package decode
+
+import "testing"
+
+// spiSignals returns the clock, MOSI, MISO, and active low chip select
+// signals of a mode 0 transfer of each group of bits, with the chip select
+// deasserted between the groups. Each clock period is 4 samples, and the
+// MISO bits are the complement of the MOSI bits.
+func spiSignals(groups ...[]bool) (clk, mosi, miso, cs Signal) {
+	var c, o, i, s []bool
+	add := func(clk, data, sel bool, n int) {
+		for k := 0; k < n; k++ {
+			c, o, i, s = append(c, clk), append(o, data), append(i, !data), append(s, sel)
+		}
+	}
+	add(false, false, true, 4)
+	for _, group := range groups {
+		add(false, false, false, 2)
+		for _, bit := range group {
+			add(false, bit, false, 2)
+			add(true, bit, false, 2)
+		}
+		add(false, false, false, 2)
+		add(false, false, true, 4)
+	}
+	signal := func(bits []bool) Signal {
+		return Signal{XIncrement: 1e-6, Bits: bits}
+	}
+	return signal(c), signal(o), signal(i), signal(s)
+}
+
+// msbBits returns the bits of the bytes MSB first.
+func msbBits(data ...byte) []bool {
+	var bits []bool
+	for _, b := range data {
+		for n := 7; n >= 0; n-- {
+			bits = append(bits, b&(1<<n) != 0)
+		}
+	}
+	return bits
+}
+
+func TestSPI(t *testing.T) {
+	clk, mosi, miso, cs := spiSignals(msbBits(0x9f), msbBits(0x03, 0x12, 0x34), msbBits(0xa5)[:5])
+	frames, err := SPI(clk, mosi, miso, cs)
+	if err != nil {
+		t.Fatalf("received error decoding SPI: %s", err)
+	}
+	var tests = []struct {
+		mosi   []byte
+		miso   []byte
+		errors FrameError
+	}{
+		{[]byte{0x9f}, []byte{0x60}, 0},
+		{[]byte{0x03, 0x12, 0x34}, []byte{0xfc, 0xed, 0xcb}, 0},
+		{nil, nil, IncompleteError},
+	}
+	assert(t, "num frames", len(frames), len(tests))
+	for i, test := range tests {
+		assert(t, "errors", frames[i].Errors, test.errors)
+		assert(t, "num bytes", len(frames[i].Data), len(test.mosi))
+		for j := range test.mosi {
+			assert(t, "mosi", frames[i].Data[j], test.mosi[j])
+			assert(t, "miso", frames[i].MISO[j], test.miso[j])
+		}
+	}
+	assertFloat64(t, "start", frames[0].Start, 4e-6, 1e-12)
+	assertFloat64(t, "end", frames[0].End, 40e-6, 1e-12)
+
+	// Without a chip select each word is a frame.
+	frames, err = SPI(clk, mosi, Signal{}, Signal{})
+	if err != nil {
+		t.Fatalf("received error decoding SPI: %s", err)
+	}
+	assert(t, "words", len(frames), 5)
+	assert(t, "word 3", frames[2].Data[0], byte(0x12))
+	assert(t, "no miso", frames[2].MISO == nil, true)
+	assert(t, "partial word", frames[4].Errors, IncompleteError)
+}
+
+func TestSPIOptions(t *testing.T) {
+	clk, mosi, miso, cs := spiSignals(msbBits(0xc0))
+	frames, err := SPI(clk, mosi, miso, cs, WithSPIBitOrder(LSBFirst), WithWordSize(4))
+	if err != nil {
+		t.Fatalf("received error decoding SPI: %s", err)
+	}
+	assert(t, "num frames", len(frames), 1)
+	assert(t, "num words", len(frames[0].Data), 2)
+	assert(t, "word 1", frames[0].Data[0], byte(0x03))
+	assert(t, "word 2", frames[0].Data[1], byte(0x00))
+
+	// Mode 2 samples on the falling edge of a clock that idles high.
+	inverted := Signal{XIncrement: clk.XIncrement, Bits: make([]bool, len(clk.Bits))}
+	for i, v := range clk.Bits {
+		inverted.Bits[i] = !v
+	}
+	frames, err = SPI(inverted, mosi, miso, cs, WithMode(2))
+	if err != nil {
+		t.Fatalf("received error decoding SPI: %s", err)
+	}
+	assert(t, "mode 2", frames[0].Data[0], byte(0xc0))
+
+	var tests = []struct {
+		name string
+		opts []SPIOption
+	}{
+		{"mode", []SPIOption{WithMode(4)}},
+		{"word size", []SPIOption{WithWordSize(9)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := SPI(clk, mosi, miso, cs, test.opts...); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+	if _, err := SPI(clk, Signal{}, miso, cs); err == nil {
+		t.Errorf("expected error for missing MOSI")
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package decode
+
+import "fmt"
+
+// Parity is the parity bit of a UART character.
+type Parity int
+
+// Available parities.
+const (
+	NoParity Parity = iota
+	EvenParity
+	OddParity
+)
+
+// UARTOption configures the UART decoder.
+type UARTOption func(*uartConfig)
+
+type uartConfig struct {
+	dataBits int
+	parity   Parity
+	stopBits float64
+	order    BitOrder
+	inverted bool
+}
+
+func newUARTConfig(opts []UARTOption) uartConfig {
+	cfg := uartConfig{dataBits: 8, stopBits: 1, order: LSBFirst}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithDataBits sets the number of data bits of each character, which is
+// from 5 to 8. The default is 8.
+func WithDataBits(n int) UARTOption {
+	return func(cfg *uartConfig) {
+		cfg.dataBits = n
+	}
+}
+
+// WithParity sets the parity. The default is NoParity.
+func WithParity(p Parity) UARTOption {
+	return func(cfg *uartConfig) {
+		cfg.parity = p
+	}
+}
+
+// WithStopBits sets the number of stop bits, which is 1, 1.5, or 2. The
+// default is 1.
+func WithStopBits(n float64) UARTOption {
+	return func(cfg *uartConfig) {
+		cfg.stopBits = n
+	}
+}
+
+// WithUARTBitOrder sets the bit order. The default is LSBFirst.
+func WithUARTBitOrder(order BitOrder) UARTOption {
+	return func(cfg *uartConfig) {
+		cfg.order = order
+	}
+}
+
+// WithInverted sets the polarity to idle low, such as for a signal probed
+// on the RS-232 side of a line driver. The default is idle high.
+func WithInverted() UARTOption {
+	return func(cfg *uartConfig) {
+		cfg.inverted = true
+	}
+}
+
+// UART decodes the characters of the UART (RS-232) signal at the given baud
+// rate. Each character starts at the falling edge of its start bit, and its
+// bits are sampled in the middle of each bit time.
+func UART(rx Signal, baud float64, opts ...UARTOption) ([]Frame, error) {
+	cfg := newUARTConfig(opts)
+	if cfg.dataBits < 5 || cfg.dataBits > 8 {
+		return nil, fmt.Errorf("invalid number of data bits: %d", cfg.dataBits)
+	}
+	if cfg.stopBits != 1 && cfg.stopBits != 1.5 && cfg.stopBits != 2 {
+		return nil, fmt.Errorf("invalid number of stop bits: %g", cfg.stopBits)
+	}
+	if baud <= 0 {
+		return nil, fmt.Errorf("invalid baud rate: %g", baud)
+	}
+	if err := checkSignals(1, rx); err != nil {
+		return nil, err
+	}
+	bitTime := 1 / baud
+	if rx.XIncrement > bitTime/2 {
+		return nil, fmt.Errorf("sample interval %g s is too long for %g baud", rx.XIncrement, baud)
+	}
+	// mark returns whether the line is idle, which is a 1 bit.
+	mark := func(i int) bool {
+		return rx.Bits[i] != cfg.inverted
+	}
+	numBits := cfg.dataBits
+	if cfg.parity != NoParity {
+		numBits++
+	}
+	var frames []Frame
+	for i := 1; i < len(rx.Bits); i++ {
+		if !mark(i-1) || mark(i) {
+			continue
+		}
+		start := rx.Time(i) - rx.XIncrement/2
+		// sample returns the level in the middle of the given bit, where the
+		// start bit is 0, and false if it's past the end of the capture.
+		sample := func(bit int) (bool, bool) {
+			j := rx.index(start + (float64(bit)+0.5)*bitTime)
+			if j >= len(rx.Bits) {
+				return false, false
+			}
+			return mark(j), true
+		}
+		if v, ok := sample(0); ok && v {
+			// Ignore a glitch shorter than half a bit.
+			continue
+		}
+		frame := Frame{
+			Start: start,
+			End:   start + (1+float64(numBits)+cfg.stopBits)*bitTime,
+		}
+		bits := make([]bool, 0, numBits+1)
+		for b := 1; b <= numBits+1; b++ {
+			v, ok := sample(b)
+			if !ok {
+				frame.Errors |= IncompleteError
+				break
+			}
+			bits = append(bits, v)
+		}
+		if frame.Errors&IncompleteError != 0 {
+			frames = append(frames, frame)
+			break
+		}
+		data := bits[:cfg.dataBits]
+		frame.Data = []byte{assemble(data, cfg.order)}
+		if cfg.parity != NoParity {
+			ones := 0
+			for _, v := range bits[:numBits] {
+				if v {
+					ones++
+				}
+			}
+			if (ones%2 == 0) != (cfg.parity == EvenParity) {
+				frame.Errors |= ParityError
+			}
+		}
+		if !bits[numBits] {
+			frame.Errors |= FramingError
+		}
+		frames = append(frames, frame)
+		// Resume searching for a start bit from the middle of the stop bit.
+		i = max(i, rx.index(start+(float64(numBits)+1.5)*bitTime))
+	}
+	return frames, nil
+}
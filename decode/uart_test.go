@@ -0,0 +1,120 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package decode
+
+import "testing"
+
+// uartSignal returns the signal of the given characters sent with 8 data
+// bits, LSB first, and the given parity bit and stop bit levels, sampled 10
+// times per bit with two idle bits between characters.
+func uartSignal(chars []byte, parity func(byte) []bool, stop bool) Signal {
+	const samplesPerBit = 10
+	var bits []bool
+	level := func(v bool, n int) {
+		for i := 0; i < n*samplesPerBit; i++ {
+			bits = append(bits, v)
+		}
+	}
+	level(true, 2)
+	for _, c := range chars {
+		level(false, 1)
+		for b := 0; b < 8; b++ {
+			level(c&(1<<b) != 0, 1)
+		}
+		if parity != nil {
+			for _, v := range parity(c) {
+				level(v, 1)
+			}
+		}
+		level(stop, 1)
+		level(true, 2)
+	}
+	return Signal{XIncrement: 1 / (9600.0 * samplesPerBit), Bits: bits}
+}
+
+func evenParity(c byte) []bool {
+	ones := 0
+	for b := 0; b < 8; b++ {
+		if c&(1<<b) != 0 {
+			ones++
+		}
+	}
+	return []bool{ones%2 == 1}
+}
+
+func TestUART(t *testing.T) {
+	var tests = []struct {
+		name    string
+		rx      Signal
+		opts    []UARTOption
+		numBits int
+		errors  FrameError
+	}{
+		{"8N1", uartSignal([]byte("Hi!"), nil, true), nil, 10, 0},
+		{"8E1", uartSignal([]byte("Hi!"), evenParity, true), []UARTOption{WithParity(EvenParity)}, 11, 0},
+		{"parity error", uartSignal([]byte("Hi!"), evenParity, true), []UARTOption{WithParity(OddParity)}, 11, ParityError},
+		{"framing error", uartSignal([]byte("Hi!"), nil, false), nil, 10, FramingError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frames, err := UART(test.rx, 9600, test.opts...)
+			if err != nil {
+				t.Fatalf("received error decoding UART: %s", err)
+			}
+			assert(t, "num frames", len(frames), 3)
+			for i, c := range []byte("Hi!") {
+				assert(t, "char", frames[i].Data[0], c)
+				assert(t, "errors", frames[i].Errors, test.errors)
+			}
+			// The start is within a sample of the edge.
+			assertFloat64(t, "start", frames[0].Start, 2/9600.0, 1e-5)
+			assertFloat64(t, "length", frames[0].End-frames[0].Start, float64(test.numBits)/9600, 1e-9)
+		})
+	}
+}
+
+func TestUARTInvertedAndIncomplete(t *testing.T) {
+	rx := uartSignal([]byte{0xa5}, nil, true)
+	inverted := Signal{XIncrement: rx.XIncrement, Bits: make([]bool, len(rx.Bits))}
+	for i, v := range rx.Bits {
+		inverted.Bits[i] = !v
+	}
+	frames, err := UART(inverted, 9600, WithInverted())
+	if err != nil {
+		t.Fatalf("received error decoding UART: %s", err)
+	}
+	assert(t, "num frames", len(frames), 1)
+	assert(t, "inverted", frames[0].Data[0], byte(0xa5))
+
+	rx.Bits = rx.Bits[:60]
+	frames, err = UART(rx, 9600)
+	if err != nil {
+		t.Fatalf("received error decoding UART: %s", err)
+	}
+	assert(t, "num frames", len(frames), 1)
+	assert(t, "incomplete", frames[0].Errors, IncompleteError)
+}
+
+func TestUARTErrors(t *testing.T) {
+	rx := uartSignal([]byte("A"), nil, true)
+	var tests = []struct {
+		name string
+		baud float64
+		opts []UARTOption
+	}{
+		{"data bits", 9600, []UARTOption{WithDataBits(9)}},
+		{"stop bits", 9600, []UARTOption{WithStopBits(3)}},
+		{"baud", 0, nil},
+		{"undersampled", 96000, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := UART(rx, test.baud, test.opts...); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
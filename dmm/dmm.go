@@ -0,0 +1,345 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package dmm has the ability to parse the data log CSV files exported by the
+// Keysight 34401A and Truevolt (34460A/34461A/34465A/34470A) digital
+// multimeters and by the U1200 series handheld multimeters. Readings and data
+// logs can also be fetched from a live Truevolt multimeter using an
+// Instrument.
+package dmm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// Reading is a single logged reading. The Time is reconstructed from the
+// start time of the data log and the elapsed time of the reading.
+type Reading struct {
+	Number  int
+	Time    time.Time
+	Elapsed time.Duration
+	Value   float64
+}
+
+// MarshalJSON implements the json.Marshaler interface. JSON doesn't have
+// infinity, so the value of an overloaded reading is encoded as null.
+func (r Reading) MarshalJSON() ([]byte, error) {
+	type reading Reading
+	return json.Marshal(struct {
+		reading
+		Value *float64
+	}{reading(r), jsonValue(r.Value)})
+}
+
+// jsonValue returns the value to encode in JSON, which is nil for
+// overloaded and other non-finite values.
+func jsonValue(v float64) *float64 {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
+
+// DataLog contains the instrument metadata and readings of a data log.
+type DataLog struct {
+	Manufacturer    string
+	Model           string
+	SerialNum       string
+	FirmwareVersion string
+	Function        string
+	Units           string
+	Range           string
+	StartTime       time.Time
+	SampleInterval  time.Duration
+	// Header contains every header line keyed by its label.
+	Header   map[string][]string
+	Readings []Reading
+}
+
+// Values returns the value of every reading.
+func (d DataLog) Values() []float64 {
+	values := make([]float64, len(d.Readings))
+	for i, r := range d.Readings {
+		values[i] = r.Value
+	}
+	return values
+}
+
+var timestampLayouts = []string{
+	"01/02/2006 15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"02-Jan-2006 15:04:05.999999999",
+	"01/02/06 15:04:05.999999999",
+}
+
+// ReadCSVFile reads the data log CSV file with the given filename.
+func ReadCSVFile(filename string) (DataLog, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return DataLog{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads a data log CSV from the io.Reader. The header consists of an
+// optional identification line followed by "Label,value[,units]" lines. The
+// data starts after the column labels line, which must contain a column
+// labeled Value or Reading. Times without a time zone are parsed as UTC.
+func ReadCSV(r io.Reader) (DataLog, error) {
+	d := DataLog{Header: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	var cols dataColumns
+	found := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return d, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		if c, ok := findDataColumns(fields); ok {
+			cols = c
+			found = true
+			break
+		}
+		if err := d.parseHeader(fields); err != nil {
+			return d, fmt.Errorf("error parsing header line %d: %s", lineNum, err)
+		}
+	}
+	if !found {
+		if err := scanner.Err(); err != nil {
+			return d, err
+		}
+		return d, fmt.Errorf("missing data column labels")
+	}
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return d, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		reading, err := d.parseReading(fields, cols)
+		if err != nil {
+			return d, fmt.Errorf("error parsing reading in line %d: %s", lineNum, err)
+		}
+		d.Readings = append(d.Readings, reading)
+	}
+	return d, scanner.Err()
+}
+
+// parseHeader parses a single header line.
+func (d *DataLog) parseHeader(fields []string) error {
+	label := strings.TrimSuffix(fields[0], ":")
+	values := fields[1:]
+	d.Header[label] = values
+	value := ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	switch strings.ToLower(label) {
+	case "keysight technologies", "agilent technologies", "hewlett-packard":
+		// Identification line in the *IDN? format.
+		d.Manufacturer = fields[0]
+		if len(fields) == 4 {
+			d.Model, d.SerialNum, d.FirmwareVersion = fields[1], fields[2], fields[3]
+		}
+	case "model":
+		d.Model = value
+	case "serial number", "serial":
+		d.SerialNum = value
+	case "firmware", "firmware version":
+		d.FirmwareVersion = value
+	case "function":
+		d.Function = value
+	case "units", "unit":
+		d.Units = value
+	case "range":
+		d.Range = strings.Join(values, " ")
+	case "start time", "start":
+		t, err := parseTimestamp(strings.Join(values, " "))
+		if err != nil {
+			return err
+		}
+		d.StartTime = t
+	case "sample interval", "interval":
+		units := ""
+		if len(values) > 1 {
+			units = values[1]
+		}
+		interval, err := parseDuration(value, units)
+		if err != nil {
+			return fmt.Errorf("error parsing sample interval: %s", err)
+		}
+		d.SampleInterval = interval
+	}
+	return nil
+}
+
+// dataColumns contains the indices of the data columns. A negative index
+// means the column doesn't exist.
+type dataColumns struct {
+	number  int
+	elapsed int
+	time    int
+	value   int
+}
+
+// findDataColumns determines whether the fields are the column labels for the
+// readings and, if so, the column indices.
+func findDataColumns(fields []string) (dataColumns, bool) {
+	cols := dataColumns{-1, -1, -1, -1}
+	reading := -1
+	for i, field := range fields {
+		label := strings.ToLower(field)
+		switch {
+		case label == "reading #" || label == "#" || label == "sample" || label == "index":
+			cols.number = i
+		case label == "reading":
+			reading = i
+		case strings.HasPrefix(label, "value") || strings.HasPrefix(label, "reading ("):
+			cols.value = i
+		case strings.HasPrefix(label, "time (s)") || strings.HasPrefix(label, "elapsed"):
+			cols.elapsed = i
+		case strings.HasPrefix(label, "time") || strings.HasPrefix(label, "timestamp"):
+			cols.time = i
+		}
+	}
+	// A column labeled Reading contains the reading number if there is a
+	// separate value column and otherwise contains the values.
+	if reading >= 0 {
+		if cols.value < 0 {
+			cols.value = reading
+		} else if cols.number < 0 {
+			cols.number = reading
+		}
+	}
+	return cols, cols.value >= 0
+}
+
+// parseReading parses a data row and reconstructs the absolute time of the
+// reading.
+func (d *DataLog) parseReading(fields []string, cols dataColumns) (Reading, error) {
+	reading := Reading{Number: len(d.Readings) + 1}
+	get := func(i int) (string, error) {
+		if i >= len(fields) {
+			return "", fmt.Errorf("missing column %d", i+1)
+		}
+		return fields[i], nil
+	}
+	s, err := get(cols.value)
+	if err != nil {
+		return reading, err
+	}
+	reading.Value, err = parseValue(s)
+	if err != nil {
+		return reading, err
+	}
+	if cols.number >= 0 {
+		s, err := get(cols.number)
+		if err != nil {
+			return reading, err
+		}
+		if reading.Number, err = strconv.Atoi(s); err != nil {
+			return reading, err
+		}
+	}
+	switch {
+	case cols.elapsed >= 0:
+		s, err := get(cols.elapsed)
+		if err != nil {
+			return reading, err
+		}
+		reading.Elapsed, err = parseDuration(s, "s")
+		if err != nil {
+			return reading, err
+		}
+		reading.Time = d.StartTime.Add(reading.Elapsed)
+	case cols.time >= 0:
+		s, err := get(cols.time)
+		if err != nil {
+			return reading, err
+		}
+		reading.Time, err = parseTimestamp(s)
+		if err != nil {
+			return reading, err
+		}
+		if d.StartTime.IsZero() && len(d.Readings) == 0 {
+			d.StartTime = reading.Time
+		}
+		reading.Elapsed = reading.Time.Sub(d.StartTime)
+	default:
+		reading.Elapsed = time.Duration(reading.Number-1) * d.SampleInterval
+		reading.Time = d.StartTime.Add(reading.Elapsed)
+	}
+	return reading, nil
+}
+
+// parseValue parses a reading. The overload value of 9.9E+37 is returned as
+// positive or negative infinity.
+func parseValue(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.Abs(v) >= 9.9e37 {
+		return math.Inf(int(math.Copysign(1, v))), nil
+	}
+	return v, nil
+}
+
+var durationUnits = map[string]time.Duration{
+	"":    time.Second,
+	"s":   time.Second,
+	"sec": time.Second,
+	"ms":  time.Millisecond,
+	"us":  time.Microsecond,
+	"min": time.Minute,
+	"h":   time.Hour,
+}
+
+// parseDuration parses a duration given as a number and units.
+func parseDuration(value, units string) (time.Duration, error) {
+	mult, ok := durationUnits[strings.ToLower(strings.TrimSpace(units))]
+	if !ok {
+		return 0, fmt.Errorf("unknown time units: %s", units)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(math.Round(v * float64(mult))), nil
+}
+
+// parseTimestamp parses a timestamp using the layouts used by the different
+// multimeters.
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown timestamp format: %s", s)
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package dmm
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	var tests = []struct {
+		filename       string
+		model          string
+		serialNum      string
+		function       string
+		units          string
+		startTime      time.Time
+		sampleInterval time.Duration
+		numReadings    int
+		index          int
+		reading        Reading
+	}{
+		{
+			filename:       "./testdata/34465a_datalog.csv",
+			model:          "34465A",
+			serialNum:      "MY57500123",
+			function:       "DC Voltage",
+			units:          "VDC",
+			startTime:      time.Date(2024, time.March, 14, 10, 21, 7, 250000000, time.UTC),
+			sampleInterval: 500 * time.Millisecond,
+			numReadings:    5,
+			index:          2,
+			reading: Reading{
+				Number:  3,
+				Time:    time.Date(2024, time.March, 14, 10, 21, 8, 250000000, time.UTC),
+				Elapsed: time.Second,
+				Value:   1.234598,
+			},
+		},
+		{
+			filename:       "./testdata/34401a_benchvue.csv",
+			model:          "34401A",
+			serialNum:      "MY41012345",
+			function:       "Resistance",
+			units:          "Ohm",
+			startTime:      time.Date(2024, time.March, 14, 10, 21, 7, 0, time.UTC),
+			sampleInterval: time.Second,
+			numReadings:    3,
+			index:          2,
+			reading: Reading{
+				Number:  3,
+				Time:    time.Date(2024, time.March, 14, 10, 21, 9, 0, time.UTC),
+				Elapsed: 2 * time.Second,
+				Value:   1000.121,
+			},
+		},
+	}
+	for _, test := range tests {
+		d, err := ReadCSVFile(test.filename)
+		if err != nil {
+			t.Errorf("error reading %s: %s", test.filename, err)
+			continue
+		}
+		assert(t, "model", d.Model, test.model)
+		assert(t, "serial number", d.SerialNum, test.serialNum)
+		assert(t, "function", d.Function, test.function)
+		assert(t, "units", d.Units, test.units)
+		assert(t, "start time", d.StartTime, test.startTime)
+		assert(t, "sample interval", d.SampleInterval, test.sampleInterval)
+		assert(t, "num readings", len(d.Readings), test.numReadings)
+		got := d.Readings[test.index]
+		assert(t, "reading number", got.Number, test.reading.Number)
+		assert(t, "reading time", got.Time, test.reading.Time)
+		assert(t, "reading elapsed", got.Elapsed, test.reading.Elapsed)
+		assertFloat64(t, "reading value", got.Value, test.reading.Value, 1e-9)
+	}
+}
+
+func TestOverload(t *testing.T) {
+	d, err := ReadCSVFile("./testdata/34465a_datalog.csv")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "overload", math.IsInf(d.Readings[3].Value, 1), true)
+	assert(t, "firmware", d.FirmwareVersion, "A.02.14-02.40-02.14-00.49-03-01")
+	assert(t, "range", d.Range, "10 V")
+	assert(t, "num values", len(d.Values()), 5)
+}
+
+func TestMarshalJSON(t *testing.T) {
+	var tests = []struct {
+		v    interface{}
+		want string
+	}{
+		{Reading{Number: 1, Value: 1.5}, `{"Number":1,"Time":"0001-01-01T00:00:00Z","Elapsed":0,"Value":1.5}`},
+		{Reading{Number: 2, Value: math.Inf(1)}, `{"Number":2,"Time":"0001-01-01T00:00:00Z","Elapsed":0,"Value":null}`},
+		{Display{Value: 0.25, Units: "V"}, `{"Units":"V","Mode":"","Overload":false,"Value":0.25}`},
+		{Display{Value: math.Inf(-1), Units: "V", Overload: true}, `{"Units":"V","Mode":"","Overload":true,"Value":null}`},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(test.v)
+		if err != nil {
+			t.Fatalf("error encoding %v: %s", test.v, err)
+		}
+		assert(t, "json", string(got), test.want)
+	}
+}
+
+func TestReadCSVSampleInterval(t *testing.T) {
+	data := `Start Time,2024-03-14 08:00:00
+Sample Interval,0.25,s
+Reading
+1.5
+1.6
+1.7`
+	d, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("error reading data: %s", err)
+	}
+	assert(t, "num readings", len(d.Readings), 3)
+	assert(t, "reading number", d.Readings[2].Number, 3)
+	assert(t, "reading time", d.Readings[2].Time, time.Date(2024, time.March, 14, 8, 0, 0, 500000000, time.UTC))
+	assertFloat64(t, "reading value", d.Readings[2].Value, 1.7, 1e-9)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing labels", "Function,DC Voltage\n"},
+		{"invalid start time", "Start Time,yesterday\nReading\n1\n"},
+		{"invalid interval units", "Sample Interval,1,fortnights\nReading\n1\n"},
+		{"invalid value", "Reading\nabc\n"},
+		{"missing column", "Reading #,Time (s),Value\n1,0.0\n"},
+	}
+	for _, test := range tests {
+		if _, err := ReadCSV(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/34*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package dmm
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadHandheldCSVFile(t *testing.T) {
+	l, err := ReadHandheldCSVFile("./testdata/u1282a_log.csv")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "model", l.Model, "U1282A")
+	assert(t, "serial number", l.SerialNum, "MY59123456")
+	assert(t, "start time", l.StartTime, time.Date(2024, time.March, 14, 10, 21, 7, 0, time.UTC))
+	assert(t, "num readings", len(l.Readings), 5)
+
+	first := l.Readings[0]
+	assert(t, "primary", first.Primary, Display{Value: 1.2345, Units: "V", Mode: "DC"})
+	if first.Secondary == nil {
+		t.Fatalf("missing secondary display")
+	}
+	assert(t, "secondary", *first.Secondary, Display{Value: 50.01, Units: "Hz", Mode: "Frequency"})
+	assert(t, "first relative", first.Relative, false)
+
+	rel := l.Readings[2]
+	assert(t, "relative", rel.Relative, true)
+	assert(t, "relative secondary", rel.Secondary == nil, true)
+	assert(t, "relative flags", strings.Join(rel.Flags, " "), "AUTO REL")
+
+	ol := l.Readings[3]
+	assert(t, "overload", ol.Primary.Overload, true)
+	assert(t, "overload value", math.IsInf(ol.Primary.Value, 1), true)
+	assert(t, "hold", l.Readings[4].Hold, true)
+	assert(t, "time", l.Readings[4].Time, time.Date(2024, time.March, 14, 10, 21, 11, 0, time.UTC))
+
+	segments := l.Segments()
+	assert(t, "num segments", len(segments), 2)
+	assert(t, "first segment mode", segments[0].Mode, "DC")
+	assert(t, "first segment length", len(segments[0].Readings), 3)
+	assert(t, "second segment units", segments[1].Units, "Ohm")
+}
+
+func TestReadHandheldModeLines(t *testing.T) {
+	l, err := ReadHandheldCSVFile("./testdata/u1233a_mode_lines.csv")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "model", l.Model, "U1233A")
+	assert(t, "num readings", len(l.Readings), 3)
+	assert(t, "first reading", l.Readings[0].Primary, Display{Value: 120.1, Units: "V", Mode: "AC V"})
+	assert(t, "last reading", l.Readings[2].Primary, Display{Value: 1.25, Units: "A", Mode: "AC A"})
+	segments := l.Segments()
+	assert(t, "num segments", len(segments), 2)
+	assert(t, "second segment mode", segments[1].Mode, "AC A")
+}
+
+func TestReadHandheldCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing labels", "Model,U1282A\n"},
+		{"invalid number", "No.,Primary\nfirst,1.0\n"},
+		{"missing primary", "No.,Primary\n1,\n"},
+		{"invalid primary", "No.,Primary\n1,abc\n"},
+		{"invalid secondary", "No.,Primary,Secondary\n1,1.0,abc\n"},
+		{"invalid time", "No.,Time,Primary\n1,yesterday,1.0\n"},
+	}
+	for _, test := range tests {
+		if _, err := ReadHandheldCSV(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
+
+func FuzzReadHandheldCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/u1*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadHandheldCSV(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package dmm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpi"
+)
+
+// Instrument is a live Truevolt digital multimeter, such as the 34465A,
+// controlled using SCPI commands sent over an io.ReadWriter, such as a
+// connection from the gotmc visa, usbtmc, or vxi11 packages.
+type Instrument struct {
+	conn *scpi.Conn
+	// now returns the time used as the start of a stream.
+	now func() time.Time
+	// poll is the time between checks for new readings while streaming.
+	poll time.Duration
+}
+
+// NewInstrument returns a digital multimeter using the given connection,
+// which must terminate each response with a newline.
+func NewInstrument(rw io.ReadWriter) *Instrument {
+	return &Instrument{conn: scpi.New(rw), now: time.Now, poll: 100 * time.Millisecond}
+}
+
+// Function is a measurement function of the multimeter given as the SCPI
+// mnemonic used with the CONFigure and MEASure commands.
+type Function string
+
+// Available measurement functions.
+const (
+	DCVoltage          Function = "VOLT:DC"
+	ACVoltage          Function = "VOLT:AC"
+	DCCurrent          Function = "CURR:DC"
+	ACCurrent          Function = "CURR:AC"
+	Resistance         Function = "RES"
+	FourWireResistance Function = "FRES"
+	Frequency          Function = "FREQ"
+	Period             Function = "PER"
+	Capacitance        Function = "CAP"
+	Temperature        Function = "TEMP"
+	Diode              Function = "DIOD"
+	Continuity         Function = "CONT"
+)
+
+// Configure sets the measurement function and range without taking a
+// reading. A range of zero selects autoranging. The diode, continuity,
+// frequency, period, and temperature functions ignore the range.
+func (inst *Instrument) Configure(fn Function, rng float64) error {
+	switch {
+	case !hasRange(fn):
+		return inst.conn.Command("CONF:%s", fn)
+	case rng == 0:
+		return inst.conn.Command("CONF:%s AUTO", fn)
+	}
+	return inst.conn.Command("CONF:%s %g", fn, rng)
+}
+
+// Measure configures the measurement function using autoranging and returns
+// a single reading.
+func (inst *Instrument) Measure(fn Function) (float64, error) {
+	return inst.conn.QueryFloat(fmt.Sprintf("MEAS:%s?", fn))
+}
+
+// Read takes a single reading using the present configuration.
+func (inst *Instrument) Read() (float64, error) {
+	return inst.conn.QueryFloat("READ?")
+}
+
+// Stream takes readings every interval using the present configuration and
+// sends them to the channel until the context is canceled, when the
+// measurement is aborted, the channel is closed, and nil is returned. The
+// readings are numbered from 1, and their times are reconstructed from the
+// start of the stream and the sample interval, like the readings of a data
+// log. Readings are removed from the reading memory as they're sent, so the
+// stream can run indefinitely.
+func (inst *Instrument) Stream(ctx context.Context, interval time.Duration, readings chan<- Reading) error {
+	defer close(readings)
+	for _, cmd := range []string{
+		"TRIG:SOUR IMM",
+		"TRIG:COUN 1",
+		"SAMP:SOUR TIM",
+		fmt.Sprintf("SAMP:TIM %g", interval.Seconds()),
+		"SAMP:COUN MAX",
+		"INIT",
+	} {
+		if err := inst.conn.Command("%s", cmd); err != nil {
+			return err
+		}
+	}
+	start := inst.now().UTC()
+	num := 0
+	for {
+		values, err := inst.removeReadings()
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			num++
+			elapsed := time.Duration(num-1) * interval
+			r := Reading{Number: num, Time: start.Add(elapsed), Elapsed: elapsed, Value: v}
+			select {
+			case readings <- r:
+			case <-ctx.Done():
+				return inst.conn.Command("ABOR")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return inst.conn.Command("ABOR")
+		case <-time.After(inst.poll):
+		}
+	}
+}
+
+// removeReadings reads and removes every reading in the reading memory,
+// which are returned as a block of comma separated values.
+func (inst *Instrument) removeReadings() ([]float64, error) {
+	data, err := inst.conn.QueryBlock("R?")
+	if err != nil {
+		return nil, err
+	}
+	s := strings.TrimSpace(string(data))
+	if s == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		if values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+			return nil, fmt.Errorf("error parsing reading %d: %s", i+1, err)
+		}
+	}
+	return values, nil
+}
+
+// DataLog transfers the data log CSV file with the given name, such as
+// `INT:\DataLog\dmmlog.csv`, from the multimeter's mass memory and parses it
+// like ReadCSV.
+func (inst *Instrument) DataLog(filename string) (DataLog, error) {
+	data, err := inst.conn.QueryBlock(fmt.Sprintf(`MMEM:UPL? "%s"`, filename))
+	if err != nil {
+		return DataLog{}, err
+	}
+	return ReadCSV(bytes.NewReader(data))
+}
+
+// hasRange returns whether the measurement function accepts a range.
+func hasRange(fn Function) bool {
+	switch fn {
+	case Diode, Continuity, Frequency, Period, Temperature:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package dmm
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpitest"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+func TestInstrumentMeasure(t *testing.T) {
+	fake := scpitest.New(map[string]string{
+		"MEAS:VOLT:DC?": "+1.23456789E+00",
+		"READ?":         "+9.90000000E+37",
+	})
+	inst := NewInstrument(fake)
+	v, err := inst.Measure(DCVoltage)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "measure", v, 1.23456789)
+	if v, err = inst.Read(); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "overload", v, 9.9e37)
+	for _, err := range []error{
+		inst.Configure(DCVoltage, 10),
+		inst.Configure(Resistance, 0),
+		inst.Configure(Frequency, 10),
+	} {
+		if err != nil {
+			t.Fatalf("received error: %s", err)
+		}
+	}
+	assert(t, "commands", strings.Join(fake.Commands[2:], ";"), "CONF:VOLT:DC 10;CONF:RES AUTO;CONF:FREQ")
+}
+
+func TestInstrumentStream(t *testing.T) {
+	fake := scpitest.New(map[string]string{"R?": "#10"}, scpitest.WithQueued(map[string][]string{"R?": {
+		string(block.Encode([]byte("+1.0E+00,+2.0E+00"))),
+		"#10",
+		string(block.Encode([]byte("+3.0E+00"))),
+	}}))
+	inst := NewInstrument(fake)
+	start := time.Date(2024, time.March, 14, 10, 21, 7, 0, time.UTC)
+	inst.now = func() time.Time { return start }
+	inst.poll = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	readings := make(chan Reading)
+	errc := make(chan error, 1)
+	go func() { errc <- inst.Stream(ctx, 500*time.Millisecond, readings) }()
+	for i, want := range []float64{1, 2, 3} {
+		r := <-readings
+		assert(t, "number", r.Number, i+1)
+		assert(t, "value", r.Value, want)
+		assert(t, "elapsed", r.Elapsed, time.Duration(i)*500*time.Millisecond)
+		assert(t, "time", r.Time, start.Add(r.Elapsed))
+	}
+	cancel()
+	for range readings {
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "sample interval", fake.Commands[3], "SAMP:TIM 0.5")
+	assert(t, "abort", fake.Commands[len(fake.Commands)-1], "ABOR")
+}
+
+func TestInstrumentStreamError(t *testing.T) {
+	fake := scpitest.New(map[string]string{"R?": string(block.Encode([]byte("+1.0E+00,bad")))})
+	readings := make(chan Reading, 2)
+	if err := NewInstrument(fake).Stream(context.Background(), time.Second, readings); err == nil {
+		t.Errorf("expected error for invalid reading")
+	}
+	if _, ok := <-readings; ok {
+		t.Errorf("expected closed channel")
+	}
+}
+
+func TestInstrumentDataLog(t *testing.T) {
+	data, err := os.ReadFile("testdata/34465a_datalog.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := scpitest.New(map[string]string{
+		`MMEM:UPL? "INT:\DataLog\dmmlog.csv"`: string(block.Encode(data)),
+	})
+	log, err := NewInstrument(fake).DataLog(`INT:\DataLog\dmmlog.csv`)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "model", log.Model, "34465A")
+	assert(t, "readings", len(log.Readings), 5)
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package emc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+// cisprBand is a CISPR 16-1-1 frequency band with the measurement bandwidth
+// and the quasi-peak pulse response of the detector.
+type cisprBand struct {
+	name  string
+	start float64
+	stop  float64
+	// bandwidth is the 6 dB measurement bandwidth, which is used as the
+	// impulse bandwidth.
+	bandwidth float64
+	// impulseArea is the area in V·s of the reference pulse that reads 60
+	// dBµV on the quasi-peak detector at the reference repetition frequency.
+	impulseArea float64
+	// response is the relative level in dB of a pulse at each repetition
+	// frequency giving the same quasi-peak reading as the reference pulse.
+	// The last entry is the isolated pulse.
+	response []pulseResponse
+}
+
+type pulseResponse struct {
+	prf   float64
+	level float64
+}
+
+var cisprBands = []cisprBand{
+	{"A", 9e3, 150e3, 200, 13.5e-6, []pulseResponse{
+		{25, 0}, {2, 4}, {1, 5}, {0, 6},
+	}},
+	{"B", 150e3, 30e6, 9e3, 0.316e-6, []pulseResponse{
+		{1000, -4.5}, {100, 0}, {20, 6.5}, {10, 10}, {2, 20.5}, {1, 22.5}, {0, 23.5},
+	}},
+	{"C/D", 30e6, 1e9, 120e3, 0.044e-6, []pulseResponse{
+		{1000, -8}, {100, 0}, {20, 9}, {10, 14}, {2, 26}, {1, 28.5}, {0, 31.5},
+	}},
+	{"E", 1e9, 18e9, 1e6, 0, nil},
+}
+
+func findCISPRBand(freq float64) (cisprBand, error) {
+	for _, b := range cisprBands {
+		if freq >= b.start && freq <= b.stop {
+			return b, nil
+		}
+	}
+	return cisprBand{}, fmt.Errorf("frequency %g Hz is outside the CISPR bands", freq)
+}
+
+// QuasiPeakCorrection returns the amount in dB to add to the peak detected
+// level of a periodic pulse with the given pulse repetition frequency (PRF)
+// in Hz to estimate the quasi-peak reading. A PRF of zero is a narrowband
+// (CW) signal, which reads the same on both detectors.
+//
+// The estimate uses the CISPR 16-1-1 pulse response of the quasi-peak
+// detector, interpolated on a log PRF scale, along with the peak reading of
+// the reference pulse in the measurement bandwidth. It's only valid for
+// regular pulses whose spectrum is broadband compared to the measurement
+// bandwidth, and can't account for modulated, intermittent, or overlapping
+// signals, so final measurements still need a real quasi-peak detector.
+// Quasi-peak isn't defined above 1 GHz.
+func QuasiPeakCorrection(freq, prf float64) (float64, error) {
+	band, err := findCISPRBand(freq)
+	if err != nil {
+		return 0, err
+	}
+	if band.response == nil {
+		return 0, fmt.Errorf("quasi-peak isn't defined at %g Hz", freq)
+	}
+	if prf < 0 {
+		return 0, fmt.Errorf("invalid prf: %g", prf)
+	}
+	if prf == 0 || prf >= band.bandwidth {
+		return 0, nil
+	}
+	// The peak reading of the reference pulse is the RMS value of a sine wave
+	// with the same peak as the pulse response of the bandwidth.
+	peak := 20 * math.Log10(math.Sqrt2*band.impulseArea*band.bandwidth/1e-6)
+	below := peak - 60 + band.responseAt(prf)
+	return -math.Max(below, 0), nil
+}
+
+// responseAt returns the relative pulse response at the PRF. Above the
+// highest PRF in the table, the response is interpolated to where the
+// quasi-peak reading reaches the peak reading at the measurement bandwidth.
+func (b cisprBand) responseAt(prf float64) float64 {
+	r := b.response
+	if prf >= r[0].prf {
+		peak := 20 * math.Log10(math.Sqrt2*b.impulseArea*b.bandwidth/1e-6)
+		end := 60 - peak
+		frac := math.Log(prf/r[0].prf) / math.Log(b.bandwidth/r[0].prf)
+		return r[0].level + frac*(end-r[0].level)
+	}
+	for i := 1; i < len(r); i++ {
+		if r[i].prf == 0 {
+			// Below 1 Hz, treat pulses as isolated once the PRF drops a
+			// decade below the last tabulated rate.
+			lo := r[i-1].prf / 10
+			if prf <= lo {
+				return r[i].level
+			}
+			frac := math.Log(r[i-1].prf/prf) / math.Log(r[i-1].prf/lo)
+			return r[i-1].level + frac*(r[i].level-r[i-1].level)
+		}
+		if prf >= r[i].prf {
+			frac := math.Log(r[i-1].prf/prf) / math.Log(r[i-1].prf/r[i].prf)
+			return r[i-1].level + frac*(r[i].level-r[i-1].level)
+		}
+	}
+	return r[len(r)-1].level
+}
+
+// AverageCorrection returns the amount in dB to add to the peak detected
+// level of a periodic pulse with the given PRF in Hz to estimate the CISPR
+// average reading. A PRF of zero is a narrowband (CW) signal.
+//
+// The average of the pulse response is the peak scaled by the ratio of the
+// PRF to the impulse bandwidth. This ignores the meter time constant of the
+// CISPR average detector, which reads higher than the estimate for PRFs
+// below about 10 Hz.
+func AverageCorrection(freq, prf float64) (float64, error) {
+	band, err := findCISPRBand(freq)
+	if err != nil {
+		return 0, err
+	}
+	if prf < 0 {
+		return 0, fmt.Errorf("invalid prf: %g", prf)
+	}
+	if prf == 0 || prf >= band.bandwidth {
+		return 0, nil
+	}
+	return 20 * math.Log10(prf/band.bandwidth), nil
+}
+
+// EstimateQuasiPeak returns a copy of the peak detected trace with the
+// quasi-peak correction for the PRF applied to each trace. See
+// QuasiPeakCorrection for the limits of the approximation.
+func EstimateQuasiPeak(trace esa.Trace, prf float64) (esa.Trace, error) {
+	return correctTrace(trace, prf, QuasiPeakCorrection)
+}
+
+// EstimateAverage returns a copy of the peak detected trace with the CISPR
+// average correction for the PRF applied to each trace. See
+// AverageCorrection for the limits of the approximation.
+func EstimateAverage(trace esa.Trace, prf float64) (esa.Trace, error) {
+	return correctTrace(trace, prf, AverageCorrection)
+}
+
+func correctTrace(trace esa.Trace, prf float64, correction func(freq, prf float64) (float64, error)) (esa.Trace, error) {
+	offsets := make([]float64, len(trace.Frequency))
+	for i, freq := range trace.Frequency {
+		offset, err := correction(freq, prf)
+		if err != nil {
+			return trace, err
+		}
+		offsets[i] = offset
+	}
+	apply := func(values []float64) ([]float64, error) {
+		if len(values) == 0 {
+			return nil, nil
+		}
+		if len(values) != len(offsets) {
+			return nil, fmt.Errorf("mismatched lengths / freq %d / trace %d", len(offsets), len(values))
+		}
+		corrected := make([]float64, len(values))
+		for i, v := range values {
+			corrected[i] = v + offsets[i]
+		}
+		return corrected, nil
+	}
+	traces := trace.Traces()
+	for i, t := range traces {
+		var err error
+		if traces[i].Values, err = apply(t.Values); err != nil {
+			return trace, err
+		}
+	}
+	trace.SetTraces(traces)
+	trace.Frequency = append([]float64(nil), trace.Frequency...)
+	return trace, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package emc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+func TestQuasiPeakCorrection(t *testing.T) {
+	peakB := 20 * math.Log10(math.Sqrt2*0.316*9e3)
+	peakCD := 20 * math.Log10(math.Sqrt2*0.044*120e3)
+	var tests = []struct {
+		name string
+		freq float64
+		prf  float64
+		want float64
+	}{
+		{"cw", 1e6, 0, 0},
+		{"band B reference", 1e6, 100, 60 - peakB},
+		{"band B 1 kHz", 1e6, 1000, 60 - peakB + 4.5},
+		{"band B 10 Hz", 1e6, 10, 60 - peakB - 10},
+		{"band B between", 1e6, math.Sqrt(10 * 20), 60 - peakB - 8.25},
+		{"band B isolated", 1e6, 0.01, 60 - peakB - 23.5},
+		{"band C/D reference", 100e6, 100, 60 - peakCD},
+		{"band C/D above bandwidth", 100e6, 200e3, 0},
+		{"band C/D at bandwidth", 100e6, 120e3, 0},
+	}
+	for _, test := range tests {
+		got, err := QuasiPeakCorrection(test.freq, test.prf)
+		if err != nil {
+			t.Errorf("received error for %s: %s", test.name, err)
+			continue
+		}
+		assertFloat64(t, test.name, got, test.want, 1e-9)
+	}
+	// The correction approaches zero as the PRF approaches the bandwidth.
+	prev := math.Inf(-1)
+	for _, prf := range []float64{100, 1e3, 3e3, 8e3, 9e3} {
+		got, _ := QuasiPeakCorrection(1e6, prf)
+		if got < prev || got > 0 {
+			t.Errorf("correction at %g Hz prf = %g after %g", prf, got, prev)
+		}
+		prev = got
+	}
+	if _, err := QuasiPeakCorrection(2e9, 100); err == nil {
+		t.Errorf("expected error for quasi-peak above 1 GHz")
+	}
+	if _, err := QuasiPeakCorrection(1e3, 100); err == nil {
+		t.Errorf("expected error below 9 kHz")
+	}
+	if _, err := QuasiPeakCorrection(1e6, -1); err == nil {
+		t.Errorf("expected error for negative prf")
+	}
+}
+
+func TestAverageCorrection(t *testing.T) {
+	got, err := AverageCorrection(1e6, 100)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "band B", got, 20*math.Log10(100/9e3), 1e-9)
+	got, _ = AverageCorrection(2e9, 1e4)
+	assertFloat64(t, "band E", got, -40, 1e-9)
+	got, _ = AverageCorrection(2e9, 0)
+	assertFloat64(t, "cw", got, 0, 1e-9)
+}
+
+func TestEstimateDetectors(t *testing.T) {
+	trace := esa.Trace{
+		Frequency: []float64{1e6, 100e6},
+		Trace1:    []float64{80, 70},
+		Trace2:    []float64{60, 50},
+	}
+	qp, err := EstimateQuasiPeak(trace, 100)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	correction, _ := QuasiPeakCorrection(100e6, 100)
+	assertFloat64(t, "trace 1", qp.Trace1[1], 70+correction, 1e-9)
+	assert(t, "trace 3", len(qp.Trace3), 0)
+	assertFloat64(t, "original unchanged", trace.Trace1[1], 70, 1e-9)
+
+	avg, err := EstimateAverage(trace, 0)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertFloat64(t, "cw average", avg.Trace2[0], 60, 1e-9)
+
+	trace.Frequency = append(trace.Frequency, 2e9)
+	if _, err := EstimateQuasiPeak(trace, 100); err == nil {
+		t.Errorf("expected error for quasi-peak above 1 GHz")
+	}
+}
+
+func TestEstimateDetectorsExtraTraces(t *testing.T) {
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_five_traces.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	qp, err := EstimateQuasiPeak(trace, 100)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	correction, _ := QuasiPeakCorrection(9e3, 100)
+	traces := qp.Traces()
+	assert(t, "num traces", len(traces), 5)
+	assertFloat64(t, "trace 5", traces[4].Values[0], 38.123+correction, 1e-9)
+	assert(t, "trace 5 label", traces[4].Label, "Trace 5")
+	assertFloat64(t, "original unchanged", trace.ExtraTraces[1].Values[0], 38.123, 1e-9)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package emc generates EMC pre-compliance reports from ESA traces. The
+// traces are corrected using the given correction factors, such as the LISN,
+// cable, and antenna factors, and compared against a limit line. The report
+// lists the worst emission within each frequency band along with its margin
+// to the limit.
+//
+// The package also provides the standard CISPR and FCC limit lines and
+// estimates of the quasi-peak and average detector readings from peak
+// detected pre-scans.
+package emc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+// Band is a frequency range of the report in Hz.
+type Band struct {
+	Name  string
+	Start float64
+	Stop  float64
+}
+
+// Contains reports whether the frequency is within the band.
+func (b Band) Contains(freq float64) bool {
+	return freq >= b.Start && freq <= b.Stop
+}
+
+// Emission is the worst emission within a band.
+type Emission struct {
+	Band Band
+	// Trace is the index of the trace containing the emission.
+	Trace     int
+	Frequency float64
+	// Level is the corrected amplitude of the emission.
+	Level float64
+	Limit float64
+	// Margin is the distance in dB from the emission to the limit, which is
+	// negative if the emission fails the limit.
+	Margin float64
+	Pass   bool
+}
+
+// Report is the result of comparing the traces against the limit line.
+type Report struct {
+	Limit     esa.LimitLine
+	Emissions []Emission
+	// Pass reports whether every emission passes the limit.
+	Pass bool
+	// WorstMargin is the smallest margin of the emissions.
+	WorstMargin float64
+}
+
+// Option configures the report.
+type Option func(*config)
+
+type config struct {
+	bands []Band
+	trace int
+}
+
+// WithBands sets the frequency bands of the report. By default, each
+// segment between two connected points of the limit line is a band.
+func WithBands(bands ...Band) Option {
+	return func(cfg *config) {
+		cfg.bands = bands
+	}
+}
+
+// WithTrace sets the ESA trace, numbered from 1, to evaluate. The default is
+// Trace 1.
+func WithTrace(n int) Option {
+	return func(cfg *config) {
+		cfg.trace = n
+	}
+}
+
+// NewReport applies the corrections to each trace and finds the worst
+// emission of all the traces within each band. Bands without any trace
+// points within the limit line are left out of the report.
+func NewReport(traces []esa.Trace, limit esa.LimitLine, corrections []esa.Correction, opts ...Option) (Report, error) {
+	cfg := config{trace: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if len(traces) == 0 {
+		return Report{}, fmt.Errorf("no traces given")
+	}
+	if len(limit.Points) == 0 {
+		return Report{}, fmt.Errorf("limit line doesn't have any points")
+	}
+	bands := cfg.bands
+	if len(bands) == 0 {
+		bands = limitBands(limit)
+	}
+	worst := make([]*Emission, len(bands))
+	for i, trace := range traces {
+		var err error
+		for _, corr := range corrections {
+			if trace, err = esa.ApplyCorrection(trace, corr); err != nil {
+				return Report{}, fmt.Errorf("error correcting trace %d: %s", i, err)
+			}
+		}
+		values, err := traceValues(trace, cfg.trace)
+		if err != nil {
+			return Report{}, fmt.Errorf("error in trace %d: %s", i, err)
+		}
+		for j, freq := range trace.Frequency {
+			lim, ok := limit.At(freq)
+			if !ok {
+				continue
+			}
+			margin := lim - values[j]
+			if limit.Type == esa.LowerLimit {
+				margin = values[j] - lim
+			}
+			for k, band := range bands {
+				if !band.Contains(freq) || (worst[k] != nil && worst[k].Margin <= margin) {
+					continue
+				}
+				worst[k] = &Emission{
+					Band:      band,
+					Trace:     i,
+					Frequency: freq,
+					Level:     values[j],
+					Limit:     lim,
+					Margin:    margin,
+					Pass:      margin >= 0,
+				}
+			}
+		}
+	}
+	report := Report{Limit: limit, Pass: true, WorstMargin: math.NaN()}
+	for _, e := range worst {
+		if e == nil {
+			continue
+		}
+		report.Emissions = append(report.Emissions, *e)
+		report.Pass = report.Pass && e.Pass
+		if math.IsNaN(report.WorstMargin) || e.Margin < report.WorstMargin {
+			report.WorstMargin = e.Margin
+		}
+	}
+	return report, nil
+}
+
+// limitBands returns a band for each segment of the limit line.
+func limitBands(limit esa.LimitLine) []Band {
+	var bands []Band
+	for i := 1; i < len(limit.Points); i++ {
+		a, b := limit.Points[i-1], limit.Points[i]
+		if b.Disconnected || b.Frequency <= a.Frequency {
+			continue
+		}
+		bands = append(bands, Band{
+			Name:  formatFreq(a.Frequency) + " - " + formatFreq(b.Frequency),
+			Start: a.Frequency,
+			Stop:  b.Frequency,
+		})
+	}
+	return bands
+}
+
+func traceValues(trace esa.Trace, n int) ([]float64, error) {
+	traces := trace.Traces()
+	if n < 1 || n > len(traces) {
+		return nil, fmt.Errorf("invalid trace number: %d", n)
+	}
+	values := traces[n-1].Values
+	if len(values) != len(trace.Frequency) {
+		return nil, fmt.Errorf("mismatched lengths / freq %d / trace %d %d", len(trace.Frequency), n, len(values))
+	}
+	return values, nil
+}
+
+// formatFreq formats the frequency in Hz using the largest unit that keeps
+// the value at least 1, such as 150 kHz.
+func formatFreq(freq float64) string {
+	units := []struct {
+		name  string
+		scale float64
+	}{{"GHz", 1e9}, {"MHz", 1e6}, {"kHz", 1e3}}
+	for _, u := range units {
+		if math.Abs(freq) >= u.scale {
+			return strconv.FormatFloat(freq/u.scale, 'f', -1, 64) + " " + u.name
+		}
+	}
+	return strconv.FormatFloat(freq, 'f', -1, 64) + " Hz"
+}
+
+// WriteCSVFile writes the report table to the given filename.
+func (r Report) WriteCSVFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSV writes the report as a table with a line for each band followed
+// by the overall result.
+func (r Report) WriteCSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	units := string(r.Limit.Units)
+	fmt.Fprintf(bw, "Band,Trace,Frequency (Hz),Level (%s),Limit (%s),Margin (dB),Result\n", units, units)
+	for _, e := range r.Emissions {
+		fmt.Fprintf(bw, "%s,%d,%s,%.2f,%.2f,%.2f,%s\n",
+			e.Band.Name,
+			e.Trace+1,
+			strconv.FormatFloat(e.Frequency, 'f', -1, 64),
+			e.Level, e.Limit, e.Margin,
+			result(e.Pass),
+		)
+	}
+	fmt.Fprintf(bw, "Overall,,,,,%.2f,%s\n", r.WorstMargin, result(r.Pass))
+	return bw.Flush()
+}
+
+func result(pass bool) string {
+	if pass {
+		return "Pass"
+	}
+	return "Fail"
+}
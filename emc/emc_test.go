@@ -0,0 +1,144 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package emc
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+var freqs = []float64{150e3, 300e3, 500e3, 1e6, 4e6, 10e6, 20e6}
+
+func testLimit() esa.LimitLine {
+	return esa.LimitLine{
+		Type:         esa.UpperLimit,
+		Units:        esa.DBuV,
+		LogFrequency: true,
+		Points: []esa.LimitPoint{
+			{Frequency: 150e3, Amplitude: 66},
+			{Frequency: 500e3, Amplitude: 56},
+			{Frequency: 5e6, Amplitude: 56},
+			{Frequency: 5e6, Amplitude: 60, Disconnected: true},
+			{Frequency: 30e6, Amplitude: 60},
+		},
+	}
+}
+
+func testTraces() []esa.Trace {
+	return []esa.Trace{
+		{Frequency: freqs, Trace1: []float64{50, 55, 40, 54, 50, 61, 45}},
+		{Frequency: freqs, Trace1: []float64{30, 30, 30, 30, 56, 30, 30}},
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	corrections := []esa.Correction{{Points: []esa.CorrectionPoint{{Frequency: 100e3, Offset: 2}, {Frequency: 50e6, Offset: 2}}}}
+	report, err := NewReport(testTraces(), testLimit(), corrections)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "pass", report.Pass, false)
+	assertFloat64(t, "worst margin", report.WorstMargin, -3, 1e-9)
+	assert(t, "num emissions", len(report.Emissions), 3)
+	var tests = []struct {
+		band   string
+		trace  int
+		freq   float64
+		level  float64
+		margin float64
+		pass   bool
+	}{
+		{"150 kHz - 500 kHz", 0, 300e3, 57, 66 - 10*math.Log10(2)/math.Log10(500.0/150) - 57, true},
+		{"500 kHz - 5 MHz", 1, 4e6, 58, -2, false},
+		{"5 MHz - 30 MHz", 0, 10e6, 63, -3, false},
+	}
+	for i, test := range tests {
+		e := report.Emissions[i]
+		assert(t, "band", e.Band.Name, test.band)
+		assert(t, "trace", e.Trace, test.trace)
+		assertFloat64(t, "frequency", e.Frequency, test.freq, 1e-6)
+		assertFloat64(t, "level", e.Level, test.level, 1e-9)
+		assertFloat64(t, "margin", e.Margin, test.margin, 1e-9)
+		assert(t, "emission pass", e.Pass, test.pass)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("received error writing report: %s", err)
+	}
+	want := `Band,Trace,Frequency (Hz),Level (dBuV),Limit (dBuV),Margin (dB),Result
+150 kHz - 500 kHz,1,300000,57.00,60.24,3.24,Pass
+500 kHz - 5 MHz,2,4000000,58.00,56.00,-2.00,Fail
+5 MHz - 30 MHz,1,10000000,63.00,60.00,-3.00,Fail
+Overall,,,,,-3.00,Fail
+`
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  = %q\nwant = %q", got, want)
+	}
+}
+
+func TestNewReportOptions(t *testing.T) {
+	bands := []Band{{Name: "Low", Start: 150e3, Stop: 1e6}, {Name: "Empty", Start: 40e6, Stop: 50e6}}
+	report, err := NewReport(testTraces()[:1], testLimit(), nil, WithBands(bands...))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num emissions", len(report.Emissions), 1)
+	assert(t, "band", report.Emissions[0].Band.Name, "Low")
+	assertFloat64(t, "margin", report.Emissions[0].Margin, 2, 1e-9)
+	assert(t, "pass", report.Pass, true)
+
+	if _, err := NewReport(testTraces(), testLimit(), nil, WithTrace(2)); err == nil {
+		t.Errorf("expected error for missing trace 2 data")
+	}
+	if _, err := NewReport(nil, testLimit(), nil); err == nil {
+		t.Errorf("expected error for no traces")
+	}
+	if _, err := NewReport(testTraces(), esa.LimitLine{}, nil); err == nil {
+		t.Errorf("expected error for empty limit line")
+	}
+}
+
+func TestNewReportExtraTraces(t *testing.T) {
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_five_traces.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	limit := esa.LimitLine{
+		Type:  esa.UpperLimit,
+		Units: esa.DBuV,
+		Points: []esa.LimitPoint{
+			{Frequency: 9e3, Amplitude: 50},
+			{Frequency: 11e3, Amplitude: 50},
+		},
+	}
+	report, err := NewReport([]esa.Trace{trace}, limit, nil, WithTrace(4))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num emissions", len(report.Emissions), 1)
+	assertFloat64(t, "frequency", report.Emissions[0].Frequency, 10e3, 1e-6)
+	assertFloat64(t, "margin", report.Emissions[0].Margin, 50-51.4703, 1e-9)
+
+	if _, err := NewReport([]esa.Trace{trace}, limit, nil, WithTrace(6)); err == nil {
+		t.Errorf("expected error for missing trace 6")
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
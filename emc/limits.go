@@ -0,0 +1,190 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package emc
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+// Detector is the EMI detector a limit applies to.
+type Detector string
+
+// Available EMI detectors.
+const (
+	Peak      Detector = "Peak"
+	QuasiPeak Detector = "Quasi-Peak"
+	Average   Detector = "Average"
+)
+
+// segment is a part of a standard limit from start to stop in Hz. The
+// amplitude is in dBµV for conducted limits and in dBµV/m at the reference
+// distance in meters for radiated limits.
+type segment struct {
+	start, stop       float64
+	startAmp, stopAmp float64
+	referenceDistance float64
+}
+
+func flat(start, stop, amp, distance float64) segment {
+	return segment{start, stop, amp, amp, distance}
+}
+
+type standardLimit struct {
+	radiated bool
+	limits   map[Detector][]segment
+}
+
+// CISPR 11, 22, and 32 share the same mains terminal and radiated limits
+// below 1 GHz, so the tables are reused.
+var (
+	cisprConductedA = map[Detector][]segment{
+		QuasiPeak: {flat(150e3, 500e3, 79, 0), flat(500e3, 30e6, 73, 0)},
+		Average:   {flat(150e3, 500e3, 66, 0), flat(500e3, 30e6, 60, 0)},
+	}
+	cisprConductedB = map[Detector][]segment{
+		QuasiPeak: {{150e3, 500e3, 66, 56, 0}, flat(500e3, 5e6, 56, 0), flat(5e6, 30e6, 60, 0)},
+		Average:   {{150e3, 500e3, 56, 46, 0}, flat(500e3, 5e6, 46, 0), flat(5e6, 30e6, 50, 0)},
+	}
+	cisprRadiatedA = []segment{flat(30e6, 230e6, 40, 10), flat(230e6, 1e9, 47, 10)}
+	cisprRadiatedB = []segment{flat(30e6, 230e6, 30, 10), flat(230e6, 1e9, 37, 10)}
+)
+
+var standardLimits = map[string]standardLimit{
+	"CISPR 11 Class A Conducted": {limits: cisprConductedA},
+	"CISPR 11 Class B Conducted": {limits: cisprConductedB},
+	"CISPR 11 Class A Radiated": {radiated: true, limits: map[Detector][]segment{
+		QuasiPeak: cisprRadiatedA,
+	}},
+	"CISPR 11 Class B Radiated": {radiated: true, limits: map[Detector][]segment{
+		QuasiPeak: cisprRadiatedB,
+	}},
+	"CISPR 22 Class A Conducted": {limits: cisprConductedA},
+	"CISPR 22 Class B Conducted": {limits: cisprConductedB},
+	"CISPR 22 Class A Radiated": {radiated: true, limits: map[Detector][]segment{
+		QuasiPeak: cisprRadiatedA,
+		Average:   {flat(1e9, 3e9, 56, 3), flat(3e9, 6e9, 60, 3)},
+		Peak:      {flat(1e9, 3e9, 76, 3), flat(3e9, 6e9, 80, 3)},
+	}},
+	"CISPR 22 Class B Radiated": {radiated: true, limits: map[Detector][]segment{
+		QuasiPeak: cisprRadiatedB,
+		Average:   {flat(1e9, 3e9, 50, 3), flat(3e9, 6e9, 54, 3)},
+		Peak:      {flat(1e9, 3e9, 70, 3), flat(3e9, 6e9, 74, 3)},
+	}},
+	"CISPR 32 Class A Conducted": {limits: cisprConductedA},
+	"CISPR 32 Class B Conducted": {limits: cisprConductedB},
+	"CISPR 32 Class A Radiated": {radiated: true, limits: map[Detector][]segment{
+		QuasiPeak: cisprRadiatedA,
+		Average:   {flat(1e9, 3e9, 56, 3), flat(3e9, 6e9, 60, 3)},
+		Peak:      {flat(1e9, 3e9, 76, 3), flat(3e9, 6e9, 80, 3)},
+	}},
+	"CISPR 32 Class B Radiated": {radiated: true, limits: map[Detector][]segment{
+		QuasiPeak: cisprRadiatedB,
+		Average:   {flat(1e9, 3e9, 50, 3), flat(3e9, 6e9, 54, 3)},
+		Peak:      {flat(1e9, 3e9, 70, 3), flat(3e9, 6e9, 74, 3)},
+	}},
+	"FCC Part 15 Class A Conducted": {limits: cisprConductedA},
+	"FCC Part 15 Class B Conducted": {limits: cisprConductedB},
+	// FCC radiated limits are given in µV/m: 90, 150, 210, and 300 µV/m at 10
+	// m for Class A, and 100, 150, 200, and 500 µV/m at 3 m for Class B.
+	"FCC Part 15 Class A Radiated": {radiated: true, limits: map[Detector][]segment{
+		QuasiPeak: {
+			flat(30e6, 88e6, 39.1, 10), flat(88e6, 216e6, 43.5, 10),
+			flat(216e6, 960e6, 46.4, 10), flat(960e6, 1e9, 49.5, 10),
+		},
+		Average: {flat(1e9, 40e9, 49.5, 10)},
+		Peak:    {flat(1e9, 40e9, 69.5, 10)},
+	}},
+	"FCC Part 15 Class B Radiated": {radiated: true, limits: map[Detector][]segment{
+		QuasiPeak: {
+			flat(30e6, 88e6, 40, 3), flat(88e6, 216e6, 43.5, 3),
+			flat(216e6, 960e6, 46, 3), flat(960e6, 1e9, 54, 3),
+		},
+		Average: {flat(1e9, 40e9, 54, 3)},
+		Peak:    {flat(1e9, 40e9, 74, 3)},
+	}},
+}
+
+// LimitNames returns the names of the standard limits, such as "CISPR 32
+// Class B Conducted".
+func LimitNames() []string {
+	names := make([]string, 0, len(standardLimits))
+	for name := range standardLimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StandardLimit returns the standard limit line with the given name for the
+// detector. The name is matched ignoring case, spaces, and punctuation, so
+// "cispr32-classb-conducted" is also accepted. Radiated limits are converted
+// from the reference distance of the standard to the given distance in
+// meters assuming the field strength is inversely proportional to distance.
+// A distance of zero uses the reference distance. The distance is ignored for
+// conducted limits. The limits use log frequency interpolation, and the
+// lower limit applies at the frequencies where the limit steps.
+func StandardLimit(name string, detector Detector, distance float64) (esa.LimitLine, error) {
+	key, std, ok := lookupLimit(name)
+	if !ok {
+		return esa.LimitLine{}, fmt.Errorf("unknown standard limit: %s", name)
+	}
+	segments, ok := std.limits[detector]
+	if !ok {
+		return esa.LimitLine{}, fmt.Errorf("%s doesn't have a %s limit", key, detector)
+	}
+	if distance < 0 {
+		return esa.LimitLine{}, fmt.Errorf("invalid distance: %g", distance)
+	}
+	line := esa.LimitLine{
+		Type:         esa.UpperLimit,
+		Description:  key + " " + string(detector),
+		Units:        esa.DBuV,
+		LogFrequency: true,
+	}
+	for i, seg := range segments {
+		offset := 0.0
+		if std.radiated && distance > 0 {
+			offset = 20 * math.Log10(seg.referenceDistance/distance)
+		}
+		line.Points = append(line.Points,
+			esa.LimitPoint{Frequency: seg.start, Amplitude: seg.startAmp + offset, Disconnected: i > 0},
+			esa.LimitPoint{Frequency: seg.stop, Amplitude: seg.stopAmp + offset},
+		)
+	}
+	if std.radiated {
+		d := distance
+		if d == 0 {
+			d = segments[0].referenceDistance
+		}
+		line.Description += fmt.Sprintf(" at %g m (dBuV/m)", d)
+	}
+	return line, nil
+}
+
+func lookupLimit(name string) (string, standardLimit, bool) {
+	want := normalizeName(name)
+	for key, std := range standardLimits {
+		if normalizeName(key) == want {
+			return key, std, true
+		}
+	}
+	return "", standardLimit{}, false
+}
+
+func normalizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
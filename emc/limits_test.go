@@ -0,0 +1,85 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package emc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+func TestStandardLimit(t *testing.T) {
+	var tests = []struct {
+		name     string
+		detector Detector
+		distance float64
+		freq     float64
+		want     float64
+	}{
+		{"CISPR 32 Class B Conducted", QuasiPeak, 0, 150e3, 66},
+		{"CISPR 32 Class B Conducted", QuasiPeak, 0, math.Sqrt(150e3 * 500e3), 61},
+		{"CISPR 32 Class B Conducted", Average, 0, 500e3, 46},
+		{"cispr32-classb-conducted", QuasiPeak, 0, 5e6, 56},
+		{"CISPR 32 Class B Conducted", QuasiPeak, 0, 10e6, 60},
+		{"CISPR 22 Class A Conducted", QuasiPeak, 0, 1e6, 73},
+		{"CISPR 11 Class A Radiated", QuasiPeak, 0, 230e6, 40},
+		{"CISPR 32 Class B Radiated", QuasiPeak, 3, 300e6, 37 + 20*math.Log10(10.0/3)},
+		{"CISPR 32 Class B Radiated", Average, 0, 2e9, 50},
+		{"CISPR 32 Class A Radiated", Peak, 3, 4e9, 80},
+		{"FCC Part 15 Class B Radiated", QuasiPeak, 0, 100e6, 43.5},
+		{"FCC Part 15 Class B Radiated", QuasiPeak, 10, 50e6, 40 - 20*math.Log10(10.0/3)},
+		{"FCC Part 15 Class A Radiated", Average, 0, 2e9, 49.5},
+		{"FCC Part 15 Class B Conducted", Average, 0, 20e6, 50},
+	}
+	for _, test := range tests {
+		line, err := StandardLimit(test.name, test.detector, test.distance)
+		if err != nil {
+			t.Errorf("received error for %s: %s", test.name, err)
+			continue
+		}
+		got, ok := line.At(test.freq)
+		if !ok {
+			t.Errorf("%s %s doesn't cover %g Hz", test.name, test.detector, test.freq)
+			continue
+		}
+		assertFloat64(t, test.name, got, test.want, 1e-9)
+		assert(t, "type", line.Type, esa.UpperLimit)
+	}
+}
+
+func TestStandardLimitErrors(t *testing.T) {
+	if _, err := StandardLimit("CISPR 99 Class Z", QuasiPeak, 0); err == nil {
+		t.Errorf("expected error for unknown limit")
+	}
+	if _, err := StandardLimit("CISPR 32 Class B Conducted", Peak, 0); err == nil {
+		t.Errorf("expected error for missing detector")
+	}
+	if _, err := StandardLimit("CISPR 32 Class B Radiated", QuasiPeak, -3); err == nil {
+		t.Errorf("expected error for negative distance")
+	}
+	names := LimitNames()
+	assert(t, "num names", len(names), 16)
+	assert(t, "first name", names[0], "CISPR 11 Class A Conducted")
+}
+
+func TestStandardLimitReport(t *testing.T) {
+	line, err := StandardLimit("CISPR 32 Class B Conducted", QuasiPeak, 0)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	trace := esa.Trace{
+		Frequency: []float64{200e3, 1e6, 10e6},
+		Trace1:    []float64{50, 57, 40},
+	}
+	report, err := NewReport([]esa.Trace{trace}, line, nil)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num emissions", len(report.Emissions), 3)
+	assert(t, "pass", report.Pass, false)
+	assertFloat64(t, "worst margin", report.WorstMargin, -1, 1e-9)
+}
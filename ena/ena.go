@@ -0,0 +1,21 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package ena reports the state files saved by the Keysight ENA network
+// analyzers, such as the E5061B and E5071C, as unsupported.
+//
+// The ENA saves its state, including the stimulus settings, trace data, and
+// markers, in binary state files (.sta). The state file format is
+// undocumented and is only intended to be recalled by the instrument itself,
+// so the trace data can't be extracted from them. Attempting to read a state
+// file returns ErrStateFile. The trace data should instead be saved as a
+// Touchstone file, which is read by the touchstone package.
+package ena
+
+import "errors"
+
+// ErrStateFile is returned when reading a state file (.sta), whose binary
+// format is undocumented.
+var ErrStateFile = errors.New("ENA state files use an undocumented binary format / save the trace data as a Touchstone file instead")
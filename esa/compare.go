@@ -0,0 +1,214 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gotmc/keysight/internal/interp"
+)
+
+// CompareOption configures Compare.
+type CompareOption func(*compareConfig)
+
+type compareConfig struct {
+	trace     int
+	tolerance float64
+}
+
+// WithCompareTrace sets the trace column, numbered from 1, whose amplitudes
+// are compared. The default is Trace 1.
+func WithCompareTrace(n int) CompareOption {
+	return func(cfg *compareConfig) {
+		cfg.trace = n
+	}
+}
+
+// WithCompareTolerance sets the relative tolerance used to decide whether
+// the frequencies of two points are the same. The default is 1e-9.
+func WithCompareTolerance(tolerance float64) CompareOption {
+	return func(cfg *compareConfig) {
+		if tolerance >= 0 {
+			cfg.tolerance = tolerance
+		}
+	}
+}
+
+// SettingChange is a header setting that differs between two traces, with
+// the value of each trace as written in the standard CSV metadata.
+type SettingChange struct {
+	Setting string
+	A       string
+	B       string
+}
+
+// PointDelta is the amplitude of two traces at a frequency in Hz and the
+// difference B minus A.
+type PointDelta struct {
+	Frequency float64
+	A         float64
+	B         float64
+	Delta     float64
+}
+
+// Comparison contains the differences between two traces found by Compare.
+type Comparison struct {
+	// Settings contains the header settings that differ, leaving out the
+	// timestamp and original filename.
+	Settings []SettingChange
+	// Units are the amplitude units of both traces' values.
+	Units string
+	// Resampled is true if the frequency grids differ, in which case B is
+	// interpolated onto the frequencies of A and the points of A outside the
+	// frequency range of B are left out.
+	Resampled bool
+	Points    []PointDelta
+	// MaxDelta is the delta with the largest magnitude at MaxDeltaFreq.
+	MaxDelta     float64
+	MaxDeltaFreq float64
+	MeanDelta    float64
+	RMSDelta     float64
+}
+
+// Compare returns the settings that differ between traces a and b, such as
+// before and after a modification of the device under test, along with the
+// amplitude difference b minus a at each frequency and summary statistics.
+// Frequency settings are compared in Hz, and b is converted to the amplitude
+// units of a. Points whose delta isn't finite are left out of the
+// statistics.
+func Compare(a, b Trace, opts ...CompareOption) (Comparison, error) {
+	cfg := compareConfig{trace: 1, tolerance: 1e-9}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var cmp Comparison
+	aSettings, bSettings := a.normalizeFrequencies().metadata(), b.normalizeFrequencies().metadata()
+	for i, s := range aSettings {
+		if s[0] == "Timestamp" || s[0] == "Original Filename" {
+			continue
+		}
+		if s[1] != bSettings[i][1] {
+			cmp.Settings = append(cmp.Settings, SettingChange{s[0], s[1], bSettings[i][1]})
+		}
+	}
+
+	aData, err := a.compareData(cfg.trace)
+	if err != nil {
+		return cmp, fmt.Errorf("trace a: %s", err)
+	}
+	bData, err := b.compareData(cfg.trace)
+	if err != nil {
+		return cmp, fmt.Errorf("trace b: %s", err)
+	}
+	aUnits, bUnits := a.traceUnits(aData), b.traceUnits(bData)
+	cmp.Units = aUnits
+	if aUnits != "" && bUnits != "" && !strings.EqualFold(aUnits, bUnits) {
+		converted, err := b.ConvertAmplitudeUnits(AmplitudeUnits(aUnits))
+		if err != nil {
+			return cmp, fmt.Errorf("unable to convert trace b to %s: %s", aUnits, err)
+		}
+		if bData, err = converted.compareData(cfg.trace); err != nil {
+			return cmp, fmt.Errorf("trace b: %s", err)
+		}
+	}
+
+	freqs, aValues, bValues := a.Frequency, aData.Values, bData.Values
+	if !sameFrequencies(a.Frequency, b.Frequency, cfg.tolerance) {
+		cmp.Resampled = true
+		lo, hi := b.Frequency[0], b.Frequency[len(b.Frequency)-1]
+		freqs, aValues = nil, nil
+		for i, f := range a.Frequency {
+			if f >= lo && f <= hi {
+				freqs = append(freqs, f)
+				aValues = append(aValues, aData.Values[i])
+			}
+		}
+		if len(freqs) == 0 {
+			return cmp, fmt.Errorf("traces don't overlap in frequency")
+		}
+		bValues = interp.Resample(b.Frequency, bData.Values, freqs, false)
+	}
+	var sum, sumSquares float64
+	var n int
+	for i, f := range freqs {
+		p := PointDelta{Frequency: f, A: aValues[i], B: bValues[i], Delta: bValues[i] - aValues[i]}
+		cmp.Points = append(cmp.Points, p)
+		if math.IsNaN(p.Delta) || math.IsInf(p.Delta, 0) {
+			continue
+		}
+		if n == 0 || math.Abs(p.Delta) > math.Abs(cmp.MaxDelta) {
+			cmp.MaxDelta, cmp.MaxDeltaFreq = p.Delta, f
+		}
+		sum += p.Delta
+		sumSquares += p.Delta * p.Delta
+		n++
+	}
+	if n > 0 {
+		cmp.MeanDelta = sum / float64(n)
+		cmp.RMSDelta = math.Sqrt(sumSquares / float64(n))
+	}
+	return cmp, nil
+}
+
+// normalizeFrequencies returns a copy of the trace with the frequency
+// settings in known units converted to Hz.
+func (trace Trace) normalizeFrequencies() Trace {
+	for _, s := range []struct {
+		value *float64
+		units *FrequencyUnits
+	}{
+		{&trace.CenterFreq, &trace.CenterFreqUnits},
+		{&trace.Span, &trace.SpanUnits},
+		{&trace.RBW, &trace.RBWUnits},
+		{&trace.VBW, &trace.VBWUnits},
+	} {
+		if hz, ok := hertz(*s.value, *s.units); ok && *s.units != "" {
+			*s.value, *s.units = hz, Hertz
+		}
+	}
+	return trace
+}
+
+// compareData returns the trace column with the given number, checking that
+// it has a value for each frequency.
+func (trace Trace) compareData(n int) (TraceData, error) {
+	traces := trace.Traces()
+	if n < 1 || n > len(traces) {
+		return TraceData{}, fmt.Errorf("no trace %d", n)
+	}
+	data := traces[n-1]
+	if len(data.Values) == 0 {
+		return data, fmt.Errorf("trace %d is empty", n)
+	}
+	if len(data.Values) != len(trace.Frequency) {
+		return data, fmt.Errorf("mismatched lengths / freq %d / trace %d %d", len(trace.Frequency), n, len(data.Values))
+	}
+	return data, nil
+}
+
+// traceUnits returns the units of the trace column, which default to the
+// reference level units.
+func (trace Trace) traceUnits(data TraceData) string {
+	if units := strings.TrimSpace(data.Units); units != "" {
+		return units
+	}
+	return string(trace.RefLevelUnits)
+}
+
+func sameFrequencies(a, b []float64, tolerance float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, f := range a {
+		scale := math.Max(math.Abs(f), math.Abs(b[i]))
+		if math.Abs(f-b[i]) > tolerance*scale {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	a, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("received error reading trace: %s", err)
+	}
+
+	cmp, err := Compare(a, a)
+	if err != nil {
+		t.Fatalf("received error comparing trace with itself: %s", err)
+	}
+	assert(t, "identical settings", len(cmp.Settings), 0)
+	assert(t, "identical points", len(cmp.Points), len(a.Frequency))
+	assert(t, "identical rms", cmp.RMSDelta, 0.0)
+	assert(t, "identical resampled", cmp.Resampled, false)
+
+	// Trace b has a different RBW, the same center frequency in kHz, a
+	// 3 dB step at point 100, and is converted to dBm.
+	b := a
+	b.CenterFreq, b.CenterFreqUnits = a.CenterFreq/1e3, Kilohertz
+	b.RBW = a.RBW * 3
+	b.Trace1 = append([]float64(nil), a.Trace1...)
+	b.Trace1[100] += 3
+	if b, err = b.ConvertAmplitudeUnits(DBm); err != nil {
+		t.Fatal(err)
+	}
+	cmp, err = Compare(a, b)
+	if err != nil {
+		t.Fatalf("received error comparing traces: %s", err)
+	}
+	if len(cmp.Settings) != 2 {
+		t.Fatalf("wrong number of setting changes / got %d / expected 2: %v", len(cmp.Settings), cmp.Settings)
+	}
+	assert(t, "rbw setting", cmp.Settings[0].Setting, "Resolution Bandwidth")
+	assert(t, "ref level setting", cmp.Settings[1].Setting, "Reference Level")
+	assert(t, "units", cmp.Units, "dBuV")
+	assertFloat64(t, "max delta", cmp.MaxDelta, 3, 1e-9)
+	assert(t, "max delta freq", cmp.MaxDeltaFreq, a.Frequency[100])
+	n := float64(len(a.Frequency))
+	assertFloat64(t, "mean delta", cmp.MeanDelta, 3/n, 1e-9)
+	assertFloat64(t, "rms delta", cmp.RMSDelta, math.Sqrt(9/n), 1e-9)
+
+	cmp, err = Compare(a, b, WithCompareTrace(2))
+	if err != nil {
+		t.Fatalf("received error comparing trace 2: %s", err)
+	}
+	assertFloat64(t, "trace 2 max delta", cmp.MaxDelta, 0, 1e-9)
+	if _, err := Compare(a, b, WithCompareTrace(5)); err == nil {
+		t.Error("expected error comparing missing trace")
+	}
+}
+
+func TestCompareResampled(t *testing.T) {
+	a := Trace{
+		Frequency: []float64{1, 2, 3, 4},
+		Trace1:    []float64{0, 0, 0, math.NaN()},
+	}
+	b := Trace{
+		Frequency: []float64{1.5, 4.5},
+		Trace1:    []float64{1, 4},
+	}
+	cmp, err := Compare(a, b)
+	if err != nil {
+		t.Fatalf("received error comparing traces: %s", err)
+	}
+	assert(t, "resampled", cmp.Resampled, true)
+	if len(cmp.Points) != 3 {
+		t.Fatalf("wrong number of points / got %d / expected 3", len(cmp.Points))
+	}
+	assert(t, "first freq", cmp.Points[0].Frequency, 2.0)
+	assertFloat64(t, "first delta", cmp.Points[0].Delta, 1.5, 1e-9)
+	assertFloat64(t, "max delta", cmp.MaxDelta, 2.5, 1e-9)
+	assert(t, "max delta freq", cmp.MaxDeltaFreq, 3.0)
+	assertFloat64(t, "mean delta skips NaN", cmp.MeanDelta, 2, 1e-9)
+
+	b.Frequency = []float64{10, 20}
+	if _, err := Compare(a, b); err == nil {
+		t.Error("expected error comparing traces that don't overlap")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotmc/keysight/units"
+)
+
+// ConvertAmplitudeUnits returns a copy of the trace with the reference level
+// and trace data converted to the target units assuming a 50 Ω reference
+// impedance.
+func (trace Trace) ConvertAmplitudeUnits(target AmplitudeUnits) (Trace, error) {
+	return trace.ConvertAmplitudeUnitsImpedance(target, units.DefaultImpedance)
+}
+
+// ConvertAmplitudeUnitsImpedance returns a copy of the trace with the
+// reference level and trace data converted to the target units using the
+// given reference impedance in ohms. Traces without units are assumed to be
+// in the reference level units, and an error is returned if neither is
+// known.
+func (trace Trace) ConvertAmplitudeUnitsImpedance(target AmplitudeUnits, impedance float64) (Trace, error) {
+	to, err := units.Parse(string(target))
+	if err != nil {
+		return trace, err
+	}
+	refUnits := trace.RefLevelUnits
+	if refUnits != "" {
+		from, err := units.Parse(string(trace.RefLevelUnits))
+		if err != nil {
+			return trace, fmt.Errorf("error converting ref level: %s", err)
+		}
+		if trace.RefLevel, err = units.Convert(trace.RefLevel, from, to, impedance); err != nil {
+			return trace, fmt.Errorf("error converting ref level: %s", err)
+		}
+		trace.RefLevelUnits = target
+	}
+	convert := func(label string, values []float64, traceUnits *string) ([]float64, error) {
+		if len(values) == 0 {
+			return values, nil
+		}
+		name := strings.TrimSpace(*traceUnits)
+		if name == "" {
+			name = string(refUnits)
+		}
+		from, err := units.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("error converting %s: %s", label, err)
+		}
+		converted, err := units.ConvertSlice(values, from, to, impedance)
+		if err != nil {
+			return nil, fmt.Errorf("error converting %s: %s", label, err)
+		}
+		*traceUnits = string(target)
+		return converted, nil
+	}
+	if trace.Trace1, err = convert("trace 1", trace.Trace1, &trace.Trace1Units); err != nil {
+		return trace, err
+	}
+	if trace.Trace2, err = convert("trace 2", trace.Trace2, &trace.Trace2Units); err != nil {
+		return trace, err
+	}
+	if trace.Trace3, err = convert("trace 3", trace.Trace3, &trace.Trace3Units); err != nil {
+		return trace, err
+	}
+	extra := make([]TraceData, len(trace.ExtraTraces))
+	copy(extra, trace.ExtraTraces)
+	for i := range extra {
+		label := fmt.Sprintf("trace %d", i+4)
+		if extra[i].Values, err = convert(label, extra[i].Values, &extra[i].Units); err != nil {
+			return trace, err
+		}
+	}
+	trace.ExtraTraces = extra
+	return trace, nil
+}
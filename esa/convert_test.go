@@ -0,0 +1,42 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import "testing"
+
+func TestConvertAmplitudeUnits(t *testing.T) {
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("received error reading trace: %s", err)
+	}
+	got, err := trace.ConvertAmplitudeUnits(DBm)
+	if err != nil {
+		t.Fatalf("received error converting units: %s", err)
+	}
+	const offset = 106.98970004336019
+	assert(t, "ref level units", got.RefLevelUnits, DBm)
+	assertFloat64(t, "ref level", got.RefLevel, 106.990-offset, 1e-9)
+	assert(t, "trace 1 units", got.Trace1Units, "dBm")
+	assert(t, "trace 3 units", got.Trace3Units, "dBm")
+	assertFloat64(t, "trace 1", got.Trace1[0], 59.0097-offset, 1e-9)
+	assertFloat64(t, "trace 2", got.Trace2[0], 47.6487-offset, 1e-9)
+	assertFloat64(t, "original unchanged", trace.Trace1[0], 59.0097, 1e-9)
+
+	got, err = trace.ConvertAmplitudeUnitsImpedance(DBmV, 75)
+	if err != nil {
+		t.Fatalf("received error converting units: %s", err)
+	}
+	assertFloat64(t, "dBmV trace 1", got.Trace1[0], 59.0097-60, 1e-9)
+
+	if _, err := trace.ConvertAmplitudeUnits("dBfoo"); err == nil {
+		t.Errorf("expected error converting to unknown units")
+	}
+	trace.RefLevelUnits = ""
+	trace.Trace1Units = ""
+	if _, err := trace.ConvertAmplitudeUnits(DBm); err == nil {
+		t.Errorf("expected error converting trace without units")
+	}
+}
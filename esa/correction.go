@@ -0,0 +1,283 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/internal/interp"
+)
+
+// correctionDataLabels is the line separating the correction header from the
+// correction points.
+const correctionDataLabels = "Frequency,Amplitude"
+
+// CorrectionType is the kind of amplitude correction, which matches the file
+// extension used by the ESA.
+type CorrectionType string
+
+// Available correction types.
+const (
+	AmplitudeCorrection CorrectionType = "Amplitude"
+	AntennaCorrection   CorrectionType = "Antenna"
+	CableCorrection     CorrectionType = "Cable"
+	OtherCorrection     CorrectionType = "Other"
+)
+
+var correctionExtensions = map[string]CorrectionType{
+	".cor": AmplitudeCorrection,
+	".ant": AntennaCorrection,
+	".cbl": CableCorrection,
+	".oth": OtherCorrection,
+}
+
+// CorrectionPoint is the offset in dB added to the amplitude at the given
+// frequency in Hz.
+type CorrectionPoint struct {
+	Frequency float64
+	Offset    float64
+}
+
+// Correction is an amplitude correction table, such as an antenna factor or
+// cable loss, saved by the ESA as a .COR, .ANT, .CBL, or .OTH file.
+type Correction struct {
+	Number      int
+	Type        CorrectionType
+	Description string
+	// LogFrequency interpolates between points using a logarithmic frequency
+	// axis instead of a linear one.
+	LogFrequency bool
+	Points       []CorrectionPoint
+}
+
+// ReadCorrectionFile reads the correction table from the given filename. If
+// the file doesn't include the correction type, it's determined from the file
+// extension.
+func ReadCorrectionFile(filename string) (Correction, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Correction{}, err
+	}
+	defer file.Close()
+	corr, err := ReadCorrection(file)
+	if err != nil {
+		return corr, err
+	}
+	if corr.Type == "" {
+		corr.Type = correctionExtensions[strings.ToLower(filepath.Ext(filename))]
+	}
+	return corr, nil
+}
+
+// ReadCorrection reads a correction table from the given io.Reader. The
+// header uses the same label/value layout as the limit line file and is
+// followed by the frequency and offset of each point, such as:
+//
+//	Correction:              ,1
+//	Type:                    ,Antenna
+//	Frequency Interpolation: ,Log
+//	Frequency,Amplitude
+//	3.00000e+07,1.80000e+01
+//
+// The points are sorted by frequency. Corrections saved in the instrument's
+// internal binary format return ErrInternalFormat.
+func ReadCorrection(r io.Reader) (Correction, error) {
+	corr := Correction{}
+	br := bufio.NewReader(r)
+	if isBinary(br) {
+		return corr, ErrInternalFormat
+	}
+	scanner := bufio.NewScanner(br)
+	lineNum := 0
+	inData := false
+	for scanner.Scan() {
+		lineNum++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		columns := trimAll(strings.Split(text, ","))
+		if inData {
+			if len(columns) != 2 {
+				return corr, fmt.Errorf("wrong number of entries in line %d / got %d / expected 2", lineNum, len(columns))
+			}
+			freq, offset, err := parseFloatPair(columns)
+			if err != nil {
+				return corr, fmt.Errorf("error parsing correction point in line %d: %s", lineNum, err)
+			}
+			corr.Points = append(corr.Points, CorrectionPoint{Frequency: freq, Offset: offset})
+			continue
+		}
+		if strings.EqualFold(columns[0], "Frequency") {
+			inData = true
+			continue
+		}
+		if err := corr.parseHeader(columns); err != nil {
+			return corr, fmt.Errorf("error in correction header line %d: %s", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return corr, err
+	}
+	if !inData {
+		return corr, fmt.Errorf("missing correction points")
+	}
+	sort.SliceStable(corr.Points, func(i, j int) bool {
+		return corr.Points[i].Frequency < corr.Points[j].Frequency
+	})
+	return corr, nil
+}
+
+func (corr *Correction) parseHeader(columns []string) error {
+	label := strings.TrimSuffix(columns[0], ":")
+	values := columns[1:]
+	if len(values) == 0 {
+		return fmt.Errorf("missing value for %s", label)
+	}
+	value := values[0]
+	var err error
+	switch strings.ToLower(label) {
+	case "correction", "amplitude correction":
+		corr.Number, err = strconv.Atoi(value)
+	case "type":
+		corr.Type, err = parseCorrectionType(value)
+	case "description":
+		corr.Description = strings.Join(values, ",")
+	case "frequency interpolation":
+		switch strings.ToLower(value) {
+		case "log", "logarithmic":
+			corr.LogFrequency = true
+		case "lin", "linear":
+			corr.LogFrequency = false
+		default:
+			err = fmt.Errorf("unknown frequency interpolation: %s", value)
+		}
+	default:
+		err = fmt.Errorf("unknown correction setting: %s", label)
+	}
+	return err
+}
+
+func parseCorrectionType(s string) (CorrectionType, error) {
+	for _, t := range []CorrectionType{AmplitudeCorrection, AntennaCorrection, CableCorrection, OtherCorrection} {
+		if strings.EqualFold(s, string(t)) {
+			return t, nil
+		}
+	}
+	if t, ok := correctionExtensions["."+strings.ToLower(s)]; ok {
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown correction type: %s", s)
+}
+
+// WriteCSVFile writes the correction table to the given filename.
+func (corr Correction) WriteCSVFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := corr.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSV writes the correction table to the given io.Writer in the layout
+// read by ReadCorrection.
+func (corr Correction) WriteCSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	writeHeaderLine(bw, "Correction:", strconv.Itoa(corr.Number))
+	if corr.Type != "" {
+		writeHeaderLine(bw, "Type:", string(corr.Type))
+	}
+	if corr.Description != "" {
+		writeHeaderLine(bw, "Description:", corr.Description)
+	}
+	interpolation := "Linear"
+	if corr.LogFrequency {
+		interpolation = "Log"
+	}
+	writeHeaderLine(bw, "Frequency Interpolation:", interpolation)
+	fmt.Fprintln(bw, correctionDataLabels)
+	for _, p := range corr.Points {
+		fmt.Fprintf(bw, "%s,%s\n", formatExp(p.Frequency), formatExp(p.Offset))
+	}
+	return bw.Flush()
+}
+
+// At returns the correction in dB at the given frequency in Hz. Frequencies
+// outside the range of the correction table use the offset of the nearest
+// point.
+func (corr Correction) At(freq float64) float64 {
+	if len(corr.Points) == 0 {
+		return 0
+	}
+	freqs, offsets := corr.columns()
+	return interp.Linear(freqs, offsets, freq, corr.LogFrequency)
+}
+
+func (corr Correction) columns() ([]float64, []float64) {
+	freqs := make([]float64, len(corr.Points))
+	offsets := make([]float64, len(corr.Points))
+	for i, p := range corr.Points {
+		freqs[i] = p.Frequency
+		offsets[i] = p.Offset
+	}
+	return freqs, offsets
+}
+
+// ApplyCorrection returns a copy of the trace with the correction
+// interpolated onto the trace frequencies and added to each trace containing
+// data.
+func ApplyCorrection(trace Trace, corr Correction) (Trace, error) {
+	if len(corr.Points) == 0 {
+		return trace, fmt.Errorf("correction doesn't have any points")
+	}
+	freqs, offsets := corr.columns()
+	grid := interp.Resample(freqs, offsets, trace.Frequency, corr.LogFrequency)
+	apply := func(label string, values []float64) ([]float64, error) {
+		switch len(values) {
+		case 0:
+			return nil, nil
+		case len(grid):
+		default:
+			return nil, fmt.Errorf("mismatched lengths / freq %d / %s %d", len(grid), label, len(values))
+		}
+		corrected := make([]float64, len(values))
+		for i, v := range values {
+			corrected[i] = v + grid[i]
+		}
+		return corrected, nil
+	}
+	var err error
+	if trace.Trace1, err = apply("trace 1", trace.Trace1); err != nil {
+		return trace, err
+	}
+	if trace.Trace2, err = apply("trace 2", trace.Trace2); err != nil {
+		return trace, err
+	}
+	if trace.Trace3, err = apply("trace 3", trace.Trace3); err != nil {
+		return trace, err
+	}
+	extra := make([]TraceData, len(trace.ExtraTraces))
+	copy(extra, trace.ExtraTraces)
+	for i := range extra {
+		if extra[i].Values, err = apply(fmt.Sprintf("trace %d", i+4), extra[i].Values); err != nil {
+			return trace, err
+		}
+	}
+	trace.ExtraTraces = extra
+	trace.Frequency = append([]float64(nil), trace.Frequency...)
+	return trace, nil
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCorrectionFile(t *testing.T) {
+	corr, err := ReadCorrectionFile("./testdata/LISN.CBL")
+	if err != nil {
+		t.Fatalf("received error reading correction: %s", err)
+	}
+	assert(t, "number", corr.Number, 3)
+	assert(t, "type", corr.Type, CableCorrection)
+	assert(t, "description", corr.Description, "LISN and cable loss")
+	assert(t, "log frequency", corr.LogFrequency, false)
+	assert(t, "num points", len(corr.Points), 2)
+	assertFloat64(t, "offset below range", corr.At(1e3), 1, 1e-9)
+	assertFloat64(t, "offset midpoint", corr.At(30e3), 2, 1e-9)
+	assertFloat64(t, "offset above range", corr.At(1e6), 3, 1e-9)
+}
+
+func TestCorrectionWriteCSVRoundTrip(t *testing.T) {
+	corr, err := ReadCorrectionFile("./testdata/LISN.CBL")
+	if err != nil {
+		t.Fatalf("received error reading correction: %s", err)
+	}
+	corr.Type = ""
+	var buf bytes.Buffer
+	if err := corr.WriteCSV(&buf); err != nil {
+		t.Fatalf("received error writing correction: %s", err)
+	}
+	want, err := os.ReadFile("./testdata/LISN.CBL")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	if got := buf.String(); got != string(want) {
+		t.Errorf("\ngot  = %q\nwant = %q", got, want)
+	}
+}
+
+func TestApplyCorrection(t *testing.T) {
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("received error reading trace: %s", err)
+	}
+	corr, err := ReadCorrectionFile("./testdata/LISN.CBL")
+	if err != nil {
+		t.Fatalf("received error reading correction: %s", err)
+	}
+	got, err := ApplyCorrection(trace, corr)
+	if err != nil {
+		t.Fatalf("received error applying correction: %s", err)
+	}
+	var tests = []struct {
+		index  int
+		offset float64
+	}{
+		{0, 1},     // 9 kHz is held at the first point
+		{168, 2},   // 30 kHz
+		{400, 3},   // 59 kHz is held at the last point
+		{328, 3},   // 50 kHz
+		{88, 1.5},  // 20 kHz
+		{8, 1},     // 10 kHz
+		{16, 1.05}, // 11 kHz
+	}
+	for _, test := range tests {
+		assertFloat64(t, "trace 1", got.Trace1[test.index], trace.Trace1[test.index]+test.offset, 1e-9)
+		assertFloat64(t, "trace 3", got.Trace3[test.index], trace.Trace3[test.index]+test.offset, 1e-9)
+	}
+	assertFloat64(t, "original unchanged", trace.Trace1[0], 59.0097, 1e-9)
+
+	trace.Trace2 = trace.Trace2[:10]
+	if _, err := ApplyCorrection(trace, corr); err == nil {
+		t.Errorf("expected error for mismatched trace lengths")
+	}
+	if _, err := ApplyCorrection(trace, Correction{}); err == nil {
+		t.Errorf("expected error for empty correction")
+	}
+}
+
+func TestReadCorrectionErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"missing points", "Correction:,1\n"},
+		{"unknown type", "Type:,Sideways\nFrequency,Amplitude\n"},
+		{"unknown setting", "Color:,Red\nFrequency,Amplitude\n"},
+		{"bad interpolation", "Frequency Interpolation:,Cubic\nFrequency,Amplitude\n"},
+		{"bad point", "Frequency,Amplitude\n1e3,abc\n"},
+		{"extra column", "Frequency,Amplitude\n1e3,2,1\n"},
+		{"binary", "\x00\x01\x02COR"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadCorrection(strings.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func FuzzReadCorrection(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.CBL")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCorrection(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileKind is the kind of file saved by the ESA.
+type FileKind string
+
+// Kinds of files recognized by ReadDir.
+const (
+	TraceFile      FileKind = "Trace"
+	StateFile      FileKind = "State"
+	LimitLineFile  FileKind = "Limit Line"
+	CorrectionFile FileKind = "Correction"
+)
+
+// ManifestEntry is the result of reading one file in ReadDir. Only the field
+// matching the Kind is set. If the file couldn't be read or parsed, Err is
+// the error and the parsed value may be incomplete. State files are listed
+// with ErrInternalFormat, since they can't be read.
+type ManifestEntry struct {
+	Path       string
+	Kind       FileKind
+	Trace      Trace
+	LimitLine  LimitLine
+	Correction Correction
+	Err        error
+}
+
+// ReadDir reads every trace, limit line, and correction file in the
+// directory and its subdirectories, such as the contents of a USB stick
+// saved by the ESA. CSV files are recognized by their first line, correction
+// files by their .COR, .ANT, .CBL, or .OTH extension, and state files by
+// their .STA extension, while any other files are ignored. The files are
+// parsed concurrently and the manifest is returned in lexical order of path.
+// A file that fails to parse doesn't stop the others from being read; its
+// error is reported in the manifest entry. The options are used when parsing
+// trace files.
+func ReadDir(dir string, opts ...Option) ([]ManifestEntry, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isRecognizedExt(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make([]ManifestEntry, len(paths))
+	// The background context is never canceled, so every file is read.
+	_ = parallel(context.Background(), len(paths), 0, func(i int) {
+		manifest[i] = readManifestEntry(paths[i], opts)
+	})
+	return manifest, nil
+}
+
+func isRecognizedExt(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	_, ok := correctionExtensions[ext]
+	return ok || ext == ".csv" || ext == ".sta"
+}
+
+func readManifestEntry(path string, opts []Option) ManifestEntry {
+	entry := ManifestEntry{Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	entry.Kind = detectKind(path, data)
+	r := bytes.NewReader(data)
+	switch entry.Kind {
+	case TraceFile:
+		entry.Trace, entry.Err = ReadCSV(r, opts...)
+	case StateFile:
+		entry.Err = ErrInternalFormat
+	case LimitLineFile:
+		entry.LimitLine, entry.Err = ReadLimitLine(r)
+	case CorrectionFile:
+		entry.Correction, entry.Err = ReadCorrection(r)
+		if entry.Err == nil && entry.Correction.Type == "" {
+			entry.Correction.Type = correctionExtensions[strings.ToLower(filepath.Ext(path))]
+		}
+	default:
+		entry.Err = fmt.Errorf("unrecognized file")
+	}
+	return entry
+}
+
+// detectKind determines the kind of file from its extension or first line.
+// Trace files start with the timestamp and original filename, while limit
+// line and correction files start with a header label ending in a colon. An
+// unrecognized file returns an empty kind.
+func detectKind(path string, data []byte) FileKind {
+	ext := strings.ToLower(filepath.Ext(path))
+	if _, ok := correctionExtensions[ext]; ok {
+		return CorrectionFile
+	}
+	if ext == ".sta" {
+		return StateFile
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Scan()
+	label := strings.TrimSpace(strings.Split(scanner.Text(), ",")[0])
+	if !strings.HasSuffix(label, ":") {
+		return TraceFile
+	}
+	switch strings.ToLower(strings.TrimSpace(strings.TrimSuffix(label, ":"))) {
+	case "limit line":
+		return LimitLineFile
+	case "correction":
+		return CorrectionFile
+	}
+	return ""
+}
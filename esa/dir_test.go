@@ -0,0 +1,76 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	copyFile := func(src, dst string) {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatalf("error reading %s: %s", src, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, dst), data, 0o644); err != nil {
+			t.Fatalf("error writing %s: %s", dst, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "usb"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	copyFile("testdata/LISN.CBL", "LISN.CBL")
+	copyFile("testdata/cispr_limit.csv", "LIMIT1.CSV")
+	if err := os.WriteFile(filepath.Join(dir, "usb", "STATE1.STA"), []byte("\x00\x01\x02"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	copyFile("testdata/e4402b_trace924.csv", "usb/TRACE924.CSV")
+	if err := os.WriteFile(filepath.Join(dir, "usb", "TRACE925.CSV"), []byte("bad,line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	var tests = []struct {
+		path string
+		kind FileKind
+		err  bool
+	}{
+		{"LIMIT1.CSV", LimitLineFile, false},
+		{"LISN.CBL", CorrectionFile, false},
+		{"usb/STATE1.STA", StateFile, true},
+		{"usb/TRACE924.CSV", TraceFile, false},
+		{"usb/TRACE925.CSV", TraceFile, true},
+	}
+	if len(manifest) != len(tests) {
+		t.Fatalf("got %d manifest entries, want %d", len(manifest), len(tests))
+	}
+	for i, test := range tests {
+		entry := manifest[i]
+		assert(t, "path", entry.Path, filepath.Join(dir, filepath.FromSlash(test.path)))
+		assert(t, "kind", entry.Kind, test.kind)
+		assert(t, "error", entry.Err != nil, test.err)
+	}
+	assert(t, "model", manifest[3].Trace.Model, "E4402B")
+	assert(t, "correction type", manifest[1].Correction.Type, CableCorrection)
+	assert(t, "limit points", len(manifest[0].LimitLine.Points) > 0, true)
+	if !errors.Is(manifest[2].Err, ErrInternalFormat) {
+		t.Errorf("got %v for state file, want ErrInternalFormat", manifest[2].Err)
+	}
+
+	if _, err := ReadDir(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected error for missing directory")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to runes. The
+// remaining bytes above 0x7F match Latin-1, which maps directly to the runes
+// U+00A0 to U+00FF. Undefined bytes map to the corresponding C1 control
+// character, which is then stripped.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// normalizeField returns the header field as trimmed UTF-8 without control
+// characters, such as the NUL padding written by the instrument. A field that
+// isn't valid UTF-8 is decoded as Windows-1252, which is a superset of the
+// printable Latin-1 characters.
+func normalizeField(s string) string {
+	if !utf8.ValidString(s) {
+		var b strings.Builder
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c < 0x80:
+				b.WriteByte(c)
+			case c < 0xA0:
+				b.WriteRune(windows1252[c-0x80])
+			default:
+				b.WriteRune(rune(c))
+			}
+		}
+		s = b.String()
+	}
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// normalizeHeader normalizes the text fields of the trace header, including
+// the extra headers, and the labels and units of the trace columns.
+func (trace *Trace) normalizeHeader(traces []TraceData) {
+	fields := []*string{
+		&trace.OriginalFilename, &trace.Title, &trace.Model, &trace.SerialNum,
+		&trace.FreqLabel, &trace.FreqUnits,
+	}
+	for i := range traces {
+		fields = append(fields, &traces[i].Label, &traces[i].Units)
+	}
+	for _, field := range fields {
+		*field = normalizeField(*field)
+	}
+	if trace.ExtraHeaders != nil {
+		extra := make(map[string]string, len(trace.ExtraHeaders))
+		for label, value := range trace.ExtraHeaders {
+			extra[normalizeField(label)] = normalizeField(value)
+		}
+		trace.ExtraHeaders = extra
+	}
+}
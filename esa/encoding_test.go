@@ -0,0 +1,72 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeField(t *testing.T) {
+	var tests = []struct {
+		given string
+		want  string
+	}{
+		{"MY45104598\x00", "MY45104598"},
+		{"  LISN \x00\x00\x00", "LISN"},
+		{"Caf\xe9 \x80 test", "Café € test"},
+		{"\x93quoted\x94", "“quoted”"},
+		{"50 \xb5V", "50 µV"},
+		{"Café", "Café"},
+		{"tab\there\r", "tabhere"},
+	}
+	for _, test := range tests {
+		if got := normalizeField(test.given); got != test.want {
+			t.Errorf("\ngot  = %q for %q\nwant = %q", got, test.given, test.want)
+		}
+	}
+}
+
+func TestReadCSVEncoding(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	given := strings.Replace(string(data), "Title:                   ,", "Title:                   ,Mesure \xe0 50 \xb5V\x00\x00", 1)
+	trace, err := ReadCSV(strings.NewReader(given))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "title", trace.Title, "Mesure à 50 µV")
+	assert(t, "s/n", trace.SerialNum, "MY45104598")
+
+	raw, err := ReadCSV(strings.NewReader(given), WithRawHeader())
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "raw title", raw.Title, "Mesure \xe0 50 \xb5V\x00\x00")
+	assert(t, "raw s/n", raw.SerialNum, "MY45104598\x00")
+}
+
+func TestWriteCSVRawHeader(t *testing.T) {
+	raw, err := ReadCSVFile("./testdata/e4402b_trace924.csv", WithRawHeader())
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	var b strings.Builder
+	if err := raw.WriteCSV(&b); err != nil {
+		t.Fatalf("error writing trace: %s", err)
+	}
+	if strings.Contains(b.String(), "\x00\x00") {
+		t.Errorf("serial number NUL terminator doubled:\n%q", b.String())
+	}
+	got, err := ReadCSV(strings.NewReader(b.String()), WithRawHeader())
+	if err != nil {
+		t.Fatalf("error reading written trace: %s", err)
+	}
+	assert(t, "raw s/n", got.SerialNum, raw.SerialNum)
+}
@@ -5,21 +5,145 @@
 
 // Package esa has the ability to parse files from the Keysight/Agilent ESA
 // spectrum analyzers.
+//
+// The ESA can save traces either in CSV format or in the instrument's internal
+// binary format (.TRC files). The internal format is undocumented and is only
+// intended to be recalled by the instrument itself, so it isn't supported.
+// Attempting to read an internal format file returns ErrInternalFormat.
+// Traces should instead be saved in CSV format or queried from the instrument.
+//
+// Traces can also be fetched from a live instrument using SCPI commands with
+// an Instrument, which returns the same Trace as reading a CSV file.
 package esa
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/gotmc/keysight/internal/csvrow"
 )
 
+// ErrInternalFormat is returned when attempting to parse a file saved in the
+// ESA's undocumented internal binary format, such as a .TRC trace file.
+var ErrInternalFormat = errors.New("file is in the ESA internal (binary) format; save as CSV instead")
+
+// FrequencyUnits are the units for a frequency value, such as the center
+// frequency, span, RBW, or VBW.
 type FrequencyUnits string
+
+// Available frequency units.
+const (
+	Hertz     FrequencyUnits = "Hz"
+	Kilohertz FrequencyUnits = "kHz"
+	Megahertz FrequencyUnits = "MHz"
+	Gigahertz FrequencyUnits = "GHz"
+)
+
+// TimeUnits are the units for a time value, such as the sweep time.
 type TimeUnits string
+
+// Available time units.
+const (
+	Seconds      TimeUnits = "s"
+	Milliseconds TimeUnits = "ms"
+	Microseconds TimeUnits = "us"
+)
+
+// AmplitudeUnits are the units for an amplitude value, such as the reference
+// level.
 type AmplitudeUnits string
 
+// Available amplitude units.
+const (
+	DBm        AmplitudeUnits = "dBm"
+	DBuV       AmplitudeUnits = "dBuV"
+	DBmV       AmplitudeUnits = "dBmV"
+	Watts      AmplitudeUnits = "W"
+	Volts      AmplitudeUnits = "V"
+	Millivolts AmplitudeUnits = "mV"
+)
+
+var frequencyUnits = map[string]FrequencyUnits{
+	"hz":  Hertz,
+	"khz": Kilohertz,
+	"mhz": Megahertz,
+	"ghz": Gigahertz,
+}
+
+var timeUnits = map[string]TimeUnits{
+	"s":    Seconds,
+	"sec":  Seconds,
+	"ms":   Milliseconds,
+	"msec": Milliseconds,
+	"us":   Microseconds,
+	"usec": Microseconds,
+	"µs":   Microseconds,
+}
+
+var amplitudeUnits = map[string]AmplitudeUnits{
+	"dbm":  DBm,
+	"dbuv": DBuV,
+	"dbµv": DBuV,
+	"dbmv": DBmV,
+	"w":    Watts,
+	"v":    Volts,
+	"mv":   Millivolts,
+}
+
+// ParseFrequencyUnits parses the frequency units as written by the ESA. An
+// empty string returns empty units, since the ESA leaves the units column
+// blank for some settings.
+func ParseFrequencyUnits(s string) (FrequencyUnits, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	units, ok := frequencyUnits[strings.ToLower(s)]
+	if !ok {
+		return "", fmt.Errorf("unknown frequency units: %s", s)
+	}
+	return units, nil
+}
+
+// ParseTimeUnits parses the time units as written by the ESA. An empty string
+// returns empty units.
+func ParseTimeUnits(s string) (TimeUnits, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	units, ok := timeUnits[strings.ToLower(s)]
+	if !ok {
+		return "", fmt.Errorf("unknown time units: %s", s)
+	}
+	return units, nil
+}
+
+// ParseAmplitudeUnits parses the amplitude units as written by the ESA. An
+// empty string returns empty units, since the ESA leaves the reference level
+// units blank when the amplitude units are not dB based.
+func ParseAmplitudeUnits(s string) (AmplitudeUnits, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	units, ok := amplitudeUnits[strings.ToLower(s)]
+	if !ok {
+		return "", fmt.Errorf("unknown amplitude units: %s", s)
+	}
+	return units, nil
+}
+
 type Trace struct {
 	Timestamp        time.Time
 	OriginalFilename string
@@ -51,212 +175,516 @@ type Trace struct {
 	Trace1           []float64
 	Trace2           []float64
 	Trace3           []float64
+	// ExtraTraces contains any trace columns after the third, which are saved
+	// by some firmware revisions.
+	ExtraTraces []TraceData
+	// Markers contains the marker table saved after the trace data by some
+	// save options. The frequencies are in Hz.
+	Markers []Marker
+	// State is the instrument state saved after the trace data when the
+	// trace and state are saved together, or nil if there isn't one.
+	State *State
+	// Detector, Attenuation in dB, Preamp, and Coupling are only set by the
+	// extended header lines written by some firmware revisions.
+	Detector    Detector
+	Attenuation float64
+	Preamp      bool
+	Coupling    Coupling
+	// ExtraHeaders contains the header lines with unrecognized labels, such
+	// as those added by newer firmware revisions, keyed by the label without
+	// the trailing colon with the values joined by commas.
+	ExtraHeaders map[string]string
+	// RawHeader contains the verbatim lines of the header, from the
+	// date/filename line through the trace units line.
+	RawHeader []string
+	// Provenance records the source and hash of the file and when and by
+	// which version of the parser it was read.
+	Provenance Provenance
 }
 
-// ReadCSVFile reads the Keysight/Agilent ESA trace data saved in CSV format.
-// It should be noted that the ESA CSV file does not meet the format described
-// in RFC 4180.
-func ReadCSVFile(filename string) (Trace, error) {
-	trace := Trace{}
-	file, err := os.Open(filename)
-	if err != nil {
-		return trace, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
+// TraceData is the label, units, and values of a trace column.
+type TraceData struct {
+	Label  string
+	Units  string
+	Values []float64
+}
 
-	// Parse first line, which should contain the timestamp and original
-	// filename.
-	columns, err := getLineAndSplitColumns(scanner, 2)
-	if err != nil {
-		return trace, fmt.Errorf("error in first (date/filename) line: %s", err)
+// Traces returns the trace columns in the order saved by the ESA. The first
+// three columns are stored in the Trace1, Trace2, and Trace3 fields, which
+// are only included up to the last one with a label or values, so a file
+// containing only Trace 1 returns one column.
+func (trace Trace) Traces() []TraceData {
+	traces := []TraceData{
+		{trace.Trace1Label, trace.Trace1Units, trace.Trace1},
+		{trace.Trace2Label, trace.Trace2Units, trace.Trace2},
+		{trace.Trace3Label, trace.Trace3Units, trace.Trace3},
 	}
-	trace.OriginalFilename = columns[1]
+	if len(trace.ExtraTraces) > 0 {
+		return append(traces, trace.ExtraTraces...)
+	}
+	for len(traces) > 0 {
+		last := traces[len(traces)-1]
+		if last.Label != "" || len(last.Values) > 0 {
+			break
+		}
+		traces = traces[:len(traces)-1]
+	}
+	return traces
+}
 
-	// Parse second line, which should contain the title.
-	columns, err = getLineAndSplitColumns(scanner, 2)
-	if err != nil {
-		return trace, fmt.Errorf("error in second (title) line: %s", err)
+// SetTraces sets the trace columns, storing the first three in the Trace1,
+// Trace2, and Trace3 fields and the rest in ExtraTraces.
+func (trace *Trace) SetTraces(traces []TraceData) {
+	fields := []struct {
+		label  *string
+		units  *string
+		values *[]float64
+	}{
+		{&trace.Trace1Label, &trace.Trace1Units, &trace.Trace1},
+		{&trace.Trace2Label, &trace.Trace2Units, &trace.Trace2},
+		{&trace.Trace3Label, &trace.Trace3Units, &trace.Trace3},
+	}
+	for i, f := range fields {
+		var t TraceData
+		if i < len(traces) {
+			t = traces[i]
+		}
+		*f.label, *f.units, *f.values = t.Label, t.Units, t.Values
 	}
-	trace.Title = columns[1]
+	trace.ExtraTraces = nil
+	if len(traces) > 3 {
+		trace.ExtraTraces = traces[3:]
+	}
+}
 
-	// Parse third line, which should contain the model.
-	columns, err = getLineAndSplitColumns(scanner, 2)
-	if err != nil {
-		return trace, fmt.Errorf("error in third (model) line: %s", err)
+// timestampLayouts lists the date/time layouts emitted by the various ESA
+// firmware revisions in the first line of the CSV file. Runs of whitespace are
+// collapsed to a single space before the layouts are tried.
+var timestampLayouts = []string{
+	"01/02/06 15:04:05",
+	"01/02/2006 15:04:05",
+	"02.01.06 15:04:05",
+	"02.01.2006 15:04:05",
+	"2006-01-02 15:04:05",
+	"02 Jan 2006 15:04:05",
+	"Jan 02 2006 15:04:05",
+	"01/02/06 15:04",
+	"01/02/2006 15:04",
+}
+
+// Option configures how an ESA trace file is parsed.
+type Option func(*parseConfig)
+
+type parseConfig struct {
+	location             *time.Location
+	strict               bool
+	allowShortTrace      bool
+	headerOrderTolerance bool
+	rawHeader            bool
+	source               string
+}
+
+func newParseConfig(opts []Option) parseConfig {
+	cfg := parseConfig{location: time.UTC}
+	for _, opt := range opts {
+		opt(&cfg)
 	}
-	trace.Model = columns[1]
+	return cfg
+}
 
-	// Parse fourth line, which should contain the serial number.
-	columns, err = getLineAndSplitColumns(scanner, 2)
-	if err != nil {
-		return trace, fmt.Errorf("error in fourth (serial number) line: %s", err)
+// WithLocation sets the time zone used to interpret the timestamp stored in
+// the trace file. The ESA stores the instrument's local time without any zone
+// information, so the timestamp is interpreted as UTC unless a location is
+// provided.
+func WithLocation(loc *time.Location) Option {
+	return func(cfg *parseConfig) {
+		if loc != nil {
+			cfg.location = loc
+		}
 	}
-	trace.SerialNum = strings.TrimSuffix(columns[1], "\x00")
+}
 
-	// Parse fifth line, which should contain the center frequency value and
-	// units.
-	columns, err = getLineAndSplitColumns(scanner, 3)
-	if err != nil {
-		return trace, fmt.Errorf("error in fifth (center freq) line: %s", err)
+// WithStrict fails on any deviation from the layout written by the ESA. Each
+// header line must have the expected label, the header must be followed by
+// exactly two blank lines, and the data must contain the number of points
+// given in the header without any blank lines, except before a marker table.
+// WithStrict can't be combined with WithAllowShortTrace or
+// WithHeaderOrderTolerance.
+func WithStrict() Option {
+	return func(cfg *parseConfig) {
+		cfg.strict = true
 	}
-	centerFreq, err := strconv.ParseFloat(columns[1], 64)
-	if err != nil {
-		return trace, fmt.Errorf("error parsing center frequency: %s", err)
+}
+
+// WithAllowShortTrace accepts a trace with fewer data points than given in
+// the header, such as a file truncated when the disk filled. An incomplete
+// last data row is dropped, and NumPoints is set to the number of points
+// read.
+func WithAllowShortTrace() Option {
+	return func(cfg *parseConfig) {
+		cfg.allowShortTrace = true
 	}
-	trace.CenterFreq = centerFreq
+}
 
-	//TODO(mdr): Parse center freq units.
+// WithHeaderOrderTolerance parses the header lines by their labels instead of
+// their positions, so that header lines may be missing, such as the title
+// line omitted by some firmware revisions, reordered, or separated by extra
+// blank lines. Header lines with unknown labels are stored in ExtraHeaders.
+// If the number of points is missing, it's determined from the data.
+func WithHeaderOrderTolerance() Option {
+	return func(cfg *parseConfig) {
+		cfg.headerOrderTolerance = true
+	}
+}
 
-	// Parse sixth line, which should contain the span value and units.
-	columns, err = getLineAndSplitColumns(scanner, 3)
-	if err != nil {
-		return trace, fmt.Errorf("error in sixth (span) line: %s", err)
+// WithRawHeader preserves the bytes of the original filename, title, model,
+// serial number, and trace labels for forensic use. By default, the text
+// fields are converted from Windows-1252 to UTF-8 if they aren't valid UTF-8,
+// and control characters, such as NUL padding, and surrounding whitespace
+// are removed.
+func WithRawHeader() Option {
+	return func(cfg *parseConfig) {
+		cfg.rawHeader = true
 	}
-	span, err := strconv.ParseFloat(columns[1], 64)
+}
+
+// ReadCSVFile reads the Keysight/Agilent ESA trace data saved in CSV format.
+// It should be noted that the ESA CSV file does not meet the format described
+// in RFC 4180.
+func ReadCSVFile(filename string, opts ...Option) (Trace, error) {
+	file, err := os.Open(filename)
 	if err != nil {
-		return trace, fmt.Errorf("error parsing span: %s", err)
+		return Trace{}, err
 	}
-	trace.Span = span
+	defer file.Close()
+	return ReadCSV(file, append([]Option{WithSource(filename)}, opts...)...)
+}
 
-	// Parse seventh line, which should contain the resolution bandwidth (RBW)
-	// value and units.
-	columns, err = getLineAndSplitColumns(scanner, 3)
+// ReadCSVFS reads the Keysight/Agilent ESA trace data saved in CSV format from
+// the named file in the file system, such as a zip archive opened with
+// archive/zip or an embed.FS.
+func ReadCSVFS(fsys fs.FS, name string, opts ...Option) (Trace, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
-		return trace, fmt.Errorf("error in seventh (rbw) line: %s", err)
+		return Trace{}, err
 	}
-	rbw, err := strconv.ParseFloat(columns[1], 64)
-	if err != nil {
-		return trace, fmt.Errorf("error parsing rbw: %s", err)
+	defer file.Close()
+	return ReadCSV(file, append([]Option{WithSource(name)}, opts...)...)
+}
+
+// ReadCSV reads the Keysight/Agilent ESA trace data in CSV format from the
+// given io.Reader, such as trace data queried directly from an instrument.
+// By default, the header lines are parsed by position and the number of data
+// points must match the header, while blank lines after the data are
+// ignored. The options WithStrict, WithAllowShortTrace, and
+// WithHeaderOrderTolerance make the parsing stricter or more tolerant. The
+// detector, attenuation, preamp, and coupling header lines written by some
+// firmware revisions are accepted anywhere in the header by every option,
+// and any other header lines after the standard lines are stored in
+// ExtraHeaders.
+//
+// A file saved using Save Trace + State contains the instrument state after
+// the trace data and any marker table, written as label/value lines, which
+// is parsed into the State field.
+func ReadCSV(r io.Reader, opts ...Option) (Trace, error) {
+	var columns [][]float64
+	trace, traces, err := readCSV(r, newParseConfig(opts), func(trace *Trace, values []float64) error {
+		if columns == nil {
+			n := csvrow.Capacity(trace.NumPoints)
+			columns = make([][]float64, len(values))
+			for i := range columns {
+				columns[i] = make([]float64, 0, n)
+			}
+		}
+		for i, v := range values {
+			columns[i] = append(columns[i], v)
+		}
+		return nil
+	})
+	if len(columns) > 0 {
+		trace.Frequency = columns[0]
+		for i := range traces {
+			traces[i].Values = columns[i+1]
+		}
 	}
-	trace.RBW = rbw
+	trace.SetTraces(traces)
+	return trace, err
+}
 
-	// Parse eighth line, which should contain the video bandwidth (vbw) value
-	// and units.
-	columns, err = getLineAndSplitColumns(scanner, 3)
-	if err != nil {
-		return trace, fmt.Errorf("error in eighth (vbw) line: %s", err)
+// Stream reads the Keysight/Agilent ESA trace data in CSV format from the
+// given io.Reader, calling fn with the frequency and trace values of each
+// data point instead of storing them, so that large files are read using
+// constant memory. The values slice is reused for each data point, so fn
+// must copy any values it keeps. If fn returns an error, Stream stops reading
+// and returns the error. The returned trace contains the header, trace labels
+// and units, and markers, without the frequency or trace values. The options
+// are the same as for ReadCSV.
+func Stream(r io.Reader, fn func(freq float64, values []float64) error, opts ...Option) (Trace, error) {
+	trace, traces, err := readCSV(r, newParseConfig(opts), func(_ *Trace, values []float64) error {
+		return fn(values[0], values[1:])
+	})
+	trace.SetTraces(traces)
+	return trace, err
+}
+
+// readCSV parses the header and calls fn with the frequency followed by the
+// trace values of each data point. It returns the trace header along with
+// the labels and units of the trace columns, which aren't set in the trace.
+func readCSV(r io.Reader, cfg parseConfig, fn func(trace *Trace, values []float64) error) (Trace, []TraceData, error) {
+	trace := Trace{}
+	if cfg.strict && (cfg.allowShortTrace || cfg.headerOrderTolerance) {
+		return trace, nil, errors.New("strict parsing can't be combined with tolerant parsing options")
 	}
-	vbw, err := strconv.ParseFloat(columns[1], 64)
-	if err != nil {
-		return trace, fmt.Errorf("error parsing vbw: %s", err)
+	hash := sha256.New()
+	br := bufio.NewReader(io.TeeReader(r, hash))
+	if isBinary(br) {
+		return trace, nil, ErrInternalFormat
+	}
+	scanner := &headerScanner{Scanner: bufio.NewScanner(br), recording: true}
+	nextLine := func() string {
+		scanner.Scan()
+		return scanner.Text()
+	}
+	if cfg.headerOrderTolerance {
+		nextLine = func() string {
+			for scanner.Scan() {
+				if strings.TrimSpace(scanner.Text()) != "" {
+					break
+				}
+			}
+			return scanner.Text()
+		}
 	}
-	trace.VBW = vbw
 
-	// Parse ninth line, which should contain the reference level value and
-	// units.
-	columns, err = getLineAndSplitColumns(scanner, 3)
-	if err != nil {
-		return trace, fmt.Errorf("error in ninth (ref level) line: %s", err)
+	// Parse first line, which should contain the timestamp and original
+	// filename.
+	columns := strings.Split(nextLine(), ",")
+	if len(columns) != 2 {
+		return trace, nil, fmt.Errorf(
+			"error in first (date/filename) line: wrong number of entries / got %d / expected 2",
+			len(columns),
+		)
 	}
-	refLevel, err := strconv.ParseFloat(columns[1], 64)
+	timestamp, err := parseTimestamp(columns[0], cfg.location)
 	if err != nil {
-		return trace, fmt.Errorf("error parsing ref level: %s", err)
+		return trace, nil, fmt.Errorf("error parsing timestamp: %s", err)
 	}
-	trace.RefLevel = refLevel
+	trace.Timestamp = timestamp
+	trace.OriginalFilename = columns[1]
 
-	// Parse tenth line, which should contain the sweep time value and units.
-	columns, err = getLineAndSplitColumns(scanner, 3)
-	if err != nil {
-		return trace, fmt.Errorf("error in tenth (sweep time) line: %s", err)
+	// Parse the remaining header lines, which are followed by the line
+	// containing the labels for the frequency and trace data.
+	var line string
+	numPointsKnown := true
+	if cfg.headerOrderTolerance {
+		line, numPointsKnown, err = trace.parseHeaderByLabel(nextLine)
+	} else {
+		line, err = trace.parseHeaderByPosition(scanner, cfg.strict)
 	}
-	sweepTime, err := strconv.ParseFloat(columns[1], 64)
 	if err != nil {
-		return trace, fmt.Errorf("error parsing sweep time: %s", err)
+		return trace, nil, err
+	}
+	if err := scanner.Err(); err != nil {
+		return trace, nil, err
 	}
-	trace.SweepTime = sweepTime
 
-	// Parse eleventh line, which should contain the number of points.
-	columns, err = getLineAndSplitColumns(scanner, 2)
-	if err != nil {
-		return trace, fmt.Errorf("error in eleventh (num points) line: %s", err)
+	labels := strings.Split(line, ",")
+	if len(labels) < 2 {
+		return trace, nil, fmt.Errorf("error in trace label line: %s", line)
+	}
+	trace.FreqLabel = labels[0]
+
+	// Parse the next line, which should contain the units for the frequency
+	// and trace data.
+	line = nextLine()
+	units := strings.Split(line, ",")
+	if len(units) != len(labels) {
+		return trace, nil, fmt.Errorf("error in trace units line: %s", line)
+	}
+	trace.FreqUnits = units[0]
+	traces := make([]TraceData, len(labels)-1)
+	for i := range traces {
+		traces[i].Label = labels[i+1]
+		traces[i].Units = strings.TrimSpace(units[i+1])
+	}
+	trace.RawHeader = scanner.lines
+	scanner.recording = false
+	if !cfg.rawHeader {
+		trace.normalizeHeader(traces)
 	}
-	numPoints, err := strconv.Atoi(columns[1])
-	if err != nil {
-		return trace, fmt.Errorf("error parsing num points: %s", err)
-	}
-	trace.NumPoints = numPoints
-
-	// Skip lines 12 and 13, which are blank.
-	scanner.Scan()
-	scanner.Scan()
-
-	// Parse 14th line, which should contain the labels for the frequency and
-	// trace data.
-	scanner.Scan()
-	line := scanner.Text()
-	s := strings.Split(line, ",")
-	if len(s) != 4 {
-		return trace, fmt.Errorf("error in trace label line: %s", line)
-	}
-	trace.FreqLabel = s[0]
-	trace.Trace1Label = s[1]
-	trace.Trace2Label = s[2]
-	trace.Trace3Label = s[3]
-
-	// Parse 15th line, which should contain the units for the frequency and
-	// trace data.
-	scanner.Scan()
-	line = scanner.Text()
-	s = strings.Split(line, ",")
-	if len(s) != 4 {
-		return trace, fmt.Errorf("error in trace units line: %s", line)
-	}
-	trace.FreqUnits = s[0]
-	trace.Trace1Units = strings.TrimSpace(s[1])
-	trace.Trace2Units = strings.TrimSpace(s[2])
-	trace.Trace3Units = strings.TrimSpace(s[3])
 
 	// Parse the remaining lines, which should now comply with RFC 4180 and be a
-	// standard CSV file.
-	trace.Frequency = make([]float64, trace.NumPoints)
-	trace.Trace1 = make([]float64, trace.NumPoints)
-	trace.Trace2 = make([]float64, trace.NumPoints)
-	trace.Trace3 = make([]float64, trace.NumPoints)
-	i := 0
+	// standard CSV file with the frequency followed by one column per trace.
+	// When short traces are allowed, a row that fails to parse is only an
+	// error if it isn't the last row. The rows are read and split without
+	// allocating, and are only converted to strings for the marker table,
+	// the state, and errors.
+	values := make([]float64, len(labels))
+	n := 0
+	var truncated error
+	blank := false
+	inMarkers := false
+	var splitter csvrow.Splitter
 	for scanner.Scan() {
-		line = scanner.Text()
-		s = strings.Split(line, ",")
-		if len(s) != 4 {
-			return trace, fmt.Errorf("error in trace data line: %s", line)
+		row := scanner.Bytes()
+		if len(bytes.TrimSpace(row)) == 0 {
+			blank = true
+			continue
 		}
-		freq, err := strconv.ParseFloat(strings.TrimSpace(s[0]), 64)
-		if err != nil {
-			return trace, fmt.Errorf("error parsing frequency %s for data point %d", s[0], i)
+		if trace.State == nil && isStateLine(row) {
+			trace.State = &State{}
 		}
-		trace1, err := strconv.ParseFloat(strings.TrimSpace(s[1]), 64)
-		if err != nil {
-			return trace, fmt.Errorf("error parsing trace 1 %s for data point %d", s[1], i)
+		if trace.State != nil {
+			line := string(row)
+			if !isStateLine(row) {
+				return trace, traces, fmt.Errorf("unexpected line after state: %s", line)
+			}
+			if err := trace.State.parseLine(strings.Split(line, ",")); err != nil {
+				return trace, traces, fmt.Errorf("error in state line %s: %s", line, err)
+			}
+			blank = false
+			continue
 		}
-		trace2, err := strconv.ParseFloat(strings.TrimSpace(s[2]), 64)
-		if err != nil {
-			return trace, fmt.Errorf("error parsing trace 2 %s for data point %d", s[2], i)
+		if !inMarkers && isMarkerHeader(row) {
+			inMarkers = true
+			blank = false
+			continue
 		}
-		trace3, err := strconv.ParseFloat(strings.TrimSpace(s[3]), 64)
-		if err != nil {
-			return trace, fmt.Errorf("error parsing trace 3 %s for data point %d", s[3], i)
+		if blank && cfg.strict {
+			return trace, traces, fmt.Errorf("blank line after data point %d", n)
+		}
+		blank = false
+		if inMarkers {
+			line := string(row)
+			marker, err := parseMarkerLine(line)
+			if err != nil {
+				return trace, traces, fmt.Errorf("error in marker line %s: %s", line, err)
+			}
+			trace.Markers = append(trace.Markers, marker)
+			continue
+		}
+		if truncated != nil {
+			return trace, traces, truncated
+		}
+		if err := parseDataLine(&splitter, row, n, values); err != nil {
+			if cfg.allowShortTrace {
+				truncated = err
+				continue
+			}
+			return trace, traces, err
+		}
+		if numPointsKnown && n == trace.NumPoints {
+			return trace, traces, fmt.Errorf("more data points than the %d given in the header", trace.NumPoints)
 		}
-		trace.Frequency[i] = freq
-		trace.Trace1[i] = trace1
-		trace.Trace2[i] = trace2
-		trace.Trace3[i] = trace3
-		i++
+		if err := fn(&trace, values); err != nil {
+			return trace, traces, err
+		}
+		n++
 	}
 
 	if err := scanner.Err(); err != nil {
-		return trace, err
+		return trace, traces, err
+	}
+	if blank && cfg.strict {
+		return trace, traces, fmt.Errorf("blank line at end of file")
 	}
 
-	return trace, nil
+	switch {
+	case !numPointsKnown, cfg.allowShortTrace && n < trace.NumPoints:
+		trace.NumPoints = n
+	case n != trace.NumPoints:
+		return trace, traces, fmt.Errorf("wrong number of data points / got %d / expected %d", n, trace.NumPoints)
+	}
+
+	trace.Provenance = Provenance{
+		Source:        cfg.source,
+		SHA256:        hex.EncodeToString(hash.Sum(nil)),
+		ParsedAt:      time.Now().UTC(),
+		ParserVersion: ParserVersion(),
+	}
+	return trace, traces, nil
 }
 
-func getLineAndSplitColumns(scanner *bufio.Scanner, numEntries int) ([]string, error) {
-	scanner.Scan()
-	line := scanner.Text()
-	s := strings.Split(line, ",")
-	if len(s) != numEntries {
-		return s, fmt.Errorf("wrong number of entries / got %d / expected %d", len(s), numEntries)
+// isMarkerHeader reports whether the line is the header of the marker table,
+// such as "Marker,Frequency,Amplitude", which some save options write after
+// the trace data.
+func isMarkerHeader(row []byte) bool {
+	label := csvrow.First(row)
+	return bytes.EqualFold(label, []byte("Marker")) || bytes.EqualFold(label, []byte("Markers"))
+}
+
+// parseMarkerLine parses a row of the marker table containing the marker
+// number, which may be written as "1", "M1", or "Marker 1", followed by the
+// frequency and amplitude.
+func parseMarkerLine(line string) (Marker, error) {
+	var marker Marker
+	s := trimAll(strings.Split(line, ","))
+	if len(s) < 3 {
+		return marker, fmt.Errorf("wrong number of entries / got %d / expected 3", len(s))
+	}
+	num, err := strconv.Atoi(strings.TrimLeftFunc(s[0], func(r rune) bool {
+		return r < '0' || r > '9'
+	}))
+	if err != nil {
+		return marker, fmt.Errorf("error parsing marker number %s", s[0])
+	}
+	marker.Number = num
+	if marker.Frequency, err = strconv.ParseFloat(s[1], 64); err != nil {
+		return marker, fmt.Errorf("error parsing marker frequency %s", s[1])
+	}
+	if marker.Amplitude, err = strconv.ParseFloat(s[2], 64); err != nil {
+		return marker, fmt.Errorf("error parsing marker amplitude %s", s[2])
+	}
+	return marker, nil
+}
+
+// parseDataLine parses the frequency and trace values of the data point with
+// the given index into values, whose length is the expected number of
+// columns. The row is split using splitter to avoid allocating.
+func parseDataLine(splitter *csvrow.Splitter, row []byte, i int, values []float64) error {
+	s := splitter.Split(row)
+	if len(s) != len(values) {
+		return fmt.Errorf("error in trace data line: %s", row)
+	}
+	for j := range s {
+		v, err := csvrow.ParseFloat(s[j])
+		if err != nil {
+			if j == 0 {
+				return fmt.Errorf("error parsing frequency %s for data point %d", s[j], i)
+			}
+			return fmt.Errorf("error parsing trace %d %s for data point %d", j, s[j], i)
+		}
+		values[j] = v
+	}
+	return nil
+}
+
+// parseTimestamp parses the date and time from the first line of an ESA CSV
+// file. An empty timestamp returns the zero time.
+func parseTimestamp(s string, loc *time.Location) (time.Time, error) {
+	s = strings.Join(strings.Fields(strings.Trim(s, "\x00")), " ")
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp format: %s", s)
+}
+
+// isBinary reports whether the first line of the buffered data contains
+// control characters, which indicates the data isn't in CSV format.
+func isBinary(br *bufio.Reader) bool {
+	peek, _ := br.Peek(512)
+	for _, b := range peek {
+		switch {
+		case b == '\n':
+			return false
+		case b < 0x20 && b != '\t' && b != '\r':
+			return true
+		}
 	}
-	return s, nil
+	return false
 }
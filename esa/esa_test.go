@@ -6,8 +6,20 @@
 package esa
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"math"
+	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
 )
 
 func TestReadCSVFile(t *testing.T) {
@@ -18,16 +30,23 @@ func TestReadCSVFile(t *testing.T) {
 		{
 			filename: "./testdata/e4402b_trace924.csv",
 			want: Trace{
+				Timestamp:        time.Date(2021, time.November, 16, 10, 50, 45, 0, time.UTC),
 				OriginalFilename: "C:\\TRACE924.CSV",
 				Title:            "",
 				Model:            "E4402B",
 				SerialNum:        "MY45104598",
 				CenterFreq:       34000.0,
+				CenterFreqUnits:  Hertz,
 				Span:             50000.0,
+				SpanUnits:        Hertz,
 				RBW:              1000.0,
+				RBWUnits:         Hertz,
 				VBW:              1000.0,
+				VBWUnits:         Hertz,
 				RefLevel:         106.99,
+				RefLevelUnits:    DBuV,
 				SweepTime:        0.085,
+				SweepTimeUnits:   Seconds,
 				NumPoints:        401,
 				FreqLabel:        "",
 				Trace1Label:      "Trace 1",
@@ -43,16 +62,23 @@ func TestReadCSVFile(t *testing.T) {
 		{
 			filename: "./testdata/e4411b_trace080.csv",
 			want: Trace{
+				Timestamp:        time.Date(2015, time.July, 29, 12, 12, 29, 0, time.UTC),
 				OriginalFilename: "A:\\TRACE080.CSV",
 				Title:            "",
 				Model:            "E4411B",
 				SerialNum:        "MY45104634",
 				CenterFreq:       750000000.0,
+				CenterFreqUnits:  Hertz,
 				Span:             500000000.0,
+				SpanUnits:        Hertz,
 				RBW:              100000.0,
+				RBWUnits:         Hertz,
 				VBW:              100000.0,
+				VBWUnits:         Hertz,
 				RefLevel:         73.0103,
+				RefLevelUnits:    "",
 				SweepTime:        0.0644205,
+				SweepTimeUnits:   Seconds,
 				NumPoints:        401,
 				FreqLabel:        "",
 				Trace1Label:      "Trace 1",
@@ -72,16 +98,23 @@ func TestReadCSVFile(t *testing.T) {
 			if err != nil {
 				t.Errorf("received error reading CSV file: %s", err)
 			}
+			assert(t, "timestamp", got.Timestamp, test.want.Timestamp)
 			assert(t, "original filename", got.OriginalFilename, test.want.OriginalFilename)
 			assert(t, "title", got.Title, test.want.Title)
 			assert(t, "model", got.Model, test.want.Model)
 			assert(t, "s/n", got.SerialNum, test.want.SerialNum)
 			assertFloat64(t, "center freq", got.CenterFreq, test.want.CenterFreq, 0.01)
+			assert(t, "center freq units", got.CenterFreqUnits, test.want.CenterFreqUnits)
 			assertFloat64(t, "span", got.Span, test.want.Span, 0.01)
+			assert(t, "span units", got.SpanUnits, test.want.SpanUnits)
 			assertFloat64(t, "rbw", got.RBW, test.want.RBW, 0.01)
+			assert(t, "rbw units", got.RBWUnits, test.want.RBWUnits)
 			assertFloat64(t, "vbw", got.VBW, test.want.VBW, 0.01)
+			assert(t, "vbw units", got.VBWUnits, test.want.VBWUnits)
 			assertFloat64(t, "ref level", got.RefLevel, test.want.RefLevel, 0.0000001)
+			assert(t, "ref level units", got.RefLevelUnits, test.want.RefLevelUnits)
 			assertFloat64(t, "sweep time", got.SweepTime, test.want.SweepTime, 0.00000001)
+			assert(t, "sweep time units", got.SweepTimeUnits, test.want.SweepTimeUnits)
 			assert(t, "num points", got.NumPoints, test.want.NumPoints)
 			assert(t, "freq label", got.FreqLabel, test.want.FreqLabel)
 			assert(t, "trace 1 label", got.Trace1Label, test.want.Trace1Label)
@@ -103,6 +136,496 @@ func TestReadCSVFile(t *testing.T) {
 	}
 }
 
+func TestReadCSV(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	got, err := ReadCSV(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("received error reading CSV: %s", err)
+	}
+	assert(t, "model", got.Model, "E4402B")
+	assert(t, "s/n", got.SerialNum, "MY45104598")
+	assert(t, "num points", got.NumPoints, 401)
+	assert(t, "trace 1 len", len(got.Trace1), 401)
+	assertFloat64(t, "t1[400]", got.Trace1[400], 5.68447e+01, 0.00000001)
+}
+
+func TestReadCSVFS(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("usb/TRACE924.CSV")
+	if err != nil {
+		t.Fatalf("error creating zip entry: %s", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("error writing zip entry: %s", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("error closing zip: %s", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("error opening zip: %s", err)
+	}
+	got, err := ReadCSVFS(zr, "usb/TRACE924.CSV")
+	if err != nil {
+		t.Fatalf("received error reading CSV: %s", err)
+	}
+	assert(t, "model", got.Model, "E4402B")
+	assert(t, "num points", len(got.Trace1), 401)
+	if _, err := ReadCSVFS(os.DirFS("testdata"), "missing.csv"); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func TestReadCSVOptions(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	join := func(lines ...[]string) string {
+		var all []string
+		for _, l := range lines {
+			all = append(all, l...)
+		}
+		return strings.Join(all, "\n") + "\n"
+	}
+	n := len(lines)
+	truncated := join(lines[:n-2], []string{"58875.000, 5.7"})
+	missingTitle := join(lines[:1], lines[2:])
+	extraBlank := join(lines[:3], []string{"", "  "}, lines[3:])
+	reordered := join(lines[:1], lines[10:11], lines[1:10], lines[11:])
+	trailingBlank := join(lines) + "\n\n"
+	tooMany := join(lines, lines[n-1:])
+	wrongLabel := strings.Replace(join(lines), "Model:", "Modell:", 1)
+	var tests = []struct {
+		name      string
+		given     string
+		opts      []Option
+		err       bool
+		numPoints int
+	}{
+		{"default", join(lines), nil, false, 401},
+		{"strict", join(lines), []Option{WithStrict()}, false, 401},
+		{"truncated default", truncated, nil, true, 0},
+		{"truncated strict", truncated, []Option{WithStrict()}, true, 0},
+		{"truncated short trace", truncated, []Option{WithAllowShortTrace()}, false, 399},
+		{"missing title default", missingTitle, nil, true, 0},
+		{"missing title tolerant", missingTitle, []Option{WithHeaderOrderTolerance()}, false, 401},
+		{"extra blank lines default", extraBlank, nil, true, 0},
+		{"extra blank lines tolerant", extraBlank, []Option{WithHeaderOrderTolerance()}, false, 401},
+		{"reordered tolerant", reordered, []Option{WithHeaderOrderTolerance()}, false, 401},
+		{"trailing blank lines default", trailingBlank, nil, false, 401},
+		{"trailing blank lines strict", trailingBlank, []Option{WithStrict()}, true, 0},
+		{"too many points", tooMany, nil, true, 0},
+		{"too many points short trace", tooMany, []Option{WithAllowShortTrace()}, true, 0},
+		{"wrong label default", wrongLabel, nil, false, 401},
+		{"wrong label strict", wrongLabel, []Option{WithStrict()}, true, 0},
+		{"strict and tolerant", join(lines), []Option{WithStrict(), WithAllowShortTrace()}, true, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(test.given), test.opts...)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "model", got.Model, "E4402B")
+			assert(t, "num points", got.NumPoints, test.numPoints)
+			assert(t, "frequency len", len(got.Frequency), test.numPoints)
+			assert(t, "trace 3 len", len(got.Trace3), test.numPoints)
+			assertFloat64(t, "rbw", got.RBW, 1000, 1e-9)
+		})
+	}
+}
+
+func TestReadCSVExtendedHeader(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	extended := strings.Join(lines[:10], "\n") +
+		"\nDetector:                ,Peak\nAttenuation:             ,10,dB\n" +
+		strings.Join(lines[10:11], "\n") +
+		"\nPreamp:                  ,On\nInput Coupling:          ,DC\n" +
+		strings.Join(lines[11:], "\n")
+	var tests = []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"strict", []Option{WithStrict()}},
+		{"header order tolerance", []Option{WithHeaderOrderTolerance()}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(extended), test.opts...)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "sweep time", got.SweepTime, 0.085)
+			assert(t, "num points", got.NumPoints, 401)
+			assert(t, "detector", got.Detector, DetectorPeak)
+			assert(t, "attenuation", got.Attenuation, 10.0)
+			assert(t, "preamp", got.Preamp, true)
+			assert(t, "coupling", got.Coupling, CouplingDC)
+			assert(t, "trace 1 len", len(got.Trace1), 401)
+		})
+	}
+
+	for _, bad := range []string{
+		strings.Replace(extended, ",Peak", ",Fancy", 1),
+		strings.Replace(extended, ",10,dB", ",10", 1),
+		strings.Replace(extended, ",On", ",Maybe", 1),
+		strings.Replace(extended, ",DC", ",RF", 1),
+	} {
+		if _, err := ReadCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for bad extended header")
+		}
+	}
+}
+
+func TestReadCSVExtraHeaders(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	extra := strings.Join(lines[:11], "\n") +
+		"\nFirmware:                ,A.08.05\nGate:                    ,Off, 1.0,ms\n" +
+		strings.Join(lines[11:], "\n")
+	// Reordered with alternative labels, a missing title, and an unknown
+	// line in the middle.
+	renamed := strings.Join([]string{
+		lines[0],
+		"Num Pts:,401",
+		"Center Freq:,34000,Hz",
+		"Firmware:,A.08.05",
+		"Model:,E4402B",
+		"Res BW:,1000,Hz",
+		"VBW:,1000,Hz",
+		"Span:,50000,Hz",
+		"Ref Level:,106.99,dBuV",
+		"Swp Time:,85,ms",
+	}, "\n") + "\n\n" + strings.Join(lines[13:], "\n")
+	var tests = []struct {
+		name  string
+		given string
+		opts  []Option
+		extra int
+	}{
+		{"default", extra, nil, 2},
+		{"strict", extra, []Option{WithStrict()}, 2},
+		{"header order tolerance", extra, []Option{WithHeaderOrderTolerance()}, 2},
+		{"renamed", renamed, []Option{WithHeaderOrderTolerance()}, 1},
+		{"none", string(data), nil, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(test.given), test.opts...)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "model", got.Model, "E4402B")
+			assert(t, "num points", got.NumPoints, 401)
+			assert(t, "center freq", got.CenterFreq, 34000.0)
+			assert(t, "rbw", got.RBW, 1000.0)
+			assert(t, "ref level", got.RefLevel, 106.99)
+			assert(t, "num extra headers", len(got.ExtraHeaders), test.extra)
+			if test.extra > 0 {
+				assert(t, "firmware", got.ExtraHeaders["Firmware"], "A.08.05")
+			}
+			if test.extra > 1 {
+				assert(t, "gate", got.ExtraHeaders["Gate"], "Off,1.0,ms")
+			}
+		})
+	}
+}
+
+func TestReadCSVProvenance(t *testing.T) {
+	filename := "./testdata/e4402b_trace924.csv"
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	sum := sha256.Sum256(data)
+	before := time.Now()
+	trace, err := ReadCSVFile(filename)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	p := trace.Provenance
+	assert(t, "source", p.Source, filename)
+	assert(t, "sha256", p.SHA256, hex.EncodeToString(sum[:]))
+	if p.ParsedAt.Before(before) || p.ParsedAt.After(time.Now()) || p.ParsedAt.Location() != time.UTC {
+		t.Errorf("parsed at %s isn't the UTC time of parsing", p.ParsedAt)
+	}
+	assert(t, "parser version", p.ParserVersion, ParserVersion())
+	lines := strings.Split(string(data), "\n")
+	assert(t, "raw header lines", len(trace.RawHeader), 15)
+	for i, line := range trace.RawHeader {
+		assert(t, fmt.Sprintf("raw header line %d", i), line, lines[i])
+	}
+
+	trace, err = ReadCSV(bytes.NewReader(data), WithSource("upload.csv"), WithHeaderOrderTolerance())
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "reader source", trace.Provenance.Source, "upload.csv")
+	assert(t, "reader sha256", trace.Provenance.SHA256, hex.EncodeToString(sum[:]))
+	assert(t, "tolerant raw header lines", len(trace.RawHeader), 15)
+
+	trace, err = Stream(bytes.NewReader(data), func(float64, []float64) error { return nil })
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "stream sha256", trace.Provenance.SHA256, hex.EncodeToString(sum[:]))
+	assert(t, "stream raw header", trace.RawHeader[14], lines[14])
+}
+
+func TestReadCSVTraceColumns(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	// withColumns keeps the frequency and the first n trace columns,
+	// repeating the last column if more are requested.
+	withColumns := func(n int) string {
+		var b strings.Builder
+		for i, line := range lines {
+			if i < 13 {
+				b.WriteString(line + "\n")
+				continue
+			}
+			s := strings.Split(line, ",")
+			for len(s) < n+1 {
+				s = append(s, s[len(s)-1])
+			}
+			b.WriteString(strings.Join(s[:n+1], ",") + "\n")
+		}
+		return b.String()
+	}
+	for _, n := range []int{1, 2, 3, 5} {
+		t.Run(fmt.Sprintf("%d traces", n), func(t *testing.T) {
+			trace, err := ReadCSV(strings.NewReader(withColumns(n)))
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			traces := trace.Traces()
+			assert(t, "num traces", len(traces), n)
+			assert(t, "extra traces", len(trace.ExtraTraces), max(n-3, 0))
+			for i, td := range traces {
+				assert(t, "values", len(td.Values), 401)
+				assert(t, "units", td.Units, "dBuV")
+				if i < 3 {
+					assert(t, "label", td.Label, fmt.Sprintf("Trace %d", i+1))
+				}
+			}
+			assertFloat64(t, "trace 1", trace.Trace1[400], 5.68447e+01, 1e-9)
+			assertFloat64(t, "last trace", traces[n-1].Values[0], []float64{59.0097, 47.6487, 45.2877}[min(n, 3)-1], 1e-9)
+
+			var buf bytes.Buffer
+			if err := trace.WriteCSV(&buf); err != nil {
+				t.Fatalf("error writing CSV: %s", err)
+			}
+			got, err := ReadCSV(&buf)
+			if err != nil {
+				t.Fatalf("error reading written CSV: %s", err)
+			}
+			assert(t, "round trip traces", len(got.Traces()), n)
+		})
+	}
+	if _, err := ReadCSV(strings.NewReader(withColumns(0))); err == nil {
+		t.Errorf("expected error without trace columns")
+	}
+}
+
+func TestReadCSVMarkers(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	markers := "\nMarker,Frequency,Amplitude\n1, 34000.000, 5.81000e+01\nM2,9.000e+03,5.90097e+01\nMarker 3, 59000, 56.8447\n"
+	given := string(data) + markers
+	var tests = []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"strict", []Option{WithStrict()}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trace, err := ReadCSV(strings.NewReader(given), test.opts...)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "num points", len(trace.Frequency), 401)
+			assert(t, "num markers", len(trace.Markers), 3)
+			assert(t, "marker 1", trace.Markers[0], Marker{1, 34000, 58.1})
+			assert(t, "marker 2", trace.Markers[1], Marker{2, 9000, 59.0097})
+			assert(t, "marker 3", trace.Markers[2], Marker{3, 59000, 56.8447})
+		})
+	}
+
+	trace, err := ReadCSV(strings.NewReader(given))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := trace.WriteCSV(&buf); err != nil {
+		t.Fatalf("error writing CSV: %s", err)
+	}
+	got, err := ReadCSV(&buf, WithStrict())
+	if err != nil {
+		t.Fatalf("error reading written CSV: %s", err)
+	}
+	assert(t, "round trip markers", len(got.Markers), 3)
+	assert(t, "round trip marker 3", got.Markers[2], trace.Markers[2])
+
+	bad := string(data) + "Marker,Frequency,Amplitude\n1,abc,0\n"
+	if _, err := ReadCSV(strings.NewReader(bad)); err == nil {
+		t.Errorf("expected error for bad marker frequency")
+	}
+}
+
+func TestStream(t *testing.T) {
+	file, err := os.Open("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error opening test file: %s", err)
+	}
+	defer file.Close()
+	var freqs []float64
+	var last []float64
+	trace, err := Stream(file, func(freq float64, values []float64) error {
+		freqs = append(freqs, freq)
+		last = append(last[:0], values...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "model", trace.Model, "E4402B")
+	assert(t, "num points", trace.NumPoints, 401)
+	assert(t, "num rows", len(freqs), 401)
+	assert(t, "frequency", len(trace.Frequency), 0)
+	assert(t, "trace 1 label", trace.Trace1Label, "Trace 1")
+	assert(t, "trace 3 units", trace.Trace3Units, "dBuV")
+	assert(t, "num traces", len(trace.Traces()), 3)
+	assertFloat64(t, "last freq", freqs[400], 59000, 1e-9)
+	assertFloat64(t, "last trace 1", last[0], 5.68447e+01, 1e-9)
+	assertFloat64(t, "last trace 3", last[2], 2.71237e+01, 1e-9)
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("error seeking test file: %s", err)
+	}
+	stop := errors.New("stop")
+	rows := 0
+	_, err = Stream(file, func(float64, []float64) error {
+		rows++
+		if rows == 10 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("\ngot  = %v\nwant = %v", err, stop)
+	}
+	assert(t, "rows before stop", rows, 10)
+}
+
+func TestReadCSVInternalFormat(t *testing.T) {
+	data := []byte{0x00, 0x01, 0x54, 0x52, 0x43, 0x00, 0x00, 0x91, 0x0a}
+	_, err := ReadCSV(bytes.NewReader(data))
+	if !errors.Is(err, ErrInternalFormat) {
+		t.Errorf("\ngot  = %v\nwant = %v", err, ErrInternalFormat)
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	freq, err := ParseFrequencyUnits(" MHz")
+	if err != nil {
+		t.Errorf("received error parsing frequency units: %s", err)
+	}
+	assert(t, "MHz", freq, Megahertz)
+	sweep, err := ParseTimeUnits("Sec")
+	if err != nil {
+		t.Errorf("received error parsing time units: %s", err)
+	}
+	assert(t, "Sec", sweep, Seconds)
+	amp, err := ParseAmplitudeUnits("dBm")
+	if err != nil {
+		t.Errorf("received error parsing amplitude units: %s", err)
+	}
+	assert(t, "dBm", amp, DBm)
+	if _, err := ParseFrequencyUnits("furlongs"); err == nil {
+		t.Errorf("expected error parsing unknown frequency units")
+	}
+	if _, err := ParseTimeUnits("fortnights"); err == nil {
+		t.Errorf("expected error parsing unknown time units")
+	}
+	if _, err := ParseAmplitudeUnits("dBfoo"); err == nil {
+		t.Errorf("expected error parsing unknown amplitude units")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	loc := time.FixedZone("MST", -7*60*60)
+	var tests = []struct {
+		given string
+		loc   *time.Location
+		want  time.Time
+	}{
+		{" 11/16/21   10:50:45", time.UTC, time.Date(2021, 11, 16, 10, 50, 45, 0, time.UTC)},
+		{"11/16/2021 10:50:45", time.UTC, time.Date(2021, 11, 16, 10, 50, 45, 0, time.UTC)},
+		{"16.11.21 10:50:45", time.UTC, time.Date(2021, 11, 16, 10, 50, 45, 0, time.UTC)},
+		{"2021-11-16 10:50:45", time.UTC, time.Date(2021, 11, 16, 10, 50, 45, 0, time.UTC)},
+		{"16 Nov 2021 10:50:45", time.UTC, time.Date(2021, 11, 16, 10, 50, 45, 0, time.UTC)},
+		{" 07/29/15   12:12:29", loc, time.Date(2015, 7, 29, 12, 12, 29, 0, loc)},
+		{"   ", time.UTC, time.Time{}},
+	}
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			got, err := parseTimestamp(test.given, test.loc)
+			if err != nil {
+				t.Fatalf("received error parsing timestamp: %s", err)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("\ngot  = %s\nwant = %s", got, test.want)
+			}
+		})
+	}
+	if _, err := parseTimestamp("yesterday", time.UTC); err == nil {
+		t.Errorf("expected error parsing invalid timestamp")
+	}
+}
+
+func TestReadCSVFileWithLocation(t *testing.T) {
+	loc := time.FixedZone("CET", 60*60)
+	got, err := ReadCSVFile("./testdata/e4402b_trace924.csv", WithLocation(loc))
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	want := time.Date(2021, time.November, 16, 9, 50, 45, 0, time.UTC)
+	if !got.Timestamp.Equal(want) {
+		t.Errorf("\ngot  = %s\nwant = %s", got.Timestamp, want)
+	}
+}
+
 func assert(t *testing.T, label string, got, want interface{}) {
 	if got != want {
 		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
@@ -114,3 +637,56 @@ func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
 		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
 	}
 }
+
+func BenchmarkReadCSV(b *testing.B) {
+	data := benchmarkCSV(b, 8192)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadCSV(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStream(b *testing.B) {
+	data := benchmarkCSV(b, 8192)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := Stream(bytes.NewReader(data), func(freq float64, values []float64) error {
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkCSV returns the header of the test file followed by the given
+// number of data rows with three traces, like a full ESA sweep.
+func benchmarkCSV(b *testing.B, points int) []byte {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		b.Fatal(err)
+	}
+	header, _, _ := bytes.Cut(data, []byte("Hz,dBuV,dBuV,dBuV\n"))
+	header = bytes.Replace(header, []byte(",0401"), []byte(fmt.Sprintf(",%04d", points)), 1)
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteString("Hz,dBuV,dBuV,dBuV\n")
+	for i := 0; i < points; i++ {
+		fmt.Fprintf(&buf, "%.3f, %.5e, %.5e, %.5e\n", 9000+125*float64(i),
+			60+5*math.Sin(float64(i)), 45+5*math.Cos(float64(i)), 40+5*math.Sin(float64(2*i)))
+	}
+	return buf.Bytes()
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
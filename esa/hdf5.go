@@ -0,0 +1,69 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gotmc/keysight/internal/hdf5"
+)
+
+// WriteHDF5File writes the trace as an HDF5 file to the given filename.
+func (trace Trace) WriteHDF5File(filename string) error {
+	return writeFile(filename, trace.WriteHDF5)
+}
+
+// WriteHDF5 writes the trace as an HDF5 file to the given io.Writer. The
+// header settings are stored as attributes of the root group, with each
+// value and its units stored separately, such as CenterFrequency and
+// CenterFrequencyUnits. The Timestamp attribute is in RFC 3339 format and is
+// left out if unknown. The frequencies are stored in the Frequency dataset
+// and each trace containing data in the Trace1, Trace2, and Trace3 datasets.
+// Every dataset has Label and Units attributes.
+func (trace Trace) WriteHDF5(w io.Writer) error {
+	root := hdf5.NewGroup()
+	if !trace.Timestamp.IsZero() {
+		root.SetString("Timestamp", trace.Timestamp.Format(time.RFC3339))
+	}
+	root.SetString("OriginalFilename", trace.OriginalFilename)
+	root.SetString("Title", trace.Title)
+	root.SetString("Model", trace.Model)
+	root.SetString("SerialNumber", trace.SerialNum)
+	for _, s := range []struct {
+		name  string
+		value float64
+		units string
+	}{
+		{"CenterFrequency", trace.CenterFreq, string(trace.CenterFreqUnits)},
+		{"Span", trace.Span, string(trace.SpanUnits)},
+		{"ResolutionBandwidth", trace.RBW, string(trace.RBWUnits)},
+		{"VideoBandwidth", trace.VBW, string(trace.VBWUnits)},
+		{"ReferenceLevel", trace.RefLevel, string(trace.RefLevelUnits)},
+		{"SweepTime", trace.SweepTime, string(trace.SweepTimeUnits)},
+	} {
+		root.SetFloat64(s.name, s.value)
+		root.SetString(s.name+"Units", s.units)
+	}
+	root.SetInt64("NumPoints", int64(trace.NumPoints))
+
+	ds := root.Dataset("Frequency", trace.Frequency)
+	ds.SetString("Label", trace.FreqLabel)
+	ds.SetString("Units", trace.FreqUnits)
+	for i, t := range trace.Traces() {
+		if len(t.Values) == 0 {
+			continue
+		}
+		if len(t.Values) != len(trace.Frequency) {
+			return fmt.Errorf("mismatched lengths / freq %d / trace %d %d", len(trace.Frequency), i+1, len(t.Values))
+		}
+		ds := root.Dataset(fmt.Sprintf("Trace%d", i+1), t.Values)
+		ds.SetString("Label", t.Label)
+		ds.SetString("Units", t.Units)
+	}
+	return hdf5.Write(w, root)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/hdf5"
+)
+
+func TestWriteHDF5(t *testing.T) {
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := trace.WriteHDF5(&buf); err != nil {
+		t.Fatalf("error writing HDF5: %s", err)
+	}
+	f, err := hdf5.Open(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("error opening HDF5: %s", err)
+	}
+	root, err := f.Root()
+	if err != nil {
+		t.Fatalf("error reading root group: %s", err)
+	}
+	attrs, err := root.Attributes()
+	if err != nil {
+		t.Fatalf("error reading attributes: %s", err)
+	}
+	var tests = []struct {
+		name string
+		want interface{}
+	}{
+		{"Model", trace.Model},
+		{"SerialNumber", trace.SerialNum},
+		{"CenterFrequency", trace.CenterFreq},
+		{"CenterFrequencyUnits", string(trace.CenterFreqUnits)},
+		{"ResolutionBandwidth", trace.RBW},
+		{"ReferenceLevelUnits", string(trace.RefLevelUnits)},
+		{"SweepTime", trace.SweepTime},
+		{"NumPoints", int64(401)},
+		{"Timestamp", "2021-11-16T10:50:45Z"},
+	}
+	for _, test := range tests {
+		assert(t, test.name, attrs[test.name], test.want)
+	}
+	names, err := root.Children()
+	if err != nil {
+		t.Fatalf("error listing datasets: %s", err)
+	}
+	if want := []string{"Frequency", "Trace1", "Trace2", "Trace3"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("\ngot  = %v\nwant = %v", names, want)
+	}
+	for name, want := range map[string][]float64{"Frequency": trace.Frequency, "Trace1": trace.Trace1} {
+		ds, err := root.Child(name)
+		if err != nil {
+			t.Fatalf("error getting %s: %s", name, err)
+		}
+		got, err := ds.Float64s()
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s values don't match (%v)", name, err)
+		}
+		attrs, err := ds.Attributes()
+		if err != nil {
+			t.Fatalf("error reading %s attributes: %s", name, err)
+		}
+		if _, ok := attrs["Units"]; !ok {
+			t.Errorf("missing units attribute for %s", name)
+		}
+	}
+
+	short := standardTrace()
+	short.Trace1 = short.Trace1[:1]
+	if err := short.WriteHDF5(&buf); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// headerLine describes a recognized header line following the first
+// (date/filename) line of the CSV file.
+type headerLine struct {
+	label   string
+	ordinal string
+	name    string
+	entries int
+	parse   func(trace *Trace, columns []string) error
+}
+
+// headerLines lists the header lines in the order written by the ESA.
+var headerLines = []headerLine{
+	{"Title", "second", "title", 2, func(trace *Trace, columns []string) error {
+		trace.Title = columns[1]
+		return nil
+	}},
+	{"Model", "third", "model", 2, func(trace *Trace, columns []string) error {
+		trace.Model = columns[1]
+		return nil
+	}},
+	{"Serial Number", "fourth", "serial number", 2, func(trace *Trace, columns []string) error {
+		trace.SerialNum = columns[1]
+		return nil
+	}},
+	{"Center Frequency", "fifth", "center freq", 3, func(trace *Trace, columns []string) (err error) {
+		trace.CenterFreq, trace.CenterFreqUnits, err = parseFrequencySetting(columns, "center frequency")
+		return err
+	}},
+	{"Span", "sixth", "span", 3, func(trace *Trace, columns []string) (err error) {
+		trace.Span, trace.SpanUnits, err = parseFrequencySetting(columns, "span")
+		return err
+	}},
+	{"Resolution Bandwidth", "seventh", "rbw", 3, func(trace *Trace, columns []string) (err error) {
+		trace.RBW, trace.RBWUnits, err = parseFrequencySetting(columns, "rbw")
+		return err
+	}},
+	{"Video Bandwidth", "eighth", "vbw", 3, func(trace *Trace, columns []string) (err error) {
+		trace.VBW, trace.VBWUnits, err = parseFrequencySetting(columns, "vbw")
+		return err
+	}},
+	{"Reference Level", "ninth", "ref level", 3, func(trace *Trace, columns []string) error {
+		refLevel, err := strconv.ParseFloat(columns[1], 64)
+		if err != nil {
+			return fmt.Errorf("error parsing ref level: %s", err)
+		}
+		trace.RefLevel = refLevel
+		trace.RefLevelUnits, err = ParseAmplitudeUnits(columns[2])
+		if err != nil {
+			return fmt.Errorf("error parsing ref level units: %s", err)
+		}
+		return nil
+	}},
+	{"Sweep Time", "tenth", "sweep time", 3, func(trace *Trace, columns []string) error {
+		sweepTime, err := strconv.ParseFloat(columns[1], 64)
+		if err != nil {
+			return fmt.Errorf("error parsing sweep time: %s", err)
+		}
+		trace.SweepTime = sweepTime
+		trace.SweepTimeUnits, err = ParseTimeUnits(columns[2])
+		if err != nil {
+			return fmt.Errorf("error parsing sweep time units: %s", err)
+		}
+		return nil
+	}},
+	{"Num Points", "eleventh", "num points", 2, func(trace *Trace, columns []string) error {
+		numPoints, err := strconv.Atoi(columns[1])
+		if err != nil {
+			return fmt.Errorf("error parsing num points: %s", err)
+		}
+		trace.NumPoints = numPoints
+		return nil
+	}},
+}
+
+// extendedHeaderLines lists the optional header lines written by some
+// firmware revisions, which may appear anywhere in the header and so don't
+// have an ordinal.
+var extendedHeaderLines = []headerLine{
+	{"Detector", "", "detector", 2, func(trace *Trace, columns []string) (err error) {
+		trace.Detector, err = ParseDetector(columns[1])
+		return err
+	}},
+	{"Attenuation", "", "attenuation", 3, func(trace *Trace, columns []string) error {
+		atten, err := strconv.ParseFloat(strings.TrimSpace(columns[1]), 64)
+		if err != nil {
+			return fmt.Errorf("error parsing attenuation: %s", err)
+		}
+		if units := strings.TrimSpace(columns[2]); !strings.EqualFold(units, "dB") {
+			return fmt.Errorf("error parsing attenuation units: %s", units)
+		}
+		trace.Attenuation = atten
+		return nil
+	}},
+	{"Preamp", "", "preamp", 2, func(trace *Trace, columns []string) (err error) {
+		if trace.Preamp, err = parseOnOff(strings.TrimSpace(columns[1])); err != nil {
+			return fmt.Errorf("error parsing preamp: %s", err)
+		}
+		return nil
+	}},
+	{"Input Coupling", "", "coupling", 2, func(trace *Trace, columns []string) (err error) {
+		trace.Coupling, err = ParseCoupling(columns[1])
+		return err
+	}},
+}
+
+// headerAliases maps the alternative labels written by some firmware
+// revisions to the key of the recognized header line.
+var headerAliases = map[string]string{
+	"center freq":      "center frequency",
+	"res bw":           "resolution bandwidth",
+	"rbw":              "resolution bandwidth",
+	"video bw":         "video bandwidth",
+	"vbw":              "video bandwidth",
+	"ref level":        "reference level",
+	"swp time":         "sweep time",
+	"num pts":          "num points",
+	"number of points": "num points",
+	"atten":            "attenuation",
+	"coupling":         "input coupling",
+}
+
+// headerKeys maps the key of each recognized header line and alias to the
+// header line.
+var headerKeys = newHeaderKeys()
+
+func newHeaderKeys() map[string]headerLine {
+	keys := make(map[string]headerLine)
+	for _, lines := range [][]headerLine{headerLines, extendedHeaderLines} {
+		for _, hl := range lines {
+			keys[headerKey(hl.label)] = hl
+		}
+	}
+	for alias, key := range headerAliases {
+		keys[alias] = keys[key]
+	}
+	return keys
+}
+
+// headerKey returns the key of the label column of a header line, which is
+// the label in lower case without the trailing colon or extra whitespace.
+func headerKey(column string) string {
+	label := strings.TrimSuffix(strings.TrimSpace(column), ":")
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// lookupHeaderLine returns the recognized header line with the label column.
+func lookupHeaderLine(column string) (headerLine, bool) {
+	hl, ok := headerKeys[headerKey(column)]
+	return hl, ok
+}
+
+// isHeaderLabel reports whether the label column ends in a colon, which
+// distinguishes the header lines from the trace label line.
+func isHeaderLabel(column string) bool {
+	return strings.HasSuffix(strings.TrimSpace(column), ":")
+}
+
+// matches reports whether the label column of a line is the header line's
+// label.
+func (hl headerLine) matches(column string) bool {
+	return headerKey(column) == headerKey(hl.label)
+}
+
+// parseColumns checks the number of entries of a header line matching the
+// label and parses it.
+func (hl headerLine) parseColumns(trace *Trace, columns []string) error {
+	if len(columns) != hl.entries {
+		return fmt.Errorf(
+			"error in %s line: wrong number of entries / got %d / expected %d",
+			hl.name, len(columns), hl.entries,
+		)
+	}
+	return hl.parse(trace, columns)
+}
+
+func parseFrequencySetting(columns []string, name string) (float64, FrequencyUnits, error) {
+	value, err := strconv.ParseFloat(columns[1], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("error parsing %s: %s", name, err)
+	}
+	units, err := ParseFrequencyUnits(columns[2])
+	if err != nil {
+		return 0, "", fmt.Errorf("error parsing %s units: %s", name, err)
+	}
+	return value, units, nil
+}
+
+// addExtraHeader stores a header line with an unrecognized label in
+// ExtraHeaders.
+func (trace *Trace) addExtraHeader(columns []string) {
+	if trace.ExtraHeaders == nil {
+		trace.ExtraHeaders = make(map[string]string)
+	}
+	label := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(columns[0]), ":"))
+	trace.ExtraHeaders[label] = strings.Join(trimAll(columns[1:]), ",")
+}
+
+// parseHeaderByPosition parses the header lines in the order written by the
+// ESA and returns the trace label line. Extended header lines may appear
+// anywhere, and header lines with unrecognized labels after the standard
+// lines are stored in ExtraHeaders.
+func (trace *Trace) parseHeaderByPosition(scanner *headerScanner, strict bool) (string, error) {
+	for _, hl := range headerLines {
+		line, err := trace.nextStandardHeaderLine(scanner, false)
+		if err != nil {
+			return "", err
+		}
+		columns, err := splitColumns(line, hl.entries)
+		if err != nil {
+			return "", fmt.Errorf("error in %s (%s) line: %s", hl.ordinal, hl.name, err)
+		}
+		if strict && !hl.matches(columns[0]) {
+			return "", fmt.Errorf("error in %s (%s) line: unexpected label %s", hl.ordinal, hl.name, strings.TrimSpace(columns[0]))
+		}
+		if err := hl.parse(trace, columns); err != nil {
+			return "", err
+		}
+	}
+
+	// Skip lines 12 and 13, which are blank, after any additional header
+	// lines.
+	for _, lineNum := range []int{12, 13} {
+		line, err := trace.nextStandardHeaderLine(scanner, true)
+		if err != nil {
+			return "", err
+		}
+		if strict && strings.TrimSpace(line) != "" {
+			return "", fmt.Errorf("expected blank line %d: %s", lineNum, line)
+		}
+	}
+
+	// The 14th line should contain the labels for the frequency and trace
+	// data.
+	scanner.Scan()
+	return scanner.Text(), nil
+}
+
+// nextStandardHeaderLine returns the next line that isn't an extended header
+// line, parsing any extended header lines before it. If extra is true, header
+// lines with unrecognized labels are also stored in ExtraHeaders instead of
+// being returned.
+func (trace *Trace) nextStandardHeaderLine(scanner *headerScanner, extra bool) (string, error) {
+	for scanner.Scan() {
+		line := scanner.Text()
+		columns := strings.Split(line, ",")
+		hl, ok := lookupHeaderLine(columns[0])
+		switch {
+		case ok && hl.ordinal == "":
+			if err := hl.parseColumns(trace, columns); err != nil {
+				return "", err
+			}
+		case !ok && extra && isHeaderLabel(columns[0]):
+			trace.addExtraHeader(columns)
+		default:
+			return line, nil
+		}
+	}
+	return "", nil
+}
+
+// parseHeaderByLabel parses the header lines in any order until the trace
+// label line, which is the first line whose label doesn't end in a colon.
+// Header lines with unrecognized labels are stored in ExtraHeaders. It
+// returns the trace label line and whether the header contained the number
+// of points.
+func (trace *Trace) parseHeaderByLabel(nextLine func() string) (string, bool, error) {
+	numPointsKnown := false
+	for {
+		line := nextLine()
+		columns := strings.Split(line, ",")
+		if !isHeaderLabel(columns[0]) {
+			return line, numPointsKnown, nil
+		}
+		hl, ok := lookupHeaderLine(columns[0])
+		if !ok {
+			trace.addExtraHeader(columns)
+			continue
+		}
+		if err := hl.parseColumns(trace, columns); err != nil {
+			return "", false, err
+		}
+		if hl.name == "num points" {
+			numPointsKnown = true
+		}
+	}
+}
+
+func splitColumns(line string, numEntries int) ([]string, error) {
+	s := strings.Split(line, ",")
+	if len(s) != numEntries {
+		return s, fmt.Errorf("wrong number of entries / got %d / expected %d", len(s), numEntries)
+	}
+	return s, nil
+}
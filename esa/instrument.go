@@ -0,0 +1,281 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpi"
+)
+
+// Instrument is a live ESA spectrum analyzer controlled using SCPI commands
+// sent over an io.ReadWriter, such as a connection from the gotmc visa,
+// usbtmc, or vxi11 packages.
+type Instrument struct {
+	conn *scpi.Conn
+	// now returns the time used as the trace timestamp.
+	now func() time.Time
+}
+
+// NewInstrument returns an ESA using the given connection, which must
+// terminate each response with a newline.
+func NewInstrument(rw io.ReadWriter) *Instrument {
+	return &Instrument{conn: scpi.New(rw), now: time.Now}
+}
+
+// FetchTrace queries the sweep settings and the data of trace 1, 2, and 3,
+// returning the same Trace as reading a CSV file saved by the instrument.
+// Frequencies are in Hz and the sweep time is in seconds. The timestamp is
+// the time the trace was fetched in UTC, and the original filename is empty.
+func (inst *Instrument) FetchTrace() (Trace, error) {
+	var trace Trace
+	id, err := inst.conn.Identify()
+	if err != nil {
+		return trace, err
+	}
+	trace.Timestamp = inst.now().UTC().Truncate(time.Second)
+	trace.Model = id.Model
+	trace.SerialNum = id.SerialNum
+	if trace.Title, err = inst.conn.QueryString(":DISP:ANN:TITL:DATA?"); err != nil {
+		return trace, err
+	}
+
+	for _, q := range []struct {
+		query string
+		value *float64
+	}{
+		{":FREQ:CENT?", &trace.CenterFreq},
+		{":FREQ:SPAN?", &trace.Span},
+		{":BAND?", &trace.RBW},
+		{":BAND:VID?", &trace.VBW},
+		{":DISP:WIND:TRAC:Y:RLEV?", &trace.RefLevel},
+		{":SWE:TIME?", &trace.SweepTime},
+	} {
+		if *q.value, err = inst.conn.QueryFloat(q.query); err != nil {
+			return trace, err
+		}
+	}
+	trace.CenterFreqUnits = Hertz
+	trace.SpanUnits = Hertz
+	trace.RBWUnits = Hertz
+	trace.VBWUnits = Hertz
+	trace.SweepTimeUnits = Seconds
+	units, err := inst.conn.Query(":UNIT:POW?")
+	if err != nil {
+		return trace, err
+	}
+	if trace.RefLevelUnits, err = ParseAmplitudeUnits(units); err != nil {
+		return trace, err
+	}
+	if trace.NumPoints, err = inst.conn.QueryInt(":SWE:POIN?"); err != nil {
+		return trace, err
+	}
+
+	trace.FreqUnits = string(Hertz)
+	trace.Frequency = make([]float64, trace.NumPoints)
+	for i := range trace.Frequency {
+		trace.Frequency[i] = trace.FrequencyAt(i)
+	}
+	if err := inst.conn.Command(":FORM:DATA ASC"); err != nil {
+		return trace, err
+	}
+	traces := make([]TraceData, 3)
+	for i := range traces {
+		values, err := inst.conn.QueryFloats(fmt.Sprintf(":TRAC:DATA? TRACE%d", i+1))
+		if err != nil {
+			return trace, err
+		}
+		if len(values) != trace.NumPoints {
+			return trace, fmt.Errorf("wrong number of trace %d points / got %d / expected %d",
+				i+1, len(values), trace.NumPoints)
+		}
+		traces[i] = TraceData{fmt.Sprintf("Trace %d", i+1), string(trace.RefLevelUnits), values}
+	}
+	trace.SetTraces(traces)
+	return trace, nil
+}
+
+// TraceMode is the update mode of a trace.
+type TraceMode string
+
+// Available trace modes.
+const (
+	ClearWrite TraceMode = "Clear Write"
+	MaxHold    TraceMode = "Max Hold"
+	MinHold    TraceMode = "Min Hold"
+	View       TraceMode = "View"
+	Blank      TraceMode = "Blank"
+)
+
+var traceModeMnemonics = map[TraceMode]string{
+	ClearWrite: "WRIT",
+	MaxHold:    "MAXH",
+	MinHold:    "MINH",
+	View:       "VIEW",
+	Blank:      "BLAN",
+}
+
+var detectorMnemonics = map[Detector]string{
+	DetectorNormal:       "NORM",
+	DetectorPeak:         "POS",
+	DetectorNegativePeak: "NEG",
+	DetectorSample:       "SAMP",
+	DetectorAverage:      "AVER",
+	DetectorQuasiPeak:    "QPE",
+	DetectorEMIAverage:   "EAV",
+}
+
+// ReadbackError is returned by the setters when the value read back from the
+// instrument doesn't match the requested value, such as when the instrument
+// coerces the RBW to the nearest available bandwidth.
+type ReadbackError struct {
+	Setting   string
+	Requested float64
+	Actual    float64
+}
+
+func (e *ReadbackError) Error() string {
+	return fmt.Sprintf("%s read back as %g instead of %g", e.Setting, e.Actual, e.Requested)
+}
+
+// readbackTolerance is the relative difference allowed between the
+// requested and read back values.
+const readbackTolerance = 1e-6
+
+// set sends the command with the value, queries the setting, and verifies
+// the value read back.
+func (inst *Instrument) set(setting, command string, value float64) error {
+	if err := inst.conn.Command("%s %g", command, value); err != nil {
+		return err
+	}
+	actual, err := inst.conn.QueryFloat(command + "?")
+	if err != nil {
+		return err
+	}
+	if math.Abs(actual-value) > readbackTolerance*math.Max(math.Abs(value), 1) {
+		return &ReadbackError{setting, value, actual}
+	}
+	return nil
+}
+
+// SetCenterFreq sets the center frequency in Hz.
+func (inst *Instrument) SetCenterFreq(freq float64) error {
+	return inst.set("center frequency", ":FREQ:CENT", freq)
+}
+
+// SetSpan sets the frequency span in Hz.
+func (inst *Instrument) SetSpan(span float64) error {
+	return inst.set("span", ":FREQ:SPAN", span)
+}
+
+// SetRBW sets the resolution bandwidth in Hz, which must be one of the
+// bandwidths available on the instrument.
+func (inst *Instrument) SetRBW(rbw float64) error {
+	return inst.set("rbw", ":BAND", rbw)
+}
+
+// SetVBW sets the video bandwidth in Hz, which must be one of the bandwidths
+// available on the instrument.
+func (inst *Instrument) SetVBW(vbw float64) error {
+	return inst.set("vbw", ":BAND:VID", vbw)
+}
+
+// SetRefLevel sets the reference level in the current amplitude units.
+func (inst *Instrument) SetRefLevel(level float64) error {
+	return inst.set("ref level", ":DISP:WIND:TRAC:Y:RLEV", level)
+}
+
+// SetSweepTime sets the sweep time in seconds.
+func (inst *Instrument) SetSweepTime(sweepTime float64) error {
+	return inst.set("sweep time", ":SWE:TIME", sweepTime)
+}
+
+// SetNumPoints sets the number of points in the sweep.
+func (inst *Instrument) SetNumPoints(n int) error {
+	return inst.set("num points", ":SWE:POIN", float64(n))
+}
+
+// SetDetector sets the detector mode.
+func (inst *Instrument) SetDetector(detector Detector) error {
+	mnemonic, ok := detectorMnemonics[detector]
+	if !ok {
+		return fmt.Errorf("unknown detector: %s", detector)
+	}
+	if err := inst.conn.Command(":DET %s", mnemonic); err != nil {
+		return err
+	}
+	actual, err := inst.Detector()
+	if err != nil {
+		return err
+	}
+	if actual != detector {
+		return fmt.Errorf("detector read back as %s instead of %s", actual, detector)
+	}
+	return nil
+}
+
+// Detector queries the detector mode.
+func (inst *Instrument) Detector() (Detector, error) {
+	s, err := inst.conn.Query(":DET?")
+	if err != nil {
+		return "", err
+	}
+	return ParseDetector(s)
+}
+
+// SetTraceMode sets the update mode of trace 1, 2, or 3.
+func (inst *Instrument) SetTraceMode(trace int, mode TraceMode) error {
+	mnemonic, ok := traceModeMnemonics[mode]
+	if !ok {
+		return fmt.Errorf("unknown trace mode: %s", mode)
+	}
+	if trace < 1 || trace > 3 {
+		return fmt.Errorf("invalid trace %d", trace)
+	}
+	if err := inst.conn.Command(":TRAC%d:MODE %s", trace, mnemonic); err != nil {
+		return err
+	}
+	actual, err := inst.TraceMode(trace)
+	if err != nil {
+		return err
+	}
+	if actual != mode {
+		return fmt.Errorf("trace %d mode read back as %s instead of %s", trace, actual, mode)
+	}
+	return nil
+}
+
+// TraceMode queries the update mode of trace 1, 2, or 3.
+func (inst *Instrument) TraceMode(trace int) (TraceMode, error) {
+	if trace < 1 || trace > 3 {
+		return "", fmt.Errorf("invalid trace %d", trace)
+	}
+	s, err := inst.conn.Query(fmt.Sprintf(":TRAC%d:MODE?", trace))
+	if err != nil {
+		return "", err
+	}
+	for mode, mnemonic := range traceModeMnemonics {
+		if strings.EqualFold(s, mnemonic) {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown trace mode: %s", s)
+}
+
+// screenFile is the file on the ESA's internal C: drive used to transfer
+// screen captures.
+const screenFile = `C:SCREEN.GIF`
+
+// Screen returns a capture of the instrument display, which the ESA saves as
+// a GIF image.
+func (inst *Instrument) Screen() (image.Image, error) {
+	return inst.conn.Screen(screenFile)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpitest"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+// newFakeESA returns a fake ESA whose settings are coerced to the given
+// responses.
+func newFakeESA(coerced map[string]string) *scpitest.Instrument {
+	return scpitest.New(map[string]string{
+		"*IDN?":                   "Hewlett-Packard, E4402B, US41192390, A.14.01",
+		":DISP:ANN:TITL:DATA?":    `"LISN L1"`,
+		":FREQ:CENT?":             "+3.40000000000E+004",
+		":FREQ:SPAN?":             "+5.00000000000E+004",
+		":BAND?":                  "+1.00000000E+003",
+		":BAND:VID?":              "+1.00000000E+003",
+		":DISP:WIND:TRAC:Y:RLEV?": "+1.06990000E+002",
+		":SWE:TIME?":              "+8.50000000E-002",
+		":UNIT:POW?":              "DBUV",
+		":SWE:POIN?":              "+5",
+		":TRAC:DATA? TRACE1":      "+5.90097E+01,+5.92727E+01,+5.70000E+01,+5.70377E+01,+5.68447E+01",
+		":TRAC:DATA? TRACE2":      "4.76E+01,4.37E+01,4.0E+01,2.88E+01,2.97E+01",
+		":TRAC:DATA? TRACE3":      "4.52E+01,4.12E+01,3.9E+01,2.61E+01,2.71E+01",
+	}, scpitest.WithSettings(coerced))
+}
+
+func TestInstrumentFetchTrace(t *testing.T) {
+	fake := newFakeESA(nil)
+	inst := NewInstrument(fake)
+	inst.now = func() time.Time { return time.Date(2021, 11, 16, 10, 50, 45, 500, time.UTC) }
+	trace, err := inst.FetchTrace()
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "timestamp", trace.Timestamp, time.Date(2021, 11, 16, 10, 50, 45, 0, time.UTC))
+	assert(t, "model", trace.Model, "E4402B")
+	assert(t, "s/n", trace.SerialNum, "US41192390")
+	assert(t, "title", trace.Title, "LISN L1")
+	assertFloat64(t, "center", trace.CenterFreq, 34000, 1e-9)
+	assert(t, "center units", trace.CenterFreqUnits, Hertz)
+	assertFloat64(t, "ref level", trace.RefLevel, 106.99, 1e-9)
+	assert(t, "ref level units", trace.RefLevelUnits, DBuV)
+	assert(t, "sweep time units", trace.SweepTimeUnits, Seconds)
+	assert(t, "num points", trace.NumPoints, 5)
+	assertFloat64(t, "freq 0", trace.Frequency[0], 9000, 1e-9)
+	assertFloat64(t, "freq 4", trace.Frequency[4], 59000, 1e-9)
+	assertFloat64(t, "trace 1", trace.Trace1[4], 56.8447, 1e-9)
+	assertFloat64(t, "trace 3", trace.Trace3[0], 45.2, 1e-9)
+	assert(t, "trace 2 label", trace.Trace2Label, "Trace 2")
+	assert(t, "trace 2 units", trace.Trace2Units, "dBuV")
+	if warnings := trace.Validate(); len(warnings) != 0 {
+		t.Errorf("got warnings: %v", warnings)
+	}
+
+	fake = newFakeESA(nil)
+	fake.Responses[":TRAC:DATA? TRACE2"] = "1,2,3"
+	if _, err := NewInstrument(fake).FetchTrace(); err == nil {
+		t.Errorf("expected error for wrong number of trace points")
+	}
+}
+
+func TestInstrumentSetters(t *testing.T) {
+	fake := newFakeESA(map[string]string{":BAND": "+3.00000000E+003"})
+	inst := NewInstrument(fake)
+	var tests = []struct {
+		name    string
+		set     func() error
+		command string
+	}{
+		{"center", func() error { return inst.SetCenterFreq(1.5e6) }, ":FREQ:CENT 1.5e+06"},
+		{"span", func() error { return inst.SetSpan(2e6) }, ":FREQ:SPAN 2e+06"},
+		{"vbw", func() error { return inst.SetVBW(10e3) }, ":BAND:VID 10000"},
+		{"ref level", func() error { return inst.SetRefLevel(-10) }, ":DISP:WIND:TRAC:Y:RLEV -10"},
+		{"sweep time", func() error { return inst.SetSweepTime(0.05) }, ":SWE:TIME 0.05"},
+		{"num points", func() error { return inst.SetNumPoints(801) }, ":SWE:POIN 801"},
+		{"detector", func() error { return inst.SetDetector(DetectorQuasiPeak) }, ":DET QPE"},
+		{"trace mode", func() error { return inst.SetTraceMode(2, MaxHold) }, ":TRAC2:MODE MAXH"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n := len(fake.Commands)
+			if err := test.set(); err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "command", fake.Commands[n], test.command)
+			assert(t, "num commands", len(fake.Commands), n+2)
+		})
+	}
+	detector, err := inst.Detector()
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "detector", detector, DetectorQuasiPeak)
+	mode, err := inst.TraceMode(2)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "trace mode", mode, MaxHold)
+
+	err = inst.SetRBW(2e3)
+	var readback *ReadbackError
+	if !errors.As(err, &readback) {
+		t.Fatalf("got error %v, want ReadbackError", err)
+	}
+	assert(t, "readback", *readback, ReadbackError{"rbw", 2e3, 3e3})
+	if err := inst.SetTraceMode(4, View); err == nil {
+		t.Errorf("expected error for invalid trace")
+	}
+	if err := inst.SetDetector("Fancy"); err == nil {
+		t.Errorf("expected error for unknown detector")
+	}
+}
+
+func TestInstrumentScreen(t *testing.T) {
+	src := image.NewPaletted(image.Rect(0, 0, 4, 3), color.Palette{color.Black, color.White})
+	src.SetColorIndex(2, 1, 1)
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeESA(nil)
+	fake.Responses["*OPC?"] = "1"
+	fake.Responses[":MMEM:DATA? 'C:SCREEN.GIF'"] = string(block.Encode(buf.Bytes()))
+	img, err := NewInstrument(fake).Screen()
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "bounds", img.Bounds(), src.Bounds())
+	assert(t, "pixel", color.GrayModel.Convert(img.At(2, 1)), color.Color(color.Gray{0xff}))
+	assert(t, "delete", fake.Commands[len(fake.Commands)-1], ":MMEM:DEL 'C:SCREEN.GIF'")
+}
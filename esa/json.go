@@ -0,0 +1,270 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// JSONSchema and JSONSchemaVersion identify the JSON encoding of a Trace.
+// The version is incremented whenever a change to the schema could break an
+// existing consumer; adding optional members doesn't change the version.
+const (
+	JSONSchema        = "keysight.esa.trace"
+	JSONSchemaVersion = 1
+)
+
+// jsonTrace is version 1 of the JSON schema of a Trace:
+//
+//	{
+//	  "schema": "keysight.esa.trace",
+//	  "version": 1,
+//	  "timestamp": "2021-11-16T10:50:45Z",
+//	  "originalFilename": "C:\\TRACE924.CSV",
+//	  "title": "",
+//	  "model": "E4402B",
+//	  "serialNumber": "MY45104598",
+//	  "centerFrequency": {"value": 34000, "units": "Hz"},
+//	  "span": {"value": 50000, "units": "Hz"},
+//	  "rbw": {"value": 1000, "units": "Hz"},
+//	  "vbw": {"value": 1000, "units": "Hz"},
+//	  "referenceLevel": {"value": 106.99, "units": "dBuV"},
+//	  "sweepTime": {"value": 0.085, "units": "s"},
+//	  "numPoints": 401,
+//	  "frequency": {"label": "", "units": "Hz", "values": [9000, ...]},
+//	  "traces": [
+//	    {"label": "Trace 1", "units": "dBuV", "values": [59.0097, ...]},
+//	    ...
+//	  ],
+//	  "extraHeaders": {"Firmware": "A.08.05"},
+//	  "rawHeader": [" 11/16/21   10:50:45,C:\\TRACE924.CSV", ...],
+//	  "provenance": {
+//	    "source": "testdata/e4402b_trace924.csv",
+//	    "sha256": "5f0c...",
+//	    "parsedAt": "2024-05-06T07:08:09.123456789Z",
+//	    "parserVersion": "v1.2.0"
+//	  }
+//	}
+//
+// The timestamp is in RFC 3339 format and is left out if unknown. Units are
+// the strings written by the instrument, which may be empty. Traces always
+// has entries for Trace 1, 2, and 3, followed by any extra trace columns. Since JSON doesn't support
+// infinity or NaN, those values are encoded as the strings "+Inf", "-Inf",
+// and "NaN". ExtraHeaders, RawHeader, and Provenance are left out if
+// they aren't set.
+type jsonTrace struct {
+	Schema           string     `json:"schema"`
+	Version          int        `json:"version"`
+	Timestamp        *time.Time `json:"timestamp,omitempty"`
+	OriginalFilename string     `json:"originalFilename"`
+	Title            string     `json:"title"`
+	Model            string     `json:"model"`
+	SerialNum        string     `json:"serialNumber"`
+	CenterFreq       jsonValue  `json:"centerFrequency"`
+	Span             jsonValue  `json:"span"`
+	RBW              jsonValue  `json:"rbw"`
+	VBW              jsonValue  `json:"vbw"`
+	RefLevel         jsonValue  `json:"referenceLevel"`
+	SweepTime        jsonValue  `json:"sweepTime"`
+	NumPoints        int        `json:"numPoints"`
+	Frequency        jsonData   `json:"frequency"`
+	Traces           []jsonData `json:"traces"`
+
+	ExtraHeaders map[string]string `json:"extraHeaders,omitempty"`
+	RawHeader    []string          `json:"rawHeader,omitempty"`
+	Provenance   *jsonProvenance   `json:"provenance,omitempty"`
+}
+
+type jsonProvenance struct {
+	Source        string     `json:"source"`
+	SHA256        string     `json:"sha256"`
+	ParsedAt      *time.Time `json:"parsedAt,omitempty"`
+	ParserVersion string     `json:"parserVersion"`
+}
+
+type jsonValue struct {
+	Value jsonFloat `json:"value"`
+	Units string    `json:"units"`
+}
+
+type jsonData struct {
+	Label  string      `json:"label"`
+	Units  string      `json:"units"`
+	Values []jsonFloat `json:"values"`
+}
+
+// jsonFloat encodes infinity and NaN as strings.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Inf"`), nil
+	}
+	return strconv.AppendFloat(nil, v, 'g', -1, 64), nil
+}
+
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case `"NaN"`:
+		*f = jsonFloat(math.NaN())
+		return nil
+	case `"+Inf"`, `"Inf"`:
+		*f = jsonFloat(math.Inf(1))
+		return nil
+	case `"-Inf"`:
+		*f = jsonFloat(math.Inf(-1))
+		return nil
+	}
+	v, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid number: %s", data)
+	}
+	*f = jsonFloat(v)
+	return nil
+}
+
+func toJSONFloats(values []float64) []jsonFloat {
+	floats := make([]jsonFloat, len(values))
+	for i, v := range values {
+		floats[i] = jsonFloat(v)
+	}
+	return floats
+}
+
+func fromJSONFloats(floats []jsonFloat) []float64 {
+	if floats == nil {
+		return nil
+	}
+	values := make([]float64, len(floats))
+	for i, f := range floats {
+		values[i] = float64(f)
+	}
+	return values
+}
+
+// MarshalJSON implements the json.Marshaler interface using the versioned
+// schema identified by JSONSchema and JSONSchemaVersion.
+func (trace Trace) MarshalJSON() ([]byte, error) {
+	jt := jsonTrace{
+		Schema:           JSONSchema,
+		Version:          JSONSchemaVersion,
+		OriginalFilename: trace.OriginalFilename,
+		Title:            trace.Title,
+		Model:            trace.Model,
+		SerialNum:        trace.SerialNum,
+		CenterFreq:       jsonValue{jsonFloat(trace.CenterFreq), string(trace.CenterFreqUnits)},
+		Span:             jsonValue{jsonFloat(trace.Span), string(trace.SpanUnits)},
+		RBW:              jsonValue{jsonFloat(trace.RBW), string(trace.RBWUnits)},
+		VBW:              jsonValue{jsonFloat(trace.VBW), string(trace.VBWUnits)},
+		RefLevel:         jsonValue{jsonFloat(trace.RefLevel), string(trace.RefLevelUnits)},
+		SweepTime:        jsonValue{jsonFloat(trace.SweepTime), string(trace.SweepTimeUnits)},
+		NumPoints:        trace.NumPoints,
+		Frequency:        jsonData{trace.FreqLabel, trace.FreqUnits, toJSONFloats(trace.Frequency)},
+		ExtraHeaders:     trace.ExtraHeaders,
+		RawHeader:        trace.RawHeader,
+	}
+	if p := trace.Provenance; p != (Provenance{}) {
+		jt.Provenance = &jsonProvenance{Source: p.Source, SHA256: p.SHA256, ParserVersion: p.ParserVersion}
+		if !p.ParsedAt.IsZero() {
+			jt.Provenance.ParsedAt = &p.ParsedAt
+		}
+	}
+	traces := trace.Traces()
+	for len(traces) < 3 {
+		traces = append(traces, TraceData{})
+	}
+	for _, t := range traces {
+		jt.Traces = append(jt.Traces, jsonData{t.Label, t.Units, toJSONFloats(t.Values)})
+	}
+	if !trace.Timestamp.IsZero() {
+		jt.Timestamp = &trace.Timestamp
+	}
+	return json.Marshal(jt)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It returns an
+// error if the data uses a different schema or a newer schema version.
+func (trace *Trace) UnmarshalJSON(data []byte) error {
+	var jt jsonTrace
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return err
+	}
+	if jt.Schema != JSONSchema {
+		return fmt.Errorf("unknown schema: %q", jt.Schema)
+	}
+	if jt.Version < 1 || jt.Version > JSONSchemaVersion {
+		return fmt.Errorf("unsupported schema version: %d", jt.Version)
+	}
+	if len(jt.Traces) == 0 {
+		return fmt.Errorf("missing traces")
+	}
+	t := Trace{
+		OriginalFilename: jt.OriginalFilename,
+		Title:            jt.Title,
+		Model:            jt.Model,
+		SerialNum:        jt.SerialNum,
+		CenterFreq:       float64(jt.CenterFreq.Value),
+		Span:             float64(jt.Span.Value),
+		RBW:              float64(jt.RBW.Value),
+		VBW:              float64(jt.VBW.Value),
+		RefLevel:         float64(jt.RefLevel.Value),
+		SweepTime:        float64(jt.SweepTime.Value),
+		NumPoints:        jt.NumPoints,
+		FreqLabel:        jt.Frequency.Label,
+		FreqUnits:        jt.Frequency.Units,
+		Frequency:        fromJSONFloats(jt.Frequency.Values),
+		ExtraHeaders:     jt.ExtraHeaders,
+		RawHeader:        jt.RawHeader,
+	}
+	if p := jt.Provenance; p != nil {
+		t.Provenance = Provenance{Source: p.Source, SHA256: p.SHA256, ParserVersion: p.ParserVersion}
+		if p.ParsedAt != nil {
+			t.Provenance.ParsedAt = *p.ParsedAt
+		}
+	}
+	traces := make([]TraceData, len(jt.Traces))
+	for i, data := range jt.Traces {
+		traces[i] = TraceData{data.Label, data.Units, fromJSONFloats(data.Values)}
+	}
+	t.SetTraces(traces)
+	if jt.Timestamp != nil {
+		t.Timestamp = *jt.Timestamp
+	}
+	var err error
+	freqUnits := []struct {
+		label string
+		units string
+		dest  *FrequencyUnits
+	}{
+		{"center frequency", jt.CenterFreq.Units, &t.CenterFreqUnits},
+		{"span", jt.Span.Units, &t.SpanUnits},
+		{"rbw", jt.RBW.Units, &t.RBWUnits},
+		{"vbw", jt.VBW.Units, &t.VBWUnits},
+	}
+	for _, f := range freqUnits {
+		if *f.dest, err = ParseFrequencyUnits(f.units); err != nil {
+			return fmt.Errorf("error parsing %s units: %s", f.label, err)
+		}
+	}
+	if t.RefLevelUnits, err = ParseAmplitudeUnits(jt.RefLevel.Units); err != nil {
+		return fmt.Errorf("error parsing ref level units: %s", err)
+	}
+	if t.SweepTimeUnits, err = ParseTimeUnits(jt.SweepTime.Units); err != nil {
+		return fmt.Errorf("error parsing sweep time units: %s", err)
+	}
+	*trace = t
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceJSONRoundTrip(t *testing.T) {
+	var tests = []string{
+		"./testdata/e4402b_trace924.csv",
+		"./testdata/e4411b_trace080.csv",
+	}
+	for _, filename := range tests {
+		t.Run(filename, func(t *testing.T) {
+			want, err := ReadCSVFile(filename)
+			if err != nil {
+				t.Fatalf("received error reading CSV file: %s", err)
+			}
+			data, err := json.Marshal(want)
+			if err != nil {
+				t.Fatalf("received error marshalling: %s", err)
+			}
+			var got Trace
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("received error unmarshalling: %s", err)
+			}
+			if !got.Timestamp.Equal(want.Timestamp) {
+				t.Errorf("timestamp = %s / want %s", got.Timestamp, want.Timestamp)
+			}
+			got.Timestamp = want.Timestamp
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip trace differs\ngot  = %+v\nwant = %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTraceMarshalJSON(t *testing.T) {
+	trace := Trace{
+		Timestamp:      time.Date(2021, time.November, 16, 10, 50, 45, 0, time.UTC),
+		Model:          "E4402B",
+		CenterFreq:     1.5,
+		SpanUnits:      Megahertz,
+		RefLevel:       -10,
+		RefLevelUnits:  DBm,
+		SweepTimeUnits: Seconds,
+		NumPoints:      2,
+		FreqUnits:      "Hz",
+		Trace1Label:    "Trace 1",
+		Frequency:      []float64{1, 2},
+		Trace1:         []float64{-20.5, math.Inf(-1)},
+	}
+	data, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatalf("received error marshalling: %s", err)
+	}
+	want := `{"schema":"keysight.esa.trace","version":1,"timestamp":"2021-11-16T10:50:45Z",` +
+		`"originalFilename":"","title":"","model":"E4402B","serialNumber":"",` +
+		`"centerFrequency":{"value":1.5,"units":""},"span":{"value":0,"units":"MHz"},` +
+		`"rbw":{"value":0,"units":""},"vbw":{"value":0,"units":""},` +
+		`"referenceLevel":{"value":-10,"units":"dBm"},"sweepTime":{"value":0,"units":"s"},` +
+		`"numPoints":2,"frequency":{"label":"","units":"Hz","values":[1,2]},` +
+		`"traces":[{"label":"Trace 1","units":"","values":[-20.5,"-Inf"]},` +
+		`{"label":"","units":"","values":[]},{"label":"","units":"","values":[]}]}`
+	if string(data) != want {
+		t.Errorf("\ngot  = %s\nwant = %s", data, want)
+	}
+	var got Trace
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("received error unmarshalling: %s", err)
+	}
+	assert(t, "trace 1 inf", math.IsInf(got.Trace1[1], -1), true)
+	assert(t, "span units", got.SpanUnits, Megahertz)
+}
+
+func TestTraceUnmarshalJSONErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"invalid json", `{"schema":`},
+		{"wrong schema", `{"schema":"keysight.xseries.trace","version":1}`},
+		{"newer version", `{"schema":"keysight.esa.trace","version":2}`},
+		{"missing traces", `{"schema":"keysight.esa.trace","version":1,"traces":[]}`},
+		{"bad units", `{"schema":"keysight.esa.trace","version":1,"span":{"value":1,"units":"furlongs"},` +
+			`"traces":[{},{},{}]}`},
+		{"bad value", `{"schema":"keysight.esa.trace","version":1,"traces":[{"values":["big"]},{},{}]}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var trace Trace
+			if err := json.Unmarshal([]byte(test.given), &trace); err == nil {
+				t.Errorf("expected error unmarshalling %s", test.given)
+			}
+		})
+	}
+}
+
+func TestTraceJSONExtraHeaders(t *testing.T) {
+	want := Trace{
+		Frequency:    []float64{1},
+		Trace1:       []float64{-20},
+		ExtraHeaders: map[string]string{"Firmware": "A.08.05"},
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("received error marshalling: %s", err)
+	}
+	if !strings.Contains(string(data), `"extraHeaders":{"Firmware":"A.08.05"}`) {
+		t.Errorf("JSON doesn't contain the extra headers: %s", data)
+	}
+	var got Trace
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("received error unmarshalling: %s", err)
+	}
+	assert(t, "firmware", got.ExtraHeaders["Firmware"], "A.08.05")
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/internal/limit"
+)
+
+// limitDataLabels is the line separating the limit line header from the
+// breakpoints.
+const limitDataLabels = "Frequency,Amplitude,Connected"
+
+// LimitResult is the result of evaluating a trace against a limit line.
+type LimitResult struct {
+	// Pass reports whether every tested point is within the limit.
+	Pass bool
+	// MarginPass reports whether every tested point is within the margin
+	// line. It's the same as Pass if the margin is off.
+	MarginPass bool
+	// WorstMargin is the smallest distance in dB between the trace and the
+	// limit line, which is negative if the trace fails the limit.
+	WorstMargin    float64
+	WorstFrequency float64
+	// Failures contains the indices of the trace points that fail the
+	// limit.
+	Failures []int
+	// Tested is the number of trace points within the frequency range of the
+	// limit line.
+	Tested int
+}
+
+// ReadLimitLineFile reads the limit line from the given CSV filename.
+func ReadLimitLineFile(filename string) (LimitLine, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return LimitLine{}, err
+	}
+	defer file.Close()
+	return ReadLimitLine(file)
+}
+
+// ReadLimitLine reads a limit line from the given io.Reader. The header uses
+// the same label/value layout as the trace CSV file and is followed by the
+// breakpoints, such as:
+//
+//	Limit Line:              ,1
+//	Type:                    ,Upper
+//	Units:                   ,dBuV
+//	Frequency Interpolation: ,Log
+//	Margin:                  ,On,-3
+//	Frequency,Amplitude,Connected
+//	1.50000e+05,6.60000e+01,1
+//
+// Limit lines saved in the instrument's internal binary format (.LIM) return
+// ErrInternalFormat.
+func ReadLimitLine(r io.Reader) (LimitLine, error) {
+	line := LimitLine{Type: UpperLimit}
+	br := bufio.NewReader(r)
+	if isBinary(br) {
+		return line, ErrInternalFormat
+	}
+	scanner := bufio.NewScanner(br)
+	lineNum := 0
+	inData := false
+	for scanner.Scan() {
+		lineNum++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		columns := trimAll(strings.Split(text, ","))
+		if inData {
+			point, err := parseLimitPoint(columns)
+			if err != nil {
+				return line, fmt.Errorf("error parsing limit point in line %d: %s", lineNum, err)
+			}
+			line.Points = append(line.Points, point)
+			continue
+		}
+		if strings.EqualFold(columns[0], "Frequency") {
+			inData = true
+			continue
+		}
+		if err := line.parseHeader(columns); err != nil {
+			return line, fmt.Errorf("error in limit line header line %d: %s", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return line, err
+	}
+	if !inData {
+		return line, fmt.Errorf("missing limit line points")
+	}
+	return line, nil
+}
+
+func (line *LimitLine) parseHeader(columns []string) error {
+	label := strings.TrimSuffix(columns[0], ":")
+	values := columns[1:]
+	if len(values) == 0 {
+		return fmt.Errorf("missing value for %s", label)
+	}
+	value := values[0]
+	var err error
+	switch strings.ToLower(label) {
+	case "limit line":
+		line.Number, err = strconv.Atoi(value)
+	case "type":
+		switch {
+		case strings.EqualFold(value, string(UpperLimit)):
+			line.Type = UpperLimit
+		case strings.EqualFold(value, string(LowerLimit)):
+			line.Type = LowerLimit
+		default:
+			err = fmt.Errorf("unknown limit line type: %s", value)
+		}
+	case "description":
+		line.Description = strings.Join(values, ",")
+	case "units":
+		line.Units, err = ParseAmplitudeUnits(value)
+	case "frequency interpolation":
+		switch strings.ToLower(value) {
+		case "log", "logarithmic":
+			line.LogFrequency = true
+		case "lin", "linear":
+			line.LogFrequency = false
+		default:
+			err = fmt.Errorf("unknown frequency interpolation: %s", value)
+		}
+	case "margin":
+		if line.MarginOn, err = parseOnOff(value); err != nil {
+			return err
+		}
+		if len(values) > 1 {
+			line.Margin, err = strconv.ParseFloat(values[1], 64)
+		}
+	default:
+		err = fmt.Errorf("unknown limit line setting: %s", label)
+	}
+	return err
+}
+
+func parseLimitPoint(columns []string) (LimitPoint, error) {
+	if len(columns) < 2 || len(columns) > 3 {
+		return LimitPoint{}, fmt.Errorf("wrong number of entries / got %d / expected 2 or 3", len(columns))
+	}
+	freq, amp, err := parseFloatPair(columns)
+	if err != nil {
+		return LimitPoint{}, err
+	}
+	point := LimitPoint{Frequency: freq, Amplitude: amp}
+	if len(columns) == 3 {
+		connected, err := parseOnOff(columns[2])
+		if err != nil {
+			return point, err
+		}
+		point.Disconnected = !connected
+	}
+	return point, nil
+}
+
+// WriteCSVFile writes the limit line to the given filename.
+func (line LimitLine) WriteCSVFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := line.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSV writes the limit line to the given io.Writer in the layout read by
+// ReadLimitLine.
+func (line LimitLine) WriteCSV(w io.Writer) error {
+	if line.Type != UpperLimit && line.Type != LowerLimit {
+		return fmt.Errorf("unknown limit line type: %s", line.Type)
+	}
+	bw := bufio.NewWriter(w)
+	writeHeaderLine(bw, "Limit Line:", strconv.Itoa(line.Number))
+	writeHeaderLine(bw, "Type:", string(line.Type))
+	if line.Description != "" {
+		writeHeaderLine(bw, "Description:", line.Description)
+	}
+	if line.Units != "" {
+		writeHeaderLine(bw, "Units:", string(line.Units))
+	}
+	interp := "Linear"
+	if line.LogFrequency {
+		interp = "Log"
+	}
+	writeHeaderLine(bw, "Frequency Interpolation:", interp)
+	margin := "Off"
+	if line.MarginOn {
+		margin = "On"
+	}
+	writeHeaderLine(bw, "Margin:", margin, formatInt(line.Margin))
+	fmt.Fprintln(bw, limitDataLabels)
+	for _, p := range line.Points {
+		connected := 1
+		if p.Disconnected {
+			connected = 0
+		}
+		fmt.Fprintf(bw, "%s,%s,%d\n", formatExp(p.Frequency), formatExp(p.Amplitude), connected)
+	}
+	return bw.Flush()
+}
+
+// Evaluate tests Trace 1 of the given trace against the limit line.
+func (line LimitLine) Evaluate(trace Trace) (LimitResult, error) {
+	return line.EvaluateValues(trace.Frequency, trace.Trace1)
+}
+
+// EvaluateValues tests the amplitude values at the given frequencies in Hz
+// against the limit line. Points outside the frequency range of the limit
+// line aren't tested.
+func (line LimitLine) EvaluateValues(freqs, values []float64) (LimitResult, error) {
+	result, err := line.limit().Evaluate(freqs, values)
+	return LimitResult(result), err
+}
+
+// At returns the limit at the given frequency in Hz. It returns false if the
+// frequency isn't covered by the limit line.
+func (line LimitLine) At(freq float64) (float64, bool) {
+	return line.limit().At(freq)
+}
+
+func (line LimitLine) limit() limit.Line {
+	l := limit.Line{
+		Upper:        line.Type != LowerLimit,
+		LogFrequency: line.LogFrequency,
+		Margin:       line.Margin,
+		MarginOn:     line.MarginOn,
+		Points:       make([]limit.Point, len(line.Points)),
+	}
+	for i, p := range line.Points {
+		l.Points[i] = limit.Point(p)
+	}
+	return l
+}
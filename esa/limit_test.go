@@ -0,0 +1,127 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadLimitLineFile(t *testing.T) {
+	line, err := ReadLimitLineFile("./testdata/cispr_limit.csv")
+	if err != nil {
+		t.Fatalf("received error reading limit line: %s", err)
+	}
+	assert(t, "number", line.Number, 1)
+	assert(t, "type", line.Type, UpperLimit)
+	assert(t, "description", line.Description, "Conducted emissions")
+	assert(t, "units", line.Units, DBuV)
+	assert(t, "log frequency", line.LogFrequency, true)
+	assert(t, "margin on", line.MarginOn, true)
+	assertFloat64(t, "margin", line.Margin, -3, 1e-12)
+	assert(t, "num points", len(line.Points), 4)
+	assertFloat64(t, "point 1 freq", line.Points[1].Frequency, 50e3, 1e-9)
+	assertFloat64(t, "point 1 amp", line.Points[1].Amplitude, 80, 1e-9)
+	assert(t, "point 2 disconnected", line.Points[2].Disconnected, true)
+	assert(t, "point 3 disconnected", line.Points[3].Disconnected, false)
+}
+
+func TestLimitLineWriteCSVRoundTrip(t *testing.T) {
+	want, err := os.ReadFile("./testdata/cispr_limit.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	line, err := ReadLimitLine(bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("received error reading limit line: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := line.WriteCSV(&buf); err != nil {
+		t.Fatalf("received error writing limit line: %s", err)
+	}
+	if got := buf.String(); got != string(want) {
+		t.Errorf("\ngot  = %q\nwant = %q", got, want)
+	}
+}
+
+func TestLimitLineEvaluate(t *testing.T) {
+	line, err := ReadLimitLineFile("./testdata/cispr_limit.csv")
+	if err != nil {
+		t.Fatalf("received error reading limit line: %s", err)
+	}
+	limit, ok := line.At(math.Sqrt(9e3 * 50e3))
+	assert(t, "log midpoint found", ok, true)
+	assertFloat64(t, "log midpoint", limit, 85, 1e-9)
+	limit, _ = line.At(50e3)
+	assertFloat64(t, "shared frequency", limit, 70, 1e-9)
+	_, ok = line.At(200e3)
+	assert(t, "outside range", ok, false)
+
+	freqs := []float64{1e3, math.Sqrt(9e3 * 50e3), 50e3, 75e3}
+	values := []float64{100, 84, 68, 71}
+	result, err := line.EvaluateValues(freqs, values)
+	if err != nil {
+		t.Fatalf("received error evaluating values: %s", err)
+	}
+	assert(t, "pass", result.Pass, false)
+	assert(t, "margin pass", result.MarginPass, false)
+	assert(t, "tested", result.Tested, 3)
+	assert(t, "num failures", len(result.Failures), 1)
+	assert(t, "failure", result.Failures[0], 3)
+	assertFloat64(t, "worst margin", result.WorstMargin, -1, 1e-9)
+	assertFloat64(t, "worst freq", result.WorstFrequency, 75e3, 1e-9)
+
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("received error reading trace: %s", err)
+	}
+	result, err = line.Evaluate(trace)
+	if err != nil {
+		t.Fatalf("received error evaluating trace: %s", err)
+	}
+	assert(t, "trace pass", result.Pass, true)
+	assert(t, "trace margin pass", result.MarginPass, true)
+	assert(t, "trace tested", result.Tested, 401)
+
+	if _, err := line.EvaluateValues(freqs, values[:2]); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+}
+
+func TestReadLimitLineErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"missing points", "Limit Line:,1\nType:,Upper\n"},
+		{"unknown type", "Type:,Sideways\nFrequency,Amplitude,Connected\n"},
+		{"unknown units", "Units:,furlongs\nFrequency,Amplitude,Connected\n"},
+		{"unknown setting", "Color:,Red\nFrequency,Amplitude,Connected\n"},
+		{"bad margin", "Margin:,Maybe,-3\nFrequency,Amplitude,Connected\n"},
+		{"bad point", "Frequency,Amplitude,Connected\n1e3,abc,1\n"},
+		{"bad connected", "Frequency,Amplitude,Connected\n1e3,50,2\n"},
+		{"binary", "\x00\x01\x02LIMIT"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadLimitLine(strings.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func FuzzReadLimitLine(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadLimitLine(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// ParseResult is the result of parsing one of the files given to ParseFiles.
+// If the file couldn't be read or parsed, Err is the error and the trace may
+// be incomplete.
+type ParseResult struct {
+	Filename string
+	Trace    Trace
+	Err      error
+}
+
+// ParseFiles parses the CSV trace files in parallel using the given number of
+// workers, or GOMAXPROCS workers if workers isn't positive. The results are
+// returned in the same order as the filenames, and a file that fails to parse
+// doesn't stop the others from being parsed. If the context is canceled,
+// files that haven't been started are not parsed, their results contain the
+// context's error, and ParseFiles returns the context's error.
+func ParseFiles(ctx context.Context, filenames []string, workers int, opts ...Option) ([]ParseResult, error) {
+	results := make([]ParseResult, len(filenames))
+	for i, filename := range filenames {
+		results[i].Filename = filename
+	}
+	parsed := make([]bool, len(filenames))
+	err := parallel(ctx, len(filenames), workers, func(i int) {
+		results[i].Trace, results[i].Err = ReadCSVFile(filenames[i], opts...)
+		parsed[i] = true
+	})
+	if err != nil {
+		for i := range results {
+			if !parsed[i] {
+				results[i].Err = err
+			}
+		}
+	}
+	return results, err
+}
+
+// parallel calls fn for the indices 0 to n-1 using a pool of workers, which
+// defaults to GOMAXPROCS if not positive. It stops starting new calls once
+// the context is done, and returns the context's error if any calls weren't
+// made.
+func parallel(ctx context.Context, n, workers int, fn func(i int)) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+	var err error
+dispatch:
+	for i := 0; i < n; i++ {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break dispatch
+		}
+	}
+	close(indices)
+	wg.Wait()
+	return err
+}
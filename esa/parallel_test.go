@@ -0,0 +1,54 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseFiles(t *testing.T) {
+	filenames := []string{
+		"./testdata/e4402b_trace924.csv",
+		"./testdata/missing.csv",
+		"./testdata/e4411b_trace080.csv",
+		"./testdata/cispr_limit.csv",
+		"./testdata/e4402b_trace924.csv",
+	}
+	results, err := ParseFiles(context.Background(), filenames, 2)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	var tests = []struct {
+		model string
+		err   bool
+	}{
+		{"E4402B", false},
+		{"", true},
+		{"E4411B", false},
+		{"", true},
+		{"E4402B", false},
+	}
+	assert(t, "num results", len(results), len(tests))
+	for i, test := range tests {
+		assert(t, "filename", results[i].Filename, filenames[i])
+		assert(t, "model", results[i].Trace.Model, test.model)
+		assert(t, "error", results[i].Err != nil, test.err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = ParseFiles(ctx, filenames, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("\ngot  = %v\nwant = %v", err, context.Canceled)
+	}
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("got %v for %s, want canceled", r.Err, r.Filename)
+		}
+	}
+}
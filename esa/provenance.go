@@ -0,0 +1,87 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bufio"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// modulePath is the path of the module containing the parser, whose version
+// is recorded in the provenance.
+const modulePath = "github.com/gotmc/keysight"
+
+// Provenance records where and how a trace was parsed, so that archived
+// traces can be audited against the original files.
+type Provenance struct {
+	// Source is the name of the file the trace was read from, which is
+	// empty if unknown.
+	Source string
+	// SHA256 is the hex-encoded SHA-256 hash of the bytes read.
+	SHA256 string
+	// ParsedAt is the UTC time the trace was parsed.
+	ParsedAt time.Time
+	// ParserVersion is the version of the module containing the parser, or
+	// "(devel)" if it isn't known, such as when running its own tests.
+	ParserVersion string
+}
+
+// WithSource sets the source filename recorded in the provenance of the
+// trace, such as the name of an uploaded file. ReadCSVFile and ReadCSVFS use
+// the name of the file read.
+func WithSource(name string) Option {
+	return func(cfg *parseConfig) {
+		cfg.source = name
+	}
+}
+
+var (
+	parserVersionOnce sync.Once
+	parserVersionText string
+)
+
+// ParserVersion returns the version of the module containing the parser as
+// recorded in the build information of the binary, or "(devel)" if it isn't
+// known.
+func ParserVersion() string {
+	parserVersionOnce.Do(func() {
+		parserVersionText = "(devel)"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Main.Path == modulePath && info.Main.Version != "" {
+			parserVersionText = info.Main.Version
+			return
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				parserVersionText = dep.Version
+				return
+			}
+		}
+	})
+	return parserVersionText
+}
+
+// headerScanner is a bufio.Scanner that records the lines scanned while
+// recording is true, which is used to keep the verbatim header lines.
+type headerScanner struct {
+	*bufio.Scanner
+	recording bool
+	lines     []string
+}
+
+// Scan advances the scanner to the next line, recording it if needed.
+func (s *headerScanner) Scan() bool {
+	ok := s.Scanner.Scan()
+	if ok && s.recording {
+		s.lines = append(s.lines, s.Text())
+	}
+	return ok
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// RedactOption configures Redact.
+type RedactOption func(*redactConfig)
+
+type redactConfig struct {
+	hash bool
+	key  []byte
+}
+
+// WithRedactHash replaces the identifying fields with a keyed hash instead
+// of removing them, so that traces from the same instrument or file can
+// still be matched without revealing the values. The hash is the first 16
+// hex digits of the HMAC-SHA256 of the value using the key, which should be
+// kept private since serial numbers are easily guessed.
+func WithRedactHash(key string) RedactOption {
+	return func(cfg *redactConfig) {
+		cfg.hash = true
+		cfg.key = []byte(key)
+	}
+}
+
+// Redact returns a copy of the trace without the fields that could identify
+// the instrument, the user, or the device under test, such as before the
+// trace is published. The serial number, title, original filename, and the
+// source filename of the provenance are removed, or hashed with
+// WithRedactHash, and the raw header, which contains them verbatim, is
+// removed. The measurement settings and data are kept intact.
+func (trace Trace) Redact(opts ...RedactOption) Trace {
+	var cfg redactConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	redact := func(s string) string {
+		if !cfg.hash || s == "" {
+			return ""
+		}
+		mac := hmac.New(sha256.New, cfg.key)
+		mac.Write([]byte(s))
+		return hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+	trace.SerialNum = redact(trace.SerialNum)
+	trace.Title = redact(trace.Title)
+	trace.OriginalFilename = redact(trace.OriginalFilename)
+	trace.Provenance.Source = redact(trace.Provenance.Source)
+	trace.RawHeader = nil
+	return trace
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("received error reading trace: %s", err)
+	}
+	trace.Title = "Unit 42 emissions"
+
+	got := trace.Redact()
+	assert(t, "serial", got.SerialNum, "")
+	assert(t, "title", got.Title, "")
+	assert(t, "original filename", got.OriginalFilename, "")
+	assert(t, "source", got.Provenance.Source, "")
+	assert(t, "raw header", len(got.RawHeader), 0)
+	assert(t, "model", got.Model, "E4402B")
+	assert(t, "rbw", got.RBW, trace.RBW)
+	assert(t, "timestamp", got.Timestamp, trace.Timestamp)
+	assert(t, "sha256", got.Provenance.SHA256, trace.Provenance.SHA256)
+	assert(t, "points", len(got.Trace2), 401)
+	assert(t, "value", got.Trace2[7], trace.Trace2[7])
+	assert(t, "original serial", trace.SerialNum, "MY45104598")
+
+	hashed := trace.Redact(WithRedactHash("secret"))
+	assert(t, "hashed serial length", len(hashed.SerialNum), 16)
+	assert(t, "hashed serial differs", hashed.SerialNum != trace.SerialNum, true)
+	assert(t, "hashed title differs", hashed.Title != hashed.SerialNum, true)
+	assert(t, "same key", trace.Redact(WithRedactHash("secret")).SerialNum, hashed.SerialNum)
+	assert(t, "other key", trace.Redact(WithRedactHash("other")).SerialNum != hashed.SerialNum, true)
+	empty := trace
+	empty.Title = ""
+	assert(t, "empty title", empty.Redact(WithRedactHash("secret")).Title, "")
+
+	// The redacted trace can still be written and read back.
+	var b bytes.Buffer
+	if err := got.WriteCSV(&b); err != nil {
+		t.Fatalf("error writing redacted trace: %s", err)
+	}
+	if strings.Contains(b.String(), "MY45104598") || strings.Contains(b.String(), "TRACE924") {
+		t.Errorf("redacted CSV contains identifying fields:\n%s", b.String()[:200])
+	}
+	if _, err := ReadCSV(&b, WithStrict()); err != nil {
+		t.Errorf("error reading redacted trace: %s", err)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Detector is the detector mode used by the spectrum analyzer.
+type Detector string
+
+// Available detector modes.
+const (
+	DetectorNormal       Detector = "Normal"
+	DetectorPeak         Detector = "Peak"
+	DetectorNegativePeak Detector = "Negative Peak"
+	DetectorSample       Detector = "Sample"
+	DetectorAverage      Detector = "Average"
+	DetectorQuasiPeak    Detector = "Quasi Peak"
+	DetectorEMIAverage   Detector = "EMI Average"
+)
+
+var detectors = map[string]Detector{
+	"normal":        DetectorNormal,
+	"norm":          DetectorNormal,
+	"peak":          DetectorPeak,
+	"pos":           DetectorPeak,
+	"negative peak": DetectorNegativePeak,
+	"neg":           DetectorNegativePeak,
+	"sample":        DetectorSample,
+	"samp":          DetectorSample,
+	"average":       DetectorAverage,
+	"aver":          DetectorAverage,
+	"rms":           DetectorAverage,
+	"quasi peak":    DetectorQuasiPeak,
+	"qpe":           DetectorQuasiPeak,
+	"emi average":   DetectorEMIAverage,
+	"eav":           DetectorEMIAverage,
+}
+
+// ParseDetector parses the detector mode using either the name shown on the
+// instrument or the SCPI mnemonic.
+func ParseDetector(s string) (Detector, error) {
+	s = strings.TrimSpace(s)
+	detector, ok := detectors[strings.ToLower(s)]
+	if !ok {
+		return "", fmt.Errorf("unknown detector: %s", s)
+	}
+	return detector, nil
+}
+
+// Coupling is the input coupling of the spectrum analyzer.
+type Coupling string
+
+// Available input couplings.
+const (
+	CouplingAC Coupling = "AC"
+	CouplingDC Coupling = "DC"
+)
+
+// ParseCoupling parses the input coupling.
+func ParseCoupling(s string) (Coupling, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.EqualFold(s, string(CouplingAC)):
+		return CouplingAC, nil
+	case strings.EqualFold(s, string(CouplingDC)):
+		return CouplingDC, nil
+	}
+	return "", fmt.Errorf("unknown coupling: %s", s)
+}
+
+// Marker is a marker placed on a trace.
+type Marker struct {
+	Number    int
+	Frequency float64
+	Amplitude float64
+}
+
+// LimitType determines whether a limit line is an upper or a lower limit.
+type LimitType string
+
+// Available limit line types.
+const (
+	UpperLimit LimitType = "Upper"
+	LowerLimit LimitType = "Lower"
+)
+
+// LimitPoint is a frequency and amplitude breakpoint of a limit line. A
+// Disconnected point starts a new segment that isn't joined to the previous
+// point.
+type LimitPoint struct {
+	Frequency    float64
+	Amplitude    float64
+	Disconnected bool
+}
+
+// LimitLine is a limit line defined by a set of frequency/amplitude
+// breakpoints. The frequencies are in Hz.
+type LimitLine struct {
+	Number      int
+	Type        LimitType
+	Description string
+	Units       AmplitudeUnits
+	// LogFrequency interpolates between breakpoints using a logarithmic
+	// frequency axis instead of a linear one.
+	LogFrequency bool
+	// Margin is the offset in dB of the margin line from the limit line, which
+	// is only used if MarginOn is true.
+	Margin   float64
+	MarginOn bool
+	Points   []LimitPoint
+}
+
+func parseOnOff(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "1":
+		return true, nil
+	case "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected on or off: %s", s)
+}
+
+func parseFloatPair(values []string) (float64, float64, error) {
+	a, err := strconv.ParseFloat(strings.TrimSpace(values[0]), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := strconv.ParseFloat(strings.TrimSpace(values[1]), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a, b, nil
+}
+
+func trimAll(values []string) []string {
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return trimmed
+}
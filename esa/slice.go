@@ -0,0 +1,104 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"math"
+)
+
+// Band is a frequency range in Hz, such as the channel of a known emitter.
+type Band struct {
+	Name  string
+	Start float64
+	Stop  float64
+}
+
+// Contains reports whether the frequency is within the band.
+func (b Band) Contains(freq float64) bool {
+	return freq >= b.Start && freq <= b.Stop
+}
+
+// Slice returns a copy of the trace restricted to the points from the start
+// to the stop frequency in Hz inclusive. The center frequency, span, and
+// number of points are updated to match the points kept, with the
+// frequencies in Hz.
+func (trace Trace) Slice(start, stop float64) (Trace, error) {
+	if start > stop {
+		return trace, fmt.Errorf("start frequency %g Hz is above stop frequency %g Hz", start, stop)
+	}
+	if err := trace.checkLengths(); err != nil {
+		return trace, err
+	}
+	lo, hi := -1, -1
+	for i, f := range trace.Frequency {
+		if f >= start && f <= stop {
+			if lo < 0 {
+				lo = i
+			}
+			hi = i
+		}
+	}
+	if lo < 0 {
+		return trace, fmt.Errorf("no points from %g to %g Hz", start, stop)
+	}
+	cut := func(values []float64) []float64 {
+		if len(values) == 0 {
+			return values
+		}
+		return append([]float64(nil), values[lo:hi+1]...)
+	}
+	traces := trace.Traces()
+	for i := range traces {
+		traces[i].Values = cut(traces[i].Values)
+	}
+	trace.Frequency = cut(trace.Frequency)
+	trace.SetTraces(traces)
+	first, last := trace.Frequency[0], trace.Frequency[len(trace.Frequency)-1]
+	trace.CenterFreq, trace.CenterFreqUnits = (first+last)/2, Hertz
+	trace.Span, trace.SpanUnits = last-first, Hertz
+	trace.NumPoints = len(trace.Frequency)
+	return trace, nil
+}
+
+// Mask returns a copy of the trace with the values of every trace column
+// within any of the bands set to NaN, such as to exclude known intentional
+// emitters before a peak search. The peak searches of the analysis package
+// skip the masked points.
+func (trace Trace) Mask(bands []Band) (Trace, error) {
+	if err := trace.checkLengths(); err != nil {
+		return trace, err
+	}
+	traces := trace.Traces()
+	for i := range traces {
+		if len(traces[i].Values) == 0 {
+			continue
+		}
+		values := append([]float64(nil), traces[i].Values...)
+		for j, f := range trace.Frequency {
+			for _, b := range bands {
+				if b.Contains(f) {
+					values[j] = math.NaN()
+					break
+				}
+			}
+		}
+		traces[i].Values = values
+	}
+	trace.SetTraces(traces)
+	return trace, nil
+}
+
+// checkLengths returns an error unless each trace column containing data
+// has a value for each frequency.
+func (trace Trace) checkLengths() error {
+	for i, t := range trace.Traces() {
+		if len(t.Values) > 0 && len(t.Values) != len(trace.Frequency) {
+			return fmt.Errorf("mismatched lengths / freq %d / trace %d %d", len(trace.Frequency), i+1, len(t.Values))
+		}
+	}
+	return nil
+}
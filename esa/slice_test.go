@@ -0,0 +1,65 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSlice(t *testing.T) {
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("received error reading trace: %s", err)
+	}
+	got, err := trace.Slice(20e3, 30.1e3)
+	if err != nil {
+		t.Fatalf("received error slicing trace: %s", err)
+	}
+	assert(t, "num points", got.NumPoints, 81)
+	assert(t, "freq points", len(got.Frequency), 81)
+	assert(t, "trace 3 points", len(got.Trace3), 81)
+	assert(t, "first freq", got.Frequency[0], 20e3)
+	assert(t, "last freq", got.Frequency[80], 30e3)
+	assert(t, "first value", got.Trace1[0], trace.Trace1[88])
+	assert(t, "center", got.CenterFreq, 25e3)
+	assert(t, "span", got.Span, 10e3)
+	assert(t, "span units", got.SpanUnits, Hertz)
+	assert(t, "valid", len(got.Validate()), 0)
+	got.Trace1[0] = 0
+	assert(t, "original unchanged", trace.Trace1[88] != 0, true)
+
+	if _, err := trace.Slice(1e9, 2e9); err == nil {
+		t.Error("expected error for slice without points")
+	}
+	if _, err := trace.Slice(30e3, 20e3); err == nil {
+		t.Error("expected error for start above stop")
+	}
+}
+
+func TestMask(t *testing.T) {
+	trace := Trace{
+		Frequency: []float64{1e6, 2e6, 3e6, 4e6, 5e6},
+		Trace1:    []float64{-80, -20, -70, -30, -90},
+		Trace2:    []float64{-81, -21, -71, -31, -91},
+	}
+	got, err := trace.Mask([]Band{{Name: "beacon", Start: 1.5e6, Stop: 2.5e6}, {Start: 4e6, Stop: 4e6}})
+	if err != nil {
+		t.Fatalf("received error masking trace: %s", err)
+	}
+	for _, i := range []int{1, 3} {
+		assert(t, "masked trace 1", math.IsNaN(got.Trace1[i]), true)
+		assert(t, "masked trace 2", math.IsNaN(got.Trace2[i]), true)
+	}
+	assert(t, "unmasked", got.Trace1[2], -70.0)
+	assert(t, "trace 3 empty", len(got.Trace3), 0)
+	assert(t, "original unchanged", trace.Trace1[1], -20.0)
+
+	trace.Trace2 = trace.Trace2[:2]
+	if _, err := trace.Mask(nil); err == nil {
+		t.Error("expected error for mismatched lengths")
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetadataMode determines where WriteStandardCSV writes the trace header
+// metadata.
+type MetadataMode int
+
+// Available metadata modes.
+const (
+	// MetadataNone leaves out the metadata.
+	MetadataNone MetadataMode = iota
+	// MetadataComments writes the metadata as "# label: value" comment lines
+	// before the header row.
+	MetadataComments
+	// MetadataSidecar writes the metadata as a two column CSV file next to
+	// the data file, which is only supported by WriteStandardCSVFile.
+	MetadataSidecar
+)
+
+// SidecarSuffix is appended to the name of the CSV file to get the name of
+// the metadata sidecar file.
+const SidecarSuffix = ".meta.csv"
+
+// StandardCSVOption configures WriteStandardCSV.
+type StandardCSVOption func(*standardCSVConfig)
+
+type standardCSVConfig struct {
+	metadata MetadataMode
+}
+
+// WithMetadata sets where the trace metadata is written. The default is
+// MetadataNone.
+func WithMetadata(mode MetadataMode) StandardCSVOption {
+	return func(cfg *standardCSVConfig) {
+		cfg.metadata = mode
+	}
+}
+
+// WriteStandardCSVFile writes the trace as a standard CSV file to the given
+// filename. With MetadataSidecar, the metadata is written to the filename
+// with SidecarSuffix appended.
+func (trace Trace) WriteStandardCSVFile(filename string, opts ...StandardCSVOption) error {
+	cfg := standardCSVConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.metadata == MetadataSidecar {
+		if err := writeFile(filename+SidecarSuffix, trace.writeMetadataCSV); err != nil {
+			return err
+		}
+		opts = append(opts, WithMetadata(MetadataNone))
+	}
+	return writeFile(filename, func(w io.Writer) error {
+		return trace.WriteStandardCSV(w, opts...)
+	})
+}
+
+func writeFile(filename string, write func(io.Writer) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteStandardCSV writes the trace as an RFC 4180 CSV with a single header
+// row followed by a row for each frequency. The columns are the frequency in
+// Hz and each trace containing data, labeled with its units, such as
+// "Trace 1 (dBuV)".
+func (trace Trace) WriteStandardCSV(w io.Writer, opts ...StandardCSVOption) error {
+	cfg := standardCSVConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.metadata == MetadataSidecar {
+		return fmt.Errorf("metadata sidecar requires WriteStandardCSVFile")
+	}
+	n := len(trace.Frequency)
+	labels := []string{"Frequency (Hz)"}
+	columns := [][]float64{trace.Frequency}
+	for i, t := range trace.Traces() {
+		if len(t.Values) == 0 {
+			continue
+		}
+		if len(t.Values) != n {
+			return fmt.Errorf("mismatched lengths / freq %d / trace %d %d", n, i+1, len(t.Values))
+		}
+		label := strings.TrimSpace(t.Label)
+		if label == "" {
+			label = fmt.Sprintf("Trace %d", i+1)
+		}
+		if units := strings.TrimSpace(t.Units); units != "" {
+			label += " (" + units + ")"
+		}
+		labels = append(labels, label)
+		columns = append(columns, t.Values)
+	}
+
+	if cfg.metadata == MetadataComments {
+		for _, m := range trace.metadata() {
+			if _, err := fmt.Fprintf(w, "# %s: %s\n", m[0], m[1]); err != nil {
+				return err
+			}
+		}
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(labels); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for i := 0; i < n; i++ {
+		for j, c := range columns {
+			record[j] = strconv.FormatFloat(c[i], 'g', -1, 64)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (trace Trace) writeMetadataCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Label", "Value"}); err != nil {
+		return err
+	}
+	for _, m := range trace.metadata() {
+		if err := cw.Write(m[:]); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// metadata returns the label and value of each header setting.
+func (trace Trace) metadata() [][2]string {
+	withUnits := func(v float64, units string) string {
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if units = strings.TrimSpace(units); units != "" {
+			s += " " + units
+		}
+		return s
+	}
+	timestamp := ""
+	if !trace.Timestamp.IsZero() {
+		timestamp = trace.Timestamp.Format(time.RFC3339)
+	}
+	return [][2]string{
+		{"Timestamp", timestamp},
+		{"Original Filename", trace.OriginalFilename},
+		{"Title", trace.Title},
+		{"Model", trace.Model},
+		{"Serial Number", trace.SerialNum},
+		{"Center Frequency", withUnits(trace.CenterFreq, string(trace.CenterFreqUnits))},
+		{"Span", withUnits(trace.Span, string(trace.SpanUnits))},
+		{"Resolution Bandwidth", withUnits(trace.RBW, string(trace.RBWUnits))},
+		{"Video Bandwidth", withUnits(trace.VBW, string(trace.VBWUnits))},
+		{"Reference Level", withUnits(trace.RefLevel, string(trace.RefLevelUnits))},
+		{"Sweep Time", withUnits(trace.SweepTime, string(trace.SweepTimeUnits))},
+		{"Num Points", strconv.Itoa(trace.NumPoints)},
+	}
+}
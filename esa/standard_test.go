@@ -0,0 +1,106 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func standardTrace() Trace {
+	return Trace{
+		Timestamp:     time.Date(2021, time.November, 16, 10, 50, 45, 0, time.UTC),
+		Model:         "E4402B",
+		Title:         "Filter, passband",
+		RBW:           1000,
+		RBWUnits:      Hertz,
+		RefLevel:      -10,
+		RefLevelUnits: DBm,
+		NumPoints:     2,
+		Trace1Label:   "Trace 1",
+		Trace1Units:   "dBm",
+		Frequency:     []float64{9000, 9125.5},
+		Trace1:        []float64{-50.25, -51},
+		Trace3:        []float64{-60, -61.5},
+	}
+}
+
+func TestWriteStandardCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := standardTrace().WriteStandardCSV(&buf); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	want := "Frequency (Hz),Trace 1 (dBm),Trace 3\n9000,-50.25,-60\n9125.5,-51,-61.5\n"
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  = %q\nwant = %q", got, want)
+	}
+
+	buf.Reset()
+	if err := standardTrace().WriteStandardCSV(&buf, WithMetadata(MetadataComments)); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	got := buf.String()
+	for _, line := range []string{
+		"# Timestamp: 2021-11-16T10:50:45Z\n",
+		"# Title: Filter, passband\n",
+		"# Resolution Bandwidth: 1000 Hz\n",
+		"# Reference Level: -10 dBm\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("missing metadata line %q", line)
+		}
+	}
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("data after metadata differs\ngot  = %q\nwant suffix = %q", got, want)
+	}
+
+	if err := standardTrace().WriteStandardCSV(&buf, WithMetadata(MetadataSidecar)); err == nil {
+		t.Errorf("expected error writing sidecar to io.Writer")
+	}
+	bad := standardTrace()
+	bad.Trace3 = bad.Trace3[:1]
+	if err := bad.WriteStandardCSV(&buf); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+}
+
+func TestWriteStandardCSVFile(t *testing.T) {
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	filename := filepath.Join(t.TempDir(), "trace924.csv")
+	if err := trace.WriteStandardCSVFile(filename, WithMetadata(MetadataSidecar)); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	records := readAllCSV(t, filename)
+	assert(t, "num rows", len(records), 402)
+	assert(t, "header", strings.Join(records[0], "|"), "Frequency (Hz)|Trace 1 (dBuV)|Trace 2 (dBuV)|Trace 3 (dBuV)")
+	assert(t, "first row", strings.Join(records[1], "|"), "9000|59.0097|47.6487|45.2877")
+
+	meta := readAllCSV(t, filename+SidecarSuffix)
+	assert(t, "meta header", strings.Join(meta[0], "|"), "Label|Value")
+	assert(t, "meta model", strings.Join(meta[4], "|"), "Model|E4402B")
+}
+
+func readAllCSV(t *testing.T, filename string) [][]string {
+	t.Helper()
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("error opening %s: %s", filename, err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("error reading %s: %s", filename, err)
+	}
+	return records
+}
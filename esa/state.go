@@ -0,0 +1,158 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// State is the instrument state written after the trace data and any marker
+// table of a CSV file saved using Save Trace + State, as label/value lines
+// in the same layout as the header, such as:
+//
+//	Detector:                ,Peak
+//	Attenuation:             ,Auto,10,dB
+//	Average:                 ,On
+//	Average Count:           ,100
+//	Marker 1:                ,1.00000e+06,-2.00000e+01
+//	Limit Line 1:            ,Upper
+//	Limit Line 1 Point:      ,1.50000e+05,6.60000e+01
+//
+// It's unrelated to the .STA state files, which are saved in the internal
+// binary format and aren't supported.
+type State struct {
+	Detector        Detector
+	Attenuation     float64
+	AttenuationAuto bool
+	AverageOn       bool
+	AverageCount    int
+	Markers         []Marker
+	LimitLines      []LimitLine
+	// Extra contains any state settings that aren't otherwise parsed, keyed by
+	// the setting's label without the trailing colon.
+	Extra map[string]string
+}
+
+// isStateLine reports whether the line is a label/value line of the state
+// section saved after the trace data, whose label ends in a colon.
+func isStateLine(row []byte) bool {
+	return bytes.HasSuffix(csvrow.First(row), []byte(":"))
+}
+
+// parseLine parses a single state line, which has already been split into
+// columns. The first column is the label.
+func (state *State) parseLine(columns []string) error {
+	label := strings.TrimSuffix(strings.TrimSpace(columns[0]), ":")
+	values := columns[1:]
+	if len(values) == 0 {
+		return fmt.Errorf("missing value for %s", label)
+	}
+	value := strings.TrimSpace(values[0])
+	key := strings.ToLower(label)
+	switch {
+	case key == "detector":
+		detector, err := ParseDetector(value)
+		if err != nil {
+			return err
+		}
+		state.Detector = detector
+	case key == "attenuation":
+		if strings.EqualFold(value, "auto") {
+			state.AttenuationAuto = true
+			if len(values) < 2 {
+				return nil
+			}
+			value = strings.TrimSpace(values[1])
+		}
+		atten, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("error parsing attenuation: %s", err)
+		}
+		state.Attenuation = atten
+	case key == "average":
+		on, err := parseOnOff(value)
+		if err != nil {
+			return fmt.Errorf("error parsing average: %s", err)
+		}
+		state.AverageOn = on
+	case key == "average count":
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("error parsing average count: %s", err)
+		}
+		state.AverageCount = count
+	case strings.HasPrefix(key, "marker "):
+		num, err := strconv.Atoi(strings.TrimSpace(label[len("marker "):]))
+		if err != nil {
+			return fmt.Errorf("error parsing marker number: %s", err)
+		}
+		if len(values) != 2 {
+			return fmt.Errorf("wrong number of marker entries / got %d / expected 2", len(values))
+		}
+		freq, amp, err := parseFloatPair(values)
+		if err != nil {
+			return fmt.Errorf("error parsing marker %d: %s", num, err)
+		}
+		state.Markers = append(state.Markers, Marker{Number: num, Frequency: freq, Amplitude: amp})
+	case strings.HasPrefix(key, "limit line "):
+		return state.parseLimitLine(label, values)
+	default:
+		if state.Extra == nil {
+			state.Extra = make(map[string]string)
+		}
+		state.Extra[label] = strings.Join(trimAll(values), ",")
+	}
+	return nil
+}
+
+func (state *State) parseLimitLine(label string, values []string) error {
+	fields := strings.Fields(label[len("limit line "):])
+	if len(fields) == 0 {
+		return fmt.Errorf("missing limit line number")
+	}
+	num, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return fmt.Errorf("error parsing limit line number: %s", err)
+	}
+	limit := state.limitLine(num)
+	if len(fields) == 1 {
+		switch lt := strings.TrimSpace(values[0]); {
+		case strings.EqualFold(lt, string(UpperLimit)):
+			limit.Type = UpperLimit
+		case strings.EqualFold(lt, string(LowerLimit)):
+			limit.Type = LowerLimit
+		default:
+			return fmt.Errorf("unknown limit line type: %s", lt)
+		}
+		return nil
+	}
+	if !strings.EqualFold(fields[1], "point") || len(values) != 2 {
+		return fmt.Errorf("invalid limit line %d entry: %s", num, label)
+	}
+	freq, amp, err := parseFloatPair(values)
+	if err != nil {
+		return fmt.Errorf("error parsing limit line %d point: %s", num, err)
+	}
+	limit.Points = append(limit.Points, LimitPoint{Frequency: freq, Amplitude: amp})
+	return nil
+}
+
+// limitLine returns the limit line with the given number, adding it to the
+// state if it doesn't already exist.
+func (state *State) limitLine(num int) *LimitLine {
+	for i := range state.LimitLines {
+		if state.LimitLines[i].Number == num {
+			return &state.LimitLines[i]
+		}
+	}
+	state.LimitLines = append(state.LimitLines, LimitLine{Number: num})
+	return &state.LimitLines[len(state.LimitLines)-1]
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadCSVWithState(t *testing.T) {
+	data, err := os.ReadFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	state, err := os.ReadFile("./testdata/state_section.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	markers := "\nMarker,Frequency,Amplitude\n1, 34000.000, 5.81000e+01\n"
+	var tests = []struct {
+		name    string
+		given   string
+		opts    []Option
+		markers int
+	}{
+		{"default", string(data) + "\n" + string(state), nil, 0},
+		{"strict", string(data) + "\n" + string(state), []Option{WithStrict()}, 0},
+		{"after markers", string(data) + markers + "\n" + string(state), nil, 1},
+		{"header order tolerance", string(data) + string(state), []Option{WithHeaderOrderTolerance()}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trace, err := ReadCSV(strings.NewReader(test.given), test.opts...)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "num points", len(trace.Frequency), 401)
+			assert(t, "last trace 1", trace.Trace1[400], 56.8447)
+			assert(t, "num markers", len(trace.Markers), test.markers)
+			if trace.State == nil {
+				t.Fatal("expected state")
+			}
+			assert(t, "detector", trace.State.Detector, DetectorPeak)
+			assert(t, "attenuation auto", trace.State.AttenuationAuto, true)
+			assertFloat64(t, "attenuation", trace.State.Attenuation, 10.0, 0.0001)
+			assert(t, "average on", trace.State.AverageOn, true)
+			assert(t, "average count", trace.State.AverageCount, 100)
+			assert(t, "state markers", len(trace.State.Markers), 2)
+			assert(t, "marker 2", trace.State.Markers[1], Marker{Number: 2, Frequency: 34000, Amplitude: 59.2727})
+			assert(t, "limit type", trace.State.LimitLines[0].Type, UpperLimit)
+			assert(t, "num limit points", len(trace.State.LimitLines[0].Points), 2)
+			assert(t, "limit point 2", trace.State.LimitLines[0].Points[1], LimitPoint{Frequency: 500000, Amplitude: 56})
+			assert(t, "extra coupling", trace.State.Extra["Input Coupling"], "AC")
+		})
+	}
+
+	trace, err := ReadCSV(strings.NewReader(string(data) + "\nDetector:,Peak\nAttenuation:,10,dB\n"))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	if trace.State == nil {
+		t.Fatal("expected state")
+	}
+	assert(t, "detector", trace.State.Detector, DetectorPeak)
+	assertFloat64(t, "attenuation", trace.State.Attenuation, 10.0, 0.0001)
+
+	trace, err = ReadCSV(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	if trace.State != nil {
+		t.Errorf("expected no state, got %+v", trace.State)
+	}
+	for _, bad := range []string{
+		string(data) + "Detector:,Fancy\n",
+		string(data) + "Marker 1:,1e6\n",
+		string(data) + "Limit Line 1:,Sideways\n",
+		string(data) + "Average:,Maybe\n",
+		string(data) + "Detector:,Peak\n9000.000, 1, 2, 3\n",
+	} {
+		if _, err := ReadCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error parsing bad state %q", bad[len(data):])
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Stats are summary statistics of the amplitudes of a trace column in its
+// units, which default to the reference level units. Values that aren't
+// numbers are left out. The statistics are computed on the values as given,
+// so the mean of a trace in dB units is the log average. Without any values,
+// Count is 0 and the statistics are NaN.
+type Stats struct {
+	Label string
+	Units string
+	Count int
+	// Min and Max are the extreme values at MinFreq and MaxFreq in Hz.
+	Min     float64
+	MinFreq float64
+	Max     float64
+	MaxFreq float64
+	Mean    float64
+	Median  float64
+	// StdDev is the population standard deviation.
+	StdDev float64
+	sorted []float64
+}
+
+// Percentile returns the pth percentile of the values, from 0 to 100,
+// interpolating linearly between the closest values, or NaN if there aren't
+// any values.
+func (s Stats) Percentile(p float64) float64 {
+	return percentile(s.sorted, p)
+}
+
+// percentile returns the pth percentile of the sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	n := len(sorted)
+	if n == 0 || math.IsNaN(p) {
+		return math.NaN()
+	}
+	pos := math.Max(0, math.Min(100, p)) / 100 * float64(n-1)
+	i := int(pos)
+	if i == n-1 {
+		return sorted[i]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// Stats returns the statistics of each trace column in the order returned
+// by Traces.
+func (trace Trace) Stats() ([]Stats, error) {
+	return trace.StatsBetween(math.Inf(-1), math.Inf(1))
+}
+
+// StatsBetween returns the statistics of each trace column over the points
+// from the start to the stop frequency in Hz inclusive, such as the noise
+// floor away from a carrier.
+func (trace Trace) StatsBetween(start, stop float64) ([]Stats, error) {
+	if start > stop {
+		return nil, fmt.Errorf("start frequency %g Hz is above stop frequency %g Hz", start, stop)
+	}
+	var stats []Stats
+	for i, t := range trace.Traces() {
+		if len(t.Values) != len(trace.Frequency) {
+			return nil, fmt.Errorf("mismatched lengths / freq %d / trace %d %d", len(trace.Frequency), i+1, len(t.Values))
+		}
+		if t.Units == "" {
+			t.Units = string(trace.RefLevelUnits)
+		}
+		stats = append(stats, computeStats(t, trace.Frequency, start, stop))
+	}
+	return stats, nil
+}
+
+func computeStats(t TraceData, freqs []float64, start, stop float64) Stats {
+	nan := math.NaN()
+	s := Stats{
+		Label: t.Label, Units: t.Units,
+		Min: nan, MinFreq: nan, Max: nan, MaxFreq: nan, Mean: nan, Median: nan, StdDev: nan,
+	}
+	var sum float64
+	for i, v := range t.Values {
+		f := freqs[i]
+		if f < start || f > stop || math.IsNaN(v) {
+			continue
+		}
+		if s.Count == 0 || v < s.Min {
+			s.Min, s.MinFreq = v, f
+		}
+		if s.Count == 0 || v > s.Max {
+			s.Max, s.MaxFreq = v, f
+		}
+		s.sorted = append(s.sorted, v)
+		sum += v
+		s.Count++
+	}
+	if s.Count == 0 {
+		return s
+	}
+	s.Mean = sum / float64(s.Count)
+	var squares float64
+	for _, v := range s.sorted {
+		squares += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(squares / float64(s.Count))
+	sort.Float64s(s.sorted)
+	s.Median = s.Percentile(50)
+	return s
+}
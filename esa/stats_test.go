@@ -0,0 +1,67 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	trace := Trace{
+		RefLevelUnits: DBm,
+		Frequency:     []float64{1e6, 2e6, 3e6, 4e6, 5e6, 6e6},
+		Trace1Label:   "Trace 1",
+		Trace1:        []float64{-80, -70, -20, math.NaN(), -60, -90},
+		Trace2Label:   "Trace 2",
+		Trace2Units:   "dBuV",
+		Trace2:        []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()},
+	}
+	stats, err := trace.Stats()
+	if err != nil {
+		t.Fatalf("received error computing stats: %s", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("wrong number of stats / got %d / expected 2", len(stats))
+	}
+	s := stats[0]
+	assert(t, "label", s.Label, "Trace 1")
+	assert(t, "units", s.Units, "dBm")
+	assert(t, "count", s.Count, 5)
+	assert(t, "min", s.Min, -90.0)
+	assert(t, "min freq", s.MinFreq, 6e6)
+	assert(t, "max", s.Max, -20.0)
+	assert(t, "max freq", s.MaxFreq, 3e6)
+	assertFloat64(t, "mean", s.Mean, -64, 1e-9)
+	assert(t, "median", s.Median, -70.0)
+	assertFloat64(t, "std dev", s.StdDev, math.Sqrt(2920/5.0), 1e-9)
+	assert(t, "p0", s.Percentile(0), -90.0)
+	assert(t, "p25", s.Percentile(25), -80.0)
+	assertFloat64(t, "p90", s.Percentile(90), -36, 1e-9)
+	assert(t, "p100", s.Percentile(100), -20.0)
+
+	assert(t, "trace 2 units", stats[1].Units, "dBuV")
+	assert(t, "trace 2 count", stats[1].Count, 0)
+	assert(t, "trace 2 mean", math.IsNaN(stats[1].Mean), true)
+	assert(t, "trace 2 percentile", math.IsNaN(stats[1].Percentile(50)), true)
+
+	stats, err = trace.StatsBetween(3.5e6, 6e6)
+	if err != nil {
+		t.Fatalf("received error computing windowed stats: %s", err)
+	}
+	s = stats[0]
+	assert(t, "window count", s.Count, 2)
+	assert(t, "window max", s.Max, -60.0)
+	assert(t, "window median", s.Median, -75.0)
+
+	if _, err := trace.StatsBetween(2e6, 1e6); err == nil {
+		t.Error("expected error for start above stop")
+	}
+	trace.Trace1 = trace.Trace1[:3]
+	if _, err := trace.Stats(); err == nil {
+		t.Error("expected error for mismatched lengths")
+	}
+}
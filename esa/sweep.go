@@ -0,0 +1,113 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"math"
+)
+
+// StartFreq returns the start frequency in Hz computed from the center
+// frequency and span. Unknown units return 0.
+func (trace Trace) StartFreq() float64 {
+	center, span := trace.centerSpan()
+	return center - span/2
+}
+
+// StopFreq returns the stop frequency in Hz computed from the center
+// frequency and span. Unknown units return 0.
+func (trace Trace) StopFreq() float64 {
+	center, span := trace.centerSpan()
+	return center + span/2
+}
+
+// BinSpacing returns the frequency spacing in Hz between adjacent points
+// computed from the span and the number of points, or 0 if there are fewer
+// than two points.
+func (trace Trace) BinSpacing() float64 {
+	if trace.NumPoints < 2 {
+		return 0
+	}
+	_, span := trace.centerSpan()
+	return span / float64(trace.NumPoints-1)
+}
+
+// FrequencyAt returns the frequency in Hz of the point with the given index
+// computed from the center frequency, span, and number of points.
+func (trace Trace) FrequencyAt(i int) float64 {
+	return trace.StartFreq() + float64(i)*trace.BinSpacing()
+}
+
+func (trace Trace) centerSpan() (float64, float64) {
+	center, _ := hertz(trace.CenterFreq, trace.CenterFreqUnits)
+	span, _ := hertz(trace.Span, trace.SpanUnits)
+	return center, span
+}
+
+// GridCheck compares the parsed frequencies with the grid computed from the
+// header by FrequencyAt.
+type GridCheck struct {
+	// Offset is the whole number of bins by which the parsed frequencies are
+	// shifted from the computed grid, such as 1 if the first frequency is the
+	// second grid point because of an off-by-one error.
+	Offset int
+	// MaxDrift is the largest difference in Hz between a parsed frequency and
+	// its grid point after removing the offset, and MaxDriftIndex is the
+	// index of the point with the largest difference.
+	MaxDrift      float64
+	MaxDriftIndex int
+}
+
+// OK reports whether the parsed frequencies aren't offset from the computed
+// grid and drift by no more than the tolerance in Hz.
+func (check GridCheck) OK(tolerance float64) bool {
+	return check.Offset == 0 && check.MaxDrift <= tolerance
+}
+
+// CheckFrequencyGrid compares the parsed Frequency column with the grid
+// computed from the center frequency, span, and number of points, reporting
+// any whole-bin offset and the remaining drift.
+func (trace Trace) CheckFrequencyGrid() (GridCheck, error) {
+	var check GridCheck
+	n := len(trace.Frequency)
+	if n != trace.NumPoints {
+		return check, fmt.Errorf("mismatched lengths / freq %d / num points %d", n, trace.NumPoints)
+	}
+	if n < 2 {
+		return check, fmt.Errorf("need at least 2 points / got %d", n)
+	}
+	for _, units := range []FrequencyUnits{trace.CenterFreqUnits, trace.SpanUnits} {
+		if _, ok := hertz(0, units); !ok {
+			return check, fmt.Errorf("unknown frequency units: %s", units)
+		}
+	}
+	freqUnits, err := ParseFrequencyUnits(trace.FreqUnits)
+	if err != nil {
+		return check, err
+	}
+	mult, _ := hertz(1, freqUnits)
+	bin := trace.BinSpacing()
+	if bin == 0 {
+		return check, fmt.Errorf("span is zero")
+	}
+
+	diffs := make([]float64, n)
+	mean := 0.0
+	for i, f := range trace.Frequency {
+		diffs[i] = f*mult - trace.FrequencyAt(i)
+		mean += diffs[i]
+	}
+	mean /= float64(n)
+	check.Offset = int(math.Round(mean / bin))
+	for i, d := range diffs {
+		drift := math.Abs(d - float64(check.Offset)*bin)
+		if drift > check.MaxDrift {
+			check.MaxDrift = drift
+			check.MaxDriftIndex = i
+		}
+	}
+	return check, nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"testing"
+)
+
+func TestSweepParameters(t *testing.T) {
+	var tests = []struct {
+		trace Trace
+		start float64
+		stop  float64
+		bin   float64
+		at10  float64
+	}{
+		{
+			Trace{CenterFreq: 34000, CenterFreqUnits: Hertz, Span: 50000, SpanUnits: Hertz, NumPoints: 401},
+			9000, 59000, 125, 10250,
+		},
+		{
+			Trace{CenterFreq: 750, CenterFreqUnits: Megahertz, Span: 0.5, SpanUnits: Gigahertz, NumPoints: 11},
+			500e6, 1000e6, 50e6, 1000e6,
+		},
+		{
+			Trace{CenterFreq: 1, CenterFreqUnits: Gigahertz, NumPoints: 1},
+			1e9, 1e9, 0, 1e9,
+		},
+	}
+	for _, test := range tests {
+		assertFloat64(t, "start", test.trace.StartFreq(), test.start, 1e-6)
+		assertFloat64(t, "stop", test.trace.StopFreq(), test.stop, 1e-6)
+		assertFloat64(t, "bin", test.trace.BinSpacing(), test.bin, 1e-6)
+		assertFloat64(t, "at 10", test.trace.FrequencyAt(10), test.at10, 1e-6)
+	}
+}
+
+func TestCheckFrequencyGrid(t *testing.T) {
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	check, err := trace.CheckFrequencyGrid()
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "ok", check.OK(1e-6), true)
+
+	shifted := trace
+	shifted.Frequency = make([]float64, len(trace.Frequency))
+	for i, f := range trace.Frequency {
+		shifted.Frequency[i] = f + 125
+	}
+	shifted.Frequency[200] += 10
+	check, err = shifted.CheckFrequencyGrid()
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "offset", check.Offset, 1)
+	assertFloat64(t, "max drift", check.MaxDrift, 10, 1e-9)
+	assert(t, "max drift index", check.MaxDriftIndex, 200)
+	assert(t, "shifted ok", check.OK(20), false)
+
+	trace.NumPoints = 400
+	if _, err := trace.CheckFrequencyGrid(); err == nil {
+		t.Errorf("expected error for mismatched number of points")
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// commonSettings are the settings that must be the same for each sweep of a
+// sweep set, compared with the frequencies in Hz.
+var commonSettings = map[string]bool{
+	"Center Frequency":     true,
+	"Span":                 true,
+	"Resolution Bandwidth": true,
+	"Video Bandwidth":      true,
+	"Num Points":           true,
+}
+
+// SweepSet is a series of sweeps of the same measurement ordered by
+// timestamp, such as the traces saved during a monitoring run, whose
+// amplitudes are combined point by point. Each sweep has the same
+// frequencies, center frequency, span, bandwidths, number of points, and
+// trace columns, with the amplitudes in the units of the first sweep added.
+type SweepSet struct {
+	sweeps []Trace
+}
+
+// NewSweepSet returns a sweep set containing the sweeps.
+func NewSweepSet(sweeps ...Trace) (*SweepSet, error) {
+	s := &SweepSet{}
+	if err := s.Add(sweeps...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add adds the sweeps to the set, which is kept sorted by timestamp with
+// sweeps having the same timestamp in the order added. Sweeps in other
+// amplitude units are converted to the units of the set. If a sweep doesn't
+// match the settings of the set, an error is returned and none of the sweeps
+// are added.
+func (s *SweepSet) Add(sweeps ...Trace) error {
+	added := append([]Trace(nil), s.sweeps...)
+	for i, sweep := range sweeps {
+		if err := sweep.checkLengths(); err != nil {
+			return fmt.Errorf("sweep %d: %s", i, err)
+		}
+		if len(sweep.Frequency) == 0 {
+			return fmt.Errorf("sweep %d: no points", i)
+		}
+		if len(added) > 0 {
+			var err error
+			if sweep, err = added[0].matchSweep(sweep); err != nil {
+				return fmt.Errorf("sweep %d: %s", i, err)
+			}
+		}
+		added = append(added, sweep)
+	}
+	sort.SliceStable(added, func(i, j int) bool {
+		return added[i].Timestamp.Before(added[j].Timestamp)
+	})
+	s.sweeps = added
+	return nil
+}
+
+// matchSweep returns the sweep converted to the amplitude units of the
+// trace, or an error if its settings, frequencies, or trace columns differ.
+func (trace Trace) matchSweep(sweep Trace) (Trace, error) {
+	settings := sweep.normalizeFrequencies().metadata()
+	for i, s := range trace.normalizeFrequencies().metadata() {
+		if commonSettings[s[0]] && s[1] != settings[i][1] {
+			return sweep, fmt.Errorf("%s is %s instead of %s", s[0], settings[i][1], s[1])
+		}
+	}
+	if !sameFrequencies(trace.Frequency, sweep.Frequency, 1e-9) {
+		return sweep, fmt.Errorf("frequencies differ")
+	}
+	want, got := trace.Traces(), sweep.Traces()
+	if len(got) != len(want) {
+		return sweep, fmt.Errorf("%d traces instead of %d", len(got), len(want))
+	}
+	for i := range want {
+		if len(got[i].Values) != len(want[i].Values) {
+			return sweep, fmt.Errorf("trace %d has %d values instead of %d", i+1, len(got[i].Values), len(want[i].Values))
+		}
+		units := trace.traceUnits(want[i])
+		if len(want[i].Values) == 0 || strings.EqualFold(sweep.traceUnits(got[i]), units) {
+			continue
+		}
+		converted, err := sweep.ConvertAmplitudeUnits(AmplitudeUnits(units))
+		if err != nil {
+			return sweep, fmt.Errorf("unable to convert to %s: %s", units, err)
+		}
+		sweep, got = converted, converted.Traces()
+		if u := sweep.traceUnits(got[i]); !strings.EqualFold(u, units) {
+			return sweep, fmt.Errorf("trace %d is in %s instead of %s", i+1, u, units)
+		}
+	}
+	return sweep, nil
+}
+
+// Len returns the number of sweeps in the set.
+func (s *SweepSet) Len() int {
+	return len(s.sweeps)
+}
+
+// Sweeps returns the sweeps in order of timestamp.
+func (s *SweepSet) Sweeps() []Trace {
+	return append([]Trace(nil), s.sweeps...)
+}
+
+// Between returns a sweep set containing the sweeps with a timestamp from
+// the start time up to but not including the end time, such as one interval
+// of a spectrogram.
+func (s *SweepSet) Between(start, end time.Time) *SweepSet {
+	lo := sort.Search(len(s.sweeps), func(i int) bool {
+		return !s.sweeps[i].Timestamp.Before(start)
+	})
+	hi := sort.Search(len(s.sweeps), func(i int) bool {
+		return !s.sweeps[i].Timestamp.Before(end)
+	})
+	if hi < lo {
+		hi = lo
+	}
+	return &SweepSet{sweeps: append([]Trace(nil), s.sweeps[lo:hi]...)}
+}
+
+// MaxHold returns the maximum of the sweeps at each point of each trace
+// column.
+func (s *SweepSet) MaxHold() (Trace, error) {
+	return s.combine(func(values []float64, _ bool) float64 {
+		hi := values[0]
+		for _, v := range values[1:] {
+			hi = math.Max(hi, v)
+		}
+		return hi
+	})
+}
+
+// MinHold returns the minimum of the sweeps at each point of each trace
+// column.
+func (s *SweepSet) MinHold() (Trace, error) {
+	return s.combine(func(values []float64, _ bool) float64 {
+		lo := values[0]
+		for _, v := range values[1:] {
+			lo = math.Min(lo, v)
+		}
+		return lo
+	})
+}
+
+// Average returns the average of the sweeps at each point of each trace
+// column. Values in dB units are power averaged, which matches the power
+// averaging of the analyzer, while values in linear units are averaged
+// directly.
+func (s *SweepSet) Average() (Trace, error) {
+	return s.combine(func(values []float64, db bool) float64 {
+		var sum float64
+		for _, v := range values {
+			if db {
+				v = math.Pow(10, v/10)
+			}
+			sum += v
+		}
+		mean := sum / float64(len(values))
+		if !db {
+			return mean
+		}
+		if mean <= 0 {
+			return math.Inf(-1)
+		}
+		return 10 * math.Log10(mean)
+	})
+}
+
+// Percentile returns the pth percentile of the sweeps, from 0 to 100, at
+// each point of each trace column, interpolating linearly between the
+// closest values. For example, the 50th percentile is the median sweep,
+// which is less affected by intermittent signals than the average.
+func (s *SweepSet) Percentile(p float64) (Trace, error) {
+	if !(p >= 0 && p <= 100) {
+		return Trace{}, fmt.Errorf("invalid percentile %g", p)
+	}
+	return s.combine(func(values []float64, _ bool) float64 {
+		sort.Float64s(values)
+		return percentile(values, p)
+	})
+}
+
+// combine returns a copy of the latest sweep with the value at each point of
+// each trace column replaced by fn of the values of the sweeps at the point,
+// leaving out values that aren't numbers. Points without any values are set
+// to NaN. The db argument reports whether the column is in dB units.
+func (s *SweepSet) combine(fn func(values []float64, db bool) float64) (Trace, error) {
+	if len(s.sweeps) == 0 {
+		return Trace{}, fmt.Errorf("no sweeps")
+	}
+	columns := make([][]TraceData, len(s.sweeps))
+	for i, sweep := range s.sweeps {
+		columns[i] = sweep.Traces()
+	}
+	result := s.sweeps[len(s.sweeps)-1]
+	traces := result.Traces()
+	values := make([]float64, 0, len(s.sweeps))
+	for i := range traces {
+		if len(traces[i].Values) == 0 {
+			continue
+		}
+		db := strings.HasPrefix(strings.ToLower(result.traceUnits(traces[i])), "db")
+		combined := make([]float64, len(traces[i].Values))
+		for j := range combined {
+			values = values[:0]
+			for _, sweep := range columns {
+				if v := sweep[i].Values[j]; !math.IsNaN(v) {
+					values = append(values, v)
+				}
+			}
+			if len(values) == 0 {
+				combined[j] = math.NaN()
+				continue
+			}
+			combined[j] = fn(values, db)
+		}
+		traces[i].Values = combined
+	}
+	result.Frequency = append([]float64(nil), result.Frequency...)
+	result.SetTraces(traces)
+	return result, nil
+}
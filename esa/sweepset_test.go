@@ -0,0 +1,105 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSweepSet(t *testing.T) {
+	start := time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC)
+	sweep := func(minutes int, values ...float64) Trace {
+		return Trace{
+			Timestamp:       start.Add(time.Duration(minutes) * time.Minute),
+			CenterFreq:      2,
+			CenterFreqUnits: Megahertz,
+			Span:            2,
+			SpanUnits:       Megahertz,
+			RBW:             100,
+			RBWUnits:        Kilohertz,
+			NumPoints:       3,
+			RefLevelUnits:   DBm,
+			Frequency:       []float64{1e6, 2e6, 3e6},
+			Trace1:          values,
+		}
+	}
+	// The third sweep has its center frequency in Hz and is in dBuV, so it's
+	// converted to dBm when added.
+	third := sweep(2, -40, -30, -20)
+	third.CenterFreq, third.CenterFreqUnits = 2e6, Hertz
+	third, err := third.ConvertAmplitudeUnits(DBuV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := NewSweepSet(sweep(1, -50, -20, math.NaN()), third, sweep(0, -60, -40, -30))
+	if err != nil {
+		t.Fatalf("received error creating sweep set: %s", err)
+	}
+	assert(t, "len", set.Len(), 3)
+	sweeps := set.Sweeps()
+	assert(t, "first", sweeps[0].Trace1[0], -60.0)
+	assert(t, "last", sweeps[2].Timestamp, start.Add(2*time.Minute))
+	assert(t, "converted units", sweeps[2].RefLevelUnits, DBm)
+	assertFloat64(t, "converted value", sweeps[2].Trace1[0], -40, 1e-9)
+
+	maxHold, err := set.MaxHold()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat64(t, "max hold 0", maxHold.Trace1[0], -40, 1e-9)
+	assertFloat64(t, "max hold 1", maxHold.Trace1[1], -20, 1e-9)
+	assert(t, "max hold timestamp", maxHold.Timestamp, start.Add(2*time.Minute))
+	minHold, err := set.MinHold()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat64(t, "min hold 2", minHold.Trace1[2], -30, 1e-9)
+	average, err := set.Average()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat64(t, "average 1", average.Trace1[1], 10*math.Log10((1e-4+1e-3+1e-2)/3), 1e-9)
+	assertFloat64(t, "average 2", average.Trace1[2], 10*math.Log10((1e-3+1e-2)/2), 1e-9)
+	median, err := set.Percentile(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFloat64(t, "median 0", median.Trace1[0], -50, 1e-9)
+	assertFloat64(t, "median 2", median.Trace1[2], -25, 1e-9)
+	assertFloat64(t, "latest sweep unchanged", set.Sweeps()[2].Trace1[0], -40, 1e-9)
+	if _, err := set.Percentile(101); err == nil {
+		t.Error("expected error for invalid percentile")
+	}
+
+	window := set.Between(start.Add(time.Minute), start.Add(2*time.Minute))
+	assert(t, "window len", window.Len(), 1)
+	assert(t, "window sweep", window.Sweeps()[0].Trace1[0], -50.0)
+	assert(t, "empty window len", set.Between(start.Add(time.Hour), start).Len(), 0)
+	if _, err := set.Between(start.Add(time.Hour), start.Add(2*time.Hour)).MaxHold(); err == nil {
+		t.Error("expected error combining no sweeps")
+	}
+
+	var tests = []struct {
+		name  string
+		sweep func(Trace) Trace
+	}{
+		{"rbw", func(s Trace) Trace { s.RBW = 30; return s }},
+		{"frequencies", func(s Trace) Trace { s.Frequency = []float64{1e6, 2e6, 4e6}; return s }},
+		{"lengths", func(s Trace) Trace { s.Trace1 = s.Trace1[:2]; return s }},
+		{"traces", func(s Trace) Trace { s.Trace2 = []float64{1, 2, 3}; return s }},
+		{"units", func(s Trace) Trace { s.RefLevelUnits = "furlongs"; return s }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := set.Add(sweep(3, -1, -2, -3), test.sweep(sweep(4, -1, -2, -3))); err == nil {
+				t.Error("expected error adding mismatched sweep")
+			}
+			assert(t, "len", set.Len(), 3)
+		})
+	}
+}
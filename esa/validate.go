@@ -0,0 +1,199 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// displayRange is the amplitude range in dB shown below the reference level
+// by the ESA's 10 division graticule at the default scale of 10 dB/div.
+const displayRange = 100
+
+var frequencyMultipliers = map[FrequencyUnits]float64{
+	Hertz:     1,
+	Kilohertz: 1e3,
+	Megahertz: 1e6,
+	Gigahertz: 1e9,
+}
+
+// hertz returns the frequency in Hz, treating empty units as Hz, and whether
+// the units are known.
+func hertz(value float64, units FrequencyUnits) (float64, bool) {
+	if units == "" {
+		return value, true
+	}
+	mult, ok := frequencyMultipliers[units]
+	return value * mult, ok
+}
+
+// Warning is a problem with a trace found by Validate.
+type Warning struct {
+	// Field is the name of the Trace field with the problem.
+	Field   string
+	Message string
+}
+
+func (w Warning) String() string {
+	return w.Field + ": " + w.Message
+}
+
+// Validate checks the internal consistency of the trace and returns a
+// warning for each problem found, so that bad captures can be flagged before
+// they're archived. It checks that:
+//
+//   - NumPoints matches the length of the frequency and trace data.
+//   - The frequencies increase and span CenterFreq ± Span/2.
+//   - The units are recognized.
+//   - The trace values are finite and, for traces in the reference level
+//     units, within the displayed range from the reference level down 10
+//     divisions at 10 dB/div.
+//
+// A valid trace returns no warnings.
+func (trace Trace) Validate() []Warning {
+	var warnings []Warning
+	warn := func(field, format string, a ...interface{}) {
+		warnings = append(warnings, Warning{field, fmt.Sprintf(format, a...)})
+	}
+
+	traces := trace.Traces()
+	if len(trace.Frequency) != trace.NumPoints {
+		warn("Frequency", "got %d points / expected %d", len(trace.Frequency), trace.NumPoints)
+	}
+	for i, t := range traces {
+		if len(t.Values) != trace.NumPoints {
+			warn(traceField(i), "got %d points / expected %d", len(t.Values), trace.NumPoints)
+		}
+	}
+
+	for _, u := range []struct {
+		field string
+		units FrequencyUnits
+	}{
+		{"CenterFreqUnits", trace.CenterFreqUnits},
+		{"SpanUnits", trace.SpanUnits},
+		{"RBWUnits", trace.RBWUnits},
+		{"VBWUnits", trace.VBWUnits},
+	} {
+		if _, ok := hertz(0, u.units); !ok {
+			warn(u.field, "unknown frequency units %s", u.units)
+		}
+	}
+	if _, err := ParseAmplitudeUnits(string(trace.RefLevelUnits)); err != nil {
+		warn("RefLevelUnits", "unknown amplitude units %s", trace.RefLevelUnits)
+	}
+	if _, err := ParseTimeUnits(string(trace.SweepTimeUnits)); err != nil {
+		warn("SweepTimeUnits", "unknown time units %s", trace.SweepTimeUnits)
+	}
+	freqUnits, err := ParseFrequencyUnits(trace.FreqUnits)
+	if err != nil {
+		warn("FreqUnits", "unknown frequency units %s", strings.TrimSpace(trace.FreqUnits))
+	}
+	for i, t := range traces {
+		if _, err := ParseAmplitudeUnits(t.Units); err != nil {
+			warn(traceField(i), "unknown amplitude units %s", t.Units)
+		}
+	}
+
+	if err == nil {
+		warnings = append(warnings, trace.validateFrequencies(freqUnits)...)
+	}
+	for i, t := range traces {
+		warnings = append(warnings, trace.validateAmplitudes(traceField(i), t)...)
+	}
+	return warnings
+}
+
+// validateFrequencies checks that the frequencies increase and match the
+// center frequency and span to within a quarter of the bin spacing.
+func (trace Trace) validateFrequencies(units FrequencyUnits) []Warning {
+	var warnings []Warning
+	n := len(trace.Frequency)
+	if n == 0 {
+		return nil
+	}
+	for i := 1; i < n; i++ {
+		if trace.Frequency[i] <= trace.Frequency[i-1] {
+			warnings = append(warnings, Warning{"Frequency", fmt.Sprintf(
+				"frequency %g at point %d doesn't increase from %g", trace.Frequency[i], i, trace.Frequency[i-1],
+			)})
+			break
+		}
+	}
+	center, okCenter := hertz(trace.CenterFreq, trace.CenterFreqUnits)
+	span, okSpan := hertz(trace.Span, trace.SpanUnits)
+	mult, _ := hertz(1, units)
+	if !okCenter || !okSpan || span == 0 || n < 2 {
+		return warnings
+	}
+	tolerance := span / float64(n-1) / 4
+	for _, end := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"first", trace.Frequency[0] * mult, center - span/2},
+		{"last", trace.Frequency[n-1] * mult, center + span/2},
+	} {
+		if math.Abs(end.got-end.want) > tolerance {
+			warnings = append(warnings, Warning{"Frequency", fmt.Sprintf(
+				"%s frequency %g Hz doesn't match center frequency and span / expected %g Hz",
+				end.name, end.got, end.want,
+			)})
+		}
+	}
+	return warnings
+}
+
+// validateAmplitudes checks that the trace values are finite and, if the
+// trace is in the reference level units, within the displayed range.
+func (trace Trace) validateAmplitudes(field string, t TraceData) []Warning {
+	var warnings []Warning
+	nonFinite, above, below := 0, 0, 0
+	units, _ := ParseAmplitudeUnits(t.Units)
+	checkRange := units != "" && units == trace.RefLevelUnits && isDB(units)
+	for _, v := range t.Values {
+		switch {
+		case math.IsNaN(v) || math.IsInf(v, 0):
+			nonFinite++
+		case !checkRange:
+		case v > trace.RefLevel:
+			above++
+		case v < trace.RefLevel-displayRange:
+			below++
+		}
+	}
+	if nonFinite > 0 {
+		warnings = append(warnings, Warning{field, fmt.Sprintf("%d values aren't finite", nonFinite)})
+	}
+	if above > 0 {
+		warnings = append(warnings, Warning{field, fmt.Sprintf(
+			"%d values are above the reference level of %g %s", above, trace.RefLevel, units,
+		)})
+	}
+	if below > 0 {
+		warnings = append(warnings, Warning{field, fmt.Sprintf(
+			"%d values are more than %d dB below the reference level of %g %s",
+			below, displayRange, trace.RefLevel, units,
+		)})
+	}
+	return warnings
+}
+
+func isDB(units AmplitudeUnits) bool {
+	return units == DBm || units == DBuV || units == DBmV
+}
+
+// traceField returns the name of the Trace field holding the trace column
+// with the given index.
+func traceField(i int) string {
+	if i < 3 {
+		return fmt.Sprintf("Trace%d", i+1)
+	}
+	return fmt.Sprintf("ExtraTraces[%d]", i-3)
+}
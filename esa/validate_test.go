@@ -0,0 +1,91 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	if warnings := valid.Validate(); len(warnings) != 0 {
+		t.Errorf("got warnings for valid trace: %v", warnings)
+	}
+
+	var tests = []struct {
+		name   string
+		modify func(trace *Trace)
+		want   []string
+	}{
+		{
+			"num points",
+			func(trace *Trace) { trace.NumPoints = 400 },
+			[]string{
+				"Frequency: got 401 points / expected 400",
+				"Trace1: got 401 points / expected 400",
+				"Trace2: got 401 points / expected 400",
+				"Trace3: got 401 points / expected 400",
+			},
+		},
+		{
+			"units",
+			func(trace *Trace) {
+				trace.SpanUnits = "furlongs"
+				trace.Trace2Units = "dBfoo"
+			},
+			[]string{"SpanUnits: unknown frequency units furlongs", "Trace2: unknown amplitude units dBfoo"},
+		},
+		{
+			"not increasing",
+			func(trace *Trace) { trace.Frequency[10] = trace.Frequency[9] },
+			[]string{"Frequency: frequency 10125 at point 10 doesn't increase from 10125"},
+		},
+		{
+			"span",
+			func(trace *Trace) {
+				trace.Span = 60
+				trace.SpanUnits = Kilohertz
+			},
+			[]string{
+				"Frequency: first frequency 9000 Hz doesn't match center frequency and span / expected 4000 Hz",
+				"Frequency: last frequency 59000 Hz doesn't match center frequency and span / expected 64000 Hz",
+			},
+		},
+		{
+			"amplitudes",
+			func(trace *Trace) {
+				trace.Trace1[0] = 120
+				trace.Trace1[1] = math.NaN()
+				trace.Trace3[2] = -10
+			},
+			[]string{
+				"Trace1: 1 values aren't finite",
+				"Trace1: 1 values are above the reference level of 106.99 dBuV",
+				"Trace3: 1 values are more than 100 dB below the reference level of 106.99 dBuV",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+			if err != nil {
+				t.Fatalf("error reading test file: %s", err)
+			}
+			test.modify(&trace)
+			warnings := trace.Validate()
+			if len(warnings) != len(test.want) {
+				t.Fatalf("got warnings %v, want %v", warnings, test.want)
+			}
+			for i, w := range warnings {
+				assert(t, "warning", w.String(), test.want[i])
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// blankUnits is written by the ESA in place of a units string when the
+// units are not shown.
+const blankUnits = "   "
+
+// WriteCSVFile writes the trace to the given filename using the same
+// non-RFC 4180 CSV layout saved by the Keysight/Agilent ESA.
+func (trace Trace) WriteCSVFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := trace.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSV writes the trace to the given io.Writer using the same non-RFC 4180
+// CSV layout saved by the Keysight/Agilent ESA, so that the output can be read
+// by ReadCSV as well as by legacy tools expecting the instrument's format.
+// The detector, attenuation, preamp, and input coupling lines, which the
+// firmware revisions writing them write together, are written after the
+// number of points if the detector or coupling is set, followed by the
+// ExtraHeaders sorted by label.
+func (trace Trace) WriteCSV(w io.Writer) error {
+	n := len(trace.Frequency)
+	traces := trace.Traces()
+	if len(traces) == 0 {
+		return fmt.Errorf("trace doesn't have any trace columns")
+	}
+	for i, t := range traces {
+		if len(t.Values) != n {
+			return fmt.Errorf("mismatched data lengths / freq %d / trace %d %d", n, i+1, len(t.Values))
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s,%s\n", formatTimestamp(trace), trace.OriginalFilename)
+	writeHeaderLine(bw, "Title:", trace.Title)
+	writeHeaderLine(bw, "Model:", trace.Model)
+	// The ESA terminates the serial number with a NUL, which is kept when
+	// read using WithRawHeader.
+	writeHeaderLine(bw, "Serial Number:", strings.TrimSuffix(trace.SerialNum, "\x00")+"\x00")
+	writeHeaderLine(bw, "Center Frequency:", formatInt(trace.CenterFreq), formatUnits(string(trace.CenterFreqUnits)))
+	writeHeaderLine(bw, "Span:", formatInt(trace.Span), formatUnits(string(trace.SpanUnits)))
+	writeHeaderLine(bw, "Resolution Bandwidth:", formatInt(trace.RBW), formatUnits(string(trace.RBWUnits)))
+	writeHeaderLine(bw, "Video Bandwidth:", formatInt(trace.VBW), formatUnits(string(trace.VBWUnits)))
+	writeHeaderLine(bw, "Reference Level:", formatExp(trace.RefLevel), formatUnits(string(trace.RefLevelUnits)))
+	writeHeaderLine(bw, "Sweep Time:", formatExp(trace.SweepTime), formatTimeUnits(trace.SweepTimeUnits))
+	writeHeaderLine(bw, "Num Points:", fmt.Sprintf("%04d", trace.NumPoints))
+	if trace.Detector != "" || trace.Coupling != "" {
+		writeHeaderLine(bw, "Detector:", string(trace.Detector))
+		writeHeaderLine(bw, "Attenuation:", formatInt(trace.Attenuation), "dB")
+		writeHeaderLine(bw, "Preamp:", formatOnOff(trace.Preamp))
+		writeHeaderLine(bw, "Input Coupling:", string(trace.Coupling))
+	}
+	labels := make([]string, 0, len(trace.ExtraHeaders))
+	for label := range trace.ExtraHeaders {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		writeHeaderLine(bw, label+":", trace.ExtraHeaders[label])
+	}
+	fmt.Fprint(bw, "\n\n")
+	bw.WriteString(trace.FreqLabel)
+	for _, t := range traces {
+		fmt.Fprintf(bw, ",%s", t.Label)
+	}
+	bw.WriteString("\n" + trace.FreqUnits)
+	for _, t := range traces {
+		fmt.Fprintf(bw, ",%s", formatUnits(t.Units))
+	}
+	bw.WriteString("\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(bw, "%.3f", trace.Frequency[i])
+		for _, t := range traces {
+			fmt.Fprintf(bw, ", %s", formatExp(t.Values[i]))
+		}
+		bw.WriteString("\n")
+	}
+	if len(trace.Markers) > 0 {
+		bw.WriteString("Marker,Frequency,Amplitude\n")
+		for _, m := range trace.Markers {
+			fmt.Fprintf(bw, "%d, %.3f, %s\n", m.Number, m.Frequency, formatExp(m.Amplitude))
+		}
+	}
+	return bw.Flush()
+}
+
+// writeHeaderLine writes a header line with the label left justified in a
+// 25 character wide column followed by the given values.
+func writeHeaderLine(w io.Writer, label string, values ...string) {
+	fmt.Fprintf(w, "%-25s", label)
+	for _, value := range values {
+		fmt.Fprintf(w, ",%s", value)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+func formatTimestamp(trace Trace) string {
+	if trace.Timestamp.IsZero() {
+		return ""
+	}
+	return trace.Timestamp.Format(" 01/02/06   15:04:05")
+}
+
+func formatInt(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatExp(f float64) string {
+	return fmt.Sprintf("%.5e", f)
+}
+
+func formatOnOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
+
+func formatUnits(units string) string {
+	if units == "" {
+		return blankUnits
+	}
+	return units
+}
+
+func formatTimeUnits(units TimeUnits) string {
+	if units == Seconds {
+		return "Sec"
+	}
+	return formatUnits(string(units))
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package esa
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteCSVRoundTrip(t *testing.T) {
+	var tests = []string{
+		"./testdata/e4402b_trace924.csv",
+		"./testdata/e4411b_trace080.csv",
+		"./testdata/e4402b_extended_header.csv",
+	}
+	for _, filename := range tests {
+		t.Run(filename, func(t *testing.T) {
+			want, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("error reading test file: %s", err)
+			}
+			trace, err := ReadCSV(bytes.NewReader(want))
+			if err != nil {
+				t.Fatalf("received error reading CSV: %s", err)
+			}
+			var buf bytes.Buffer
+			if err := trace.WriteCSV(&buf); err != nil {
+				t.Fatalf("received error writing CSV: %s", err)
+			}
+			got := buf.Bytes()
+			if !bytes.Equal(got, want) {
+				gotLines := bytes.Split(got, []byte("\n"))
+				wantLines := bytes.Split(want, []byte("\n"))
+				for i := 0; i < len(gotLines) && i < len(wantLines); i++ {
+					if !bytes.Equal(gotLines[i], wantLines[i]) {
+						t.Fatalf("line %d differs\ngot  = %q\nwant = %q", i+1, gotLines[i], wantLines[i])
+					}
+				}
+				t.Fatalf("output length differs / got %d / want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestWriteCSVFile(t *testing.T) {
+	trace, err := ReadCSVFile("./testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	filename := filepath.Join(t.TempDir(), "TRACE924.CSV")
+	if err := trace.WriteCSVFile(filename); err != nil {
+		t.Fatalf("received error writing CSV file: %s", err)
+	}
+	got, err := ReadCSVFile(filename)
+	if err != nil {
+		t.Fatalf("received error reading written CSV file: %s", err)
+	}
+	assert(t, "s/n", got.SerialNum, trace.SerialNum)
+	assert(t, "timestamp", got.Timestamp, trace.Timestamp)
+	assert(t, "num points", got.NumPoints, trace.NumPoints)
+	assertFloat64(t, "t3[400]", got.Trace3[400], trace.Trace3[400], 0.00000001)
+}
+
+func TestWriteCSVMismatchedLengths(t *testing.T) {
+	trace := Trace{
+		Frequency: []float64{1, 2},
+		Trace1:    []float64{1},
+	}
+	if err := trace.WriteCSV(&bytes.Buffer{}); err == nil {
+		t.Errorf("expected error writing trace with mismatched data lengths")
+	}
+}
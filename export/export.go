@@ -0,0 +1,80 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package export writes parsed instrument data to file formats used by data
+// analysis tools, such as Apache Parquet and MATLAB MAT-files.
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+var frequencyMultipliers = map[esa.FrequencyUnits]float64{
+	"":            1,
+	esa.Hertz:     1,
+	esa.Kilohertz: 1e3,
+	esa.Megahertz: 1e6,
+	esa.Gigahertz: 1e9,
+}
+
+var timeMultipliers = map[esa.TimeUnits]float64{
+	"":               1,
+	esa.Seconds:      1,
+	esa.Milliseconds: 1e-3,
+	esa.Microseconds: 1e-6,
+}
+
+// settings are the trace settings converted to Hz and seconds.
+type settings struct {
+	centerFreq float64
+	span       float64
+	rbw        float64
+	vbw        float64
+	sweepTime  float64
+}
+
+func convertSettings(trace esa.Trace) (settings, error) {
+	s := settings{}
+	for _, f := range []struct {
+		dst   *float64
+		value float64
+		units esa.FrequencyUnits
+	}{
+		{&s.centerFreq, trace.CenterFreq, trace.CenterFreqUnits},
+		{&s.span, trace.Span, trace.SpanUnits},
+		{&s.rbw, trace.RBW, trace.RBWUnits},
+		{&s.vbw, trace.VBW, trace.VBWUnits},
+	} {
+		mult, ok := frequencyMultipliers[f.units]
+		if !ok {
+			return s, fmt.Errorf("unknown frequency units: %s", f.units)
+		}
+		*f.dst = f.value * mult
+	}
+	mult, ok := timeMultipliers[trace.SweepTimeUnits]
+	if !ok {
+		return s, fmt.Errorf("unknown time units: %s", trace.SweepTimeUnits)
+	}
+	s.sweepTime = trace.SweepTime * mult
+	return s, nil
+}
+
+// writeFile creates the given filename and writes to it using the write
+// function.
+func writeFile(filename string, write func(io.Writer) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
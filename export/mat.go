@@ -0,0 +1,72 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/internal/mat"
+)
+
+// WriteMATFile writes the trace to the given filename as a MATLAB MAT-file.
+func WriteMATFile(filename string, trace esa.Trace) error {
+	return writeFile(filename, func(w io.Writer) error {
+		return WriteMAT(w, trace)
+	})
+}
+
+// WriteMAT writes the trace to the given io.Writer as a Level 5 MATLAB
+// MAT-file, which can be loaded using load(). The file contains the
+// frequency column vector in Hz, a trace1, trace2, ... column vector for
+// each trace containing data, and a metadata struct with the
+// header settings. The frequency, bandwidth, and sweep time settings in the
+// metadata are converted to Hz and seconds. The timestamp is in RFC 3339
+// format and is empty if unknown.
+func WriteMAT(w io.Writer, trace esa.Trace) error {
+	s, err := convertSettings(trace)
+	if err != nil {
+		return err
+	}
+	timestamp := ""
+	if !trace.Timestamp.IsZero() {
+		timestamp = trace.Timestamp.Format(time.RFC3339)
+	}
+	vars := []mat.Variable{{Name: "frequency", Value: mat.Column(trace.Frequency)}}
+	fields := []mat.Variable{
+		{Name: "timestamp", Value: mat.String(timestamp)},
+		{Name: "originalFilename", Value: mat.String(trace.OriginalFilename)},
+		{Name: "title", Value: mat.String(trace.Title)},
+		{Name: "model", Value: mat.String(trace.Model)},
+		{Name: "serialNumber", Value: mat.String(trace.SerialNum)},
+		{Name: "centerFrequency", Value: mat.Scalar(s.centerFreq)},
+		{Name: "span", Value: mat.Scalar(s.span)},
+		{Name: "rbw", Value: mat.Scalar(s.rbw)},
+		{Name: "vbw", Value: mat.Scalar(s.vbw)},
+		{Name: "referenceLevel", Value: mat.Scalar(trace.RefLevel)},
+		{Name: "referenceLevelUnits", Value: mat.String(string(trace.RefLevelUnits))},
+		{Name: "sweepTime", Value: mat.Scalar(s.sweepTime)},
+		{Name: "numPoints", Value: mat.Scalar(float64(trace.NumPoints))},
+	}
+	for i, d := range trace.Traces() {
+		if len(d.Values) == 0 {
+			continue
+		}
+		if len(d.Values) != len(trace.Frequency) {
+			return fmt.Errorf("mismatched lengths / freq %d / trace %d %d", len(trace.Frequency), i+1, len(d.Values))
+		}
+		name := fmt.Sprintf("trace%d", i+1)
+		vars = append(vars, mat.Variable{Name: name, Value: mat.Column(d.Values)})
+		fields = append(fields,
+			mat.Variable{Name: name + "Label", Value: mat.String(d.Label)},
+			mat.Variable{Name: name + "Units", Value: mat.String(d.Units)},
+		)
+	}
+	vars = append(vars, mat.Variable{Name: "metadata", Value: mat.Struct(fields...)})
+	return mat.Write(w, vars)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/internal/mat"
+)
+
+func TestWriteMAT(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "trace.mat")
+	if err := WriteMATFile(filename, testTrace()); err != nil {
+		t.Fatalf("error writing MAT-file: %s", err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("error reading MAT-file: %s", err)
+	}
+	assert(t, "header", string(b[:19]), "MATLAB 5.0 MAT-file")
+	assert(t, "endian indicator", string(b[126:128]), "IM")
+	for _, name := range []string{"frequency", "trace1", "trace2", "metadata", "centerFrequency", "trace2Units"} {
+		if !bytes.Contains(b, []byte(name)) {
+			t.Errorf("missing %s", name)
+		}
+	}
+	if bytes.Contains(b, []byte("trace3")) {
+		t.Errorf("unexpected trace3 without data")
+	}
+}
+
+func TestWriteMATExtraTraces(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMAT(&buf, readTrace(t, "../esa/testdata/e4402b_five_traces.csv")); err != nil {
+		t.Fatalf("error writing MAT-file: %s", err)
+	}
+	vars, err := mat.Read(&buf)
+	if err != nil {
+		t.Fatalf("error reading MAT-file: %s", err)
+	}
+	trace5, ok := mat.Lookup(vars, "trace5")
+	if !ok {
+		t.Fatalf("missing trace5")
+	}
+	assert(t, "trace5 points", len(trace5.Real()), 11)
+	assertFloat64(t, "trace5[0]", trace5.Real()[0], 38.123, 1e-9)
+	metadata, _ := mat.Lookup(vars, "metadata")
+	label, _ := metadata.Field("trace4Label")
+	assert(t, "trace4 label", label.Text(), "Trace 4")
+}
+
+// readTrace reads the ESA trace file with the given filename.
+func readTrace(t *testing.T, filename string) esa.Trace {
+	t.Helper()
+	trace, err := esa.ReadCSVFile(filename)
+	if err != nil {
+		t.Fatalf("error reading %s: %s", filename, err)
+	}
+	return trace
+}
+
+func TestWriteMATErrors(t *testing.T) {
+	badUnits := testTrace()
+	badUnits.SweepTimeUnits = "fortnights"
+	badLength := testTrace()
+	badLength.Trace2 = badLength.Trace2[:1]
+	var tests = []struct {
+		name  string
+		trace esa.Trace
+	}{
+		{"unknown units", badUnits},
+		{"mismatched lengths", badLength},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := WriteMAT(&bytes.Buffer{}, test.trace); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
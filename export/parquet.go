@@ -0,0 +1,139 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/parquet-go/parquet-go"
+)
+
+// WriteParquetFile writes the traces to the given filename in Apache Parquet
+// format.
+func WriteParquetFile(filename string, traces ...esa.Trace) error {
+	return writeFile(filename, func(w io.Writer) error {
+		return WriteParquet(w, traces...)
+	})
+}
+
+// WriteParquet writes the traces to the given io.Writer in Apache Parquet
+// format. The table is in long format with one row for each point of each
+// trace, so that many sweeps can be filtered and grouped together. The
+// columns are:
+//
+//	sweep                  index of the trace in the given traces
+//	timestamp              sweep timestamp, or null if unknown
+//	model                  instrument model
+//	serial_number          instrument serial number
+//	title                  screen title
+//	original_filename      filename the trace was saved to
+//	center_frequency_hz    center frequency in Hz
+//	span_hz                span in Hz
+//	rbw_hz                 resolution bandwidth in Hz
+//	vbw_hz                 video bandwidth in Hz
+//	reference_level        reference level
+//	reference_level_units  reference level units
+//	sweep_time_s           sweep time in seconds
+//	trace                  trace number from 1 to 3
+//	label                  trace label
+//	frequency_hz           frequency of the point in Hz
+//	amplitude              amplitude of the point
+//	units                  amplitude units of the trace
+//
+// Only the traces that contain data are written. The file is written using
+// github.com/parquet-go/parquet-go with Snappy compression, which every
+// Parquet reader supports.
+func WriteParquet(w io.Writer, traces ...esa.Trace) error {
+	var t table
+	for i, trace := range traces {
+		if err := t.add(i, trace); err != nil {
+			return fmt.Errorf("error adding trace %d: %s", i, err)
+		}
+	}
+	pw := parquet.NewGenericWriter[parquetRow](w,
+		parquet.Compression(&parquet.Snappy),
+		parquet.KeyValueMetadata("sweeps", strconv.Itoa(len(traces))),
+	)
+	if _, err := pw.Write(t.rows); err != nil {
+		return fmt.Errorf("error writing parquet: %s", err)
+	}
+	return pw.Close()
+}
+
+// parquetRow is a row of the Parquet table. The timestamp is in milliseconds
+// since the Unix epoch, and an unknown timestamp is written as null, since
+// it's the zero value of an optional column.
+type parquetRow struct {
+	Sweep         int32   `parquet:"sweep"`
+	Timestamp     int64   `parquet:"timestamp,optional,timestamp(millisecond)"`
+	Model         string  `parquet:"model"`
+	SerialNum     string  `parquet:"serial_number"`
+	Title         string  `parquet:"title"`
+	Filename      string  `parquet:"original_filename"`
+	CenterFreq    float64 `parquet:"center_frequency_hz"`
+	Span          float64 `parquet:"span_hz"`
+	RBW           float64 `parquet:"rbw_hz"`
+	VBW           float64 `parquet:"vbw_hz"`
+	RefLevel      float64 `parquet:"reference_level"`
+	RefLevelUnits string  `parquet:"reference_level_units"`
+	SweepTime     float64 `parquet:"sweep_time_s"`
+	Trace         int32   `parquet:"trace"`
+	Label         string  `parquet:"label"`
+	Frequency     float64 `parquet:"frequency_hz"`
+	Amplitude     float64 `parquet:"amplitude"`
+	Units         string  `parquet:"units"`
+}
+
+// table accumulates the rows of the Parquet table.
+type table struct {
+	rows []parquetRow
+}
+
+func (t *table) add(sweep int, trace esa.Trace) error {
+	s, err := convertSettings(trace)
+	if err != nil {
+		return err
+	}
+	var timestamp int64
+	if !trace.Timestamp.IsZero() {
+		timestamp = trace.Timestamp.UnixMilli()
+	}
+
+	for i, d := range trace.Traces() {
+		if len(d.Values) == 0 {
+			continue
+		}
+		if len(d.Values) != len(trace.Frequency) {
+			return fmt.Errorf("mismatched lengths / freq %d / trace %d %d", len(trace.Frequency), i+1, len(d.Values))
+		}
+		for j, value := range d.Values {
+			t.rows = append(t.rows, parquetRow{
+				Sweep:         int32(sweep),
+				Timestamp:     timestamp,
+				Model:         trace.Model,
+				SerialNum:     trace.SerialNum,
+				Title:         trace.Title,
+				Filename:      trace.OriginalFilename,
+				CenterFreq:    s.centerFreq,
+				Span:          s.span,
+				RBW:           s.rbw,
+				VBW:           s.vbw,
+				RefLevel:      trace.RefLevel,
+				RefLevelUnits: string(trace.RefLevelUnits),
+				SweepTime:     s.sweepTime,
+				Trace:         int32(i + 1),
+				Label:         d.Label,
+				Frequency:     trace.Frequency[j],
+				Amplitude:     value,
+				Units:         d.Units,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package export
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/parquet-go/parquet-go"
+)
+
+func testTrace() esa.Trace {
+	return esa.Trace{
+		Timestamp:       time.Date(2024, time.March, 14, 10, 21, 7, 0, time.UTC),
+		Model:           "E4402B",
+		SerialNum:       "US41192295",
+		CenterFreq:      1.5,
+		CenterFreqUnits: esa.Megahertz,
+		Span:            1,
+		SpanUnits:       esa.Megahertz,
+		RBW:             10,
+		RBWUnits:        esa.Kilohertz,
+		VBW:             30,
+		VBWUnits:        esa.Kilohertz,
+		RefLevel:        -10,
+		RefLevelUnits:   esa.DBm,
+		SweepTime:       50,
+		SweepTimeUnits:  esa.Milliseconds,
+		Trace1Label:     "Trace 1",
+		Trace1Units:     "dBm",
+		Trace2Label:     "Trace 2",
+		Trace2Units:     "dBm",
+		Frequency:       []float64{1e6, 1.5e6, 2e6},
+		Trace1:          []float64{-80, -20, -81},
+		Trace2:          []float64{-70, -15, -72},
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	var buf bytes.Buffer
+	untimed := testTrace()
+	untimed.Timestamp = time.Time{}
+	untimed.Trace2 = nil
+	if err := WriteParquet(&buf, testTrace(), untimed); err != nil {
+		t.Fatalf("error writing parquet: %s", err)
+	}
+
+	// Read the file back using the generic schema, rather than the one of
+	// the rows written.
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("error opening parquet: %s", err)
+	}
+	assert(t, "num rows", f.NumRows(), int64(9))
+	sweeps, _ := f.Lookup("sweeps")
+	assert(t, "sweeps metadata", sweeps, "2")
+	var tests = []struct {
+		column string
+		kind   parquet.Kind
+	}{
+		{"sweep", parquet.Int32},
+		{"timestamp", parquet.Int64},
+		{"model", parquet.ByteArray},
+		{"rbw_hz", parquet.Double},
+		{"sweep_time_s", parquet.Double},
+		{"trace", parquet.Int32},
+		{"frequency_hz", parquet.Double},
+		{"amplitude", parquet.Double},
+		{"units", parquet.ByteArray},
+	}
+	for _, test := range tests {
+		col, ok := f.Schema().Lookup(test.column)
+		if !ok {
+			t.Errorf("missing column %s", test.column)
+			continue
+		}
+		assert(t, test.column+" type", col.Node.Type().Kind(), test.kind)
+	}
+	timestamp, _ := f.Schema().Lookup("timestamp")
+	assert(t, "timestamp optional", timestamp.Node.Optional(), true)
+	logical := timestamp.Node.Type().LogicalType()
+	if logical == nil || logical.Timestamp == nil || logical.Timestamp.Unit.Millis == nil {
+		t.Errorf("timestamp logical type: got %v, want millisecond timestamp", logical)
+	}
+	model, _ := f.Schema().Lookup("model")
+	if logical := model.Node.Type().LogicalType(); logical == nil || logical.UTF8 == nil {
+		t.Errorf("model logical type: got %v, want string", logical)
+	}
+
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("error reading parquet: %s", err)
+	}
+	assert(t, "rows", len(rows), 9)
+	assertFloat64(t, "center frequency", rows[0].CenterFreq, 1.5e6, 1e-6)
+	assertFloat64(t, "rbw", rows[0].RBW, 10e3, 1e-6)
+	assertFloat64(t, "sweep time", rows[0].SweepTime, 0.05, 1e-12)
+	assert(t, "trace number", rows[3].Trace, int32(2))
+	assertFloat64(t, "amplitude", rows[4].Amplitude, -15, 1e-12)
+	assert(t, "units", rows[4].Units, "dBm")
+	assert(t, "timestamp", rows[0].Timestamp, int64(1710411667000))
+	assert(t, "second sweep", rows[6].Sweep, int32(1))
+
+	// An unknown timestamp is null.
+	r := parquet.NewReader(f)
+	defer r.Close()
+	generic := make([]parquet.Row, 9)
+	if n, err := r.ReadRows(generic); n != len(generic) {
+		t.Fatalf("error reading rows: got %d rows, %v", n, err)
+	}
+	assert(t, "timestamp not null", generic[0][timestamp.ColumnIndex].IsNull(), false)
+	assert(t, "timestamp null", generic[6][timestamp.ColumnIndex].IsNull(), true)
+}
+
+func TestWriteParquetExtraTraces(t *testing.T) {
+	var tbl table
+	if err := tbl.add(0, readTrace(t, "../esa/testdata/e4402b_five_traces.csv")); err != nil {
+		t.Fatalf("error adding trace: %s", err)
+	}
+	assert(t, "num rows", len(tbl.rows), 55)
+	assert(t, "trace number", tbl.rows[44].Trace, int32(5))
+	assert(t, "label", tbl.rows[44].Label, "Trace 5")
+	assertFloat64(t, "trace 4 amplitude", tbl.rows[33].Amplitude, 50.1234, 1e-9)
+	assertFloat64(t, "trace 5 amplitude", tbl.rows[54].Amplitude, 37.938, 1e-9)
+}
+
+func TestWriteParquetErrors(t *testing.T) {
+	badUnits := testTrace()
+	badUnits.RBWUnits = "furlongs"
+	badLength := testTrace()
+	badLength.Trace1 = badLength.Trace1[:2]
+	var tests = []struct {
+		name  string
+		trace esa.Trace
+	}{
+		{"unknown units", badUnits},
+		{"mismatched lengths", badLength},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := WriteParquet(&bytes.Buffer{}, test.trace); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package export
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/touchstone"
+	"github.com/gotmc/keysight/tracemath"
+)
+
+// NormalizedS21 returns the magnitude-only S21 of a tracking generator sweep
+// normalized by a reference sweep, such as one measured with a through
+// connection in place of the device. Trace 1 of each trace is used. The
+// traces are compared in dBm unless they're in the same dB units, and the
+// options determine how traces measured at different frequencies are
+// aligned. The phase of S21 and the other S-parameters aren't measured, so
+// they're zero. The returned S-parameters use the MA format with frequencies
+// in Hz, so they can be written as an .s2p file using WriteFile.
+func NormalizedS21(trace, reference esa.Trace, opts ...tracemath.Option) (touchstone.SParameters, error) {
+	s := touchstone.SParameters{
+		Ports:     2,
+		FreqUnit:  "Hz",
+		Parameter: "S",
+		Format:    touchstone.MA,
+		R:         50,
+	}
+	if !strings.EqualFold(strings.TrimSpace(trace.Trace1Units), strings.TrimSpace(reference.Trace1Units)) ||
+		!isDBUnits(trace.Trace1Units) {
+		var err error
+		if trace, err = trace.ConvertAmplitudeUnits(esa.DBm); err != nil {
+			return s, fmt.Errorf("error converting trace: %s", err)
+		}
+		if reference, err = reference.ConvertAmplitudeUnits(esa.DBm); err != nil {
+			return s, fmt.Errorf("error converting reference: %s", err)
+		}
+	}
+	t, err := tracemath.New(trace.Frequency, trace.Trace1)
+	if err != nil {
+		return s, fmt.Errorf("invalid trace: %s", err)
+	}
+	ref, err := tracemath.New(reference.Frequency, reference.Trace1)
+	if err != nil {
+		return s, fmt.Errorf("invalid reference: %s", err)
+	}
+	gain, err := tracemath.Subtract(t, ref, opts...)
+	if err != nil {
+		return s, err
+	}
+
+	s.Comments = []string{"Magnitude-only S21 normalized to a reference sweep"}
+	if trace.Model != "" {
+		s.Comments = append(s.Comments, strings.TrimSpace(trace.Model+" "+trace.SerialNum))
+	}
+	if !trace.Timestamp.IsZero() {
+		s.Comments = append(s.Comments, "Measured "+trace.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+	s.Comments = append(s.Comments, "S11, S12, and S22 weren't measured")
+	s.Frequency = gain.Frequency
+	s.Data = make([][][]complex128, gain.Len())
+	for k, db := range gain.Values {
+		s.Data[k] = [][]complex128{
+			{0, 0},
+			{complex(math.Pow(10, db/20), 0), 0},
+		}
+	}
+	return s, nil
+}
+
+// isDBUnits reports whether the amplitude units are logarithmic, so that the
+// difference of two values is a ratio in dB. Blank units are assumed to be
+// logarithmic.
+func isDBUnits(units string) bool {
+	units = strings.ToLower(strings.TrimSpace(units))
+	return units == "" || strings.HasPrefix(units, "db")
+}
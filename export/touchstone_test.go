@@ -0,0 +1,88 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package export
+
+import (
+	"math"
+	"math/cmplx"
+	"path/filepath"
+	"testing"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/touchstone"
+	"github.com/gotmc/keysight/tracemath"
+)
+
+func TestNormalizedS21(t *testing.T) {
+	dut := testTrace()
+	ref := testTrace()
+	ref.Trace1 = []float64{-74, -14, -75}
+	s, err := NormalizedS21(dut, ref)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "ports", s.Ports, 2)
+	assert(t, "num points", len(s.Data), 3)
+	s21 := s.At(2, 1)
+	assertFloat64(t, "|S21| dB", 20*math.Log10(cmplx.Abs(s21[1])), -6, 1e-9)
+	assert(t, "S11", s.At(1, 1)[0], complex128(0))
+
+	filename := filepath.Join(t.TempDir(), "filter.s2p")
+	if err := s.WriteFile(filename); err != nil {
+		t.Fatalf("error writing touchstone file: %s", err)
+	}
+	got, err := touchstone.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("error reading touchstone file: %s", err)
+	}
+	assertFloat64(t, "read |S21|", cmplx.Abs(got.At(2, 1)[2]), math.Pow(10, -6.0/20), 1e-12)
+	assertFloat64(t, "read frequency", got.Frequency[2], 2e6, 1e-6)
+
+	// A reference in linear units is converted before normalizing.
+	linear, err := ref.ConvertAmplitudeUnits(esa.Watts)
+	if err != nil {
+		t.Fatalf("error converting reference: %s", err)
+	}
+	s, err = NormalizedS21(dut, linear)
+	if err != nil {
+		t.Fatalf("received error with linear reference: %s", err)
+	}
+	assertFloat64(t, "linear |S21| dB", 20*math.Log10(cmplx.Abs(s.At(2, 1)[0])), -6, 1e-9)
+
+	// A reference measured with more points is resampled.
+	fine := testTrace()
+	fine.Frequency = []float64{1e6, 1.25e6, 1.5e6, 1.75e6, 2e6}
+	fine.Trace1 = []float64{-74, -74, -14, -75, -75}
+	if _, err := NormalizedS21(dut, fine); err == nil {
+		t.Errorf("expected error for mismatched frequencies")
+	}
+	s, err = NormalizedS21(dut, fine, tracemath.WithResample())
+	if err != nil {
+		t.Fatalf("received error resampling: %s", err)
+	}
+	assertFloat64(t, "resampled |S21| dB", 20*math.Log10(cmplx.Abs(s.At(2, 1)[1])), -6, 1e-9)
+}
+
+func TestNormalizedS21Errors(t *testing.T) {
+	unknown := testTrace()
+	unknown.Trace1Units = "furlongs"
+	short := testTrace()
+	short.Trace1 = short.Trace1[:2]
+	var tests = []struct {
+		name  string
+		trace esa.Trace
+	}{
+		{"unknown units", unknown},
+		{"mismatched lengths", short},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NormalizedS21(test.trace, testTrace()); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package eye builds eye diagrams from long oscilloscope waveforms of serial
+// data signals, like the real-time eye of the Infiniium oscilloscopes. The
+// waveform is folded by the unit interval, which is either given or
+// recovered from the threshold crossings using a constant frequency clock,
+// into a matrix of hit counts. The eye height and width and the time
+// interval error (TIE) jitter are measured from the same crossings, and the
+// eye can be rendered as a color graded PNG image.
+package eye
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gotmc/keysight/scope"
+)
+
+// Default settings.
+const (
+	DefaultTimeBins      = 256
+	DefaultAmplitudeBins = 256
+	// DefaultHysteresis is the default hysteresis of the threshold crossings
+	// in percent of the peak-to-peak amplitude.
+	DefaultHysteresis = 10
+)
+
+// centerWindow is the width of the window around the eye center, in UI,
+// whose samples are used to measure the eye height.
+const centerWindow = 0.2
+
+// Option configures how an eye diagram is built.
+type Option func(*config)
+
+type config struct {
+	ui            float64
+	threshold     float64
+	hasThreshold  bool
+	hysteresis    float64
+	timeBins      int
+	amplitudeBins int
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		hysteresis:    DefaultHysteresis,
+		timeBins:      DefaultTimeBins,
+		amplitudeBins: DefaultAmplitudeBins,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithUnitInterval sets the unit interval in seconds, which is the
+// reciprocal of the bit rate. By default, the unit interval is recovered from
+// the waveform.
+func WithUnitInterval(ui float64) Option {
+	return func(cfg *config) {
+		cfg.ui = ui
+	}
+}
+
+// WithThreshold sets the decision threshold in the y units of the waveform.
+// The default is halfway between the minimum and maximum.
+func WithThreshold(level float64) Option {
+	return func(cfg *config) {
+		cfg.threshold, cfg.hasThreshold = level, true
+	}
+}
+
+// WithHysteresis sets the hysteresis of the threshold crossings in percent
+// of the peak-to-peak amplitude, which keeps noise near the threshold from
+// being counted as crossings. The default is DefaultHysteresis.
+func WithHysteresis(percent float64) Option {
+	return func(cfg *config) {
+		cfg.hysteresis = percent
+	}
+}
+
+// WithResolution sets the number of time and amplitude bins of the eye
+// matrix. The default is DefaultTimeBins by DefaultAmplitudeBins.
+func WithResolution(timeBins, amplitudeBins int) Option {
+	return func(cfg *config) {
+		cfg.timeBins, cfg.amplitudeBins = timeBins, amplitudeBins
+	}
+}
+
+// Diagram is an eye diagram. Times are in seconds and amplitudes in the y
+// units of the waveform.
+type Diagram struct {
+	UnitInterval float64
+	// Offset is the time of the recovered clock edge at the first crossing.
+	// The clock edges are at Offset plus a whole number of unit intervals,
+	// and the eye is centered half a unit interval later.
+	Offset    float64
+	Threshold float64
+	// NumUIs is the number of unit intervals folded into the eye.
+	NumUIs int
+	// MinY and MaxY are the amplitudes at the bottom of the first and the
+	// top of the last amplitude bin.
+	MinY float64
+	MaxY float64
+	// Counts is the eye matrix, where Counts[i][j] is the number of hits in
+	// amplitude bin i, counted from MinY, and time bin j, which spans one
+	// unit interval from a clock edge, so the eye is centered in the matrix.
+	Counts [][]int
+	// OneLevel and ZeroLevel are the mean levels of the ones and zeros at
+	// the eye center.
+	OneLevel  float64
+	ZeroLevel float64
+	// EyeHeight is the vertical opening at the eye center, which is the
+	// difference between the one level minus three standard deviations and
+	// the zero level plus three standard deviations.
+	EyeHeight float64
+	// EyeWidth is the horizontal opening, which is the unit interval minus
+	// the peak-to-peak TIE jitter.
+	EyeWidth float64
+	Jitter   Jitter
+}
+
+// Jitter contains the time interval error (TIE) statistics, which is the
+// difference between each threshold crossing and the recovered clock edge.
+type Jitter struct {
+	TIE        []float64
+	Mean       float64
+	RMS        float64
+	PeakToPeak float64
+}
+
+// New returns the eye diagram of the waveform.
+func New(wfm scope.Waveform, opts ...Option) (Diagram, error) {
+	cfg := newConfig(opts)
+	if cfg.timeBins < 2 || cfg.amplitudeBins < 2 {
+		return Diagram{}, fmt.Errorf("invalid resolution %d x %d", cfg.timeBins, cfg.amplitudeBins)
+	}
+	values := wfm.Samples()
+	if len(values) < 2 {
+		return Diagram{}, errors.New("waveform has no samples")
+	}
+	if wfm.XIncrement <= 0 {
+		return Diagram{}, fmt.Errorf("invalid x increment: %g", wfm.XIncrement)
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if hi == lo {
+		return Diagram{}, errors.New("waveform has no transitions")
+	}
+	d := Diagram{Threshold: (lo + hi) / 2}
+	if cfg.hasThreshold {
+		d.Threshold = cfg.threshold
+	}
+	times := func(i float64) float64 {
+		return wfm.XOrigin + i*wfm.XIncrement
+	}
+	crossings := findCrossings(values, d.Threshold, cfg.hysteresis/100*(hi-lo))
+	for i := range crossings {
+		crossings[i] = times(crossings[i])
+	}
+	if err := d.recoverClock(crossings, cfg.ui); err != nil {
+		return d, err
+	}
+	if wfm.XIncrement >= d.UnitInterval {
+		return d, fmt.Errorf("sample interval %g s isn't shorter than the unit interval %g s", wfm.XIncrement, d.UnitInterval)
+	}
+
+	margin := 0.05 * (hi - lo)
+	d.MinY, d.MaxY = lo-margin, hi+margin
+	d.Counts = make([][]int, cfg.amplitudeBins)
+	for i := range d.Counts {
+		d.Counts[i] = make([]int, cfg.timeBins)
+	}
+	// Interpolate between the samples so that every time bin the waveform
+	// passes through is hit, even when there are few samples per UI.
+	steps := max(1, int(math.Ceil(wfm.XIncrement/d.UnitInterval*float64(cfg.timeBins))))
+	var ones, zeros []float64
+	for i := 0; i < len(values); i++ {
+		for s := 0; s < steps && (s == 0 || i+1 < len(values)); s++ {
+			f := float64(s) / float64(steps)
+			v := values[i]
+			if s > 0 {
+				v += f * (values[i+1] - values[i])
+			}
+			phase := (times(float64(i)+f) - d.Offset) / d.UnitInterval
+			phase -= math.Floor(phase)
+			col := min(int(phase*float64(cfg.timeBins)), cfg.timeBins-1)
+			row := min(int((v-d.MinY)/(d.MaxY-d.MinY)*float64(cfg.amplitudeBins)), cfg.amplitudeBins-1)
+			d.Counts[row][col]++
+			if s == 0 && math.Abs(phase-0.5) <= centerWindow/2 {
+				if v >= d.Threshold {
+					ones = append(ones, v)
+				} else {
+					zeros = append(zeros, v)
+				}
+			}
+		}
+	}
+	if len(ones) == 0 || len(zeros) == 0 {
+		return d, errors.New("eye center doesn't contain both ones and zeros")
+	}
+	oneMean, oneStd := meanStd(ones)
+	zeroMean, zeroStd := meanStd(zeros)
+	d.OneLevel, d.ZeroLevel = oneMean, zeroMean
+	d.EyeHeight = (oneMean - 3*oneStd) - (zeroMean + 3*zeroStd)
+	d.EyeWidth = d.UnitInterval - d.Jitter.PeakToPeak
+	return d, nil
+}
+
+// findCrossings returns the fractional sample indices where the values
+// cross the threshold. A crossing is only counted once the values move past
+// the threshold by half the hysteresis, and is then the last crossing of
+// the threshold itself.
+func findCrossings(values []float64, threshold, hysteresis float64) []float64 {
+	var crossings []float64
+	state := 0
+	last := -1.0
+	for i, v := range values {
+		if i > 0 && (values[i-1] < threshold) != (v < threshold) {
+			last = float64(i-1) + (threshold-values[i-1])/(v-values[i-1])
+		}
+		switch {
+		case v >= threshold+hysteresis/2 && state != 1:
+			if state == -1 && last >= 0 {
+				crossings = append(crossings, last)
+			}
+			state = 1
+		case v <= threshold-hysteresis/2 && state != -1:
+			if state == 1 && last >= 0 {
+				crossings = append(crossings, last)
+			}
+			state = -1
+		}
+	}
+	return crossings
+}
+
+// recoverClock sets the unit interval, offset, and jitter from the
+// crossing times. Each crossing is assigned the number of the clock edge
+// nearest to it, and the unit interval and offset of a constant frequency
+// clock are fitted to the crossings by least squares, unless the unit
+// interval is given, in which case only the offset is fitted.
+func (d *Diagram) recoverClock(crossings []float64, ui float64) error {
+	if len(crossings) < 3 {
+		return fmt.Errorf("not enough crossings / got %d / expected at least 3", len(crossings))
+	}
+	estimate := ui
+	if estimate <= 0 {
+		estimate = estimateUI(crossings)
+	}
+	if estimate <= 0 {
+		return errors.New("unable to recover the unit interval")
+	}
+	edges := make([]float64, len(crossings))
+	for i := 1; i < len(crossings); i++ {
+		edges[i] = edges[i-1] + math.Max(1, math.Round((crossings[i]-crossings[i-1])/estimate))
+	}
+	var sumX, sumY, sumXX, sumXY float64
+	n := float64(len(crossings))
+	for i, t := range crossings {
+		sumX += edges[i]
+		sumY += t
+		sumXX += edges[i] * edges[i]
+		sumXY += edges[i] * t
+	}
+	if ui > 0 {
+		d.UnitInterval = ui
+	} else {
+		d.UnitInterval = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	}
+	d.Offset = (sumY - d.UnitInterval*sumX) / n
+	d.NumUIs = int(edges[len(edges)-1])
+	d.Jitter.TIE = make([]float64, len(crossings))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, t := range crossings {
+		tie := t - (d.Offset + edges[i]*d.UnitInterval)
+		d.Jitter.TIE[i] = tie
+		lo, hi = math.Min(lo, tie), math.Max(hi, tie)
+	}
+	d.Jitter.Mean, d.Jitter.RMS = meanStd(d.Jitter.TIE)
+	d.Jitter.PeakToPeak = hi - lo
+	return nil
+}
+
+// estimateUI returns an estimate of the unit interval, which is the median
+// of the shortest intervals between crossings, since most runs of a data
+// signal are a single bit long.
+func estimateUI(crossings []float64) float64 {
+	intervals := make([]float64, len(crossings)-1)
+	for i := range intervals {
+		intervals[i] = crossings[i+1] - crossings[i]
+	}
+	sort.Float64s(intervals)
+	n := sort.SearchFloat64s(intervals, 1.5*intervals[0])
+	return intervals[n/2]
+}
+
+// meanStd returns the mean and standard deviation of the values.
+func meanStd(values []float64) (float64, float64) {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	ss := 0.0
+	for _, v := range values {
+		ss += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(values)))
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package eye
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/scope"
+)
+
+const (
+	testUI            = 100e-12
+	testSamplesPerUI  = 20
+	testJitter        = 0.05 * testUI
+	testRiseTimeInUIs = 0.2
+)
+
+// prbs7 returns n bits of the PRBS7 sequence.
+func prbs7(n int) []float64 {
+	bits := make([]float64, n)
+	reg := uint8(0x7f)
+	for i := range bits {
+		b := (reg>>6 ^ reg>>5) & 1
+		reg = reg<<1&0x7f | b
+		bits[i] = float64(b)
+	}
+	return bits
+}
+
+// nrzWaveform returns a 0 to 1 V NRZ waveform of a PRBS7 sequence with
+// linear edges, whose bit boundaries have sinusoidal jitter with an
+// amplitude of testJitter.
+func nrzWaveform(numBits int) scope.Waveform {
+	bits := prbs7(numBits)
+	boundary := func(k int) float64 {
+		return float64(k)*testUI + testJitter*math.Sin(2*math.Pi*float64(k)/37)
+	}
+	dt := testUI / testSamplesPerUI
+	n := (numBits - 1) * testSamplesPerUI
+	values := make([]float32, n)
+	for i := range values {
+		t := float64(i) * dt
+		k := max(1, min(numBits-1, int(math.Round(t/testUI))))
+		f := (t-boundary(k))/(testRiseTimeInUIs*testUI) + 0.5
+		f = math.Max(0, math.Min(1, f))
+		values[i] = float32(bits[k-1] + f*(bits[k]-bits[k-1]))
+	}
+	return scope.Waveform{
+		NumPoints:  n,
+		XIncrement: dt,
+		XUnits:     scope.UnitsSeconds,
+		YUnits:     scope.UnitsVolts,
+		Buffers:    []scope.Buffer{{Type: scope.BufferNormal, BytesPerPoint: 4, Values: values}},
+	}
+}
+
+func TestNew(t *testing.T) {
+	wfm := nrzWaveform(500)
+	var tests = []struct {
+		name string
+		opts []Option
+	}{
+		{"recovered", nil},
+		{"given", []Option{WithUnitInterval(testUI), WithThreshold(0.5)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := New(wfm, test.opts...)
+			if err != nil {
+				t.Fatalf("received error building eye: %s", err)
+			}
+			assertFloat64(t, "ui", d.UnitInterval, testUI, 1e-15)
+			assertFloat64(t, "threshold", d.Threshold, 0.5, 1e-9)
+			// The bit boundaries are at whole unit intervals.
+			assertFloat64(t, "offset", math.Remainder(d.Offset, testUI), 0, 0.05*testJitter)
+			assert(t, "num uis", d.NumUIs > 450, true)
+			assertFloat64(t, "one level", d.OneLevel, 1, 1e-6)
+			assertFloat64(t, "zero level", d.ZeroLevel, 0, 1e-6)
+			assertFloat64(t, "eye height", d.EyeHeight, 1, 1e-6)
+			// The sampled sinusoidal jitter spans nearly twice its amplitude.
+			assertFloat64(t, "tie p-p", d.Jitter.PeakToPeak, 2*testJitter, 0.1*testJitter)
+			assertFloat64(t, "tie rms", d.Jitter.RMS, testJitter/math.Sqrt2, 0.1*testJitter)
+			assertFloat64(t, "tie mean", d.Jitter.Mean, 0, 0.05*testJitter)
+			assertFloat64(t, "eye width", d.EyeWidth, d.UnitInterval-d.Jitter.PeakToPeak, 1e-18)
+			assert(t, "num rows", len(d.Counts), DefaultAmplitudeBins)
+			assert(t, "num cols", len(d.Counts[0]), DefaultTimeBins)
+
+			// The eye is open at its center between 0.1 and 0.9 V.
+			center := DefaultTimeBins / 2
+			for i, row := range d.Counts {
+				v := d.MinY + (float64(i)+0.5)*(d.MaxY-d.MinY)/DefaultAmplitudeBins
+				if v > 0.1 && v < 0.9 && row[center] != 0 {
+					t.Errorf("eye center has %d hits at %g V", row[center], v)
+				}
+			}
+		})
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	d, err := New(nrzWaveform(200), WithResolution(64, 32))
+	if err != nil {
+		t.Fatalf("received error building eye: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, d); err != nil {
+		t.Fatalf("received error writing PNG: %s", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("received error decoding PNG: %s", err)
+	}
+	assert(t, "width", img.Bounds().Dx(), 64)
+	assert(t, "height", img.Bounds().Dy(), 32)
+	// The center of the eye is black and the one level is graded.
+	r, g, b, _ := img.At(32, 16).RGBA()
+	assert(t, "center", r+g+b, uint32(0))
+	row := 31 - int((1-d.MinY)/(d.MaxY-d.MinY)*32)
+	r, g, b, _ = img.At(32, row).RGBA()
+	assert(t, "one level", r+g+b > 0, true)
+}
+
+func TestNewErrors(t *testing.T) {
+	flat := scope.Waveform{
+		XIncrement: 1e-12,
+		Buffers:    []scope.Buffer{{Type: scope.BufferNormal, Values: []float32{1, 1, 1, 1}}},
+	}
+	var tests = []struct {
+		name string
+		wfm  scope.Waveform
+		opts []Option
+	}{
+		{"no samples", scope.Waveform{}, nil},
+		{"no transitions", flat, nil},
+		{"resolution", nrzWaveform(50), []Option{WithResolution(1, 10)}},
+		{"threshold", nrzWaveform(50), []Option{WithThreshold(2)}},
+		{"undersampled", nrzWaveform(50), []Option{WithUnitInterval(testUI / 40)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := New(test.wfm, test.opts...); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package eye
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+)
+
+// colorGrade is the color ramp of the hit counts from the fewest to the
+// most hits, like the color grade display of the oscilloscope.
+var colorGrade = []color.RGBA{
+	{0, 0, 255, 255},
+	{0, 255, 255, 255},
+	{0, 255, 0, 255},
+	{255, 255, 0, 255},
+	{255, 0, 0, 255},
+}
+
+// Image returns the eye matrix as a color graded image with a pixel per
+// bin and the highest amplitude at the top. Bins without hits are black, and
+// the color of the others goes from blue to red with the logarithm of the
+// number of hits.
+func (d Diagram) Image() *image.RGBA {
+	rows := len(d.Counts)
+	cols := 0
+	maxCount := 0
+	for _, row := range d.Counts {
+		cols = max(cols, len(row))
+		for _, n := range row {
+			maxCount = max(maxCount, n)
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, cols, rows))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	for i, row := range d.Counts {
+		for j, n := range row {
+			if n == 0 {
+				continue
+			}
+			f := 1.0
+			if maxCount > 1 {
+				f = math.Log(float64(n)) / math.Log(float64(maxCount))
+			}
+			img.SetRGBA(j, rows-1-i, grade(f))
+		}
+	}
+	return img
+}
+
+// grade returns the color at the fraction of the color grade ramp.
+func grade(f float64) color.RGBA {
+	pos := f * float64(len(colorGrade)-1)
+	i := min(int(pos), len(colorGrade)-2)
+	frac := pos - float64(i)
+	a, b := colorGrade[i], colorGrade[i+1]
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + frac*(float64(y)-float64(x))))
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// WritePNGFile renders the eye as a PNG image to the given filename.
+func WritePNGFile(filename string, d Diagram) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := WritePNG(file, d); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WritePNG renders the eye as a PNG image to the io.Writer.
+func WritePNG(w io.Writer, d Diagram) error {
+	return png.Encode(w, d.Image())
+}
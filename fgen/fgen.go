@@ -0,0 +1,212 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package fgen controls the Keysight 33500 and 33600 series Trueform
+// waveform generators using SCPI commands, such as uploading arbitrary
+// waveforms created with the arb package to the instrument's volatile
+// memory and playing them.
+package fgen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gotmc/keysight/arb"
+	"github.com/gotmc/keysight/internal/scpi"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+// Instrument is a live Trueform waveform generator controlled using SCPI
+// commands sent over an io.ReadWriter, such as a connection from the gotmc
+// visa, usbtmc, or vxi11 packages. Channels are numbered from 1.
+type Instrument struct {
+	conn *scpi.Conn
+}
+
+// NewInstrument returns a waveform generator using the given connection,
+// which must terminate each response with a newline.
+func NewInstrument(rw io.ReadWriter) *Instrument {
+	return &Instrument{conn: scpi.New(rw)}
+}
+
+// filters maps the arbitrary waveform filters to the SCPI mnemonics.
+var filters = map[arb.Filter]string{
+	arb.FilterNormal: "NORM",
+	arb.FilterStep:   "STEP",
+	arb.FilterOff:    "OFF",
+}
+
+// Upload loads the waveform into the volatile memory of the channel under
+// the given name, which must start with a letter and contain at most 12
+// letters, digits, and underscores. The samples are transferred as a binary
+// block of big-endian DAC values. A two channel waveform is loaded as a dual
+// arbitrary waveform with interleaved samples.
+func (inst *Instrument) Upload(channel int, name string, wfm arb.Waveform) error {
+	if !validName(name) {
+		return fmt.Errorf("invalid arbitrary waveform name %q", name)
+	}
+	var samples []int16
+	command := fmt.Sprintf("SOUR%d:DATA:ARB:DAC %s,", channel, name)
+	switch wfm.ChannelCount() {
+	case 1:
+		samples = wfm.Samples[0]
+	case 2:
+		if len(wfm.Samples[0]) != len(wfm.Samples[1]) {
+			return fmt.Errorf("mismatched lengths / channel 1 %d / channel 2 %d",
+				len(wfm.Samples[0]), len(wfm.Samples[1]))
+		}
+		samples = make([]int16, 0, 2*wfm.NumPoints())
+		for i := range wfm.Samples[0] {
+			samples = append(samples, wfm.Samples[0][i], wfm.Samples[1][i])
+		}
+		command = fmt.Sprintf("SOUR%d:DATA:ARB2:DAC %s,", channel, name)
+	default:
+		return fmt.Errorf("unsupported number of channels %d", wfm.ChannelCount())
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("arbitrary waveform %s has no samples", name)
+	}
+	if err := inst.conn.Command(":FORM:BORD NORM"); err != nil {
+		return err
+	}
+	if err := inst.conn.Command("%s", command+string(block.EncodeInt16s(samples, block.Normal))); err != nil {
+		return err
+	}
+	return inst.checkError("uploading " + name)
+}
+
+// Select plays the named arbitrary waveform from the volatile memory of the
+// channel.
+func (inst *Instrument) Select(channel int, name string) error {
+	if err := inst.conn.Command("SOUR%d:FUNC:ARB %s", channel, name); err != nil {
+		return err
+	}
+	if err := inst.conn.Command("SOUR%d:FUNC ARB", channel); err != nil {
+		return err
+	}
+	return inst.checkError("selecting " + name)
+}
+
+// Play uploads the waveform to the channel's volatile memory, selects it,
+// and configures the channel's sample rate, filter, and levels from the
+// waveform. The output state is left unchanged.
+func (inst *Instrument) Play(channel int, name string, wfm arb.Waveform) error {
+	if err := inst.Upload(channel, name, wfm); err != nil {
+		return err
+	}
+	if err := inst.Select(channel, name); err != nil {
+		return err
+	}
+	if wfm.SampleRate > 0 {
+		if err := inst.SetSampleRate(channel, wfm.SampleRate); err != nil {
+			return err
+		}
+	}
+	if filter, ok := filters[wfm.Filter]; ok {
+		if err := inst.conn.Command("SOUR%d:FUNC:ARB:FILT %s", channel, filter); err != nil {
+			return err
+		}
+	}
+	if wfm.HighLevel > wfm.LowLevel {
+		amplitude := wfm.HighLevel - wfm.LowLevel
+		offset := (wfm.HighLevel + wfm.LowLevel) / 2
+		if err := inst.SetAmplitude(channel, amplitude, offset); err != nil {
+			return err
+		}
+	}
+	return inst.checkError("configuring " + name)
+}
+
+// SetSampleRate sets the arbitrary waveform sample rate of the channel in
+// samples per second.
+func (inst *Instrument) SetSampleRate(channel int, rate float64) error {
+	return inst.conn.Command("SOUR%d:FUNC:ARB:SRAT %g", channel, rate)
+}
+
+// SetAmplitude sets the peak to peak amplitude and the DC offset of the
+// channel in volts.
+func (inst *Instrument) SetAmplitude(channel int, amplitude, offset float64) error {
+	if err := inst.conn.Command("SOUR%d:VOLT %g", channel, amplitude); err != nil {
+		return err
+	}
+	return inst.conn.Command("SOUR%d:VOLT:OFFS %g", channel, offset)
+}
+
+// SetOutput turns the output of the channel on or off.
+func (inst *Instrument) SetOutput(channel int, on bool) error {
+	state := "OFF"
+	if on {
+		state = "ON"
+	}
+	return inst.conn.Command("OUTP%d %s", channel, state)
+}
+
+// Output returns whether the output of the channel is on.
+func (inst *Instrument) Output(channel int) (bool, error) {
+	state, err := inst.conn.QueryInt(fmt.Sprintf("OUTP%d?", channel))
+	return state == 1, err
+}
+
+// Volatile returns the names of the arbitrary waveforms in the volatile
+// memory of the channel.
+func (inst *Instrument) Volatile(channel int) ([]string, error) {
+	resp, err := inst.conn.Query(fmt.Sprintf("SOUR%d:DATA:VOL:CAT?", channel))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, field := range strings.Split(resp, ",") {
+		if name := strings.Trim(strings.TrimSpace(field), `"`); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// FreeVolatile returns the number of points available in the volatile
+// memory of the channel.
+func (inst *Instrument) FreeVolatile(channel int) (int, error) {
+	return inst.conn.QueryInt(fmt.Sprintf("SOUR%d:DATA:VOL:FREE?", channel))
+}
+
+// ClearVolatile deletes every arbitrary waveform in the volatile memory of
+// the channel, which must not be playing one of them.
+func (inst *Instrument) ClearVolatile(channel int) error {
+	if err := inst.conn.Command("SOUR%d:DATA:VOL:CLE", channel); err != nil {
+		return err
+	}
+	return inst.checkError("clearing volatile memory")
+}
+
+// checkError returns an error if the instrument's error queue isn't empty
+// after the given action.
+func (inst *Instrument) checkError(action string) error {
+	resp, err := inst.conn.Query("SYST:ERR?")
+	if err != nil {
+		return err
+	}
+	code, _, _ := strings.Cut(resp, ",")
+	if strings.TrimPrefix(strings.TrimSpace(code), "+") == "0" {
+		return nil
+	}
+	return fmt.Errorf("instrument error %s: %s", action, resp)
+}
+
+// validName returns whether the name is a valid arbitrary waveform name.
+func validName(name string) bool {
+	if name == "" || len(name) > 12 {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
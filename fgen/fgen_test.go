@@ -0,0 +1,136 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package fgen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/arb"
+	"github.com/gotmc/keysight/internal/scpitest"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+func newFakeTrueform() *scpitest.Instrument {
+	return scpitest.New(map[string]string{
+		"SYST:ERR?":            `+0,"No error"`,
+		"OUTP1?":               "1",
+		"OUTP2?":               "0",
+		"SOUR1:DATA:VOL:CAT?":  `"EXP_RISE","HAVERSINE","RAMP10"`,
+		"SOUR2:DATA:VOL:CAT?":  `""`,
+		"SOUR1:DATA:VOL:FREE?": "+16777216",
+	}, scpitest.WithBinaryWrites())
+}
+
+func TestInstrumentPlay(t *testing.T) {
+	fake := newFakeTrueform()
+	inst := NewInstrument(fake)
+	wfm := arb.NewWaveform([]float64{-1, 0, 1, 0.5}, 1e6)
+	if err := inst.Play(1, "RAMP10", wfm); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	want := []string{
+		":FORM:BORD NORM",
+		"SOUR1:DATA:ARB:DAC RAMP10," + string(block.EncodeInt16s(wfm.Samples[0], block.Normal)),
+		"SYST:ERR?",
+		"SOUR1:FUNC:ARB RAMP10",
+		"SOUR1:FUNC ARB",
+		"SYST:ERR?",
+		"SOUR1:FUNC:ARB:SRAT 1e+06",
+		"SOUR1:FUNC:ARB:FILT NORM",
+		"SOUR1:VOLT 2",
+		"SOUR1:VOLT:OFFS 0",
+		"SYST:ERR?",
+	}
+	assert(t, "num commands", len(fake.Commands), len(want))
+	for i := range want {
+		assert(t, fmt.Sprintf("command %d", i), fake.Commands[i], want[i])
+	}
+}
+
+func TestInstrumentUpload(t *testing.T) {
+	dual := arb.Waveform{Samples: [][]int16{{1, 2}, {-1, -2}}}
+	var tests = []struct {
+		name    string
+		wfmName string
+		wfm     arb.Waveform
+		command string
+		err     bool
+	}{
+		{"dual", "DUAL_1", dual, "SOUR2:DATA:ARB2:DAC DUAL_1," + string(block.EncodeInt16s([]int16{1, -1, 2, -2}, block.Normal)), false},
+		{"bad name", "1ARB", dual, "", true},
+		{"long name", "ABCDEFGHIJKLM", dual, "", true},
+		{"mismatched", "DUAL", arb.Waveform{Samples: [][]int16{{1, 2}, {1}}}, "", true},
+		{"three channels", "TRIPLE", arb.Waveform{Samples: [][]int16{{1}, {1}, {1}}}, "", true},
+		{"empty", "EMPTY", arb.Waveform{Samples: [][]int16{{}}}, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := newFakeTrueform()
+			err := NewInstrument(fake).Upload(2, test.wfmName, test.wfm)
+			assert(t, "error", err != nil, test.err)
+			if !test.err {
+				assert(t, "command", fake.Commands[1], test.command)
+			}
+		})
+	}
+
+	fake := newFakeTrueform()
+	fake.Responses["SYST:ERR?"] = `-781,"Not enough memory to store new arb"`
+	if err := NewInstrument(fake).Upload(1, "BIG", dual); err == nil {
+		t.Errorf("expected error from the instrument error queue")
+	}
+}
+
+func TestInstrumentVolatile(t *testing.T) {
+	fake := newFakeTrueform()
+	inst := NewInstrument(fake)
+	names, err := inst.Volatile(1)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "catalog", strings.Join(names, ";"), "EXP_RISE;HAVERSINE;RAMP10")
+	names, err = inst.Volatile(2)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "empty catalog", len(names), 0)
+	free, err := inst.FreeVolatile(1)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "free", free, 16777216)
+	if err := inst.ClearVolatile(1); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "clear", fake.Commands[len(fake.Commands)-2], "SOUR1:DATA:VOL:CLE")
+}
+
+func TestInstrumentOutput(t *testing.T) {
+	fake := newFakeTrueform()
+	inst := NewInstrument(fake)
+	if err := inst.SetOutput(1, true); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	if err := inst.SetOutput(2, false); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "commands", strings.Join(fake.Commands, ";"), "OUTP1 ON;OUTP2 OFF")
+	for channel, want := range map[int]bool{1: true, 2: false} {
+		on, err := inst.Output(channel)
+		if err != nil {
+			t.Fatalf("received error: %s", err)
+		}
+		assert(t, fmt.Sprintf("output %d", channel), on, want)
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
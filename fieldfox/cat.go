@@ -0,0 +1,143 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package fieldfox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// metersPerFoot converts distances in feet to meters.
+const metersPerFoot = 0.3048
+
+// distanceUnits and cableLossUnits are the scales converting the units of
+// the DTF settings to meters and dB/m.
+var (
+	distanceUnits  = map[string]float64{"m": 1, "ft": metersPerFoot}
+	cableLossUnits = map[string]float64{"db/m": 1, "db/ft": 1 / metersPerFoot}
+)
+
+// CableTrace contains the cable and antenna test (CAT mode) data saved by a
+// FieldFox, such as return loss or VSWR versus frequency, or distance to
+// fault (DTF) versus distance. Frequencies are in Hz and distances in
+// meters, converting from feet if needed.
+type CableTrace struct {
+	Header
+	// Measurement is the CAT measurement, such as "Return Loss" or
+	// "DTF (dB)".
+	Measurement string
+	StartFreq   float64
+	StopFreq    float64
+	// StartDistance, StopDistance, VelocityFactor, and CableLoss in dB/m
+	// are the DTF settings.
+	StartDistance  float64
+	StopDistance   float64
+	VelocityFactor float64
+	CableLoss      float64
+	// XLabel is the label of the first column. For distance-domain data,
+	// Distance is set instead of Frequency.
+	XLabel      string
+	TraceLabels []string
+	Frequency   []float64
+	Distance    []float64
+	Traces      [][]float64
+}
+
+// ReadCableCSVFile reads the cable and antenna test data saved by a FieldFox
+// in CSV format.
+func ReadCableCSVFile(filename string) (CableTrace, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return CableTrace{}, err
+	}
+	defer file.Close()
+	return ReadCableCSV(file)
+}
+
+// ReadCableCSV reads the cable and antenna test data saved by a FieldFox in
+// CSV format from the given io.Reader.
+func ReadCableCSV(r io.Reader) (CableTrace, error) {
+	trace := CableTrace{}
+	block, err := readBlock(r)
+	if err != nil {
+		return trace, err
+	}
+	trace.Header = block.header
+	if err := block.checkMode("CAT"); err != nil {
+		return trace, err
+	}
+	trace.Measurement = trace.Metadata["Measurement"]
+	err = parseSettings(trace.Metadata, []setting{
+		{"Start Frequency", &trace.StartFreq},
+		{"Stop Frequency", &trace.StopFreq},
+		{"Velocity Factor", &trace.VelocityFactor},
+	})
+	if err != nil {
+		return trace, err
+	}
+	for _, d := range []struct {
+		label string
+		value *float64
+		units map[string]float64
+	}{
+		{"Start Distance", &trace.StartDistance, distanceUnits},
+		{"Stop Distance", &trace.StopDistance, distanceUnits},
+		{"Cable Loss", &trace.CableLoss, cableLossUnits},
+	} {
+		s, ok := trace.Metadata[d.label]
+		if !ok {
+			continue
+		}
+		if *d.value, err = parseWithUnits(s, d.units); err != nil {
+			return trace, fmt.Errorf("error parsing %s: %s", strings.ToLower(d.label), err)
+		}
+	}
+	if len(block.labels) < 2 {
+		return trace, fmt.Errorf("expected frequency or distance and at least one trace column / got %d columns", len(block.labels))
+	}
+	trace.XLabel = block.labels[0]
+	trace.TraceLabels = block.labels[1:]
+	trace.Traces = block.columns[1:]
+	label := strings.ToLower(trace.XLabel)
+	if !strings.HasPrefix(label, "distance") {
+		trace.Frequency = block.columns[0]
+		return trace, nil
+	}
+	scale := 1.0
+	if strings.Contains(label, "(ft)") || strings.Contains(label, "(feet)") {
+		scale = metersPerFoot
+	}
+	trace.Distance = make([]float64, len(block.columns[0]))
+	for i, d := range block.columns[0] {
+		trace.Distance[i] = d * scale
+	}
+	return trace, nil
+}
+
+// parseWithUnits parses a value followed by optional units, such as
+// "30 ft", and returns it multiplied by the scale of the units, which are
+// matched ignoring case. A value without units isn't scaled.
+func parseWithUnits(s string, scales map[string]float64) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, fmt.Errorf("invalid value: %s", s)
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) == 1 {
+		return v, nil
+	}
+	scale, ok := scales[strings.ToLower(fields[1])]
+	if !ok {
+		return 0, fmt.Errorf("unknown units: %s", fields[1])
+	}
+	return v * scale, nil
+}
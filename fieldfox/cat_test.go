@@ -0,0 +1,80 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package fieldfox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCableCSVFile(t *testing.T) {
+	got, err := ReadCableCSVFile("./testdata/n9952a_dtf.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	assert(t, "model", got.Model, "N9952A")
+	assert(t, "mode", got.Mode, "CAT")
+	assert(t, "measurement", got.Measurement, "DTF (dB)")
+	assertFloat64(t, "stop freq", got.StopFreq, 1.5e9, 0.01)
+	assertFloat64(t, "start distance", got.StartDistance, 0, 1e-9)
+	assertFloat64(t, "stop distance", got.StopDistance, 12.192, 1e-9)
+	assertFloat64(t, "velocity factor", got.VelocityFactor, 0.66, 1e-9)
+	assertFloat64(t, "cable loss", got.CableLoss, 0.04/0.3048, 1e-9)
+	assert(t, "x label", got.XLabel, "Distance (ft)")
+	assert(t, "frequency", len(got.Frequency), 0)
+	assert(t, "distance len", len(got.Distance), 5)
+	assertFloat64(t, "distance[2]", got.Distance[2], 6.096, 1e-9)
+	assert(t, "num traces", len(got.Traces), 1)
+	assertFloat64(t, "t1[2]", got.Traces[0][2], -12.5, 1e-9)
+}
+
+func TestReadCableCSV(t *testing.T) {
+	data := "!Mode: CAT\n!Measurement: Return Loss\n!Start Frequency: 1000000 Hz\n" +
+		"BEGIN CAT\nFrequency,Trace 1\n1000000,-20.5\n2000000,-18.25\nEND\n"
+	got, err := ReadCableCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("received error reading CSV: %s", err)
+	}
+	assert(t, "measurement", got.Measurement, "Return Loss")
+	assertFloat64(t, "start freq", got.StartFreq, 1e6, 1e-9)
+	assert(t, "distance", len(got.Distance), 0)
+	assert(t, "frequency len", len(got.Frequency), 2)
+	assertFloat64(t, "t1[1]", got.Traces[0][1], -18.25, 1e-9)
+
+	metric := strings.Replace(data, "Frequency,Trace 1", "Distance (m),Trace 1", 1)
+	got, err = ReadCableCSV(strings.NewReader(metric))
+	if err != nil {
+		t.Fatalf("received error reading CSV: %s", err)
+	}
+	assertFloat64(t, "meters", got.Distance[1], 2e6, 1e-9)
+
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"wrong mode", strings.Replace(data, "CAT", "SA", 1)},
+		{"bad distance units", strings.Replace(data, "!Mode: CAT\n", "!Mode: CAT\n!Stop Distance: 3 yd\n", 1)},
+		{"bad cable loss", strings.Replace(data, "!Mode: CAT\n", "!Mode: CAT\n!Cable Loss: lots\n", 1)},
+		{"no trace", "!Mode: CAT\nBEGIN\n1\nEND\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadCableCSV(strings.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func FuzzReadCableCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCableCSV(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package fieldfox has the ability to parse files from the Keysight FieldFox
+// handheld analyzers, such as the N9912A, N9918A, and N9952A. Spectrum
+// analyzer (SA mode), network analyzer (NA mode), and cable and antenna test
+// (CAT mode) data are returned as separate types.
+//
+// FieldFox CSV files start with metadata lines prefixed with an exclamation
+// mark, followed by a BEGIN line, a column label line, the data rows, and an
+// END line.
+package fieldfox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header contains the metadata saved in the FieldFox file header.
+type Header struct {
+	Manufacturer    string
+	Model           string
+	SerialNum       string
+	FirmwareVersion string
+	Timestamp       time.Time
+	Mode            string
+	// Location is nil when the file doesn't contain GPS coordinates.
+	Location *Location
+	// Temperature is the internal instrument temperature in degrees Celsius.
+	// HasTemperature is false when the file doesn't contain a temperature.
+	Temperature    float64
+	HasTemperature bool
+	// Metadata contains every metadata line keyed by label, including those
+	// that are also parsed into the fields above.
+	Metadata map[string]string
+}
+
+// Location is the GPS position of the FieldFox when the file was saved.
+// Latitude and longitude are in decimal degrees, with south and west being
+// negative, and the altitude is in meters.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// Trace contains the spectrum analyzer (SA mode) data saved by a FieldFox.
+// Frequencies are in Hz.
+type Trace struct {
+	Header
+	CenterFreq  float64
+	Span        float64
+	RBW         float64
+	VBW         float64
+	RefLevel    float64
+	FreqLabel   string
+	TraceLabels []string
+	Frequency   []float64
+	Traces      [][]float64
+}
+
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	"01/02/2006 15:04:05",
+	"Monday, January 2, 2006 15:04:05",
+	"Mon, Jan 2, 2006 15:04:05",
+}
+
+// ReadCSVFile reads the spectrum analyzer trace data saved by a FieldFox in
+// CSV format.
+func ReadCSVFile(filename string) (Trace, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Trace{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads the spectrum analyzer trace data saved by a FieldFox in CSV
+// format from the given io.Reader.
+func ReadCSV(r io.Reader) (Trace, error) {
+	trace := Trace{}
+	block, err := readBlock(r)
+	if err != nil {
+		return trace, err
+	}
+	trace.Header = block.header
+	if err := trace.parseSettings(); err != nil {
+		return trace, err
+	}
+	if len(block.labels) < 2 {
+		return trace, fmt.Errorf("expected frequency and at least one trace column / got %d columns", len(block.labels))
+	}
+	trace.FreqLabel = block.labels[0]
+	trace.TraceLabels = block.labels[1:]
+	trace.Frequency = block.columns[0]
+	trace.Traces = block.columns[1:]
+	return trace, nil
+}
+
+// parseSettings parses the spectrum analyzer settings from the metadata.
+func (trace *Trace) parseSettings() error {
+	return parseSettings(trace.Metadata, []setting{
+		{"Center Frequency", &trace.CenterFreq},
+		{"Span", &trace.Span},
+		{"RBW", &trace.RBW},
+		{"VBW", &trace.VBW},
+		{"Ref Level", &trace.RefLevel},
+	})
+}
+
+// setting is a numeric setting saved as a metadata line with the label.
+type setting struct {
+	label string
+	value *float64
+}
+
+// parseSettings parses the settings found in the metadata, leaving the
+// missing settings unchanged.
+func parseSettings(metadata map[string]string, settings []setting) error {
+	for _, setting := range settings {
+		s, ok := metadata[setting.label]
+		if !ok {
+			continue
+		}
+		v, err := parseValue(s)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %s", strings.ToLower(setting.label), err)
+		}
+		*setting.value = v
+	}
+	return nil
+}
+
+// block is the generic contents of a FieldFox CSV file.
+type block struct {
+	header  Header
+	kind    string
+	labels  []string
+	columns [][]float64
+}
+
+// readBlock reads the metadata and the numeric data between the BEGIN and END
+// lines.
+func readBlock(r io.Reader) (block, error) {
+	b := block{header: Header{Metadata: make(map[string]string)}}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	foundBegin := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			if err := b.header.parseMetadata(strings.TrimPrefix(line, "!")); err != nil {
+				return b, fmt.Errorf("error in metadata line %d: %s", lineNum, err)
+			}
+			continue
+		}
+		if strings.HasPrefix(strings.ToUpper(line), "BEGIN") {
+			b.kind = strings.TrimSpace(line[len("BEGIN"):])
+			foundBegin = true
+			break
+		}
+		return b, fmt.Errorf("unexpected line %d before BEGIN: %s", lineNum, line)
+	}
+	if !foundBegin {
+		if err := scanner.Err(); err != nil {
+			return b, err
+		}
+		return b, fmt.Errorf("missing BEGIN line")
+	}
+	foundEnd := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.EqualFold(line, "END") {
+			foundEnd = true
+			break
+		}
+		fields := strings.Split(line, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if b.labels == nil && b.columns == nil {
+			if _, err := strconv.ParseFloat(fields[0], 64); err != nil {
+				b.labels = fields
+				continue
+			}
+		}
+		if b.columns == nil {
+			b.columns = make([][]float64, len(fields))
+		}
+		if len(fields) != len(b.columns) {
+			return b, fmt.Errorf("wrong number of columns in line %d / got %d / expected %d",
+				lineNum, len(fields), len(b.columns))
+		}
+		for i, field := range fields {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return b, fmt.Errorf("error parsing column %d value %s in line %d", i+1, field, lineNum)
+			}
+			b.columns[i] = append(b.columns[i], v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return b, err
+	}
+	if !foundEnd {
+		return b, fmt.Errorf("missing END line")
+	}
+	if b.labels == nil {
+		b.labels = make([]string, len(b.columns))
+	}
+	if b.columns == nil {
+		b.columns = make([][]float64, len(b.labels))
+	}
+	if len(b.labels) != len(b.columns) {
+		return b, fmt.Errorf("wrong number of column labels / got %d / expected %d",
+			len(b.labels), len(b.columns))
+	}
+	return b, nil
+}
+
+// checkMode returns an error unless the mode given by the metadata, or by
+// the BEGIN line if the metadata doesn't include it, is the expected mode.
+func (b block) checkMode(mode string) error {
+	got := b.header.Mode
+	if got == "" {
+		got = b.kind
+	}
+	if got != "" && !strings.EqualFold(got, mode) {
+		return fmt.Errorf("expected %s mode / got %s", mode, got)
+	}
+	return nil
+}
+
+// parseMetadata parses a single metadata line without the leading exclamation
+// mark.
+func (h *Header) parseMetadata(line string) error {
+	label, value, found := strings.Cut(line, ":")
+	if !found {
+		// The identification line is a comma separated list of the
+		// manufacturer, model, serial number, and firmware version.
+		fields := strings.Split(line, ",")
+		if len(fields) == 4 && h.Model == "" {
+			h.Manufacturer = strings.TrimSpace(fields[0])
+			h.Model = strings.TrimSpace(fields[1])
+			h.SerialNum = strings.TrimSpace(fields[2])
+			h.FirmwareVersion = strings.TrimSpace(fields[3])
+		}
+		return nil
+	}
+	label = strings.TrimSpace(label)
+	value = strings.TrimSpace(value)
+	h.Metadata[label] = value
+	var err error
+	switch strings.ToLower(label) {
+	case "date":
+		h.Timestamp, err = parseTimestamp(value)
+	case "mode":
+		h.Mode = value
+	case "gps latitude":
+		h.location().Latitude, err = parseCoordinate(value, "N", "S")
+	case "gps longitude":
+		h.location().Longitude, err = parseCoordinate(value, "E", "W")
+	case "gps altitude":
+		h.location().Altitude, err = parseValue(value)
+	case "temperature":
+		h.Temperature, err = parseValue(value)
+		h.HasTemperature = err == nil
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %s", strings.ToLower(label), err)
+	}
+	return nil
+}
+
+func (h *Header) location() *Location {
+	if h.Location == nil {
+		h.Location = &Location{}
+	}
+	return h.Location
+}
+
+// parseValue parses a numeric value that may be followed by units separated
+// by whitespace, such as "1609 m".
+func parseValue(s string) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("missing value")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// parseCoordinate parses a coordinate in decimal degrees with an optional
+// hemisphere suffix. The negative hemisphere returns a negative value.
+func parseCoordinate(s, positive, negative string) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, fmt.Errorf("invalid coordinate: %s", s)
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) == 2 {
+		switch strings.ToUpper(fields[1]) {
+		case positive:
+		case negative:
+			v = -v
+		default:
+			return 0, fmt.Errorf("invalid hemisphere: %s", fields[1])
+		}
+	}
+	return v, nil
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp format: %s", s)
+}
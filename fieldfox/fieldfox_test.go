@@ -0,0 +1,102 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package fieldfox
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	got, err := ReadCSVFile("./testdata/n9912a_spectrum.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	assert(t, "manufacturer", got.Manufacturer, "Keysight Technologies")
+	assert(t, "model", got.Model, "N9912A")
+	assert(t, "s/n", got.SerialNum, "MY53101234")
+	assert(t, "firmware", got.FirmwareVersion, "A.10.17")
+	assert(t, "timestamp", got.Timestamp, time.Date(2023, time.June, 2, 14, 21, 7, 0, time.UTC))
+	assert(t, "mode", got.Mode, "SA")
+	if got.Location == nil {
+		t.Fatalf("missing GPS location")
+	}
+	assertFloat64(t, "latitude", got.Location.Latitude, 39.7392, 1e-9)
+	assertFloat64(t, "longitude", got.Location.Longitude, -104.9903, 1e-9)
+	assertFloat64(t, "altitude", got.Location.Altitude, 1609, 1e-9)
+	assert(t, "has temperature", got.HasTemperature, true)
+	assertFloat64(t, "temperature", got.Temperature, 31.5, 1e-9)
+	assertFloat64(t, "center freq", got.CenterFreq, 2.4e9, 0.01)
+	assertFloat64(t, "span", got.Span, 100e6, 0.01)
+	assertFloat64(t, "rbw", got.RBW, 300e3, 0.01)
+	assertFloat64(t, "ref level", got.RefLevel, -10, 1e-9)
+	assert(t, "freq label", got.FreqLabel, "Frequency")
+	assert(t, "num traces", len(got.Traces), 2)
+	assert(t, "trace 2 label", got.TraceLabels[1], "Trace 2")
+	assert(t, "freq len", len(got.Frequency), 11)
+	assertFloat64(t, "freq[5]", got.Frequency[5], 2.4e9, 0.01)
+	assertFloat64(t, "t1[5]", got.Traces[0][5], -50, 1e-9)
+	assertFloat64(t, "t2[5]", got.Traces[1][5], -48, 1e-9)
+}
+
+func TestReadCSVWithoutGPS(t *testing.T) {
+	data := "!Keysight Technologies,N9918A,MY1,A.01\n!Mode: SA\nBEGIN\n1,-10\n2,-20\nEND\n"
+	got, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("received error reading CSV: %s", err)
+	}
+	if got.Location != nil {
+		t.Errorf("expected nil location / got %#v", got.Location)
+	}
+	assert(t, "has temperature", got.HasTemperature, false)
+	assert(t, "num traces", len(got.Traces), 1)
+	assertFloat64(t, "t1[1]", got.Traces[0][1], -20, 1e-9)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"missing begin", "!Mode: SA\n"},
+		{"missing end", "BEGIN\n1,2\n"},
+		{"ragged", "BEGIN\n1,2\n1,2,3\nEND\n"},
+		{"bad hemisphere", "!GPS Latitude: 39.7 X\nBEGIN\n1,2\nEND\n"},
+		{"bad timestamp", "!Date: yesterday\nBEGIN\n1,2\nEND\n"},
+		{"no trace", "BEGIN\n1\nEND\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package fieldfox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// NetworkTrace contains the network analyzer (NA mode) data saved by a
+// FieldFox, such as S-parameters. Frequencies are in Hz and the output power
+// is in dBm.
+type NetworkTrace struct {
+	Header
+	StartFreq   float64
+	StopFreq    float64
+	IFBW        float64
+	OutputPower float64
+	FreqLabel   string
+	// Labels are the labels of the data columns, such as "S21 Log Mag(dB)"
+	// or "S11 Real" and "S11 Imag" for complex data.
+	Labels    []string
+	Frequency []float64
+	Data      [][]float64
+}
+
+// ReadNetworkCSVFile reads the network analyzer data saved by a FieldFox in
+// CSV format.
+func ReadNetworkCSVFile(filename string) (NetworkTrace, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return NetworkTrace{}, err
+	}
+	defer file.Close()
+	return ReadNetworkCSV(file)
+}
+
+// ReadNetworkCSV reads the network analyzer data saved by a FieldFox in CSV
+// format from the given io.Reader.
+func ReadNetworkCSV(r io.Reader) (NetworkTrace, error) {
+	trace := NetworkTrace{}
+	block, err := readBlock(r)
+	if err != nil {
+		return trace, err
+	}
+	trace.Header = block.header
+	if err := block.checkMode("NA"); err != nil {
+		return trace, err
+	}
+	err = parseSettings(trace.Metadata, []setting{
+		{"Start Frequency", &trace.StartFreq},
+		{"Stop Frequency", &trace.StopFreq},
+		{"IF BW", &trace.IFBW},
+		{"Output Power", &trace.OutputPower},
+	})
+	if err != nil {
+		return trace, err
+	}
+	if len(block.labels) < 2 {
+		return trace, fmt.Errorf("expected frequency and at least one data column / got %d columns", len(block.labels))
+	}
+	trace.FreqLabel = block.labels[0]
+	trace.Labels = block.labels[1:]
+	trace.Frequency = block.columns[0]
+	trace.Data = block.columns[1:]
+	return trace, nil
+}
+
+// Column returns the data column with the given label, ignoring case.
+func (trace NetworkTrace) Column(label string) ([]float64, bool) {
+	for i, l := range trace.Labels {
+		if strings.EqualFold(l, label) {
+			return trace.Data[i], true
+		}
+	}
+	return nil, false
+}
+
+// Complex returns the complex data of the parameter, such as "S11", from its
+// real and imaginary columns.
+func (trace NetworkTrace) Complex(param string) ([]complex128, error) {
+	re, ok := trace.Column(param + " Real")
+	if !ok {
+		return nil, fmt.Errorf("no real column for %s", param)
+	}
+	im, ok := trace.Column(param + " Imag")
+	if !ok {
+		return nil, fmt.Errorf("no imaginary column for %s", param)
+	}
+	values := make([]complex128, len(re))
+	for i := range re {
+		values[i] = complex(re[i], im[i])
+	}
+	return values, nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package fieldfox
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadNetworkCSVFile(t *testing.T) {
+	got, err := ReadNetworkCSVFile("./testdata/n9918a_na.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	assert(t, "model", got.Model, "N9918A")
+	assert(t, "mode", got.Mode, "NA")
+	assertFloat64(t, "start freq", got.StartFreq, 2.3e9, 0.01)
+	assertFloat64(t, "stop freq", got.StopFreq, 2.5e9, 0.01)
+	assertFloat64(t, "if bw", got.IFBW, 10e3, 1e-9)
+	assertFloat64(t, "output power", got.OutputPower, -15, 1e-9)
+	assert(t, "freq label", got.FreqLabel, "Frequency")
+	assert(t, "num columns", len(got.Data), 3)
+	assert(t, "label 3", got.Labels[2], "S21 Log Mag(dB)")
+	assert(t, "freq len", len(got.Frequency), 5)
+
+	s21, ok := got.Column("s21 log mag(db)")
+	if !ok {
+		t.Fatal("missing S21 column")
+	}
+	assertFloat64(t, "s21[2]", s21[2], -0.8, 1e-9)
+	s11, err := got.Complex("S11")
+	if err != nil {
+		t.Fatalf("received error getting S11: %s", err)
+	}
+	assert(t, "s11[4]", s11[4], complex(0.29, 0.14))
+	if _, err := got.Complex("S21"); err == nil {
+		t.Error("expected error for S21 without real and imaginary columns")
+	}
+}
+
+func TestReadNetworkCSVWrongMode(t *testing.T) {
+	data, err := os.ReadFile("./testdata/n9912a_spectrum.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadNetworkCSV(strings.NewReader(string(data))); err == nil {
+		t.Error("expected error reading SA mode file")
+	}
+}
+
+func FuzzReadNetworkCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadNetworkCSV(bytes.NewReader(data))
+	})
+}
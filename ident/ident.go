@@ -0,0 +1,159 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package ident parses the identity of Keysight/Agilent/HP instruments
+// returned by the *IDN? query and maps model numbers to instrument families,
+// so that data from an instrument or a file saved by it can be routed to the
+// package that decodes it.
+package ident
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Identity is the response to the *IDN? query.
+type Identity struct {
+	Manufacturer string
+	Model        string
+	SerialNum    string
+	Firmware     string
+}
+
+// Parse parses a *IDN? response, such as
+// "Keysight Technologies,N9020A,MY49100744,A.23.05". Some instruments use
+// commas within the firmware field, so any fields after the fourth are kept
+// as part of the firmware.
+func Parse(idn string) (Identity, error) {
+	fields := strings.SplitN(strings.TrimSpace(idn), ",", 4)
+	if len(fields) != 4 {
+		return Identity{}, fmt.Errorf("wrong number of *IDN? fields / got %d / expected 4", len(fields))
+	}
+	for i := range fields {
+		fields[i] = strings.Trim(strings.TrimSpace(fields[i]), `"`)
+	}
+	return Identity{fields[0], fields[1], fields[2], fields[3]}, nil
+}
+
+// String returns the identity in the *IDN? response format.
+func (id Identity) String() string {
+	return strings.Join([]string{id.Manufacturer, id.Model, id.SerialNum, id.Firmware}, ",")
+}
+
+// Vendor returns the company name of the manufacturer, which is Keysight,
+// Agilent, or Hewlett-Packard for the instruments supported by this module,
+// or the manufacturer as returned by the instrument for others.
+func (id Identity) Vendor() string {
+	m := strings.ToUpper(id.Manufacturer)
+	switch {
+	case strings.HasPrefix(m, "KEYSIGHT"):
+		return "Keysight"
+	case strings.HasPrefix(m, "AGILENT"):
+		return "Agilent"
+	case strings.HasPrefix(m, "HEWLETT") || m == "HP":
+		return "Hewlett-Packard"
+	}
+	return id.Manufacturer
+}
+
+// Family returns the instrument family of the model.
+func (id Identity) Family() Family {
+	return Lookup(id.Model)
+}
+
+// Family is a family of instruments sharing a file format or command set,
+// which typically corresponds to a package of this module.
+type Family string
+
+// Instrument families known to the registry. Unknown is returned for models
+// that aren't registered.
+const (
+	Unknown         Family = ""
+	ESA             Family = "ESA"
+	PSA             Family = "PSA"
+	XSeries         Family = "X-Series"
+	FieldFox        Family = "FieldFox"
+	InfiniiVision   Family = "InfiniiVision"
+	Infiniium       Family = "Infiniium"
+	DMM             Family = "DMM"
+	Trueform        Family = "Trueform"
+	E36xx           Family = "E36xx"
+	N67xx           Family = "N67xx"
+	PowerMeter      Family = "Power Meter"
+	Counter         Family = "Counter"
+	LCR             Family = "LCR"
+	DAQ             Family = "DAQ"
+	NetworkAnalyzer Family = "Network Analyzer"
+	NoiseFigure     Family = "Noise Figure"
+)
+
+type entry struct {
+	pattern *regexp.Regexp
+	family  Family
+}
+
+var (
+	mu sync.RWMutex
+	// registry is searched from the most recently registered entry, so that
+	// later registrations override the built-in patterns.
+	registry []entry
+)
+
+func init() {
+	for _, e := range []struct {
+		pattern string
+		family  Family
+	}{
+		{`E440[1-8]B|E4411B`, ESA},
+		{`E444[0-8]A`, PSA},
+		{`N90[0-4][0-9][AB]`, XSeries},
+		{`N99[1-6][0-9][AB]`, FieldFox},
+		{`(DSO|MSO)X[1-6][0-9]{3}[A-Z]|EDUX1[0-9]{3}[AGT]|(DSO|MSO)[5-7][0-9]{3}[AB]`, InfiniiVision},
+		{`(DSO|MSO|DSA)(9[0-9]{3,4}|8[0-9]{4}|[SVZ][0-9]{3})[A-Z]|UXR[0-9]{4}[AB]|[ME]XR[0-9]{3}[AB]`, Infiniium},
+		{`344[0-9]{2}A`, DMM},
+		{`335[0-9]{2}[AB]|336[0-9]{2}A`, Trueform},
+		{`E36[0-9]{2,3}[AB]?`, E36xx},
+		{`N67[0-9]{2}[ABC]`, N67xx},
+		{`N19[0-9]{2}[AB]|E441[6-9][AB]|U2[0-9]{3}[AXH]`, PowerMeter},
+		{`53[12][0-9]{2}A`, Counter},
+		{`E498[01]AL?|E4990A|E4991B`, LCR},
+		{`3497[0-2]A|DAQ97[0-3]A`, DAQ},
+		{`E50[6-8][0-9][ABC]|N52[2-4][0-9][AB]|P50[0-2][0-9][AB]`, NetworkAnalyzer},
+		{`N897[0-9][AB]`, NoiseFigure},
+	} {
+		Register(e.pattern, e.family)
+	}
+}
+
+// Register maps the model numbers matching the regular expression pattern to
+// the family. The pattern must match the whole model number, which is
+// compared ignoring case. Registering a pattern overrides the previously
+// registered patterns matching the same models. Register panics if the
+// pattern isn't a valid regular expression.
+func Register(pattern string, family Family) {
+	re := regexp.MustCompile(`(?i)^(?:` + pattern + `)$`)
+	mu.Lock()
+	defer mu.Unlock()
+	registry = append(registry, entry{re, family})
+}
+
+// Lookup returns the family of the model number, such as ESA for "E4402B",
+// or Unknown if the model isn't registered. Spaces and hyphens are ignored,
+// so "DSO-X 3034T" and "DSOX3034T" are the same model, as are option
+// suffixes after a slash, such as "N9020A/503".
+func Lookup(model string) Family {
+	model = strings.NewReplacer(" ", "", "-", "").Replace(model)
+	model, _, _ = strings.Cut(model, "/")
+	mu.RLock()
+	defer mu.RUnlock()
+	for i := len(registry) - 1; i >= 0; i-- {
+		if registry[i].pattern.MatchString(model) {
+			return registry[i].family
+		}
+	}
+	return Unknown
+}
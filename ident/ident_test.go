@@ -0,0 +1,132 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package ident
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		idn    string
+		id     Identity
+		vendor string
+		family Family
+	}{
+		{
+			"Hewlett-Packard, E4402B, US41192390, A.14.01",
+			Identity{"Hewlett-Packard", "E4402B", "US41192390", "A.14.01"},
+			"Hewlett-Packard", ESA,
+		},
+		{
+			"Keysight Technologies,N9020A,MY49100744,A.23.05\n",
+			Identity{"Keysight Technologies", "N9020A", "MY49100744", "A.23.05"},
+			"Keysight", XSeries,
+		},
+		{
+			"AGILENT TECHNOLOGIES,DSO-X 3034A,MY51360314,02.41.2015102200",
+			Identity{"AGILENT TECHNOLOGIES", "DSO-X 3034A", "MY51360314", "02.41.2015102200"},
+			"Agilent", InfiniiVision,
+		},
+		{
+			"Keysight Technologies,34465A,MY57500123,A.03.01-02.40-03.01-00.52-02-01",
+			Identity{"Keysight Technologies", "34465A", "MY57500123", "A.03.01-02.40-03.01-00.52-02-01"},
+			"Keysight", DMM,
+		},
+		{
+			"HEWLETT-PACKARD,34401A,0,11-5-2",
+			Identity{"HEWLETT-PACKARD", "34401A", "0", "11-5-2"},
+			"Hewlett-Packard", DMM,
+		},
+		{
+			"Agilent Technologies,E4980A,MY46103726,A.02.20,extra",
+			Identity{"Agilent Technologies", "E4980A", "MY46103726", "A.02.20,extra"},
+			"Agilent", LCR,
+		},
+		{
+			"Rohde&Schwarz,FSV-7,101234,2.30",
+			Identity{"Rohde&Schwarz", "FSV-7", "101234", "2.30"},
+			"Rohde&Schwarz", Unknown,
+		},
+	}
+	for _, test := range tests {
+		id, err := Parse(test.idn)
+		if err != nil {
+			t.Errorf("received error parsing %q: %s", test.idn, err)
+			continue
+		}
+		assert(t, "identity", id, test.id)
+		assert(t, "vendor", id.Vendor(), test.vendor)
+		assert(t, "family", id.Family(), test.family)
+	}
+	if _, err := Parse("E4402B,US41192390"); err == nil {
+		t.Errorf("expected error for too few fields")
+	}
+	id := Identity{"Keysight Technologies", "N9918A", "MY53101234", "A.11.50"}
+	assert(t, "string", id.String(), "Keysight Technologies,N9918A,MY53101234,A.11.50")
+}
+
+func TestLookup(t *testing.T) {
+	var tests = []struct {
+		model  string
+		family Family
+	}{
+		{"E4402B", ESA},
+		{"e4407b", ESA},
+		{"E4440A", PSA},
+		{"E4448A", PSA},
+		{"N9010B", XSeries},
+		{"N9030A/503", XSeries},
+		{"N9040B", XSeries},
+		{"N9912A", FieldFox},
+		{"N9918A", FieldFox},
+		{"N9952A", FieldFox},
+		{"DSOX1204G", InfiniiVision},
+		{"MSO-X 4104A", InfiniiVision},
+		{"EDUX1052G", InfiniiVision},
+		{"DSO7104B", InfiniiVision},
+		{"DSO9254A", Infiniium},
+		{"DSOS254A", Infiniium},
+		{"DSO90254A", Infiniium},
+		{"UXR0334A", Infiniium},
+		{"MXR058A", Infiniium},
+		{"34470A", DMM},
+		{"33622A", Trueform},
+		{"33511B", Trueform},
+		{"E3631A", E36xx},
+		{"E36312A", E36xx},
+		{"N6705C", N67xx},
+		{"N1914A", PowerMeter},
+		{"53230A", Counter},
+		{"E4980AL", LCR},
+		{"DAQ970A", DAQ},
+		{"34972A", DAQ},
+		{"E5071C", NetworkAnalyzer},
+		{"N5227B", NetworkAnalyzer},
+		{"N8975A", NoiseFigure},
+		{"", Unknown},
+		{"E4402", Unknown},
+		{"FSV-7", Unknown},
+	}
+	for _, test := range tests {
+		assert(t, test.model, Lookup(test.model), test.family)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	const custom Family = "Custom"
+	n := len(registry)
+	defer func() { registry = registry[:n] }()
+	Register(`ACME[0-9]+`, custom)
+	Register(`N9020A`, custom)
+	assert(t, "new model", Lookup("ACME100"), custom)
+	assert(t, "override", Lookup("N9020A"), custom)
+	assert(t, "others unchanged", Lookup("N9030A"), XSeries)
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package bmp decodes the uncompressed Windows BMP images saved as screen
+// captures by the spectrum analyzers. Importing the package registers the
+// format with image.Decode.
+package bmp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+const fileHeaderSize = 14
+
+func init() {
+	image.RegisterFormat("bmp", "BM", Decode, DecodeConfig)
+}
+
+// header is the combined file and information header of a BMP image.
+type header struct {
+	offset  int
+	width   int
+	height  int
+	topDown bool
+	bpp     int
+	palette color.Palette
+}
+
+// Decode reads a BMP image from r. Uncompressed images with 1, 4, 8, 24, or
+// 32 bits per pixel are supported.
+func Decode(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	h, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	stride := (h.width*h.bpp + 31) / 32 * 4
+	if h.offset > len(data) || len(data)-h.offset < stride*h.height {
+		return nil, errors.New("bmp: truncated pixel data")
+	}
+	rect := image.Rect(0, 0, h.width, h.height)
+	var img interface {
+		image.Image
+		Set(x, y int, c color.Color)
+	}
+	var paletted *image.Paletted
+	if h.palette != nil {
+		paletted = image.NewPaletted(rect, h.palette)
+		img = paletted
+	} else {
+		img = image.NewRGBA(rect)
+	}
+	for row := 0; row < h.height; row++ {
+		y := h.height - 1 - row
+		if h.topDown {
+			y = row
+		}
+		line := data[h.offset+row*stride:]
+		for x := 0; x < h.width; x++ {
+			switch h.bpp {
+			case 1, 4, 8:
+				bit := x * h.bpp
+				index := line[bit/8] >> (8 - h.bpp - bit%8) & (1<<h.bpp - 1)
+				if int(index) >= len(h.palette) {
+					return nil, fmt.Errorf("bmp: palette index %d out of range", index)
+				}
+				paletted.SetColorIndex(x, y, index)
+			case 24:
+				p := line[3*x:]
+				img.Set(x, y, color.RGBA{p[2], p[1], p[0], 0xff})
+			case 32:
+				p := line[4*x:]
+				img.Set(x, y, color.RGBA{p[2], p[1], p[0], 0xff})
+			}
+		}
+	}
+	return img, nil
+}
+
+// DecodeConfig returns the color model and dimensions of a BMP image without
+// decoding the entire image.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	// The palette follows the headers and has at most 256 entries.
+	data := make([]byte, fileHeaderSize+124+256*4)
+	n, err := io.ReadFull(r, data)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return image.Config{}, err
+	}
+	h, err := parseHeader(data[:n])
+	if err != nil {
+		return image.Config{}, err
+	}
+	var model color.Model = color.RGBAModel
+	if h.palette != nil {
+		model = h.palette
+	}
+	return image.Config{ColorModel: model, Width: h.width, Height: h.height}, nil
+}
+
+func parseHeader(data []byte) (header, error) {
+	var h header
+	if len(data) < fileHeaderSize+40 || string(data[:2]) != "BM" {
+		return h, errors.New("bmp: invalid header")
+	}
+	le := binary.LittleEndian
+	h.offset = int(le.Uint32(data[10:]))
+	info := data[fileHeaderSize:]
+	infoSize := int(le.Uint32(info[0:]))
+	if infoSize < 40 {
+		return h, fmt.Errorf("bmp: unsupported header size %d", infoSize)
+	}
+	h.width = int(int32(le.Uint32(info[4:])))
+	h.height = int(int32(le.Uint32(info[8:])))
+	if h.height < 0 {
+		h.height = -h.height
+		h.topDown = true
+	}
+	if h.width <= 0 || h.height == 0 {
+		return h, fmt.Errorf("bmp: invalid size %dx%d", h.width, h.height)
+	}
+	h.bpp = int(le.Uint16(info[14:]))
+	if compression := le.Uint32(info[16:]); compression != 0 {
+		return h, fmt.Errorf("bmp: unsupported compression %d", compression)
+	}
+	switch h.bpp {
+	case 1, 4, 8:
+		colors := int(le.Uint32(info[32:]))
+		if colors == 0 || colors > 1<<h.bpp {
+			colors = 1 << h.bpp
+		}
+		start := fileHeaderSize + infoSize
+		if len(data) < start+4*colors {
+			return h, errors.New("bmp: truncated palette")
+		}
+		h.palette = make(color.Palette, colors)
+		for i := range h.palette {
+			p := data[start+4*i:]
+			h.palette[i] = color.RGBA{p[2], p[1], p[0], 0xff}
+		}
+	case 24, 32:
+	default:
+		return h, fmt.Errorf("bmp: unsupported bits per pixel %d", h.bpp)
+	}
+	return h, nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package bmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// encode returns a BMP image with the given bits per pixel, palette, and
+// rows of pixel data, which are stored bottom-up unless topDown is set.
+func encode(width, height, bpp int, palette []color.RGBA, rows [][]byte, topDown bool) []byte {
+	le := binary.LittleEndian
+	offset := fileHeaderSize + 40 + 4*len(palette)
+	b := []byte("BM")
+	b = le.AppendUint32(b, 0)
+	b = le.AppendUint32(b, 0)
+	b = le.AppendUint32(b, uint32(offset))
+	b = le.AppendUint32(b, 40)
+	b = le.AppendUint32(b, uint32(width))
+	if topDown {
+		height = -height
+	}
+	b = le.AppendUint32(b, uint32(int32(height)))
+	b = le.AppendUint16(b, 1)
+	b = le.AppendUint16(b, uint16(bpp))
+	b = append(b, make([]byte, 16)...)
+	b = le.AppendUint32(b, uint32(len(palette)))
+	b = le.AppendUint32(b, 0)
+	for _, c := range palette {
+		b = append(b, c.B, c.G, c.R, 0)
+	}
+	stride := (width*bpp + 31) / 32 * 4
+	for _, row := range rows {
+		b = append(b, row...)
+		b = append(b, make([]byte, stride-len(row))...)
+	}
+	le.PutUint32(b[2:], uint32(len(b)))
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	var tests = []struct {
+		name string
+		data []byte
+		want [2][3]color.RGBA
+	}{
+		{
+			"24 bit bottom-up",
+			encode(3, 2, 24, nil, [][]byte{
+				{0, 0, 0xff, 0xff, 0, 0, 0xff, 0xff, 0xff},
+				{0xff, 0xff, 0xff, 0, 0, 0xff, 0xff, 0, 0},
+			}, false),
+			[2][3]color.RGBA{{white, red, blue}, {red, blue, white}},
+		},
+		{
+			"32 bit top-down",
+			encode(3, 2, 32, nil, [][]byte{
+				{0, 0, 0xff, 0, 0xff, 0, 0, 0, 0xff, 0xff, 0xff, 0},
+				{0xff, 0xff, 0xff, 0, 0, 0, 0xff, 0, 0xff, 0, 0, 0},
+			}, true),
+			[2][3]color.RGBA{{red, blue, white}, {white, red, blue}},
+		},
+		{
+			"8 bit paletted",
+			encode(3, 2, 8, []color.RGBA{red, blue, white}, [][]byte{{2, 2, 0}, {0, 1, 2}}, false),
+			[2][3]color.RGBA{{red, blue, white}, {white, white, red}},
+		},
+		{
+			"4 bit paletted",
+			encode(3, 2, 4, []color.RGBA{red, blue, white}, [][]byte{{0x21, 0x00}, {0x01, 0x20}}, false),
+			[2][3]color.RGBA{{red, blue, white}, {white, blue, red}},
+		},
+		{
+			"1 bit paletted",
+			encode(3, 2, 1, []color.RGBA{red, white}, [][]byte{{0xa0}, {0x40}}, true),
+			[2][3]color.RGBA{{white, red, white}, {red, white, red}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, format, err := image.Decode(bytes.NewReader(test.data))
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "format", format, "bmp")
+			assert(t, "bounds", img.Bounds(), image.Rect(0, 0, 3, 2))
+			for y, row := range test.want {
+				for x, want := range row {
+					assert(t, "pixel", color.RGBAModel.Convert(img.At(x, y)), color.Color(want))
+				}
+			}
+			config, err := DecodeConfig(bytes.NewReader(test.data))
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "width", config.Width, 3)
+			assert(t, "height", config.Height, 2)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	valid := encode(2, 2, 24, nil, [][]byte{{1, 2, 3, 4, 5, 6}, {1, 2, 3, 4, 5, 6}}, false)
+	compressed := append([]byte(nil), valid...)
+	compressed[fileHeaderSize+16] = 1
+	badPalette := encode(2, 1, 8, []color.RGBA{{}}, [][]byte{{0, 5}}, false)
+	var tests = []struct {
+		name string
+		data []byte
+	}{
+		{"not bmp", []byte("GIF89a")},
+		{"truncated header", valid[:20]},
+		{"truncated pixels", valid[:len(valid)-1]},
+		{"compressed", compressed},
+		{"palette index", badPalette},
+	}
+	for _, test := range tests {
+		if _, err := Decode(bytes.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package csvrow
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	var tests = []struct {
+		row  string
+		want []string
+	}{
+		{"", []string{""}},
+		{"1", []string{"1"}},
+		{" 1.5e9 , -80.25,-78 ", []string{"1.5e9", "-80.25", "-78"}},
+		{"1,2,", []string{"1", "2", ""}},
+		{",", []string{"", ""}},
+	}
+	var s Splitter
+	for _, test := range tests {
+		fields := s.Split([]byte(test.row))
+		if len(fields) != len(test.want) {
+			t.Errorf("got %d fields for %q, want %d", len(fields), test.row, len(test.want))
+			continue
+		}
+		for i, want := range test.want {
+			assert(t, "field", string(fields[i]), want)
+		}
+	}
+}
+
+func TestColumns(t *testing.T) {
+	var tests = []struct {
+		line string
+		want []string
+	}{
+		{`"Center Freq", 1.0E9 ,"Hz",`, []string{"Center Freq", "1.0E9", "Hz"}},
+		{"1,,", []string{"1"}},
+		{",", []string{""}},
+		{"", []string{""}},
+	}
+	var s Splitter
+	for _, test := range tests {
+		columns := Columns(test.line)
+		rowColumns := s.Columns([]byte(test.line))
+		if len(columns) != len(test.want) || len(rowColumns) != len(test.want) {
+			t.Errorf("got %d and %d columns for %q, want %d", len(columns), len(rowColumns), test.line, len(test.want))
+			continue
+		}
+		for i, want := range test.want {
+			assert(t, "column", columns[i], want)
+			assert(t, "row column", string(rowColumns[i]), want)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	fields, err := Fields(` 1 ,"2,5 V", DC ,`)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num fields", len(fields), 4)
+	assert(t, "quoted", fields[1], "2,5 V")
+	assert(t, "trimmed", fields[2], "DC")
+	assert(t, "empty", fields[3], "")
+	if _, err := Fields(`1,"2`); err == nil {
+		t.Errorf("expected error for unterminated quote")
+	}
+}
+
+func TestFirst(t *testing.T) {
+	assert(t, "with comma", string(First([]byte(" Marker ,Frequency"))), "Marker")
+	assert(t, "without comma", string(First([]byte(" Span: "))), "Span:")
+}
+
+func TestParseFloat(t *testing.T) {
+	v, err := ParseFloat([]byte("-80.25e-3"))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "value", v, -80.25e-3)
+	if _, err := ParseFloat([]byte("abc")); err == nil {
+		t.Errorf("expected error parsing abc")
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	assert(t, "points", Capacity(8192), 8192)
+	assert(t, "max", Capacity(MaxPreallocated), MaxPreallocated)
+	assert(t, "too large", Capacity(MaxPreallocated+1), 0)
+	assert(t, "negative", Capacity(-1), 0)
+}
+
+func TestNoAllocations(t *testing.T) {
+	row := []byte("1000000000, -70.0000, -68.5000")
+	var s Splitter
+	s.Split(row)
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, field := range s.Split(row) {
+			if _, err := ParseFloat(field); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	assert(t, "allocations", allocs, 0.0)
+}
+
+func BenchmarkSplitParse(b *testing.B) {
+	row := []byte("1000000000,-70.0000,-68.5000")
+	var s Splitter
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, field := range s.Split(row) {
+			if _, err := ParseFloat(field); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
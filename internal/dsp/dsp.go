@@ -0,0 +1,63 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package dsp contains the signal processing shared by the packages that
+// compute spectra from sampled data, such as I/Q captures and oscilloscope
+// waveforms.
+package dsp
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// FFT computes the discrete Fourier transform of x in place using the
+// radix-2 algorithm. The length of x must be a power of two.
+func FFT(x []complex128) {
+	n := len(x)
+	shift := 64 - bits.Len(uint(n-1))
+	for i := range x {
+		if j := int(bits.Reverse64(uint64(i)) >> shift); i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size *= 2 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], w*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
+
+// CosineWindow returns the n point periodic cosine-sum window with the
+// coefficients a, whose terms alternate in sign.
+func CosineWindow(a []float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		sign := 1.0
+		for k, c := range a {
+			values[i] += sign * c * math.Cos(2*math.Pi*float64(k*i)/float64(n))
+			sign = -sign
+		}
+	}
+	return values
+}
+
+// NoiseBandwidth returns the equivalent noise bandwidth of the window in
+// FFT bins.
+func NoiseBandwidth(window []float64) float64 {
+	var sum, sumSquares float64
+	for _, v := range window {
+		sum += v
+		sumSquares += v * v
+	}
+	return float64(len(window)) * sumSquares / (sum * sum)
+}
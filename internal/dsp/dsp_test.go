@@ -0,0 +1,37 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	x := []complex128{1, 2 - 1i, -1i, -1 + 2i, 0.5, 3, -2, 1i}
+	want := make([]complex128, len(x))
+	for k := range want {
+		for j, v := range x {
+			want[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/float64(len(x))))
+		}
+	}
+	FFT(x)
+	for k := range x {
+		assertFloat64(t, "fft", cmplx.Abs(x[k]-want[k]), 0, 1e-12)
+	}
+}
+
+func TestNoiseBandwidth(t *testing.T) {
+	assertFloat64(t, "rectangular", NoiseBandwidth(CosineWindow([]float64{1}, 64)), 1, 1e-12)
+	assertFloat64(t, "hann", NoiseBandwidth(CosineWindow([]float64{0.5, 0.5}, 64)), 1.5, 1e-12)
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
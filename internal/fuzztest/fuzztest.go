@@ -0,0 +1,32 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package fuzztest contains helpers shared by the fuzz tests of the file
+// parsers.
+package fuzztest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// AddSeeds adds the files matching the patterns to the seed corpus.
+func AddSeeds(f *testing.F, patterns ...string) {
+	f.Helper()
+	for _, pattern := range patterns {
+		filenames, err := filepath.Glob(pattern)
+		if err != nil {
+			f.Fatal(err)
+		}
+		for _, filename := range filenames {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				f.Fatal(err)
+			}
+			f.Add(data)
+		}
+	}
+}
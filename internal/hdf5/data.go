@@ -0,0 +1,370 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package hdf5
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// Data layout classes.
+const (
+	layoutCompact    = 0
+	layoutContiguous = 1
+	layoutChunked    = 2
+)
+
+// Filter identifiers.
+const (
+	filterDeflate    = 1
+	filterShuffle    = 2
+	filterFletcher32 = 3
+)
+
+type layout struct {
+	class     uint8
+	addr      uint64
+	size      uint64
+	compact   []byte
+	chunkDims []uint64
+	btreeAddr uint64
+}
+
+// Dims returns the dimensions of the dataset. Scalar datasets return nil.
+func (obj *Object) Dims() ([]uint64, error) {
+	msg, ok := obj.message(msgDataspace)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a dataset", obj.name)
+	}
+	dims, _, err := obj.f.parseDataspace(msg.data)
+	return dims, err
+}
+
+// Float64s reads all elements of a numeric dataset and converts them to
+// float64 values.
+func (obj *Object) Float64s() ([]float64, error) {
+	dtMsg, ok := obj.message(msgDatatype)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a dataset", obj.name)
+	}
+	dt, err := parseDatatype(dtMsg.data)
+	if err != nil {
+		return nil, err
+	}
+	if !dt.isNumeric() {
+		return nil, fmt.Errorf("%s is not a numeric dataset", obj.name)
+	}
+	raw, err := obj.rawData(dt.size)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", obj.name, err)
+	}
+	values := make([]float64, len(raw)/dt.size)
+	for i := range values {
+		values[i] = dt.float64(raw[i*dt.size : (i+1)*dt.size])
+	}
+	return values, nil
+}
+
+// rawData returns the raw bytes of every element in the dataset in row-major
+// order.
+func (obj *Object) rawData(elemSize int) ([]byte, error) {
+	f := obj.f
+	dsMsg, ok := obj.message(msgDataspace)
+	if !ok {
+		return nil, fmt.Errorf("missing dataspace")
+	}
+	dims, _, err := f.parseDataspace(dsMsg.data)
+	if err != nil {
+		return nil, err
+	}
+	n, err := numElements(dims)
+	if err != nil {
+		return nil, err
+	}
+	// The elements are usually converted to float64 values, so also bound
+	// the size of the converted values.
+	if n > maxAllocation/max(elemSize, 8) {
+		return nil, fmt.Errorf("dataset is too large")
+	}
+	size := n * elemSize
+	layoutMsg, _ := obj.message(msgDataLayout)
+	lay, err := f.parseLayout(layoutMsg.data)
+	if err != nil {
+		return nil, err
+	}
+	switch lay.class {
+	case layoutCompact:
+		if len(lay.compact) < size {
+			return nil, fmt.Errorf("compact data is too short")
+		}
+		return lay.compact[:size], nil
+	case layoutContiguous:
+		if f.undefined(lay.addr) {
+			// Storage hasn't been allocated, so the data is the fill value.
+			return make([]byte, size), nil
+		}
+		return f.readAt(lay.addr, size)
+	case layoutChunked:
+		filters, err := obj.filters()
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, size)
+		if f.undefined(lay.btreeAddr) {
+			return out, nil
+		}
+		chunk := chunkReader{
+			f:        f,
+			dims:     dims,
+			chunk:    lay.chunkDims[:len(lay.chunkDims)-1],
+			elemSize: elemSize,
+			filters:  filters,
+			out:      out,
+		}
+		if len(chunk.chunk) != len(dims) {
+			return nil, fmt.Errorf("chunk rank %d doesn't match dataset rank %d", len(chunk.chunk), len(dims))
+		}
+		if err := chunk.readBTree(lay.btreeAddr, 0); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: layout class %d", ErrUnsupported, lay.class)
+}
+
+func (f *File) parseLayout(b []byte) (layout, error) {
+	c := f.newCursor(b)
+	lay := layout{}
+	version := c.u8()
+	switch version {
+	case 1, 2:
+		rank := int(c.u8())
+		lay.class = c.u8()
+		c.skip(5)
+		if lay.class != layoutCompact {
+			lay.addr = c.offset()
+			lay.btreeAddr = lay.addr
+		}
+		// As with version 3, the chunk dimensions include the element size.
+		for i := 0; i < rank; i++ {
+			lay.chunkDims = append(lay.chunkDims, uint64(c.u32()))
+		}
+		if lay.class == layoutChunked {
+			c.skip(4) // Dataset element size
+		}
+		if lay.class == layoutCompact {
+			lay.compact = c.bytes(int(c.u32()))
+		}
+	case 3, 4:
+		lay.class = c.u8()
+		switch lay.class {
+		case layoutCompact:
+			lay.compact = c.bytes(int(c.u16()))
+		case layoutContiguous:
+			lay.addr = c.offset()
+			lay.size = c.length()
+		case layoutChunked:
+			if version == 4 {
+				return lay, fmt.Errorf("%w: version 4 chunked layout", ErrUnsupported)
+			}
+			rank := int(c.u8())
+			lay.btreeAddr = c.offset()
+			for i := 0; i < rank; i++ {
+				lay.chunkDims = append(lay.chunkDims, uint64(c.u32()))
+			}
+		default:
+			return lay, fmt.Errorf("%w: layout class %d", ErrUnsupported, lay.class)
+		}
+	default:
+		return lay, fmt.Errorf("%w: layout version %d", ErrUnsupported, version)
+	}
+	if lay.class == layoutChunked && len(lay.chunkDims) < 2 {
+		return lay, fmt.Errorf("invalid chunk dimensions")
+	}
+	return lay, c.err
+}
+
+// filters returns the filter identifiers of the filter pipeline in the order
+// they were applied when writing.
+func (obj *Object) filters() ([]uint16, error) {
+	msg, ok := obj.message(msgFilterPipe)
+	if !ok {
+		return nil, nil
+	}
+	c := obj.f.newCursor(msg.data)
+	version := c.u8()
+	n := int(c.u8())
+	if version == 1 {
+		c.skip(6)
+	}
+	var ids []uint16
+	for i := 0; i < n; i++ {
+		id := c.u16()
+		nameLen := 0
+		if version == 1 || id >= 256 {
+			nameLen = int(c.u16())
+		}
+		c.skip(2) // Flags
+		numValues := int(c.u16())
+		if version == 1 {
+			c.skip(pad8(nameLen))
+		} else {
+			c.skip(nameLen)
+		}
+		c.skip(4 * numValues)
+		if version == 1 && numValues%2 != 0 {
+			c.skip(4)
+		}
+		switch id {
+		case filterDeflate, filterShuffle, filterFletcher32:
+		default:
+			return nil, fmt.Errorf("%w: filter %d", ErrUnsupported, id)
+		}
+		ids = append(ids, id)
+	}
+	return ids, c.err
+}
+
+// chunkReader reads the chunks of a chunked dataset into the output buffer.
+type chunkReader struct {
+	f        *File
+	dims     []uint64
+	chunk    []uint64
+	elemSize int
+	filters  []uint16
+	out      []byte
+}
+
+func (cr *chunkReader) readBTree(addr uint64, depth int) error {
+	f := cr.f
+	if depth > 64 {
+		return fmt.Errorf("chunk B-tree is too deep")
+	}
+	head, err := f.readAt(addr, 8+2*f.offsetSize)
+	if err != nil {
+		return fmt.Errorf("error reading chunk B-tree: %s", err)
+	}
+	if string(head[:4]) != "TREE" || head[4] != 1 {
+		return fmt.Errorf("invalid chunk B-tree node")
+	}
+	level := head[5]
+	entries := int(f.newCursor(head[6:8]).u16())
+	keySize := 8 + 8*(len(cr.chunk)+1)
+	b, err := f.readAt(addr+uint64(len(head)), (entries+1)*keySize+entries*f.offsetSize)
+	if err != nil {
+		return fmt.Errorf("error reading chunk B-tree: %s", err)
+	}
+	c := f.newCursor(b)
+	for i := 0; i < entries; i++ {
+		size := int(c.u32())
+		mask := c.u32()
+		offsets := make([]uint64, len(cr.chunk))
+		for j := range offsets {
+			offsets[j] = c.u64()
+		}
+		c.skip(8) // Element offset, which is always zero
+		child := c.offset()
+		if c.err != nil {
+			return c.err
+		}
+		if level > 0 {
+			err = cr.readBTree(child, depth+1)
+		} else {
+			err = cr.readChunk(child, size, mask, offsets)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cr *chunkReader) readChunk(addr uint64, size int, mask uint32, offsets []uint64) error {
+	data, err := cr.f.readAt(addr, size)
+	if err != nil {
+		return fmt.Errorf("error reading chunk: %s", err)
+	}
+	chunkElems, err := numElements(cr.chunk)
+	if err != nil {
+		return err
+	}
+	chunkSize := chunkElems * cr.elemSize
+	for i := len(cr.filters) - 1; i >= 0; i-- {
+		if mask&(1<<uint(i)) != 0 {
+			continue
+		}
+		switch cr.filters[i] {
+		case filterDeflate:
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return fmt.Errorf("error decompressing chunk: %s", err)
+			}
+			inflated, err := io.ReadAll(io.LimitReader(zr, int64(chunkSize)+1))
+			if err != nil {
+				return fmt.Errorf("error decompressing chunk: %s", err)
+			}
+			data = inflated
+		case filterShuffle:
+			data = unshuffle(data, cr.elemSize)
+		case filterFletcher32:
+			if len(data) < 4 {
+				return fmt.Errorf("chunk is too short for checksum")
+			}
+			data = data[:len(data)-4]
+		}
+	}
+	if len(data) < chunkSize {
+		return fmt.Errorf("chunk size %d is smaller than expected %d", len(data), chunkSize)
+	}
+	cr.copyChunk(data, offsets, 0, 0, 0)
+	return nil
+}
+
+// copyChunk copies the chunk elements that are within the dataset bounds into
+// the output buffer, recursing over the dimensions. The last dimension is
+// copied as a contiguous run.
+func (cr *chunkReader) copyChunk(data []byte, offsets []uint64, dim int, src, dst uint64) {
+	if dim == len(cr.dims) {
+		return
+	}
+	start := offsets[dim]
+	if start >= cr.dims[dim] {
+		return
+	}
+	count := min(cr.chunk[dim], cr.dims[dim]-start)
+	srcStride := uint64(cr.elemSize)
+	for d := dim + 1; d < len(cr.dims); d++ {
+		srcStride *= cr.chunk[d]
+	}
+	dst = dst*cr.dims[dim] + start
+	if dim == len(cr.dims)-1 {
+		n := count * uint64(cr.elemSize)
+		copy(cr.out[dst*uint64(cr.elemSize):], data[src:src+n])
+		return
+	}
+	for i := uint64(0); i < count; i++ {
+		cr.copyChunk(data, offsets, dim+1, src+i*srcStride, dst+i)
+	}
+}
+
+// unshuffle reverses the shuffle filter, which groups the bytes of each
+// element by significance.
+func unshuffle(data []byte, elemSize int) []byte {
+	if elemSize <= 1 {
+		return data
+	}
+	n := len(data) / elemSize
+	out := make([]byte, len(data))
+	for b := 0; b < elemSize; b++ {
+		for i := 0; i < n; i++ {
+			out[i*elemSize+b] = data[b*n+i]
+		}
+	}
+	copy(out[n*elemSize:], data[n*elemSize:])
+	return out
+}
@@ -0,0 +1,365 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package hdf5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Datatype classes.
+const (
+	classFixedPoint    = 0
+	classFloatingPoint = 1
+	classString        = 3
+	classVariableLen   = 9
+)
+
+type datatype struct {
+	class      uint8
+	size       int
+	order      binary.ByteOrder
+	signed     bool
+	vlenString bool
+}
+
+func parseDatatype(b []byte) (datatype, error) {
+	if len(b) < 8 {
+		return datatype{}, fmt.Errorf("datatype message is too short")
+	}
+	dt := datatype{
+		class: b[0] & 0x0F,
+		size:  int(binary.LittleEndian.Uint32(b[4:8])),
+		order: binary.LittleEndian,
+	}
+	bits := b[1]
+	switch dt.class {
+	case classFixedPoint:
+		if bits&0x01 != 0 {
+			dt.order = binary.BigEndian
+		}
+		dt.signed = bits&0x08 != 0
+		switch dt.size {
+		case 1, 2, 4, 8:
+		default:
+			return dt, fmt.Errorf("%w: %d byte integer", ErrUnsupported, dt.size)
+		}
+	case classFloatingPoint:
+		if bits&0x01 != 0 {
+			dt.order = binary.BigEndian
+		}
+		if dt.size != 4 && dt.size != 8 {
+			return dt, fmt.Errorf("%w: %d byte float", ErrUnsupported, dt.size)
+		}
+	case classString:
+	case classVariableLen:
+		if bits&0x0F != 1 {
+			return dt, fmt.Errorf("%w: variable-length sequence", ErrUnsupported)
+		}
+		dt.vlenString = true
+	default:
+		return dt, fmt.Errorf("%w: datatype class %d", ErrUnsupported, dt.class)
+	}
+	return dt, nil
+}
+
+func (dt datatype) isNumeric() bool {
+	return dt.class == classFixedPoint || dt.class == classFloatingPoint
+}
+
+// float64 decodes a numeric element.
+func (dt datatype) float64(b []byte) float64 {
+	switch dt.class {
+	case classFloatingPoint:
+		if dt.size == 4 {
+			return float64(math.Float32frombits(dt.order.Uint32(b)))
+		}
+		return math.Float64frombits(dt.order.Uint64(b))
+	case classFixedPoint:
+		if dt.signed {
+			return float64(dt.int64(b))
+		}
+		return float64(dt.uint64(b))
+	}
+	return math.NaN()
+}
+
+func (dt datatype) uint64(b []byte) uint64 {
+	switch dt.size {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(dt.order.Uint16(b))
+	case 4:
+		return uint64(dt.order.Uint32(b))
+	}
+	return dt.order.Uint64(b)
+}
+
+func (dt datatype) int64(b []byte) int64 {
+	switch dt.size {
+	case 1:
+		return int64(int8(b[0]))
+	case 2:
+		return int64(int16(dt.order.Uint16(b)))
+	case 4:
+		return int64(int32(dt.order.Uint32(b)))
+	}
+	return int64(dt.order.Uint64(b))
+}
+
+// value decodes a single element into an int64, uint64, float64, or string.
+func (f *File) value(dt datatype, b []byte) (interface{}, error) {
+	switch dt.class {
+	case classFixedPoint:
+		if dt.signed {
+			return dt.int64(b), nil
+		}
+		return dt.uint64(b), nil
+	case classFloatingPoint:
+		return dt.float64(b), nil
+	case classString:
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+		return strings.TrimRight(string(b), " "), nil
+	case classVariableLen:
+		c := f.newCursor(b)
+		length := c.u32()
+		heap := c.offset()
+		index := c.u32()
+		if c.err != nil {
+			return nil, c.err
+		}
+		if length == 0 {
+			return "", nil
+		}
+		data, err := f.globalHeapObject(heap, index)
+		if err != nil {
+			return nil, err
+		}
+		if int(length) < len(data) {
+			data = data[:length]
+		}
+		return string(data), nil
+	}
+	return nil, fmt.Errorf("%w: datatype class %d", ErrUnsupported, dt.class)
+}
+
+// parseDataspace returns the dimensions of the dataspace. A scalar dataspace
+// returns nil dimensions and scalar as true.
+func (f *File) parseDataspace(b []byte) (dims []uint64, scalar bool, err error) {
+	c := f.newCursor(b)
+	version := c.u8()
+	rank := int(c.u8())
+	c.skip(1) // Flags
+	switch version {
+	case 1:
+		c.skip(5)
+		if rank == 0 {
+			scalar = true
+		}
+	case 2:
+		switch c.u8() {
+		case 0:
+			scalar = true
+		case 2:
+			return []uint64{0}, false, c.err
+		}
+	default:
+		return nil, false, fmt.Errorf("%w: dataspace version %d", ErrUnsupported, version)
+	}
+	for i := 0; i < rank; i++ {
+		dims = append(dims, c.length())
+	}
+	return dims, scalar, c.err
+}
+
+func numElements(dims []uint64) (int, error) {
+	n := uint64(1)
+	for _, d := range dims {
+		if d != 0 && n > maxAllocation/d {
+			return 0, fmt.Errorf("dataspace is too large")
+		}
+		n *= d
+	}
+	return int(n), nil
+}
+
+type globalHeapObject struct {
+	index uint16
+	data  []byte
+}
+
+// globalHeapObject returns the data for the object with the given index in
+// the global heap collection at the given address.
+func (f *File) globalHeapObject(addr uint64, index uint32) ([]byte, error) {
+	objects, ok := f.heaps[addr]
+	if !ok {
+		head, err := f.readAt(addr, 8+f.lengthSize)
+		if err != nil {
+			return nil, fmt.Errorf("error reading global heap: %s", err)
+		}
+		if string(head[:4]) != "GCOL" {
+			return nil, fmt.Errorf("invalid global heap signature")
+		}
+		size := int(f.newCursor(head[8:]).length())
+		b, err := f.readAt(addr, size)
+		if err != nil {
+			return nil, fmt.Errorf("error reading global heap: %s", err)
+		}
+		c := f.newCursor(b)
+		c.skip(len(head))
+		for c.remaining() >= 8+f.lengthSize {
+			idx := c.u16()
+			c.skip(6) // Reference count and reserved
+			n := int(c.length())
+			if idx == 0 {
+				break
+			}
+			data := c.bytes(n)
+			c.align(8)
+			if c.err != nil {
+				return nil, fmt.Errorf("error reading global heap: %s", c.err)
+			}
+			objects = append(objects, globalHeapObject{idx, data})
+		}
+		f.heaps[addr] = objects
+	}
+	for _, obj := range objects {
+		if uint32(obj.index) == index {
+			return obj.data, nil
+		}
+	}
+	return nil, fmt.Errorf("global heap object %d not found", index)
+}
+
+// Attributes returns the attributes of the object keyed by name. Scalar
+// attributes are returned as an int64, uint64, float64, or string, and array
+// attributes as a slice of one of those types.
+func (obj *Object) Attributes() (map[string]interface{}, error) {
+	attrs := make(map[string]interface{})
+	for _, msg := range obj.messages {
+		switch msg.typ {
+		case msgAttributeInfo:
+			c := obj.f.newCursor(msg.data)
+			c.skip(1) // Version
+			if flags := c.u8(); flags&0x01 != 0 {
+				c.skip(2) // Maximum creation index
+			}
+			if heap := c.offset(); c.err == nil && !obj.f.undefined(heap) {
+				return nil, fmt.Errorf("%w: dense attribute storage", ErrUnsupported)
+			}
+		case msgAttribute:
+			name, value, err := obj.f.parseAttribute(msg.data)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing attribute of %s: %s", obj.name, err)
+			}
+			attrs[name] = value
+		}
+	}
+	return attrs, nil
+}
+
+func (f *File) parseAttribute(b []byte) (string, interface{}, error) {
+	c := f.newCursor(b)
+	version := c.u8()
+	flags := c.u8()
+	nameSize := int(c.u16())
+	dtSize := int(c.u16())
+	dsSize := int(c.u16())
+	if flags&0x03 != 0 {
+		return "", nil, fmt.Errorf("%w: shared attribute datatype or dataspace", ErrUnsupported)
+	}
+	var name, dtb, dsb []byte
+	switch version {
+	case 1:
+		name = c.bytes(pad8(nameSize))
+		dtb = c.bytes(pad8(dtSize))
+		dsb = c.bytes(pad8(dsSize))
+	case 2, 3:
+		if version == 3 {
+			c.skip(1) // Name character set encoding
+		}
+		name = c.bytes(nameSize)
+		dtb = c.bytes(dtSize)
+		dsb = c.bytes(dsSize)
+	default:
+		return "", nil, fmt.Errorf("%w: attribute version %d", ErrUnsupported, version)
+	}
+	if c.err != nil {
+		return "", nil, c.err
+	}
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	dt, err := parseDatatype(dtb)
+	if err != nil {
+		return string(name), nil, err
+	}
+	dims, scalar, err := f.parseDataspace(dsb)
+	if err != nil {
+		return string(name), nil, err
+	}
+	n := 1
+	if !scalar {
+		if n, err = numElements(dims); err != nil {
+			return string(name), nil, err
+		}
+	}
+	data := c.bytes(n * dt.size)
+	if c.err != nil {
+		return string(name), nil, c.err
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		if values[i], err = f.value(dt, data[i*dt.size:(i+1)*dt.size]); err != nil {
+			return string(name), nil, err
+		}
+	}
+	if scalar {
+		return string(name), values[0], nil
+	}
+	return string(name), typedSlice(values), nil
+}
+
+// typedSlice converts a slice of values of the same type into a slice of that
+// type.
+func typedSlice(values []interface{}) interface{} {
+	if len(values) == 0 {
+		return values
+	}
+	switch values[0].(type) {
+	case int64:
+		s := make([]int64, len(values))
+		for i, v := range values {
+			s[i] = v.(int64)
+		}
+		return s
+	case uint64:
+		s := make([]uint64, len(values))
+		for i, v := range values {
+			s[i] = v.(uint64)
+		}
+		return s
+	case float64:
+		s := make([]float64, len(values))
+		for i, v := range values {
+			s[i] = v.(float64)
+		}
+		return s
+	case string:
+		s := make([]string, len(values))
+		for i, v := range values {
+			s[i] = v.(string)
+		}
+		return s
+	}
+	return values
+}
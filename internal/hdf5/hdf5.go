@@ -0,0 +1,262 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package hdf5 is a minimal, pure Go reader and writer for the subset of the
+// HDF5 file format used by instrument waveform files.
+//
+// The reader supports version 0 through 3 superblocks, version 1 and 2 object
+// headers, groups stored using symbol tables or compact link messages, and
+// datasets with compact, contiguous, or chunked (version 1 B-tree) layouts
+// compressed using the deflate and shuffle filters. Numeric, fixed-length
+// string, and variable-length string datatypes are supported. Dense link and
+// attribute storage, which use fractal heaps, aren't supported.
+//
+// The writer creates files containing groups, one-dimensional float64
+// datasets, and scalar string, float64, and int64 attributes.
+package hdf5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var signature = []byte("\x89HDF\r\n\x1a\n")
+
+// ErrUnsupported is returned when the file uses a feature of the HDF5 format
+// that isn't supported by this package.
+var ErrUnsupported = errors.New("unsupported HDF5 feature")
+
+// File is an open HDF5 file.
+type File struct {
+	r          io.ReaderAt
+	offsetSize int
+	lengthSize int
+	baseAddr   uint64
+	rootAddr   uint64
+	heaps      map[uint64][]globalHeapObject
+}
+
+// Open reads the HDF5 superblock from the given io.ReaderAt. The superblock
+// may be located at offset 0, 512, 1024, 2048, and so on.
+func Open(r io.ReaderAt) (*File, error) {
+	f := &File{r: r, heaps: make(map[uint64][]globalHeapObject)}
+	sig := make([]byte, len(signature))
+	for offset := int64(0); ; offset = max(512, offset*2) {
+		if _, err := r.ReadAt(sig, offset); err != nil {
+			return nil, fmt.Errorf("HDF5 signature not found")
+		}
+		if bytes.Equal(sig, signature) {
+			return f, f.readSuperblock(uint64(offset))
+		}
+	}
+}
+
+func (f *File) readSuperblock(addr uint64) error {
+	head, err := f.readAt(addr+8, 16)
+	if err != nil {
+		return fmt.Errorf("error reading superblock: %s", err)
+	}
+	version := head[0]
+	switch version {
+	case 0, 1:
+		f.offsetSize = int(head[5])
+		f.lengthSize = int(head[6])
+		if err := f.checkSizes(); err != nil {
+			return err
+		}
+		// Skip the versions, sizes, group K values, and consistency flags.
+		pos := addr + 8 + 16
+		if version == 1 {
+			pos += 4
+		}
+		b, err := f.readAt(pos, 6*f.offsetSize+24)
+		if err != nil {
+			return fmt.Errorf("error reading superblock: %s", err)
+		}
+		c := f.newCursor(b)
+		f.baseAddr = c.offset()
+		c.offset() // Free-space info address
+		c.offset() // End of file address
+		c.offset() // Driver information block address
+		// The root group symbol table entry.
+		c.offset() // Link name offset
+		f.rootAddr = c.offset()
+		return c.err
+	case 2, 3:
+		f.offsetSize = int(head[1])
+		f.lengthSize = int(head[2])
+		if err := f.checkSizes(); err != nil {
+			return err
+		}
+		b, err := f.readAt(addr+12, 4*f.offsetSize)
+		if err != nil {
+			return fmt.Errorf("error reading superblock: %s", err)
+		}
+		c := f.newCursor(b)
+		f.baseAddr = c.offset()
+		c.offset() // Superblock extension address
+		c.offset() // End of file address
+		f.rootAddr = c.offset()
+		return c.err
+	}
+	return fmt.Errorf("%w: superblock version %d", ErrUnsupported, version)
+}
+
+func (f *File) checkSizes() error {
+	for _, size := range []int{f.offsetSize, f.lengthSize} {
+		switch size {
+		case 2, 4, 8:
+		default:
+			return fmt.Errorf("invalid offset or length size: %d", size)
+		}
+	}
+	return nil
+}
+
+// Root returns the root group.
+func (f *File) Root() (*Object, error) {
+	return f.object("/", f.rootAddr)
+}
+
+// Get returns the object at the given absolute path, such as
+// "/Waveforms/Channel 1".
+func (f *File) Get(path string) (*Object, error) {
+	obj, err := f.Root()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range bytes.Split([]byte(path), []byte("/")) {
+		if len(name) == 0 {
+			continue
+		}
+		obj, err = obj.Child(string(name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// readAt reads n bytes at the given address relative to the base address.
+func (f *File) readAt(addr uint64, n int) ([]byte, error) {
+	if n < 0 || n > maxAllocation {
+		return nil, fmt.Errorf("invalid read size: %d", n)
+	}
+	// Read into a growing buffer so that a corrupt size is detected at the
+	// end of the file instead of allocating the whole size up front.
+	b := bytes.NewBuffer(make([]byte, 0, min(n, 1<<20)))
+	sr := io.NewSectionReader(f.r, int64(f.baseAddr+addr), int64(n))
+	if _, err := io.CopyN(b, sr, int64(n)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// maxAllocation limits the size of any single read so that corrupt sizes
+// don't cause huge allocations.
+const maxAllocation = 1 << 30
+
+func (f *File) undefined(addr uint64) bool {
+	return addr == ^uint64(0)>>(64-8*f.offsetSize)
+}
+
+// cursor decodes little-endian values from a byte slice. The first error
+// encountered is stored and subsequent reads return zero values.
+type cursor struct {
+	f   *File
+	b   []byte
+	pos int
+	err error
+}
+
+func (f *File) newCursor(b []byte) *cursor {
+	return &cursor{f: f, b: b}
+}
+
+func (c *cursor) bytes(n int) []byte {
+	if c.err != nil {
+		return nil
+	}
+	if n < 0 || c.pos+n > len(c.b) {
+		c.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := c.b[c.pos : c.pos+n]
+	c.pos += n
+	return b
+}
+
+func (c *cursor) skip(n int) {
+	c.bytes(n)
+}
+
+func (c *cursor) u8() uint8 {
+	b := c.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (c *cursor) u16() uint16 {
+	b := c.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func (c *cursor) u32() uint32 {
+	b := c.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (c *cursor) u64() uint64 {
+	b := c.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// uint reads an unsigned integer of the given size in bytes.
+func (c *cursor) uint(size int) uint64 {
+	b := c.bytes(size)
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+func (c *cursor) offset() uint64 {
+	return c.uint(c.f.offsetSize)
+}
+
+func (c *cursor) length() uint64 {
+	return c.uint(c.f.lengthSize)
+}
+
+func (c *cursor) remaining() int {
+	return len(c.b) - c.pos
+}
+
+// align advances the position to the next multiple of n relative to the
+// start of the slice.
+func (c *cursor) align(n int) {
+	if r := c.pos % n; r != 0 {
+		c.skip(n - r)
+	}
+}
+
+func pad8(n int) int {
+	return (n + 7) &^ 7
+}
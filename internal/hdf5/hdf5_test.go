@@ -0,0 +1,135 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package hdf5
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	file, err := os.Open("./testdata/groups_v0.h5")
+	if err != nil {
+		t.Fatalf("error opening test file: %s", err)
+	}
+	defer file.Close()
+	f, err := Open(file)
+	if err != nil {
+		t.Fatalf("received error opening HDF5 file: %s", err)
+	}
+	group, err := f.Get("/Waveforms")
+	if err != nil {
+		t.Fatalf("received error getting group: %s", err)
+	}
+	names, err := group.Children()
+	if err != nil {
+		t.Fatalf("received error listing group: %s", err)
+	}
+	if want := []string{"Channel 1", "Channel 2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("\ngot  = %v\nwant = %v", names, want)
+	}
+	ch, err := f.Get("/Waveforms/Channel 1")
+	if err != nil {
+		t.Fatalf("received error getting channel: %s", err)
+	}
+	if !ch.IsGroup() {
+		t.Errorf("expected channel to be a group")
+	}
+	attrs, err := ch.Attributes()
+	if err != nil {
+		t.Fatalf("received error reading attributes: %s", err)
+	}
+	var tests = []struct {
+		name string
+		want interface{}
+	}{
+		{"NumPoints", int64(200)},
+		{"XInc", 2e-9},
+		{"XUnits", "Second"},
+		{"YUnits", "Volt"},
+		{"SavedTime", "2023-03-16 10:42:17"},
+	}
+	for _, test := range tests {
+		if got := attrs[test.name]; got != test.want {
+			t.Errorf("\ngot  = %#v for %s\nwant = %#v", got, test.name, test.want)
+		}
+	}
+	ds, err := ch.Child("Channel 1Data")
+	if err != nil {
+		t.Fatalf("received error getting dataset: %s", err)
+	}
+	dims, err := ds.Dims()
+	if err != nil || !reflect.DeepEqual(dims, []uint64{200}) {
+		t.Errorf("\ngot  = %v (%v)\nwant = [200]", dims, err)
+	}
+	values, err := ds.Float64s()
+	if err != nil {
+		t.Fatalf("received error reading dataset: %s", err)
+	}
+	if len(values) != 200 || values[0] != 0 {
+		t.Errorf("unexpected dataset values: %v", values[:4])
+	}
+	if _, err := f.Get("/Waveforms/Channel 9"); err == nil {
+		t.Errorf("expected error getting missing object")
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	if _, err := Open(bytes.NewReader([]byte("not an HDF5 file at all"))); err == nil {
+		t.Errorf("expected error opening invalid file")
+	}
+}
+
+func TestUnshuffle(t *testing.T) {
+	shuffled := []byte{1, 3, 5, 2, 4, 6}
+	want := []byte{1, 2, 3, 4, 5, 6}
+	if got := unshuffle(shuffled, 2); !bytes.Equal(got, want) {
+		t.Errorf("\ngot  = %v\nwant = %v", got, want)
+	}
+}
+
+func FuzzOpen(f *testing.F) {
+	data, err := os.ReadFile("./testdata/groups_v0.h5")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		file, err := Open(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		root, err := file.Root()
+		if err != nil {
+			return
+		}
+		walk(root, 0)
+	})
+}
+
+// walk reads the attributes and data of the object and its descendants up
+// to a depth that stops cycles of hard links.
+func walk(obj *Object, depth int) {
+	obj.Attributes()
+	if obj.IsDataset() {
+		obj.Dims()
+		obj.Float64s()
+	}
+	if !obj.IsGroup() || depth > 4 {
+		return
+	}
+	names, err := obj.Children()
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		if child, err := obj.Child(name); err == nil {
+			walk(child, depth+1)
+		}
+	}
+}
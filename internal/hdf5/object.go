@@ -0,0 +1,412 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package hdf5
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// Header message types.
+const (
+	msgNil           = 0x0000
+	msgDataspace     = 0x0001
+	msgLinkInfo      = 0x0002
+	msgDatatype      = 0x0003
+	msgLink          = 0x0006
+	msgDataLayout    = 0x0008
+	msgFilterPipe    = 0x000B
+	msgAttribute     = 0x000C
+	msgContinuation  = 0x0010
+	msgSymbolTable   = 0x0011
+	msgAttributeInfo = 0x0015
+)
+
+// maxHeaderChunks limits the number of object header continuation chunks to
+// guard against cycles in corrupt files.
+const maxHeaderChunks = 1024
+
+type message struct {
+	typ   uint16
+	flags uint8
+	data  []byte
+}
+
+// Object is a group or dataset in an HDF5 file.
+type Object struct {
+	f        *File
+	name     string
+	addr     uint64
+	messages []message
+}
+
+func (f *File) object(name string, addr uint64) (*Object, error) {
+	obj := &Object{f: f, name: name, addr: addr}
+	sig, err := f.readAt(addr, 4)
+	if err != nil {
+		return nil, fmt.Errorf("error reading object header for %s: %s", name, err)
+	}
+	if string(sig) == "OHDR" {
+		err = obj.readHeaderV2()
+	} else {
+		err = obj.readHeaderV1()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading object header for %s: %s", name, err)
+	}
+	return obj, nil
+}
+
+func (obj *Object) readHeaderV1() error {
+	f := obj.f
+	head, err := f.readAt(obj.addr, 16)
+	if err != nil {
+		return err
+	}
+	c := f.newCursor(head)
+	if version := c.u8(); version != 1 {
+		return fmt.Errorf("%w: object header version %d", ErrUnsupported, version)
+	}
+	c.skip(3) // Reserved and number of header messages
+	c.skip(4) // Object reference count
+	size := int(c.u32())
+	type chunk struct {
+		addr uint64
+		size int
+	}
+	chunks := []chunk{{obj.addr + 16, size}}
+	for i := 0; i < len(chunks); i++ {
+		if i >= maxHeaderChunks {
+			return fmt.Errorf("too many object header chunks")
+		}
+		b, err := f.readAt(chunks[i].addr, chunks[i].size)
+		if err != nil {
+			return err
+		}
+		c := f.newCursor(b)
+		for c.remaining() >= 8 {
+			typ := c.u16()
+			n := int(c.u16())
+			flags := c.u8()
+			c.skip(3)
+			data := c.bytes(n)
+			if c.err != nil {
+				return c.err
+			}
+			if typ == msgContinuation {
+				cc := f.newCursor(data)
+				addr, length := cc.offset(), cc.length()
+				if cc.err != nil {
+					return cc.err
+				}
+				chunks = append(chunks, chunk{addr, int(length)})
+				continue
+			}
+			if typ != msgNil {
+				obj.messages = append(obj.messages, message{typ, flags, data})
+			}
+		}
+	}
+	return nil
+}
+
+func (obj *Object) readHeaderV2() error {
+	f := obj.f
+	head, err := f.readAt(obj.addr, 6)
+	if err != nil {
+		return err
+	}
+	if version := head[4]; version != 2 {
+		return fmt.Errorf("%w: object header version %d", ErrUnsupported, version)
+	}
+	flags := head[5]
+	pos := obj.addr + 6
+	if flags&0x20 != 0 {
+		pos += 16 // Access, modification, change, and birth times
+	}
+	if flags&0x10 != 0 {
+		pos += 4 // Maximum compact and minimum dense attribute counts
+	}
+	sizeBytes := 1 << (flags & 0x03)
+	b, err := f.readAt(pos, sizeBytes)
+	if err != nil {
+		return err
+	}
+	size := f.newCursor(b).uint(sizeBytes)
+	pos += uint64(sizeBytes)
+	trackOrder := flags&0x04 != 0
+
+	type chunk struct {
+		addr uint64
+		size int
+	}
+	chunks := []chunk{{pos, int(size)}}
+	for i := 0; i < len(chunks); i++ {
+		if i >= maxHeaderChunks {
+			return fmt.Errorf("too many object header chunks")
+		}
+		b, err := f.readAt(chunks[i].addr, chunks[i].size)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			// Continuation chunks start with a signature and end with a
+			// checksum.
+			if len(b) < 8 || string(b[:4]) != "OCHK" {
+				return fmt.Errorf("invalid object header continuation signature")
+			}
+			b = b[4 : len(b)-4]
+		}
+		msgHeaderSize := 4
+		if trackOrder {
+			msgHeaderSize = 6
+		}
+		c := f.newCursor(b)
+		for c.remaining() >= msgHeaderSize {
+			typ := uint16(c.u8())
+			n := int(c.u16())
+			mflags := c.u8()
+			if trackOrder {
+				c.skip(2)
+			}
+			data := c.bytes(n)
+			if c.err != nil {
+				return c.err
+			}
+			if typ == msgContinuation {
+				cc := f.newCursor(data)
+				addr, length := cc.offset(), cc.length()
+				if cc.err != nil {
+					return cc.err
+				}
+				chunks = append(chunks, chunk{addr, int(length)})
+				continue
+			}
+			if typ != msgNil {
+				obj.messages = append(obj.messages, message{typ, mflags, data})
+			}
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the object within its parent group.
+func (obj *Object) Name() string {
+	return obj.name
+}
+
+func (obj *Object) message(typ uint16) (message, bool) {
+	for _, msg := range obj.messages {
+		if msg.typ == typ {
+			return msg, true
+		}
+	}
+	return message{}, false
+}
+
+// IsDataset reports whether the object is a dataset.
+func (obj *Object) IsDataset() bool {
+	_, ok := obj.message(msgDataLayout)
+	return ok
+}
+
+// IsGroup reports whether the object is a group.
+func (obj *Object) IsGroup() bool {
+	return !obj.IsDataset()
+}
+
+// Children returns the names of the group members sorted by name.
+func (obj *Object) Children() ([]string, error) {
+	links, err := obj.links()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(links))
+	for name := range links {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Child returns the group member with the given name.
+func (obj *Object) Child(name string) (*Object, error) {
+	links, err := obj.links()
+	if err != nil {
+		return nil, err
+	}
+	addr, ok := links[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in %s", name, obj.name)
+	}
+	return obj.f.object(name, addr)
+}
+
+// links returns the object header addresses of the group members keyed by
+// name.
+func (obj *Object) links() (map[string]uint64, error) {
+	links := make(map[string]uint64)
+	f := obj.f
+	for _, msg := range obj.messages {
+		switch msg.typ {
+		case msgSymbolTable:
+			c := f.newCursor(msg.data)
+			btree, heap := c.offset(), c.offset()
+			if c.err != nil {
+				return nil, c.err
+			}
+			heapData, err := f.localHeap(heap)
+			if err != nil {
+				return nil, err
+			}
+			if err := f.groupBTree(btree, heapData, links, 0); err != nil {
+				return nil, err
+			}
+		case msgLinkInfo:
+			c := f.newCursor(msg.data)
+			c.skip(1) // Version
+			flags := c.u8()
+			if flags&0x01 != 0 {
+				c.skip(8) // Maximum creation index
+			}
+			heap := c.offset()
+			if c.err != nil {
+				return nil, c.err
+			}
+			if !f.undefined(heap) {
+				return nil, fmt.Errorf("%w: dense link storage", ErrUnsupported)
+			}
+		case msgLink:
+			name, addr, ok, err := f.parseLink(msg.data)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				links[name] = addr
+			}
+		}
+	}
+	return links, nil
+}
+
+// parseLink parses a link message. Only hard links are returned; soft and
+// external links return ok as false.
+func (f *File) parseLink(data []byte) (name string, addr uint64, ok bool, err error) {
+	c := f.newCursor(data)
+	c.skip(1) // Version
+	flags := c.u8()
+	linkType := uint8(0)
+	if flags&0x08 != 0 {
+		linkType = c.u8()
+	}
+	if flags&0x04 != 0 {
+		c.skip(8) // Creation order
+	}
+	if flags&0x10 != 0 {
+		c.skip(1) // Link name character set
+	}
+	nameLen := c.uint(1 << (flags & 0x03))
+	name = string(c.bytes(int(nameLen)))
+	if linkType == 0 {
+		addr = c.offset()
+		ok = true
+	}
+	return name, addr, ok, c.err
+}
+
+// localHeap returns the data segment of the local heap at the given address.
+func (f *File) localHeap(addr uint64) ([]byte, error) {
+	b, err := f.readAt(addr, 8+2*f.lengthSize+f.offsetSize)
+	if err != nil {
+		return nil, fmt.Errorf("error reading local heap: %s", err)
+	}
+	if string(b[:4]) != "HEAP" {
+		return nil, fmt.Errorf("invalid local heap signature")
+	}
+	c := f.newCursor(b[8:])
+	size := c.length()
+	c.length() // Offset to head of free list
+	dataAddr := c.offset()
+	if c.err != nil {
+		return nil, c.err
+	}
+	return f.readAt(dataAddr, int(size))
+}
+
+// groupBTree walks the version 1 B-tree of a symbol table group adding the
+// symbol table entries to links.
+func (f *File) groupBTree(addr uint64, heap []byte, links map[string]uint64, depth int) error {
+	if depth > 64 {
+		return fmt.Errorf("group B-tree is too deep")
+	}
+	head, err := f.readAt(addr, 8+2*f.offsetSize)
+	if err != nil {
+		return fmt.Errorf("error reading group B-tree: %s", err)
+	}
+	if string(head[:4]) != "TREE" || head[4] != 0 {
+		return fmt.Errorf("invalid group B-tree node")
+	}
+	level := head[5]
+	entries := int(f.newCursor(head[6:8]).u16())
+	b, err := f.readAt(addr+uint64(len(head)), (entries+1)*f.lengthSize+entries*f.offsetSize)
+	if err != nil {
+		return fmt.Errorf("error reading group B-tree: %s", err)
+	}
+	c := f.newCursor(b)
+	for i := 0; i < entries; i++ {
+		c.length() // Key
+		child := c.offset()
+		if c.err != nil {
+			return c.err
+		}
+		if level > 0 {
+			err = f.groupBTree(child, heap, links, depth+1)
+		} else {
+			err = f.symbolTableNode(child, heap, links)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// symbolTableNode adds the entries of the symbol table node at the given
+// address to links.
+func (f *File) symbolTableNode(addr uint64, heap []byte, links map[string]uint64) error {
+	head, err := f.readAt(addr, 8)
+	if err != nil {
+		return fmt.Errorf("error reading symbol table node: %s", err)
+	}
+	if string(head[:4]) != "SNOD" {
+		return fmt.Errorf("invalid symbol table node signature")
+	}
+	n := int(f.newCursor(head[6:8]).u16())
+	entrySize := 2*f.offsetSize + 24
+	b, err := f.readAt(addr+8, n*entrySize)
+	if err != nil {
+		return fmt.Errorf("error reading symbol table node: %s", err)
+	}
+	c := f.newCursor(b)
+	for i := 0; i < n; i++ {
+		nameOffset := c.offset()
+		objAddr := c.offset()
+		c.skip(24) // Cache type, reserved, and scratch pad
+		if c.err != nil {
+			return c.err
+		}
+		if nameOffset >= uint64(len(heap)) {
+			return fmt.Errorf("invalid link name offset: %d", nameOffset)
+		}
+		name := heap[nameOffset:]
+		if end := bytes.IndexByte(name, 0); end >= 0 {
+			name = name[:end]
+		}
+		links[string(name)] = objAddr
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package hdf5
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Header message types only used when writing.
+const (
+	msgFillValue = 0x0005
+	msgGroupInfo = 0x000A
+)
+
+// undefinedAddr is the undefined address for 8 byte offsets.
+const undefinedAddr = ^uint64(0)
+
+// superblockSize is the size of a version 2 superblock with 8 byte offsets
+// and lengths.
+const superblockSize = 48
+
+// Group is a group to be written by Write. Groups and datasets are written
+// using version 2 object headers with compact link storage, which can be
+// read by HDF5 1.8 and later.
+type Group struct {
+	attributes
+	links []groupLink
+	addr  uint64
+}
+
+// Dataset is a one-dimensional float64 dataset to be written by Write. The
+// data is stored contiguously without compression.
+type Dataset struct {
+	attributes
+	values []float64
+	addr   uint64
+	// dataAddr is the address of the raw data.
+	dataAddr uint64
+}
+
+type groupLink struct {
+	name    string
+	group   *Group
+	dataset *Dataset
+}
+
+func (l groupLink) addr() uint64 {
+	if l.group != nil {
+		return l.group.addr
+	}
+	return l.dataset.addr
+}
+
+type attribute struct {
+	name     string
+	datatype []byte
+	data     []byte
+}
+
+// attributes holds the attributes of a group or dataset. Setting an
+// attribute with an existing name replaces its value.
+type attributes struct {
+	attrs []attribute
+}
+
+// NewGroup returns an empty group, which is used as the root group.
+func NewGroup() *Group {
+	return &Group{}
+}
+
+// Group adds a child group with the given name.
+func (g *Group) Group(name string) *Group {
+	child := &Group{}
+	g.links = append(g.links, groupLink{name: name, group: child})
+	return child
+}
+
+// Dataset adds a dataset with the given name and values.
+func (g *Group) Dataset(name string, values []float64) *Dataset {
+	ds := &Dataset{values: values}
+	g.links = append(g.links, groupLink{name: name, dataset: ds})
+	return ds
+}
+
+// SetString sets a UTF-8 string attribute.
+func (a *attributes) SetString(name, value string) {
+	// A null terminated string of at least one byte.
+	size := len(value) + 1
+	dt := []byte{0x13, 0x10, 0, 0}
+	dt = binary.LittleEndian.AppendUint32(dt, uint32(size))
+	a.set(attribute{name, dt, append([]byte(value), 0)})
+}
+
+// SetFloat64 sets a float64 attribute.
+func (a *attributes) SetFloat64(name string, value float64) {
+	data := binary.LittleEndian.AppendUint64(nil, math.Float64bits(value))
+	a.set(attribute{name, float64Datatype(), data})
+}
+
+// SetInt64 sets an int64 attribute.
+func (a *attributes) SetInt64(name string, value int64) {
+	data := binary.LittleEndian.AppendUint64(nil, uint64(value))
+	a.set(attribute{name, int64Datatype(), data})
+}
+
+func (a *attributes) set(attr attribute) {
+	for i := range a.attrs {
+		if a.attrs[i].name == attr.name {
+			a.attrs[i] = attr
+			return
+		}
+	}
+	a.attrs = append(a.attrs, attr)
+}
+
+// float64Datatype returns a datatype message for a little-endian IEEE 754
+// double.
+func float64Datatype() []byte {
+	b := []byte{0x11, 0x20, 63, 0}
+	b = binary.LittleEndian.AppendUint32(b, 8)
+	b = binary.LittleEndian.AppendUint16(b, 0)  // Bit offset
+	b = binary.LittleEndian.AppendUint16(b, 64) // Bit precision
+	b = append(b, 52, 11, 0, 52)                // Exponent and mantissa location and size
+	return binary.LittleEndian.AppendUint32(b, 1023)
+}
+
+// int64Datatype returns a datatype message for a little-endian signed 64-bit
+// integer.
+func int64Datatype() []byte {
+	b := []byte{0x10, 0x08, 0, 0}
+	b = binary.LittleEndian.AppendUint32(b, 8)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	return binary.LittleEndian.AppendUint16(b, 64)
+}
+
+// scalarDataspace is a version 2 scalar dataspace message.
+var scalarDataspace = []byte{2, 0, 0, 0}
+
+func simpleDataspace(n int) []byte {
+	b := []byte{2, 1, 0, 1}
+	return binary.LittleEndian.AppendUint64(b, uint64(n))
+}
+
+// Write writes an HDF5 file with the given root group to the io.Writer. The
+// file uses a version 2 superblock with 8 byte offsets and lengths.
+func Write(w io.Writer, root *Group) error {
+	eof, err := allocate(root, superblockSize, 0)
+	if err != nil {
+		return err
+	}
+	b := make([]byte, 0, eof)
+	b = append(b, signature...)
+	b = append(b, 2, 8, 8, 0)
+	b = binary.LittleEndian.AppendUint64(b, 0) // Base address
+	b = binary.LittleEndian.AppendUint64(b, undefinedAddr)
+	b = binary.LittleEndian.AppendUint64(b, eof)
+	b = binary.LittleEndian.AppendUint64(b, root.addr)
+	b = binary.LittleEndian.AppendUint32(b, lookup3(b))
+	b = appendGroup(b, root)
+	if uint64(len(b)) != eof {
+		return fmt.Errorf("internal error: wrote %d bytes but allocated %d", len(b), eof)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// allocate assigns the addresses of the group and its members in the order
+// they're written, returning the next free address.
+func allocate(g *Group, addr uint64, depth int) (uint64, error) {
+	if depth > 64 {
+		return 0, fmt.Errorf("groups are nested too deeply")
+	}
+	g.addr = addr
+	addr += uint64(len(objectHeader(g.messages())))
+	for _, l := range g.links {
+		if l.group != nil {
+			var err error
+			if addr, err = allocate(l.group, addr, depth+1); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		ds := l.dataset
+		ds.addr = addr
+		addr += uint64(len(objectHeader(ds.messages())))
+		ds.dataAddr = addr
+		addr += uint64(8 * len(ds.values))
+	}
+	return addr, nil
+}
+
+func appendGroup(b []byte, g *Group) []byte {
+	b = append(b, objectHeader(g.messages())...)
+	for _, l := range g.links {
+		if l.group != nil {
+			b = appendGroup(b, l.group)
+			continue
+		}
+		b = append(b, objectHeader(l.dataset.messages())...)
+		for _, v := range l.dataset.values {
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+		}
+	}
+	return b
+}
+
+func (g *Group) messages() []message {
+	linkInfo := []byte{0, 0}
+	linkInfo = binary.LittleEndian.AppendUint64(linkInfo, undefinedAddr) // Fractal heap
+	linkInfo = binary.LittleEndian.AppendUint64(linkInfo, undefinedAddr) // Name index B-tree
+	msgs := []message{
+		{typ: msgLinkInfo, data: linkInfo},
+		{typ: msgGroupInfo, data: []byte{0, 0}},
+	}
+	for _, l := range g.links {
+		// Version 1 hard link with a UTF-8 name, whose length is stored
+		// using one or two bytes.
+		data := []byte{1, 0x10, 1}
+		if len(l.name) > math.MaxUint8 {
+			data[1] |= 0x01
+			data = binary.LittleEndian.AppendUint16(data, uint16(len(l.name)))
+		} else {
+			data = append(data, byte(len(l.name)))
+		}
+		data = append(data, l.name...)
+		data = binary.LittleEndian.AppendUint64(data, l.addr())
+		msgs = append(msgs, message{typ: msgLink, data: data})
+	}
+	return append(msgs, g.attributeMessages()...)
+}
+
+func (ds *Dataset) messages() []message {
+	layout := []byte{3, layoutContiguous}
+	addr := ds.dataAddr
+	if len(ds.values) == 0 {
+		addr = undefinedAddr
+	}
+	layout = binary.LittleEndian.AppendUint64(layout, addr)
+	layout = binary.LittleEndian.AppendUint64(layout, uint64(8*len(ds.values)))
+	msgs := []message{
+		{typ: msgDataspace, data: simpleDataspace(len(ds.values))},
+		{typ: msgDatatype, flags: 0x01, data: float64Datatype()},
+		// Version 3 fill value with late allocation, written only if
+		// defined, and no fill value defined.
+		{typ: msgFillValue, data: []byte{3, 0x0A}},
+		{typ: msgDataLayout, data: layout},
+	}
+	return append(msgs, ds.attributeMessages()...)
+}
+
+func (a *attributes) attributeMessages() []message {
+	msgs := make([]message, 0, len(a.attrs))
+	for _, attr := range a.attrs {
+		data := []byte{3, 0}
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(attr.name)+1))
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(attr.datatype)))
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(scalarDataspace)))
+		data = append(data, 1) // UTF-8 name
+		data = append(data, attr.name...)
+		data = append(data, 0)
+		data = append(data, attr.datatype...)
+		data = append(data, scalarDataspace...)
+		data = append(data, attr.data...)
+		msgs = append(msgs, message{typ: msgAttribute, data: data})
+	}
+	return msgs
+}
+
+// objectHeader encodes a version 2 object header with the messages stored in
+// a single chunk.
+func objectHeader(msgs []message) []byte {
+	size := 0
+	for _, msg := range msgs {
+		size += 4 + len(msg.data)
+	}
+	// Store the chunk size using 4 bytes.
+	b := []byte("OHDR")
+	b = append(b, 2, 0x02)
+	b = binary.LittleEndian.AppendUint32(b, uint32(size))
+	for _, msg := range msgs {
+		b = append(b, byte(msg.typ))
+		b = binary.LittleEndian.AppendUint16(b, uint16(len(msg.data)))
+		b = append(b, msg.flags)
+		b = append(b, msg.data...)
+	}
+	return binary.LittleEndian.AppendUint32(b, lookup3(b))
+}
+
+// lookup3 returns Bob Jenkins' lookup3 hash of the data with an initial value
+// of zero, which HDF5 uses as the checksum of metadata structures.
+func lookup3(k []byte) uint32 {
+	a := 0xdeadbeef + uint32(len(k))
+	b, c := a, a
+	for len(k) > 12 {
+		a += binary.LittleEndian.Uint32(k)
+		b += binary.LittleEndian.Uint32(k[4:])
+		c += binary.LittleEndian.Uint32(k[8:])
+		a -= c
+		a ^= rotl(c, 4)
+		c += b
+		b -= a
+		b ^= rotl(a, 6)
+		a += c
+		c -= b
+		c ^= rotl(b, 8)
+		b += a
+		a -= c
+		a ^= rotl(c, 16)
+		c += b
+		b -= a
+		b ^= rotl(a, 19)
+		a += c
+		c -= b
+		c ^= rotl(b, 4)
+		b += a
+		k = k[12:]
+	}
+	if len(k) == 0 {
+		return c
+	}
+	var tail [12]byte
+	copy(tail[:], k)
+	a += binary.LittleEndian.Uint32(tail[:])
+	b += binary.LittleEndian.Uint32(tail[4:])
+	c += binary.LittleEndian.Uint32(tail[8:])
+	c ^= b
+	c -= rotl(b, 14)
+	a ^= c
+	a -= rotl(c, 11)
+	b ^= a
+	b -= rotl(a, 25)
+	c ^= b
+	c -= rotl(b, 16)
+	a ^= c
+	a -= rotl(c, 4)
+	b ^= a
+	b -= rotl(a, 14)
+	c ^= b
+	c -= rotl(b, 24)
+	return c
+}
+
+func rotl(x uint32, k uint) uint32 {
+	return x<<k | x>>(32-k)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package hdf5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	root := NewGroup()
+	root.SetString("Title", "Test")
+	root.SetString("Title", "Replaced")
+	root.SetInt64("Count", 3)
+	traces := root.Group("Traces")
+	ds := traces.Dataset("Frequency", []float64{1e6, 2e6, 3e6})
+	ds.SetString("Units", "Hz")
+	ds.SetFloat64("Scale", 0.5)
+	traces.Dataset("Empty", nil)
+	long := strings.Repeat("x", 300)
+	root.Dataset(long, []float64{-1})
+
+	var buf bytes.Buffer
+	if err := Write(&buf, root); err != nil {
+		t.Fatalf("error writing HDF5 file: %s", err)
+	}
+	f, err := Open(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("error opening written file: %s", err)
+	}
+	obj, err := f.Root()
+	if err != nil {
+		t.Fatalf("error reading root group: %s", err)
+	}
+	attrs, err := obj.Attributes()
+	if err != nil {
+		t.Fatalf("error reading attributes: %s", err)
+	}
+	if want := map[string]interface{}{"Title": "Replaced", "Count": int64(3)}; !reflect.DeepEqual(attrs, want) {
+		t.Errorf("\ngot  = %v\nwant = %v", attrs, want)
+	}
+	names, err := obj.Children()
+	if err != nil {
+		t.Fatalf("error listing root group: %s", err)
+	}
+	if want := []string{"Traces", long}; !reflect.DeepEqual(names, want) {
+		t.Errorf("\ngot  = %v\nwant = %v", names, want)
+	}
+	obj, err = f.Get("/Traces/Frequency")
+	if err != nil {
+		t.Fatalf("error getting dataset: %s", err)
+	}
+	if !obj.IsDataset() {
+		t.Errorf("expected a dataset")
+	}
+	values, err := obj.Float64s()
+	if err != nil || !reflect.DeepEqual(values, []float64{1e6, 2e6, 3e6}) {
+		t.Errorf("\ngot  = %v (%v)\nwant = [1e6 2e6 3e6]", values, err)
+	}
+	attrs, err = obj.Attributes()
+	if err != nil {
+		t.Fatalf("error reading dataset attributes: %s", err)
+	}
+	if want := map[string]interface{}{"Units": "Hz", "Scale": 0.5}; !reflect.DeepEqual(attrs, want) {
+		t.Errorf("\ngot  = %v\nwant = %v", attrs, want)
+	}
+	obj, err = f.Get("/Traces/Empty")
+	if err != nil {
+		t.Fatalf("error getting empty dataset: %s", err)
+	}
+	if values, err := obj.Float64s(); err != nil || len(values) != 0 {
+		t.Errorf("expected no values, got %v (%v)", values, err)
+	}
+	obj, err = f.Get("/" + long)
+	if err != nil {
+		t.Fatalf("error getting long name dataset: %s", err)
+	}
+	if values, err := obj.Float64s(); err != nil || !reflect.DeepEqual(values, []float64{-1}) {
+		t.Errorf("\ngot  = %v (%v)\nwant = [-1]", values, err)
+	}
+}
+
+// TestWriteLayout compares the encoding of a small file with the structures
+// given by the HDF5 File Format Specification, without using the reader of
+// this package. The checksums are computed using lookup3, which is tested
+// against the published test vectors.
+func TestWriteLayout(t *testing.T) {
+	root := NewGroup()
+	root.SetString("Title", "Test")
+	root.Dataset("Frequency", []float64{1e6})
+	var buf bytes.Buffer
+	if err := Write(&buf, root); err != nil {
+		t.Fatalf("error writing HDF5 file: %s", err)
+	}
+
+	// The root group object header is at 48, the dataset object header at
+	// 151, the raw data at 233, and the end of the file at 241.
+	var want []byte
+	withChecksum := func(start int) {
+		want = binary.LittleEndian.AppendUint32(want, lookup3(want[start:]))
+	}
+	appendHex := func(s string) {
+		b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, b...)
+	}
+	// Superblock version 2 with 8 byte offsets and lengths, base address 0,
+	// no superblock extension, end of file address, and root group object
+	// header address.
+	appendHex(`89484446 0d0a1a0a 02 08 08 00
+		0000000000000000 ffffffffffffffff f100000000000000 3000000000000000`)
+	withChecksum(0)
+	// Version 2 object header storing the chunk size using 4 bytes.
+	appendHex(`4f484452 02 02 59000000`)
+	// Link info message without creation order, with compact storage.
+	appendHex(`02 1200 00  00 00 ffffffffffffffff ffffffffffffffff`)
+	// Group info message.
+	appendHex(`0a 0200 00  00 00`)
+	// Link message of a hard link with a UTF-8 name of one byte length.
+	appendHex(`06 1500 00  01 10 01 09` + hex.EncodeToString([]byte("Frequency")) + `9700000000000000`)
+	// Version 3 attribute message with a UTF-8 name, a null terminated UTF-8
+	// string datatype of 5 bytes, and a scalar dataspace.
+	appendHex(`0c 2000 00  03 00 0600 0800 0400 01` + hex.EncodeToString([]byte("Title\x00")) +
+		`13 10 00 00 05000000  02 00 00 00` + hex.EncodeToString([]byte("Test\x00")))
+	withChecksum(48)
+	appendHex(`4f484452 02 02 44000000`)
+	// Version 2 simple dataspace of one dimension.
+	appendHex(`01 0c00 00  02 01 00 01 0100000000000000`)
+	// Constant IEEE 754 little-endian double datatype.
+	appendHex(`03 1400 01  11 20 3f 00 08000000 0000 4000 34 0b 00 34 ff030000`)
+	// Version 3 fill value message with late allocation, written if set,
+	// and no fill value defined.
+	appendHex(`05 0200 00  03 0a`)
+	// Version 3 contiguous data layout message.
+	appendHex(`08 1200 00  03 01 e900000000000000 0800000000000000`)
+	withChecksum(151)
+	want = binary.LittleEndian.AppendUint64(want, math.Float64bits(1e6))
+
+	got := buf.Bytes()
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if got[i] != want[i] {
+			t.Fatalf("byte %d: got %#02x, want %#02x", i, got[i], want[i])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestLookup3(t *testing.T) {
+	var tests = []struct {
+		data string
+		want uint32
+	}{
+		{"", 0xdeadbeef},
+		{"Four score and seven years ago", 0x17770551},
+	}
+	for _, test := range tests {
+		if got := lookup3([]byte(test.data)); got != test.want {
+			t.Errorf("\ngot  = %#x for %q\nwant = %#x", got, test.data, test.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package interp interpolates tabulated values, such as corrections or trace
+// data, onto another frequency grid.
+package interp
+
+import (
+	"math"
+	"sort"
+)
+
+// Linear returns the value at x by interpolating linearly between the points
+// given by xs and ys, which must be sorted by increasing x. If logX is true,
+// the interpolation uses a logarithmic x axis when both neighboring points are
+// positive. Values outside the range of xs are held at the end values.
+func Linear(xs, ys []float64, x float64, logX bool) float64 {
+	n := len(xs)
+	if n == 0 {
+		return math.NaN()
+	}
+	if x <= xs[0] {
+		return ys[0]
+	}
+	if x >= xs[n-1] {
+		return ys[n-1]
+	}
+	i := sort.SearchFloat64s(xs, x)
+	if xs[i] == x {
+		return ys[i]
+	}
+	x0, x1 := xs[i-1], xs[i]
+	y0, y1 := ys[i-1], ys[i]
+	frac := (x - x0) / (x1 - x0)
+	if logX && x0 > 0 {
+		frac = math.Log(x/x0) / math.Log(x1/x0)
+	}
+	return y0 + frac*(y1-y0)
+}
+
+// Resample interpolates the values given at xs onto the new grid.
+func Resample(xs, ys, grid []float64, logX bool) []float64 {
+	values := make([]float64, len(grid))
+	for i, x := range grid {
+		values[i] = Linear(xs, ys, x, logX)
+	}
+	return values
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package interp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLinear(t *testing.T) {
+	xs := []float64{10, 100, 1000}
+	ys := []float64{0, 10, 30}
+	var tests = []struct {
+		x    float64
+		logX bool
+		want float64
+	}{
+		{1, false, 0},
+		{10, false, 0},
+		{55, false, 5},
+		{100, true, 10},
+		{550, false, 20},
+		{math.Sqrt(1000), true, 5},
+		{5000, true, 30},
+	}
+	for _, test := range tests {
+		if got := Linear(xs, ys, test.x, test.logX); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Linear(%g, %t) = %g / want %g", test.x, test.logX, got, test.want)
+		}
+	}
+	if got := Linear(nil, nil, 1, false); !math.IsNaN(got) {
+		t.Errorf("expected NaN for empty table, got %g", got)
+	}
+	got := Resample(xs, ys, []float64{55, 550}, false)
+	if len(got) != 2 || got[0] != 5 || got[1] != 20 {
+		t.Errorf("Resample = %v", got)
+	}
+}
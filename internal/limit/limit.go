@@ -0,0 +1,146 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package limit evaluates traces against limit lines. It's shared by the
+// spectrum analyzer packages, which wrap it with their own limit line types.
+package limit
+
+import (
+	"fmt"
+	"math"
+)
+
+// Point is a breakpoint of a limit line. A Disconnected point starts a new
+// segment that isn't joined to the previous point.
+type Point struct {
+	Frequency    float64
+	Amplitude    float64
+	Disconnected bool
+}
+
+// Line is a limit line.
+type Line struct {
+	Points []Point
+	Upper  bool
+	// LogFrequency interpolates between points using a logarithmic frequency
+	// axis instead of a linear one.
+	LogFrequency bool
+	// Margin is the offset in dB of the margin line from the limit line. It's
+	// only used if MarginOn is true.
+	Margin   float64
+	MarginOn bool
+}
+
+// Result is the result of evaluating a trace against a limit line.
+type Result struct {
+	// Pass reports whether every tested point is within the limit.
+	Pass bool
+	// MarginPass reports whether every tested point is within the margin
+	// line. It's the same as Pass if the margin is off.
+	MarginPass bool
+	// WorstMargin is the smallest distance in dB between the trace and the
+	// limit line, which is negative if the trace fails the limit.
+	WorstMargin    float64
+	WorstFrequency float64
+	// Failures contains the indices of the trace points that fail the
+	// limit.
+	Failures []int
+	// Tested is the number of trace points within the frequency range of the
+	// limit line.
+	Tested int
+}
+
+// At returns the limit at the given frequency. It returns false if the
+// frequency isn't covered by the limit line. Where two segments meet at the
+// same frequency, the more restrictive limit is returned.
+func (l Line) At(freq float64) (float64, bool) {
+	found := false
+	limit := 0.0
+	use := func(v float64) {
+		switch {
+		case !found:
+			limit = v
+		case l.Upper:
+			limit = math.Min(limit, v)
+		default:
+			limit = math.Max(limit, v)
+		}
+		found = true
+	}
+	for i, p := range l.Points {
+		if p.Frequency == freq {
+			use(p.Amplitude)
+		}
+		if i == 0 || p.Disconnected {
+			continue
+		}
+		prev := l.Points[i-1]
+		lo, hi := prev.Frequency, p.Frequency
+		if lo > hi || freq <= lo || freq >= hi {
+			continue
+		}
+		use(l.interpolate(prev, p, freq))
+	}
+	return limit, found
+}
+
+func (l Line) interpolate(a, b Point, freq float64) float64 {
+	if l.LogFrequency && a.Frequency > 0 && freq > 0 {
+		frac := math.Log(freq/a.Frequency) / math.Log(b.Frequency/a.Frequency)
+		return a.Amplitude + frac*(b.Amplitude-a.Amplitude)
+	}
+	frac := (freq - a.Frequency) / (b.Frequency - a.Frequency)
+	return a.Amplitude + frac*(b.Amplitude-a.Amplitude)
+}
+
+// Evaluate tests the trace values at the given frequencies against the limit
+// line. Trace points outside the frequency range of the limit line aren't
+// tested.
+func (l Line) Evaluate(freqs, values []float64) (Result, error) {
+	if len(freqs) != len(values) {
+		return Result{}, fmt.Errorf("mismatched lengths / freq %d / values %d", len(freqs), len(values))
+	}
+	if len(l.Points) == 0 {
+		return Result{}, fmt.Errorf("limit line doesn't have any points")
+	}
+	result := Result{Pass: true, MarginPass: true, WorstMargin: math.Inf(1)}
+	for i, freq := range freqs {
+		limit, ok := l.At(freq)
+		if !ok {
+			continue
+		}
+		result.Tested++
+		margin := values[i] - limit
+		if l.Upper {
+			margin = limit - values[i]
+		}
+		if margin < 0 {
+			result.Pass = false
+			result.Failures = append(result.Failures, i)
+		}
+		if l.MarginOn {
+			// The margin line is offset from the limit towards the passing
+			// side for a negative upper margin or a positive lower margin.
+			offset := l.Margin
+			if !l.Upper {
+				offset = -offset
+			}
+			if margin+offset < 0 {
+				result.MarginPass = false
+			}
+		}
+		if margin < result.WorstMargin {
+			result.WorstMargin = margin
+			result.WorstFrequency = freq
+		}
+	}
+	if !l.MarginOn {
+		result.MarginPass = result.Pass
+	}
+	if result.Tested == 0 {
+		result.WorstMargin = math.NaN()
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package limit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLineAt(t *testing.T) {
+	line := Line{
+		Upper: true,
+		Points: []Point{
+			{Frequency: 10, Amplitude: 50},
+			{Frequency: 20, Amplitude: 40},
+			{Frequency: 30, Amplitude: 60, Disconnected: true},
+			{Frequency: 40, Amplitude: 60},
+		},
+	}
+	var tests = []struct {
+		freq  float64
+		limit float64
+		found bool
+	}{
+		{5, 0, false},
+		{10, 50, true},
+		{15, 45, true},
+		{20, 40, true},
+		{25, 0, false},
+		{30, 60, true},
+		{35, 60, true},
+		{45, 0, false},
+	}
+	for _, test := range tests {
+		limit, found := line.At(test.freq)
+		if found != test.found || limit != test.limit {
+			t.Errorf("At(%g) = %g, %t / want %g, %t", test.freq, limit, found, test.limit, test.found)
+		}
+	}
+	line.LogFrequency = true
+	if limit, _ := line.At(math.Sqrt(200)); math.Abs(limit-45) > 1e-9 {
+		t.Errorf("log interpolation = %g / want 45", limit)
+	}
+}
+
+func TestLineEvaluate(t *testing.T) {
+	line := Line{Points: []Point{{Frequency: 0, Amplitude: -10}, {Frequency: 10, Amplitude: -10}}}
+	result, err := line.Evaluate([]float64{20}, []float64{0})
+	if err != nil {
+		t.Fatalf("received error evaluating: %s", err)
+	}
+	if result.Tested != 0 || !math.IsNaN(result.WorstMargin) || !result.Pass {
+		t.Errorf("untested result = %+v", result)
+	}
+	result, _ = line.Evaluate([]float64{5}, []float64{-12})
+	if result.Pass || result.MarginPass || result.WorstMargin != -2 {
+		t.Errorf("lower limit result = %+v", result)
+	}
+	if _, err := (Line{}).Evaluate(nil, nil); err == nil {
+		t.Errorf("expected error for empty limit line")
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package mat reads and writes Level 5 MATLAB MAT-files containing double
+// arrays, character arrays, and structs. The data is written uncompressed,
+// so the files can be loaded by MATLAB, Octave, and SciPy.
+package mat
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf16"
+)
+
+// Header text written to the start of the file.
+const headerText = "MATLAB 5.0 MAT-file, Created by: github.com/gotmc/keysight"
+
+// Data types.
+const (
+	miINT8   = 1
+	miUINT16 = 4
+	miINT32  = 5
+	miUINT32 = 6
+	miDOUBLE = 9
+	miMATRIX = 14
+)
+
+// Array classes.
+const (
+	mxSTRUCT = 2
+	mxCHAR   = 4
+	mxDOUBLE = 6
+)
+
+// complexFlag is the array flag set for complex arrays.
+const complexFlag = 0x0800
+
+// maxNameLength is the longest variable or field name supported by MATLAB.
+const maxNameLength = 63
+
+// Array is a MATLAB array.
+type Array struct {
+	class int
+	rows  int
+	cols  int
+	// data contains the encoded data elements following the array name.
+	data []byte
+	err  error
+
+	// values, imag, text, and fields are the decoded contents returned by
+	// the accessors.
+	values []float64
+	imag   []float64
+	text   string
+	fields []Variable
+}
+
+// Variable is a named array, which is either a variable in the MAT-file or
+// a field of a struct.
+type Variable struct {
+	Name  string
+	Value Array
+}
+
+// Column returns a column vector of doubles.
+func Column(values []float64) Array {
+	b := make([]byte, 0, 8*len(values))
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	return Array{class: mxDOUBLE, rows: len(values), cols: 1, data: element(miDOUBLE, b), values: values}
+}
+
+// ComplexColumn returns a complex column vector of doubles.
+func ComplexColumn(values []complex128) Array {
+	re := make([]float64, len(values))
+	im := make([]float64, len(values))
+	for i, v := range values {
+		re[i], im[i] = real(v), imag(v)
+	}
+	a := Column(re)
+	a.data = append(a.data, Column(im).data...)
+	a.imag = im
+	return a
+}
+
+// Scalar returns a 1-by-1 double.
+func Scalar(v float64) Array {
+	return Column([]float64{v})
+}
+
+// String returns a 1-by-n character array, or an empty character array if
+// the string is empty.
+func String(s string) Array {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	a := Array{class: mxCHAR, rows: 1, cols: len(units), data: element(miUINT16, b), text: s}
+	if len(units) == 0 {
+		a.rows = 0
+	}
+	return a
+}
+
+// Struct returns a 1-by-1 struct with the given fields.
+func Struct(fields ...Variable) Array {
+	length := 1
+	for _, f := range fields {
+		if err := checkName(f.Name); err != nil {
+			return Array{err: err}
+		}
+		if f.Value.err != nil {
+			return Array{err: fmt.Errorf("field %s: %s", f.Name, f.Value.err)}
+		}
+		length = max(length, len(f.Name)+1)
+	}
+	// The field name length is stored using the small data element format.
+	b := binary.LittleEndian.AppendUint32(nil, miINT32|4<<16)
+	b = binary.LittleEndian.AppendUint32(b, uint32(length))
+	names := make([]byte, length*len(fields))
+	for i, f := range fields {
+		copy(names[i*length:], f.Name)
+	}
+	b = append(b, element(miINT8, names)...)
+	for _, f := range fields {
+		b = append(b, matrix("", f.Value)...)
+	}
+	return Array{class: mxSTRUCT, rows: 1, cols: 1, data: b, fields: fields}
+}
+
+// Write writes a MAT-file containing the variables to the io.Writer.
+func Write(w io.Writer, vars []Variable) error {
+	header := make([]byte, 128)
+	copy(header, headerText)
+	for i := len(headerText); i < 116; i++ {
+		header[i] = ' '
+	}
+	binary.LittleEndian.PutUint16(header[124:], 0x0100)
+	copy(header[126:], "IM")
+	b := header
+	seen := make(map[string]bool)
+	for _, v := range vars {
+		if err := checkName(v.Name); err != nil {
+			return err
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variable name: %s", v.Name)
+		}
+		seen[v.Name] = true
+		if v.Value.err != nil {
+			return fmt.Errorf("variable %s: %s", v.Name, v.Value.err)
+		}
+		b = append(b, matrix(v.Name, v.Value)...)
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// checkName returns an error if the name isn't a valid MATLAB identifier.
+func checkName(name string) error {
+	if name == "" || len(name) > maxNameLength {
+		return fmt.Errorf("invalid name length: %q", name)
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c == '_' || c >= '0' && c <= '9'):
+		default:
+			return fmt.Errorf("invalid name: %q", name)
+		}
+	}
+	return nil
+}
+
+// matrix encodes the array as a miMATRIX data element.
+func matrix(name string, a Array) []byte {
+	class := uint32(a.class)
+	if a.imag != nil {
+		class |= complexFlag
+	}
+	flags := binary.LittleEndian.AppendUint32(nil, class)
+	flags = binary.LittleEndian.AppendUint32(flags, 0)
+	dims := binary.LittleEndian.AppendUint32(nil, uint32(a.rows))
+	dims = binary.LittleEndian.AppendUint32(dims, uint32(a.cols))
+	b := element(miUINT32, flags)
+	b = append(b, element(miINT32, dims)...)
+	b = append(b, element(miINT8, []byte(name))...)
+	b = append(b, a.data...)
+	return element(miMATRIX, b)
+}
+
+// element encodes a data element padded to a multiple of 8 bytes.
+func element(typ uint32, data []byte) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, typ)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	b = append(b, data...)
+	if r := len(b) % 8; r != 0 {
+		b = append(b, make([]byte, 8-r)...)
+	}
+	return b
+}
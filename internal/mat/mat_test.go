@@ -0,0 +1,158 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package mat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	vars := []Variable{
+		{"frequency", Column([]float64{1e6, 2e6, 3e6})},
+		{"metadata", Struct(
+			Variable{"model", String("E4402B")},
+			Variable{"rbw", Scalar(1000)},
+			Variable{"title", String("")},
+		)},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, vars); err != nil {
+		t.Fatalf("error writing MAT-file: %s", err)
+	}
+	b := buf.Bytes()
+	if !strings.HasPrefix(string(b), "MATLAB 5.0 MAT-file") {
+		t.Errorf("invalid header text: %q", b[:20])
+	}
+	assert(t, "version", binary.LittleEndian.Uint16(b[124:]), uint16(0x0100))
+	assert(t, "endian indicator", string(b[126:128]), "IM")
+	assert(t, "length is padded", len(b)%8, 0)
+
+	elements := readElements(t, b[128:])
+	assert(t, "num variables", len(elements), 2)
+	freq := readMatrix(t, elements[0])
+	assert(t, "frequency class", freq.class, mxDOUBLE)
+	assert(t, "frequency name", freq.name, "frequency")
+	assert(t, "frequency rows", freq.rows, 3)
+	assert(t, "frequency cols", freq.cols, 1)
+	sub := readElements(t, freq.data)
+	assert(t, "frequency type", sub[0].typ, uint32(miDOUBLE))
+	assert(t, "frequency[1]", math.Float64frombits(binary.LittleEndian.Uint64(sub[0].data[8:])), 2e6)
+
+	meta := readMatrix(t, elements[1])
+	assert(t, "metadata class", meta.class, mxSTRUCT)
+	assert(t, "metadata name", meta.name, "metadata")
+	assert(t, "field name length tag", binary.LittleEndian.Uint32(meta.data), uint32(miINT32|4<<16))
+	length := int(binary.LittleEndian.Uint32(meta.data[4:]))
+	assert(t, "field name length", length, 6)
+	sub = readElements(t, meta.data[8:])
+	assert(t, "num struct elements", len(sub), 4)
+	assert(t, "field names", string(bytes.TrimRight(sub[0].data[length:2*length], "\x00")), "rbw")
+	model := readMatrix(t, sub[1])
+	assert(t, "model class", model.class, mxCHAR)
+	assert(t, "model name", model.name, "")
+	assert(t, "model cols", model.cols, 6)
+	chars := readElements(t, model.data)[0]
+	assert(t, "model type", chars.typ, uint32(miUINT16))
+	assert(t, "model[1]", binary.LittleEndian.Uint16(chars.data[2:]), uint16('4'))
+	title := readMatrix(t, sub[3])
+	assert(t, "empty string rows", title.rows, 0)
+}
+
+func TestWriteErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		vars []Variable
+	}{
+		{"invalid name", []Variable{{"1abc", Scalar(1)}}},
+		{"empty name", []Variable{{"", Scalar(1)}}},
+		{"duplicate name", []Variable{{"a", Scalar(1)}, {"a", Scalar(2)}}},
+		{"invalid field name", []Variable{{"s", Struct(Variable{"a b", Scalar(1)})}}},
+		{"long name", []Variable{{strings.Repeat("a", 64), Scalar(1)}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Write(&bytes.Buffer{}, test.vars); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+type testElement struct {
+	typ  uint32
+	data []byte
+}
+
+func readElements(t *testing.T, b []byte) []testElement {
+	t.Helper()
+	var elements []testElement
+	for len(b) > 0 {
+		if len(b) < 8 {
+			t.Fatalf("truncated element tag")
+		}
+		typ := binary.LittleEndian.Uint32(b)
+		if n := typ >> 16; n != 0 {
+			// Small data element format.
+			elements = append(elements, testElement{typ & 0xFFFF, b[4 : 4+n]})
+			b = b[8:]
+			continue
+		}
+		n := int(binary.LittleEndian.Uint32(b[4:]))
+		if 8+n > len(b) {
+			t.Fatalf("truncated element data")
+		}
+		elements = append(elements, testElement{typ, b[8 : 8+n]})
+		size := 8 + n
+		if r := size % 8; r != 0 {
+			size += 8 - r
+		}
+		b = b[size:]
+	}
+	return elements
+}
+
+type testMatrix struct {
+	class int
+	name  string
+	rows  int
+	cols  int
+	data  []byte
+}
+
+func readMatrix(t *testing.T, e testElement) testMatrix {
+	t.Helper()
+	if e.typ != miMATRIX {
+		t.Fatalf("expected miMATRIX, got %d", e.typ)
+	}
+	sub := readElements(t, e.data)
+	if len(sub) < 3 {
+		t.Fatalf("missing matrix subelements")
+	}
+	m := testMatrix{
+		class: int(binary.LittleEndian.Uint32(sub[0].data)),
+		rows:  int(binary.LittleEndian.Uint32(sub[1].data)),
+		cols:  int(binary.LittleEndian.Uint32(sub[1].data[4:])),
+		name:  string(sub[2].data),
+	}
+	// The remaining data starts after the three padded subelements.
+	offset := 16 + 16
+	offset += 8 + len(sub[2].data)
+	if r := offset % 8; r != 0 {
+		offset += 8 - r
+	}
+	m.data = e.data[offset:]
+	return m
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
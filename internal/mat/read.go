@@ -0,0 +1,435 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package mat
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+// Data types that are only read.
+const (
+	miUINT8      = 2
+	miINT16      = 3
+	miSINGLE     = 7
+	miINT64      = 12
+	miUINT64     = 13
+	miCOMPRESSED = 15
+	miUTF8       = 16
+	miUTF16      = 17
+	miUTF32      = 18
+)
+
+// Numeric array classes that are converted to doubles when read.
+const (
+	mxSINGLE = 7
+	mxUINT64 = 15
+)
+
+var errTruncated = errors.New("truncated data element")
+
+// Read reads the variables of a Level 5 MAT-file, which may be compressed
+// and in either byte order. Numeric arrays of any class are converted to
+// doubles and character arrays to strings. Only 1-by-1 structs are
+// supported, and variables of other classes, such as cell arrays and sparse
+// arrays, are skipped. MATLAB 7.3 MAT-files, which are HDF5 files, and
+// Level 4 MAT-files aren't supported.
+func Read(r io.Reader) ([]Variable, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 128 {
+		return nil, errors.New("missing MAT-file header")
+	}
+	var d decoder
+	switch string(b[126:128]) {
+	case "IM":
+		d.order = binary.LittleEndian
+	case "MI":
+		d.order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid MAT-file endian indicator")
+	}
+	if version := d.order.Uint16(b[124:]); version != 0x0100 {
+		return nil, fmt.Errorf("unsupported MAT-file version: %#04x", version)
+	}
+	var vars []Variable
+	for b = b[128:]; len(b) > 0; {
+		typ, data, rest, err := d.element(b)
+		if err != nil {
+			return vars, err
+		}
+		b = rest
+		if typ == miCOMPRESSED {
+			if typ, data, err = d.inflate(data); err != nil {
+				return vars, err
+			}
+		}
+		if typ != miMATRIX {
+			continue
+		}
+		v, ok, err := d.matrix(data)
+		if err != nil {
+			return vars, fmt.Errorf("variable %d: %s", len(vars)+1, err)
+		}
+		if ok {
+			vars = append(vars, v)
+		}
+	}
+	return vars, nil
+}
+
+// Dims returns the number of rows and columns of the array, where the
+// columns include any higher dimensions.
+func (a Array) Dims() (rows, cols int) {
+	return a.rows, a.cols
+}
+
+// IsNumeric returns whether the array is a numeric array.
+func (a Array) IsNumeric() bool {
+	return a.class == mxDOUBLE
+}
+
+// IsChar returns whether the array is a character array.
+func (a Array) IsChar() bool {
+	return a.class == mxCHAR
+}
+
+// IsStruct returns whether the array is a struct.
+func (a Array) IsStruct() bool {
+	return a.class == mxSTRUCT
+}
+
+// IsComplex returns whether the array is a complex numeric array.
+func (a Array) IsComplex() bool {
+	return a.imag != nil
+}
+
+// Real returns the real parts of the elements of a numeric array in column
+// major order.
+func (a Array) Real() []float64 {
+	return a.values
+}
+
+// Complex returns the elements of a numeric array in column major order.
+// The imaginary parts are zero if the array is real.
+func (a Array) Complex() []complex128 {
+	values := make([]complex128, len(a.values))
+	for i, v := range a.values {
+		values[i] = complex(v, 0)
+		if a.imag != nil {
+			values[i] = complex(v, a.imag[i])
+		}
+	}
+	return values
+}
+
+// Text returns the text of a character array, with the rows separated by
+// newlines.
+func (a Array) Text() string {
+	return a.text
+}
+
+// Fields returns the fields of a struct.
+func (a Array) Fields() []Variable {
+	return a.fields
+}
+
+// Field returns the field of a struct with the given name.
+func (a Array) Field(name string) (Array, bool) {
+	for _, f := range a.fields {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return Array{}, false
+}
+
+// Lookup returns the variable with the given name.
+func Lookup(vars []Variable, name string) (Array, bool) {
+	for _, v := range vars {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	return Array{}, false
+}
+
+// decoder decodes the data elements of a MAT-file with the given byte
+// order.
+type decoder struct {
+	order binary.ByteOrder
+}
+
+// element returns the type and data of the data element at the start of b
+// along with the remaining bytes.
+func (d decoder) element(b []byte) (uint32, []byte, []byte, error) {
+	if len(b) < 8 {
+		return 0, nil, nil, errTruncated
+	}
+	tag := d.order.Uint32(b)
+	if n := tag >> 16; n != 0 {
+		// Small data element format.
+		if n > 4 {
+			return 0, nil, nil, fmt.Errorf("invalid small data element size: %d", n)
+		}
+		return tag & 0xFFFF, b[4 : 4+n], b[8:], nil
+	}
+	n := uint64(d.order.Uint32(b[4:]))
+	if n > uint64(len(b)-8) {
+		return 0, nil, nil, errTruncated
+	}
+	size := 8 + int(n)
+	data := b[8:size]
+	// Compressed data elements aren't padded.
+	if r := size % 8; r != 0 && tag != miCOMPRESSED {
+		size += 8 - r
+	}
+	return tag, data, b[min(size, len(b)):], nil
+}
+
+// elements returns the type and data of each data element in b.
+func (d decoder) elements(b []byte) ([]uint32, [][]byte, error) {
+	var types []uint32
+	var data [][]byte
+	for len(b) > 0 {
+		typ, e, rest, err := d.element(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		types = append(types, typ)
+		data = append(data, e)
+		b = rest
+	}
+	return types, data, nil
+}
+
+// maxInflatedSize limits the size of a decompressed variable so that a
+// small corrupt or malicious file can't decompress into gigabytes.
+const maxInflatedSize = 1 << 30
+
+// inflate returns the type and data of the data element compressed in the
+// data of a miCOMPRESSED data element.
+func (d decoder) inflate(data []byte) (uint32, []byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, fmt.Errorf("error decompressing variable: %s", err)
+	}
+	defer zr.Close()
+	b, err := io.ReadAll(io.LimitReader(zr, maxInflatedSize+1))
+	if err != nil {
+		return 0, nil, fmt.Errorf("error decompressing variable: %s", err)
+	}
+	if len(b) > maxInflatedSize {
+		return 0, nil, fmt.Errorf("compressed variable is larger than %d bytes", maxInflatedSize)
+	}
+	typ, e, _, err := d.element(b)
+	return typ, e, err
+}
+
+// matrix decodes the data of a miMATRIX data element, returning false if
+// the class of the array isn't supported.
+func (d decoder) matrix(data []byte) (Variable, bool, error) {
+	if len(data) == 0 {
+		// Empty arrays may be written without any subelements.
+		return Variable{Value: Column(nil)}, true, nil
+	}
+	types, elements, err := d.elements(data)
+	if err != nil {
+		return Variable{}, false, err
+	}
+	if len(elements) < 3 || len(elements[0]) < 8 || len(elements[1]) < 8 || len(elements[1])%4 != 0 {
+		return Variable{}, false, errors.New("missing array flags, dimensions, or name")
+	}
+	flags := d.order.Uint32(elements[0])
+	class := int(flags & 0xFF)
+	dims, err := d.numbers(types[1], elements[1])
+	if err != nil {
+		return Variable{}, false, err
+	}
+	rows, cols := int(dims[0]), 1
+	for _, n := range dims[1:] {
+		cols *= int(n)
+	}
+	v := Variable{Name: string(elements[2])}
+	elements = elements[3:]
+	types = types[3:]
+	switch {
+	case class == mxDOUBLE || class >= mxSINGLE && class <= mxUINT64:
+		if len(elements) < 1 {
+			return v, false, errors.New("missing real part")
+		}
+		re, err := d.numbers(types[0], elements[0])
+		if err != nil {
+			return v, false, err
+		}
+		if len(re) != rows*cols {
+			return v, false, fmt.Errorf("got %d elements for %d-by-%d array", len(re), rows, cols)
+		}
+		v.Value = Column(re)
+		if flags&complexFlag != 0 {
+			if len(elements) < 2 {
+				return v, false, errors.New("missing imaginary part")
+			}
+			im, err := d.numbers(types[1], elements[1])
+			if err != nil {
+				return v, false, err
+			}
+			if len(im) != len(re) {
+				return v, false, errors.New("mismatched real and imaginary parts")
+			}
+			v.Value = ComplexColumn(complexes(re, im))
+		}
+	case class == mxCHAR:
+		if len(elements) < 1 {
+			v.Value = String("")
+			break
+		}
+		units, err := d.chars(types[0], elements[0])
+		if err != nil {
+			return v, false, err
+		}
+		v.Value = String(string(units))
+		v.Value.text = charRows(units, rows)
+	case class == mxSTRUCT:
+		if rows*cols != 1 {
+			return v, false, nil
+		}
+		if len(elements) < 2 || len(elements[0]) < 4 {
+			return v, false, errors.New("missing field names")
+		}
+		length := int(d.order.Uint32(elements[0]))
+		names := elements[1]
+		if length == 0 || len(names)%length != 0 || len(elements)-2 < len(names)/length {
+			return v, false, errors.New("invalid field names")
+		}
+		var fields []Variable
+		for i := 0; i < len(names)/length; i++ {
+			name := string(bytes.TrimRight(names[i*length:(i+1)*length], "\x00"))
+			if types[i+2] != miMATRIX {
+				return v, false, fmt.Errorf("field %s isn't an array", name)
+			}
+			f, ok, err := d.matrix(elements[i+2])
+			if err != nil {
+				return v, false, fmt.Errorf("field %s: %s", name, err)
+			}
+			if ok {
+				f.Name = name
+				fields = append(fields, f)
+			}
+		}
+		v.Value = Struct(fields...)
+	default:
+		return v, false, nil
+	}
+	v.Value.rows, v.Value.cols = rows, cols
+	return v, true, nil
+}
+
+// numbers decodes the numeric data of a data element as float64 values.
+func (d decoder) numbers(typ uint32, b []byte) ([]float64, error) {
+	size := map[uint32]int{
+		miINT8: 1, miUINT8: 1, miINT16: 2, miUINT16: 2, miINT32: 4,
+		miUINT32: 4, miSINGLE: 4, miDOUBLE: 8, miINT64: 8, miUINT64: 8,
+	}[typ]
+	if size == 0 {
+		return nil, fmt.Errorf("unsupported numeric data type: %d", typ)
+	}
+	if len(b)%size != 0 {
+		return nil, fmt.Errorf("invalid data length %d for data type %d", len(b), typ)
+	}
+	values := make([]float64, len(b)/size)
+	for i := range values {
+		p := b[i*size:]
+		switch typ {
+		case miINT8:
+			values[i] = float64(int8(p[0]))
+		case miUINT8:
+			values[i] = float64(p[0])
+		case miINT16:
+			values[i] = float64(int16(d.order.Uint16(p)))
+		case miUINT16:
+			values[i] = float64(d.order.Uint16(p))
+		case miINT32:
+			values[i] = float64(int32(d.order.Uint32(p)))
+		case miUINT32:
+			values[i] = float64(d.order.Uint32(p))
+		case miSINGLE:
+			values[i] = float64(math.Float32frombits(d.order.Uint32(p)))
+		case miDOUBLE:
+			values[i] = math.Float64frombits(d.order.Uint64(p))
+		case miINT64:
+			values[i] = float64(int64(d.order.Uint64(p)))
+		case miUINT64:
+			values[i] = float64(d.order.Uint64(p))
+		}
+	}
+	return values, nil
+}
+
+// chars decodes the character data of a data element as runes.
+func (d decoder) chars(typ uint32, b []byte) ([]rune, error) {
+	switch typ {
+	case miUTF8:
+		return []rune(string(b)), nil
+	case miINT8, miUINT8:
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return runes, nil
+	case miUTF16, miUINT16:
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			units[i] = d.order.Uint16(b[2*i:])
+		}
+		return utf16.Decode(units), nil
+	case miUTF32, miINT32, miUINT32:
+		runes := make([]rune, len(b)/4)
+		for i := range runes {
+			runes[i] = rune(d.order.Uint32(b[4*i:]))
+		}
+		return runes, nil
+	}
+	return nil, fmt.Errorf("unsupported character data type: %d", typ)
+}
+
+// charRows returns the text of a character array stored in column major
+// order with the rows separated by newlines. Trailing spaces, which pad the
+// rows to the same length, are removed.
+func charRows(chars []rune, rows int) string {
+	if rows <= 1 || rows > len(chars) {
+		return string(chars)
+	}
+	cols := len(chars) / rows
+	lines := make([]string, rows)
+	for i := range lines {
+		line := make([]rune, cols)
+		for j := range line {
+			line[j] = chars[i+j*rows]
+		}
+		lines[i] = strings.TrimRight(string(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// complexes returns the complex values with the given real and imaginary
+// parts.
+func complexes(re, im []float64) []complex128 {
+	values := make([]complex128, len(re))
+	for i := range re {
+		values[i] = complex(re[i], im[i])
+	}
+	return values
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package mat
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestRead(t *testing.T) {
+	vars := []Variable{
+		{"frequency", Column([]float64{1e6, 2e6, 3e6})},
+		{"iq", ComplexColumn([]complex128{1 + 2i, -3 - 4i})},
+		{"metadata", Struct(
+			Variable{"model", String("N9030A")},
+			Variable{"rbw", Scalar(1000)},
+			Variable{"title", String("")},
+		)},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, vars); err != nil {
+		t.Fatalf("error writing MAT-file: %s", err)
+	}
+	plain := buf.Bytes()
+
+	// Compress each variable the way MATLAB does by default.
+	compressed := append([]byte(nil), plain[:128]...)
+	for _, e := range readElements(t, plain[128:]) {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(element(e.typ, e.data))
+		zw.Close()
+		compressed = binary.LittleEndian.AppendUint32(compressed, miCOMPRESSED)
+		compressed = binary.LittleEndian.AppendUint32(compressed, uint32(z.Len()))
+		compressed = append(compressed, z.Bytes()...)
+	}
+
+	for name, b := range map[string][]byte{"plain": plain, "compressed": compressed} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Read(bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("error reading MAT-file: %s", err)
+			}
+			assert(t, "num variables", len(got), 3)
+			freq, ok := Lookup(got, "frequency")
+			assert(t, "frequency found", ok, true)
+			rows, cols := freq.Dims()
+			assert(t, "frequency rows", rows, 3)
+			assert(t, "frequency cols", cols, 1)
+			assert(t, "frequency numeric", freq.IsNumeric(), true)
+			assert(t, "frequency complex", freq.IsComplex(), false)
+			assert(t, "frequency[2]", freq.Real()[2], 3e6)
+			iq, _ := Lookup(got, "iq")
+			assert(t, "iq complex", iq.IsComplex(), true)
+			assert(t, "iq[1]", iq.Complex()[1], -3-4i)
+			meta, _ := Lookup(got, "metadata")
+			assert(t, "metadata struct", meta.IsStruct(), true)
+			assert(t, "num fields", len(meta.Fields()), 3)
+			model, _ := meta.Field("model")
+			assert(t, "model char", model.IsChar(), true)
+			assert(t, "model", model.Text(), "N9030A")
+			rbw, _ := meta.Field("rbw")
+			assert(t, "rbw", rbw.Real()[0], 1000.0)
+			title, _ := meta.Field("title")
+			assert(t, "title", title.Text(), "")
+			_, ok = meta.Field("span")
+			assert(t, "missing field", ok, false)
+
+			// The variables read can be written again.
+			var again bytes.Buffer
+			if err := Write(&again, got); err != nil {
+				t.Fatalf("error writing variables read: %s", err)
+			}
+			assert(t, "rewritten", bytes.Equal(again.Bytes(), plain), true)
+		})
+	}
+}
+
+func TestReadBigEndian(t *testing.T) {
+	be := binary.BigEndian
+	b := make([]byte, 128)
+	copy(b, headerText)
+	be.PutUint16(b[124:], 0x0100)
+	copy(b[126:], "MI")
+	// An int16 matrix named "n" containing 258 and -2, followed by a 2-by-2
+	// character array using 8 bit characters.
+	n := be.AppendUint32(nil, miUINT32)
+	n = be.AppendUint32(n, 8)
+	n = be.AppendUint32(n, 10)
+	n = be.AppendUint32(n, 0)
+	n = be.AppendUint32(n, miINT32)
+	n = be.AppendUint32(n, 8)
+	n = be.AppendUint32(n, 1)
+	n = be.AppendUint32(n, 2)
+	n = be.AppendUint32(n, miINT8|1<<16)
+	n = append(n, 'n', 0, 0, 0)
+	n = be.AppendUint32(n, miINT16|4<<16)
+	n = be.AppendUint16(n, 258)
+	n = be.AppendUint16(n, 0xFFFE)
+	b = be.AppendUint32(b, miMATRIX)
+	b = be.AppendUint32(b, uint32(len(n)))
+	b = append(b, n...)
+	c := be.AppendUint32(nil, miUINT32)
+	c = be.AppendUint32(c, 8)
+	c = be.AppendUint32(c, mxCHAR)
+	c = be.AppendUint32(c, 0)
+	c = be.AppendUint32(c, miINT32)
+	c = be.AppendUint32(c, 8)
+	c = be.AppendUint32(c, 2)
+	c = be.AppendUint32(c, 2)
+	c = be.AppendUint32(c, miINT8|1<<16)
+	c = append(c, 's', 0, 0, 0)
+	c = be.AppendUint32(c, miUTF8|4<<16)
+	c = append(c, "aczd"...)
+	b = be.AppendUint32(b, miMATRIX)
+	b = be.AppendUint32(b, uint32(len(c)))
+	b = append(b, c...)
+
+	got, err := Read(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("error reading MAT-file: %s", err)
+	}
+	assert(t, "num variables", len(got), 2)
+	assert(t, "name", got[0].Name, "n")
+	rows, cols := got[0].Value.Dims()
+	assert(t, "rows", rows, 1)
+	assert(t, "cols", cols, 2)
+	assert(t, "n[0]", got[0].Value.Real()[0], 258.0)
+	assert(t, "n[1]", got[0].Value.Real()[1], -2.0)
+	assert(t, "text", got[1].Value.Text(), "az\ncd")
+}
+
+func TestReadErrors(t *testing.T) {
+	header := make([]byte, 128)
+	copy(header, headerText)
+	binary.LittleEndian.PutUint16(header[124:], 0x0100)
+	copy(header[126:], "IM")
+	v73 := append([]byte(nil), header...)
+	binary.LittleEndian.PutUint16(v73[124:], 0x0200)
+	var tests = []struct {
+		name string
+		data []byte
+	}{
+		{"short", []byte("MATLAB 5.0 MAT-file")},
+		{"endian", append(append([]byte(nil), header[:126]...), 'X', 'X')},
+		{"version 7.3", v73},
+		{"truncated", append(append([]byte(nil), header...), 14, 0, 0, 0, 64, 0, 0, 0)},
+		{"bad compression", append(append([]byte(nil), header...), 15, 0, 0, 0, 2, 0, 0, 0, 1, 2)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(test.data)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	fuzztest.AddSeeds(f, "../../vsa/testdata/*.mat")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Read(bytes.NewReader(data))
+	})
+}
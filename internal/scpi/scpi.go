@@ -0,0 +1,151 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package scpi sends SCPI commands and queries to an instrument over an
+// io.ReadWriter, such as a VISA, USBTMC, or VXI-11 connection.
+package scpi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/ident"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+// Conn is a connection to an instrument. Commands are terminated by a
+// newline, and responses are read up to a newline.
+type Conn struct {
+	w io.Writer
+	r *bufio.Reader
+}
+
+// New returns a connection using the given io.ReadWriter.
+func New(rw io.ReadWriter) *Conn {
+	return &Conn{w: rw, r: bufio.NewReader(rw)}
+}
+
+// Command sends the formatted command.
+func (c *Conn) Command(format string, a ...interface{}) error {
+	cmd := fmt.Sprintf(format, a...)
+	if _, err := io.WriteString(c.w, cmd+"\n"); err != nil {
+		return fmt.Errorf("error sending %s: %s", cmd, err)
+	}
+	return nil
+}
+
+// Query sends the query and returns the response without the terminating
+// newline or surrounding whitespace.
+func (c *Conn) Query(query string) (string, error) {
+	if err := c.Command("%s", query); err != nil {
+		return "", err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("error reading response to %s: %s", query, err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// QueryFloat sends the query and parses the response as a float64.
+func (c *Conn) QueryFloat(query string) (float64, error) {
+	s, err := c.Query(query)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing response to %s: %s", query, err)
+	}
+	return v, nil
+}
+
+// QueryInt sends the query and parses the response as an int. Responses
+// written in floating point notation, such as 4.01E+02, are accepted if the
+// value is a whole number.
+func (c *Conn) QueryInt(query string) (int, error) {
+	v, err := c.QueryFloat(query)
+	if err != nil {
+		return 0, err
+	}
+	if v != float64(int(v)) {
+		return 0, fmt.Errorf("response to %s isn't an integer: %g", query, v)
+	}
+	return int(v), nil
+}
+
+// QueryFloats sends the query and parses the response as comma separated
+// float64 values.
+func (c *Conn) QueryFloats(query string) ([]float64, error) {
+	s, err := c.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		if values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+			return nil, fmt.Errorf("error parsing value %d of response to %s: %s", i, query, err)
+		}
+	}
+	return values, nil
+}
+
+// QueryString sends the query and returns the response without the
+// surrounding double or single quotes of a SCPI string response.
+func (c *Conn) QueryString(query string) (string, error) {
+	s, err := c.Query(query)
+	if err != nil {
+		return "", err
+	}
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	}
+	return s, nil
+}
+
+// QueryBlock sends the query and returns the data of the IEEE 488.2 binary
+// block response, such as #3012 followed by 12 bytes of data. An indefinite
+// length block, starting with #0, extends to the terminating newline.
+func (c *Conn) QueryBlock(query string) ([]byte, error) {
+	if err := c.Command("%s", query); err != nil {
+		return nil, err
+	}
+	data, err := c.readBlock()
+	if err != nil {
+		return nil, fmt.Errorf("error reading response to %s: %s", query, err)
+	}
+	return data, nil
+}
+
+func (c *Conn) readBlock() ([]byte, error) {
+	data, err := block.Read(c.r)
+	if err != nil {
+		return nil, err
+	}
+	// Discard the terminating newline following a definite length block.
+	if b, err := c.r.Peek(1); err == nil && b[0] == '\n' {
+		c.r.Discard(1)
+	}
+	return data, nil
+}
+
+// Identity is the response to the *IDN? query.
+type Identity = ident.Identity
+
+// Identify queries the instrument's identity using *IDN?.
+func (c *Conn) Identify() (Identity, error) {
+	s, err := c.Query("*IDN?")
+	if err != nil {
+		return Identity{}, err
+	}
+	return ident.Parse(s)
+}
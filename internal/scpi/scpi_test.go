@@ -0,0 +1,88 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scpi
+
+import (
+	"testing"
+
+	"github.com/gotmc/keysight/internal/scpitest"
+)
+
+func TestConn(t *testing.T) {
+	inst := scpitest.New(map[string]string{
+		"*IDN?":                "Hewlett-Packard, E4402B, US41192390, A.14.01",
+		":SWE:POIN?":           "+4.01000000E+002",
+		":FREQ:CENT?":          "+1.50000000000E+006",
+		":TRAC:DATA? TRACE1":   "-8.0E+01,-2.0E+01, -8.1E+01",
+		":DISP:ANN:TITL:DATA?": `"My title"`,
+		":BAD?":                "abc",
+	})
+	conn := New(inst)
+	id, err := conn.Identify()
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "identity", id, Identity{
+		Manufacturer: "Hewlett-Packard",
+		Model:        "E4402B",
+		SerialNum:    "US41192390",
+		Firmware:     "A.14.01",
+	})
+	n, err := conn.QueryInt(":SWE:POIN?")
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "points", n, 401)
+	f, err := conn.QueryFloat(":FREQ:CENT?")
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "center", f, 1.5e6)
+	values, err := conn.QueryFloats(":TRAC:DATA? TRACE1")
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num values", len(values), 3)
+	assert(t, "value 2", values[2], -81.0)
+	title, err := conn.QueryString(":DISP:ANN:TITL:DATA?")
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "title", title, "My title")
+	if err := conn.Command(":FREQ:SPAN %g", 50e3); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "last command", inst.Commands[len(inst.Commands)-1], ":FREQ:SPAN 50000")
+	inst.Responses[":BLOCK?"] = "#15hello"
+	inst.Responses[":INDEF?"] = "#0abc"
+	inst.Responses[":NOTBLOCK?"] = "1,2"
+	block, err := conn.QueryBlock(":BLOCK?")
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "block", string(block), "hello")
+	block, err = conn.QueryBlock(":INDEF?")
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "indefinite block", string(block), "abc")
+	if _, err := conn.QueryBlock(":NOTBLOCK?"); err == nil {
+		t.Errorf("expected error for response without block header")
+	}
+	conn = New(inst)
+	if _, err := conn.QueryFloat(":BAD?"); err == nil {
+		t.Errorf("expected error parsing bad response")
+	}
+	if _, err := conn.Query(":MISSING?"); err == nil {
+		t.Errorf("expected error for missing response")
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
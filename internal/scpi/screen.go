@@ -0,0 +1,44 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scpi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	// Register the image formats saved by the instruments.
+	_ "image/gif"
+	_ "image/png"
+
+	_ "github.com/gotmc/keysight/internal/bmp"
+)
+
+// Screen saves a capture of the instrument display to the named file in the
+// instrument's mass memory, transfers the file, deletes it, and decodes the
+// GIF, PNG, or BMP image. The instrument chooses the image format from the
+// file extension.
+func (c *Conn) Screen(filename string) (image.Image, error) {
+	if err := c.Command(":MMEM:STOR:SCR '%s'", filename); err != nil {
+		return nil, err
+	}
+	// Wait for the file to be written before reading it.
+	if _, err := c.Query("*OPC?"); err != nil {
+		return nil, err
+	}
+	data, err := c.QueryBlock(fmt.Sprintf(":MMEM:DATA? '%s'", filename))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Command(":MMEM:DEL '%s'", filename); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding screen capture %s: %s", filename, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scpi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/scpitest"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+func TestScreen(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(1, 0, color.RGBA{0xff, 0x80, 0, 0xff})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	inst := scpitest.New(map[string]string{
+		"*OPC?":                       "1",
+		`:MMEM:DATA? 'D:\SCREEN.PNG'`: string(block.Encode(buf.Bytes())),
+	})
+	conn := New(inst)
+	img, err := conn.Screen(`D:\SCREEN.PNG`)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "bounds", img.Bounds(), src.Bounds())
+	assert(t, "pixel", color.RGBAModel.Convert(img.At(1, 0)), color.Color(color.RGBA{0xff, 0x80, 0, 0xff}))
+	assert(t, "commands", fmt.Sprint(inst.Commands),
+		`[:MMEM:STOR:SCR 'D:\SCREEN.PNG' *OPC? :MMEM:DATA? 'D:\SCREEN.PNG' :MMEM:DEL 'D:\SCREEN.PNG']`)
+
+	inst.Responses[`:MMEM:DATA? 'D:\SCREEN.PNG'`] = string(block.Encode([]byte("not an image")))
+	if _, err := conn.Screen(`D:\SCREEN.PNG`); err == nil {
+		t.Errorf("expected error for invalid image")
+	}
+}
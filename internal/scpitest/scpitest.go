@@ -0,0 +1,104 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package scpitest contains a fake instrument shared by the tests of the
+// packages controlling instruments using SCPI.
+package scpitest
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Instrument is a fake instrument, which is an io.ReadWriter. It records
+// every command written to it, with each line of a write being a command
+// unless WithBinaryWrites is used, and responds to the queries using the
+// canned responses.
+type Instrument struct {
+	// Responses maps each query to its response.
+	Responses map[string]string
+	// Commands contains the commands written to the instrument in order.
+	Commands []string
+	queued   map[string][]string
+	binary   bool
+	settings bool
+	coerced  map[string]string
+	out      bytes.Buffer
+}
+
+// Option configures an Instrument.
+type Option func(*Instrument)
+
+// WithQueued sets responses that are returned in turn by the queries before
+// their response in Responses.
+func WithQueued(queued map[string][]string) Option {
+	return func(f *Instrument) {
+		f.queued = queued
+	}
+}
+
+// WithBinaryWrites records each write as a single command instead of
+// splitting it into lines, since binary blocks may contain newlines.
+func WithBinaryWrites() Option {
+	return func(f *Instrument) {
+		f.binary = true
+	}
+}
+
+// WithSettings makes the argument of each setting command without a canned
+// response the response of its query, as done by an instrument, so that
+// the setting can be read back. The instrument coerces the settings of the
+// commands in coerced to the given responses instead, such as a bandwidth
+// rounded to the nearest available value.
+func WithSettings(coerced map[string]string) Option {
+	return func(f *Instrument) {
+		f.settings = true
+		f.coerced = coerced
+	}
+}
+
+// New returns a fake instrument with the canned responses.
+func New(responses map[string]string, opts ...Option) *Instrument {
+	if responses == nil {
+		responses = make(map[string]string)
+	}
+	f := &Instrument{Responses: responses}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Write implements io.Writer.
+func (f *Instrument) Write(p []byte) (int, error) {
+	cmds := []string{strings.TrimSuffix(string(p), "\n")}
+	if !f.binary {
+		cmds = strings.Split(cmds[0], "\n")
+	}
+	for _, cmd := range cmds {
+		f.Commands = append(f.Commands, cmd)
+		if queue := f.queued[cmd]; len(queue) > 0 {
+			f.out.WriteString(queue[0] + "\n")
+			f.queued[cmd] = queue[1:]
+			continue
+		}
+		if resp, ok := f.Responses[cmd]; ok {
+			f.out.WriteString(resp + "\n")
+			continue
+		}
+		if name, arg, ok := strings.Cut(cmd, " "); f.settings && ok && !strings.HasSuffix(name, "?") {
+			if c, ok := f.coerced[name]; ok {
+				arg = c
+			}
+			f.Responses[name+"?"] = arg
+		}
+	}
+	return len(p), nil
+}
+
+// Read implements io.Reader.
+func (f *Instrument) Read(p []byte) (int, error) {
+	return f.out.Read(p)
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package iq
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/internal/dsp"
+)
+
+// DefaultImpedance is the reference impedance in ohms used to convert the
+// samples in volts to power.
+const DefaultImpedance = 50
+
+// defaultFFTSize is the largest FFT size used when neither the RBW nor the
+// FFT size is given.
+const defaultFFTSize = 1024
+
+// Window is the window function applied to each FFT segment.
+type Window int
+
+// Available windows.
+const (
+	// FlatTop has the best amplitude accuracy for tones, and is the default
+	// like the FFT mode of the X-Series.
+	FlatTop Window = iota
+	// Rectangular has the narrowest RBW for a given FFT size but the most
+	// scalloping and leakage.
+	Rectangular
+	Hann
+	Hamming
+	Blackman
+	// BlackmanHarris is the 4-term Blackman-Harris window, which has the
+	// lowest sidelobes.
+	BlackmanHarris
+)
+
+// windowCoefficients are the coefficients of the cosine terms of each
+// window.
+var windowCoefficients = map[Window][]float64{
+	FlatTop:        {0.21557895, 0.41663158, 0.277263158, 0.083578947, 0.006947368},
+	Rectangular:    {1},
+	Hann:           {0.5, 0.5},
+	Hamming:        {0.54, 0.46},
+	Blackman:       {0.42, 0.5, 0.08},
+	BlackmanHarris: {0.35875, 0.48829, 0.14128, 0.01168},
+}
+
+// String implements the Stringer interface for Window.
+func (w Window) String() string {
+	switch w {
+	case FlatTop:
+		return "Flat Top"
+	case Rectangular:
+		return "Rectangular"
+	case Hann:
+		return "Hann"
+	case Hamming:
+		return "Hamming"
+	case Blackman:
+		return "Blackman"
+	case BlackmanHarris:
+		return "Blackman-Harris"
+	}
+	return fmt.Sprintf("Window(%d)", int(w))
+}
+
+// Coefficients returns the n point periodic window.
+func (w Window) Coefficients(n int) []float64 {
+	return dsp.CosineWindow(windowCoefficients[w], n)
+}
+
+// NoiseBandwidth returns the equivalent noise bandwidth of the n point
+// window in FFT bins.
+func (w Window) NoiseBandwidth(n int) float64 {
+	return dsp.NoiseBandwidth(w.Coefficients(n))
+}
+
+// Option configures the spectrum and power versus time computations.
+type Option func(*dspConfig)
+
+type dspConfig struct {
+	window      Window
+	rbw         float64
+	fftSize     int
+	impedance   float64
+	averageTime float64
+}
+
+func newDSPConfig(opts []Option) dspConfig {
+	cfg := dspConfig{impedance: DefaultImpedance}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithWindow sets the window used by Spectrum. The default is FlatTop.
+func WithWindow(w Window) Option {
+	return func(cfg *dspConfig) {
+		cfg.window = w
+	}
+}
+
+// WithRBW sets the resolution bandwidth in Hz of Spectrum, which determines
+// the FFT size. Since the FFT size is a power of two, the RBW of the
+// spectrum is the nearest one not wider than requested.
+func WithRBW(rbw float64) Option {
+	return func(cfg *dspConfig) {
+		cfg.rbw = rbw
+	}
+}
+
+// WithFFTSize sets the FFT size of Spectrum, which must be a power of two.
+// It is ignored if the RBW is given.
+func WithFFTSize(n int) Option {
+	return func(cfg *dspConfig) {
+		cfg.fftSize = n
+	}
+}
+
+// WithImpedance sets the reference impedance in ohms. The default is
+// DefaultImpedance.
+func WithImpedance(ohms float64) Option {
+	return func(cfg *dspConfig) {
+		if ohms > 0 {
+			cfg.impedance = ohms
+		}
+	}
+}
+
+// WithAverageTime sets the time in seconds over which PowerVsTime averages
+// the power of consecutive samples. The default is a single sample.
+func WithAverageTime(seconds float64) Option {
+	return func(cfg *dspConfig) {
+		cfg.averageTime = seconds
+	}
+}
+
+// Spectrum returns the power spectrum of the capture in dBm as an esa.Trace,
+// so it can be used with the limit, plot, and export tooling. The capture is
+// split into segments of the FFT size overlapping by half, and the power of
+// the windowed segments is averaged. The power is calibrated so that a tone
+// reads its power, and noise reads the power within the RBW, which is the
+// noise bandwidth of the window, like a spectrum analyzer. The samples are
+// taken as peak volts, so a sample of amplitude A has a power of A²/2R.
+func Spectrum(c Capture, opts ...Option) (esa.Trace, error) {
+	cfg := newDSPConfig(opts)
+	if _, ok := windowCoefficients[cfg.window]; !ok {
+		return esa.Trace{}, fmt.Errorf("unknown window: %s", cfg.window)
+	}
+	if c.SampleRate <= 0 {
+		return esa.Trace{}, errors.New("missing sample rate")
+	}
+	n, err := fftSize(c, cfg)
+	if err != nil {
+		return esa.Trace{}, err
+	}
+	window := cfg.window.Coefficients(n)
+	sum := 0.0
+	for _, w := range window {
+		sum += w
+	}
+	// Scale the magnitude squared of each bin so a tone reads its power.
+	scale := 1000 / (2 * cfg.impedance * sum * sum)
+	power := make([]float64, n)
+	segment := make([]complex128, n)
+	numSegments := 0
+	for start := 0; start+n <= len(c.Samples); start += n / 2 {
+		for i, w := range window {
+			segment[i] = c.Samples[start+i] * complex(w, 0)
+		}
+		dsp.FFT(segment)
+		for i, v := range segment {
+			power[i] += real(v)*real(v) + imag(v)*imag(v)
+		}
+		numSegments++
+	}
+	binWidth := c.SampleRate / float64(n)
+	freqs := make([]float64, n)
+	values := make([]float64, n)
+	for i := range freqs {
+		// Reorder the bins from the most negative frequency.
+		k := (i + n/2) % n
+		freqs[i] = c.CenterFreq + float64(i-n/2)*binWidth
+		values[i] = 10 * math.Log10(power[k]*scale/float64(numSegments))
+	}
+	rbw := cfg.window.NoiseBandwidth(n) * binWidth
+	trace := newTrace(c, freqs, values)
+	trace.Span, trace.SpanUnits = freqs[n-1]-freqs[0], esa.Hertz
+	trace.RBW, trace.RBWUnits = rbw, esa.Hertz
+	trace.VBW, trace.VBWUnits = rbw, esa.Hertz
+	trace.FreqUnits = string(esa.Hertz)
+	return trace, nil
+}
+
+// PowerVsTime returns the power of the capture in dBm versus time as an
+// esa.Trace, like a zero span trace, where the Frequency column contains the
+// time in seconds of the start of each point. Each point is the mean power
+// of the samples within the average time.
+func PowerVsTime(c Capture, opts ...Option) (esa.Trace, error) {
+	cfg := newDSPConfig(opts)
+	if c.SampleRate <= 0 {
+		return esa.Trace{}, errors.New("missing sample rate")
+	}
+	if len(c.Samples) == 0 {
+		return esa.Trace{}, errors.New("capture has no samples")
+	}
+	m := max(1, int(math.Round(cfg.averageTime*c.SampleRate)))
+	if m > len(c.Samples) {
+		return esa.Trace{}, fmt.Errorf("average time %g s is longer than the capture", cfg.averageTime)
+	}
+	scale := 1000 / (2 * cfg.impedance)
+	times := make([]float64, 0, len(c.Samples)/m)
+	values := make([]float64, 0, len(c.Samples)/m)
+	for start := 0; start+m <= len(c.Samples); start += m {
+		sum := 0.0
+		for _, v := range c.Samples[start : start+m] {
+			a := cmplx.Abs(v)
+			sum += a * a
+		}
+		times = append(times, float64(start)/c.SampleRate)
+		values = append(values, 10*math.Log10(sum*scale/float64(m)))
+	}
+	trace := newTrace(c, times, values)
+	trace.SpanUnits = esa.Hertz
+	trace.RBW, trace.RBWUnits = c.SampleRate/float64(m), esa.Hertz
+	trace.VBW, trace.VBWUnits = trace.RBW, esa.Hertz
+	trace.FreqLabel = "Time"
+	trace.FreqUnits = string(esa.Seconds)
+	return trace, nil
+}
+
+// newTrace returns a trace with the settings of the capture and the given
+// points.
+func newTrace(c Capture, x, values []float64) esa.Trace {
+	trace := esa.Trace{
+		Timestamp:       c.Timestamp,
+		Model:           c.Model,
+		SerialNum:       c.SerialNum,
+		CenterFreq:      c.CenterFreq,
+		CenterFreqUnits: esa.Hertz,
+		RefLevelUnits:   esa.DBm,
+		SweepTime:       c.Duration(),
+		SweepTimeUnits:  esa.Seconds,
+		NumPoints:       len(x),
+		Frequency:       x,
+	}
+	trace.SetTraces([]esa.TraceData{{Label: "Trace 1", Units: string(esa.DBm), Values: values}})
+	return trace
+}
+
+// fftSize returns the FFT size given by the options, checking that the
+// capture is long enough.
+func fftSize(c Capture, cfg dspConfig) (int, error) {
+	n := cfg.fftSize
+	switch {
+	case cfg.rbw > 0:
+		// The noise bandwidth in bins barely depends on the size.
+		bins := cfg.window.NoiseBandwidth(defaultFFTSize)
+		n = max(2, 1<<bits.Len(uint(math.Ceil(bins*c.SampleRate/cfg.rbw))-1))
+	case n == 0:
+		n = defaultFFTSize
+		for n > len(c.Samples) {
+			n /= 2
+		}
+	}
+	if n < 2 || n&(n-1) != 0 {
+		return 0, fmt.Errorf("FFT size %d isn't a power of two", n)
+	}
+	if n > len(c.Samples) {
+		return 0, fmt.Errorf("capture has %d samples / FFT size %d", len(c.Samples), n)
+	}
+	return n, nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package iq
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// tone returns a capture of a tone with the given amplitude in volts at the
+// given offset from the center frequency.
+func tone(n int, amplitude, offset float64) Capture {
+	c := Capture{CenterFreq: 1e9, SampleRate: 1e6, Samples: make([]complex128, n)}
+	for i := range c.Samples {
+		c.Samples[i] = complex(amplitude, 0) * cmplx.Exp(complex(0, 2*math.Pi*offset*float64(i)/c.SampleRate))
+	}
+	return c
+}
+
+func TestWindow(t *testing.T) {
+	var tests = []struct {
+		window Window
+		name   string
+		nbw    float64
+	}{
+		{Rectangular, "Rectangular", 1},
+		{Hann, "Hann", 1.5},
+		{Hamming, "Hamming", 1.3628},
+		{Blackman, "Blackman", 1.7268},
+		{BlackmanHarris, "Blackman-Harris", 2.0044},
+		{FlatTop, "Flat Top", 3.7702},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert(t, "name", test.window.String(), test.name)
+			assertFloat64(t, "noise bandwidth", test.window.NoiseBandwidth(1024), test.nbw, 0.0001)
+		})
+	}
+	assert(t, "unknown", Window(42).String(), "Window(42)")
+}
+
+func TestSpectrum(t *testing.T) {
+	// A 0.1 V tone is -10 dBm into 50 ohms.
+	c := tone(4096, 0.1, 62500)
+	c.Model = "N9030A"
+	for _, w := range []Window{FlatTop, Rectangular, Hann, BlackmanHarris} {
+		t.Run(w.String(), func(t *testing.T) {
+			trace, err := Spectrum(c, WithWindow(w))
+			if err != nil {
+				t.Fatalf("error computing spectrum: %s", err)
+			}
+			assert(t, "num points", trace.NumPoints, 1024)
+			assert(t, "freq len", len(trace.Frequency), 1024)
+			assertFloat64(t, "first freq", trace.Frequency[0], 1e9-500e3, 1e-6)
+			assertFloat64(t, "center bin", trace.Frequency[512], 1e9, 1e-6)
+			assertFloat64(t, "span", trace.Span, 1e6-1e6/1024, 1e-6)
+			assertFloat64(t, "rbw", trace.RBW, w.NoiseBandwidth(1024)*1e6/1024, 1e-6)
+			assert(t, "units", trace.Trace1Units, "dBm")
+			assert(t, "model", trace.Model, "N9030A")
+			peak := 0
+			for i, v := range trace.Trace1 {
+				if v > trace.Trace1[peak] {
+					peak = i
+				}
+			}
+			assertFloat64(t, "peak freq", trace.Frequency[peak], 1e9+62500, 1e-6)
+			assertFloat64(t, "peak power", trace.Trace1[peak], -10, 1e-9)
+		})
+	}
+
+	// Between bins only the flat top window keeps the amplitude accurate.
+	offBin := tone(4096, 0.1, 62500+1e6/2048)
+	trace, err := Spectrum(offBin)
+	if err != nil {
+		t.Fatalf("error computing spectrum: %s", err)
+	}
+	maxPower := math.Inf(-1)
+	for _, v := range trace.Trace1 {
+		maxPower = math.Max(maxPower, v)
+	}
+	assertFloat64(t, "off bin flat top peak", maxPower, -10, 0.01)
+}
+
+func TestSpectrumNoise(t *testing.T) {
+	// Complex Gaussian noise with a total power of -10 dBm reads the power
+	// within the RBW.
+	rng := rand.New(rand.NewSource(1))
+	c := Capture{SampleRate: 1e6, Samples: make([]complex128, 1<<16)}
+	sigma := 0.1 / math.Sqrt2
+	for i := range c.Samples {
+		c.Samples[i] = complex(rng.NormFloat64()*sigma, rng.NormFloat64()*sigma)
+	}
+	trace, err := Spectrum(c, WithRBW(1000), WithWindow(Hann))
+	if err != nil {
+		t.Fatalf("error computing spectrum: %s", err)
+	}
+	assert(t, "fft size", trace.NumPoints, 2048)
+	assertFloat64(t, "rbw", trace.RBW, 1.5*1e6/2048, 1e-6)
+	mean := 0.0
+	for _, v := range trace.Trace1 {
+		mean += math.Pow(10, v/10)
+	}
+	mean = 10 * math.Log10(mean/float64(len(trace.Trace1)))
+	assertFloat64(t, "noise level", mean, -10+10*math.Log10(trace.RBW/1e6), 0.1)
+}
+
+func TestSpectrumErrors(t *testing.T) {
+	c := tone(100, 0.1, 0)
+	var tests = []struct {
+		name    string
+		capture Capture
+		opts    []Option
+	}{
+		{"unknown window", c, []Option{WithWindow(Window(42))}},
+		{"missing sample rate", Capture{Samples: c.Samples}, nil},
+		{"not power of two", c, []Option{WithFFTSize(48)}},
+		{"too short", c, []Option{WithFFTSize(128)}},
+		{"rbw too narrow", c, []Option{WithRBW(1000)}},
+		{"no samples", Capture{SampleRate: 1e6}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Spectrum(test.capture, test.opts...); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestPowerVsTime(t *testing.T) {
+	c := tone(1000, 0.1, 1000)
+	trace, err := PowerVsTime(c)
+	if err != nil {
+		t.Fatalf("error computing power vs time: %s", err)
+	}
+	assert(t, "num points", trace.NumPoints, 1000)
+	assert(t, "label", trace.FreqLabel, "Time")
+	assert(t, "units", trace.FreqUnits, "s")
+	assertFloat64(t, "span", trace.Span, 0, 1e-12)
+	assertFloat64(t, "sweep time", trace.SweepTime, 1e-3, 1e-12)
+	assertFloat64(t, "time[3]", trace.Frequency[3], 3e-6, 1e-12)
+	assertFloat64(t, "power[3]", trace.Trace1[3], -10, 1e-9)
+
+	// Halve the amplitude of the second half to step the power by 6 dB.
+	for i := 500; i < len(c.Samples); i++ {
+		c.Samples[i] /= 2
+	}
+	trace, err = PowerVsTime(c, WithAverageTime(100e-6), WithImpedance(25))
+	if err != nil {
+		t.Fatalf("error computing averaged power vs time: %s", err)
+	}
+	assert(t, "averaged points", trace.NumPoints, 10)
+	assertFloat64(t, "averaged time[5]", trace.Frequency[5], 500e-6, 1e-12)
+	assertFloat64(t, "averaged power[4]", trace.Trace1[4], -10+10*math.Log10(2), 1e-9)
+	assertFloat64(t, "averaged power[5]", trace.Trace1[5], -10+10*math.Log10(2)-20*math.Log10(2), 1e-9)
+	assertFloat64(t, "rbw", trace.RBW, 10e3, 1e-9)
+
+	if _, err := PowerVsTime(c, WithAverageTime(1)); err == nil {
+		t.Errorf("expected error for average time longer than the capture")
+	}
+	if _, err := PowerVsTime(Capture{SampleRate: 1e6}); err == nil {
+		t.Errorf("expected error for empty capture")
+	}
+}
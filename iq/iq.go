@@ -0,0 +1,345 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package iq reads the IQ captures saved by the IQ Analyzer (Basic) mode of
+// the Keysight X-Series signal analyzers, such as the N9020A MXA and N9030A
+// PXA. A capture has a header of label/value/units lines, like an X-Series
+// trace, terminated by a DATA line and followed by the interleaved I and Q
+// samples, either as text with one sample per line or as binary data.
+// Spectrum and PowerVsTime compute the power spectrum and the power versus
+// time of a capture as an esa.Trace for use with the rest of the tooling.
+package iq
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// Capture contains the settings and samples of an IQ capture. Frequencies
+// are in Hz and times in seconds.
+type Capture struct {
+	Timestamp  time.Time
+	Model      string
+	SerialNum  string
+	Mode       string
+	CenterFreq float64
+	SampleRate float64
+	// Bandwidth is the information bandwidth of the capture, or zero if
+	// unknown.
+	Bandwidth float64
+	// Units are the units of the samples, which are usually V.
+	Units   string
+	Samples []complex128
+	// Header contains the values for every header line keyed by the header
+	// label, including those that are also parsed into the fields above.
+	Header map[string][]string
+}
+
+// Duration returns the length of the capture.
+func (c Capture) Duration() float64 {
+	if c.SampleRate == 0 {
+		return 0
+	}
+	return float64(len(c.Samples)) / c.SampleRate
+}
+
+// Times returns the time of each sample relative to the first.
+func (c Capture) Times() []float64 {
+	times := make([]float64, len(c.Samples))
+	for i := range times {
+		times[i] = float64(i) / c.SampleRate
+	}
+	return times
+}
+
+// MarshalJSON implements the json.Marshaler interface. JSON doesn't have
+// complex numbers, so each sample is encoded as an [I, Q] pair.
+func (c Capture) MarshalJSON() ([]byte, error) {
+	type capture Capture
+	v := struct {
+		capture
+		Samples [][2]float64
+	}{capture: capture(c)}
+	if c.Samples != nil {
+		v.Samples = make([][2]float64, len(c.Samples))
+		for i, s := range c.Samples {
+			v.Samples[i] = [2]float64{real(s), imag(s)}
+		}
+	}
+	return json.Marshal(v)
+}
+
+// dataMarker is the line separating the header from the samples.
+const dataMarker = "DATA"
+
+var frequencyMultipliers = map[string]float64{
+	"":    1,
+	"hz":  1,
+	"khz": 1e3,
+	"mhz": 1e6,
+	"ghz": 1e9,
+}
+
+var dateLayouts = []string{
+	"01/02/2006",
+	"2006-01-02",
+	"02 Jan 2006",
+}
+
+// ReadCSVFile reads the IQ capture saved in CSV format.
+func ReadCSVFile(filename string) (Capture, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Capture{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads the IQ capture in CSV format from the given io.Reader. Each
+// line after the DATA line contains the I and Q values of a sample.
+func ReadCSV(r io.Reader) (Capture, error) {
+	br := bufio.NewReaderSize(r, maxLineLength)
+	capture, lineNum, err := readHeader(br)
+	if err != nil {
+		return capture, err
+	}
+	scanner := bufio.NewScanner(br)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		columns := csvrow.Columns(line)
+		if len(columns) != 2 {
+			return capture, fmt.Errorf("expected I and Q columns in line %d / got %d columns", lineNum, len(columns))
+		}
+		var iq [2]float64
+		for i, col := range columns {
+			if iq[i], err = strconv.ParseFloat(col, 64); err != nil {
+				return capture, fmt.Errorf("error parsing sample %s in line %d", col, lineNum)
+			}
+		}
+		capture.Samples = append(capture.Samples, complex(iq[0], iq[1]))
+	}
+	if err := scanner.Err(); err != nil {
+		return capture, err
+	}
+	return capture, capture.checkPoints()
+}
+
+// ReadBinaryFile reads the IQ capture saved in binary format.
+func ReadBinaryFile(filename string) (Capture, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Capture{}, err
+	}
+	defer file.Close()
+	return ReadBinary(file)
+}
+
+// ReadBinary reads the IQ capture in binary format from the given
+// io.Reader. The bytes after the DATA line are the interleaved I and Q
+// values. Their format is given by the Data Format header line, which is
+// REAL,32 or REAL,64 like the FORMat:DATA command, and the byte order by the
+// Byte Order header line, which is NORMal for big-endian or SWAPped for
+// little-endian like the FORMat:BORDer command. The defaults are REAL,32 and
+// NORMal.
+func ReadBinary(r io.Reader) (Capture, error) {
+	br := bufio.NewReaderSize(r, maxLineLength)
+	capture, _, err := readHeader(br)
+	if err != nil {
+		return capture, err
+	}
+	size := 4
+	if format := capture.Header["Data Format"]; len(format) > 0 {
+		if !strings.EqualFold(format[0], "REAL") || len(format) < 2 || (format[1] != "32" && format[1] != "64") {
+			return capture, fmt.Errorf("unsupported data format: %s", strings.Join(format, ","))
+		}
+		size = 8
+		if format[1] == "32" {
+			size = 4
+		}
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if bord := capture.Header["Byte Order"]; len(bord) > 0 {
+		switch strings.ToUpper(bord[0]) {
+		case "NORM", "NORMAL":
+		case "SWAP", "SWAPPED":
+			order = binary.LittleEndian
+		default:
+			return capture, fmt.Errorf("unsupported byte order: %s", bord[0])
+		}
+	}
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return capture, err
+	}
+	// Ignore the line ending the X-Series writes after the data.
+	if r := len(data) % (2 * size); r > 0 && r <= 2 && strings.TrimSpace(string(data[len(data)-r:])) == "" {
+		data = data[:len(data)-r]
+	}
+	if len(data)%(2*size) != 0 {
+		return capture, fmt.Errorf("data length %d isn't a multiple of the sample size %d", len(data), 2*size)
+	}
+	value := func(b []byte) float64 {
+		if size == 4 {
+			return float64(math.Float32frombits(order.Uint32(b)))
+		}
+		return math.Float64frombits(order.Uint64(b))
+	}
+	capture.Samples = make([]complex128, len(data)/(2*size))
+	for i := range capture.Samples {
+		b := data[2*size*i:]
+		capture.Samples[i] = complex(value(b), value(b[size:]))
+	}
+	return capture, capture.checkPoints()
+}
+
+// maxLineLength limits the length of a header line, which is the same as
+// the limit of the data lines read using a bufio.Scanner, so that the header
+// of a corrupt binary file isn't read into a huge line.
+const maxLineLength = bufio.MaxScanTokenSize
+
+// readHeader reads the header lines up to and including the DATA line,
+// returning the number of lines read.
+func readHeader(br *bufio.Reader) (Capture, int, error) {
+	capture := Capture{Header: make(map[string][]string)}
+	var date, clock string
+	lineNum := 0
+	for {
+		b, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			return capture, lineNum, fmt.Errorf("line %d is longer than %d bytes", lineNum+1, maxLineLength)
+		}
+		s := string(b)
+		if err != nil && (err != io.EOF || s == "") {
+			if err == io.EOF {
+				return capture, lineNum, fmt.Errorf("missing %s line", dataMarker)
+			}
+			return capture, lineNum, err
+		}
+		lineNum++
+		line := strings.TrimSpace(s)
+		if line == "" {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSuffix(line, ","), dataMarker) {
+			break
+		}
+		columns := csvrow.Columns(line)
+		label := columns[0]
+		values := columns[1:]
+		capture.Header[label] = append(capture.Header[label], values...)
+		if err := capture.parseHeader(label, values, &date, &clock); err != nil {
+			return capture, lineNum, fmt.Errorf("error in header line %d (%s): %s", lineNum, label, err)
+		}
+	}
+	if date != "" {
+		ts, err := parseTimestamp(date, clock)
+		if err != nil {
+			return capture, lineNum, fmt.Errorf("error parsing timestamp: %s", err)
+		}
+		capture.Timestamp = ts
+	}
+	if capture.SampleRate <= 0 {
+		return capture, lineNum, errors.New("missing sample rate")
+	}
+	return capture, lineNum, nil
+}
+
+// parseHeader parses the known header labels into the capture fields.
+func (c *Capture) parseHeader(label string, values []string, date, clock *string) error {
+	value := ""
+	units := ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	if len(values) > 1 {
+		units = values[1]
+	}
+	var err error
+	switch strings.ToLower(label) {
+	case "model":
+		c.Model = value
+	case "serial number":
+		c.SerialNum = value
+	case "date":
+		*date = value
+	case "time":
+		*clock = value
+	case "mode":
+		c.Mode = value
+	case "center frequency", "center freq":
+		c.CenterFreq, err = parseScaled(value, units, frequencyMultipliers)
+	case "sample rate":
+		c.SampleRate, err = parseScaled(value, units, frequencyMultipliers)
+	case "information bandwidth", "info bw":
+		c.Bandwidth, err = parseScaled(value, units, frequencyMultipliers)
+	case "y axis unit", "y axis units":
+		c.Units = value
+	}
+	return err
+}
+
+// checkPoints returns an error if the number of samples doesn't match the
+// Number of Points header line.
+func (c Capture) checkPoints() error {
+	values := c.Header["Number of Points"]
+	if len(values) == 0 {
+		return nil
+	}
+	n, err := strconv.Atoi(values[0])
+	if err != nil {
+		return fmt.Errorf("error parsing number of points: %s", err)
+	}
+	if n != len(c.Samples) {
+		return fmt.Errorf("wrong number of samples / got %d / expected %d", len(c.Samples), n)
+	}
+	return nil
+}
+
+func parseScaled(value, units string, multipliers map[string]float64) (float64, error) {
+	mult, ok := multipliers[strings.ToLower(units)]
+	if !ok {
+		return 0, fmt.Errorf("unknown units: %s", units)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return f * mult, nil
+}
+
+func parseTimestamp(date, clock string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		d, err := time.Parse(layout, date)
+		if err != nil {
+			continue
+		}
+		if clock == "" {
+			return d, nil
+		}
+		c, err := time.Parse("15:04:05", clock)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Date(d.Year(), d.Month(), d.Day(),
+			c.Hour(), c.Minute(), c.Second(), 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format: %s", date)
+}
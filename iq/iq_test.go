@@ -0,0 +1,150 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package iq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadFile(t *testing.T) {
+	var tests = []struct {
+		filename string
+		read     func(string) (Capture, error)
+	}{
+		{"./testdata/n9030a_iq.csv", ReadCSVFile},
+		{"./testdata/n9030a_iq.bin", ReadBinaryFile},
+	}
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			got, err := test.read(test.filename)
+			if err != nil {
+				t.Fatalf("received error reading capture: %s", err)
+			}
+			assert(t, "timestamp", got.Timestamp, time.Date(2024, time.March, 12, 10, 15, 42, 0, time.UTC))
+			assert(t, "model", got.Model, "N9030A")
+			assert(t, "s/n", got.SerialNum, "MY55170321")
+			assert(t, "mode", got.Mode, "BASIC")
+			assertFloat64(t, "center freq", got.CenterFreq, 2.4e9, 0.01)
+			assertFloat64(t, "sample rate", got.SampleRate, 12.5e6, 0.01)
+			assertFloat64(t, "bandwidth", got.Bandwidth, 10e6, 0.01)
+			assert(t, "units", got.Units, "V")
+			assert(t, "num samples", len(got.Samples), 32)
+			assertFloat64(t, "duration", got.Duration(), 2.56e-6, 1e-15)
+			assertFloat64(t, "times[2]", got.Times()[2], 1.6e-7, 1e-15)
+			assertFloat64(t, "sample[1] I", real(got.Samples[1]), 0.03535534, 1e-7)
+			assertFloat64(t, "sample[2] Q", imag(got.Samples[2]), 0.05, 1e-7)
+			assertFloat64(t, "sample[4] I", real(got.Samples[4]), -0.05, 1e-7)
+			assert(t, "header measurement", got.Header["Measurement"][0], "IQ Waveform")
+		})
+	}
+}
+
+func TestReadBinaryFormats(t *testing.T) {
+	var tests = []struct {
+		name   string
+		header string
+		order  binary.AppendByteOrder
+		size   int
+	}{
+		{"default", "", binary.BigEndian, 4},
+		{"real 64 normal", "Data Format,REAL,64\nByte Order,NORM,\n", binary.BigEndian, 8},
+		{"real 32 swapped", "Data Format,REAL,32\nByte Order,SWAP,\n", binary.LittleEndian, 4},
+	}
+	samples := []complex128{1 - 2i, 0.5 + 0.25i}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := []byte("Sample Rate,1,MHz\n" + test.header + "DATA\n")
+			for _, s := range samples {
+				for _, v := range []float64{real(s), imag(s)} {
+					if test.size == 4 {
+						b = test.order.AppendUint32(b, math.Float32bits(float32(v)))
+					} else {
+						b = test.order.AppendUint64(b, math.Float64bits(v))
+					}
+				}
+			}
+			got, err := ReadBinary(bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("received error reading capture: %s", err)
+			}
+			assert(t, "num samples", len(got.Samples), 2)
+			assert(t, "sample[0]", got.Samples[0], samples[0])
+			assert(t, "sample[1]", got.Samples[1], samples[1])
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing data", "Sample Rate,1,MHz\n1,2\n"},
+		{"missing sample rate", "Center Frequency,1,GHz\nDATA\n1,2\n"},
+		{"unknown units", "Sample Rate,1,Mbps\nDATA\n1,2\n"},
+		{"invalid date", "Date,yesterday,\nSample Rate,1,MHz\nDATA\n1,2\n"},
+		{"one column", "Sample Rate,1,MHz\nDATA\n1\n"},
+		{"invalid sample", "Sample Rate,1,MHz\nDATA\n1,x\n"},
+		{"wrong number of points", "Sample Rate,1,MHz\nNumber of Points,2,\nDATA\n1,2\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(test.data)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+
+	tests = []struct {
+		name string
+		data string
+	}{
+		{"data format", "Sample Rate,1,MHz\nData Format,INT,32\nDATA\n\x00\x00\x00\x00\x00\x00\x00\x00"},
+		{"byte order", "Sample Rate,1,MHz\nByte Order,MIXED,\nDATA\n\x00\x00\x00\x00\x00\x00\x00\x00"},
+		{"partial sample", "Sample Rate,1,MHz\nDATA\n\x00\x00\x00\x00\x00"},
+		{"long header line", "Sample Rate,1,MHz\n" + strings.Repeat("\x00", 1<<17) + "\nDATA\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadBinary(strings.NewReader(test.data)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
+
+func FuzzReadBinary(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.bin")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadBinary(bytes.NewReader(data))
+	})
+}
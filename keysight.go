@@ -0,0 +1,474 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package keysight reads the files saved by Keysight/Agilent/HP test
+// equipment without knowing in advance which instrument saved them. ReadFile
+// detects the format of a file and parses it using the matching package,
+// such as esa, xseries, scope, touchstone, or vsa. Use those packages
+// directly when the format is known. Other formats can be added to the
+// detected formats using Register.
+package keysight
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/arb"
+	"github.com/gotmc/keysight/cal"
+	"github.com/gotmc/keysight/citifile"
+	"github.com/gotmc/keysight/counter"
+	"github.com/gotmc/keysight/daq"
+	"github.com/gotmc/keysight/dmm"
+	"github.com/gotmc/keysight/ena"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/fieldfox"
+	"github.com/gotmc/keysight/ident"
+	"github.com/gotmc/keysight/internal/mat"
+	"github.com/gotmc/keysight/iq"
+	"github.com/gotmc/keysight/lcr"
+	"github.com/gotmc/keysight/nfa"
+	"github.com/gotmc/keysight/powermeter"
+	"github.com/gotmc/keysight/psa"
+	"github.com/gotmc/keysight/scope"
+	"github.com/gotmc/keysight/touchstone"
+	"github.com/gotmc/keysight/vsa"
+	"github.com/gotmc/keysight/xseries"
+)
+
+// ErrUnknownFormat is returned when the format of a file can't be detected.
+var ErrUnknownFormat = errors.New("unknown file format")
+
+// Format is the name of a file format detected by Detect.
+type Format string
+
+// Formats detected by Detect along with the type of the value returned by
+// ReadFile for each.
+const (
+	ESATrace          Format = "ESA trace"             // esa.Trace
+	ESALimitLine      Format = "ESA limit line"        // esa.LimitLine
+	ESACorrection     Format = "ESA correction"        // esa.Correction
+	ESAInternal       Format = "ESA internal"          // not supported
+	PSATrace          Format = "PSA trace"             // psa.Trace
+	XSeriesTrace      Format = "X-Series trace"        // xseries.Trace
+	XSeriesLimitLine  Format = "X-Series limit line"   // xseries.LimitLine
+	XSeriesIQ         Format = "X-Series IQ capture"   // iq.Capture
+	FieldFoxTrace     Format = "FieldFox trace"        // fieldfox.Trace
+	FieldFoxNetwork   Format = "FieldFox NA trace"     // fieldfox.NetworkTrace
+	FieldFoxCable     Format = "FieldFox CAT trace"    // fieldfox.CableTrace
+	ScopeBin          Format = "scope binary waveform" // scope.BinFile
+	ScopeCSV          Format = "scope CSV waveform"    // scope.CSVFile
+	ScopeH5           Format = "scope HDF5 waveform"   // []scope.Waveform
+	ScopeOsc          Format = "scope offline setup"   // scope.OscFile
+	Touchstone        Format = "Touchstone"            // touchstone.SParameters
+	CITIfile          Format = "CITIfile"              // []citifile.Package
+	ArbWaveform       Format = "arbitrary waveform"    // arb.Waveform
+	ArbSequence       Format = "waveform sequence"     // arb.Sequence
+	DMMDataLog        Format = "DMM data log"          // dmm.DataLog
+	HandheldDMMLog    Format = "handheld DMM log"      // dmm.HandheldLog
+	CounterLog        Format = "counter log"           // counter.Log
+	DAQScanLog        Format = "DAQ scan log"          // daq.ScanLog
+	LCRSweep          Format = "LCR sweep"             // lcr.Sweep
+	PowerMeterLog     Format = "power meter log"       // powermeter.Log
+	PowerAnalyzerDlog Format = "power analyzer dlog"   // not supported
+	VSARecording      Format = "VSA recording"         // vsa.Recording
+	NoiseFigureResult Format = "noise figure result"   // nfa.NFResult
+	CalKit            Format = "cal kit"               // cal.Kit
+	ENAState          Format = "ENA state"             // not supported
+)
+
+// sniffLines is the number of lines searched for a model number or table
+// labels when detecting the format of a CSV file.
+const sniffLines = 64
+
+var (
+	// touchstoneExt matches the .sNp extensions of Touchstone files.
+	touchstoneExt = regexp.MustCompile(`^\.s[1-9][0-9]?p$`)
+	// enaModel matches the model number of an ENA network analyzer, which
+	// is stored in the state files it saves.
+	enaModel      = regexp.MustCompile(`E50[6-8][0-9][ABC]`)
+	hdf5Signature = []byte("\x89HDF\r\n\x1a\n")
+	matSignature  = []byte("MATLAB 5.0 MAT-file")
+	zipSignature  = []byte("PK\x03\x04")
+	// correctionTypes are the correction types given by the extensions of
+	// correction files that don't include the type.
+	correctionTypes = map[string]esa.CorrectionType{
+		".cor": esa.AmplitudeCorrection,
+		".ant": esa.AntennaCorrection,
+		".cbl": esa.CableCorrection,
+		".oth": esa.OtherCorrection,
+	}
+)
+
+// ReadFile reads the file with the given filename, detecting its format
+// using Detect, and returns the value parsed by the package for that format.
+// The type of the value for each built-in format is listed with the Format
+// constants.
+func ReadFile(filename string) (interface{}, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Read(filename, data)
+}
+
+// Read parses the contents of a file like ReadFile. The filename is only
+// used to detect the format and to determine the settings given by the
+// extension, such as the number of ports of a Touchstone file, so it can be
+// the name of an uploaded file. The data may come from an untrusted source,
+// so a panic while parsing it is returned as an error.
+func Read(filename string, data []byte) (v interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			v, err = nil, fmt.Errorf("error parsing %s: %v", filename, r)
+		}
+	}()
+	return read(filename, data)
+}
+
+// read implements Read without recovering from panics, so that they are
+// found by fuzzing.
+func read(filename string, data []byte) (interface{}, error) {
+	format, err := Detect(filename, data)
+	if err != nil {
+		return nil, fmt.Errorf("error detecting format of %s: %s", filename, err)
+	}
+	for _, f := range registeredFormats() {
+		if f.Name() == format {
+			return f.Parse(filename, data)
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	r := bytes.NewReader(data)
+	switch format {
+	case ESATrace:
+		return esa.ReadCSV(r, esa.WithSource(filename))
+	case ESALimitLine:
+		return esa.ReadLimitLine(r)
+	case ESACorrection:
+		corr, err := esa.ReadCorrection(r)
+		if err == nil && corr.Type == "" {
+			corr.Type = correctionTypes[ext]
+		}
+		return corr, err
+	case ESAInternal:
+		return nil, esa.ErrInternalFormat
+	case PSATrace:
+		return psa.ReadCSV(r)
+	case XSeriesTrace:
+		return xseries.ReadCSV(r)
+	case XSeriesLimitLine:
+		return xseries.ReadLimitLine(r)
+	case XSeriesIQ:
+		if headerValue(firstLines(data, sniffLines), "data format") != "" {
+			return iq.ReadBinary(r)
+		}
+		return iq.ReadCSV(r)
+	case FieldFoxTrace:
+		return fieldfox.ReadCSV(r)
+	case FieldFoxNetwork:
+		return fieldfox.ReadNetworkCSV(r)
+	case FieldFoxCable:
+		return fieldfox.ReadCableCSV(r)
+	case ScopeBin:
+		return scope.ReadBin(r)
+	case ScopeCSV:
+		return scope.ReadCSV(r)
+	case ScopeH5:
+		return scope.ReadH5(r)
+	case ScopeOsc:
+		return scope.ReadOsc(r, int64(len(data)))
+	case Touchstone:
+		// Touchstone 2.0 .ts files give the number of ports using a keyword.
+		ports := 0
+		if touchstoneExt.MatchString(ext) {
+			ports, _ = strconv.Atoi(ext[2 : len(ext)-1])
+		}
+		return touchstone.Read(r, ports)
+	case CITIfile:
+		return citifile.Read(r)
+	case ArbWaveform:
+		return arb.Read(r)
+	case ArbSequence:
+		return arb.ReadSequence(r)
+	case DMMDataLog:
+		return dmm.ReadCSV(r)
+	case HandheldDMMLog:
+		return dmm.ReadHandheldCSV(r)
+	case CounterLog:
+		return counter.ReadCSV(r)
+	case DAQScanLog:
+		return daq.ReadCSV(r)
+	case LCRSweep:
+		return lcr.ReadCSV(r)
+	case PowerMeterLog:
+		return powermeter.ReadCSV(r)
+	case NoiseFigureResult:
+		return nfa.ReadCSV(r)
+	case CalKit:
+		return cal.ReadKit(r)
+	case ENAState:
+		return nil, ena.ErrStateFile
+	case VSARecording:
+		if ext == ".sdf" {
+			return nil, vsa.ErrSDF
+		}
+		return vsa.ReadMAT(r)
+	}
+	return nil, fmt.Errorf("%s files aren't supported", format)
+}
+
+// Detect returns the format of the file with the given filename and
+// contents. Binary files are recognized by their signature, with MAT-files
+// only recognized if they contain a VSA recording and zip archives only if
+// they have the .osc extension of an offline setup, Touchstone, correction,
+// power analyzer data log, cal kit, and VSA SDF files by their extension,
+// binary state files (.sta) as ENA state files if they contain the model
+// number of an ENA and as ESA state files otherwise, and text files by the
+// shape of their first lines, using the ident package to determine the
+// instrument family from the model number in the header. Formats added using
+// Register are tried before the built-in formats.
+func Detect(filename string, data []byte) (Format, error) {
+	for _, f := range registeredFormats() {
+		if f.Detect(filename, data) {
+			return f.Name(), nil
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch {
+	case bytes.HasPrefix(data, hdf5Signature):
+		return ScopeH5, nil
+	case isScopeBin(data):
+		return ScopeBin, nil
+	case ext == ".osc" && bytes.HasPrefix(data, zipSignature):
+		return ScopeOsc, nil
+	case ext == ".sdf" || isVSAMAT(data):
+		return VSARecording, nil
+	case touchstoneExt.MatchString(ext) || ext == ".ts":
+		return Touchstone, nil
+	case ext == ".cor" || ext == ".ant" || ext == ".cbl" || ext == ".oth":
+		return ESACorrection, nil
+	case ext == ".trc":
+		return ESAInternal, nil
+	case ext == ".dlog":
+		return PowerAnalyzerDlog, nil
+	case ext == ".xkt":
+		return CalKit, nil
+	case ext == ".sta" && enaModel.Match(data):
+		return ENAState, nil
+	case ext == ".sta" && isBinary(data):
+		// ESA state files saved in the internal format.
+		return ESAInternal, nil
+	}
+	lines := firstLines(data, sniffLines)
+	if len(lines) == 0 {
+		return "", ErrUnknownFormat
+	}
+	first := lines[0]
+	fields := splitFields(first)
+	label := strings.ToLower(fields[0])
+	switch {
+	case strings.HasPrefix(first, "CITIFILE"):
+		return CITIfile, nil
+	case strings.HasPrefix(first, "!"):
+		switch strings.ToUpper(fieldFoxMode(lines)) {
+		case "NA":
+			return FieldFoxNetwork, nil
+		case "CAT":
+			return FieldFoxCable, nil
+		}
+		return FieldFoxTrace, nil
+	case strings.HasPrefix(label, "file format:"):
+		if ext == ".seq" || hasLinePrefix(lines, "Header:") {
+			return ArbSequence, nil
+		}
+		return ArbWaveform, nil
+	case isTraceHeader(lines):
+		if ident.Lookup(headerValue(lines, "model:")) == ident.PSA {
+			return PSATrace, nil
+		}
+		return ESATrace, nil
+	case strings.HasSuffix(label, ":"):
+		switch strings.TrimSpace(strings.TrimSuffix(label, ":")) {
+		case "limit line":
+			return ESALimitLine, nil
+		case "correction":
+			return ESACorrection, nil
+		}
+		return "", ErrUnknownFormat
+	case label == "x" || label == "x-axis":
+		return ScopeCSV, nil
+	case label == "limit":
+		return XSeriesLimitLine, nil
+	case label == "no." && len(fields) > 1 && strings.Contains(fields[1], "["):
+		// LCR meter single point measurements have no header.
+		return LCRSweep, nil
+	case strings.Contains(strings.ToLower(first), "handheld meter logger"):
+		return HandheldDMMLog, nil
+	}
+
+	model := headerValue(lines, "model")
+	if len(fields) == 4 && ident.Lookup(fields[1]) != ident.Unknown {
+		// The first line is the *IDN? response of the instrument.
+		model = fields[1]
+	}
+	switch ident.Lookup(model) {
+	case ident.XSeries:
+		if hasNoiseFigureLabels(lines) {
+			return NoiseFigureResult, nil
+		}
+		if headerValue(lines, "sample rate") != "" {
+			return XSeriesIQ, nil
+		}
+		return XSeriesTrace, nil
+	case ident.DMM:
+		return DMMDataLog, nil
+	case ident.Counter:
+		return CounterLog, nil
+	case ident.LCR:
+		return LCRSweep, nil
+	case ident.PowerMeter:
+		return PowerMeterLog, nil
+	case ident.NoiseFigure:
+		return NoiseFigureResult, nil
+	}
+	if strings.HasPrefix(strings.ToUpper(model), "U1") {
+		return HandheldDMMLog, nil
+	}
+	for _, line := range lines {
+		if label := strings.ToLower(splitFields(line)[0]); label == "scan" || label == "channel" {
+			return DAQScanLog, nil
+		}
+	}
+	return "", ErrUnknownFormat
+}
+
+// isScopeBin returns whether the data starts with the binary waveform file
+// cookie followed by the two digit version.
+func isScopeBin(data []byte) bool {
+	return len(data) >= 4 && string(data[:2]) == "AG" &&
+		data[2] >= '0' && data[2] <= '9' && data[3] >= '0' && data[3] <= '9'
+}
+
+// isBinary returns whether the first line of the data contains a control
+// character other than a tab or carriage return.
+func isBinary(data []byte) bool {
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	for _, b := range data {
+		switch {
+		case b == '\n':
+			return false
+		case b < 0x20 && b != '\t' && b != '\r':
+			return true
+		}
+	}
+	return false
+}
+
+// isVSAMAT returns whether the data is a MAT-file containing the XDelta and
+// Y variables of a VSA recording.
+func isVSAMAT(data []byte) bool {
+	if !bytes.HasPrefix(data, matSignature) {
+		return false
+	}
+	vars, err := mat.Read(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	_, hasDelta := mat.Lookup(vars, "XDelta")
+	_, hasY := mat.Lookup(vars, "Y")
+	return hasDelta && hasY
+}
+
+// firstLines returns up to n non-blank lines from the start of the data.
+func firstLines(data []byte, n int) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for len(lines) < n && scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// splitFields splits a CSV line into its trimmed fields without unquoting
+// them, which is sufficient for sniffing the format.
+func splitFields(line string) []string {
+	fields := strings.Split(line, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// isTraceHeader reports whether the lines start with the header of an ESA
+// or PSA trace file. The first line contains the timestamp, whose layout
+// depends on the firmware revision and may be empty, and the original
+// filename, so the file is recognized by the Title and Model lines that
+// follow it.
+func isTraceHeader(lines []string) bool {
+	if len(lines) < 3 {
+		return false
+	}
+	return strings.EqualFold(splitFields(lines[1])[0], "title:") &&
+		strings.EqualFold(splitFields(lines[2])[0], "model:")
+}
+
+// headerValue returns the value of the first "label,value" line with the
+// given label, which is compared ignoring case.
+func headerValue(lines []string, label string) string {
+	for _, line := range lines {
+		if fields := splitFields(line); len(fields) > 1 && strings.EqualFold(fields[0], label) {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// fieldFoxMode returns the mode of a FieldFox file given by the Mode
+// metadata line or the BEGIN line.
+func fieldFoxMode(lines []string) string {
+	for _, line := range lines {
+		label, value, ok := strings.Cut(strings.TrimPrefix(line, "!"), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(label), "mode") {
+			return strings.TrimSpace(value)
+		}
+		if fields := strings.Fields(line); len(fields) == 2 && strings.EqualFold(fields[0], "BEGIN") {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// hasNoiseFigureLabels returns whether there is a column labels line
+// starting with the frequency and including the noise figure or factor,
+// which is saved by the noise figure mode of the X-Series.
+func hasNoiseFigureLabels(lines []string) bool {
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		if strings.HasPrefix(lower, "freq") && strings.Contains(lower, ",noise f") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLinePrefix(lines []string, prefix string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
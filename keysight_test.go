@@ -0,0 +1,235 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package keysight
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/ena"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/iq"
+	"github.com/gotmc/keysight/vsa"
+)
+
+func TestReadFile(t *testing.T) {
+	var tests = []struct {
+		filename string
+		format   Format
+		typ      string
+	}{
+		{"arb/testdata/burst.seq", ArbSequence, "arb.Sequence"},
+		{"arb/testdata/sine8.arb", ArbWaveform, "arb.Waveform"},
+		{"cal/testdata/85033e.xkt", CalKit, "cal.Kit"},
+		{"citifile/testdata/n5230c_two_port.cti", CITIfile, "[]citifile.Package"},
+		{"counter/testdata/53230a_gapfree.csv", CounterLog, "counter.Log"},
+		{"daq/testdata/34972a_scan.csv", DAQScanLog, "daq.ScanLog"},
+		{"dmm/testdata/34401a_benchvue.csv", DMMDataLog, "dmm.DataLog"},
+		{"dmm/testdata/34465a_datalog.csv", DMMDataLog, "dmm.DataLog"},
+		{"dmm/testdata/u1233a_mode_lines.csv", HandheldDMMLog, "dmm.HandheldLog"},
+		{"dmm/testdata/u1282a_log.csv", HandheldDMMLog, "dmm.HandheldLog"},
+		{"esa/testdata/LISN.CBL", ESACorrection, "esa.Correction"},
+		{"esa/testdata/cispr_limit.csv", ESALimitLine, "esa.LimitLine"},
+		{"esa/testdata/e4402b_trace924.csv", ESATrace, "esa.Trace"},
+		{"fieldfox/testdata/n9912a_spectrum.csv", FieldFoxTrace, "fieldfox.Trace"},
+		{"fieldfox/testdata/n9918a_na.csv", FieldFoxNetwork, "fieldfox.NetworkTrace"},
+		{"fieldfox/testdata/n9952a_dtf.csv", FieldFoxCable, "fieldfox.CableTrace"},
+		{"iq/testdata/n9030a_iq.bin", XSeriesIQ, "iq.Capture"},
+		{"iq/testdata/n9030a_iq.csv", XSeriesIQ, "iq.Capture"},
+		{"lcr/testdata/e4980a_cpd_sweep.csv", LCRSweep, "lcr.Sweep"},
+		{"lcr/testdata/e4980a_ztd_single.csv", LCRSweep, "lcr.Sweep"},
+		{"nfa/testdata/n8975a_amplifier.csv", NoiseFigureResult, "nfa.NFResult"},
+		{"powermeter/testdata/n1913a_elapsed.csv", PowerMeterLog, "powermeter.Log"},
+		{"powermeter/testdata/n1914a_log.csv", PowerMeterLog, "powermeter.Log"},
+		{"psa/testdata/e4440a_trace001.csv", PSATrace, "psa.Trace"},
+		{"scope/testdata/dsox1204g_increment.csv", ScopeCSV, "scope.CSVFile"},
+		{"scope/testdata/dsox3034t_segmented.bin", ScopeBin, "scope.BinFile"},
+		{"scope/testdata/dsox3034t_time_column.csv", ScopeCSV, "scope.CSVFile"},
+		{"scope/testdata/dsox3034t_two_channels.bin", ScopeBin, "scope.BinFile"},
+		{"scope/testdata/infiniium_two_channels.h5", ScopeH5, "[]scope.Waveform"},
+		{"scope/testdata/msox3034t_digital.bin", ScopeBin, "scope.BinFile"},
+		{"scope/testdata/msox3034t_digital.csv", ScopeCSV, "scope.CSVFile"},
+		{"scope/testdata/mxr058a_setup.osc", ScopeOsc, "scope.OscFile"},
+		{"touchstone/testdata/e5071c_filter.s2p", Touchstone, "touchstone.SParameters"},
+		{"touchstone/testdata/n5222b_coupler.s4p", Touchstone, "touchstone.SParameters"},
+		{"xseries/testdata/n9020a_limit.csv", XSeriesLimitLine, "xseries.LimitLine"},
+		{"xseries/testdata/n9020a_trace.csv", XSeriesTrace, "xseries.Trace"},
+		{"vsa/testdata/n9030a_iq.mat", VSARecording, "vsa.Recording"},
+	}
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			data, err := os.ReadFile(test.filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			format, err := Detect(test.filename, data)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "format", format, test.format)
+			v, err := ReadFile(test.filename)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "type", fmt.Sprintf("%T", v), test.typ)
+		})
+	}
+}
+
+func TestReadFileJSON(t *testing.T) {
+	var tests = []string{
+		"iq/testdata/n9030a_iq.bin",
+		"iq/testdata/n9030a_iq.csv",
+		"vsa/testdata/n9030a_iq.mat",
+	}
+	for _, filename := range tests {
+		t.Run(filename, func(t *testing.T) {
+			v, err := ReadFile(filename)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("received error encoding JSON: %s", err)
+			}
+			var got struct {
+				Samples [][2]float64
+			}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("received error decoding JSON: %s", err)
+			}
+			want := samples(v)
+			if len(got.Samples) != len(want) {
+				t.Fatalf("got %d samples, want %d", len(got.Samples), len(want))
+			}
+			for i, s := range want {
+				assert(t, fmt.Sprintf("sample %d", i), complex(got.Samples[i][0], got.Samples[i][1]), s)
+			}
+		})
+	}
+}
+
+// samples returns the complex samples of the value read from a file.
+func samples(v interface{}) []complex128 {
+	switch v := v.(type) {
+	case iq.Capture:
+		return v.Samples
+	case vsa.Recording:
+		return v.Samples
+	}
+	return nil
+}
+
+func TestDetectTraceTimestamps(t *testing.T) {
+	var files = []struct {
+		filename string
+		format   Format
+	}{
+		{"esa/testdata/e4402b_trace924.csv", ESATrace},
+		{"psa/testdata/e4440a_trace001.csv", PSATrace},
+	}
+	var timestamps = []string{
+		" 11/16/21   10:50:45",
+		" 11/16/2021 10:50:45",
+		"16.11.21 10:50:45",
+		"16.11.2021 10:50:45",
+		"2021-11-16 10:50:45",
+		"16 Nov 2021 10:50:45",
+		"Nov 16 2021 10:50:45",
+		"11/16/21 10:50",
+		"11/16/2021 10:50",
+		"",
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rest, _ := strings.Cut(string(data), "\n")
+		for _, ts := range timestamps {
+			t.Run(file.filename+"/"+ts, func(t *testing.T) {
+				given := []byte(ts + ",C:\\TRACE.CSV\n" + rest)
+				format, err := Detect("TRACE.CSV", given)
+				if err != nil {
+					t.Fatalf("received error: %s", err)
+				}
+				assert(t, "format", format, file.format)
+				if file.format != ESATrace {
+					return
+				}
+				if _, err := Read("TRACE.CSV", given); err != nil {
+					t.Errorf("received error reading: %s", err)
+				}
+			})
+		}
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	if _, err := ReadFile(write("TRACE1.TRC", "\x00\x01\x02")); !errors.Is(err, esa.ErrInternalFormat) {
+		t.Errorf("got %v, want ErrInternalFormat", err)
+	}
+	if _, err := ReadFile(write("STATE01.STA", "\x00\x01E5071C\x00\x02")); !errors.Is(err, ena.ErrStateFile) {
+		t.Errorf("got %v, want ErrStateFile", err)
+	}
+	if _, err := ReadFile(write("STATE02.STA", "\x00\x01\x02")); !errors.Is(err, esa.ErrInternalFormat) {
+		t.Errorf("got %v, want ErrInternalFormat for ESA state file", err)
+	}
+	if _, err := ReadFile(write("capture.sdf", "\x00\x01\x02")); !errors.Is(err, vsa.ErrSDF) {
+		t.Errorf("got %v, want ErrSDF", err)
+	}
+	if _, err := ReadFile(write("log.dlog", "<dlog/>")); err == nil {
+		t.Errorf("expected error for unsupported dlog file")
+	}
+	if _, err := ReadFile(write("notes.csv", "hello,world\n")); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+	if _, err := ReadFile(write("empty.csv", "\n\n")); err == nil {
+		t.Errorf("expected error for empty file")
+	}
+	if _, err := ReadFile(filepath.Join(dir, "missing.csv")); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	filenames, err := filepath.Glob("*/testdata/*")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(filepath.Base(filename), data)
+	}
+	f.Fuzz(func(t *testing.T, filename string, data []byte) {
+		if strings.HasSuffix(filename, ".broken") {
+			return
+		}
+		read(filename, data)
+	})
+}
@@ -0,0 +1,354 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package lcr has the ability to parse the list sweep and single point
+// measurement CSV files saved by the Keysight E4980A precision LCR meter.
+package lcr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// Parameter is a measured parameter, such as capacitance or dissipation
+// factor.
+type Parameter struct {
+	Name  string
+	Units string
+}
+
+// Function is a measurement function, which is a pair of primary and secondary
+// parameters, such as Cp-D.
+type Function struct {
+	Name      string
+	Primary   Parameter
+	Secondary Parameter
+}
+
+// String returns the function name, such as "Cp-D".
+func (f Function) String() string {
+	return f.Name
+}
+
+// Available measurement functions keyed by the SCPI mnemonic.
+var functions = map[string]Function{
+	"CPD":  {"Cp-D", Parameter{"Cp", "F"}, Parameter{"D", ""}},
+	"CPQ":  {"Cp-Q", Parameter{"Cp", "F"}, Parameter{"Q", ""}},
+	"CPG":  {"Cp-G", Parameter{"Cp", "F"}, Parameter{"G", "S"}},
+	"CPRP": {"Cp-Rp", Parameter{"Cp", "F"}, Parameter{"Rp", "Ω"}},
+	"CSD":  {"Cs-D", Parameter{"Cs", "F"}, Parameter{"D", ""}},
+	"CSQ":  {"Cs-Q", Parameter{"Cs", "F"}, Parameter{"Q", ""}},
+	"CSRS": {"Cs-Rs", Parameter{"Cs", "F"}, Parameter{"Rs", "Ω"}},
+	"LPD":  {"Lp-D", Parameter{"Lp", "H"}, Parameter{"D", ""}},
+	"LPQ":  {"Lp-Q", Parameter{"Lp", "H"}, Parameter{"Q", ""}},
+	"LPG":  {"Lp-G", Parameter{"Lp", "H"}, Parameter{"G", "S"}},
+	"LPRP": {"Lp-Rp", Parameter{"Lp", "H"}, Parameter{"Rp", "Ω"}},
+	"LPRD": {"Lp-Rdc", Parameter{"Lp", "H"}, Parameter{"Rdc", "Ω"}},
+	"LSD":  {"Ls-D", Parameter{"Ls", "H"}, Parameter{"D", ""}},
+	"LSQ":  {"Ls-Q", Parameter{"Ls", "H"}, Parameter{"Q", ""}},
+	"LSRS": {"Ls-Rs", Parameter{"Ls", "H"}, Parameter{"Rs", "Ω"}},
+	"LSRD": {"Ls-Rdc", Parameter{"Ls", "H"}, Parameter{"Rdc", "Ω"}},
+	"RX":   {"R-X", Parameter{"R", "Ω"}, Parameter{"X", "Ω"}},
+	"ZTD":  {"Z-θd", Parameter{"Z", "Ω"}, Parameter{"θ", "°"}},
+	"ZTR":  {"Z-θr", Parameter{"Z", "Ω"}, Parameter{"θ", "rad"}},
+	"GB":   {"G-B", Parameter{"G", "S"}, Parameter{"B", "S"}},
+	"YTD":  {"Y-θd", Parameter{"Y", "S"}, Parameter{"θ", "°"}},
+	"YTR":  {"Y-θr", Parameter{"Y", "S"}, Parameter{"θ", "rad"}},
+	"VDID": {"Vdc-Idc", Parameter{"Vdc", "V"}, Parameter{"Idc", "A"}},
+}
+
+// functionAliases maps alternative spellings of the theta functions to their
+// SCPI mnemonic.
+var functionAliases = map[string]string{
+	"ZTHD": "ZTD",
+	"ZTHR": "ZTR",
+	"YTHD": "YTD",
+	"YTHR": "YTR",
+	"ZΘD":  "ZTD",
+	"ZΘR":  "ZTR",
+	"YΘD":  "YTD",
+	"YΘR":  "YTR",
+}
+
+// ParseFunction parses a measurement function given either as the displayed
+// name, such as "Cp-D" or "Z-θd", or as the SCPI mnemonic, such as "CPD".
+func ParseFunction(s string) (Function, error) {
+	key := normalizeFunction(s)
+	if alias, ok := functionAliases[key]; ok {
+		key = alias
+	}
+	if f, ok := functions[key]; ok {
+		return f, nil
+	}
+	for _, f := range functions {
+		if normalizeFunction(f.Name) == key {
+			return f, nil
+		}
+	}
+	return Function{}, fmt.Errorf("unknown measurement function: %s", s)
+}
+
+func normalizeFunction(s string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "", "_", "").Replace(s))
+}
+
+// Measurement is a single measurement point. Sweep is the value of the swept
+// parameter, such as the frequency, and is zero for single point
+// measurements.
+type Measurement struct {
+	Number    int
+	Sweep     float64
+	Primary   float64
+	Secondary float64
+	// Status is the measurement status, where 0 is a normal measurement.
+	Status int
+	// Bin is the comparator bin, such as "OFF", "1", or "OUT".
+	Bin string
+}
+
+// Sweep contains the instrument metadata and measurements of a list sweep or
+// single point measurement file.
+type Sweep struct {
+	Manufacturer    string
+	Model           string
+	SerialNum       string
+	FirmwareVersion string
+	Function        Function
+	// SweepParameter is the swept parameter, such as "Frequency", and is
+	// empty for single point measurements.
+	SweepParameter string
+	SweepUnits     string
+	// Header contains every header line keyed by its label.
+	Header       map[string][]string
+	Measurements []Measurement
+}
+
+// Primary returns the primary parameter value of every measurement.
+func (s Sweep) Primary() []float64 {
+	values := make([]float64, len(s.Measurements))
+	for i, m := range s.Measurements {
+		values[i] = m.Primary
+	}
+	return values
+}
+
+// Secondary returns the secondary parameter value of every measurement.
+func (s Sweep) Secondary() []float64 {
+	values := make([]float64, len(s.Measurements))
+	for i, m := range s.Measurements {
+		values[i] = m.Secondary
+	}
+	return values
+}
+
+// ReadCSVFile reads the E4980A measurement CSV file with the given filename.
+func ReadCSVFile(filename string) (Sweep, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Sweep{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads an E4980A measurement CSV from the io.Reader. The header
+// consists of an optional identification line followed by "Label,value"
+// lines. The measurements start after the column labels line, whose first
+// column is labeled "No.". If there isn't a Function header line, the
+// function is determined from the column labels.
+func ReadCSV(r io.Reader) (Sweep, error) {
+	s := Sweep{Header: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	var cols columns
+	found := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return s, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		if strings.EqualFold(fields[0], "No.") || strings.EqualFold(fields[0], "No") {
+			cols, err = s.parseLabels(fields)
+			if err != nil {
+				return s, fmt.Errorf("error parsing column labels in line %d: %s", lineNum, err)
+			}
+			found = true
+			break
+		}
+		if err := s.parseHeader(fields); err != nil {
+			return s, fmt.Errorf("error parsing header line %d: %s", lineNum, err)
+		}
+	}
+	if !found {
+		if err := scanner.Err(); err != nil {
+			return s, err
+		}
+		return s, fmt.Errorf("missing column labels")
+	}
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return s, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		m, err := cols.parse(fields)
+		if err != nil {
+			return s, fmt.Errorf("error parsing measurement in line %d: %s", lineNum, err)
+		}
+		s.Measurements = append(s.Measurements, m)
+	}
+	return s, scanner.Err()
+}
+
+func (s *Sweep) parseHeader(fields []string) error {
+	label := strings.TrimSuffix(fields[0], ":")
+	values := fields[1:]
+	s.Header[label] = values
+	value := ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	switch strings.ToLower(label) {
+	case "keysight technologies", "agilent technologies":
+		s.Manufacturer = fields[0]
+		if len(fields) == 4 {
+			s.Model, s.SerialNum, s.FirmwareVersion = fields[1], fields[2], fields[3]
+		}
+	case "function", "meas function":
+		f, err := ParseFunction(value)
+		if err != nil {
+			return err
+		}
+		s.Function = f
+	case "sweep parameter", "list sweep parameter":
+		s.SweepParameter = value
+	}
+	return nil
+}
+
+// columns contains the indices of the measurement columns. A negative index
+// means the column doesn't exist.
+type columns struct {
+	number    int
+	sweep     int
+	primary   int
+	secondary int
+	status    int
+	bin       int
+}
+
+// parseLabels determines the measurement columns from the column labels, such
+// as "No.,Freq[Hz],Cp[F],D,Status,Bin No.".
+func (s *Sweep) parseLabels(labels []string) (columns, error) {
+	cols := columns{number: 0, sweep: -1, primary: -1, secondary: -1, status: -1, bin: -1}
+	var params []int
+	for i, label := range labels[1:] {
+		i++
+		name, units := splitUnits(label)
+		switch lower := strings.ToLower(name); {
+		case lower == "status":
+			cols.status = i
+		case strings.HasPrefix(lower, "bin"):
+			cols.bin = i
+		case cols.sweep < 0 && isSweepLabel(lower, s.SweepParameter):
+			cols.sweep = i
+			s.SweepUnits = units
+			if s.SweepParameter == "" {
+				s.SweepParameter = name
+			}
+		default:
+			params = append(params, i)
+		}
+	}
+	if len(params) != 2 {
+		return cols, fmt.Errorf("expected 2 parameter columns / got %d", len(params))
+	}
+	cols.primary, cols.secondary = params[0], params[1]
+	if s.Function.Name == "" {
+		primary, _ := splitUnits(labels[cols.primary])
+		secondary, units := splitUnits(labels[cols.secondary])
+		if lower := strings.ToLower(secondary); lower == "theta" || lower == "θ" {
+			// The theta units distinguish the Z-θd and Z-θr functions.
+			secondary = "θd"
+			if strings.HasPrefix(strings.ToLower(units), "rad") {
+				secondary = "θr"
+			}
+		}
+		f, err := ParseFunction(primary + secondary)
+		if err != nil {
+			return cols, err
+		}
+		s.Function = f
+	}
+	return cols, nil
+}
+
+// sweepLabels are the column label prefixes of the sweep parameters.
+var sweepLabels = []string{"freq", "volt", "curr", "bias", "level", "dc bias"}
+
+func isSweepLabel(label, parameter string) bool {
+	if parameter != "" && strings.HasPrefix(label, strings.ToLower(parameter)) {
+		return true
+	}
+	for _, prefix := range sweepLabels {
+		if strings.HasPrefix(label, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cols columns) parse(fields []string) (Measurement, error) {
+	m := Measurement{}
+	var err error
+	for i, field := range fields {
+		switch i {
+		case cols.number:
+			m.Number, err = strconv.Atoi(field)
+		case cols.sweep:
+			m.Sweep, err = strconv.ParseFloat(field, 64)
+		case cols.primary:
+			m.Primary, err = strconv.ParseFloat(field, 64)
+		case cols.secondary:
+			m.Secondary, err = strconv.ParseFloat(field, 64)
+		case cols.status:
+			m.Status, err = strconv.Atoi(field)
+		case cols.bin:
+			m.Bin = field
+		}
+		if err != nil {
+			return m, err
+		}
+	}
+	if len(fields) <= cols.secondary {
+		return m, fmt.Errorf("expected at least %d columns / got %d", cols.secondary+1, len(fields))
+	}
+	return m, nil
+}
+
+// splitUnits splits a column label, such as "Freq[Hz]", into the name and
+// units.
+func splitUnits(label string) (string, string) {
+	name, units, ok := strings.Cut(label, "[")
+	if !ok {
+		return strings.TrimSpace(label), ""
+	}
+	return strings.TrimSpace(name), strings.TrimSuffix(strings.TrimSpace(units), "]")
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package lcr
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	var tests = []struct {
+		filename       string
+		model          string
+		function       string
+		primaryUnits   string
+		secondaryUnits string
+		sweepParameter string
+		sweepUnits     string
+		numMeas        int
+		index          int
+		want           Measurement
+	}{
+		{
+			filename:       "./testdata/e4980a_cpd_sweep.csv",
+			model:          "E4980A",
+			function:       "Cp-D",
+			primaryUnits:   "F",
+			secondaryUnits: "",
+			sweepParameter: "Frequency",
+			sweepUnits:     "Hz",
+			numMeas:        4,
+			index:          2,
+			want:           Measurement{3, 1e4, 1.02105e-9, 9.8e-4, 0, "OFF"},
+		},
+		{
+			filename:       "./testdata/e4980a_ztd_single.csv",
+			model:          "",
+			function:       "Z-θd",
+			primaryUnits:   "Ω",
+			secondaryUnits: "°",
+			sweepParameter: "",
+			sweepUnits:     "",
+			numMeas:        2,
+			index:          1,
+			want:           Measurement{2, 0, 50.229, -12.28, 0, "1"},
+		},
+	}
+	for _, test := range tests {
+		s, err := ReadCSVFile(test.filename)
+		if err != nil {
+			t.Errorf("error reading %s: %s", test.filename, err)
+			continue
+		}
+		assert(t, "model", s.Model, test.model)
+		assert(t, "function", s.Function.String(), test.function)
+		assert(t, "primary units", s.Function.Primary.Units, test.primaryUnits)
+		assert(t, "secondary units", s.Function.Secondary.Units, test.secondaryUnits)
+		assert(t, "sweep parameter", s.SweepParameter, test.sweepParameter)
+		assert(t, "sweep units", s.SweepUnits, test.sweepUnits)
+		assert(t, "num measurements", len(s.Measurements), test.numMeas)
+		got := s.Measurements[test.index]
+		assert(t, "number", got.Number, test.want.Number)
+		assertFloat64(t, "sweep", got.Sweep, test.want.Sweep, 1e-6)
+		assertFloat64(t, "primary", got.Primary, test.want.Primary, math.Abs(test.want.Primary)*1e-9)
+		assertFloat64(t, "secondary", got.Secondary, test.want.Secondary, 1e-9)
+		assert(t, "status", got.Status, test.want.Status)
+		assert(t, "bin", got.Bin, test.want.Bin)
+		assert(t, "num primary", len(s.Primary()), test.numMeas)
+		assert(t, "num secondary", len(s.Secondary()), test.numMeas)
+	}
+}
+
+func TestParseFunction(t *testing.T) {
+	var tests = []struct {
+		given string
+		want  string
+	}{
+		{"Cp-D", "Cp-D"},
+		{"CPD", "Cp-D"},
+		{"ls-rs", "Ls-Rs"},
+		{"Z-θd", "Z-θd"},
+		{"ZTR", "Z-θr"},
+		{"Y-thr", "Y-θr"},
+		{"Vdc-Idc", "Vdc-Idc"},
+	}
+	for _, test := range tests {
+		f, err := ParseFunction(test.given)
+		if err != nil {
+			t.Errorf("error parsing %s: %s", test.given, err)
+			continue
+		}
+		assert(t, test.given, f.Name, test.want)
+	}
+	if _, err := ParseFunction("Cp-X"); err == nil {
+		t.Errorf("expected error for unknown function")
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing labels", "Function,Cp-D\n"},
+		{"unknown function", "Function,Cp-X\nNo.,Cp[F],D\n"},
+		{"too many parameters", "No.,Cp[F],D,Q\n"},
+		{"unknown label function", "No.,Foo,Bar\n"},
+		{"invalid value", "No.,Cp[F],D\n1,abc,0.1\n"},
+		{"missing column", "No.,Cp[F],D\n1,1e-9\n"},
+	}
+	for _, test := range tests {
+		if _, err := ReadCSV(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package measure performs the automatic measurements of the oscilloscope
+// Measure menu, such as the period, rise time, and RMS voltage, on the
+// waveforms read by the scope package. Like the oscilloscope, the timing
+// measurements use lower, middle, and upper thresholds, which default to
+// 10%, 50%, and 90% of the amplitude between the top and base of the
+// waveform, and each result has a status that flags results that can't be
+// trusted.
+package measure
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gotmc/keysight/scope"
+)
+
+// Status is the validity of a measurement result.
+type Status int
+
+// Available statuses.
+const (
+	// Valid is a result that was measured as specified.
+	Valid Status = iota
+	// Questionable is a result that was measured but may be inaccurate, such
+	// as a rise time spanning fewer than two samples or a period measured
+	// from a single cycle, which the oscilloscope shows with a question mark.
+	Questionable
+	// Invalid is a result that couldn't be measured, such as the period of a
+	// waveform without enough edges. Its value is NaN.
+	Invalid
+)
+
+var statusNames = map[Status]string{
+	Valid:        "valid",
+	Questionable: "questionable",
+	Invalid:      "invalid",
+}
+
+// String implements the Stringer interface for Status.
+func (s Status) String() string {
+	return statusNames[s]
+}
+
+// Result is the result of a measurement.
+type Result struct {
+	Value float64
+	// Units is the symbol of the units of the value, such as "V", "s", "Hz",
+	// or "%".
+	Units  string
+	Status Status
+	// Reason explains why the status isn't Valid.
+	Reason string
+}
+
+// Valid reports whether the result was measured, which includes
+// questionable results.
+func (r Result) Valid() bool {
+	return r.Status != Invalid
+}
+
+func invalid(units, reason string) Result {
+	return Result{Value: math.NaN(), Units: units, Status: Invalid, Reason: reason}
+}
+
+// Option configures the thresholds of the timing measurements.
+type Option func(*config)
+
+type config struct {
+	lower, middle, upper float64
+	absolute             bool
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{lower: 10, middle: 50, upper: 90}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithThresholds sets the lower, middle, and upper thresholds in percent of
+// the amplitude between the base and top of the waveform. The default is 10,
+// 50, and 90, so the rise time is the 10–90% rise time. Use 20, 50, and 80
+// for the 20–80% rise time.
+func WithThresholds(lower, middle, upper float64) Option {
+	return func(cfg *config) {
+		cfg.lower, cfg.middle, cfg.upper = lower, middle, upper
+		cfg.absolute = false
+	}
+}
+
+// WithAbsoluteThresholds sets the lower, middle, and upper thresholds in the
+// y units of the waveform, such as for logic levels.
+func WithAbsoluteThresholds(lower, middle, upper float64) Option {
+	return func(cfg *config) {
+		cfg.lower, cfg.middle, cfg.upper = lower, middle, upper
+		cfg.absolute = true
+	}
+}
+
+// waveform is a waveform prepared for measurement.
+type waveform struct {
+	values     []float64
+	xOrigin    float64
+	xIncrement float64
+	xUnits     string
+	yUnits     string
+}
+
+func newWaveform(wfm scope.Waveform) waveform {
+	return waveform{
+		values:     wfm.Samples(),
+		xOrigin:    wfm.XOrigin,
+		xIncrement: wfm.XIncrement,
+		xUnits:     wfm.XUnits.String(),
+		yUnits:     wfm.YUnits.String(),
+	}
+}
+
+// time returns the time at the fractional sample index.
+func (w waveform) time(index float64) float64 {
+	return w.xOrigin + index*w.xIncrement
+}
+
+// extremes returns the minimum and maximum values.
+func (w waveform) extremes() (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range w.values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	return lo, hi
+}
+
+// histogramBins is the number of bins of the histogram used to find the top
+// and base of a waveform.
+const histogramBins = 256
+
+// minModeFraction is the fraction of the samples in the upper or lower half
+// of the waveform that the most common level must contain to be used as the
+// top or base instead of the maximum or minimum.
+const minModeFraction = 0.2
+
+// topBase returns the top and base of the waveform, which are the most
+// common levels in the upper and lower halves of the waveform, like the
+// flat top and bottom of a pulse, and whether each was found. If a half
+// doesn't have a common level, such as for a sine wave, the maximum or
+// minimum is used.
+func (w waveform) topBase() (top, base float64, flatTop, flatBase bool) {
+	lo, hi := w.extremes()
+	if hi == lo {
+		return hi, lo, true, true
+	}
+	width := (hi - lo) / histogramBins
+	counts := make([]int, histogramBins)
+	for _, v := range w.values {
+		counts[min(int((v-lo)/width), histogramBins-1)]++
+	}
+	// mode returns the most common bin in the range, or -1 if it doesn't
+	// contain enough of the samples in the range.
+	mode := func(from, to int) int {
+		best, total := from, 0
+		for i := from; i < to; i++ {
+			total += counts[i]
+			if counts[i] > counts[best] {
+				best = i
+			}
+		}
+		if counts[best] < 2 || float64(counts[best]) < minModeFraction*float64(total) {
+			return -1
+		}
+		return best
+	}
+	top, base = hi, lo
+	if i := mode(histogramBins/2, histogramBins); i >= 0 {
+		top, flatTop = w.binMean(lo, width, i), true
+	}
+	if i := mode(0, histogramBins/2); i >= 0 {
+		base, flatBase = w.binMean(lo, width, i), true
+	}
+	return top, base, flatTop, flatBase
+}
+
+// binMean returns the mean of the values in the histogram bin.
+func (w waveform) binMean(lo, width float64, bin int) float64 {
+	sum, n := 0.0, 0
+	for _, v := range w.values {
+		if min(int((v-lo)/width), histogramBins-1) == bin {
+			sum += v
+			n++
+		}
+	}
+	return sum / float64(n)
+}
+
+// thresholds returns the lower, middle, and upper thresholds in y units.
+func (w waveform) thresholds(cfg config) (float64, float64, float64, error) {
+	if !(cfg.lower < cfg.middle && cfg.middle < cfg.upper) {
+		return 0, 0, 0, fmt.Errorf("thresholds %g, %g, %g aren't increasing", cfg.lower, cfg.middle, cfg.upper)
+	}
+	if cfg.absolute {
+		return cfg.lower, cfg.middle, cfg.upper, nil
+	}
+	top, base, _, _ := w.topBase()
+	amplitude := top - base
+	if amplitude <= 0 {
+		return 0, 0, 0, fmt.Errorf("waveform has no amplitude")
+	}
+	level := func(percent float64) float64 {
+		return base + percent/100*amplitude
+	}
+	return level(cfg.lower), level(cfg.middle), level(cfg.upper), nil
+}
+
+// edge is a transition between the lower and upper thresholds, with the
+// times it crosses each threshold.
+type edge struct {
+	rising               bool
+	lower, middle, upper float64
+}
+
+// edges returns the transitions of the waveform that cross both the lower
+// and upper thresholds in order, which gives the hysteresis of the
+// oscilloscope's edge detection.
+func (w waveform) edges(lower, middle, upper float64) []edge {
+	var edges []edge
+	// state is -1 after the waveform is at or below the lower threshold, 1
+	// after it's at or above the upper threshold, and 0 before either.
+	state := 0
+	// The last samples at or below and at or above each threshold, which
+	// precede the threshold crossings of an edge.
+	belowLower, belowMiddle := -1, -1
+	aboveUpper, aboveMiddle := -1, -1
+	for i, v := range w.values {
+		switch {
+		case state != 1 && v >= upper:
+			if state == -1 {
+				edges = append(edges, edge{
+					rising: true,
+					lower:  w.time(w.crossing(belowLower, lower)),
+					middle: w.time(w.crossing(belowMiddle, middle)),
+					upper:  w.time(w.crossing(i-1, upper)),
+				})
+			}
+			state = 1
+		case state != -1 && v <= lower:
+			if state == 1 {
+				edges = append(edges, edge{
+					rising: false,
+					lower:  w.time(w.crossing(i-1, lower)),
+					middle: w.time(w.crossing(aboveMiddle, middle)),
+					upper:  w.time(w.crossing(aboveUpper, upper)),
+				})
+			}
+			state = -1
+		}
+		if v <= lower {
+			belowLower = i
+		}
+		if v <= middle {
+			belowMiddle = i
+		}
+		if v >= upper {
+			aboveUpper = i
+		}
+		if v >= middle {
+			aboveMiddle = i
+		}
+	}
+	return edges
+}
+
+// crossing returns the fractional index where the waveform crosses the
+// level between sample i and the next, using linear interpolation.
+func (w waveform) crossing(i int, level float64) float64 {
+	if i+1 >= len(w.values) {
+		return float64(i)
+	}
+	a, b := w.values[i], w.values[i+1]
+	if a == b {
+		return float64(i)
+	}
+	return float64(i) + (level-a)/(b-a)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package measure
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/scope"
+)
+
+// newTestWaveform returns a waveform of the values sampled every ns.
+func newTestWaveform(values []float64) scope.Waveform {
+	buf := scope.Buffer{Type: scope.BufferNormal, BytesPerPoint: 4, Values: make([]float32, len(values))}
+	for i, v := range values {
+		buf.Values[i] = float32(v)
+	}
+	return scope.Waveform{
+		NumPoints:  len(values),
+		XIncrement: 1e-9,
+		XUnits:     scope.UnitsSeconds,
+		YUnits:     scope.UnitsVolts,
+		Buffers:    []scope.Buffer{buf},
+	}
+}
+
+// pulses returns 4 cycles of a 100 ns pulse train from 0 to 1 V following
+// 20 ns at 0 V. Each pulse rises in 10 ns, overshoots to 1.1 V for 1 ns,
+// stays at 1 V until 40 ns, and falls in 10 ns.
+func pulses() []float64 {
+	values := make([]float64, 20)
+	for cycle := 0; cycle < 4; cycle++ {
+		for i := 0; i < 100; i++ {
+			var v float64
+			switch {
+			case i < 10:
+				v = float64(i) / 10
+			case i == 11:
+				v = 1.1
+			case i < 40:
+				v = 1
+			case i < 50:
+				v = 1 - float64(i-40)/10
+			}
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func TestPulseMeasurements(t *testing.T) {
+	values := pulses()
+	wfm := newTestWaveform(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += float64(float32(v)) * float64(float32(v))
+	}
+	var tests = []struct {
+		name      string
+		got       Result
+		want      float64
+		units     string
+		tolerance float64
+	}{
+		{"vpp", Vpp(wfm), 1.1, "V", 1e-6},
+		{"vrms", Vrms(wfm), math.Sqrt(sum / float64(len(values))), "V", 1e-9},
+		{"top", Top(wfm), 1, "V", 1e-9},
+		{"base", Base(wfm), 0, "V", 1e-9},
+		{"amplitude", Amplitude(wfm), 1, "V", 1e-9},
+		{"overshoot", Overshoot(wfm), 10, "%", 1e-4},
+		{"period", Period(wfm), 100e-9, "s", 1e-15},
+		{"frequency", Frequency(wfm), 10e6, "Hz", 1e-3},
+		{"rise time", RiseTime(wfm), 8e-9, "s", 1e-15},
+		{"fall time", FallTime(wfm), 8e-9, "s", 1e-15},
+		{"duty cycle", DutyCycle(wfm), 40, "%", 1e-9},
+		{"20-80 rise time", RiseTime(wfm, WithThresholds(20, 50, 80)), 6e-9, "s", 1e-15},
+		{"absolute rise time", RiseTime(wfm, WithAbsoluteThresholds(0.3, 0.5, 0.7)), 4e-9, "s", 1e-15},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert(t, "status", test.got.Status, Valid)
+			assert(t, "units", test.got.Units, test.units)
+			assertFloat64(t, test.name, test.got.Value, test.want, test.tolerance)
+		})
+	}
+}
+
+func TestQuestionable(t *testing.T) {
+	sine := make([]float64, 210)
+	for i := range sine {
+		sine[i] = math.Sin(2 * math.Pi * float64(i) / 100)
+	}
+	square := make([]float64, 100)
+	for i := range square {
+		if i%50 >= 25 {
+			square[i] = 1
+		}
+	}
+	var tests = []struct {
+		name string
+		got  Result
+		want float64
+	}{
+		{"top of sine", Top(newTestWaveform(sine)), 1},
+		{"base of sine", Base(newTestWaveform(sine)), -1},
+		{"overshoot of sine", Overshoot(newTestWaveform(sine)), 0},
+		{"single period", Period(newTestWaveform(sine)), 100e-9},
+		{"single cycle duty", DutyCycle(newTestWaveform(square)), 50},
+		{"under-sampled edge", RiseTime(newTestWaveform(square)), 0.8e-9},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert(t, "status", test.got.Status, Questionable)
+			assert(t, "valid", test.got.Valid(), true)
+			assertFloat64(t, test.name, test.got.Value, test.want, 1e-6)
+			if test.got.Reason == "" {
+				t.Errorf("missing reason")
+			}
+		})
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	empty := newTestWaveform(nil)
+	flat := newTestWaveform([]float64{1, 1, 1, 1})
+	step := newTestWaveform([]float64{0, 0, 1, 1})
+	pulse := newTestWaveform(pulses())
+	var tests = []struct {
+		name string
+		got  Result
+	}{
+		{"empty vpp", Vpp(empty)},
+		{"empty vrms", Vrms(empty)},
+		{"empty top", Top(empty)},
+		{"empty period", Period(empty)},
+		{"flat period", Period(flat)},
+		{"flat overshoot", Overshoot(flat)},
+		{"step period", Period(step)},
+		{"step fall time", FallTime(step)},
+		{"step duty cycle", DutyCycle(step)},
+		{"bad thresholds", RiseTime(pulse, WithThresholds(90, 50, 10))},
+		{"missed thresholds", Frequency(pulse, WithAbsoluteThresholds(1.5, 2, 2.5))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert(t, "status", test.got.Status, Invalid)
+			assert(t, "valid", test.got.Valid(), false)
+			assert(t, "NaN", math.IsNaN(test.got.Value), true)
+			if test.got.Reason == "" {
+				t.Errorf("missing reason")
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
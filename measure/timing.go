@@ -0,0 +1,145 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package measure
+
+import "github.com/gotmc/keysight/scope"
+
+// notEnoughEdges is the reason a waveform doesn't have the edges needed by
+// a measurement.
+const notEnoughEdges = "not enough edges"
+
+// timingEdges returns the edges of the waveform using the thresholds of the
+// options, or an invalid result if they can't be found.
+func timingEdges(w waveform, opts []Option, units string) ([]edge, Result, bool) {
+	if len(w.values) == 0 {
+		return nil, invalid(units, noSamples), false
+	}
+	lower, middle, upper, err := w.thresholds(newConfig(opts))
+	if err != nil {
+		return nil, invalid(units, err.Error()), false
+	}
+	return w.edges(lower, middle, upper), Result{}, true
+}
+
+// Period returns the mean period of the complete cycles of the waveform,
+// measured between the middle threshold crossings of consecutive rising
+// edges, or of falling edges if there aren't two rising edges. It's
+// questionable if the waveform only contains a single cycle.
+func Period(wfm scope.Waveform, opts ...Option) Result {
+	w := newWaveform(wfm)
+	edges, r, ok := timingEdges(w, opts, w.xUnits)
+	if !ok {
+		return r
+	}
+	var rising, falling []float64
+	for _, e := range edges {
+		if e.rising {
+			rising = append(rising, e.middle)
+		} else {
+			falling = append(falling, e.middle)
+		}
+	}
+	crossings := rising
+	if len(crossings) < 2 {
+		crossings = falling
+	}
+	if len(crossings) < 2 {
+		return invalid(w.xUnits, notEnoughEdges)
+	}
+	n := len(crossings) - 1
+	r = Result{Value: (crossings[n] - crossings[0]) / float64(n), Units: w.xUnits}
+	if n == 1 {
+		r.Status, r.Reason = Questionable, "single cycle"
+	}
+	return r
+}
+
+// Frequency returns the reciprocal of the Period.
+func Frequency(wfm scope.Waveform, opts ...Option) Result {
+	r := Period(wfm, opts...)
+	r.Units = scope.UnitsHertz.String()
+	if r.Valid() {
+		r.Value = 1 / r.Value
+	}
+	return r
+}
+
+// RiseTime returns the mean time of the rising edges to rise from the lower
+// to the upper threshold, which is the 10–90% rise time by default.
+func RiseTime(wfm scope.Waveform, opts ...Option) Result {
+	return transitionTime(wfm, true, opts)
+}
+
+// FallTime returns the mean time of the falling edges to fall from the upper
+// to the lower threshold, which is the 90–10% fall time by default.
+func FallTime(wfm scope.Waveform, opts ...Option) Result {
+	return transitionTime(wfm, false, opts)
+}
+
+// transitionTime returns the mean rise or fall time. It's questionable if
+// the edges span fewer than two samples, since the interpolated times then
+// depend on the sample rate rather than the signal.
+func transitionTime(wfm scope.Waveform, rising bool, opts []Option) Result {
+	w := newWaveform(wfm)
+	edges, r, ok := timingEdges(w, opts, w.xUnits)
+	if !ok {
+		return r
+	}
+	sum, n := 0.0, 0
+	for _, e := range edges {
+		if e.rising != rising {
+			continue
+		}
+		// A falling edge crosses the upper threshold first.
+		if rising {
+			sum += e.upper - e.lower
+		} else {
+			sum += e.lower - e.upper
+		}
+		n++
+	}
+	if n == 0 {
+		return invalid(w.xUnits, notEnoughEdges)
+	}
+	r = Result{Value: sum / float64(n), Units: w.xUnits}
+	if r.Value < 2*w.xIncrement {
+		r.Status, r.Reason = Questionable, "edge is under-sampled"
+	}
+	return r
+}
+
+// DutyCycle returns the positive duty cycle in percent, which is the mean
+// ratio of the time between the middle threshold crossings of a rising edge
+// and the following falling edge to the period of each complete cycle
+// starting at a rising edge. It's questionable if the waveform only
+// contains a single cycle.
+func DutyCycle(wfm scope.Waveform, opts ...Option) Result {
+	w := newWaveform(wfm)
+	edges, r, ok := timingEdges(w, opts, "%")
+	if !ok {
+		return r
+	}
+	// Skip to the first rising edge. The edges alternate between rising and
+	// falling due to the hysteresis.
+	for len(edges) > 0 && !edges[0].rising {
+		edges = edges[1:]
+	}
+	sum, n := 0.0, 0
+	for i := 0; i+2 < len(edges); i += 2 {
+		width := edges[i+1].middle - edges[i].middle
+		period := edges[i+2].middle - edges[i].middle
+		sum += width / period
+		n++
+	}
+	if n == 0 {
+		return invalid("%", notEnoughEdges)
+	}
+	r = Result{Value: 100 * sum / float64(n), Units: "%"}
+	if n == 1 {
+		r.Status, r.Reason = Questionable, "single cycle"
+	}
+	return r
+}
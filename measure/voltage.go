@@ -0,0 +1,100 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package measure
+
+import (
+	"math"
+
+	"github.com/gotmc/keysight/scope"
+)
+
+// noSamples is the reason a waveform without samples can't be measured.
+const noSamples = "waveform has no samples"
+
+// Vpp returns the peak-to-peak voltage, which is the difference between the
+// maximum and minimum values.
+func Vpp(wfm scope.Waveform) Result {
+	w := newWaveform(wfm)
+	if len(w.values) == 0 {
+		return invalid(w.yUnits, noSamples)
+	}
+	lo, hi := w.extremes()
+	return Result{Value: hi - lo, Units: w.yUnits}
+}
+
+// Vrms returns the DC RMS voltage of the whole waveform.
+func Vrms(wfm scope.Waveform) Result {
+	w := newWaveform(wfm)
+	if len(w.values) == 0 {
+		return invalid(w.yUnits, noSamples)
+	}
+	sum := 0.0
+	for _, v := range w.values {
+		sum += v * v
+	}
+	return Result{Value: math.Sqrt(sum / float64(len(w.values))), Units: w.yUnits}
+}
+
+// Top returns the most common level in the upper half of the waveform,
+// which is the flat top of a pulse. It's questionable if the waveform
+// doesn't have a flat top, such as a sine wave, in which case the maximum
+// is returned.
+func Top(wfm scope.Waveform) Result {
+	w := newWaveform(wfm)
+	if len(w.values) == 0 {
+		return invalid(w.yUnits, noSamples)
+	}
+	top, _, flatTop, _ := w.topBase()
+	if !flatTop {
+		return Result{Value: top, Units: w.yUnits, Status: Questionable, Reason: "waveform has no flat top"}
+	}
+	return Result{Value: top, Units: w.yUnits}
+}
+
+// Base returns the most common level in the lower half of the waveform,
+// which is the flat base of a pulse. It's questionable if the waveform
+// doesn't have a flat base, in which case the minimum is returned.
+func Base(wfm scope.Waveform) Result {
+	w := newWaveform(wfm)
+	if len(w.values) == 0 {
+		return invalid(w.yUnits, noSamples)
+	}
+	_, base, _, flatBase := w.topBase()
+	if !flatBase {
+		return Result{Value: base, Units: w.yUnits, Status: Questionable, Reason: "waveform has no flat base"}
+	}
+	return Result{Value: base, Units: w.yUnits}
+}
+
+// Amplitude returns the difference between the top and base.
+func Amplitude(wfm scope.Waveform) Result {
+	w := newWaveform(wfm)
+	if len(w.values) == 0 {
+		return invalid(w.yUnits, noSamples)
+	}
+	top, base, _, _ := w.topBase()
+	return Result{Value: top - base, Units: w.yUnits}
+}
+
+// Overshoot returns the overshoot in percent of the amplitude, which is how
+// far the maximum rises above the top. It's questionable if the waveform
+// doesn't have a flat top, since the top is then the maximum.
+func Overshoot(wfm scope.Waveform) Result {
+	w := newWaveform(wfm)
+	if len(w.values) == 0 {
+		return invalid("%", noSamples)
+	}
+	top, base, flatTop, _ := w.topBase()
+	if top <= base {
+		return invalid("%", "waveform has no amplitude")
+	}
+	_, hi := w.extremes()
+	r := Result{Value: 100 * (hi - top) / (top - base), Units: "%"}
+	if !flatTop {
+		r.Status, r.Reason = Questionable, "waveform has no flat top"
+	}
+	return r
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package monitor continuously sweeps a live spectrum analyzer and exposes
+// the peak amplitude, the channel power within configured bands, and the
+// noise floor as Prometheus metrics, for monitoring the RF environment.
+//
+// The metrics are written in the Prometheus text exposition format using
+// only the standard library, so an Exporter can be served on any HTTP
+// server:
+//
+//	exp := monitor.New(monitor.ESA(esa.NewInstrument(conn)),
+//		monitor.WithBands(monitor.Band{Name: "ism", Center: 2.44e9, Bandwidth: 80e6}))
+//	go exp.Run(ctx)
+//	http.Handle("/metrics", exp)
+//
+// Amplitudes are converted to dBm before they're measured, and are assumed
+// to be in dBm if the trace has no units. The noise floor
+// is the median amplitude of the sweep, which ignores the signals occupying
+// less than half of the span.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/analysis"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/tracemath"
+	"github.com/gotmc/keysight/xseries"
+)
+
+// DefaultInterval is the default time between sweeps.
+const DefaultInterval = 10 * time.Second
+
+// Sweeper takes a sweep of a live analyzer.
+type Sweeper interface {
+	Sweep() (esa.Trace, error)
+}
+
+// SweeperFunc adapts a function to the Sweeper interface.
+type SweeperFunc func() (esa.Trace, error)
+
+// Sweep calls f.
+func (f SweeperFunc) Sweep() (esa.Trace, error) {
+	return f()
+}
+
+// ESA returns a Sweeper fetching the traces of an ESA.
+func ESA(inst *esa.Instrument) Sweeper {
+	return SweeperFunc(inst.FetchTrace)
+}
+
+// XSeries returns a Sweeper fetching the given trace, from 1 to 6, of an
+// X-Series analyzer. The trace is stored as Trace 1 of the esa.Trace.
+func XSeries(inst *xseries.Instrument, trace int) Sweeper {
+	return SweeperFunc(func() (esa.Trace, error) {
+		t, err := inst.FetchTrace(trace)
+		if err != nil {
+			return esa.Trace{}, err
+		}
+		converted, _ := keysight.SpectrumTrace(t)
+		return converted, nil
+	})
+}
+
+// Band is a frequency band whose channel power is exported, such as a
+// communications channel. The frequencies are in Hz.
+type Band struct {
+	Name      string
+	Center    float64
+	Bandwidth float64
+}
+
+// Option configures an Exporter.
+type Option func(*Exporter)
+
+// WithInterval sets the time between sweeps. The default is
+// DefaultInterval.
+func WithInterval(d time.Duration) Option {
+	return func(e *Exporter) {
+		e.interval = d
+	}
+}
+
+// WithBands sets the bands whose channel power is exported.
+func WithBands(bands ...Band) Option {
+	return func(e *Exporter) {
+		e.bands = bands
+	}
+}
+
+// WithTrace sets the trace number, numbered from 1, that is measured. The
+// default is Trace 1.
+func WithTrace(n int) Option {
+	return func(e *Exporter) {
+		e.trace = n
+	}
+}
+
+// Exporter sweeps an analyzer and serves the measurements of the last
+// successful sweep as Prometheus metrics. It's safe to serve the metrics
+// while sweeping.
+type Exporter struct {
+	sweeper  Sweeper
+	interval time.Duration
+	bands    []Band
+	trace    int
+	now      func() time.Time
+
+	mu     sync.Mutex
+	last   *measurement
+	sweeps int
+	errors int
+}
+
+// measurement contains the metrics of a sweep.
+type measurement struct {
+	model        string
+	serialNum    string
+	time         time.Time
+	duration     time.Duration
+	peak         analysis.Marker
+	noiseFloor   float64
+	channelPower map[string]float64
+}
+
+// New returns an exporter using the sweeper, configured by the options.
+func New(sweeper Sweeper, opts ...Option) *Exporter {
+	e := &Exporter{sweeper: sweeper, interval: DefaultInterval, trace: 1, now: time.Now}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Run sweeps the analyzer every interval until the context is canceled,
+// when it returns nil. Failed sweeps are counted by the
+// keysight_analyzer_sweep_errors_total metric and don't stop the exporter.
+func (e *Exporter) Run(ctx context.Context) error {
+	if e.interval <= 0 {
+		return fmt.Errorf("invalid interval: %s", e.interval)
+	}
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		e.Sweep()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep takes a single sweep and updates the metrics. A band outside the
+// frequency range of the sweep is left out of the metrics.
+func (e *Exporter) Sweep() error {
+	start := e.now()
+	m, err := e.measure()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sweeps++
+	if err != nil {
+		e.errors++
+		return err
+	}
+	m.time = start
+	m.duration = e.now().Sub(start)
+	e.last = m
+	return nil
+}
+
+func (e *Exporter) measure() (*measurement, error) {
+	trace, err := e.sweeper.Sweep()
+	if err != nil {
+		return nil, err
+	}
+	if trace.RefLevelUnits != "" || trace.Trace1Units != "" {
+		if trace, err = trace.ConvertAmplitudeUnits(esa.DBm); err != nil {
+			return nil, err
+		}
+	}
+	traces := trace.Traces()
+	if e.trace < 1 || e.trace > len(traces) {
+		return nil, fmt.Errorf("invalid trace number: %d", e.trace)
+	}
+	values := traces[e.trace-1].Values
+	t, err := tracemath.New(trace.Frequency, values)
+	if err != nil {
+		return nil, err
+	}
+	m := &measurement{model: trace.Model, serialNum: trace.SerialNum, channelPower: make(map[string]float64)}
+	if m.peak, err = analysis.MaxPeak(t); err != nil {
+		return nil, err
+	}
+	stats, err := trace.Stats()
+	if err != nil {
+		return nil, err
+	}
+	m.noiseFloor = stats[e.trace-1].Median
+	var opts []analysis.PowerOption
+	if trace.RBWUnits == esa.Hertz && trace.RBW > 0 {
+		opts = append(opts, analysis.WithRBW(trace.RBW))
+	}
+	for _, b := range e.bands {
+		result, err := analysis.ChannelPower(t, b.Center, b.Bandwidth, opts...)
+		if err != nil {
+			continue
+		}
+		m.channelPower[b.Name] = result.Power
+	}
+	return m, nil
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.WriteMetrics(w)
+}
+
+// WriteMetrics writes the metrics in the Prometheus text exposition format.
+// The sweep counters are always written, and the measurements once a sweep
+// has succeeded.
+func (e *Exporter) WriteMetrics(w io.Writer) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var b strings.Builder
+	metric(&b, "sweeps_total", "counter", "Number of sweeps taken.", sample{value: float64(e.sweeps)})
+	metric(&b, "sweep_errors_total", "counter", "Number of sweeps that failed.", sample{value: float64(e.errors)})
+	if m := e.last; m != nil {
+		metric(&b, "info", "gauge", "Model and serial number of the analyzer.",
+			sample{labels: [][2]string{{"model", m.model}, {"serial", m.serialNum}}, value: 1})
+		metric(&b, "last_sweep_timestamp_seconds", "gauge", "Time of the last successful sweep.",
+			sample{value: float64(m.time.UnixMilli()) / 1e3})
+		metric(&b, "sweep_duration_seconds", "gauge", "Time taken by the last successful sweep.",
+			sample{value: m.duration.Seconds()})
+		metric(&b, "peak_dbm", "gauge", "Amplitude of the highest point of the last sweep.",
+			sample{value: m.peak.Amplitude})
+		metric(&b, "peak_frequency_hertz", "gauge", "Frequency of the highest point of the last sweep.",
+			sample{value: m.peak.Frequency})
+		metric(&b, "noise_floor_dbm", "gauge", "Median amplitude of the last sweep.",
+			sample{value: m.noiseFloor})
+		if len(m.channelPower) > 0 {
+			names := make([]string, 0, len(m.channelPower))
+			for name := range m.channelPower {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			samples := make([]sample, len(names))
+			for i, name := range names {
+				samples[i] = sample{labels: [][2]string{{"band", name}}, value: m.channelPower[name]}
+			}
+			metric(&b, "channel_power_dbm", "gauge", "Power within each band in the last sweep.", samples...)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sample is a value of a metric with its labels.
+type sample struct {
+	labels [][2]string
+	value  float64
+}
+
+// namespace is the prefix of the metric names.
+const namespace = "keysight_analyzer_"
+
+func metric(b *strings.Builder, name, typ, help string, samples ...sample) {
+	name = namespace + name
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	for _, s := range samples {
+		b.WriteString(name)
+		if len(s.labels) > 0 {
+			b.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(b, "%s=\"%s\"", l[0], escapeLabel(l[1]))
+			}
+			b.WriteByte('}')
+		}
+		b.WriteByte(' ')
+		b.WriteString(formatValue(s.value))
+		b.WriteByte('\n')
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
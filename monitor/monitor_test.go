@@ -0,0 +1,189 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package monitor
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+// sweepTrace returns a 1 MHz span with a -20 dBm carrier at 100.5 MHz on a
+// -90 dBm noise floor.
+func sweepTrace() esa.Trace {
+	trace := esa.Trace{
+		Model: "E4402B", SerialNum: "US41192", RBW: 10e3, RBWUnits: esa.Hertz,
+		RefLevelUnits: esa.DBm,
+	}
+	for i := 0; i <= 100; i++ {
+		trace.Frequency = append(trace.Frequency, 100e6+float64(i)*10e3)
+		trace.Trace1 = append(trace.Trace1, -90)
+	}
+	trace.Trace1[50] = -20
+	return trace
+}
+
+func TestSweep(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := start
+	exp := New(SweeperFunc(func() (esa.Trace, error) {
+		clock = clock.Add(1500 * time.Millisecond)
+		return sweepTrace(), nil
+	}), WithBands(
+		Band{Name: "carrier", Center: 100.5e6, Bandwidth: 100e3},
+		Band{Name: "out of span", Center: 200e6, Bandwidth: 100e3},
+	))
+	exp.now = func() time.Time { return clock }
+	if err := exp.Sweep(); err != nil {
+		t.Fatalf("error sweeping: %s", err)
+	}
+	var b strings.Builder
+	if err := exp.WriteMetrics(&b); err != nil {
+		t.Fatal(err)
+	}
+	metrics := b.String()
+	for _, want := range []string{
+		"# TYPE keysight_analyzer_sweeps_total counter\nkeysight_analyzer_sweeps_total 1\n",
+		"keysight_analyzer_sweep_errors_total 0\n",
+		`keysight_analyzer_info{model="E4402B",serial="US41192"} 1` + "\n",
+		"keysight_analyzer_last_sweep_timestamp_seconds 1.7092944e+09\n",
+		"keysight_analyzer_sweep_duration_seconds 1.5\n",
+		"keysight_analyzer_peak_dbm -20\n",
+		"keysight_analyzer_peak_frequency_hertz 1.005e+08\n",
+		"keysight_analyzer_noise_floor_dbm -90\n",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics missing %q:\n%s", want, metrics)
+		}
+	}
+	if strings.Contains(metrics, "out of span") {
+		t.Errorf("band outside the span was exported:\n%s", metrics)
+	}
+	if !strings.Contains(metrics, `keysight_analyzer_channel_power_dbm{band="carrier"} `) {
+		t.Errorf("metrics missing channel power:\n%s", metrics)
+	}
+	assert(t, "channel power", math.Round(exp.last.channelPower["carrier"]), -20.0)
+}
+
+func TestSweepUnits(t *testing.T) {
+	exp := New(SweeperFunc(func() (esa.Trace, error) {
+		trace := sweepTrace()
+		trace.RefLevelUnits = esa.DBuV
+		for i := range trace.Trace1 {
+			trace.Trace1[i] += 107
+		}
+		return trace, nil
+	}))
+	if err := exp.Sweep(); err != nil {
+		t.Fatalf("error sweeping: %s", err)
+	}
+	assert(t, "peak", math.Round(exp.last.peak.Amplitude), -20.0)
+	assert(t, "noise floor", math.Round(exp.last.noiseFloor), -90.0)
+}
+
+func TestSweepErrors(t *testing.T) {
+	fail := true
+	exp := New(SweeperFunc(func() (esa.Trace, error) {
+		if fail {
+			return esa.Trace{}, errors.New("timeout")
+		}
+		return sweepTrace(), nil
+	}))
+	if err := exp.Sweep(); err == nil {
+		t.Fatal("expected error sweeping")
+	}
+	rec := httptest.NewRecorder()
+	exp.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert(t, "content type", rec.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8")
+	body := rec.Body.String()
+	if !strings.Contains(body, "keysight_analyzer_sweep_errors_total 1\n") {
+		t.Errorf("error not counted:\n%s", body)
+	}
+	if strings.Contains(body, "peak_dbm") {
+		t.Errorf("measurements exported before a successful sweep:\n%s", body)
+	}
+
+	fail = false
+	exp.Sweep()
+	fail = true
+	exp.Sweep()
+	var b strings.Builder
+	exp.WriteMetrics(&b)
+	for _, want := range []string{
+		"keysight_analyzer_sweeps_total 3\n",
+		"keysight_analyzer_sweep_errors_total 2\n",
+		"keysight_analyzer_peak_dbm -20\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, b.String())
+		}
+	}
+
+	exp = New(SweeperFunc(func() (esa.Trace, error) { return sweepTrace(), nil }), WithTrace(4))
+	if err := exp.Sweep(); err == nil {
+		t.Error("expected error for invalid trace number")
+	}
+}
+
+func TestSweepExtraTraces(t *testing.T) {
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_five_traces.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	exp := New(SweeperFunc(func() (esa.Trace, error) { return trace, nil }), WithTrace(5))
+	if err := exp.Sweep(); err != nil {
+		t.Fatalf("error sweeping: %s", err)
+	}
+	assert(t, "peak frequency", exp.last.peak.Frequency, 10000.0)
+	assert(t, "peak", math.Round(exp.last.peak.Amplitude), -68.0)
+
+	exp = New(SweeperFunc(func() (esa.Trace, error) { return trace, nil }), WithTrace(6))
+	if err := exp.Sweep(); err == nil {
+		t.Error("expected error for invalid trace number")
+	}
+}
+
+func TestRun(t *testing.T) {
+	sweeps := make(chan struct{}, 10)
+	exp := New(SweeperFunc(func() (esa.Trace, error) {
+		select {
+		case sweeps <- struct{}{}:
+		default:
+		}
+		return sweepTrace(), nil
+	}), WithInterval(time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- exp.Run(ctx) }()
+	<-sweeps
+	<-sweeps
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("error running: %s", err)
+	}
+	if err := New(nil, WithInterval(0)).Run(context.Background()); err == nil {
+		t.Error("expected error for zero interval")
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	assert(t, "escaped", escapeLabel("a\\b\"c\nd"), `a\\b\"c\nd`)
+	assert(t, "NaN", formatValue(math.NaN()), "NaN")
+	assert(t, "+Inf", formatValue(math.Inf(1)), "+Inf")
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
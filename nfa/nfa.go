@@ -0,0 +1,389 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package nfa has the ability to parse the noise figure measurements saved
+// in CSV format by the Keysight N8970 series noise figure analyzers, such as
+// the N8975A, and by the noise figure mode of the X-Series signal analyzers.
+package nfa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+	"github.com/gotmc/keysight/internal/interp"
+)
+
+// ENRPoint is the excess noise ratio in dB of the noise source at a
+// frequency in Hz.
+type ENRPoint struct {
+	Frequency float64
+	ENR       float64
+}
+
+// ENRTable is the ENR table of the noise source used for the measurement.
+type ENRTable struct {
+	Model     string
+	SerialNum string
+	Points    []ENRPoint
+}
+
+// At returns the ENR in dB at the frequency in Hz, interpolating linearly
+// between the points of the table like the analyzer. The ENR is held at
+// the end values outside the table and is NaN if the table is empty.
+func (t ENRTable) At(freq float64) float64 {
+	freqs := make([]float64, len(t.Points))
+	enrs := make([]float64, len(t.Points))
+	for i, p := range t.Points {
+		freqs[i], enrs[i] = p.Frequency, p.ENR
+	}
+	return interp.Linear(freqs, enrs, freq, false)
+}
+
+// NFResult contains the settings and results of a noise figure measurement.
+// Frequencies are in Hz, the noise figure, gain, and Y-factor in dB, and
+// the temperatures in kelvin. The result columns that weren't saved are
+// nil.
+type NFResult struct {
+	Manufacturer    string
+	Model           string
+	SerialNum       string
+	FirmwareVersion string
+	Timestamp       time.Time
+	Mode            string
+	StartFreq       float64
+	StopFreq        float64
+	NumPoints       int
+	Averages        int
+	Bandwidth       float64
+	TCold           float64
+	ENR             ENRTable
+	Frequency       []float64
+	NoiseFigure     []float64
+	Gain            []float64
+	YFactor         []float64
+	Teff            []float64
+	// Extra contains the result columns that aren't parsed into the fields
+	// above, such as the hot and cold powers, keyed by the column label.
+	Extra map[string][]float64
+	// Header contains every header line keyed by its label.
+	Header map[string][]string
+}
+
+// enrTableLabel is the line starting the ENR table.
+const enrTableLabel = "ENR Table"
+
+var frequencyMultipliers = map[string]float64{
+	"":    1,
+	"hz":  1,
+	"khz": 1e3,
+	"mhz": 1e6,
+	"ghz": 1e9,
+}
+
+var dateLayouts = []string{
+	"01/02/2006",
+	"2006-01-02",
+	"02 Jan 2006",
+}
+
+// ReadCSVFile reads the noise figure CSV file with the given filename.
+func ReadCSVFile(filename string) (NFResult, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return NFResult{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads a noise figure CSV from the io.Reader. The header consists
+// of an optional identification line followed by "Label,value,units" lines.
+// An optional ENR table starts with an "ENR Table" line followed by its
+// column labels and the frequency and ENR rows. The results start after the
+// column labels line whose first column is the frequency, such as
+// "Frequency (Hz),Noise Figure (dB),Gain (dB)". Noise factor columns, which
+// are linear, are converted to the noise figure in dB.
+func ReadCSV(r io.Reader) (NFResult, error) {
+	res := NFResult{Header: make(map[string][]string), Extra: make(map[string][]float64)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	var date, clock string
+	var cols []column
+	inENR := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			inENR = false
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return res, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		switch {
+		case strings.EqualFold(fields[0], enrTableLabel):
+			inENR = true
+		case isFrequencyLabel(fields[0]) && inENR:
+			// The ENR table column labels.
+		case inENR:
+			p, err := parseENRPoint(fields)
+			if err != nil {
+				return res, fmt.Errorf("error parsing ENR table line %d: %s", lineNum, err)
+			}
+			res.ENR.Points = append(res.ENR.Points, p)
+		case isFrequencyLabel(fields[0]):
+			if cols, err = parseLabels(fields); err != nil {
+				return res, fmt.Errorf("error parsing column labels in line %d: %s", lineNum, err)
+			}
+		default:
+			if err := res.parseHeader(fields, &date, &clock); err != nil {
+				return res, fmt.Errorf("error parsing header line %d (%s): %s", lineNum, fields[0], err)
+			}
+		}
+		if cols != nil {
+			break
+		}
+	}
+	if cols == nil {
+		if err := scanner.Err(); err != nil {
+			return res, err
+		}
+		return res, fmt.Errorf("missing result column labels")
+	}
+	if date != "" {
+		ts, err := parseTimestamp(date, clock)
+		if err != nil {
+			return res, fmt.Errorf("error parsing timestamp: %s", err)
+		}
+		res.Timestamp = ts
+	}
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return res, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		if len(fields) != len(cols) {
+			return res, fmt.Errorf("wrong number of columns in line %d / got %d / expected %d", lineNum, len(fields), len(cols))
+		}
+		for i, field := range fields {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return res, fmt.Errorf("error parsing %s value %s in line %d", cols[i].label, field, lineNum)
+			}
+			res.appendValue(cols[i], v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return res, err
+	}
+	if res.NumPoints != 0 && len(res.Frequency) != res.NumPoints {
+		return res, fmt.Errorf("wrong number of points / got %d / expected %d", len(res.Frequency), res.NumPoints)
+	}
+	if res.NumPoints == 0 {
+		res.NumPoints = len(res.Frequency)
+	}
+	return res, nil
+}
+
+// Kind of result column.
+const (
+	extraColumn = iota
+	frequencyColumn
+	noiseFigureColumn
+	noiseFactorColumn
+	gainColumn
+	yFactorColumn
+	teffColumn
+)
+
+// column is a result column with the scale converting its units.
+type column struct {
+	label string
+	kind  int
+	scale float64
+}
+
+// parseLabels determines the result columns from the column labels.
+func parseLabels(labels []string) ([]column, error) {
+	cols := make([]column, len(labels))
+	seen := make(map[int]bool)
+	for i, label := range labels {
+		name, units := splitUnits(label)
+		col := column{label: label, scale: 1}
+		switch lower := strings.ToLower(name); {
+		case i == 0:
+			col.kind = frequencyColumn
+			scale, ok := frequencyMultipliers[strings.ToLower(units)]
+			if !ok {
+				return nil, fmt.Errorf("unknown frequency units: %s", units)
+			}
+			col.scale = scale
+		case lower == "noise figure" || lower == "nf":
+			col.kind = noiseFigureColumn
+		case lower == "noise factor":
+			col.kind = noiseFactorColumn
+		case lower == "gain":
+			col.kind = gainColumn
+		case lower == "y factor" || lower == "y-factor":
+			col.kind = yFactorColumn
+		case lower == "teff" || lower == "effective temperature":
+			col.kind = teffColumn
+		}
+		if col.kind != extraColumn {
+			if seen[col.kind] || (col.kind == noiseFactorColumn && seen[noiseFigureColumn]) ||
+				(col.kind == noiseFigureColumn && seen[noiseFactorColumn]) {
+				return nil, fmt.Errorf("duplicate column: %s", label)
+			}
+			seen[col.kind] = true
+		}
+		cols[i] = col
+	}
+	return cols, nil
+}
+
+// appendValue appends the value of the column to the result.
+func (res *NFResult) appendValue(col column, v float64) {
+	switch col.kind {
+	case frequencyColumn:
+		res.Frequency = append(res.Frequency, v*col.scale)
+	case noiseFigureColumn:
+		res.NoiseFigure = append(res.NoiseFigure, v)
+	case noiseFactorColumn:
+		res.NoiseFigure = append(res.NoiseFigure, 10*math.Log10(v))
+	case gainColumn:
+		res.Gain = append(res.Gain, v)
+	case yFactorColumn:
+		res.YFactor = append(res.YFactor, v)
+	case teffColumn:
+		res.Teff = append(res.Teff, v)
+	default:
+		res.Extra[col.label] = append(res.Extra[col.label], v)
+	}
+}
+
+func (res *NFResult) parseHeader(fields []string, date, clock *string) error {
+	label := strings.TrimSuffix(fields[0], ":")
+	values := fields[1:]
+	res.Header[label] = values
+	value, units := "", ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	if len(values) > 1 {
+		units = values[1]
+	}
+	var err error
+	switch strings.ToLower(label) {
+	case "keysight technologies", "agilent technologies":
+		res.Manufacturer = fields[0]
+		if len(fields) == 4 {
+			res.Model, res.SerialNum, res.FirmwareVersion = fields[1], fields[2], fields[3]
+		}
+	case "model":
+		res.Model = value
+	case "serial number":
+		res.SerialNum = value
+	case "date":
+		*date = value
+	case "time":
+		*clock = value
+	case "measurement mode", "mode":
+		res.Mode = value
+	case "start frequency", "start freq":
+		res.StartFreq, err = parseScaled(value, units, frequencyMultipliers)
+	case "stop frequency", "stop freq":
+		res.StopFreq, err = parseScaled(value, units, frequencyMultipliers)
+	case "points", "number of points":
+		res.NumPoints, err = strconv.Atoi(value)
+	case "averages":
+		res.Averages, err = strconv.Atoi(value)
+	case "bandwidth", "meas bandwidth":
+		res.Bandwidth, err = parseScaled(value, units, frequencyMultipliers)
+	case "t cold", "tcold":
+		res.TCold, err = strconv.ParseFloat(value, 64)
+	case "enr model":
+		res.ENR.Model = value
+	case "enr serial number":
+		res.ENR.SerialNum = value
+	}
+	return err
+}
+
+func parseENRPoint(fields []string) (ENRPoint, error) {
+	if len(fields) != 2 {
+		return ENRPoint{}, fmt.Errorf("expected frequency and ENR / got %d columns", len(fields))
+	}
+	freq, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return ENRPoint{}, err
+	}
+	enr, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return ENRPoint{}, err
+	}
+	return ENRPoint{freq, enr}, nil
+}
+
+// isFrequencyLabel returns whether the label is that of a frequency column,
+// such as "Frequency (Hz)" or "Freq[MHz]".
+func isFrequencyLabel(label string) bool {
+	name, _ := splitUnits(label)
+	name = strings.ToLower(name)
+	return name == "frequency" || name == "freq"
+}
+
+// splitUnits splits a column label, such as "Gain (dB)" or "Freq[Hz]", into
+// the name and units.
+func splitUnits(label string) (string, string) {
+	i := strings.IndexAny(label, "([")
+	if i < 0 {
+		return strings.TrimSpace(label), ""
+	}
+	return strings.TrimSpace(label[:i]), strings.TrimSpace(strings.TrimRight(label[i+1:], ")] "))
+}
+
+func parseScaled(value, units string, multipliers map[string]float64) (float64, error) {
+	mult, ok := multipliers[strings.ToLower(units)]
+	if !ok {
+		return 0, fmt.Errorf("unknown units: %s", units)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return f * mult, nil
+}
+
+func parseTimestamp(date, clock string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		d, err := time.Parse(layout, date)
+		if err != nil {
+			continue
+		}
+		if clock == "" {
+			return d, nil
+		}
+		c, err := time.Parse("15:04:05", clock)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Date(d.Year(), d.Month(), d.Day(),
+			c.Hour(), c.Minute(), c.Second(), 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format: %s", date)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package nfa
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	got, err := ReadCSVFile("./testdata/n8975a_amplifier.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	assert(t, "manufacturer", got.Manufacturer, "Agilent Technologies")
+	assert(t, "model", got.Model, "N8975A")
+	assert(t, "s/n", got.SerialNum, "MY43120375")
+	assert(t, "firmware", got.FirmwareVersion, "A.05.01")
+	assert(t, "timestamp", got.Timestamp, time.Date(2024, time.March, 12, 10, 15, 42, 0, time.UTC))
+	assert(t, "mode", got.Mode, "Amplifier")
+	assertFloat64(t, "start freq", got.StartFreq, 100e6, 0.01)
+	assertFloat64(t, "stop freq", got.StopFreq, 1e9, 0.01)
+	assert(t, "num points", got.NumPoints, 10)
+	assert(t, "averages", got.Averages, 8)
+	assertFloat64(t, "bandwidth", got.Bandwidth, 4e6, 0.01)
+	assertFloat64(t, "t cold", got.TCold, 296.5, 1e-9)
+	assert(t, "header", got.Header["Loss Compensation"][0], "Off")
+
+	assert(t, "enr model", got.ENR.Model, "346B")
+	assert(t, "enr s/n", got.ENR.SerialNum, "MY44420199")
+	assert(t, "enr points", len(got.ENR.Points), 5)
+	assertFloat64(t, "enr[1]", got.ENR.Points[1].ENR, 15.21, 1e-9)
+	assertFloat64(t, "enr at 550 MHz", got.ENR.At(550e6), 15.15, 1e-9)
+	assertFloat64(t, "enr below table", got.ENR.At(1e6), 15.27, 1e-9)
+
+	assert(t, "freq len", len(got.Frequency), 10)
+	assert(t, "nf len", len(got.NoiseFigure), 10)
+	assertFloat64(t, "freq[4]", got.Frequency[4], 500e6, 0.01)
+	assertFloat64(t, "nf[4]", got.NoiseFigure[4], 1.66, 1e-9)
+	assertFloat64(t, "gain[9]", got.Gain[9], 21.05, 1e-9)
+	assertFloat64(t, "y factor[0]", got.YFactor[0], 13.891, 1e-9)
+	assertFloat64(t, "teff[0]", got.Teff[0], 119.64, 1e-9)
+	assert(t, "extra columns", len(got.Extra), 0)
+}
+
+func TestReadCSVColumns(t *testing.T) {
+	data := "Model,N9030A\nFreq[MHz],Noise Factor,Phot (dB),Pcold (dB)\n100,1.5,-60.1,-72.3\n200,2,-60.2,-72.4\n"
+	got, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("received error reading CSV: %s", err)
+	}
+	assert(t, "model", got.Model, "N9030A")
+	assert(t, "num points", got.NumPoints, 2)
+	assertFloat64(t, "freq[1]", got.Frequency[1], 200e6, 0.01)
+	assertFloat64(t, "nf[1]", got.NoiseFigure[1], 10*math.Log10(2), 1e-9)
+	assert(t, "gain", got.Gain == nil, true)
+	assert(t, "enr points", len(got.ENR.Points), 0)
+	assert(t, "enr at", math.IsNaN(got.ENR.At(1e9)), true)
+	assertFloat64(t, "pcold[0]", got.Extra["Pcold (dB)"][0], -72.3, 1e-9)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing labels", "Model,N8975A\nPoints,2\n"},
+		{"bad frequency units", "Frequency (furlongs),Noise Figure (dB)\n1,2\n"},
+		{"duplicate column", "Frequency (Hz),Noise Figure (dB),Noise Factor\n1,2,3\n"},
+		{"bad header value", "Points,many\nFrequency (Hz),Noise Figure (dB)\n1,2\n"},
+		{"bad date", "Date,someday\nFrequency (Hz),Noise Figure (dB)\n1,2\n"},
+		{"bad enr", "ENR Table\nFrequency (Hz),ENR (dB)\n1e9,hot\n\nFrequency (Hz),Noise Figure (dB)\n1,2\n"},
+		{"wrong columns", "Frequency (Hz),Noise Figure (dB)\n1,2,3\n"},
+		{"bad value", "Frequency (Hz),Noise Figure (dB)\n1,low\n"},
+		{"wrong points", "Points,3\nFrequency (Hz),Noise Figure (dB)\n1,2\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(test.data)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,484 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package plot renders spectrum analyzer traces as PNG or SVG images laid out
+// like the analyzer display, for use in reports.
+//
+// The images are rendered using gonum.org/v1/plot. The display has a ten by
+// ten division graticule with the reference level at the top line and the
+// amplitude scale in dB per division, so the trace amplitudes are assumed to
+// be in a logarithmic unit. Amplitudes outside the graticule are clipped to
+// its edges like the analyzer display. The reference level and scale are
+// shown along the amplitude axis, and the frequency range and bandwidth
+// settings along the frequency axis. Limit lines and markers can be added
+// using options.
+package plot
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/analysis"
+	"github.com/gotmc/keysight/esa"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// Default settings.
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 600
+	DefaultScale  = 10
+)
+
+// divisions is the number of graticule divisions along each axis.
+const divisions = 10
+
+// minSize is the minimum width and height of the image in pixels.
+const minSize = 100
+
+// pixel is the length of a pixel at the 96 DPI used for PNG images.
+const pixel = vg.Inch / 96
+
+// Display colors.
+var (
+	backgroundColor = color.RGBA{0x10, 0x10, 0x18, 0xff}
+	graticuleColor  = color.RGBA{0x50, 0x50, 0x58, 0xff}
+	textColor       = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	limitColor      = color.RGBA{0xff, 0x40, 0x40, 0xff}
+	markerColor     = color.RGBA{0x40, 0xff, 0x40, 0xff}
+	traceColors     = []color.RGBA{
+		{0xff, 0xe0, 0x30, 0xff}, // Trace 1 is yellow
+		{0x30, 0xd0, 0xff, 0xff}, // Trace 2 is cyan
+		{0xff, 0x50, 0xff, 0xff}, // Trace 3 is magenta
+		{0xff, 0x90, 0x20, 0xff}, // Trace 4 is orange
+		{0x80, 0x90, 0xff, 0xff}, // Trace 5 is blue
+		{0xff, 0xff, 0xff, 0xff}, // Trace 6 is white
+	}
+)
+
+// Option configures how a trace is plotted.
+type Option func(*config)
+
+type config struct {
+	width   int
+	height  int
+	scale   float64
+	title   string
+	traces  []int
+	limits  []esa.LimitLine
+	markers []analysis.Marker
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		width:  DefaultWidth,
+		height: DefaultHeight,
+		scale:  DefaultScale,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithSize sets the image size in pixels. The default is DefaultWidth by
+// DefaultHeight.
+func WithSize(width, height int) Option {
+	return func(cfg *config) {
+		cfg.width = width
+		cfg.height = height
+	}
+}
+
+// WithScale sets the amplitude scale in dB per division. The default is
+// DefaultScale.
+func WithScale(dbPerDiv float64) Option {
+	return func(cfg *config) {
+		cfg.scale = dbPerDiv
+	}
+}
+
+// WithTitle sets the title shown above the graticule. The default is the
+// trace title, or the model if the trace doesn't have a title.
+func WithTitle(title string) Option {
+	return func(cfg *config) {
+		cfg.title = title
+	}
+}
+
+// WithTraces sets the trace numbers to plot. By default, every trace
+// containing data is plotted.
+func WithTraces(numbers ...int) Option {
+	return func(cfg *config) {
+		cfg.traces = numbers
+	}
+}
+
+// WithLimitLines adds limit lines to the plot.
+func WithLimitLines(lines ...esa.LimitLine) Option {
+	return func(cfg *config) {
+		cfg.limits = append(cfg.limits, lines...)
+	}
+}
+
+// WithMarkers adds markers to the plot, which are labeled M1, M2, and so on
+// in the given order.
+func WithMarkers(markers ...analysis.Marker) Option {
+	return func(cfg *config) {
+		cfg.markers = append(cfg.markers, markers...)
+	}
+}
+
+// New returns the gonum plot of the trace, which can be customized further
+// before saving it. The image size option isn't used.
+func New(trace esa.Trace, opts ...Option) (*plot.Plot, error) {
+	return newPlot(trace, newConfig(opts))
+}
+
+// WritePNGFile renders the trace as a PNG image to the given filename.
+func WritePNGFile(filename string, trace esa.Trace, opts ...Option) error {
+	return writeFile(filename, func(w io.Writer) error {
+		return WritePNG(w, trace, opts...)
+	})
+}
+
+// WritePNG renders the trace as a PNG image to the io.Writer.
+func WritePNG(w io.Writer, trace esa.Trace, opts ...Option) error {
+	return write(w, "png", trace, newConfig(opts))
+}
+
+// WriteSVGFile renders the trace as an SVG image to the given filename.
+func WriteSVGFile(filename string, trace esa.Trace, opts ...Option) error {
+	return writeFile(filename, func(w io.Writer) error {
+		return WriteSVG(w, trace, opts...)
+	})
+}
+
+// WriteSVG renders the trace as an SVG image to the io.Writer.
+func WriteSVG(w io.Writer, trace esa.Trace, opts ...Option) error {
+	return write(w, "svg", trace, newConfig(opts))
+}
+
+func write(w io.Writer, format string, trace esa.Trace, cfg config) error {
+	if cfg.width < minSize || cfg.height < minSize {
+		return fmt.Errorf("image size is too small: %dx%d", cfg.width, cfg.height)
+	}
+	p, err := newPlot(trace, cfg)
+	if err != nil {
+		return err
+	}
+	wt, err := p.WriterTo(vg.Length(cfg.width)*pixel, vg.Length(cfg.height)*pixel, format)
+	if err != nil {
+		return err
+	}
+	_, err = wt.WriteTo(w)
+	return err
+}
+
+func writeFile(filename string, write func(io.Writer) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// display maps amplitudes to the graticule.
+type display struct {
+	startFreq, stopFreq   float64
+	refLevel, bottomLevel float64
+}
+
+// clip returns the amplitude clipped to the graticule like the analyzer
+// display.
+func (d display) clip(amp float64) float64 {
+	if math.IsNaN(amp) {
+		return d.bottomLevel
+	}
+	return math.Max(d.bottomLevel, math.Min(d.refLevel, amp))
+}
+
+func newPlot(trace esa.Trace, cfg config) (*plot.Plot, error) {
+	if cfg.scale <= 0 {
+		return nil, fmt.Errorf("invalid scale: %g dB/div", cfg.scale)
+	}
+	n := len(trace.Frequency)
+	if n < 2 {
+		return nil, fmt.Errorf("trace needs at least 2 points / got %d", n)
+	}
+	all := trace.Traces()
+	numbers := cfg.traces
+	if numbers == nil {
+		for i, td := range all {
+			if len(td.Values) > 0 {
+				numbers = append(numbers, i+1)
+			}
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("trace doesn't contain any data")
+	}
+	for _, number := range numbers {
+		if number < 1 || number > len(all) {
+			return nil, fmt.Errorf("invalid trace number: %d", number)
+		}
+		if len(all[number-1].Values) != n {
+			return nil, fmt.Errorf("mismatched lengths / freq %d / trace %d %d", n, number, len(all[number-1].Values))
+		}
+	}
+	d := display{
+		startFreq:   trace.Frequency[0],
+		stopFreq:    trace.Frequency[n-1],
+		refLevel:    trace.RefLevel,
+		bottomLevel: trace.RefLevel - divisions*cfg.scale,
+	}
+	if d.stopFreq <= d.startFreq {
+		return nil, fmt.Errorf("frequencies must increase / start %g / stop %g", d.startFreq, d.stopFreq)
+	}
+
+	p := plot.New()
+	setStyle(p)
+	p.Add(graticule{})
+	for _, l := range cfg.limits {
+		for _, xys := range limitSegments(d, l) {
+			line, err := plotter.NewLine(xys)
+			if err != nil {
+				return nil, err
+			}
+			line.Color = limitColor
+			p.Add(line)
+		}
+	}
+	for _, number := range numbers {
+		td := all[number-1]
+		xys := make(plotter.XYs, n)
+		for i, freq := range trace.Frequency {
+			xys[i] = plotter.XY{X: freq, Y: d.clip(td.Values[i])}
+		}
+		line, err := plotter.NewLine(xys)
+		if err != nil {
+			return nil, err
+		}
+		line.Color = traceColors[(number-1)%len(traceColors)]
+		p.Add(line)
+		label := strings.TrimSpace(td.Label)
+		if label == "" {
+			label = fmt.Sprintf("Trace %d", number)
+		}
+		p.Legend.Add(label, line)
+	}
+	if err := addMarkers(p, d, cfg.markers, trace.RefLevelUnits); err != nil {
+		return nil, err
+	}
+	addAnnotations(p, d, trace, cfg)
+	p.X.Min, p.X.Max = d.startFreq, d.stopFreq
+	p.Y.Min, p.Y.Max = d.bottomLevel, d.refLevel
+	return p, nil
+}
+
+// setStyle sets the colors of the plot to resemble the analyzer display.
+func setStyle(p *plot.Plot) {
+	p.BackgroundColor = backgroundColor
+	p.Title.TextStyle.Color = textColor
+	p.Title.TextStyle.Font.Size = vg.Points(14)
+	p.Legend.TextStyle.Color = textColor
+	p.Legend.Top = true
+	for _, axis := range []*plot.Axis{&p.X, &p.Y} {
+		axis.Color = graticuleColor
+		axis.Label.TextStyle.Color = textColor
+		axis.Tick.Color = graticuleColor
+		axis.Tick.Label.Color = textColor
+		axis.Padding = 0
+	}
+}
+
+// graticule draws the ten by ten divisions of the display.
+type graticule struct{}
+
+// Plot implements the plot.Plotter interface.
+func (graticule) Plot(c draw.Canvas, p *plot.Plot) {
+	trX, trY := p.Transforms(&c)
+	style := draw.LineStyle{Color: graticuleColor, Width: pixel}
+	for i := 0; i <= divisions; i++ {
+		x := trX(p.X.Min + float64(i)*(p.X.Max-p.X.Min)/divisions)
+		y := trY(p.Y.Min + float64(i)*(p.Y.Max-p.Y.Min)/divisions)
+		c.StrokeLine2(style, x, c.Min.Y, x, c.Max.Y)
+		c.StrokeLine2(style, c.Min.X, y, c.Max.X, y)
+	}
+}
+
+// limitSegments returns the segments of the limit line within the frequency
+// range of the display. Segments using logarithmic frequency interpolation
+// are sampled so that they're drawn as curves on the linear frequency axis.
+func limitSegments(d display, l esa.LimitLine) []plotter.XYs {
+	const steps = 32
+	var segments []plotter.XYs
+	var segment plotter.XYs
+	flush := func() {
+		if len(segment) > 1 {
+			segments = append(segments, segment)
+		}
+		segment = nil
+	}
+	for i, p := range l.Points {
+		if i == 0 || p.Disconnected {
+			flush()
+			continue
+		}
+		prev := l.Points[i-1]
+		lo := math.Max(prev.Frequency, d.startFreq)
+		hi := math.Min(p.Frequency, d.stopFreq)
+		if lo >= hi {
+			flush()
+			continue
+		}
+		for k := 0; k <= steps; k++ {
+			freq := lo + float64(k)*(hi-lo)/steps
+			frac := (freq - prev.Frequency) / (p.Frequency - prev.Frequency)
+			if l.LogFrequency && prev.Frequency > 0 {
+				frac = math.Log(freq/prev.Frequency) / math.Log(p.Frequency/prev.Frequency)
+			}
+			pt := plotter.XY{X: freq, Y: d.clip(prev.Amplitude + frac*(p.Amplitude-prev.Amplitude))}
+			if n := len(segment); n == 0 || segment[n-1] != pt {
+				segment = append(segment, pt)
+			}
+		}
+	}
+	flush()
+	return segments
+}
+
+// diamond is the glyph drawn at each marker.
+type diamond struct{}
+
+// DrawGlyph implements the draw.GlyphDrawer interface.
+func (diamond) DrawGlyph(c *draw.Canvas, sty draw.GlyphStyle, pt vg.Point) {
+	var path vg.Path
+	r := sty.Radius
+	path.Move(vg.Point{X: pt.X, Y: pt.Y + r})
+	path.Line(vg.Point{X: pt.X + r, Y: pt.Y})
+	path.Line(vg.Point{X: pt.X, Y: pt.Y - r})
+	path.Line(vg.Point{X: pt.X - r, Y: pt.Y})
+	path.Close()
+	c.SetColor(sty.Color)
+	c.SetLineWidth(vg.Points(1))
+	c.Stroke(path)
+}
+
+// addMarkers draws a diamond at each marker within the frequency range with
+// its number, and lists the marker readouts in the legend.
+func addMarkers(p *plot.Plot, d display, markers []analysis.Marker, units esa.AmplitudeUnits) error {
+	var xys plotter.XYs
+	var names []string
+	for i, m := range markers {
+		if m.Frequency < d.startFreq || m.Frequency > d.stopFreq {
+			continue
+		}
+		xys = append(xys, plotter.XY{X: m.Frequency, Y: d.clip(m.Amplitude)})
+		names = append(names, strconv.Itoa(i+1))
+		readout := fmt.Sprintf("M%d  %s  %s", i+1, formatFrequency(m.Frequency), formatValue(m.Amplitude, string(units)))
+		p.Legend.Add(readout, markerThumbnail{})
+	}
+	if len(xys) == 0 {
+		return nil
+	}
+	scatter, err := plotter.NewScatter(xys)
+	if err != nil {
+		return err
+	}
+	scatter.GlyphStyle = draw.GlyphStyle{Color: markerColor, Radius: vg.Points(4), Shape: diamond{}}
+	labels, err := plotter.NewLabels(plotter.XYLabels{XYs: xys, Labels: names})
+	if err != nil {
+		return err
+	}
+	for i := range labels.TextStyle {
+		labels.TextStyle[i].Color = markerColor
+		labels.TextStyle[i].XAlign = draw.XCenter
+	}
+	labels.Offset = vg.Point{Y: vg.Points(6)}
+	p.Add(scatter, labels)
+	return nil
+}
+
+// markerThumbnail draws the marker glyph in the legend.
+type markerThumbnail struct{}
+
+// Thumbnail implements the plot.Thumbnailer interface.
+func (markerThumbnail) Thumbnail(c *draw.Canvas) {
+	diamond{}.DrawGlyph(c, draw.GlyphStyle{Color: markerColor, Radius: vg.Points(4)}, c.Center())
+}
+
+// addAnnotations sets the title and labels the axes with the settings shown
+// by the analyzer around the graticule.
+func addAnnotations(p *plot.Plot, d display, trace esa.Trace, cfg config) {
+	title := cfg.title
+	if title == "" {
+		title = strings.TrimSpace(trace.Title)
+	}
+	if title == "" {
+		title = strings.TrimSpace(trace.Model)
+	}
+	p.Title.Text = title
+
+	center := (d.startFreq + d.stopFreq) / 2
+	p.X.Tick.Marker = plot.ConstantTicks([]plot.Tick{
+		{Value: d.startFreq, Label: "Start " + formatFrequency(d.startFreq)},
+		{Value: center, Label: "Center " + formatFrequency(center)},
+		{Value: d.stopFreq, Label: "Stop " + formatFrequency(d.stopFreq)},
+	})
+	var settings []string
+	if trace.RBWUnits != "" {
+		settings = append(settings, "Res BW "+formatValue(trace.RBW, string(trace.RBWUnits)))
+	}
+	if trace.VBWUnits != "" {
+		settings = append(settings, "VBW "+formatValue(trace.VBW, string(trace.VBWUnits)))
+	}
+	if trace.SweepTimeUnits != "" {
+		settings = append(settings, "Sweep "+formatValue(trace.SweepTime, string(trace.SweepTimeUnits)))
+	}
+	p.X.Label.Text = strings.Join(settings, "    ")
+
+	var ticks []plot.Tick
+	for i := 0; i <= divisions; i++ {
+		level := d.bottomLevel + float64(i)*cfg.scale
+		ticks = append(ticks, plot.Tick{Value: level, Label: strconv.FormatFloat(level, 'g', 6, 64)})
+	}
+	p.Y.Tick.Marker = plot.ConstantTicks(ticks)
+	p.Y.Label.Text = "Ref " + formatValue(trace.RefLevel, string(trace.RefLevelUnits)) + "    " + formatValue(cfg.scale, "dB/div")
+}
+
+// formatFrequency formats the frequency in Hz using the largest units that
+// keep the value at least 1.
+func formatFrequency(freq float64) string {
+	for _, u := range []struct {
+		name string
+		mult float64
+	}{{"GHz", 1e9}, {"MHz", 1e6}, {"kHz", 1e3}} {
+		if math.Abs(freq) >= u.mult {
+			return formatValue(freq/u.mult, u.name)
+		}
+	}
+	return formatValue(freq, "Hz")
+}
+
+func formatValue(v float64, units string) string {
+	s := strconv.FormatFloat(v, 'g', 6, 64)
+	if units = strings.TrimSpace(units); units != "" {
+		s += " " + units
+	}
+	return s
+}
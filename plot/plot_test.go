@@ -0,0 +1,231 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package plot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/analysis"
+	"github.com/gotmc/keysight/esa"
+	"gonum.org/v1/plot/vg"
+)
+
+func testTrace(t *testing.T) esa.Trace {
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	return trace
+}
+
+func testOptions(trace esa.Trace) []Option {
+	limit := esa.LimitLine{
+		Type:         esa.UpperLimit,
+		LogFrequency: true,
+		Points: []esa.LimitPoint{
+			{Frequency: 9e3, Amplitude: 90},
+			{Frequency: 40e3, Amplitude: 80},
+			{Frequency: 40e3, Amplitude: 75, Disconnected: true},
+			{Frequency: 100e3, Amplitude: 75},
+		},
+	}
+	marker := analysis.Marker{Index: 100, Frequency: trace.Frequency[100], Amplitude: trace.Trace1[100]}
+	return []Option{WithLimitLines(limit), WithMarkers(marker), WithTitle("Conducted <Scan> & Test")}
+}
+
+func TestWritePNG(t *testing.T) {
+	trace := testTrace(t)
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, trace, append(testOptions(trace), WithSize(640, 480))...); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("error decoding PNG: %s", err)
+	}
+	assert(t, "width", img.Bounds().Dx(), 640)
+	assert(t, "height", img.Bounds().Dy(), 480)
+	counts := make(map[[3]uint32]int)
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			counts[[3]uint32{r >> 8, g >> 8, b >> 8}]++
+		}
+	}
+	for name, c := range map[string][3]uint32{
+		"trace 1":   {0xff, 0xe0, 0x30},
+		"trace 3":   {0xff, 0x50, 0xff},
+		"limit":     {0xff, 0x40, 0x40},
+		"marker":    {0x40, 0xff, 0x40},
+		"text":      {0xe0, 0xe0, 0xe0},
+		"graticule": {0x50, 0x50, 0x58},
+	} {
+		if counts[c] == 0 {
+			t.Errorf("missing %s pixels", name)
+		}
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	trace := testTrace(t)
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, trace, append(testOptions(trace), WithTraces(1), WithScale(5))...); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	strokes := make(map[string]int)
+	var texts []string
+	d := xml.NewDecoder(&buf)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %s", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			switch se.Name.Local {
+			case "path":
+				for _, attr := range se.Attr {
+					if i := strings.Index(attr.Value, "stroke:#"); attr.Name.Local == "style" && i >= 0 {
+						strokes[attr.Value[i+len("stroke:"):i+len("stroke:#ffffff")]]++
+					}
+				}
+			case "text":
+				var s string
+				if err := d.DecodeElement(&s, &se); err != nil {
+					t.Fatalf("error decoding text: %s", err)
+				}
+				texts = append(texts, s)
+			}
+		}
+	}
+	// The trace and marker are drawn on the graticule and in the legend, and
+	// the limit line has 2 segments.
+	assert(t, "trace 1 paths", strokes["#FFE030"], 2)
+	assert(t, "trace 2 paths", strokes["#30D0FF"], 0)
+	assert(t, "limit paths", strokes["#FF4040"], 2)
+	assert(t, "marker paths", strokes["#40FF40"], 2)
+	joined := strings.Join(texts, "\n")
+	for _, want := range []string{
+		"Conducted <Scan> & Test",
+		"Ref 106.99 dBuV",
+		"5 dB/div",
+		"Start 9 kHz",
+		"Center 34 kHz",
+		"Stop 59 kHz",
+		"Res BW 1000 Hz",
+		"M1  21.5 kHz",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing text %q", want)
+		}
+	}
+}
+
+func TestWriteExtraTraces(t *testing.T) {
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_five_traces.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, trace); err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	for _, color := range []string{"#FF9020", "#8090FF"} {
+		if !strings.Contains(buf.String(), "stroke:"+color) {
+			t.Errorf("missing trace color %s", color)
+		}
+	}
+	if !strings.Contains(buf.String(), ">Trace 5<") {
+		t.Errorf("missing Trace 5 legend entry")
+	}
+	if err := WriteSVG(&bytes.Buffer{}, trace, WithTraces(4, 5)); err != nil {
+		t.Errorf("received error plotting traces 4 and 5: %s", err)
+	}
+	if err := WriteSVG(&bytes.Buffer{}, trace, WithTraces(6)); err == nil {
+		t.Errorf("expected error for invalid trace number")
+	}
+}
+
+func TestNew(t *testing.T) {
+	trace := testTrace(t)
+	p, err := New(trace, WithScale(5))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "title", p.Title.Text, "E4402B")
+	assert(t, "x min", p.X.Min, 9000.0)
+	assert(t, "x max", p.X.Max, 59000.0)
+	assert(t, "y max", p.Y.Max, 106.99)
+	assertFloat64(t, "y min", p.Y.Min, 56.99, 1e-9)
+	if err := p.Save(4*vg.Inch, 3*vg.Inch, filepath.Join(t.TempDir(), "trace.pdf")); err != nil {
+		t.Errorf("error saving plot: %s", err)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	trace := testTrace(t)
+	short := trace
+	short.Trace2 = short.Trace2[:10]
+	var tests = []struct {
+		name  string
+		trace esa.Trace
+		opts  []Option
+	}{
+		{"too small", trace, []Option{WithSize(10, 10)}},
+		{"invalid scale", trace, []Option{WithScale(0)}},
+		{"invalid trace number", trace, []Option{WithTraces(4)}},
+		{"mismatched lengths", short, nil},
+		{"no data", esa.Trace{Frequency: []float64{1, 2}}, nil},
+		{"one point", esa.Trace{Frequency: []float64{1}, Trace1: []float64{1}}, nil},
+		{"decreasing frequency", esa.Trace{Frequency: []float64{2, 1}, Trace1: []float64{1, 1}}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := WriteSVG(&bytes.Buffer{}, test.trace, test.opts...); err == nil {
+				t.Errorf("expected SVG error")
+			}
+			if err := WritePNG(&bytes.Buffer{}, test.trace, test.opts...); err == nil {
+				t.Errorf("expected PNG error")
+			}
+		})
+	}
+}
+
+func TestFormatFrequency(t *testing.T) {
+	var tests = []struct {
+		freq float64
+		want string
+	}{
+		{500, "500 Hz"},
+		{9e3, "9 kHz"},
+		{1.5e6, "1.5 MHz"},
+		{26.5e9, "26.5 GHz"},
+	}
+	for _, test := range tests {
+		assert(t, "frequency", formatFrequency(test.freq), test.want)
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package powermeter has the ability to parse the logged power readings
+// exported by the Keysight N1913A and N1914A EPM series power meters.
+package powermeter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// Units are the units of the power readings.
+type Units string
+
+// Available units.
+const (
+	DBm     Units = "dBm"
+	Watts   Units = "W"
+	DB      Units = "dB"
+	Percent Units = "%"
+)
+
+var unitsNames = map[string]Units{
+	"dbm": DBm,
+	"w":   Watts,
+	"db":  DB,
+	"%":   Percent,
+}
+
+// ParseUnits parses the power units.
+func ParseUnits(s string) (Units, error) {
+	u, ok := unitsNames[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return "", fmt.Errorf("unknown power units: %s", s)
+	}
+	return u, nil
+}
+
+// Channel is the configuration of a measurement channel.
+type Channel struct {
+	Name         string
+	Sensor       string
+	SensorSerial string
+	// Frequency is the frequency in Hz used for the sensor cal factor.
+	Frequency float64
+	// CalFactor is the sensor cal factor in percent.
+	CalFactor float64
+	// Offset is the channel offset in dB.
+	Offset    float64
+	Units     Units
+	Averaging string
+}
+
+// Reading contains the power readings of every channel at a single time. The
+// Values are in the same order as the channels of the Log.
+type Reading struct {
+	Time    time.Time
+	Elapsed time.Duration
+	Values  []float64
+}
+
+// Log contains the instrument metadata, channel configuration, and readings
+// of a power meter log.
+type Log struct {
+	Manufacturer    string
+	Model           string
+	SerialNum       string
+	FirmwareVersion string
+	StartTime       time.Time
+	Channels        []Channel
+	// Header contains every header line keyed by its label. Repeated labels
+	// for different channels contain the values of the last channel.
+	Header   map[string][]string
+	Readings []Reading
+}
+
+// Channel returns the configuration of the channel with the given name, such
+// as "A".
+func (l Log) Channel(name string) (Channel, bool) {
+	if i := l.channelIndex(name); i >= 0 {
+		return l.Channels[i], true
+	}
+	return Channel{}, false
+}
+
+// Values returns the readings of the channel with the given name.
+func (l Log) Values(name string) []float64 {
+	idx := l.channelIndex(name)
+	if idx < 0 {
+		return nil
+	}
+	values := make([]float64, len(l.Readings))
+	for i, r := range l.Readings {
+		values[i] = r.Values[idx]
+	}
+	return values
+}
+
+func (l Log) channelIndex(name string) int {
+	for i, ch := range l.Channels {
+		if strings.EqualFold(ch.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"01/02/2006 15:04:05.999999999",
+	"02 Jan 2006 15:04:05.999999999",
+}
+
+var freqMultipliers = map[string]float64{
+	"":    1,
+	"hz":  1,
+	"khz": 1e3,
+	"mhz": 1e6,
+	"ghz": 1e9,
+}
+
+// ReadCSVFile reads the power meter log CSV file with the given filename.
+func ReadCSVFile(filename string) (Log, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Log{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads a power meter log CSV from the io.Reader. The header consists
+// of an optional identification line followed by "Label,value[,units]" lines.
+// Each "Channel" header line starts the configuration of a channel. The
+// readings start after the column labels line, whose first column is labeled
+// Time and whose other columns contain the readings of each channel, such as
+// "Channel A (dBm)". Times without a time zone are parsed as UTC.
+func ReadCSV(r io.Reader) (Log, error) {
+	l := Log{Header: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	var cols []int
+	elapsed := false
+	found := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return l, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		if label := strings.ToLower(fields[0]); strings.HasPrefix(label, "time") || label == "elapsed" {
+			elapsed = strings.Contains(label, "(s)") || label == "elapsed"
+			cols, err = l.parseLabels(fields[1:])
+			if err != nil {
+				return l, fmt.Errorf("error parsing column labels in line %d: %s", lineNum, err)
+			}
+			found = true
+			break
+		}
+		if err := l.parseHeader(fields); err != nil {
+			return l, fmt.Errorf("error parsing header line %d: %s", lineNum, err)
+		}
+	}
+	if !found {
+		if err := scanner.Err(); err != nil {
+			return l, err
+		}
+		return l, fmt.Errorf("missing column labels")
+	}
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := csvrow.Fields(line)
+		if err != nil {
+			return l, fmt.Errorf("error parsing line %d: %s", lineNum, err)
+		}
+		reading, err := l.parseReading(fields, cols, elapsed)
+		if err != nil {
+			return l, fmt.Errorf("error parsing reading in line %d: %s", lineNum, err)
+		}
+		l.Readings = append(l.Readings, reading)
+	}
+	return l, scanner.Err()
+}
+
+func (l *Log) parseHeader(fields []string) error {
+	label := strings.TrimSuffix(fields[0], ":")
+	values := fields[1:]
+	l.Header[label] = values
+	value, units := "", ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	if len(values) > 1 {
+		units = values[1]
+	}
+	lower := strings.ToLower(label)
+	switch lower {
+	case "keysight technologies", "agilent technologies":
+		l.Manufacturer = fields[0]
+		if len(fields) == 4 {
+			l.Model, l.SerialNum, l.FirmwareVersion = fields[1], fields[2], fields[3]
+		}
+		return nil
+	case "model":
+		l.Model = value
+		return nil
+	case "serial number":
+		l.SerialNum = value
+		return nil
+	case "start time":
+		t, err := parseTimestamp(strings.Join(values, " "))
+		if err != nil {
+			return err
+		}
+		l.StartTime = t
+		return nil
+	case "channel":
+		l.Channels = append(l.Channels, Channel{Name: value})
+		return nil
+	}
+
+	// The remaining header lines configure the current channel, which is
+	// created if there isn't a Channel header line.
+	if len(l.Channels) == 0 {
+		switch lower {
+		case "sensor", "frequency", "cal factor", "offset", "units", "averaging":
+			l.Channels = append(l.Channels, Channel{Name: "A"})
+		default:
+			return nil
+		}
+	}
+	ch := &l.Channels[len(l.Channels)-1]
+	var err error
+	switch lower {
+	case "sensor":
+		ch.Sensor = value
+		ch.SensorSerial = units
+	case "frequency":
+		mult, ok := freqMultipliers[strings.ToLower(units)]
+		if !ok {
+			return fmt.Errorf("unknown frequency units: %s", units)
+		}
+		ch.Frequency, err = strconv.ParseFloat(value, 64)
+		ch.Frequency *= mult
+	case "cal factor":
+		ch.CalFactor, err = strconv.ParseFloat(value, 64)
+	case "offset":
+		ch.Offset, err = strconv.ParseFloat(value, 64)
+	case "units":
+		ch.Units, err = ParseUnits(value)
+	case "averaging":
+		ch.Averaging = strings.Join(values, " ")
+	}
+	return err
+}
+
+// parseLabels maps the reading columns to the channels. The channel name is
+// the last word of the label before any units in parentheses, such as "A" in
+// "Channel A (dBm)". Channels that weren't in the header are added.
+func (l *Log) parseLabels(labels []string) ([]int, error) {
+	cols := make([]int, len(labels))
+	for i, label := range labels {
+		name, units, _ := strings.Cut(label, "(")
+		words := strings.Fields(name)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("missing channel name in column %d", i+2)
+		}
+		name = words[len(words)-1]
+		idx := l.channelIndex(name)
+		if idx < 0 {
+			l.Channels = append(l.Channels, Channel{Name: name})
+			idx = len(l.Channels) - 1
+		}
+		if units = strings.TrimSuffix(strings.TrimSpace(units), ")"); units != "" {
+			u, err := ParseUnits(units)
+			if err != nil {
+				return nil, err
+			}
+			l.Channels[idx].Units = u
+		}
+		cols[i] = idx
+	}
+	return cols, nil
+}
+
+func (l *Log) parseReading(fields []string, cols []int, elapsed bool) (Reading, error) {
+	reading := Reading{Values: make([]float64, len(l.Channels))}
+	if len(fields) != len(cols)+1 {
+		return reading, fmt.Errorf("expected %d columns / got %d", len(cols)+1, len(fields))
+	}
+	if elapsed {
+		secs, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return reading, err
+		}
+		reading.Elapsed = time.Duration(math.Round(secs * float64(time.Second)))
+		reading.Time = l.StartTime.Add(reading.Elapsed)
+	} else {
+		t, err := parseTimestamp(fields[0])
+		if err != nil {
+			return reading, err
+		}
+		if l.StartTime.IsZero() && len(l.Readings) == 0 {
+			l.StartTime = t
+		}
+		reading.Time = t
+		reading.Elapsed = t.Sub(l.StartTime)
+	}
+	for i, idx := range cols {
+		v, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return reading, err
+		}
+		reading.Values[idx] = v
+	}
+	return reading, nil
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown timestamp format: %s", s)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package powermeter
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	l, err := ReadCSVFile("./testdata/n1914a_log.csv")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "model", l.Model, "N1914A")
+	assert(t, "serial number", l.SerialNum, "MY53100123")
+	assert(t, "firmware", l.FirmwareVersion, "A2.01.06")
+	assert(t, "start time", l.StartTime, time.Date(2024, time.March, 14, 10, 21, 7, 0, time.UTC))
+	assert(t, "num channels", len(l.Channels), 2)
+	var channels = []Channel{
+		{"A", "N8481A", "MY12345678", 1e9, 98.5, 0, DBm, "Auto 16"},
+		{"B", "E9304A", "MY87654321", 2.4e9, 97.2, 10, Watts, "Manual 64"},
+	}
+	for _, want := range channels {
+		ch, ok := l.Channel(want.Name)
+		if !ok {
+			t.Errorf("missing channel %s", want.Name)
+			continue
+		}
+		assert(t, "channel", ch, want)
+	}
+	assert(t, "num readings", len(l.Readings), 3)
+	assert(t, "elapsed", l.Readings[2].Elapsed, 2*time.Second)
+	assertFloat64(t, "channel A", l.Values("A")[1], -10.118, 1e-9)
+	assertFloat64(t, "channel B", l.Values("b")[2], 1.234e-3, 1e-12)
+	if l.Values("C") != nil {
+		t.Errorf("expected nil values for unknown channel")
+	}
+}
+
+func TestReadCSVFileElapsed(t *testing.T) {
+	l, err := ReadCSVFile("./testdata/n1913a_elapsed.csv")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "model", l.Model, "N1913A")
+	assert(t, "num channels", len(l.Channels), 1)
+	ch := l.Channels[0]
+	assert(t, "channel name", ch.Name, "A")
+	assertFloat64(t, "frequency", ch.Frequency, 50e6, 1e-6)
+	assertFloat64(t, "cal factor", ch.CalFactor, 100, 1e-9)
+	assert(t, "units", ch.Units, DBm)
+	assert(t, "reading time", l.Readings[1].Time, time.Date(2024, time.March, 14, 8, 0, 0, 500000000, time.UTC))
+	assertFloat64(t, "reading", l.Readings[2].Values[0], 0.009, 1e-12)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing labels", "Channel,A\n"},
+		{"unknown units", "Units,furlongs\nTime,A\n"},
+		{"unknown frequency units", "Frequency,1,THz\nTime,A\n"},
+		{"unknown column units", "Time,Channel A (dBW)\n"},
+		{"invalid reading", "Time (s),A\n0,abc\n"},
+		{"wrong number of columns", "Time (s),A\n0,1,2\n"},
+		{"invalid time", "Time,A\nyesterday,1\n"},
+	}
+	for _, test := range tests {
+		if _, err := ReadCSV(strings.NewReader(test.data)); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package psa has the ability to parse files from the Keysight/Agilent PSA
+// (E444xA) spectrum analyzers.
+//
+// The PSA CSV file is similar to the ESA CSV file, but the header contains
+// additional rows, such as the firmware version, attenuation, and detector,
+// and the numeric values and units are formatted differently. Rather than
+// relying on the position of each header line, the header is parsed by label.
+// As with the ESA, traces saved in the internal (.TRC) format aren't supported.
+package psa
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// Trace contains the header and trace data saved by a PSA spectrum analyzer.
+type Trace struct {
+	Timestamp        time.Time
+	OriginalFilename string
+	Title            string
+	Model            string
+	SerialNum        string
+	FirmwareVersion  string
+	CenterFreq       float64
+	CenterFreqUnits  esa.FrequencyUnits
+	Span             float64
+	SpanUnits        esa.FrequencyUnits
+	RBW              float64
+	RBWUnits         esa.FrequencyUnits
+	VBW              float64
+	VBWUnits         esa.FrequencyUnits
+	RefLevel         float64
+	RefLevelUnits    esa.AmplitudeUnits
+	Attenuation      float64
+	Detector         esa.Detector
+	SweepTime        float64
+	SweepTimeUnits   esa.TimeUnits
+	NumPoints        int
+	FreqLabel        string
+	Trace1Label      string
+	Trace2Label      string
+	Trace3Label      string
+	FreqUnits        string
+	Trace1Units      string
+	Trace2Units      string
+	Trace3Units      string
+	Frequency        []float64
+	Trace1           []float64
+	Trace2           []float64
+	Trace3           []float64
+	// Extra contains any header lines that aren't otherwise parsed, keyed by
+	// the label without the trailing colon.
+	Extra map[string]string
+}
+
+var timestampLayouts = []string{
+	"01/02/06 15:04:05",
+	"01/02/2006 15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// ReadCSVFile reads the Keysight/Agilent PSA trace data saved in CSV format.
+func ReadCSVFile(filename string) (Trace, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Trace{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads the Keysight/Agilent PSA trace data in CSV format from the
+// given io.Reader.
+func ReadCSV(r io.Reader) (Trace, error) {
+	trace := Trace{}
+	scanner := bufio.NewScanner(r)
+
+	// Parse first line, which should contain the timestamp and original
+	// filename.
+	if !scanner.Scan() {
+		return trace, fmt.Errorf("missing first (date/filename) line")
+	}
+	columns := strings.Split(scanner.Text(), ",")
+	if len(columns) != 2 {
+		return trace, fmt.Errorf("error in first (date/filename) line: %s", scanner.Text())
+	}
+	timestamp, err := parseTimestamp(columns[0])
+	if err != nil {
+		return trace, fmt.Errorf("error parsing timestamp: %s", err)
+	}
+	trace.Timestamp = timestamp
+	trace.OriginalFilename = columns[1]
+
+	// Parse the labeled header lines, which end at the first blank line.
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if err := trace.parseHeader(strings.Split(line, ",")); err != nil {
+			return trace, fmt.Errorf("error in header line %q: %s", line, err)
+		}
+	}
+
+	// Skip any additional blank lines and parse the line containing the labels
+	// for the frequency and trace data.
+	line := ""
+	for scanner.Scan() {
+		line = scanner.Text()
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+	s := strings.Split(line, ",")
+	if len(s) != 4 {
+		return trace, fmt.Errorf("error in trace label line: %s", line)
+	}
+	trace.FreqLabel = strings.TrimSpace(s[0])
+	trace.Trace1Label = strings.TrimSpace(s[1])
+	trace.Trace2Label = strings.TrimSpace(s[2])
+	trace.Trace3Label = strings.TrimSpace(s[3])
+
+	// Parse the line containing the units for the frequency and trace data.
+	scanner.Scan()
+	line = scanner.Text()
+	s = strings.Split(line, ",")
+	if len(s) != 4 {
+		return trace, fmt.Errorf("error in trace units line: %s", line)
+	}
+	trace.FreqUnits = strings.TrimSpace(s[0])
+	trace.Trace1Units = strings.TrimSpace(s[1])
+	trace.Trace2Units = strings.TrimSpace(s[2])
+	trace.Trace3Units = strings.TrimSpace(s[3])
+
+	// Parse the trace data. The rows are split without allocating.
+	n := csvrow.Capacity(trace.NumPoints)
+	trace.Frequency = make([]float64, 0, n)
+	trace.Trace1 = make([]float64, 0, n)
+	trace.Trace2 = make([]float64, 0, n)
+	trace.Trace3 = make([]float64, 0, n)
+	var splitter csvrow.Splitter
+	i := 0
+	for scanner.Scan() {
+		row := scanner.Bytes()
+		if len(bytes.TrimSpace(row)) == 0 {
+			continue
+		}
+		s := splitter.Split(row)
+		if len(s) != 4 {
+			return trace, fmt.Errorf("error in trace data line: %s", row)
+		}
+		var values [4]float64
+		for j := range s {
+			v, err := csvrow.ParseFloat(s[j])
+			if err != nil {
+				return trace, fmt.Errorf("error parsing column %d value %s for data point %d", j+1, s[j], i)
+			}
+			values[j] = v
+		}
+		trace.Frequency = append(trace.Frequency, values[0])
+		trace.Trace1 = append(trace.Trace1, values[1])
+		trace.Trace2 = append(trace.Trace2, values[2])
+		trace.Trace3 = append(trace.Trace3, values[3])
+		i++
+	}
+	if err := scanner.Err(); err != nil {
+		return trace, err
+	}
+	if i != trace.NumPoints {
+		return trace, fmt.Errorf("wrong number of data points / got %d / expected %d", i, trace.NumPoints)
+	}
+
+	return trace, nil
+}
+
+// parseHeader parses a labeled header line that has been split into columns.
+func (trace *Trace) parseHeader(columns []string) error {
+	if len(columns) < 2 {
+		return fmt.Errorf("missing value")
+	}
+	label := strings.TrimSuffix(strings.TrimSpace(columns[0]), ":")
+	value := strings.TrimSpace(columns[1])
+	units := ""
+	if len(columns) > 2 {
+		units = columns[2]
+	}
+	var err error
+	switch strings.ToLower(label) {
+	case "title":
+		trace.Title = value
+	case "model":
+		trace.Model = value
+	case "serial number":
+		trace.SerialNum = strings.TrimSuffix(value, "\x00")
+	case "firmware version":
+		trace.FirmwareVersion = value
+	case "center frequency":
+		trace.CenterFreq, err = parseFloat(value)
+		if err == nil {
+			trace.CenterFreqUnits, err = esa.ParseFrequencyUnits(units)
+		}
+	case "span":
+		trace.Span, err = parseFloat(value)
+		if err == nil {
+			trace.SpanUnits, err = esa.ParseFrequencyUnits(units)
+		}
+	case "resolution bandwidth":
+		trace.RBW, err = parseFloat(value)
+		if err == nil {
+			trace.RBWUnits, err = esa.ParseFrequencyUnits(units)
+		}
+	case "video bandwidth":
+		trace.VBW, err = parseFloat(value)
+		if err == nil {
+			trace.VBWUnits, err = esa.ParseFrequencyUnits(units)
+		}
+	case "reference level":
+		trace.RefLevel, err = parseFloat(value)
+		if err == nil {
+			trace.RefLevelUnits, err = esa.ParseAmplitudeUnits(units)
+		}
+	case "attenuation":
+		trace.Attenuation, err = parseFloat(value)
+	case "detector":
+		trace.Detector, err = esa.ParseDetector(value)
+	case "sweep time":
+		trace.SweepTime, err = parseFloat(value)
+		if err == nil {
+			trace.SweepTimeUnits, err = esa.ParseTimeUnits(units)
+		}
+	case "num points", "number of points":
+		trace.NumPoints, err = strconv.Atoi(value)
+	default:
+		if trace.Extra == nil {
+			trace.Extra = make(map[string]string)
+		}
+		trace.Extra[label] = strings.TrimSpace(strings.Join(columns[1:], ","))
+	}
+	return err
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(s), 64)
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp format: %s", s)
+}
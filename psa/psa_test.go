@@ -0,0 +1,121 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package psa
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	got, err := ReadCSVFile("./testdata/e4440a_trace001.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	assert(t, "timestamp", got.Timestamp, time.Date(2022, time.March, 14, 9, 15, 2, 0, time.UTC))
+	assert(t, "original filename", got.OriginalFilename, "C:\\TRACE001.CSV")
+	assert(t, "title", got.Title, "Filter response")
+	assert(t, "model", got.Model, "E4440A")
+	assert(t, "s/n", got.SerialNum, "MY44022215")
+	assert(t, "firmware", got.FirmwareVersion, "A.11.21")
+	assertFloat64(t, "center freq", got.CenterFreq, 1e9, 0.01)
+	assert(t, "center freq units", got.CenterFreqUnits, esa.Hertz)
+	assertFloat64(t, "span", got.Span, 20e6, 0.01)
+	assertFloat64(t, "rbw", got.RBW, 100e3, 0.01)
+	assertFloat64(t, "vbw", got.VBW, 100e3, 0.01)
+	assertFloat64(t, "ref level", got.RefLevel, 0, 0.0001)
+	assert(t, "ref level units", got.RefLevelUnits, esa.DBm)
+	assertFloat64(t, "attenuation", got.Attenuation, 10, 0.0001)
+	assert(t, "detector", got.Detector, esa.DetectorPeak)
+	assertFloat64(t, "sweep time", got.SweepTime, 0.002, 1e-9)
+	assert(t, "sweep time units", got.SweepTimeUnits, esa.Seconds)
+	assert(t, "num points", got.NumPoints, 21)
+	assert(t, "trace 1 label", got.Trace1Label, "Trace 1")
+	assert(t, "freq units", got.FreqUnits, "Hz")
+	assert(t, "trace 1 units", got.Trace1Units, "dBm")
+	assert(t, "freq len", len(got.Frequency), 21)
+	assert(t, "trace 3 len", len(got.Trace3), 21)
+	assertFloat64(t, "freq[10]", got.Frequency[10], 1e9, 0.01)
+	assertFloat64(t, "t1[10]", got.Trace1[10], -10.0, 0.00001)
+	assertFloat64(t, "t2[10]", got.Trace2[10], -13.0, 0.00001)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	header := " 03/14/22   09:15:02,C:\\TRACE001.CSV\n"
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"empty", ""},
+		{"bad timestamp", "yesterday,C:\\TRACE001.CSV\n"},
+		{"bad units", header + "Span:,1,furlongs\n"},
+		{"bad detector", header + "Detector:,Fancy\n"},
+		{"wrong num points", header + "Num Points:,2\n\n,T1,T2,T3\nHz,dBm,dBm,dBm\n1,2,3,4\n"},
+		{"negative num points", header + "Num Points:,-1\n\n,T1,T2,T3\nHz,dBm,dBm,dBm\n1,2,3,4\n"},
+		{"huge num points", header + "Num Points:,2000000000\n\n,T1,T2,T3\nHz,dBm,dBm,dBm\n1,2,3,4\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func BenchmarkReadCSV(b *testing.B) {
+	data, err := os.ReadFile("./testdata/e4440a_trace001.csv")
+	if err != nil {
+		b.Fatal(err)
+	}
+	// Replace the data rows with those of a full 8192 point sweep.
+	const points = 8192
+	header, _, _ := bytes.Cut(data, []byte("Hz,dBm,dBm,dBm\n"))
+	header = bytes.Replace(header, []byte(",21\n"), []byte(fmt.Sprintf(",%d\n", points)), 1)
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteString("Hz,dBm,dBm,dBm\n")
+	for i := 0; i < points; i++ {
+		fmt.Fprintf(&buf, "%.3f, %.5e, %.5e, %.5e\n", 990000000+2500*float64(i),
+			-60+10*math.Sin(float64(i)), -63+10*math.Cos(float64(i)), -100.0)
+	}
+	data = buf.Bytes()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadCSV(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
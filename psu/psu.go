@@ -0,0 +1,186 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package psu controls the Keysight E36xx bench power supplies and the N67xx
+// modular power systems using SCPI commands.
+package psu
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpi"
+)
+
+// Instrument is a live power supply controlled using SCPI commands sent over
+// an io.ReadWriter, such as a connection from the gotmc visa, usbtmc, or
+// vxi11 packages. Channels are numbered from 1.
+type Instrument struct {
+	conn *scpi.Conn
+	// channelList is true if commands address the channel using a channel
+	// list, such as (@2), instead of selecting it using INST:NSEL.
+	channelList bool
+	// now returns the time of a measurement.
+	now func() time.Time
+	// after waits for the delay of a sequence step.
+	after func(time.Duration) <-chan time.Time
+}
+
+// NewE36xx returns an E36xx bench power supply, such as the E3631A or an
+// E36300 series supply, using the given connection, which must terminate
+// each response with a newline. Channels are selected using INST:NSEL
+// before each command.
+func NewE36xx(rw io.ReadWriter) *Instrument {
+	return &Instrument{conn: scpi.New(rw), now: time.Now, after: time.After}
+}
+
+// NewN67xx returns an N67xx modular power system, such as the N6705C, using
+// the given connection, which must terminate each response with a newline.
+// Channels are the module slots, which are addressed using channel lists.
+func NewN67xx(rw io.ReadWriter) *Instrument {
+	return &Instrument{conn: scpi.New(rw), channelList: true, now: time.Now, after: time.After}
+}
+
+// Measurement is the measured output voltage in volts and current in amps
+// of a channel.
+type Measurement struct {
+	Channel int
+	Time    time.Time
+	Voltage float64
+	Current float64
+}
+
+// Power returns the output power in watts.
+func (m Measurement) Power() float64 {
+	return m.Voltage * m.Current
+}
+
+// Step is a step of an output sequence, which turns the output of the
+// channel on or off after the delay.
+type Step struct {
+	Channel int
+	On      bool
+	Delay   time.Duration
+}
+
+// SetVoltage sets the output voltage of the channel in volts.
+func (inst *Instrument) SetVoltage(channel int, volts float64) error {
+	return inst.command(channel, "VOLT", fmt.Sprintf("%g", volts))
+}
+
+// SetCurrent sets the current limit of the channel in amps.
+func (inst *Instrument) SetCurrent(channel int, amps float64) error {
+	return inst.command(channel, "CURR", fmt.Sprintf("%g", amps))
+}
+
+// Voltage returns the voltage setting of the channel in volts.
+func (inst *Instrument) Voltage(channel int) (float64, error) {
+	return inst.queryFloat(channel, "VOLT?")
+}
+
+// Current returns the current limit of the channel in amps.
+func (inst *Instrument) Current(channel int) (float64, error) {
+	return inst.queryFloat(channel, "CURR?")
+}
+
+// Measure measures the output voltage and current of the channel.
+func (inst *Instrument) Measure(channel int) (Measurement, error) {
+	m := Measurement{Channel: channel, Time: inst.now().UTC()}
+	var err error
+	if m.Voltage, err = inst.queryFloat(channel, "MEAS:VOLT?"); err != nil {
+		return m, err
+	}
+	if m.Current, err = inst.queryFloat(channel, "MEAS:CURR?"); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// SetOVP sets the over-voltage protection level of the channel in volts and
+// enables the protection, or disables it if the level is zero. The N67xx
+// over-voltage protection can't be disabled.
+func (inst *Instrument) SetOVP(channel int, volts float64) error {
+	if volts == 0 {
+		if inst.channelList {
+			return fmt.Errorf("over-voltage protection of channel %d can't be disabled", channel)
+		}
+		return inst.command(channel, "VOLT:PROT:STAT", "OFF")
+	}
+	if err := inst.command(channel, "VOLT:PROT", fmt.Sprintf("%g", volts)); err != nil {
+		return err
+	}
+	if inst.channelList {
+		return nil
+	}
+	return inst.command(channel, "VOLT:PROT:STAT", "ON")
+}
+
+// SetOCP enables or disables the over-current protection of the channel,
+// which turns off the output when it reaches the current limit.
+func (inst *Instrument) SetOCP(channel int, on bool) error {
+	return inst.command(channel, "CURR:PROT:STAT", onOff(on))
+}
+
+// SetOutput turns the output of the channel on or off. Supplies with a
+// single output switch, such as the E3631A, switch every channel.
+func (inst *Instrument) SetOutput(channel int, on bool) error {
+	return inst.command(channel, "OUTP", onOff(on))
+}
+
+// Output returns whether the output of the channel is on.
+func (inst *Instrument) Output(channel int) (bool, error) {
+	state, err := inst.queryFloat(channel, "OUTP?")
+	return state == 1, err
+}
+
+// Sequence switches the outputs in the order of the steps, waiting for each
+// step's delay before switching its output. If the context is canceled, the
+// remaining steps are skipped and the context's error is returned.
+func (inst *Instrument) Sequence(ctx context.Context, steps ...Step) error {
+	for _, step := range steps {
+		if step.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-inst.after(step.Delay):
+			}
+		}
+		if err := inst.SetOutput(step.Channel, step.On); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// command sends the command with the argument to the channel.
+func (inst *Instrument) command(channel int, cmd, arg string) error {
+	if inst.channelList {
+		return inst.conn.Command("%s %s,(@%d)", cmd, arg, channel)
+	}
+	if err := inst.conn.Command("INST:NSEL %d", channel); err != nil {
+		return err
+	}
+	return inst.conn.Command("%s %s", cmd, arg)
+}
+
+// queryFloat sends the query to the channel and parses the response.
+func (inst *Instrument) queryFloat(channel int, query string) (float64, error) {
+	if inst.channelList {
+		return inst.conn.QueryFloat(fmt.Sprintf("%s (@%d)", query, channel))
+	}
+	if err := inst.conn.Command("INST:NSEL %d", channel); err != nil {
+		return 0, err
+	}
+	return inst.conn.QueryFloat(query)
+}
+
+func onOff(on bool) string {
+	if on {
+		return "ON"
+	}
+	return "OFF"
+}
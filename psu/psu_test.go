@@ -0,0 +1,115 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package psu
+
+import (
+	"context"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpitest"
+)
+
+func TestInstrument(t *testing.T) {
+	var tests = []struct {
+		name      string
+		newInst   func(io.ReadWriter) *Instrument
+		responses map[string]string
+		commands  string
+	}{
+		{
+			"E36xx",
+			NewE36xx,
+			map[string]string{"MEAS:VOLT?": "+5.00120000E+00", "MEAS:CURR?": "+2.50000000E-01", "OUTP?": "1"},
+			"INST:NSEL 2;VOLT 5;INST:NSEL 2;CURR 0.5;INST:NSEL 2;VOLT:PROT 6;INST:NSEL 2;VOLT:PROT:STAT ON;" +
+				"INST:NSEL 2;CURR:PROT:STAT ON;INST:NSEL 2;OUTP ON;" +
+				"INST:NSEL 2;MEAS:VOLT?;INST:NSEL 2;MEAS:CURR?;INST:NSEL 2;OUTP?",
+		},
+		{
+			"N67xx",
+			NewN67xx,
+			map[string]string{"MEAS:VOLT? (@2)": "+5.00120000E+00", "MEAS:CURR? (@2)": "+2.50000000E-01", "OUTP? (@2)": "1"},
+			"VOLT 5,(@2);CURR 0.5,(@2);VOLT:PROT 6,(@2);CURR:PROT:STAT ON,(@2);OUTP ON,(@2);" +
+				"MEAS:VOLT? (@2);MEAS:CURR? (@2);OUTP? (@2)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := scpitest.New(test.responses)
+			inst := test.newInst(fake)
+			now := time.Date(2024, time.March, 14, 10, 21, 7, 0, time.UTC)
+			inst.now = func() time.Time { return now }
+			for _, err := range []error{
+				inst.SetVoltage(2, 5),
+				inst.SetCurrent(2, 0.5),
+				inst.SetOVP(2, 6),
+				inst.SetOCP(2, true),
+				inst.SetOutput(2, true),
+			} {
+				if err != nil {
+					t.Fatalf("received error: %s", err)
+				}
+			}
+			m, err := inst.Measure(2)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "measurement", m, Measurement{Channel: 2, Time: now, Voltage: 5.0012, Current: 0.25})
+			if math.Abs(m.Power()-1.2503) > 1e-9 {
+				t.Errorf("got power %g, want 1.2503", m.Power())
+			}
+			on, err := inst.Output(2)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "output", on, true)
+			assert(t, "commands", strings.Join(fake.Commands, ";"), test.commands)
+		})
+	}
+	if err := NewN67xx(scpitest.New(nil)).SetOVP(1, 0); err == nil {
+		t.Errorf("expected error disabling N67xx over-voltage protection")
+	}
+}
+
+func TestInstrumentSequence(t *testing.T) {
+	fake := scpitest.New(nil)
+	inst := NewN67xx(fake)
+	var delays []time.Duration
+	inst.after = func(d time.Duration) <-chan time.Time {
+		delays = append(delays, d)
+		c := make(chan time.Time, 1)
+		c <- time.Time{}
+		return c
+	}
+	err := inst.Sequence(context.Background(),
+		Step{Channel: 1, On: true},
+		Step{Channel: 2, On: true, Delay: 10 * time.Millisecond},
+		Step{Channel: 2, On: false, Delay: time.Second},
+	)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "commands", strings.Join(fake.Commands, ";"), "OUTP ON,(@1);OUTP ON,(@2);OUTP OFF,(@2)")
+	assert(t, "delays", len(delays), 2)
+	assert(t, "delay 2", delays[1], time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fake.Commands = nil
+	inst.after = func(time.Duration) <-chan time.Time { return nil }
+	err = inst.Sequence(ctx, Step{Channel: 1, On: true}, Step{Channel: 2, On: true, Delay: time.Hour})
+	assert(t, "canceled", err, context.Canceled)
+	assert(t, "canceled commands", strings.Join(fake.Commands, ";"), "OUTP ON,(@1)")
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package keysight
+
+import "sync"
+
+// FileFormat is a file format added to Detect and Read using Register, so
+// that ReadFile, the keysight command, and the server package can read files
+// of formats that aren't built into this package.
+type FileFormat interface {
+	// Name returns the name of the format returned by Detect, which must be
+	// unique.
+	Name() Format
+	// Detect returns whether the file with the given filename and contents
+	// has the format.
+	Detect(filename string, data []byte) bool
+	// Parse parses the contents of a file with the format. The filename is
+	// the one passed to Read.
+	Parse(filename string, data []byte) (interface{}, error)
+}
+
+// builtinFormats are the formats detected by this package in the order of
+// the Format constants.
+var builtinFormats = []Format{
+	ESATrace, ESALimitLine, ESACorrection, ESAInternal,
+	PSATrace, XSeriesTrace, XSeriesLimitLine, XSeriesIQ,
+	FieldFoxTrace, FieldFoxNetwork, FieldFoxCable,
+	ScopeBin, ScopeCSV, ScopeH5, ScopeOsc,
+	Touchstone, CITIfile, ArbWaveform, ArbSequence,
+	DMMDataLog, HandheldDMMLog, CounterLog, DAQScanLog, LCRSweep,
+	PowerMeterLog, PowerAnalyzerDlog, VSARecording, NoiseFigureResult,
+	CalKit, ENAState,
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []FileFormat
+)
+
+// Register adds the file format to those detected by Detect and read by
+// Read. Registered formats are detected before the built-in formats in the
+// order they were registered, so a format can take over some of the files of
+// a built-in format. Register is typically called from the init function of
+// the package implementing the format. It panics if the name of the format
+// is empty or already used by another format.
+func Register(f FileFormat) {
+	name := f.Name()
+	if name == "" {
+		panic("keysight: Register format without a name")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, builtin := range builtinFormats {
+		if name == builtin {
+			panic("keysight: Register of built-in format " + string(name))
+		}
+	}
+	if lookupFormat(name) != nil {
+		panic("keysight: Register called twice for format " + string(name))
+	}
+	registry = append(registry, f)
+}
+
+// Formats returns the names of the built-in formats followed by those of
+// the registered formats.
+func Formats() []Format {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	formats := make([]Format, 0, len(builtinFormats)+len(registry))
+	formats = append(formats, builtinFormats...)
+	for _, f := range registry {
+		formats = append(formats, f.Name())
+	}
+	return formats
+}
+
+// registeredFormats returns a copy of the registered formats, so that they
+// can be used without holding the lock.
+func registeredFormats() []FileFormat {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]FileFormat(nil), registry...)
+}
+
+// lookupFormat returns the registered format with the given name or nil.
+// The caller must hold registryMu.
+func lookupFormat(name Format) FileFormat {
+	for _, f := range registry {
+		if f.Name() == name {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package keysight
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// labNotes is a format registered by the tests. It takes over the CSV files
+// starting with a "# lab notes" line.
+type labNotes struct {
+	name Format
+}
+
+func (f labNotes) Name() Format { return f.name }
+
+func (f labNotes) Detect(filename string, data []byte) bool {
+	return strings.HasSuffix(filename, ".csv") && bytes.HasPrefix(data, []byte("# lab notes"))
+}
+
+func (f labNotes) Parse(filename string, data []byte) (interface{}, error) {
+	return strings.Split(strings.TrimSpace(string(data)), "\n")[1:], nil
+}
+
+// brokenFormat is a format registered by the tests whose parser panics.
+type brokenFormat struct{}
+
+func (brokenFormat) Name() Format { return "broken" }
+
+func (brokenFormat) Detect(filename string, data []byte) bool {
+	return strings.HasSuffix(filename, ".broken")
+}
+
+func (brokenFormat) Parse(filename string, data []byte) (interface{}, error) {
+	var values []float64
+	return values[len(data)], nil
+}
+
+func init() {
+	Register(labNotes{"lab notes"})
+	Register(brokenFormat{})
+}
+
+func TestRegister(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "notes.csv")
+	if err := os.WriteFile(filename, []byte("# lab notes\nmodel,E4402B\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := Detect(filename, data)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "format", format, Format("lab notes"))
+	v, err := ReadFile(filename)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	lines, ok := v.([]string)
+	if !ok || len(lines) != 1 {
+		t.Fatalf("got %#v, want one line", v)
+	}
+	assert(t, "line", lines[0], "model,E4402B")
+
+	formats := Formats()
+	assert(t, "num formats", len(formats), len(builtinFormats)+2)
+	assert(t, "first format", formats[0], ESATrace)
+	assert(t, "registered format", formats[len(builtinFormats)], Format("lab notes"))
+}
+
+func TestReadRecoversPanic(t *testing.T) {
+	if _, err := Read("data.broken", []byte("x")); err == nil {
+		t.Errorf("expected error from panicking parser")
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	var tests = []struct {
+		name   string
+		format FileFormat
+	}{
+		{"empty name", labNotes{""}},
+		{"built-in name", labNotes{ESATrace}},
+		{"duplicate name", labNotes{"lab notes"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			Register(test.format)
+		})
+	}
+}
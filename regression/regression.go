@@ -0,0 +1,409 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package regression verifies spectrum analyzer traces against a golden
+// trace, such as on a production test station. A Golden contains the
+// nominal trace of a known good unit along with the lower and upper bounds
+// of each point, which are either a tolerance band around a single golden
+// trace or the envelope of several golden sweeps.
+//
+// Goldens are stored as JSON so that they can be versioned along with the
+// test programs, and Verify returns a Report that also encodes as JSON for
+// test executives and dashboards. Traces are compared using esa.Compare, so
+// they're converted to the units of the golden and interpolated onto its
+// frequencies if their grids differ.
+package regression
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/tracemath"
+)
+
+// JSONSchema and JSONSchemaVersion identify the JSON encoding of a Golden.
+const (
+	JSONSchema        = "keysight.regression.golden"
+	JSONSchemaVersion = 1
+)
+
+// Golden is a golden trace with the bounds of each point.
+type Golden struct {
+	Name    string
+	Created time.Time
+	// Sweeps is the number of sweeps the golden was built from.
+	Sweeps int
+	// Reference contains the settings of the golden trace, with the
+	// nominal amplitude of each point in Trace 1.
+	Reference esa.Trace
+	// Lower and Upper are the bounds of each point of the reference in the
+	// reference's amplitude units.
+	Lower []float64
+	Upper []float64
+}
+
+// Tolerance returns the allowed deviation below and above the nominal
+// amplitude at a frequency in Hz.
+type Tolerance func(freq float64) (below, above float64)
+
+// Uniform returns a tolerance allowing the same deviation at every
+// frequency.
+func Uniform(below, above float64) Tolerance {
+	return func(float64) (float64, float64) {
+		return below, above
+	}
+}
+
+// Option configures how goldens are built and traces are verified.
+type Option func(*config)
+
+type config struct {
+	name           string
+	trace          int
+	strictSettings bool
+	now            func() time.Time
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{trace: 1, now: time.Now}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithName sets the name of a golden, such as the part number and test.
+func WithName(name string) Option {
+	return func(cfg *config) {
+		cfg.name = name
+	}
+}
+
+// WithTrace sets the trace column, numbered from 1, of the golden sweeps or
+// the verified trace. The default is Trace 1.
+func WithTrace(n int) Option {
+	return func(cfg *config) {
+		cfg.trace = n
+	}
+}
+
+// WithStrictSettings fails verification if any header setting of the trace,
+// such as the RBW, differs from the golden. By default, the differences are
+// reported without failing.
+func WithStrictSettings() Option {
+	return func(cfg *config) {
+		cfg.strictSettings = true
+	}
+}
+
+// FromTrace returns a golden whose bounds are the tolerance around each
+// point of the trace.
+func FromTrace(trace esa.Trace, tol Tolerance, opts ...Option) (Golden, error) {
+	cfg := newConfig(opts)
+	data, err := column(trace, cfg.trace)
+	if err != nil {
+		return Golden{}, err
+	}
+	g := Golden{
+		Name:      cfg.name,
+		Created:   cfg.now().UTC(),
+		Sweeps:    1,
+		Reference: reference(trace, data),
+		Lower:     make([]float64, len(data.Values)),
+		Upper:     make([]float64, len(data.Values)),
+	}
+	for i, v := range data.Values {
+		below, above := tol(trace.Frequency[i])
+		g.Lower[i], g.Upper[i] = v-below, v+above
+	}
+	return g, nil
+}
+
+// FromSweeps returns a golden whose bounds are the envelope of the sweeps
+// widened by the margin, such as sweeps of several known good units. The
+// nominal amplitude is the mean of the sweeps in the units of the first
+// sweep, which the other sweeps are converted to. The sweeps must have the
+// same frequencies.
+func FromSweeps(sweeps []esa.Trace, margin float64, opts ...Option) (Golden, error) {
+	cfg := newConfig(opts)
+	if len(sweeps) == 0 {
+		return Golden{}, fmt.Errorf("no sweeps given")
+	}
+	first, err := column(sweeps[0], cfg.trace)
+	if err != nil {
+		return Golden{}, fmt.Errorf("sweep 0: %s", err)
+	}
+	units := traceUnits(sweeps[0], first)
+	traces := make([]tracemath.Trace, len(sweeps))
+	for i, sweep := range sweeps {
+		data, err := column(sweep, cfg.trace)
+		if err != nil {
+			return Golden{}, fmt.Errorf("sweep %d: %s", i, err)
+		}
+		if u := traceUnits(sweep, data); units != "" && u != "" && u != units {
+			converted, err := sweep.ConvertAmplitudeUnits(esa.AmplitudeUnits(units))
+			if err != nil {
+				return Golden{}, fmt.Errorf("sweep %d: %s", i, err)
+			}
+			if data, err = column(converted, cfg.trace); err != nil {
+				return Golden{}, fmt.Errorf("sweep %d: %s", i, err)
+			}
+		}
+		traces[i] = tracemath.Trace{Frequency: sweep.Frequency, Values: data.Values}
+	}
+	lower, err := tracemath.MinHold(traces)
+	if err != nil {
+		return Golden{}, err
+	}
+	upper, err := tracemath.MaxHold(traces)
+	if err != nil {
+		return Golden{}, err
+	}
+	mean, err := tracemath.Mean(traces)
+	if err != nil {
+		return Golden{}, err
+	}
+	first.Units = units
+	first.Values = mean.Values
+	g := Golden{
+		Name:      cfg.name,
+		Created:   cfg.now().UTC(),
+		Sweeps:    len(sweeps),
+		Reference: reference(sweeps[0], first),
+		Lower:     tracemath.Offset(lower, -margin).Values,
+		Upper:     tracemath.Offset(upper, margin).Values,
+	}
+	return g, nil
+}
+
+// column returns the trace column with the given number.
+func column(trace esa.Trace, n int) (esa.TraceData, error) {
+	traces := trace.Traces()
+	if n < 1 || n > len(traces) {
+		return esa.TraceData{}, fmt.Errorf("no trace %d", n)
+	}
+	data := traces[n-1]
+	if len(data.Values) == 0 {
+		return data, fmt.Errorf("trace %d is empty", n)
+	}
+	if len(data.Values) != len(trace.Frequency) {
+		return data, fmt.Errorf("mismatched lengths / freq %d / trace %d %d", len(trace.Frequency), n, len(data.Values))
+	}
+	return data, nil
+}
+
+// traceUnits returns the units of the trace column, which default to the
+// reference level units.
+func traceUnits(trace esa.Trace, data esa.TraceData) string {
+	if data.Units != "" {
+		return data.Units
+	}
+	return string(trace.RefLevelUnits)
+}
+
+// reference returns a copy of the trace containing only the data as
+// Trace 1.
+func reference(trace esa.Trace, data esa.TraceData) esa.Trace {
+	data.Values = append([]float64(nil), data.Values...)
+	trace.Frequency = append([]float64(nil), trace.Frequency...)
+	trace.ExtraTraces = nil
+	trace.SetTraces([]esa.TraceData{data})
+	return trace
+}
+
+// Violation is a point of a verified trace outside the bounds of the golden.
+type Violation struct {
+	Frequency float64 `json:"frequency"`
+	Value     float64 `json:"value"`
+	Lower     float64 `json:"lower"`
+	Upper     float64 `json:"upper"`
+	// Margin is the distance to the nearest bound, which is negative
+	// outside the bounds.
+	Margin float64 `json:"margin"`
+}
+
+// Setting is a header setting of the trace that differs from the golden.
+type Setting struct {
+	Setting  string `json:"setting"`
+	Golden   string `json:"golden"`
+	Measured string `json:"measured"`
+}
+
+// Report is the result of verifying a trace against a golden.
+type Report struct {
+	Golden string `json:"golden"`
+	// Pass reports whether every point of the golden was checked and is
+	// within its bounds, and, with WithStrictSettings, whether the settings
+	// match.
+	Pass     bool      `json:"pass"`
+	Settings []Setting `json:"settings"`
+	Units    string    `json:"units"`
+	// Checked is the number of points checked, and Missing the number of
+	// points of the golden outside the frequency range of the trace.
+	Checked int `json:"checked"`
+	Missing int `json:"missing"`
+	// WorstMargin is the smallest distance from a point to its nearest
+	// bound at WorstFrequency, which is negative if the point fails.
+	WorstMargin    float64     `json:"worstMargin"`
+	WorstFrequency float64     `json:"worstFrequency"`
+	MaxDelta       float64     `json:"maxDelta"`
+	RMSDelta       float64     `json:"rmsDelta"`
+	Violations     []Violation `json:"violations"`
+}
+
+// Verify checks the trace against the golden. Points whose amplitude isn't
+// a number are violations.
+func (g Golden) Verify(trace esa.Trace, opts ...Option) (Report, error) {
+	cfg := newConfig(opts)
+	if n := len(g.Reference.Frequency); len(g.Lower) != n || len(g.Upper) != n {
+		return Report{}, fmt.Errorf("mismatched lengths / freq %d / lower %d / upper %d", n, len(g.Lower), len(g.Upper))
+	}
+	data, err := column(trace, cfg.trace)
+	if err != nil {
+		return Report{}, err
+	}
+	measured := trace
+	measured.ExtraTraces = nil
+	measured.SetTraces([]esa.TraceData{data})
+	cmp, err := esa.Compare(g.Reference, measured)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{
+		Golden:      g.Name,
+		Settings:    []Setting{},
+		Units:       cmp.Units,
+		Checked:     len(cmp.Points),
+		Missing:     len(g.Reference.Frequency) - len(cmp.Points),
+		WorstMargin: math.Inf(1),
+		MaxDelta:    cmp.MaxDelta,
+		RMSDelta:    cmp.RMSDelta,
+		Violations:  []Violation{},
+	}
+	for _, s := range cmp.Settings {
+		report.Settings = append(report.Settings, Setting{s.Setting, s.A, s.B})
+	}
+	// The compared points are the points of the golden covered by the
+	// trace, in order.
+	i := 0
+	for _, p := range cmp.Points {
+		for g.Reference.Frequency[i] != p.Frequency {
+			i++
+		}
+		lower, upper := g.Lower[i], g.Upper[i]
+		margin := math.Min(p.B-lower, upper-p.B)
+		if margin < report.WorstMargin {
+			report.WorstMargin, report.WorstFrequency = margin, p.Frequency
+		}
+		if !(margin >= 0) {
+			report.Violations = append(report.Violations, Violation{p.Frequency, p.B, lower, upper, margin})
+		}
+	}
+	if math.IsInf(report.WorstMargin, 1) {
+		report.WorstMargin = 0
+	}
+	report.Pass = len(report.Violations) == 0 && report.Missing == 0 &&
+		(!cfg.strictSettings || len(report.Settings) == 0)
+	return report, nil
+}
+
+// jsonGolden is version 1 of the JSON schema of a Golden. The reference is
+// encoded using the JSON schema of an esa.Trace.
+type jsonGolden struct {
+	Schema    string     `json:"schema"`
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Created   *time.Time `json:"created,omitempty"`
+	Sweeps    int        `json:"sweeps"`
+	Reference esa.Trace  `json:"reference"`
+	Lower     []float64  `json:"lower"`
+	Upper     []float64  `json:"upper"`
+}
+
+// MarshalJSON implements the json.Marshaler interface using the versioned
+// schema identified by JSONSchema and JSONSchemaVersion. The bounds must be
+// finite numbers.
+func (g Golden) MarshalJSON() ([]byte, error) {
+	jg := jsonGolden{
+		Schema:    JSONSchema,
+		Version:   JSONSchemaVersion,
+		Name:      g.Name,
+		Sweeps:    g.Sweeps,
+		Reference: g.Reference,
+		Lower:     g.Lower,
+		Upper:     g.Upper,
+	}
+	if !g.Created.IsZero() {
+		jg.Created = &g.Created
+	}
+	return json.Marshal(jg)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It returns an
+// error if the data uses a different schema or a newer schema version.
+func (g *Golden) UnmarshalJSON(data []byte) error {
+	var jg jsonGolden
+	if err := json.Unmarshal(data, &jg); err != nil {
+		return err
+	}
+	if jg.Schema != JSONSchema {
+		return fmt.Errorf("unknown schema: %q", jg.Schema)
+	}
+	if jg.Version < 1 || jg.Version > JSONSchemaVersion {
+		return fmt.Errorf("unsupported schema version: %d", jg.Version)
+	}
+	if n := len(jg.Reference.Frequency); len(jg.Lower) != n || len(jg.Upper) != n {
+		return fmt.Errorf("mismatched lengths / freq %d / lower %d / upper %d", n, len(jg.Lower), len(jg.Upper))
+	}
+	*g = Golden{
+		Name:      jg.Name,
+		Sweeps:    jg.Sweeps,
+		Reference: jg.Reference,
+		Lower:     jg.Lower,
+		Upper:     jg.Upper,
+	}
+	if jg.Created != nil {
+		g.Created = *jg.Created
+	}
+	return nil
+}
+
+// ReadFile reads the golden stored in the named JSON file.
+func ReadFile(filename string) (Golden, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Golden{}, err
+	}
+	var g Golden
+	if err := json.Unmarshal(data, &g); err != nil {
+		return Golden{}, fmt.Errorf("error reading golden %s: %s", filename, err)
+	}
+	return g, nil
+}
+
+// WriteFile writes the golden as JSON to the named file.
+func (g Golden) WriteFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := g.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write writes the golden as indented JSON.
+func (g Golden) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package regression
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+func readTrace(t *testing.T) esa.Trace {
+	t.Helper()
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	return trace
+}
+
+// offset returns a copy of the trace with the offset added to Trace 1 at
+// the given indexes, or every point if none are given.
+func offset(trace esa.Trace, dB float64, indexes ...int) esa.Trace {
+	trace.Trace1 = append([]float64(nil), trace.Trace1...)
+	if len(indexes) == 0 {
+		for i := range trace.Trace1 {
+			indexes = append(indexes, i)
+		}
+	}
+	for _, i := range indexes {
+		trace.Trace1[i] += dB
+	}
+	return trace
+}
+
+func TestFromTrace(t *testing.T) {
+	trace := readTrace(t)
+	g, err := FromTrace(trace, func(freq float64) (float64, float64) {
+		if freq < 20e3 {
+			return 3, 3
+		}
+		return 1, 2
+	}, WithName("filter"), WithTrace(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, "name", g.Name, "filter")
+	assert(t, "sweeps", g.Sweeps, 1)
+	assert(t, "reference traces", len(g.Reference.Traces()), 1)
+	assert(t, "nominal", g.Reference.Trace1[0], trace.Trace2[0])
+	assertFloat64(t, "lower", g.Lower[0], trace.Trace2[0]-3)
+	assertFloat64(t, "upper", g.Upper[400], trace.Trace2[400]+2)
+
+	if _, err := FromTrace(trace, Uniform(1, 1), WithTrace(4)); err == nil {
+		t.Error("expected error for missing trace")
+	}
+}
+
+func TestFromSweeps(t *testing.T) {
+	trace := readTrace(t)
+	dBm, err := offset(trace, 2).ConvertAmplitudeUnits(esa.DBm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := FromSweeps([]esa.Trace{trace, dBm, offset(trace, -1)}, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, "sweeps", g.Sweeps, 3)
+	assert(t, "units", g.Reference.Trace1Units, "dBuV")
+	assertFloat64(t, "nominal", g.Reference.Trace1[10], trace.Trace1[10]+1.0/3)
+	assertFloat64(t, "lower", g.Lower[10], trace.Trace1[10]-1.5)
+	assertFloat64(t, "upper", g.Upper[10], trace.Trace1[10]+2.5)
+
+	if _, err := FromSweeps(nil, 1); err == nil {
+		t.Error("expected error for no sweeps")
+	}
+	short := trace
+	short.Frequency, short.Trace1 = trace.Frequency[:10], trace.Trace1[:10]
+	short.Trace2, short.Trace3 = nil, nil
+	if _, err := FromSweeps([]esa.Trace{trace, short}, 1); err == nil {
+		t.Error("expected error for different frequencies")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	trace := readTrace(t)
+	g, err := FromTrace(trace, Uniform(1, 1), WithName("golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rbw := offset(trace, 0.5)
+	rbw.RBW *= 3
+	dBm, err := trace.ConvertAmplitudeUnits(esa.DBm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name       string
+		trace      esa.Trace
+		opts       []Option
+		pass       bool
+		settings   int
+		violations int
+		missing    int
+		margin     float64
+	}{
+		{"identical", trace, nil, true, 0, 0, 0, 1},
+		{"within tolerance", rbw, nil, true, 1, 0, 0, 0.5},
+		{"strict settings", rbw, []Option{WithStrictSettings()}, false, 1, 0, 0, 0.5},
+		{"converted units", dBm, nil, true, 1, 0, 0, 1},
+		{"outside tolerance", offset(trace, -1.5, 100, 200), nil, false, 0, 2, 0, -0.5},
+		{"trace 2", trace, []Option{WithTrace(2)}, false, 0, 401, 0, math.Inf(1)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report, err := g.Verify(test.trace, test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert(t, "golden", report.Golden, "golden")
+			assert(t, "pass", report.Pass, test.pass)
+			assert(t, "settings", len(report.Settings), test.settings)
+			assert(t, "violations", len(report.Violations), test.violations)
+			assert(t, "missing", report.Missing, test.missing)
+			assert(t, "checked", report.Checked, 401)
+			if !math.IsInf(test.margin, 1) {
+				assertFloat64(t, "worst margin", report.WorstMargin, test.margin)
+			}
+		})
+	}
+
+	report, err := g.Verify(offset(trace, -1.5, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := report.Violations[0]
+	assert(t, "violation freq", v.Frequency, trace.Frequency[100])
+	assertFloat64(t, "violation value", v.Value, trace.Trace1[100]-1.5)
+	assertFloat64(t, "violation lower", v.Lower, trace.Trace1[100]-1)
+	assert(t, "worst freq", report.WorstFrequency, trace.Frequency[100])
+
+	// A trace covering part of the golden fails with the missing points.
+	part := trace
+	part.Frequency, part.Trace1 = trace.Frequency[:200], trace.Trace1[:200]
+	part.Trace2, part.Trace3 = nil, nil
+	report, err = g.Verify(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, "partial pass", report.Pass, false)
+	assert(t, "partial checked", report.Checked, 200)
+	assert(t, "partial missing", report.Missing, 201)
+	assert(t, "partial violations", len(report.Violations), 0)
+}
+
+func TestReportJSON(t *testing.T) {
+	trace := readTrace(t)
+	g, err := FromTrace(trace, Uniform(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := g.Verify(offset(trace, 2, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"pass":false`, `"settings":[]`, `"violations":[{"frequency":9625,`, `"worstMargin":-1`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report JSON doesn't contain %s:\n%s", want, data)
+		}
+	}
+}
+
+func TestGoldenFile(t *testing.T) {
+	trace := readTrace(t)
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	g, err := FromTrace(trace, Uniform(1, 2), WithName("unit 42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Created = created
+	filename := filepath.Join(t.TempDir(), "golden.json")
+	if err := g.WriteFile(filename); err != nil {
+		t.Fatalf("error writing golden: %s", err)
+	}
+	got, err := ReadFile(filename)
+	if err != nil {
+		t.Fatalf("error reading golden: %s", err)
+	}
+	assert(t, "name", got.Name, "unit 42")
+	assert(t, "created", got.Created.Equal(created), true)
+	assert(t, "reference model", got.Reference.Model, trace.Model)
+	assert(t, "points", len(got.Reference.Frequency), 401)
+	assert(t, "lower", got.Lower[7], g.Lower[7])
+	assert(t, "upper", got.Upper[7], g.Upper[7])
+	report, err := got.Verify(trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, "round trip pass", report.Pass, true)
+
+	var b bytes.Buffer
+	g.Write(&b)
+	for _, data := range []string{
+		strings.Replace(b.String(), JSONSchema, "other", 1),
+		strings.Replace(b.String(), `"version": 1`, `"version": 2`, 1),
+		strings.Replace(b.String(), `"lower": [`, `"lower": [1,`, 1),
+	} {
+		var g Golden
+		if err := json.Unmarshal([]byte(data), &g); err == nil {
+			t.Error("expected error decoding invalid golden")
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: got %g, want %g", label, got, want)
+	}
+}
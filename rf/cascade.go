@@ -0,0 +1,86 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Cascade returns the two-port of the given two-ports connected in order,
+// with port 2 of each connected to port 1 of the next. The two-ports must
+// have the same frequencies and reference impedance.
+func Cascade(networks ...Network) (Network, error) {
+	if len(networks) == 0 {
+		return Network{}, errors.New("no networks to cascade")
+	}
+	if err := checkCompatible(2, networks...); err != nil {
+		return Network{}, err
+	}
+	chains := make([][]Matrix, len(networks))
+	for i, n := range networks {
+		abcd, err := n.ABCD()
+		if err != nil {
+			return Network{}, fmt.Errorf("error converting network %d: %s", i+1, err)
+		}
+		chains[i] = abcd
+	}
+	total := chains[0]
+	for _, abcd := range chains[1:] {
+		product := make([]Matrix, len(total))
+		for k := range total {
+			product[k] = total[k].mul(abcd[k])
+		}
+		total = product
+	}
+	return FromABCD(networks[0].Frequency, total, networks[0].Z0)
+}
+
+// Deembed returns the two-port measured between the left and right
+// fixtures, removing their effect from the measurement, which is the
+// inverse of cascading the left fixture, the device, and the right fixture.
+// A fixture without data, such as the zero Network, is omitted, so a single
+// fixture can be de-embedded.
+func Deembed(measured, left, right Network) (Network, error) {
+	networks := []Network{measured}
+	for _, fixture := range []Network{left, right} {
+		if len(fixture.S) > 0 {
+			networks = append(networks, fixture)
+		}
+	}
+	if err := checkCompatible(2, networks...); err != nil {
+		return Network{}, err
+	}
+	abcd, err := measured.ABCD()
+	if err != nil {
+		return Network{}, fmt.Errorf("error converting measurement: %s", err)
+	}
+	fixtures := []struct {
+		side    string
+		network Network
+	}{{"left", left}, {"right", right}}
+	for _, f := range fixtures {
+		if len(f.network.S) == 0 {
+			continue
+		}
+		chain, err := f.network.ABCD()
+		if err != nil {
+			return Network{}, fmt.Errorf("error converting %s fixture: %s", f.side, err)
+		}
+		for k := range abcd {
+			inv, err := chain[k].inverse()
+			if err != nil {
+				return Network{}, fmt.Errorf("error inverting %s fixture at %g Hz: %s", f.side, measured.Frequency[k], err)
+			}
+			if f.side == "left" {
+				abcd[k] = inv.mul(abcd[k])
+			} else {
+				abcd[k] = abcd[k].mul(inv)
+			}
+		}
+	}
+	return FromABCD(measured.Frequency, abcd, measured.Z0)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import "testing"
+
+func TestCascade(t *testing.T) {
+	got, err := Cascade(series(t, 20), series(t, complex(30, 5)))
+	if err != nil {
+		t.Fatalf("received error cascading: %s", err)
+	}
+	assertNetwork(t, got, series(t, complex(50, 5)), 1e-12)
+
+	if _, err := Cascade(); err == nil {
+		t.Errorf("expected error for no networks")
+	}
+	other := series(t, 20)
+	other.Z0 = 75
+	if _, err := Cascade(series(t, 20), other); err == nil {
+		t.Errorf("expected error for mismatched impedances")
+	}
+	other = series(t, 20)
+	other.Frequency = []float64{1e9, 3e9}
+	if _, err := Cascade(series(t, 20), other); err == nil {
+		t.Errorf("expected error for mismatched frequencies")
+	}
+	oneport := Network{Frequency: testFreqs, Z0: 50, S: []Matrix{{{0}}, {{0}}}}
+	if _, err := Cascade(oneport); err == nil {
+		t.Errorf("expected error for a one-port")
+	}
+}
+
+func TestDeembed(t *testing.T) {
+	left, err := Cascade(series(t, complex(5, 12)), shunt(t, complex(0, 0.002)))
+	if err != nil {
+		t.Fatalf("received error cascading: %s", err)
+	}
+	right := shunt(t, complex(0.001, 0.003))
+	dut := series(t, complex(40, -15))
+	measured, err := Cascade(left, dut, right)
+	if err != nil {
+		t.Fatalf("received error cascading: %s", err)
+	}
+	var tests = []struct {
+		name        string
+		measured    Network
+		left, right Network
+	}{
+		{"both", measured, left, right},
+		{"left", mustCascade(t, left, dut), left, Network{}},
+		{"right", mustCascade(t, dut, right), Network{}, right},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Deembed(test.measured, test.left, test.right)
+			if err != nil {
+				t.Fatalf("received error de-embedding: %s", err)
+			}
+			assertNetwork(t, got, dut, 1e-12)
+		})
+	}
+	blocked := Network{Frequency: testFreqs, Z0: 50, S: []Matrix{{{1, 0}, {0, 1}}, {{1, 0}, {0, 1}}}}
+	if _, err := Deembed(measured, blocked, Network{}); err == nil {
+		t.Errorf("expected error for a fixture without transmission")
+	}
+}
+
+func mustCascade(t *testing.T, networks ...Network) Network {
+	t.Helper()
+	n, err := Cascade(networks...)
+	if err != nil {
+		t.Fatalf("received error cascading: %s", err)
+	}
+	return n
+}
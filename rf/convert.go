@@ -0,0 +1,179 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import (
+	"fmt"
+)
+
+// Z returns the impedance parameters in ohms at each frequency, which are
+// Z0 (I + S)(I − S)⁻¹. They don't exist for networks such as a series
+// element, for which an error is returned.
+func (n Network) Z() ([]Matrix, error) {
+	return n.convert(sToZ)
+}
+
+// Y returns the admittance parameters in siemens at each frequency, which
+// are (I − S)(I + S)⁻¹ / Z0. They don't exist for networks such as a shunt
+// element, for which an error is returned.
+func (n Network) Y() ([]Matrix, error) {
+	return n.convert(sToY)
+}
+
+// ABCD returns the chain parameters of a two-port at each frequency, with B
+// in ohms and C in siemens. They don't exist if S21 is zero.
+func (n Network) ABCD() ([]Matrix, error) {
+	if n.Ports() != 2 {
+		return nil, fmt.Errorf("ABCD parameters require a two-port / got %d ports", n.Ports())
+	}
+	return n.convert(sToABCD)
+}
+
+func (n Network) convert(f func(Matrix, float64) (Matrix, error)) ([]Matrix, error) {
+	values := make([]Matrix, len(n.S))
+	for k, s := range n.S {
+		m, err := f(s, n.Z0)
+		if err != nil {
+			return nil, fmt.Errorf("error at %g Hz: %s", n.frequency(k), err)
+		}
+		values[k] = m
+	}
+	return values, nil
+}
+
+// frequency returns the kth frequency, or zero if it's missing.
+func (n Network) frequency(k int) float64 {
+	if k < len(n.Frequency) {
+		return n.Frequency[k]
+	}
+	return 0
+}
+
+// FromZ returns the network with the given impedance parameters in ohms
+// normalized to the reference impedance z0.
+func FromZ(freq []float64, z []Matrix, z0 float64) (Network, error) {
+	return fromParameters(freq, z, z0, zToS)
+}
+
+// FromY returns the network with the given admittance parameters in
+// siemens normalized to the reference impedance z0.
+func FromY(freq []float64, y []Matrix, z0 float64) (Network, error) {
+	return fromParameters(freq, y, z0, yToS)
+}
+
+// FromABCD returns the two-port with the given chain parameters normalized
+// to the reference impedance z0.
+func FromABCD(freq []float64, abcd []Matrix, z0 float64) (Network, error) {
+	for _, m := range abcd {
+		if len(m) != 2 {
+			return Network{}, fmt.Errorf("ABCD parameters require a two-port / got %d ports", len(m))
+		}
+	}
+	return fromParameters(freq, abcd, z0, abcdToS)
+}
+
+func fromParameters(freq []float64, values []Matrix, z0 float64, f func(Matrix, float64) (Matrix, error)) (Network, error) {
+	if len(values) != len(freq) {
+		return Network{}, fmt.Errorf("mismatched lengths / freq %d / data %d", len(freq), len(values))
+	}
+	if z0 <= 0 {
+		return Network{}, fmt.Errorf("invalid reference impedance: %g", z0)
+	}
+	n := Network{Frequency: freq, Z0: z0, S: make([]Matrix, len(values))}
+	for k, m := range values {
+		s, err := f(m, z0)
+		if err != nil {
+			return Network{}, fmt.Errorf("error at %g Hz: %s", freq[k], err)
+		}
+		n.S[k] = s
+	}
+	return n, nil
+}
+
+// Renormalize returns the network with its S-parameters normalized to the
+// reference impedance z0 instead of Z0, which are
+// (S − ΓI)(I − ΓS)⁻¹ where Γ is the reflection coefficient of Z0 relative to
+// z0. Unlike converting through the Z-parameters, this works for every
+// network.
+func (n Network) Renormalize(z0 float64) (Network, error) {
+	if z0 <= 0 {
+		return Network{}, fmt.Errorf("invalid reference impedance: %g", z0)
+	}
+	gamma := complex((z0-n.Z0)/(z0+n.Z0), 0)
+	r := Network{Frequency: n.Frequency, Z0: z0, S: make([]Matrix, len(n.S))}
+	for k, s := range n.S {
+		id := identity(len(s))
+		inv, err := id.sub(s.scale(gamma)).inverse()
+		if err != nil {
+			return Network{}, fmt.Errorf("error at %g Hz: %s", n.frequency(k), err)
+		}
+		r.S[k] = s.sub(id.scale(gamma)).mul(inv)
+	}
+	return r, nil
+}
+
+func sToZ(s Matrix, z0 float64) (Matrix, error) {
+	id := identity(len(s))
+	inv, err := id.sub(s).inverse()
+	if err != nil {
+		return nil, err
+	}
+	return id.add(s).mul(inv).scale(complex(z0, 0)), nil
+}
+
+func sToY(s Matrix, z0 float64) (Matrix, error) {
+	id := identity(len(s))
+	inv, err := id.add(s).inverse()
+	if err != nil {
+		return nil, err
+	}
+	return id.sub(s).mul(inv).scale(complex(1/z0, 0)), nil
+}
+
+func zToS(z Matrix, z0 float64) (Matrix, error) {
+	id := identity(len(z)).scale(complex(z0, 0))
+	inv, err := z.add(id).inverse()
+	if err != nil {
+		return nil, err
+	}
+	return z.sub(id).mul(inv), nil
+}
+
+func yToS(y Matrix, z0 float64) (Matrix, error) {
+	id := identity(len(y))
+	zy := y.scale(complex(z0, 0))
+	inv, err := id.add(zy).inverse()
+	if err != nil {
+		return nil, err
+	}
+	return id.sub(zy).mul(inv), nil
+}
+
+func sToABCD(s Matrix, z0 float64) (Matrix, error) {
+	s11, s12, s21, s22 := s[0][0], s[0][1], s[1][0], s[1][1]
+	if s21 == 0 {
+		return nil, errSingular
+	}
+	z := complex(z0, 0)
+	d := 2 * s21
+	return Matrix{
+		{((1+s11)*(1-s22) + s12*s21) / d, z * ((1+s11)*(1+s22) - s12*s21) / d},
+		{((1-s11)*(1-s22) - s12*s21) / (d * z), ((1-s11)*(1+s22) + s12*s21) / d},
+	}, nil
+}
+
+func abcdToS(m Matrix, z0 float64) (Matrix, error) {
+	a, b, c, d := m[0][0], m[0][1], m[1][0], m[1][1]
+	z := complex(z0, 0)
+	den := a + b/z + c*z + d
+	if den == 0 {
+		return nil, errSingular
+	}
+	return Matrix{
+		{(a + b/z - c*z - d) / den, 2 * (a*d - b*c) / den},
+		{2 / den, (-a + b/z - c*z + d) / den},
+	}, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import "testing"
+
+func TestSeriesResistor(t *testing.T) {
+	n := series(t, 50)
+	// A 50 Ω series resistor between 50 Ω ports.
+	assertComplex(t, "s11", n.S[0][0][0], complex(1.0/3, 0), 1e-12)
+	assertComplex(t, "s21", n.S[0][1][0], complex(2.0/3, 0), 1e-12)
+	assertComplex(t, "s12", n.S[0][0][1], complex(2.0/3, 0), 1e-12)
+	assertComplex(t, "s22", n.S[0][1][1], complex(1.0/3, 0), 1e-12)
+
+	y, err := n.Y()
+	if err != nil {
+		t.Fatalf("received error converting to Y: %s", err)
+	}
+	assertComplex(t, "y11", y[0][0][0], 0.02, 1e-12)
+	assertComplex(t, "y21", y[0][1][0], -0.02, 1e-12)
+	if _, err := n.Z(); err == nil {
+		t.Errorf("expected error for Z-parameters of a series element")
+	}
+	abcd, err := n.ABCD()
+	if err != nil {
+		t.Fatalf("received error converting to ABCD: %s", err)
+	}
+	assertComplex(t, "a", abcd[1][0][0], 1, 1e-12)
+	assertComplex(t, "b", abcd[1][0][1], 50, 1e-12)
+	assertComplex(t, "c", abcd[1][1][0], 0, 1e-12)
+	assertComplex(t, "d", abcd[1][1][1], 1, 1e-12)
+}
+
+func TestShuntCapacitor(t *testing.T) {
+	y := complex(0, 0.01)
+	n := shunt(t, y)
+	z, err := n.Z()
+	if err != nil {
+		t.Fatalf("received error converting to Z: %s", err)
+	}
+	for _, v := range []complex128{z[0][0][0], z[0][0][1], z[0][1][0], z[0][1][1]} {
+		assertComplex(t, "z", v, 1/y, 1e-9)
+	}
+	if _, err := n.Y(); err == nil {
+		t.Errorf("expected error for Y-parameters of a shunt element")
+	}
+	back, err := FromZ(n.Frequency, z, n.Z0)
+	if err != nil {
+		t.Fatalf("received error converting from Z: %s", err)
+	}
+	assertNetwork(t, back, n, 1e-12)
+}
+
+func TestRoundTrip(t *testing.T) {
+	// A pi attenuator has Z, Y, and ABCD parameters.
+	n, err := Cascade(shunt(t, 0.005), series(t, complex(30, 10)), shunt(t, complex(0.004, -0.001)))
+	if err != nil {
+		t.Fatalf("received error cascading: %s", err)
+	}
+	z, err := n.Z()
+	if err != nil {
+		t.Fatalf("received error converting to Z: %s", err)
+	}
+	y, err := n.Y()
+	if err != nil {
+		t.Fatalf("received error converting to Y: %s", err)
+	}
+	// Z and Y are inverses.
+	product := z[0].mul(y[0])
+	for i := range product {
+		for j := range product[i] {
+			want := complex128(0)
+			if i == j {
+				want = 1
+			}
+			assertComplex(t, "zy", product[i][j], want, 1e-12)
+		}
+	}
+	fromY, err := FromY(n.Frequency, y, n.Z0)
+	if err != nil {
+		t.Fatalf("received error converting from Y: %s", err)
+	}
+	assertNetwork(t, fromY, n, 1e-12)
+}
+
+func TestRenormalize(t *testing.T) {
+	// A 100 Ω load.
+	n := Network{Frequency: testFreqs, Z0: 50, S: []Matrix{{{1.0 / 3}}, {{1.0 / 3}}}}
+	var tests = []struct {
+		z0   float64
+		want complex128
+	}{
+		{100, 0},
+		{75, complex(25.0/175, 0)},
+		{50, complex(1.0/3, 0)},
+	}
+	for _, test := range tests {
+		r, err := n.Renormalize(test.z0)
+		if err != nil {
+			t.Fatalf("received error renormalizing: %s", err)
+		}
+		assertFloat64(t, "z0", r.Z0, test.z0, 1e-12)
+		assertComplex(t, "s11", r.S[0][0][0], test.want, 1e-12)
+	}
+
+	// Renormalizing a two-port and back is lossless.
+	two := series(t, complex(20, -5))
+	r, err := two.Renormalize(75)
+	if err != nil {
+		t.Fatalf("received error renormalizing: %s", err)
+	}
+	abcd, _ := r.ABCD()
+	assertComplex(t, "b", abcd[0][0][1], complex(20, -5), 1e-12)
+	back, err := r.Renormalize(50)
+	if err != nil {
+		t.Fatalf("received error renormalizing: %s", err)
+	}
+	assertNetwork(t, back, two, 1e-12)
+	if _, err := two.Renormalize(0); err == nil {
+		t.Errorf("expected error for invalid impedance")
+	}
+}
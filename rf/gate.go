@@ -0,0 +1,159 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/gotmc/keysight/internal/dsp"
+)
+
+// GateShape is the shape of a time-domain gate, which sets how gradually it
+// turns on and off, using the names of the PNA gate shapes.
+type GateShape int
+
+// Available gate shapes.
+const (
+	GateNormal GateShape = iota
+	GateMinimum
+	GateWide
+	GateMaximum
+)
+
+// gateEdges are the widths of the gate edges of each shape in reciprocal
+// frequency spans, which are the cutoff times of the PNA gate shapes.
+var gateEdges = map[GateShape]float64{
+	GateMinimum: 1.4,
+	GateNormal:  2.8,
+	GateWide:    4.4,
+	GateMaximum: 12.7,
+}
+
+// String implements the Stringer interface for GateShape.
+func (s GateShape) String() string {
+	switch s {
+	case GateMinimum:
+		return "Minimum"
+	case GateNormal:
+		return "Normal"
+	case GateWide:
+		return "Wide"
+	case GateMaximum:
+		return "Maximum"
+	}
+	return fmt.Sprintf("GateShape(%d)", int(s))
+}
+
+// GateOption configures a time-domain gate.
+type GateOption func(*gateConfig)
+
+type gateConfig struct {
+	shape GateShape
+	notch bool
+}
+
+// WithGateShape sets the shape of the gate. The default is GateNormal.
+func WithGateShape(s GateShape) GateOption {
+	return func(cfg *gateConfig) {
+		cfg.shape = s
+	}
+}
+
+// WithNotch makes the gate remove the responses between the start and stop
+// times instead of keeping them.
+func WithNotch() GateOption {
+	return func(cfg *gateConfig) {
+		cfg.notch = true
+	}
+}
+
+// Gate returns the network with every S-parameter gated in the time domain
+// between the start and stop times in seconds, which removes the effect of
+// the responses outside the gate, such as the reflections of connectors or
+// fixtures, from the frequency response. Each S-parameter is transformed to
+// the time domain over the alias-free range, multiplied by the gate, and
+// transformed back. The gate has raised cosine edges that are half on at
+// the start and stop times, and whose width depends on the shape and the
+// frequency span. The frequencies must be evenly spaced.
+func (n Network) Gate(start, stop float64, opts ...GateOption) (Network, error) {
+	var cfg gateConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	edge, ok := gateEdges[cfg.shape]
+	if !ok {
+		return Network{}, fmt.Errorf("unknown gate shape: %s", cfg.shape)
+	}
+	if stop <= start {
+		return Network{}, fmt.Errorf("gate stop %g s isn't after start %g s", stop, start)
+	}
+	if len(n.S) != len(n.Frequency) {
+		return Network{}, fmt.Errorf("mismatched lengths / freq %d / data %d", len(n.Frequency), len(n.S))
+	}
+	_, df, err := linearSweep(n.Frequency)
+	if err != nil {
+		return Network{}, err
+	}
+	period := 1 / df
+	edge /= n.Frequency[len(n.Frequency)-1] - n.Frequency[0]
+	center := (start + stop) / 2
+	// Oversample the time response so the gate edges are smooth.
+	size := 1 << bits.Len(uint(8*len(n.Frequency)-1))
+	gate := make([]float64, size)
+	for m := range gate {
+		t := float64(m) * period / float64(size)
+		// Use the alias of the time nearest the gate.
+		t -= period * math.Round((t-center)/period)
+		g := gateEdge(t-start, edge) * gateEdge(stop-t, edge)
+		if cfg.notch {
+			g = 1 - g
+		}
+		gate[m] = g
+	}
+
+	gated := Network{Frequency: n.Frequency, Z0: n.Z0, S: make([]Matrix, len(n.S))}
+	for k := range gated.S {
+		gated.S[k] = newMatrix(n.Ports())
+	}
+	x := make([]complex128, size)
+	for i := 0; i < n.Ports(); i++ {
+		for j := 0; j < n.Ports(); j++ {
+			// The inverse FFT is the conjugate of the FFT of the conjugate,
+			// and the start frequency only changes the phase of the time
+			// response, which the real gate doesn't affect.
+			for m := range x {
+				x[m] = 0
+			}
+			for k, s := range n.S {
+				x[k] = cmplx.Conj(s[i][j])
+			}
+			dsp.FFT(x)
+			for m := range x {
+				x[m] = cmplx.Conj(x[m]) * complex(gate[m]/float64(size), 0)
+			}
+			dsp.FFT(x)
+			for k := range gated.S {
+				gated.S[k][i][j] = x[k]
+			}
+		}
+	}
+	return gated, nil
+}
+
+// gateEdge returns the raised cosine edge of the given width, which is zero
+// before -width/2, one after width/2, and one half at zero.
+func gateEdge(t, width float64) float64 {
+	switch {
+	case t <= -width/2:
+		return 0
+	case t >= width/2:
+		return 1
+	}
+	return 0.5 * (1 + math.Sin(math.Pi*t/width))
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import (
+	"errors"
+	"math/cmplx"
+)
+
+// Matrix is a square matrix of network parameters indexed by row and
+// column.
+type Matrix [][]complex128
+
+// errSingular is returned when a matrix can't be inverted, such as when
+// converting a network whose Z-parameters don't exist.
+var errSingular = errors.New("singular matrix")
+
+func newMatrix(n int) Matrix {
+	m := make(Matrix, n)
+	for i := range m {
+		m[i] = make([]complex128, n)
+	}
+	return m
+}
+
+func identity(n int) Matrix {
+	m := newMatrix(n)
+	for i := range m {
+		m[i][i] = 1
+	}
+	return m
+}
+
+func (m Matrix) clone() Matrix {
+	c := make(Matrix, len(m))
+	for i, row := range m {
+		c[i] = append([]complex128(nil), row...)
+	}
+	return c
+}
+
+func (m Matrix) add(b Matrix) Matrix {
+	c := newMatrix(len(m))
+	for i := range m {
+		for j := range m[i] {
+			c[i][j] = m[i][j] + b[i][j]
+		}
+	}
+	return c
+}
+
+func (m Matrix) sub(b Matrix) Matrix {
+	return m.add(b.scale(-1))
+}
+
+func (m Matrix) scale(s complex128) Matrix {
+	c := newMatrix(len(m))
+	for i := range m {
+		for j := range m[i] {
+			c[i][j] = s * m[i][j]
+		}
+	}
+	return c
+}
+
+func (m Matrix) mul(b Matrix) Matrix {
+	c := newMatrix(len(m))
+	for i := range m {
+		for j := range m {
+			for k := range m {
+				c[i][j] += m[i][k] * b[k][j]
+			}
+		}
+	}
+	return c
+}
+
+// inverse returns the inverse of the matrix using Gauss-Jordan elimination
+// with partial pivoting.
+func (m Matrix) inverse() (Matrix, error) {
+	n := len(m)
+	a := m.clone()
+	inv := identity(n)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if cmplx.Abs(a[row][col]) > cmplx.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if cmplx.Abs(a[pivot][col]) < 1e-14 {
+			return nil, errSingular
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+		p := a[col][col]
+		for j := 0; j < n; j++ {
+			a[col][j] /= p
+			inv[col][j] /= p
+		}
+		for row := 0; row < n; row++ {
+			if row == col || a[row][col] == 0 {
+				continue
+			}
+			f := a[row][col]
+			for j := 0; j < n; j++ {
+				a[row][j] -= f * a[col][j]
+				inv[row][j] -= f * inv[col][j]
+			}
+		}
+	}
+	return inv, nil
+}
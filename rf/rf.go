@@ -0,0 +1,203 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package rf post-processes the network data exported by the Keysight ENA
+// and PNA network analyzers as Touchstone files or CITIfiles. A Network holds
+// the S-parameters of an N-port normalized to a real reference impedance,
+// which can be renormalized to another reference impedance and converted to
+// and from Z, Y, and two-port ABCD parameters. Two-ports can be cascaded,
+// and fixtures can be de-embedded from a measurement. Like the time-domain
+// option of the PNA, the S-parameters can be transformed to the time domain
+// and gated.
+package rf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/citifile"
+	"github.com/gotmc/keysight/touchstone"
+)
+
+// DefaultImpedance is the reference impedance in ohms used for CITIfile
+// data, which doesn't record it.
+const DefaultImpedance = 50
+
+// Network contains the S-parameters of an N-port at each frequency, which
+// are normalized to the same real reference impedance at every port.
+type Network struct {
+	// Frequency is in Hz.
+	Frequency []float64
+	// Z0 is the reference impedance in ohms.
+	Z0 float64
+	// S contains the S-parameters indexed by frequency, so that S[k][1][0] is
+	// S21 at Frequency[k].
+	S []Matrix
+}
+
+// Ports returns the number of ports of the network, or zero if the network
+// has no data.
+func (n Network) Ports() int {
+	if len(n.S) == 0 {
+		return 0
+	}
+	return len(n.S[0])
+}
+
+// At returns the S-parameter for the given one-based port indices at every
+// frequency. For example, At(2, 1) returns S21.
+func (n Network) At(i, j int) []complex128 {
+	ports := n.Ports()
+	if i < 1 || j < 1 || i > ports || j > ports {
+		return nil
+	}
+	values := make([]complex128, len(n.S))
+	for k := range n.S {
+		values[k] = n.S[k][i-1][j-1]
+	}
+	return values
+}
+
+// FromTouchstone returns the network of the Touchstone data, converting Z
+// and Y parameters, which are normalized to the reference resistance, to
+// S-parameters.
+func FromTouchstone(s touchstone.SParameters) (Network, error) {
+	if len(s.Data) != len(s.Frequency) {
+		return Network{}, fmt.Errorf("mismatched lengths / freq %d / data %d", len(s.Frequency), len(s.Data))
+	}
+	if s.R <= 0 {
+		return Network{}, fmt.Errorf("invalid reference resistance: %g", s.R)
+	}
+	n := Network{Frequency: s.Frequency, Z0: s.R, S: make([]Matrix, len(s.Data))}
+	for k, data := range s.Data {
+		m := Matrix(data).clone()
+		var err error
+		switch strings.ToUpper(s.Parameter) {
+		case "S", "":
+		case "Z":
+			m, err = zToS(m.scale(complex(s.R, 0)), s.R)
+		case "Y":
+			m, err = yToS(m.scale(complex(1/s.R, 0)), s.R)
+		default:
+			return Network{}, fmt.Errorf("unsupported parameter: %s", s.Parameter)
+		}
+		if err != nil {
+			return Network{}, fmt.Errorf("error converting %s-parameters at %g Hz: %s", s.Parameter, s.Frequency[k], err)
+		}
+		n.S[k] = m
+	}
+	return n, nil
+}
+
+// Touchstone returns the network as S-parameters in real and imaginary
+// format with the frequencies in Hz, ready to be written as a Touchstone
+// file.
+func (n Network) Touchstone() touchstone.SParameters {
+	data := make([][][]complex128, len(n.S))
+	for k, m := range n.S {
+		data[k] = m.clone()
+	}
+	return touchstone.SParameters{
+		Ports:     n.Ports(),
+		FreqUnit:  "Hz",
+		Parameter: "S",
+		Format:    touchstone.RI,
+		R:         n.Z0,
+		Frequency: append([]float64(nil), n.Frequency...),
+		Data:      data,
+	}
+}
+
+// citiDataName matches the S-parameter data names of a CITIfile, such as
+// S[2,1] or S21.
+var citiDataName = regexp.MustCompile(`(?i)^S(?:\[(\d+),(\d+)\]|(\d)(\d))$`)
+
+// FromCITI returns the network of the S-parameters in the CITIfile package,
+// whose frequencies are given by the FREQ variable. All the S-parameters of
+// the network must be present, and the reference impedance is
+// DefaultImpedance.
+func FromCITI(p citifile.Package) (Network, error) {
+	freq, ok := p.Var("FREQ")
+	if !ok {
+		return Network{}, errors.New("missing FREQ variable")
+	}
+	type entry struct {
+		i, j   int
+		values []complex128
+	}
+	var entries []entry
+	ports := 0
+	for _, name := range p.DataNames {
+		m := citiDataName.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		if m[1] == "" {
+			m[1], m[2] = m[3], m[4]
+		}
+		i, _ := strconv.Atoi(m[1])
+		j, _ := strconv.Atoi(m[2])
+		if i < 1 || j < 1 {
+			return Network{}, fmt.Errorf("invalid data name: %s", name)
+		}
+		values := p.Data[name]
+		if len(values) != len(freq.Values) {
+			return Network{}, fmt.Errorf("wrong number of %s values / got %d / expected %d", name, len(values), len(freq.Values))
+		}
+		entries = append(entries, entry{i, j, values})
+		ports = max(ports, i, j)
+	}
+	if ports == 0 {
+		return Network{}, errors.New("package has no S-parameters")
+	}
+	if len(entries) != ports*ports {
+		return Network{}, fmt.Errorf("wrong number of S-parameters / got %d / expected %d", len(entries), ports*ports)
+	}
+	n := Network{Frequency: freq.Values, Z0: DefaultImpedance, S: make([]Matrix, len(freq.Values))}
+	for k := range n.S {
+		n.S[k] = newMatrix(ports)
+	}
+	seen := make(map[[2]int]bool)
+	for _, e := range entries {
+		if seen[[2]int{e.i, e.j}] {
+			return Network{}, fmt.Errorf("duplicate S[%d,%d]", e.i, e.j)
+		}
+		seen[[2]int{e.i, e.j}] = true
+		for k, v := range e.values {
+			n.S[k][e.i-1][e.j-1] = v
+		}
+	}
+	return n, nil
+}
+
+// checkCompatible checks that the networks have the given number of ports
+// and the same frequencies and reference impedance.
+func checkCompatible(ports int, networks ...Network) error {
+	first := networks[0]
+	for _, n := range networks {
+		if len(n.S) != len(n.Frequency) {
+			return fmt.Errorf("mismatched lengths / freq %d / data %d", len(n.Frequency), len(n.S))
+		}
+		if n.Ports() != ports {
+			return fmt.Errorf("wrong number of ports / got %d / expected %d", n.Ports(), ports)
+		}
+		if n.Z0 != first.Z0 {
+			return fmt.Errorf("mismatched reference impedances / got %g / expected %g", n.Z0, first.Z0)
+		}
+		if len(n.Frequency) != len(first.Frequency) {
+			return fmt.Errorf("wrong number of frequencies / got %d / expected %d", len(n.Frequency), len(first.Frequency))
+		}
+		for k, f := range n.Frequency {
+			if math.Abs(f-first.Frequency[k]) > 1e-9*math.Abs(f) {
+				return fmt.Errorf("mismatched frequencies / got %g / expected %g", f, first.Frequency[k])
+			}
+		}
+	}
+	return nil
+}
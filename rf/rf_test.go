@@ -0,0 +1,160 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/gotmc/keysight/citifile"
+	"github.com/gotmc/keysight/touchstone"
+)
+
+var testFreqs = []float64{1e9, 2e9}
+
+// series returns the two-port of a series impedance in ohms at the test
+// frequencies.
+func series(t *testing.T, z complex128) Network {
+	return fromChain(t, Matrix{{1, z}, {0, 1}})
+}
+
+// shunt returns the two-port of a shunt admittance in siemens at the test
+// frequencies.
+func shunt(t *testing.T, y complex128) Network {
+	return fromChain(t, Matrix{{1, 0}, {y, 1}})
+}
+
+func fromChain(t *testing.T, abcd Matrix) Network {
+	t.Helper()
+	n, err := FromABCD(testFreqs, []Matrix{abcd, abcd}, 50)
+	if err != nil {
+		t.Fatalf("received error creating network: %s", err)
+	}
+	return n
+}
+
+func TestFromTouchstone(t *testing.T) {
+	s, err := touchstone.ReadFile("../touchstone/testdata/e5071c_filter.s2p")
+	if err != nil {
+		t.Fatalf("received error reading touchstone: %s", err)
+	}
+	n, err := FromTouchstone(s)
+	if err != nil {
+		t.Fatalf("received error converting touchstone: %s", err)
+	}
+	assert(t, "ports", n.Ports(), 2)
+	assertFloat64(t, "z0", n.Z0, 50, 1e-12)
+	assert(t, "num freqs", len(n.Frequency), 3)
+	s21 := s.At(2, 1)
+	for k, v := range n.At(2, 1) {
+		assertComplex(t, "s21", v, s21[k], 1e-12)
+	}
+	assert(t, "bad port", n.At(3, 1) == nil, true)
+
+	out := n.Touchstone()
+	assert(t, "format", out.Format, touchstone.RI)
+	assert(t, "freq unit", out.FreqUnit, "Hz")
+	assert(t, "out ports", out.Ports, 2)
+	assertComplex(t, "out s12", out.Data[1][0][1], s.Data[1][0][1], 1e-12)
+}
+
+func TestFromTouchstoneZY(t *testing.T) {
+	// A 25 Ω series resistor has normalized Z-parameters that don't exist,
+	// so use a 100 Ω shunt resistor, whose normalized Z-parameters are all 2.
+	want := shunt(t, 0.01)
+	z := touchstone.SParameters{
+		Ports:     2,
+		Parameter: "Z",
+		R:         50,
+		Frequency: testFreqs,
+		Data:      [][][]complex128{{{2, 2}, {2, 2}}, {{2, 2}, {2, 2}}},
+	}
+	n, err := FromTouchstone(z)
+	if err != nil {
+		t.Fatalf("received error converting Z-parameters: %s", err)
+	}
+	assertNetwork(t, n, want, 1e-12)
+
+	// The normalized Y-parameters of a 25 Ω series resistor are ±2.
+	want = series(t, 25)
+	y := z
+	y.Parameter = "Y"
+	y.Data = [][][]complex128{{{2, -2}, {-2, 2}}, {{2, -2}, {-2, 2}}}
+	if n, err = FromTouchstone(y); err != nil {
+		t.Fatalf("received error converting Y-parameters: %s", err)
+	}
+	assertNetwork(t, n, want, 1e-12)
+
+	h := z
+	h.Parameter = "H"
+	if _, err := FromTouchstone(h); err == nil {
+		t.Errorf("expected error for H-parameters")
+	}
+}
+
+func TestFromCITI(t *testing.T) {
+	pkg := citifile.Package{
+		Vars:      []citifile.Var{{Name: "FREQ", Values: testFreqs}},
+		DataNames: []string{"S[1,1]", "S21", "S[1,2]", "S[2,2]"},
+		Data: map[string][]complex128{
+			"S[1,1]": {0.1, 0.2},
+			"S21":    {0.9i, 0.8i},
+			"S[1,2]": {0.9i, 0.8i},
+			"S[2,2]": {-0.1, -0.2},
+		},
+	}
+	n, err := FromCITI(pkg)
+	if err != nil {
+		t.Fatalf("received error converting CITIfile: %s", err)
+	}
+	assert(t, "ports", n.Ports(), 2)
+	assertFloat64(t, "z0", n.Z0, DefaultImpedance, 1e-12)
+	assertComplex(t, "s21", n.S[1][1][0], 0.8i, 1e-12)
+	assertComplex(t, "s22", n.S[0][1][1], -0.1, 1e-12)
+
+	pkgs, err := citifile.ReadFile("../citifile/testdata/n5230c_two_port.cti")
+	if err != nil {
+		t.Fatalf("received error reading CITIfile: %s", err)
+	}
+	// The file only contains S11 and S21.
+	if _, err := FromCITI(pkgs[0]); err == nil {
+		t.Errorf("expected error for missing S-parameters")
+	}
+}
+
+func assertNetwork(t *testing.T, got, want Network, tolerance float64) {
+	t.Helper()
+	if len(got.S) != len(want.S) {
+		t.Fatalf("got %d frequencies / want %d", len(got.S), len(want.S))
+	}
+	for k := range want.S {
+		for i := range want.S[k] {
+			for j := range want.S[k][i] {
+				assertComplex(t, "s", got.S[k][i][j], want.S[k][i][j], tolerance)
+			}
+		}
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func assertComplex(t *testing.T, label string, got, want complex128, tolerance float64) {
+	t.Helper()
+	if diff := cmplx.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %v \t\nwant %s = %v", label, got, label, want)
+	}
+}
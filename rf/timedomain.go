@@ -0,0 +1,313 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/gotmc/keysight/internal/dsp"
+)
+
+// DefaultKaiserBeta is the default Kaiser window beta of the time-domain
+// transform, which is the normal window of the PNA.
+const DefaultKaiserBeta = 6
+
+// TransformMode is the mode of the time-domain transform, which are the
+// modes of the PNA time-domain option.
+type TransformMode int
+
+// Available transform modes.
+const (
+	// BandpassImpulse works with any linear sweep, and returns the complex
+	// impulse response, whose magnitude shows the location of
+	// discontinuities.
+	BandpassImpulse TransformMode = iota
+	// LowpassImpulse requires a harmonic sweep, whose frequencies are
+	// multiples of the start frequency, and returns the real impulse
+	// response, whose sign shows the type of discontinuity.
+	LowpassImpulse
+	// LowpassStep requires a harmonic sweep, and returns the real step
+	// response, which is the reflection coefficient versus time of a TDR.
+	LowpassStep
+)
+
+// String implements the Stringer interface for TransformMode.
+func (m TransformMode) String() string {
+	switch m {
+	case BandpassImpulse:
+		return "Bandpass Impulse"
+	case LowpassImpulse:
+		return "Lowpass Impulse"
+	case LowpassStep:
+		return "Lowpass Step"
+	}
+	return fmt.Sprintf("TransformMode(%d)", int(m))
+}
+
+// TimeOption configures the time-domain transform.
+type TimeOption func(*timeConfig)
+
+type timeConfig struct {
+	mode        TransformMode
+	beta        float64
+	start, stop float64
+	hasSpan     bool
+	points      int
+}
+
+// WithMode sets the transform mode. The default is BandpassImpulse.
+func WithMode(m TransformMode) TimeOption {
+	return func(cfg *timeConfig) {
+		cfg.mode = m
+	}
+}
+
+// WithKaiserBeta sets the beta of the Kaiser window applied to the
+// frequency data, which trades the sidelobe level for the width of the
+// response. Zero is a rectangular window, the minimum window of the PNA, and
+// 13 is the maximum window. The default is DefaultKaiserBeta.
+func WithKaiserBeta(beta float64) TimeOption {
+	return func(cfg *timeConfig) {
+		cfg.beta = beta
+	}
+}
+
+// WithTimeSpan sets the start and stop times in seconds of the time-domain
+// response. By default, the response spans the alias-free range, which is
+// the reciprocal of the frequency step, starting at zero.
+func WithTimeSpan(start, stop float64) TimeOption {
+	return func(cfg *timeConfig) {
+		cfg.start, cfg.stop, cfg.hasSpan = start, stop, true
+	}
+}
+
+// WithTimePoints sets the number of points of the time-domain response. The
+// default is the number of frequencies.
+func WithTimePoints(n int) TimeOption {
+	return func(cfg *timeConfig) {
+		cfg.points = n
+	}
+}
+
+// TimeDomain is the time-domain response of an S-parameter.
+type TimeDomain struct {
+	Mode TransformMode
+	// Time is in seconds.
+	Time []float64
+	// Values contains the response at each time, which is real for the
+	// lowpass modes. The impulse responses are scaled so that a frequency
+	// independent S-parameter has a peak of the same value.
+	Values []complex128
+}
+
+// TimeDomain returns the time-domain transform of the S-parameter with the
+// given one-based port indices, such as TimeDomain(1, 1) for the TDR of
+// port 1. The frequencies must be evenly spaced. The transform is evaluated
+// at arbitrary times using the chirp-z transform, so the time span isn't
+// tied to the frequency step like an inverse FFT. The DC value of the
+// lowpass modes is extrapolated from the two lowest frequencies.
+func (n Network) TimeDomain(i, j int, opts ...TimeOption) (TimeDomain, error) {
+	cfg := timeConfig{beta: DefaultKaiserBeta, points: len(n.Frequency)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	values := n.At(i, j)
+	if values == nil {
+		return TimeDomain{}, fmt.Errorf("invalid S-parameter S%d%d of %d-port", i, j, n.Ports())
+	}
+	if len(values) != len(n.Frequency) {
+		return TimeDomain{}, fmt.Errorf("mismatched lengths / freq %d / data %d", len(n.Frequency), len(values))
+	}
+	f0, df, err := linearSweep(n.Frequency)
+	if err != nil {
+		return TimeDomain{}, err
+	}
+	if cfg.points < 1 {
+		return TimeDomain{}, fmt.Errorf("invalid number of time points: %d", cfg.points)
+	}
+	if cfg.beta < 0 {
+		return TimeDomain{}, fmt.Errorf("invalid Kaiser beta: %g", cfg.beta)
+	}
+	if !cfg.hasSpan {
+		cfg.start, cfg.stop = 0, float64(cfg.points-1)/(float64(cfg.points)*df)
+	}
+	if cfg.stop < cfg.start {
+		return TimeDomain{}, fmt.Errorf("stop time %g s is before start time %g s", cfg.stop, cfg.start)
+	}
+	td := TimeDomain{Mode: cfg.mode, Time: make([]float64, cfg.points)}
+	dt := 0.0
+	if cfg.points > 1 {
+		dt = (cfg.stop - cfg.start) / float64(cfg.points-1)
+	}
+	for m := range td.Time {
+		td.Time[m] = cfg.start + float64(m)*dt
+	}
+
+	switch cfg.mode {
+	case BandpassImpulse:
+		window := kaiser(len(values), cfg.beta)
+		a := make([]complex128, len(values))
+		sum := 0.0
+		for k, v := range values {
+			a[k] = v * complex(window[k], 0)
+			sum += window[k]
+		}
+		td.Values = chirpZ(a, f0, df, cfg.start, dt, cfg.points)
+		for m := range td.Values {
+			td.Values[m] /= complex(sum, 0)
+		}
+	case LowpassImpulse, LowpassStep:
+		if math.Abs(f0-df) > 1e-6*df {
+			return TimeDomain{}, fmt.Errorf("lowpass transform requires harmonic frequencies / start %g Hz / step %g Hz", f0, df)
+		}
+		if len(values) < 2 {
+			return TimeDomain{}, errors.New("lowpass transform requires at least 2 frequencies")
+		}
+		td.Values = lowpass(values, df, cfg, td.Time, dt)
+	default:
+		return TimeDomain{}, fmt.Errorf("unknown transform mode: %s", cfg.mode)
+	}
+	return td, nil
+}
+
+// lowpass returns the lowpass impulse or step response at the times, using
+// the Hermitian extension of the data to negative frequencies, so the
+// response is real.
+func lowpass(values []complex128, df float64, cfg timeConfig, times []float64, dt float64) []complex128 {
+	n := len(values)
+	// The two-sided window is centered on DC.
+	window := kaiser(2*n+1, cfg.beta)[n:]
+	dc := extrapolateDC(values[0], values[1])
+	a := make([]complex128, n)
+	result := make([]complex128, len(times))
+	if cfg.mode == LowpassImpulse {
+		sum := window[0]
+		for k, v := range values {
+			a[k] = v * complex(window[k+1], 0)
+			sum += 2 * window[k+1]
+		}
+		x := chirpZ(a, df, df, cfg.start, dt, len(times))
+		for m := range result {
+			result[m] = complex((window[0]*dc+2*real(x[m]))/sum, 0)
+		}
+		return result
+	}
+	// The step response is the integral of the impulse response, with unit
+	// area, from half the alias-free range before zero, where the response
+	// is taken as zero.
+	period := 1 / df
+	offset := 0.0
+	for k, v := range values {
+		harmonic := float64(k + 1)
+		c := v * complex(window[k+1], 0) / complex(0, 2*math.Pi*harmonic*df)
+		a[k] = c
+		offset += real(c) * math.Cos(math.Pi*harmonic)
+	}
+	x := chirpZ(a, df, df, cfg.start, dt, len(times))
+	for m, t := range times {
+		result[m] = complex(df*(window[0]*dc*(t+period/2)+2*(real(x[m])-offset)), 0)
+	}
+	return result
+}
+
+// extrapolateDC returns the real DC value extrapolated linearly in
+// magnitude and phase from the values at the two lowest harmonic
+// frequencies, which is exact for a delayed frequency independent response.
+func extrapolateDC(v1, v2 complex128) float64 {
+	if v1 == 0 {
+		return 0
+	}
+	mag := 2*cmplx.Abs(v1) - cmplx.Abs(v2)
+	phase := cmplx.Phase(v1) - cmplx.Phase(v2/v1)
+	return mag * math.Cos(phase)
+}
+
+// linearSweep returns the start frequency and step of evenly spaced
+// frequencies.
+func linearSweep(freqs []float64) (float64, float64, error) {
+	if len(freqs) < 2 {
+		return 0, 0, fmt.Errorf("not enough frequencies / got %d / expected at least 2", len(freqs))
+	}
+	df := (freqs[len(freqs)-1] - freqs[0]) / float64(len(freqs)-1)
+	if df <= 0 {
+		return 0, 0, errors.New("frequencies must be increasing")
+	}
+	for k, f := range freqs {
+		if math.Abs(f-(freqs[0]+float64(k)*df)) > 1e-6*df {
+			return 0, 0, fmt.Errorf("frequencies aren't evenly spaced at %g Hz", f)
+		}
+	}
+	return freqs[0], df, nil
+}
+
+// kaiser returns the n point symmetric Kaiser window.
+func kaiser(n int, beta float64) []float64 {
+	w := make([]float64, n)
+	if n == 1 {
+		w[0] = 1
+		return w
+	}
+	for i := range w {
+		r := 2*float64(i)/float64(n-1) - 1
+		w[i] = besselI0(beta*math.Sqrt(math.Max(0, 1-r*r))) / besselI0(beta)
+	}
+	return w
+}
+
+// besselI0 returns the zeroth order modified Bessel function of the first
+// kind using its power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > 1e-16*sum; k++ {
+		f := x / (2 * float64(k))
+		term *= f * f
+		sum += term
+	}
+	return sum
+}
+
+// chirpZ returns Σ a[k] exp(j2π (f0 + k df)(t0 + m dt)) for m from 0 to
+// points-1 using Bluestein's algorithm, which computes the sum as a
+// convolution with FFTs of a power of two length.
+func chirpZ(a []complex128, f0, df, t0, dt float64, points int) []complex128 {
+	n := len(a)
+	size := 1 << bits.Len(uint(n+points-2))
+	theta := df * dt
+	// chirp returns exp(jπ θ k²), reducing the phase to keep its precision.
+	chirp := func(k int) complex128 {
+		return cmplx.Exp(complex(0, math.Pi*math.Mod(theta*float64(k)*float64(k), 2)))
+	}
+	phase := func(f, t float64) complex128 {
+		return cmplx.Exp(complex(0, 2*math.Pi*math.Mod(f*t, 1)))
+	}
+	b := make([]complex128, size)
+	for k, v := range a {
+		b[k] = v * phase(float64(k)*df, t0) * chirp(k)
+	}
+	c := make([]complex128, size)
+	for k := 0; k < points; k++ {
+		c[k] = cmplx.Conj(chirp(k))
+	}
+	for k := 1; k < n; k++ {
+		c[size-k] = cmplx.Conj(chirp(k))
+	}
+	dsp.FFT(b)
+	dsp.FFT(c)
+	for i := range b {
+		b[i] = cmplx.Conj(b[i] * c[i])
+	}
+	dsp.FFT(b)
+	x := make([]complex128, points)
+	for m := range x {
+		conv := cmplx.Conj(b[m]) / complex(float64(size), 0)
+		x[m] = phase(f0, t0+float64(m)*dt) * chirp(m) * conv
+	}
+	return x
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rf
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// oneport returns the one-port with the given reflection coefficient at
+// each of the n frequencies from start in steps of df.
+func oneport(n int, start, df float64, s11 func(f float64) complex128) Network {
+	net := Network{Z0: 50, Frequency: make([]float64, n), S: make([]Matrix, n)}
+	for k := range net.Frequency {
+		f := start + float64(k)*df
+		net.Frequency[k] = f
+		net.S[k] = Matrix{{s11(f)}}
+	}
+	return net
+}
+
+// delayed returns the response of a frequency independent reflection
+// coefficient delayed by tau seconds.
+func delayed(gamma complex128, tau float64) func(float64) complex128 {
+	return func(f float64) complex128 {
+		return gamma * cmplx.Exp(complex(0, -2*math.Pi*f*tau))
+	}
+}
+
+func TestChirpZ(t *testing.T) {
+	a := []complex128{1, 2 - 1i, -0.5i, 3, 0.25 + 0.5i}
+	f0, df, t0, dt := 1.5e9, 10e6, -2e-9, 0.37e-9
+	x := chirpZ(a, f0, df, t0, dt, 7)
+	for m := range x {
+		var want complex128
+		for k, v := range a {
+			want += v * cmplx.Exp(complex(0, 2*math.Pi*(f0+float64(k)*df)*(t0+float64(m)*dt)))
+		}
+		assertComplex(t, "czt", x[m], want, 1e-9)
+	}
+}
+
+func TestBandpassImpulse(t *testing.T) {
+	net := oneport(201, 1e9, 10e6, delayed(-0.5, 2e-9))
+	for _, beta := range []float64{0, DefaultKaiserBeta, 13} {
+		td, err := net.TimeDomain(1, 1, WithKaiserBeta(beta), WithTimeSpan(0, 10e-9), WithTimePoints(1001))
+		if err != nil {
+			t.Fatalf("received error transforming: %s", err)
+		}
+		assert(t, "mode", td.Mode, BandpassImpulse)
+		assert(t, "num points", len(td.Values), 1001)
+		assertFloat64(t, "time", td.Time[200], 2e-9, 1e-18)
+		peak := 0
+		for m, v := range td.Values {
+			if cmplx.Abs(v) > cmplx.Abs(td.Values[peak]) {
+				peak = m
+			}
+		}
+		assert(t, "peak index", peak, 200)
+		assertComplex(t, "peak", td.Values[peak]*cmplx.Exp(complex(0, -2*math.Pi*1e9*2e-9)), -0.5, 1e-9)
+	}
+
+	// The default time span is the alias-free range.
+	td, err := net.TimeDomain(1, 1)
+	if err != nil {
+		t.Fatalf("received error transforming: %s", err)
+	}
+	assert(t, "default points", len(td.Time), 201)
+	assertFloat64(t, "default stop", td.Time[200], 100e-9*200/201, 1e-18)
+}
+
+func TestLowpass(t *testing.T) {
+	net := oneport(200, 10e6, 10e6, delayed(-1, 5e-9))
+	td, err := net.TimeDomain(1, 1, WithMode(LowpassImpulse), WithTimeSpan(0, 10e-9), WithTimePoints(101))
+	if err != nil {
+		t.Fatalf("received error transforming: %s", err)
+	}
+	assertComplex(t, "short", td.Values[50], -1, 1e-9)
+	assertComplex(t, "before", td.Values[20], 0, 0.01)
+	for _, v := range td.Values {
+		assertFloat64(t, "imag", imag(v), 0, 1e-12)
+	}
+
+	// A 75 Ω load at the end of a 50 Ω line steps up to 0.2.
+	net = oneport(200, 10e6, 10e6, delayed(0.2, 5e-9))
+	td, err = net.TimeDomain(1, 1, WithMode(LowpassStep), WithTimeSpan(0, 10e-9), WithTimePoints(101))
+	if err != nil {
+		t.Fatalf("received error transforming: %s", err)
+	}
+	assertComplex(t, "before step", td.Values[20], 0, 0.005)
+	assertComplex(t, "at step", td.Values[50], 0.1, 0.005)
+	assertComplex(t, "after step", td.Values[80], 0.2, 0.005)
+}
+
+func TestTimeDomainErrors(t *testing.T) {
+	net := oneport(11, 1e9, 10e6, delayed(1, 1e-9))
+	uneven := oneport(3, 1e9, 10e6, delayed(1, 1e-9))
+	uneven.Frequency[1] = 1.002e9
+	var tests = []struct {
+		name string
+		net  Network
+		i, j int
+		opts []TimeOption
+	}{
+		{"port", net, 2, 1, nil},
+		{"uneven", uneven, 1, 1, nil},
+		{"not harmonic", net, 1, 1, []TimeOption{WithMode(LowpassStep)}},
+		{"points", net, 1, 1, []TimeOption{WithTimePoints(0)}},
+		{"beta", net, 1, 1, []TimeOption{WithKaiserBeta(-1)}},
+		{"span", net, 1, 1, []TimeOption{WithTimeSpan(2e-9, 1e-9)}},
+		{"mode", net, 1, 1, []TimeOption{WithMode(TransformMode(42))}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.net.TimeDomain(test.i, test.j, test.opts...); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestGate(t *testing.T) {
+	near, far := delayed(0.1, 1e-9), delayed(0.05, 5e-9)
+	net := oneport(201, 1e9, 10e6, func(f float64) complex128 {
+		return near(f) + far(f)
+	})
+	var tests = []struct {
+		name string
+		opts []GateOption
+		want func(float64) complex128
+	}{
+		{"bandpass", nil, near},
+		{"minimum", []GateOption{WithGateShape(GateMinimum)}, near},
+		{"notch", []GateOption{WithNotch()}, far},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gated, err := net.Gate(0, 3e-9, test.opts...)
+			if err != nil {
+				t.Fatalf("received error gating: %s", err)
+			}
+			// The edges of the band are degraded by the gate.
+			for k := 50; k <= 150; k++ {
+				assertComplex(t, "s11", gated.S[k][0][0], test.want(net.Frequency[k]), 0.01)
+			}
+		})
+	}
+	if _, err := net.Gate(3e-9, 0); err == nil {
+		t.Errorf("expected error for reversed gate")
+	}
+	if _, err := net.Gate(0, 3e-9, WithGateShape(GateShape(42))); err == nil {
+		t.Errorf("expected error for unknown shape")
+	}
+	assert(t, "shape name", GateMaximum.String(), "Maximum")
+	assert(t, "mode name", LowpassStep.String(), "Lowpass Step")
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// marshalJSON returns the JSON encoding of a value returned by
+// keysight.Read, which is the encoding returned by the HTTP API unless the
+// value contains infinity, NaN, or complex numbers that its package doesn't
+// encode itself, which encoding/json refuses to encode. The value is then
+// encoded by walking it, with non-finite values encoded as the strings
+// "+Inf", "-Inf", and "NaN", as in version 1 of the JSON schema of an
+// esa.Trace, and complex numbers as [real, imaginary] pairs, as done by the
+// MarshalJSON methods of the packages returning them.
+func marshalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	var valueErr *json.UnsupportedValueError
+	var typeErr *json.UnsupportedTypeError
+	if err == nil || !errors.As(err, &valueErr) && !errors.As(err, &typeErr) {
+		return data, err
+	}
+	return json.Marshal(jsonValue(reflect.ValueOf(v)))
+}
+
+// jsonValue returns a value that encoding/json can encode in place of v. The
+// MarshalJSON methods are used if they succeed.
+func jsonValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	if v.CanInterface() {
+		switch m := v.Interface().(type) {
+		case json.Marshaler:
+			if data, err := m.MarshalJSON(); err == nil {
+				return json.RawMessage(data)
+			}
+		case encoding.TextMarshaler:
+			if text, err := m.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		return jsonValue(v.Elem())
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return jsonFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return [2]jsonFloat{jsonFloat(real(c)), jsonFloat(imag(c))}
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded using base64, as by encoding/json.
+			data := make([]byte, v.Len())
+			for i := range data {
+				data[i] = byte(v.Index(i).Uint())
+			}
+			return data
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = jsonValue(v.Index(i))
+		}
+		return values
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key())] = jsonValue(iter.Value())
+		}
+		return m
+	case reflect.Struct:
+		return jsonFields(v)
+	}
+	return nil
+}
+
+// jsonFloat encodes infinity and NaN as strings.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Inf"`), nil
+	}
+	return strconv.AppendFloat(nil, v, 'g', -1, 64), nil
+}
+
+// jsonObject is a JSON object whose fields are encoded in order, like the
+// fields of a struct.
+type jsonObject []jsonField
+
+type jsonField struct {
+	name  string
+	value interface{}
+}
+
+func (obj jsonObject) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, f := range obj {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(append(buf, name...), ':'), value...)
+	}
+	return append(buf, '}'), nil
+}
+
+// jsonFields returns the fields of a struct encoded by encoding/json, using
+// the names and omitempty option of the json struct tags. The fields of
+// embedded structs are promoted.
+func jsonFields(v reflect.Value) jsonObject {
+	var obj jsonObject
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				obj = append(obj, jsonFields(fv)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmpty(fv) {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		obj = append(obj, jsonField{name, jsonValue(fv)})
+	}
+	return obj
+}
+
+// isEmpty reports whether the value is left out by the omitempty option.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,731 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// The Parser service parses the files saved by Keysight/Agilent/HP test
+// equipment, so that programs in any language can use the parsers of the
+// keysight Go module. It's implemented by the rpc package and served by
+// "keysight serve".
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: keysight.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ParseRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The filename is used to detect the format, so it should be the name
+	// saved by the instrument.
+	Filename      string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Data          []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseRequest) Reset() {
+	*x = ParseRequest{}
+	mi := &file_keysight_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseRequest) ProtoMessage() {}
+
+func (x *ParseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keysight_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseRequest.ProtoReflect.Descriptor instead.
+func (*ParseRequest) Descriptor() ([]byte, []int) {
+	return file_keysight_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ParseRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *ParseRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ParseResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filename string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	// The format detected, such as "ESA trace" or "scope binary waveform".
+	Format string `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*ParseResponse_Trace
+	//	*ParseResponse_Waveforms
+	//	*ParseResponse_Json
+	Data          isParseResponse_Data `protobuf_oneof:"data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseResponse) Reset() {
+	*x = ParseResponse{}
+	mi := &file_keysight_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseResponse) ProtoMessage() {}
+
+func (x *ParseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keysight_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseResponse.ProtoReflect.Descriptor instead.
+func (*ParseResponse) Descriptor() ([]byte, []int) {
+	return file_keysight_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ParseResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *ParseResponse) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ParseResponse) GetData() isParseResponse_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ParseResponse) GetTrace() *Trace {
+	if x != nil {
+		if x, ok := x.Data.(*ParseResponse_Trace); ok {
+			return x.Trace
+		}
+	}
+	return nil
+}
+
+func (x *ParseResponse) GetWaveforms() *Waveforms {
+	if x != nil {
+		if x, ok := x.Data.(*ParseResponse_Waveforms); ok {
+			return x.Waveforms
+		}
+	}
+	return nil
+}
+
+func (x *ParseResponse) GetJson() string {
+	if x != nil {
+		if x, ok := x.Data.(*ParseResponse_Json); ok {
+			return x.Json
+		}
+	}
+	return ""
+}
+
+type isParseResponse_Data interface {
+	isParseResponse_Data()
+}
+
+type ParseResponse_Trace struct {
+	// Spectrum analyzer traces of every model.
+	Trace *Trace `protobuf:"bytes,3,opt,name=trace,proto3,oneof"`
+}
+
+type ParseResponse_Waveforms struct {
+	// Oscilloscope waveforms.
+	Waveforms *Waveforms `protobuf:"bytes,4,opt,name=waveforms,proto3,oneof"`
+}
+
+type ParseResponse_Json struct {
+	// The other formats are encoded using the JSON encoding of the Go
+	// type, as returned by the HTTP API. Infinity and NaN values that the
+	// Go type doesn't encode itself are encoded as the strings "+Inf",
+	// "-Inf", and "NaN", and complex numbers as [real, imaginary] pairs.
+	Json string `protobuf:"bytes,5,opt,name=json,proto3,oneof"`
+}
+
+func (*ParseResponse_Trace) isParseResponse_Data() {}
+
+func (*ParseResponse_Waveforms) isParseResponse_Data() {}
+
+func (*ParseResponse_Json) isParseResponse_Data() {}
+
+// Value is a setting with its units, which are the strings written by the
+// instrument and may be empty.
+type Value struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         float64                `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Units         string                 `protobuf:"bytes,2,opt,name=units,proto3" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_keysight_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_keysight_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_keysight_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Value) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Value) GetUnits() string {
+	if x != nil {
+		return x.Units
+	}
+	return ""
+}
+
+// Data is a column of trace data.
+type Data struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Units         string                 `protobuf:"bytes,2,opt,name=units,proto3" json:"units,omitempty"`
+	Values        []float64              `protobuf:"fixed64,3,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data) Reset() {
+	*x = Data{}
+	mi := &file_keysight_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data) ProtoMessage() {}
+
+func (x *Data) ProtoReflect() protoreflect.Message {
+	mi := &file_keysight_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data.ProtoReflect.Descriptor instead.
+func (*Data) Descriptor() ([]byte, []int) {
+	return file_keysight_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Data) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Data) GetUnits() string {
+	if x != nil {
+		return x.Units
+	}
+	return ""
+}
+
+func (x *Data) GetValues() []float64 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// Trace is a spectrum analyzer trace, matching version 1 of the JSON
+// schema of an esa.Trace.
+type Trace struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Left out if unknown.
+	Timestamp        *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	OriginalFilename string                 `protobuf:"bytes,2,opt,name=original_filename,json=originalFilename,proto3" json:"original_filename,omitempty"`
+	Title            string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Model            string                 `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
+	SerialNumber     string                 `protobuf:"bytes,5,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	CenterFrequency  *Value                 `protobuf:"bytes,6,opt,name=center_frequency,json=centerFrequency,proto3" json:"center_frequency,omitempty"`
+	Span             *Value                 `protobuf:"bytes,7,opt,name=span,proto3" json:"span,omitempty"`
+	Rbw              *Value                 `protobuf:"bytes,8,opt,name=rbw,proto3" json:"rbw,omitempty"`
+	Vbw              *Value                 `protobuf:"bytes,9,opt,name=vbw,proto3" json:"vbw,omitempty"`
+	ReferenceLevel   *Value                 `protobuf:"bytes,10,opt,name=reference_level,json=referenceLevel,proto3" json:"reference_level,omitempty"`
+	SweepTime        *Value                 `protobuf:"bytes,11,opt,name=sweep_time,json=sweepTime,proto3" json:"sweep_time,omitempty"`
+	NumPoints        int32                  `protobuf:"varint,12,opt,name=num_points,json=numPoints,proto3" json:"num_points,omitempty"`
+	Frequency        *Data                  `protobuf:"bytes,13,opt,name=frequency,proto3" json:"frequency,omitempty"`
+	Traces           []*Data                `protobuf:"bytes,14,rep,name=traces,proto3" json:"traces,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Trace) Reset() {
+	*x = Trace{}
+	mi := &file_keysight_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trace) ProtoMessage() {}
+
+func (x *Trace) ProtoReflect() protoreflect.Message {
+	mi := &file_keysight_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trace.ProtoReflect.Descriptor instead.
+func (*Trace) Descriptor() ([]byte, []int) {
+	return file_keysight_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Trace) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Trace) GetOriginalFilename() string {
+	if x != nil {
+		return x.OriginalFilename
+	}
+	return ""
+}
+
+func (x *Trace) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Trace) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Trace) GetSerialNumber() string {
+	if x != nil {
+		return x.SerialNumber
+	}
+	return ""
+}
+
+func (x *Trace) GetCenterFrequency() *Value {
+	if x != nil {
+		return x.CenterFrequency
+	}
+	return nil
+}
+
+func (x *Trace) GetSpan() *Value {
+	if x != nil {
+		return x.Span
+	}
+	return nil
+}
+
+func (x *Trace) GetRbw() *Value {
+	if x != nil {
+		return x.Rbw
+	}
+	return nil
+}
+
+func (x *Trace) GetVbw() *Value {
+	if x != nil {
+		return x.Vbw
+	}
+	return nil
+}
+
+func (x *Trace) GetReferenceLevel() *Value {
+	if x != nil {
+		return x.ReferenceLevel
+	}
+	return nil
+}
+
+func (x *Trace) GetSweepTime() *Value {
+	if x != nil {
+		return x.SweepTime
+	}
+	return nil
+}
+
+func (x *Trace) GetNumPoints() int32 {
+	if x != nil {
+		return x.NumPoints
+	}
+	return 0
+}
+
+func (x *Trace) GetFrequency() *Data {
+	if x != nil {
+		return x.Frequency
+	}
+	return nil
+}
+
+func (x *Trace) GetTraces() []*Data {
+	if x != nil {
+		return x.Traces
+	}
+	return nil
+}
+
+// Waveform is an oscilloscope channel. The x value of sample i is
+// x_origin + i*x_increment.
+type Waveform struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	XUnits        string                 `protobuf:"bytes,2,opt,name=x_units,json=xUnits,proto3" json:"x_units,omitempty"`
+	YUnits        string                 `protobuf:"bytes,3,opt,name=y_units,json=yUnits,proto3" json:"y_units,omitempty"`
+	XOrigin       float64                `protobuf:"fixed64,4,opt,name=x_origin,json=xOrigin,proto3" json:"x_origin,omitempty"`
+	XIncrement    float64                `protobuf:"fixed64,5,opt,name=x_increment,json=xIncrement,proto3" json:"x_increment,omitempty"`
+	Samples       []float64              `protobuf:"fixed64,6,rep,packed,name=samples,proto3" json:"samples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Waveform) Reset() {
+	*x = Waveform{}
+	mi := &file_keysight_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Waveform) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Waveform) ProtoMessage() {}
+
+func (x *Waveform) ProtoReflect() protoreflect.Message {
+	mi := &file_keysight_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Waveform.ProtoReflect.Descriptor instead.
+func (*Waveform) Descriptor() ([]byte, []int) {
+	return file_keysight_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Waveform) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Waveform) GetXUnits() string {
+	if x != nil {
+		return x.XUnits
+	}
+	return ""
+}
+
+func (x *Waveform) GetYUnits() string {
+	if x != nil {
+		return x.YUnits
+	}
+	return ""
+}
+
+func (x *Waveform) GetXOrigin() float64 {
+	if x != nil {
+		return x.XOrigin
+	}
+	return 0
+}
+
+func (x *Waveform) GetXIncrement() float64 {
+	if x != nil {
+		return x.XIncrement
+	}
+	return 0
+}
+
+func (x *Waveform) GetSamples() []float64 {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+type Waveforms struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Waveforms     []*Waveform            `protobuf:"bytes,1,rep,name=waveforms,proto3" json:"waveforms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Waveforms) Reset() {
+	*x = Waveforms{}
+	mi := &file_keysight_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Waveforms) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Waveforms) ProtoMessage() {}
+
+func (x *Waveforms) ProtoReflect() protoreflect.Message {
+	mi := &file_keysight_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Waveforms.ProtoReflect.Descriptor instead.
+func (*Waveforms) Descriptor() ([]byte, []int) {
+	return file_keysight_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Waveforms) GetWaveforms() []*Waveform {
+	if x != nil {
+		return x.Waveforms
+	}
+	return nil
+}
+
+var File_keysight_proto protoreflect.FileDescriptor
+
+const file_keysight_proto_rawDesc = "" +
+	"\n" +
+	"\x0ekeysight.proto\x12\vkeysight.v1\x1a\x1fgoogle/protobuf/timestamp.proto\">\n" +
+	"\fParseRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\xc5\x01\n" +
+	"\rParseResponse\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\x12*\n" +
+	"\x05trace\x18\x03 \x01(\v2\x12.keysight.v1.TraceH\x00R\x05trace\x126\n" +
+	"\twaveforms\x18\x04 \x01(\v2\x16.keysight.v1.WaveformsH\x00R\twaveforms\x12\x14\n" +
+	"\x04json\x18\x05 \x01(\tH\x00R\x04jsonB\x06\n" +
+	"\x04data\"3\n" +
+	"\x05Value\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x01R\x05value\x12\x14\n" +
+	"\x05units\x18\x02 \x01(\tR\x05units\"J\n" +
+	"\x04Data\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x14\n" +
+	"\x05units\x18\x02 \x01(\tR\x05units\x12\x16\n" +
+	"\x06values\x18\x03 \x03(\x01R\x06values\"\xdd\x04\n" +
+	"\x05Trace\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12+\n" +
+	"\x11original_filename\x18\x02 \x01(\tR\x10originalFilename\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x14\n" +
+	"\x05model\x18\x04 \x01(\tR\x05model\x12#\n" +
+	"\rserial_number\x18\x05 \x01(\tR\fserialNumber\x12=\n" +
+	"\x10center_frequency\x18\x06 \x01(\v2\x12.keysight.v1.ValueR\x0fcenterFrequency\x12&\n" +
+	"\x04span\x18\a \x01(\v2\x12.keysight.v1.ValueR\x04span\x12$\n" +
+	"\x03rbw\x18\b \x01(\v2\x12.keysight.v1.ValueR\x03rbw\x12$\n" +
+	"\x03vbw\x18\t \x01(\v2\x12.keysight.v1.ValueR\x03vbw\x12;\n" +
+	"\x0freference_level\x18\n" +
+	" \x01(\v2\x12.keysight.v1.ValueR\x0ereferenceLevel\x121\n" +
+	"\n" +
+	"sweep_time\x18\v \x01(\v2\x12.keysight.v1.ValueR\tsweepTime\x12\x1d\n" +
+	"\n" +
+	"num_points\x18\f \x01(\x05R\tnumPoints\x12/\n" +
+	"\tfrequency\x18\r \x01(\v2\x11.keysight.v1.DataR\tfrequency\x12)\n" +
+	"\x06traces\x18\x0e \x03(\v2\x11.keysight.v1.DataR\x06traces\"\xa8\x01\n" +
+	"\bWaveform\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x17\n" +
+	"\ax_units\x18\x02 \x01(\tR\x06xUnits\x12\x17\n" +
+	"\ay_units\x18\x03 \x01(\tR\x06yUnits\x12\x19\n" +
+	"\bx_origin\x18\x04 \x01(\x01R\axOrigin\x12\x1f\n" +
+	"\vx_increment\x18\x05 \x01(\x01R\n" +
+	"xIncrement\x12\x18\n" +
+	"\asamples\x18\x06 \x03(\x01R\asamples\"@\n" +
+	"\tWaveforms\x123\n" +
+	"\twaveforms\x18\x01 \x03(\v2\x15.keysight.v1.WaveformR\twaveforms2H\n" +
+	"\x06Parser\x12>\n" +
+	"\x05Parse\x12\x19.keysight.v1.ParseRequest\x1a\x1a.keysight.v1.ParseResponseB\x1fZ\x1dgithub.com/gotmc/keysight/rpcb\x06proto3"
+
+var (
+	file_keysight_proto_rawDescOnce sync.Once
+	file_keysight_proto_rawDescData []byte
+)
+
+func file_keysight_proto_rawDescGZIP() []byte {
+	file_keysight_proto_rawDescOnce.Do(func() {
+		file_keysight_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_keysight_proto_rawDesc), len(file_keysight_proto_rawDesc)))
+	})
+	return file_keysight_proto_rawDescData
+}
+
+var file_keysight_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_keysight_proto_goTypes = []any{
+	(*ParseRequest)(nil),          // 0: keysight.v1.ParseRequest
+	(*ParseResponse)(nil),         // 1: keysight.v1.ParseResponse
+	(*Value)(nil),                 // 2: keysight.v1.Value
+	(*Data)(nil),                  // 3: keysight.v1.Data
+	(*Trace)(nil),                 // 4: keysight.v1.Trace
+	(*Waveform)(nil),              // 5: keysight.v1.Waveform
+	(*Waveforms)(nil),             // 6: keysight.v1.Waveforms
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+}
+var file_keysight_proto_depIdxs = []int32{
+	4,  // 0: keysight.v1.ParseResponse.trace:type_name -> keysight.v1.Trace
+	6,  // 1: keysight.v1.ParseResponse.waveforms:type_name -> keysight.v1.Waveforms
+	7,  // 2: keysight.v1.Trace.timestamp:type_name -> google.protobuf.Timestamp
+	2,  // 3: keysight.v1.Trace.center_frequency:type_name -> keysight.v1.Value
+	2,  // 4: keysight.v1.Trace.span:type_name -> keysight.v1.Value
+	2,  // 5: keysight.v1.Trace.rbw:type_name -> keysight.v1.Value
+	2,  // 6: keysight.v1.Trace.vbw:type_name -> keysight.v1.Value
+	2,  // 7: keysight.v1.Trace.reference_level:type_name -> keysight.v1.Value
+	2,  // 8: keysight.v1.Trace.sweep_time:type_name -> keysight.v1.Value
+	3,  // 9: keysight.v1.Trace.frequency:type_name -> keysight.v1.Data
+	3,  // 10: keysight.v1.Trace.traces:type_name -> keysight.v1.Data
+	5,  // 11: keysight.v1.Waveforms.waveforms:type_name -> keysight.v1.Waveform
+	0,  // 12: keysight.v1.Parser.Parse:input_type -> keysight.v1.ParseRequest
+	1,  // 13: keysight.v1.Parser.Parse:output_type -> keysight.v1.ParseResponse
+	13, // [13:14] is the sub-list for method output_type
+	12, // [12:13] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_keysight_proto_init() }
+func file_keysight_proto_init() {
+	if File_keysight_proto != nil {
+		return
+	}
+	file_keysight_proto_msgTypes[1].OneofWrappers = []any{
+		(*ParseResponse_Trace)(nil),
+		(*ParseResponse_Waveforms)(nil),
+		(*ParseResponse_Json)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_keysight_proto_rawDesc), len(file_keysight_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_keysight_proto_goTypes,
+		DependencyIndexes: file_keysight_proto_depIdxs,
+		MessageInfos:      file_keysight_proto_msgTypes,
+	}.Build()
+	File_keysight_proto = out.File
+	file_keysight_proto_goTypes = nil
+	file_keysight_proto_depIdxs = nil
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package rpc implements the keysight.v1.Parser gRPC service defined by
+// keysight.proto, so that lab infrastructure written in other languages,
+// such as Python analysis scripts or LabVIEW front ends, can use the parsers
+// of this module as a microservice. Clients are generated from
+// keysight.proto using the usual protoc plugins, and the Go client and
+// messages generated by protoc-gen-go and protoc-gen-go-grpc are part of
+// this package.
+//
+// Server implements ParserServer, so it can be registered with a
+// grpc.Server using RegisterParserServer. It's also an http.Handler, which
+// serves the service using the ServeHTTP method of grpc.Server, so it can
+// share a port with the HTTP API, as done by the serve command of
+// cmd/keysight. gRPC requires HTTP/2, which net/http negotiates over TLS, so
+// the http.Server should be served using ServeTLS.
+//
+// Spectrum analyzer traces of every model are returned as a Trace message
+// and oscilloscope waveforms as Waveforms. The other formats are returned
+// using their JSON encoding.
+package rpc
+
+//go:generate buf generate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/scope"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ServicePath is the path prefix of the methods of the Parser service, used
+// to route the gRPC requests to a Server, and ParsePath is the path of the
+// Parse method.
+const (
+	ServicePath = "/keysight.v1.Parser/"
+	ParsePath   = Parser_Parse_FullMethodName
+)
+
+// DefaultMaxMessageSize is the default maximum size of a request message in
+// bytes.
+const DefaultMaxMessageSize = 64 << 20
+
+// NewParseResponse returns the response of the Parse method for the file's
+// format and the value returned by keysight.Read.
+func NewParseResponse(filename string, format keysight.Format, v interface{}) (*ParseResponse, error) {
+	resp := &ParseResponse{Filename: filename, Format: string(format)}
+	if trace, ok := keysight.SpectrumTrace(v); ok {
+		resp.Data = &ParseResponse_Trace{Trace: newTrace(trace)}
+		return resp, nil
+	}
+	var wfms []*Waveform
+	switch v := v.(type) {
+	case scope.BinFile:
+		wfms = fromScopeWaveforms(v.Waveforms)
+	case []scope.Waveform:
+		wfms = fromScopeWaveforms(v)
+	case scope.CSVFile:
+		for _, ch := range v.Channels {
+			wfms = append(wfms, &Waveform{
+				Label:      validUTF8(ch.Name),
+				XUnits:     validUTF8(v.XUnits),
+				YUnits:     validUTF8(ch.Units),
+				XOrigin:    v.XOrigin,
+				XIncrement: v.XIncrement,
+				Samples:    ch.Data,
+			})
+		}
+	default:
+		data, err := marshalJSON(v)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding JSON: %s", err)
+		}
+		resp.Data = &ParseResponse_Json{Json: string(data)}
+		return resp, nil
+	}
+	resp.Data = &ParseResponse_Waveforms{Waveforms: &Waveforms{Waveforms: wfms}}
+	return resp, nil
+}
+
+func newTrace(t esa.Trace) *Trace {
+	value := func(v float64, units string) *Value {
+		return &Value{Value: v, Units: validUTF8(units)}
+	}
+	msg := &Trace{
+		OriginalFilename: validUTF8(t.OriginalFilename),
+		Title:            validUTF8(t.Title),
+		Model:            validUTF8(t.Model),
+		SerialNumber:     validUTF8(t.SerialNum),
+		CenterFrequency:  value(t.CenterFreq, string(t.CenterFreqUnits)),
+		Span:             value(t.Span, string(t.SpanUnits)),
+		Rbw:              value(t.RBW, string(t.RBWUnits)),
+		Vbw:              value(t.VBW, string(t.VBWUnits)),
+		ReferenceLevel:   value(t.RefLevel, string(t.RefLevelUnits)),
+		SweepTime:        value(t.SweepTime, string(t.SweepTimeUnits)),
+		NumPoints:        int32(t.NumPoints),
+		Frequency:        newData(esa.TraceData{Label: t.FreqLabel, Units: t.FreqUnits, Values: t.Frequency}),
+	}
+	if !t.Timestamp.IsZero() {
+		msg.Timestamp = timestamppb.New(t.Timestamp)
+	}
+	for _, data := range t.Traces() {
+		msg.Traces = append(msg.Traces, newData(data))
+	}
+	return msg
+}
+
+func newData(data esa.TraceData) *Data {
+	return &Data{Label: validUTF8(data.Label), Units: validUTF8(data.Units), Values: data.Values}
+}
+
+func fromScopeWaveforms(scopeWfms []scope.Waveform) []*Waveform {
+	wfms := make([]*Waveform, len(scopeWfms))
+	for i, w := range scopeWfms {
+		wfms[i] = &Waveform{
+			Label:      validUTF8(w.Label),
+			XUnits:     w.XUnits.String(),
+			YUnits:     w.YUnits.String(),
+			XOrigin:    w.XOrigin,
+			XIncrement: w.XIncrement,
+			Samples:    w.Samples(),
+		}
+	}
+	return wfms
+}
+
+// validUTF8 replaces the invalid UTF-8 in a string read from a file, such as
+// a Latin-1 micro sign, since protocol buffer strings must be valid UTF-8.
+// It's the same replacement done by encoding/json.
+func validUTF8(s string) string {
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
+
+// Server serves the Parser service.
+type Server struct {
+	UnimplementedParserServer
+	maxMessageSize int64
+	grpc           *grpc.Server
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithMaxMessageSize sets the maximum size of a request message in bytes.
+// The default is DefaultMaxMessageSize.
+func WithMaxMessageSize(n int64) Option {
+	return func(s *Server) {
+		s.maxMessageSize = n
+	}
+}
+
+// New returns a server configured using the options.
+func New(opts ...Option) *Server {
+	s := &Server{maxMessageSize: DefaultMaxMessageSize}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.grpc = grpc.NewServer(grpc.MaxRecvMsgSize(int(s.maxMessageSize)))
+	RegisterParserServer(s.grpc, s)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.grpc.ServeHTTP(w, r)
+}
+
+// Parse implements ParserServer.
+func (s *Server) Parse(ctx context.Context, req *ParseRequest) (*ParseResponse, error) {
+	if req.Filename == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing filename")
+	}
+	format, err := keysight.Detect(req.Filename, req.Data)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error detecting format of %s: %s", req.Filename, err)
+	}
+	v, err := keysight.Read(req.Filename, req.Data)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+	resp, err := NewParseResponse(req.Filename, format, v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%s", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/esa"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewParseResponse(t *testing.T) {
+	ts := time.Date(2021, 11, 16, 10, 50, 45, 500, time.UTC)
+	trace := esa.Trace{
+		Timestamp: ts, Model: "E4402B", RBW: 1e3, RBWUnits: esa.Hertz, NumPoints: 2,
+		FreqUnits: "Hz", Frequency: []float64{1e6, 2e6},
+		Trace1Label: "Trace 1", Trace1Units: "dBm", Trace1: []float64{-10, math.Inf(-1)},
+	}
+	resp, err := NewParseResponse("T.CSV", keysight.ESATrace, trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Round trip through the protocol buffer encoding.
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		t.Fatalf("error encoding response: %s", err)
+	}
+	var got ParseResponse
+	if err := proto.Unmarshal(data, &got); err != nil {
+		t.Fatalf("error decoding response: %s", err)
+	}
+	assert(t, "filename", got.GetFilename(), "T.CSV")
+	assert(t, "format", got.GetFormat(), "ESA trace")
+	msg := got.GetTrace()
+	assert(t, "timestamp", msg.GetTimestamp().AsTime().Equal(ts), true)
+	assert(t, "model", msg.GetModel(), "E4402B")
+	assert(t, "rbw", msg.GetRbw().GetValue(), 1e3)
+	assert(t, "rbw units", msg.GetRbw().GetUnits(), "Hz")
+	assert(t, "num points", msg.GetNumPoints(), int32(2))
+	assert(t, "frequency units", msg.GetFrequency().GetUnits(), "Hz")
+	assert(t, "traces", len(msg.GetTraces()), 1)
+	trace1 := msg.GetTraces()[0]
+	assert(t, "trace label", trace1.GetLabel(), "Trace 1")
+	assert(t, "values", len(trace1.GetValues()), 2)
+	assert(t, "value", trace1.GetValues()[1], math.Inf(-1))
+
+	trace.Title = "Level \xb5V"
+	resp, err = NewParseResponse("T.CSV", keysight.ESATrace, trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := proto.Marshal(resp); err != nil {
+		t.Errorf("error encoding Latin-1 title: %s", err)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	type inner struct {
+		Gain complex128
+	}
+	type value struct {
+		inner
+		Name    string
+		Level   float64 `json:"level"`
+		Values  []float64
+		Skipped string `json:"-"`
+		Empty   string `json:",omitempty"`
+		Counts  map[string]int
+	}
+	v := value{
+		inner:  inner{complex(1, math.Inf(-1))},
+		Name:   "S21",
+		Level:  math.Inf(1),
+		Values: []float64{1.5, math.NaN()},
+		Counts: map[string]int{"a": 1},
+	}
+	if _, err := json.Marshal(v); err == nil {
+		t.Fatal("expected encoding/json to fail")
+	}
+	data, err := marshalJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Gain":[1,"-Inf"],"Name":"S21","level":"+Inf","Values":[1.5,"NaN"],"Counts":{"a":1}}`
+	assert(t, "JSON", string(data), want)
+
+	// Values that encoding/json can encode use its encoding.
+	data, err = marshalJSON(struct{ Time time.Time }{time.Date(2021, 11, 16, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, "time", string(data), `{"Time":"2021-11-16T00:00:00Z"}`)
+}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		filename string
+		format   keysight.Format
+		count    int
+	}{
+		{"../esa/testdata/e4402b_trace924.csv", keysight.ESATrace, 1},
+		{"../xseries/testdata/n9020a_trace.csv", keysight.XSeriesTrace, 1},
+		{"../scope/testdata/dsox3034t_two_channels.bin", keysight.ScopeBin, 2},
+		{"../scope/testdata/dsox1204g_increment.csv", keysight.ScopeCSV, -1},
+		{"../arb/testdata/sine8.arb", keysight.ArbWaveform, -1},
+		{"../iq/testdata/n9030a_iq.csv", keysight.XSeriesIQ, -1},
+		{"../dmm/testdata/34465a_datalog.csv", keysight.DMMDataLog, -1},
+	}
+	client := newClient(t, New())
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			data, err := os.ReadFile(test.filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &ParseRequest{Filename: filepath.Base(test.filename), Data: data}
+			resp, err := client.Parse(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert(t, "filename", resp.GetFilename(), req.Filename)
+			assert(t, "format", resp.GetFormat(), string(test.format))
+			switch resp.GetData().(type) {
+			case *ParseResponse_Trace:
+				assert(t, "traces", len(resp.GetTrace().GetTraces()) >= test.count, true)
+			case *ParseResponse_Waveforms:
+				wfms := resp.GetWaveforms().GetWaveforms()
+				if test.count > 0 {
+					assert(t, "waveforms", len(wfms), test.count)
+				} else if len(wfms) == 0 {
+					t.Error("no waveforms")
+				}
+			case *ParseResponse_Json:
+				if !json.Valid([]byte(resp.GetJson())) {
+					t.Errorf("invalid JSON: %.100s", resp.GetJson())
+				}
+			default:
+				t.Errorf("missing data")
+			}
+		})
+	}
+
+	// JSON can't encode the infinite complex value.
+	req := &ParseRequest{Filename: "INF.S1P", Data: []byte("# Hz S RI R 50\n1000000 inf 0\n2000000 0.5 -0.5\n")}
+	resp, err := client.Parse(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Ports int
+		Data  [][][][2]interface{}
+	}
+	if err := json.Unmarshal([]byte(resp.GetJson()), &got); err != nil {
+		t.Fatalf("error decoding JSON: %s", err)
+	}
+	assert(t, "ports", got.Ports, 1)
+	assert(t, "infinite real part", got.Data[0][0][0][0], "+Inf")
+	assert(t, "imaginary part", got.Data[1][0][0][1], -0.5)
+}
+
+func TestParseErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		server *Server
+		req    *ParseRequest
+		code   codes.Code
+	}{
+		{"unknown format", New(), &ParseRequest{Filename: "notes.txt", Data: []byte("hello\n")}, codes.InvalidArgument},
+		{"missing filename", New(), &ParseRequest{}, codes.InvalidArgument},
+		{"invalid file", New(), &ParseRequest{Filename: "TRACE1.CSV", Data: []byte("Title:\n")}, codes.InvalidArgument},
+		{"too large", New(WithMaxMessageSize(4)), &ParseRequest{Filename: "notes.txt"}, codes.ResourceExhausted},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newClient(t, test.server).Parse(context.Background(), test.req)
+			assert(t, "code", status.Code(err), test.code)
+		})
+	}
+
+	// Requests must use gRPC over HTTP/2.
+	req := httptest.NewRequest(http.MethodPost, ParsePath, strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	New().ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK {
+		t.Error("expected error serving HTTP/1.1 request")
+	}
+}
+
+// newClient returns a client of the server, which is served over TLS by an
+// http.Server as done by the serve command.
+func newClient(t *testing.T, s *Server) ParserClient {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle(ServicePath, s)
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	creds := credentials.NewTLS(&tls.Config{RootCAs: roots})
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "https://"), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewParserClient(conn)
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
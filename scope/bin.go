@@ -0,0 +1,221 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// binCookie is the two character identifier at the start of a binary
+// waveform file.
+const binCookie = "AG"
+
+// BinFile is the contents of a binary waveform (.bin) file. A segmented
+// memory acquisition contains a waveform for each segment of each channel,
+// which are grouped by channel using Segments.
+type BinFile struct {
+	Version   string
+	Waveforms []Waveform
+}
+
+// binFileHeader is the 12 byte header at the start of the file.
+type binFileHeader struct {
+	Cookie       [2]byte
+	Version      [2]byte
+	FileSize     int32
+	NumWaveforms int32
+}
+
+// binWaveformHeader is the 140 byte header preceding each waveform.
+type binWaveformHeader struct {
+	HeaderSize     int32
+	WaveformType   int32
+	NumBuffers     int32
+	NumPoints      int32
+	Count          int32
+	XDisplayRange  float32
+	XDisplayOrigin float64
+	XIncrement     float64
+	XOrigin        float64
+	XUnits         int32
+	YUnits         int32
+	Date           [16]byte
+	Time           [16]byte
+	Frame          [24]byte
+	Label          [16]byte
+	TimeTag        float64
+	SegmentIndex   uint32
+}
+
+// binDataHeader is the 12 byte header preceding each waveform data buffer.
+type binDataHeader struct {
+	HeaderSize    int32
+	BufferType    int16
+	BytesPerPoint int16
+	BufferSize    int32
+}
+
+const (
+	binWaveformHeaderSize = 140
+	binDataHeaderSize     = 12
+)
+
+// ReadBinFile reads the binary waveform (.bin) file saved by a Keysight/Agilent
+// oscilloscope.
+func ReadBinFile(filename string) (BinFile, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return BinFile{}, err
+	}
+	defer file.Close()
+	return ReadBin(file)
+}
+
+// ReadBin reads the binary waveform data from the given io.Reader. The file
+// consists of a file header followed by each waveform's header and data
+// buffers. All values are little-endian.
+func ReadBin(r io.Reader) (BinFile, error) {
+	bf := BinFile{}
+	br := bufio.NewReader(r)
+
+	var fh binFileHeader
+	if err := binary.Read(br, binary.LittleEndian, &fh); err != nil {
+		return bf, fmt.Errorf("error reading file header: %s", err)
+	}
+	if string(fh.Cookie[:]) != binCookie {
+		return bf, fmt.Errorf("invalid file cookie: %q", fh.Cookie[:])
+	}
+	bf.Version = string(fh.Version[:])
+	if fh.NumWaveforms < 0 {
+		return bf, fmt.Errorf("invalid number of waveforms: %d", fh.NumWaveforms)
+	}
+
+	for i := 0; i < int(fh.NumWaveforms); i++ {
+		wfm, err := readBinWaveform(br)
+		if err != nil {
+			return bf, fmt.Errorf("error reading waveform %d: %s", i+1, err)
+		}
+		bf.Waveforms = append(bf.Waveforms, wfm)
+	}
+	return bf, nil
+}
+
+func readBinWaveform(r io.Reader) (Waveform, error) {
+	var wh binWaveformHeader
+	if err := binary.Read(r, binary.LittleEndian, &wh); err != nil {
+		return Waveform{}, fmt.Errorf("error reading waveform header: %s", err)
+	}
+	if wh.HeaderSize < binWaveformHeaderSize {
+		return Waveform{}, fmt.Errorf("invalid waveform header size: %d", wh.HeaderSize)
+	}
+	// Skip any additional header bytes added by newer file versions.
+	if err := skip(r, int64(wh.HeaderSize-binWaveformHeaderSize)); err != nil {
+		return Waveform{}, err
+	}
+	if wh.NumBuffers < 0 || wh.NumPoints < 0 {
+		return Waveform{}, fmt.Errorf("invalid waveform header / buffers %d / points %d",
+			wh.NumBuffers, wh.NumPoints)
+	}
+	wfm := Waveform{
+		Label:          cString(wh.Label[:]),
+		Type:           WaveformType(wh.WaveformType),
+		NumPoints:      int(wh.NumPoints),
+		Count:          int(wh.Count),
+		XDisplayRange:  float64(wh.XDisplayRange),
+		XDisplayOrigin: wh.XDisplayOrigin,
+		XIncrement:     wh.XIncrement,
+		XOrigin:        wh.XOrigin,
+		XUnits:         Units(wh.XUnits),
+		YUnits:         Units(wh.YUnits),
+		Date:           cString(wh.Date[:]),
+		Time:           cString(wh.Time[:]),
+		Frame:          cString(wh.Frame[:]),
+		TimeTag:        wh.TimeTag,
+		SegmentIndex:   int(wh.SegmentIndex),
+	}
+	for i := 0; i < int(wh.NumBuffers); i++ {
+		buf, err := readBinBuffer(r)
+		if err != nil {
+			return wfm, fmt.Errorf("error reading buffer %d: %s", i+1, err)
+		}
+		// Check the number of points in the header, which is used to size
+		// the x axis values, against the data actually read.
+		if n := len(buf.Values) + len(buf.Counts) + len(buf.Logic)/buf.BytesPerPoint; n < wfm.NumPoints {
+			return wfm, fmt.Errorf("too few points in buffer %d / got %d / expected %d", i+1, n, wfm.NumPoints)
+		}
+		wfm.Buffers = append(wfm.Buffers, buf)
+	}
+	return wfm, nil
+}
+
+func readBinBuffer(r io.Reader) (Buffer, error) {
+	var dh binDataHeader
+	if err := binary.Read(r, binary.LittleEndian, &dh); err != nil {
+		return Buffer{}, fmt.Errorf("error reading data header: %s", err)
+	}
+	if dh.HeaderSize < binDataHeaderSize {
+		return Buffer{}, fmt.Errorf("invalid data header size: %d", dh.HeaderSize)
+	}
+	if err := skip(r, int64(dh.HeaderSize-binDataHeaderSize)); err != nil {
+		return Buffer{}, err
+	}
+	if dh.BufferSize < 0 || dh.BytesPerPoint <= 0 || dh.BufferSize%int32(dh.BytesPerPoint) != 0 {
+		return Buffer{}, fmt.Errorf("invalid data header / buffer size %d / bytes per point %d",
+			dh.BufferSize, dh.BytesPerPoint)
+	}
+	data := make([]byte, 0, min(int(dh.BufferSize), 1<<20))
+	b := bytes.NewBuffer(data)
+	if _, err := io.CopyN(b, r, int64(dh.BufferSize)); err != nil {
+		return Buffer{}, fmt.Errorf("error reading data: %s", err)
+	}
+	buf := Buffer{
+		Type:          BufferType(dh.BufferType),
+		BytesPerPoint: int(dh.BytesPerPoint),
+	}
+	n := int(dh.BufferSize) / buf.BytesPerPoint
+	switch buf.Type {
+	case BufferNormal, BufferMaximum, BufferMinimum, BufferTime:
+		if buf.BytesPerPoint != 4 {
+			return buf, fmt.Errorf("expected 4 bytes per point for float data / got %d", buf.BytesPerPoint)
+		}
+		buf.Values = make([]float32, n)
+		if err := binary.Read(b, binary.LittleEndian, buf.Values); err != nil {
+			return buf, err
+		}
+	case BufferCounts:
+		if buf.BytesPerPoint != 4 {
+			return buf, fmt.Errorf("expected 4 bytes per point for counts / got %d", buf.BytesPerPoint)
+		}
+		buf.Counts = make([]int32, n)
+		if err := binary.Read(b, binary.LittleEndian, buf.Counts); err != nil {
+			return buf, err
+		}
+	default:
+		buf.Logic = b.Bytes()
+	}
+	return buf, nil
+}
+
+func skip(r io.Reader, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
+
+// cString returns the string stored in a NUL padded byte array.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadBinFile(t *testing.T) {
+	got, err := ReadBinFile("./testdata/dsox3034t_two_channels.bin")
+	if err != nil {
+		t.Fatalf("received error reading bin file: %s", err)
+	}
+	assert(t, "version", got.Version, "10")
+	assert(t, "num waveforms", len(got.Waveforms), 2)
+	ch1 := got.Waveforms[0]
+	assert(t, "label", ch1.Label, "1")
+	assert(t, "type", ch1.Type, WaveformNormal)
+	assert(t, "num points", ch1.NumPoints, 100)
+	assert(t, "x units", ch1.XUnits, UnitsSeconds)
+	assert(t, "y units", ch1.YUnits, UnitsVolts)
+	assert(t, "date", ch1.Date, "16 MAR 2023")
+	assert(t, "time", ch1.Time, "10:42:17")
+	assert(t, "frame", ch1.Frame, "DSOX3034T:MY58100123")
+	assertFloat64(t, "x increment", ch1.XIncrement, 1e-6, 1e-15)
+	assertFloat64(t, "x origin", ch1.XOrigin, -50e-6, 1e-15)
+	assert(t, "num buffers", len(ch1.Buffers), 1)
+	assert(t, "buffer type", ch1.Buffers[0].Type, BufferNormal)
+	samples := ch1.Samples()
+	assert(t, "num samples", len(samples), 100)
+	assertFloat64(t, "ch1[0]", samples[0], 0, 1e-6)
+	assertFloat64(t, "ch1[6]", samples[6], math.Sin(2*math.Pi*6/25), 1e-6)
+	times := ch1.Times()
+	assertFloat64(t, "time[50]", times[50], 0, 1e-15)
+	ch2 := got.Waveforms[1]
+	assert(t, "ch2 label", ch2.Label, "2")
+	assertFloat64(t, "ch2[0]", ch2.Samples()[0], 0.5, 1e-6)
+}
+
+func TestReadBinErrors(t *testing.T) {
+	data, err := os.ReadFile("./testdata/dsox3034t_two_channels.bin")
+	if err != nil {
+		t.Fatalf("error reading test file: %s", err)
+	}
+	var tests = []struct {
+		name  string
+		given []byte
+	}{
+		{"empty", nil},
+		{"bad cookie", append([]byte("XX"), data[2:]...)},
+		{"truncated header", data[:40]},
+		{"truncated data", data[:len(data)-10]},
+		{"too many points", numPoints(data, 1<<30)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadBin(bytes.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %s", test.name)
+			}
+		})
+	}
+}
+
+// numPoints returns a copy of the binary waveform file with the number of
+// points in the header of the first waveform changed to n.
+func numPoints(data []byte, n uint32) []byte {
+	data = append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(data[24:], n)
+	return data
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadBin(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.bin")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadBin(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Channel is the data for a single channel saved in a CSV waveform file.
+type Channel struct {
+	Name  string
+	Units string
+	Data  []float64
+}
+
+// CSVFile is the contents of a CSV waveform file.
+type CSVFile struct {
+	XLabel     string
+	XUnits     string
+	XOrigin    float64
+	XIncrement float64
+	// SampleRate is the reciprocal of the x increment in samples per second.
+	SampleRate float64
+	// X contains the x axis value, typically time, for each sample.
+	X        []float64
+	Channels []Channel
+}
+
+// ReadCSVFile reads the CSV waveform file saved by a Keysight/Agilent
+// oscilloscope.
+func ReadCSVFile(filename string) (CSVFile, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return CSVFile{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads the CSV waveform data from the given io.Reader. The first line
+// contains the column labels and the second line the units. Two layouts are
+// supported:
+//
+//   - An x-axis column containing the time of each sample followed by one
+//     column per channel.
+//   - A sequence column followed by one column per channel, with the start
+//     time and x increment stored in the Start and Increment columns of the
+//     units line.
+func ReadCSV(r io.Reader) (CSVFile, error) {
+	csv := CSVFile{}
+	scanner := bufio.NewScanner(r)
+
+	if !scanner.Scan() {
+		return csv, fmt.Errorf("missing label line")
+	}
+	labels := splitCSVLine(scanner.Text())
+	if !scanner.Scan() {
+		return csv, fmt.Errorf("missing units line")
+	}
+	units := splitCSVLine(scanner.Text())
+	if len(labels) < 2 {
+		return csv, fmt.Errorf("expected x axis and at least one channel column / got %d columns", len(labels))
+	}
+	if len(units) != len(labels) {
+		return csv, fmt.Errorf("wrong number of units / got %d / expected %d", len(units), len(labels))
+	}
+
+	// Determine whether the x increment is stored in the header.
+	numColumns := len(labels)
+	hasIncrement := false
+	startIdx, incrIdx := -1, -1
+	for i, label := range labels {
+		switch strings.ToLower(label) {
+		case "start":
+			startIdx = i
+		case "increment":
+			incrIdx = i
+		}
+	}
+	if startIdx > 0 && incrIdx > 0 {
+		hasIncrement = true
+		numColumns = min(startIdx, incrIdx)
+		var err error
+		if csv.XOrigin, err = strconv.ParseFloat(units[startIdx], 64); err != nil {
+			return csv, fmt.Errorf("error parsing start: %s", err)
+		}
+		if csv.XIncrement, err = strconv.ParseFloat(units[incrIdx], 64); err != nil {
+			return csv, fmt.Errorf("error parsing increment: %s", err)
+		}
+	}
+	csv.XLabel = labels[0]
+	csv.XUnits = units[0]
+	for i := 1; i < numColumns; i++ {
+		csv.Channels = append(csv.Channels, Channel{Name: labels[i], Units: units[i]})
+	}
+
+	lineNum := 2
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := splitCSVLine(line)
+		if len(fields) < numColumns {
+			return csv, fmt.Errorf("wrong number of columns in line %d / got %d / expected %d",
+				lineNum, len(fields), numColumns)
+		}
+		x, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return csv, fmt.Errorf("error parsing %s value %s in line %d", csv.XLabel, fields[0], lineNum)
+		}
+		if hasIncrement {
+			x = csv.XOrigin + x*csv.XIncrement
+		}
+		csv.X = append(csv.X, x)
+		for i := 1; i < numColumns; i++ {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return csv, fmt.Errorf("error parsing %s value %s in line %d", labels[i], fields[i], lineNum)
+			}
+			csv.Channels[i-1].Data = append(csv.Channels[i-1].Data, v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return csv, err
+	}
+	if hasIncrement {
+		csv.XUnits = "second"
+	} else if len(csv.X) > 0 {
+		csv.XOrigin = csv.X[0]
+		if len(csv.X) > 1 {
+			csv.XIncrement = (csv.X[len(csv.X)-1] - csv.X[0]) / float64(len(csv.X)-1)
+		}
+	}
+	if csv.XIncrement != 0 {
+		csv.SampleRate = 1 / csv.XIncrement
+	}
+	return csv, nil
+}
+
+// splitCSVLine splits the line into trimmed fields, dropping the trailing
+// empty field caused by a trailing comma.
+func splitCSVLine(line string) []string {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if len(fields) > 1 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return fields
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	var tests = []struct {
+		filename     string
+		xLabel       string
+		channels     []string
+		units        string
+		ch1At6       float64
+		ch1Tolerance float64
+	}{
+		{
+			filename:     "./testdata/dsox3034t_time_column.csv",
+			xLabel:       "x-axis",
+			channels:     []string{"1", "2"},
+			units:        "Volt",
+			ch1At6:       math.Sin(2 * math.Pi * 6 / 25),
+			ch1Tolerance: 1e-5,
+		},
+		{
+			filename:     "./testdata/dsox1204g_increment.csv",
+			xLabel:       "X",
+			channels:     []string{"CH1"},
+			units:        "Volt",
+			ch1At6:       math.Sin(2 * math.Pi * 6 / 25),
+			ch1Tolerance: 1e-2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			got, err := ReadCSVFile(test.filename)
+			if err != nil {
+				t.Fatalf("received error reading CSV file: %s", err)
+			}
+			assert(t, "x label", got.XLabel, test.xLabel)
+			assert(t, "num channels", len(got.Channels), len(test.channels))
+			for i, name := range test.channels {
+				assert(t, "channel name", got.Channels[i].Name, name)
+				assert(t, "channel units", got.Channels[i].Units, test.units)
+				assert(t, "channel len", len(got.Channels[i].Data), 50)
+			}
+			assert(t, "x len", len(got.X), 50)
+			assertFloat64(t, "x origin", got.XOrigin, -25e-6, 1e-12)
+			assertFloat64(t, "x increment", got.XIncrement, 1e-6, 1e-12)
+			assertFloat64(t, "sample rate", got.SampleRate, 1e6, 1e-3)
+			assertFloat64(t, "x[25]", got.X[25], 0, 1e-12)
+			assertFloat64(t, "ch1[6]", got.Channels[0].Data[6], test.ch1At6, test.ch1Tolerance)
+		})
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"empty", ""},
+		{"missing units", "x-axis,1\n"},
+		{"no channels", "x-axis\nsecond\n"},
+		{"mismatched units", "x-axis,1,2\nsecond,Volt\n"},
+		{"short row", "x-axis,1,2\nsecond,Volt,Volt\n0.1,2\n"},
+		{"bad value", "x-axis,1\nsecond,Volt\n0.1,abc\n"},
+		{"bad increment", "X,CH1,Start,Increment\nSequence,Volt,0,abc\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
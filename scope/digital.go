@@ -0,0 +1,154 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DigitalChannel is the bit stream of a single digital channel of a mixed
+// signal oscilloscope (MSO). The x origin and increment are those of the
+// waveform the channel was decoded from, so the bits line up with the
+// samples of the analog channels.
+type DigitalChannel struct {
+	// Name is the name of the channel, such as "D0".
+	Name string
+	// Number is the channel number, which is the bit position within the
+	// 16 digital channels.
+	Number     int
+	XOrigin    float64
+	XIncrement float64
+	Bits       []bool
+}
+
+// Times returns the x axis value for each bit computed from the x origin and
+// x increment.
+func (dc DigitalChannel) Times() []float64 {
+	times := make([]float64, len(dc.Bits))
+	for i := range times {
+		times[i] = dc.XOrigin + float64(i)*dc.XIncrement
+	}
+	return times
+}
+
+var (
+	// podLabel matches the label of a digital pod, such as "POD1".
+	podLabel = regexp.MustCompile(`(?i)^pod\s*([12])$`)
+	// rangeLabel matches the label of a range of digital channels, such as
+	// "D7-D0" or "D15-8".
+	rangeLabel = regexp.MustCompile(`(?i)^d(\d+)\s*-\s*d?(\d+)$`)
+	// channelLabel matches the label of a single digital channel, such as
+	// "D3".
+	channelLabel = regexp.MustCompile(`(?i)^d(\d+)$`)
+)
+
+// DigitalChannels returns the digital channels of the logic buffers of the
+// waveforms in the file.
+func (bf BinFile) DigitalChannels() []DigitalChannel {
+	var channels []DigitalChannel
+	for _, wfm := range bf.Waveforms {
+		channels = append(channels, wfm.DigitalChannels()...)
+	}
+	return channels
+}
+
+// DigitalChannels returns the digital channels packed as bit fields in the
+// logic buffers of the waveform, or nil if the waveform doesn't contain a
+// logic buffer. Each point of a logic buffer is a byte per group of 8
+// channels, with the least significant bit being the lowest numbered
+// channel. The channel numbers are determined from the waveform label, which
+// is a pod such as "POD2" for D15-D8, a range such as "D15-D8", or a single
+// channel such as "D3", whose bit is 1 when any bit of the point is set.
+func (wfm Waveform) DigitalChannels() []DigitalChannel {
+	first, single := digitalBase(wfm.Label)
+	var channels []DigitalChannel
+	for _, buf := range wfm.Buffers {
+		if buf.Logic == nil || buf.BytesPerPoint <= 0 {
+			continue
+		}
+		n := len(buf.Logic) / buf.BytesPerPoint
+		numBits := 8 * buf.BytesPerPoint
+		if single {
+			numBits = 1
+		}
+		for bit := 0; bit < numBits; bit++ {
+			dc := DigitalChannel{
+				Name:       "D" + strconv.Itoa(first+bit),
+				Number:     first + bit,
+				XOrigin:    wfm.XOrigin,
+				XIncrement: wfm.XIncrement,
+				Bits:       make([]bool, n),
+			}
+			for i := range dc.Bits {
+				point := buf.Logic[i*buf.BytesPerPoint : (i+1)*buf.BytesPerPoint]
+				if single {
+					dc.Bits[i] = anySet(point)
+					continue
+				}
+				dc.Bits[i] = point[bit/8]&(1<<(bit%8)) != 0
+			}
+			channels = append(channels, dc)
+		}
+	}
+	return channels
+}
+
+// DigitalChannels returns the digital channels of the CSV file, which are
+// the channels named D0 through D15, with a value of at least 0.5 being
+// high.
+func (csv CSVFile) DigitalChannels() []DigitalChannel {
+	var channels []DigitalChannel
+	for _, ch := range csv.Channels {
+		m := channelLabel.FindStringSubmatch(ch.Name)
+		if m == nil {
+			continue
+		}
+		num, _ := strconv.Atoi(m[1])
+		dc := DigitalChannel{
+			Name:       "D" + strconv.Itoa(num),
+			Number:     num,
+			XOrigin:    csv.XOrigin,
+			XIncrement: csv.XIncrement,
+			Bits:       make([]bool, len(ch.Data)),
+		}
+		for i, v := range ch.Data {
+			dc.Bits[i] = v >= 0.5
+		}
+		channels = append(channels, dc)
+	}
+	return channels
+}
+
+// digitalBase returns the number of the first digital channel given by the
+// waveform label, and whether the label is a single channel.
+func digitalBase(label string) (int, bool) {
+	label = strings.TrimSpace(label)
+	if m := podLabel.FindStringSubmatch(label); m != nil {
+		pod, _ := strconv.Atoi(m[1])
+		return 8 * (pod - 1), false
+	}
+	if m := rangeLabel.FindStringSubmatch(label); m != nil {
+		a, _ := strconv.Atoi(m[1])
+		b, _ := strconv.Atoi(m[2])
+		return min(a, b), false
+	}
+	if m := channelLabel.FindStringSubmatch(label); m != nil {
+		num, _ := strconv.Atoi(m[1])
+		return num, true
+	}
+	return 0, false
+}
+
+func anySet(point []byte) bool {
+	for _, b := range point {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
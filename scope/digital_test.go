@@ -0,0 +1,82 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import "testing"
+
+func TestDigitalChannelsBin(t *testing.T) {
+	got, err := ReadBinFile("./testdata/msox3034t_digital.bin")
+	if err != nil {
+		t.Fatalf("received error reading bin file: %s", err)
+	}
+	assert(t, "analog channels", len(got.Waveforms[0].DigitalChannels()), 0)
+	channels := got.DigitalChannels()
+	assert(t, "num channels", len(channels), 8)
+	for i, dc := range channels {
+		assert(t, "name", dc.Name, "D"+string(rune('0'+i)))
+		assert(t, "number", dc.Number, i)
+		assert(t, "num bits", len(dc.Bits), 16)
+	}
+	d0, d1, d7 := channels[0], channels[1], channels[7]
+	for i := 0; i < 16; i++ {
+		assert(t, "d0", d0.Bits[i], i&1 == 1)
+		assert(t, "d1", d1.Bits[i], i>>1&1 == 1)
+		assert(t, "d7", d7.Bits[i], true)
+		assert(t, "d4", channels[4].Bits[i], false)
+	}
+	analog := got.Waveforms[0].Times()
+	times := d0.Times()
+	assertFloat64(t, "time[0]", times[0], analog[0], 1e-15)
+	assertFloat64(t, "time[15]", times[15], analog[15], 1e-15)
+}
+
+func TestDigitalChannelsCSV(t *testing.T) {
+	got, err := ReadCSVFile("./testdata/msox3034t_digital.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	channels := got.DigitalChannels()
+	assert(t, "num channels", len(channels), 2)
+	assert(t, "name", channels[1].Name, "D1")
+	assert(t, "number", channels[1].Number, 1)
+	assertFloat64(t, "x origin", channels[0].XOrigin, -8e-6, 1e-12)
+	assertFloat64(t, "x increment", channels[0].XIncrement, 1e-6, 1e-12)
+	for i := 0; i < 16; i++ {
+		assert(t, "d0", channels[0].Bits[i], i&1 == 1)
+		assert(t, "d1", channels[1].Bits[i], i>>1&1 == 1)
+	}
+}
+
+func TestDigitalLabels(t *testing.T) {
+	var tests = []struct {
+		label string
+		logic []uint8
+		bpp   int
+		names []string
+	}{
+		{"POD2", []uint8{0x80}, 1, []string{"D8", "D9", "D10", "D11", "D12", "D13", "D14", "D15"}},
+		{"D15-D8", []uint8{0x80}, 1, []string{"D8", "D9", "D10", "D11", "D12", "D13", "D14", "D15"}},
+		{"D5", []uint8{0x01}, 1, []string{"D5"}},
+		{"Digital", []uint8{0x00, 0x80}, 2, []string{
+			"D0", "D1", "D2", "D3", "D4", "D5", "D6", "D7",
+			"D8", "D9", "D10", "D11", "D12", "D13", "D14", "D15"}},
+	}
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			wfm := Waveform{
+				Label:   test.label,
+				Type:    WaveformLogic,
+				Buffers: []Buffer{{Type: BufferLogic, BytesPerPoint: test.bpp, Logic: test.logic}},
+			}
+			channels := wfm.DigitalChannels()
+			assert(t, "num channels", len(channels), len(test.names))
+			for i, name := range test.names {
+				assert(t, "name", channels[i].Name, name)
+			}
+			assert(t, "highest bit", channels[len(channels)-1].Bits[0], true)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/internal/dsp"
+)
+
+// DefaultImpedance is the reference impedance in ohms used to convert volts
+// to dBm.
+const DefaultImpedance = 50
+
+// Window is the window function applied to the waveform before the FFT.
+type Window int
+
+// Available windows, which are those of the InfiniiVision FFT math
+// function.
+const (
+	// Hanning is the default, and is a good compromise between frequency
+	// resolution and amplitude accuracy.
+	Hanning Window = iota
+	// FlatTop has the best amplitude accuracy for tones.
+	FlatTop
+	// Rectangular is best for transients and signals that are periodic in
+	// the record, but has the most leakage otherwise.
+	Rectangular
+	// BlackmanHarris is the 4-term Blackman-Harris window, which has the
+	// lowest sidelobes.
+	BlackmanHarris
+)
+
+// windowCoefficients are the coefficients of the cosine terms of each
+// window.
+var windowCoefficients = map[Window][]float64{
+	Hanning:        {0.5, 0.5},
+	FlatTop:        {0.21557895, 0.41663158, 0.277263158, 0.083578947, 0.006947368},
+	Rectangular:    {1},
+	BlackmanHarris: {0.35875, 0.48829, 0.14128, 0.01168},
+}
+
+// String implements the Stringer interface for Window.
+func (w Window) String() string {
+	switch w {
+	case Hanning:
+		return "Hanning"
+	case FlatTop:
+		return "Flat Top"
+	case Rectangular:
+		return "Rectangular"
+	case BlackmanHarris:
+		return "Blackman-Harris"
+	}
+	return fmt.Sprintf("Window(%d)", int(w))
+}
+
+// Scale is the vertical scale of an FFT.
+type Scale int
+
+// Available FFT scales.
+const (
+	// DBV is dB relative to 1 V rms, which is the default like the
+	// InfiniiVision FFT.
+	DBV Scale = iota
+	// DBm is the power into the reference impedance.
+	DBm
+	// Linear is volts rms.
+	Linear
+)
+
+// String implements the Stringer interface for Scale and returns the unit
+// symbol.
+func (s Scale) String() string {
+	switch s {
+	case DBV:
+		return "dBV"
+	case DBm:
+		return string(esa.DBm)
+	case Linear:
+		return string(esa.Volts)
+	}
+	return fmt.Sprintf("Scale(%d)", int(s))
+}
+
+// FFTOption configures the FFT of a waveform.
+type FFTOption func(*fftConfig)
+
+type fftConfig struct {
+	window    Window
+	scale     Scale
+	impedance float64
+}
+
+// WithWindow sets the window of the FFT. The default is Hanning.
+func WithWindow(w Window) FFTOption {
+	return func(cfg *fftConfig) {
+		cfg.window = w
+	}
+}
+
+// WithScale sets the vertical scale of the FFT. The default is DBV.
+func WithScale(s Scale) FFTOption {
+	return func(cfg *fftConfig) {
+		cfg.scale = s
+	}
+}
+
+// WithImpedance sets the reference impedance in ohms of the DBm scale. The
+// default is DefaultImpedance.
+func WithImpedance(ohms float64) FFTOption {
+	return func(cfg *fftConfig) {
+		if ohms > 0 {
+			cfg.impedance = ohms
+		}
+	}
+}
+
+// FFT returns the one-sided spectrum of the waveform from DC to half the
+// sample rate as an esa.Trace, so it can be used with the peak, plot, and
+// limit tooling of the spectrum analyzer traces. The windowed samples are
+// zero padded to a power of two, and the magnitudes are calibrated so a
+// sine reads its rms voltage. The RBW of the trace is the noise bandwidth of
+// the window.
+func (wfm Waveform) FFT(opts ...FFTOption) (esa.Trace, error) {
+	cfg := fftConfig{impedance: DefaultImpedance}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	a, ok := windowCoefficients[cfg.window]
+	if !ok {
+		return esa.Trace{}, fmt.Errorf("unknown window: %s", cfg.window)
+	}
+	if cfg.scale < DBV || cfg.scale > Linear {
+		return esa.Trace{}, fmt.Errorf("unknown scale: %s", cfg.scale)
+	}
+	samples := wfm.Samples()
+	if len(samples) < 2 {
+		return esa.Trace{}, errors.New("waveform has too few samples")
+	}
+	if wfm.XIncrement <= 0 {
+		return esa.Trace{}, fmt.Errorf("invalid x increment: %g", wfm.XIncrement)
+	}
+	window := dsp.CosineWindow(a, len(samples))
+	n := 1 << bits.Len(uint(len(samples)-1))
+	x := make([]complex128, n)
+	sum := 0.0
+	for i, v := range samples {
+		x[i] = complex(v*window[i], 0)
+		sum += window[i]
+	}
+	dsp.FFT(x)
+
+	sampleRate := 1 / wfm.XIncrement
+	binWidth := sampleRate / float64(n)
+	freqs := make([]float64, n/2+1)
+	values := make([]float64, n/2+1)
+	for k := range freqs {
+		freqs[k] = float64(k) * binWidth
+		// The bins other than DC and Nyquist hold half the energy, and the
+		// peak amplitude of a sine is √2 times its rms voltage.
+		rms := math.Hypot(real(x[k]), imag(x[k])) / sum
+		if k > 0 && k < n/2 {
+			rms *= math.Sqrt2
+		}
+		switch cfg.scale {
+		case DBV:
+			values[k] = 20 * math.Log10(rms)
+		case DBm:
+			values[k] = 10 * math.Log10(1000*rms*rms/cfg.impedance)
+		case Linear:
+			values[k] = rms
+		}
+	}
+
+	rbw := dsp.NoiseBandwidth(window) * sampleRate / float64(len(samples))
+	trace := esa.Trace{
+		Title:           wfm.Label,
+		CenterFreq:      sampleRate / 4,
+		CenterFreqUnits: esa.Hertz,
+		Span:            sampleRate / 2,
+		SpanUnits:       esa.Hertz,
+		RBW:             rbw,
+		RBWUnits:        esa.Hertz,
+		VBW:             rbw,
+		VBWUnits:        esa.Hertz,
+		RefLevelUnits:   esa.AmplitudeUnits(cfg.scale.String()),
+		SweepTime:       float64(len(samples)) * wfm.XIncrement,
+		SweepTimeUnits:  esa.Seconds,
+		NumPoints:       len(freqs),
+		FreqUnits:       string(esa.Hertz),
+		Frequency:       freqs,
+	}
+	if t, ok := wfm.Timestamp(); ok {
+		trace.Timestamp = t
+	}
+	trace.SetTraces([]esa.TraceData{{Label: "Trace 1", Units: cfg.scale.String(), Values: values}})
+	return trace, nil
+}
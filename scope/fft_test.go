@@ -0,0 +1,108 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+// sineWaveform returns n samples taken every µs of a 1 V peak sine at the
+// given frequency plus the DC offset.
+func sineWaveform(n int, freq, offset float64) Waveform {
+	values := make([]float32, n)
+	for i := range values {
+		values[i] = float32(offset + math.Sin(2*math.Pi*freq*float64(i)*1e-6))
+	}
+	return Waveform{
+		Label:      "1",
+		NumPoints:  n,
+		XIncrement: 1e-6,
+		XUnits:     UnitsSeconds,
+		YUnits:     UnitsVolts,
+		Buffers:    []Buffer{{Type: BufferNormal, BytesPerPoint: 4, Values: values}},
+	}
+}
+
+func TestFFT(t *testing.T) {
+	// The tone is centered on bin 100.
+	binWidth := 1e6 / 1024
+	wfm := sineWaveform(1024, 100*binWidth, 0.5)
+	var tests = []struct {
+		name  string
+		opts  []FFTOption
+		units string
+		tone  float64
+		dc    float64
+		nbw   float64
+	}{
+		{"default", nil, "dBV", -3.0103, -6.0206, 1.5},
+		{"flat top", []FFTOption{WithWindow(FlatTop)}, "dBV", -3.0103, -6.0206, 3.7702},
+		{"rectangular dbm", []FFTOption{WithWindow(Rectangular), WithScale(DBm)}, "dBm", 10, 6.9897, 1},
+		{"dbm 75 ohm", []FFTOption{WithScale(DBm), WithImpedance(75)}, "dBm", 8.2391, 5.2288, 1.5},
+		{"linear", []FFTOption{WithWindow(BlackmanHarris), WithScale(Linear)}, "V", math.Sqrt(0.5), 0.5, 2.0044},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trace, err := wfm.FFT(test.opts...)
+			if err != nil {
+				t.Fatalf("received error computing FFT: %s", err)
+			}
+			assert(t, "num points", trace.NumPoints, 513)
+			assert(t, "units", trace.Trace1Units, test.units)
+			assert(t, "ref level units", trace.RefLevelUnits, esa.AmplitudeUnits(test.units))
+			assert(t, "title", trace.Title, "1")
+			assertFloat64(t, "last freq", trace.Frequency[512], 500e3, 1e-9)
+			assertFloat64(t, "span", trace.Span, 500e3, 1e-9)
+			assertFloat64(t, "rbw", trace.RBW, test.nbw*binWidth, 0.001*binWidth)
+			assertFloat64(t, "tone freq", trace.Frequency[100], 100*binWidth, 1e-9)
+			assertFloat64(t, "tone", trace.Trace1[100], test.tone, 1e-4)
+			assertFloat64(t, "dc", trace.Trace1[0], test.dc, 1e-4)
+		})
+	}
+}
+
+func TestFFTZeroPadded(t *testing.T) {
+	// A tone between bins of a record that isn't a power of two.
+	trace, err := sineWaveform(1000, 123.4e3, 0).FFT(WithWindow(FlatTop))
+	if err != nil {
+		t.Fatalf("received error computing FFT: %s", err)
+	}
+	assert(t, "num points", trace.NumPoints, 513)
+	peak := 0
+	for i, v := range trace.Trace1 {
+		if v > trace.Trace1[peak] {
+			peak = i
+		}
+	}
+	assertFloat64(t, "peak freq", trace.Frequency[peak], 123.4e3, 1e6/1024)
+	assertFloat64(t, "peak", trace.Trace1[peak], -3.0103, 0.02)
+}
+
+func TestFFTErrors(t *testing.T) {
+	wfm := sineWaveform(64, 1e3, 0)
+	var tests = []struct {
+		name string
+		wfm  Waveform
+		opts []FFTOption
+	}{
+		{"no samples", Waveform{XIncrement: 1e-6}, nil},
+		{"no x increment", Waveform{Buffers: wfm.Buffers}, nil},
+		{"window", wfm, []FFTOption{WithWindow(Window(42))}},
+		{"scale", wfm, []FFTOption{WithScale(Scale(42))}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.wfm.FFT(test.opts...); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+	assert(t, "window name", Window(42).String(), "Window(42)")
+	assert(t, "scale name", Scale(42).String(), "Scale(42)")
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gotmc/keysight/internal/hdf5"
+)
+
+// h5WaveformsGroup is the HDF5 group containing one group per waveform.
+const h5WaveformsGroup = "/Waveforms"
+
+// ReadH5File reads the waveforms from an HDF5 (.h5) file saved by an
+// Infiniium oscilloscope.
+func ReadH5File(filename string) ([]Waveform, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadH5(file)
+}
+
+// ReadH5 reads the waveforms from an Infiniium HDF5 file using the given
+// io.ReaderAt. Each member of the Waveforms group, such as "Channel 1",
+// contains the waveform settings as attributes and a dataset with the sample
+// data. Integer samples are converted to y axis units using the YInc and YOrg
+// attributes.
+func ReadH5(r io.ReaderAt) ([]Waveform, error) {
+	file, err := hdf5.Open(r)
+	if err != nil {
+		return nil, err
+	}
+	group, err := file.Get(h5WaveformsGroup)
+	if err != nil {
+		return nil, fmt.Errorf("error reading waveforms group: %s", err)
+	}
+	names, err := group.Children()
+	if err != nil {
+		return nil, fmt.Errorf("error reading waveforms group: %s", err)
+	}
+	var wfms []Waveform
+	for _, name := range names {
+		obj, err := group.Child(name)
+		if err != nil {
+			return wfms, err
+		}
+		wfm, err := readH5Waveform(obj)
+		if err != nil {
+			return wfms, fmt.Errorf("error reading %s: %s", name, err)
+		}
+		wfms = append(wfms, wfm)
+	}
+	return wfms, nil
+}
+
+func readH5Waveform(obj *hdf5.Object) (Waveform, error) {
+	wfm := Waveform{Label: obj.Name()}
+	attrs, err := obj.Attributes()
+	if err != nil {
+		return wfm, err
+	}
+	wfm.NumPoints = int(attrFloat(attrs, "NumPoints", 0))
+	wfm.Count = int(attrFloat(attrs, "Count", 0))
+	wfm.Type = WaveformType(attrFloat(attrs, "WaveformType", float64(WaveformNormal)))
+	wfm.XIncrement = attrFloat(attrs, "XInc", 0)
+	wfm.XOrigin = attrFloat(attrs, "XOrg", 0)
+	wfm.XDisplayRange = attrFloat(attrs, "XDispRange", 0)
+	wfm.XDisplayOrigin = attrFloat(attrs, "XDispOrigin", 0)
+	wfm.XUnits = attrUnits(attrs, "XUnits")
+	wfm.YUnits = attrUnits(attrs, "YUnits")
+	if saved, ok := attrs["SavedTime"].(string); ok {
+		wfm.Date, wfm.Time, _ = strings.Cut(saved, " ")
+	}
+	yInc := attrFloat(attrs, "YInc", 1)
+	yOrg := attrFloat(attrs, "YOrg", 0)
+
+	// The sample data is stored in the first dataset in the waveform group.
+	names, err := obj.Children()
+	if err != nil {
+		return wfm, err
+	}
+	for _, name := range names {
+		ds, err := obj.Child(name)
+		if err != nil {
+			return wfm, err
+		}
+		if !ds.IsDataset() {
+			continue
+		}
+		raw, err := ds.Float64s()
+		if err != nil {
+			return wfm, err
+		}
+		values := make([]float32, len(raw))
+		for i, v := range raw {
+			values[i] = float32(v*yInc + yOrg)
+		}
+		wfm.Buffers = append(wfm.Buffers, Buffer{
+			Type:          BufferNormal,
+			BytesPerPoint: 4,
+			Values:        values,
+		})
+		break
+	}
+	if len(wfm.Buffers) == 0 {
+		return wfm, fmt.Errorf("missing waveform dataset")
+	}
+	if n := len(wfm.Buffers[0].Values); wfm.NumPoints < 0 || wfm.NumPoints > n {
+		return wfm, fmt.Errorf("invalid number of points / got %d / dataset has %d", wfm.NumPoints, n)
+	}
+	if wfm.NumPoints == 0 {
+		wfm.NumPoints = len(wfm.Buffers[0].Values)
+	}
+	return wfm, nil
+}
+
+// WriteH5File writes the waveforms to the given filename in the Infiniium
+// HDF5 layout read by ReadH5File.
+func WriteH5File(filename string, wfms []Waveform) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := WriteH5(file, wfms); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteH5 writes the waveforms to the io.Writer in the Infiniium HDF5 layout
+// read by ReadH5. Each waveform is written as a member of the Waveforms group
+// named after its label, containing the settings as attributes and the
+// samples as a float64 dataset, so YInc and YOrg are always 1 and 0.
+func WriteH5(w io.Writer, wfms []Waveform) error {
+	root := hdf5.NewGroup()
+	group := root.Group(strings.TrimPrefix(h5WaveformsGroup, "/"))
+	seen := make(map[string]bool)
+	for i, wfm := range wfms {
+		if wfm.Label == "" {
+			return fmt.Errorf("waveform %d doesn't have a label", i)
+		}
+		if seen[wfm.Label] {
+			return fmt.Errorf("duplicate waveform label: %s", wfm.Label)
+		}
+		seen[wfm.Label] = true
+		samples := wfm.Samples()
+		if samples == nil {
+			return fmt.Errorf("waveform %s doesn't contain sample data", wfm.Label)
+		}
+		g := group.Group(wfm.Label)
+		g.SetInt64("NumPoints", int64(len(samples)))
+		g.SetInt64("Count", int64(wfm.Count))
+		g.SetInt64("WaveformType", int64(wfm.Type))
+		g.SetFloat64("XInc", wfm.XIncrement)
+		g.SetFloat64("XOrg", wfm.XOrigin)
+		g.SetFloat64("XDispRange", wfm.XDisplayRange)
+		g.SetFloat64("XDispOrigin", wfm.XDisplayOrigin)
+		g.SetString("XUnits", h5UnitsLabels[wfm.XUnits])
+		g.SetString("YUnits", h5UnitsLabels[wfm.YUnits])
+		g.SetFloat64("YInc", 1)
+		g.SetFloat64("YOrg", 0)
+		if saved := strings.TrimSpace(wfm.Date + " " + wfm.Time); saved != "" {
+			g.SetString("SavedTime", saved)
+		}
+		g.Dataset(wfm.Label+"Data", samples)
+	}
+	return hdf5.Write(w, root)
+}
+
+// attrFloat returns the numeric attribute with the given name, or the default
+// value if the attribute doesn't exist or isn't numeric.
+func attrFloat(attrs map[string]interface{}, name string, def float64) float64 {
+	switch v := attrs[name].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+	return def
+}
+
+var h5UnitsNames = map[string]Units{
+	"volt":     UnitsVolts,
+	"volts":    UnitsVolts,
+	"v":        UnitsVolts,
+	"second":   UnitsSeconds,
+	"seconds":  UnitsSeconds,
+	"s":        UnitsSeconds,
+	"constant": UnitsConstant,
+	"amp":      UnitsAmps,
+	"amps":     UnitsAmps,
+	"a":        UnitsAmps,
+	"db":       UnitsDecibels,
+	"decibel":  UnitsDecibels,
+	"hertz":    UnitsHertz,
+	"hz":       UnitsHertz,
+}
+
+// h5UnitsLabels are the units names written by Infiniium oscilloscopes.
+var h5UnitsLabels = map[Units]string{
+	UnitsUnknown:  "Unknown",
+	UnitsVolts:    "Volt",
+	UnitsSeconds:  "Second",
+	UnitsConstant: "Constant",
+	UnitsAmps:     "Amp",
+	UnitsDecibels: "Decibel",
+	UnitsHertz:    "Hertz",
+}
+
+// attrUnits returns the units attribute with the given name, which may be
+// stored either as the units name or as the enumerated value.
+func attrUnits(attrs map[string]interface{}, name string) Units {
+	if s, ok := attrs[name].(string); ok {
+		return h5UnitsNames[strings.ToLower(strings.TrimSpace(s))]
+	}
+	return Units(attrFloat(attrs, name, float64(UnitsUnknown)))
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadH5File(t *testing.T) {
+	got, err := ReadH5File("./testdata/infiniium_two_channels.h5")
+	if err != nil {
+		t.Fatalf("received error reading h5 file: %s", err)
+	}
+	assert(t, "num waveforms", len(got), 2)
+	var tests = []struct {
+		label     string
+		at12      float64
+		tolerance float64
+	}{
+		{"Channel 1", math.Sin(2 * math.Pi * 12 / 50), 1e-4},
+		{"Channel 2", 0.5*math.Cos(2*math.Pi*12/50) + 0.25, 1e-2},
+	}
+	for i, test := range tests {
+		wfm := got[i]
+		assert(t, "label", wfm.Label, test.label)
+		assert(t, "type", wfm.Type, WaveformNormal)
+		assert(t, "num points", wfm.NumPoints, 200)
+		assert(t, "x units", wfm.XUnits, UnitsSeconds)
+		assert(t, "y units", wfm.YUnits, UnitsVolts)
+		assert(t, "date", wfm.Date, "2023-03-16")
+		assert(t, "time", wfm.Time, "10:42:17")
+		assertFloat64(t, "x increment", wfm.XIncrement, 2e-9, 1e-18)
+		assertFloat64(t, "x origin", wfm.XOrigin, -200e-9, 1e-18)
+		samples := wfm.Samples()
+		assert(t, "num samples", len(samples), 200)
+		assertFloat64(t, test.label+"[12]", samples[12], test.at12, test.tolerance)
+	}
+	// The last chunk of channel 1 is only partially filled.
+	assertFloat64(t, "ch1[199]", got[0].Samples()[199], math.Sin(2*math.Pi*199/50), 1e-4)
+}
+
+func TestReadH5Errors(t *testing.T) {
+	if _, err := ReadH5(bytes.NewReader([]byte("not an HDF5 file"))); err == nil {
+		t.Errorf("expected error reading invalid HDF5 file")
+	}
+}
+
+func TestWriteH5(t *testing.T) {
+	wfms, err := ReadH5File("./testdata/infiniium_two_channels.h5")
+	if err != nil {
+		t.Fatalf("received error reading h5 file: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteH5(&buf, wfms); err != nil {
+		t.Fatalf("received error writing h5: %s", err)
+	}
+	got, err := ReadH5(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("received error reading written h5: %s", err)
+	}
+	assert(t, "num waveforms", len(got), len(wfms))
+	for i, wfm := range got {
+		want := wfms[i]
+		assert(t, "label", wfm.Label, want.Label)
+		assert(t, "type", wfm.Type, want.Type)
+		assert(t, "num points", wfm.NumPoints, want.NumPoints)
+		assert(t, "x units", wfm.XUnits, want.XUnits)
+		assert(t, "y units", wfm.YUnits, want.YUnits)
+		assert(t, "date", wfm.Date, want.Date)
+		assert(t, "time", wfm.Time, want.Time)
+		assertFloat64(t, "x increment", wfm.XIncrement, want.XIncrement, 1e-18)
+		assertFloat64(t, "x origin", wfm.XOrigin, want.XOrigin, 1e-18)
+		assertFloat64(t, "sample", wfm.Samples()[12], want.Samples()[12], 1e-9)
+	}
+
+	var tests = []struct {
+		name string
+		wfms []Waveform
+	}{
+		{"missing label", []Waveform{{Buffers: wfms[0].Buffers}}},
+		{"duplicate label", []Waveform{wfms[0], wfms[0]}},
+		{"missing samples", []Waveform{{Label: "Channel 1"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := WriteH5(&bytes.Buffer{}, test.wfms); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func FuzzReadH5(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.h5")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadH5(bytes.NewReader(data))
+	})
+}
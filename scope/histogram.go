@@ -0,0 +1,92 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Histogram is the amplitude histogram of a waveform, like the vertical
+// waveform histogram of the oscilloscope. The statistics are computed from
+// the samples rather than the bins.
+type Histogram struct {
+	Label string
+	Units Units
+	// Min is the lower edge of the first bin, and each bin is BinWidth wide.
+	Min      float64
+	BinWidth float64
+	Counts   []int
+	Hits     int
+	Mean     float64
+	StdDev   float64
+	Median   float64
+}
+
+// Center returns the amplitude of the center of bin i.
+func (h Histogram) Center(i int) float64 {
+	return h.Min + (float64(i)+0.5)*h.BinWidth
+}
+
+// Peak returns the amplitude of the center of the bin with the most hits,
+// which is the first of them if there are several.
+func (h Histogram) Peak() float64 {
+	peak := 0
+	for i, n := range h.Counts {
+		if n > h.Counts[peak] {
+			peak = i
+		}
+	}
+	return h.Center(peak)
+}
+
+// Histogram returns the amplitude histogram of the samples of the waveform
+// using the given number of bins spanning the minimum to the maximum sample.
+// The maximum sample is counted in the last bin. A waveform whose samples
+// are all the same has bins 1 unit wide centered on the value.
+func (wfm Waveform) Histogram(bins int) (Histogram, error) {
+	if bins < 1 {
+		return Histogram{}, fmt.Errorf("invalid number of bins: %d", bins)
+	}
+	samples := wfm.Samples()
+	if len(samples) == 0 {
+		return Histogram{}, errors.New("waveform has no samples")
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	sum := 0.0
+	for _, v := range samples {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+		sum += v
+	}
+	h := Histogram{
+		Label:  wfm.Label,
+		Units:  wfm.YUnits,
+		Counts: make([]int, bins),
+		Hits:   len(samples),
+		Mean:   sum / float64(len(samples)),
+	}
+	if hi == lo {
+		h.Min, h.BinWidth = lo-float64(bins)/2, 1
+	} else {
+		h.Min, h.BinWidth = lo, (hi-lo)/float64(bins)
+	}
+	ss := 0.0
+	for _, v := range samples {
+		i := min(int((v-h.Min)/h.BinWidth), bins-1)
+		h.Counts[i]++
+		ss += (v - h.Mean) * (v - h.Mean)
+	}
+	h.StdDev = math.Sqrt(ss / float64(len(samples)))
+	sort.Float64s(samples)
+	mid := len(samples) / 2
+	h.Median = samples[mid]
+	if len(samples)%2 == 0 {
+		h.Median = (samples[mid-1] + samples[mid]) / 2
+	}
+	return h, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	values := []float32{0, 0, 0, 0.25, 0.5, 1, 1, 1, 1, 1}
+	wfm := Waveform{
+		Label:   "2",
+		YUnits:  UnitsVolts,
+		Buffers: []Buffer{{Type: BufferNormal, Values: values}},
+	}
+	h, err := wfm.Histogram(4)
+	if err != nil {
+		t.Fatalf("received error computing histogram: %s", err)
+	}
+	assert(t, "label", h.Label, "2")
+	assert(t, "units", h.Units, UnitsVolts)
+	assert(t, "hits", h.Hits, 10)
+	assert(t, "num bins", len(h.Counts), 4)
+	// The maximum is counted in the last bin.
+	for i, want := range []int{3, 1, 1, 5} {
+		assert(t, "count", h.Counts[i], want)
+	}
+	assertFloat64(t, "min", h.Min, 0, 1e-12)
+	assertFloat64(t, "bin width", h.BinWidth, 0.25, 1e-12)
+	assertFloat64(t, "center", h.Center(1), 0.375, 1e-12)
+	assertFloat64(t, "peak", h.Peak(), 0.875, 1e-12)
+	assertFloat64(t, "mean", h.Mean, 0.575, 1e-12)
+	assertFloat64(t, "median", h.Median, 0.75, 1e-12)
+	ss := 0.0
+	for _, v := range values {
+		ss += (float64(v) - 0.575) * (float64(v) - 0.575)
+	}
+	assertFloat64(t, "std dev", h.StdDev, math.Sqrt(ss/10), 1e-12)
+}
+
+func TestHistogramFlat(t *testing.T) {
+	wfm := Waveform{Buffers: []Buffer{{Type: BufferNormal, Values: []float32{2, 2, 2}}}}
+	h, err := wfm.Histogram(5)
+	if err != nil {
+		t.Fatalf("received error computing histogram: %s", err)
+	}
+	assert(t, "center count", h.Counts[2], 3)
+	assertFloat64(t, "peak", h.Peak(), 2, 1e-12)
+	assertFloat64(t, "std dev", h.StdDev, 0, 1e-12)
+}
+
+func TestHistogramErrors(t *testing.T) {
+	if _, err := (Waveform{}).Histogram(10); err == nil {
+		t.Errorf("expected error for no samples")
+	}
+	wfm := Waveform{Buffers: []Buffer{{Type: BufferNormal, Values: []float32{1, 2}}}}
+	if _, err := wfm.Histogram(0); err == nil {
+		t.Errorf("expected error for no bins")
+	}
+}
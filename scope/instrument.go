@@ -0,0 +1,160 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpi"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+// Instrument is a live InfiniiVision oscilloscope controlled using SCPI
+// commands sent over an io.ReadWriter, such as a connection from the gotmc
+// visa, usbtmc, or vxi11 packages.
+type Instrument struct {
+	conn *scpi.Conn
+	// now returns the time used as the waveform date and time.
+	now func() time.Time
+}
+
+// NewInstrument returns an InfiniiVision oscilloscope using the given
+// connection, which must terminate each response with a newline.
+func NewInstrument(rw io.ReadWriter) *Instrument {
+	return &Instrument{conn: scpi.New(rw), now: time.Now}
+}
+
+// WaveformFormat is the format used to transfer waveform data as set using
+// the :WAVeform:FORMat command.
+type WaveformFormat string
+
+// Available waveform transfer formats. Words transfer 16 bit values, which
+// preserve the full resolution of high resolution and averaged acquisitions,
+// while bytes transfer 8 bit values in half the time.
+const (
+	FormatByte WaveformFormat = "BYTE"
+	FormatWord WaveformFormat = "WORD"
+)
+
+// preambleTypes maps the acquisition type in the waveform preamble to the
+// waveform type. High resolution acquisitions are reported as normal
+// waveforms, as the binary waveform file format has no such type.
+var preambleTypes = map[int]WaveformType{
+	0: WaveformNormal,
+	1: WaveformPeakDetect,
+	2: WaveformAverage,
+	3: WaveformNormal,
+}
+
+// referenceFractions maps the responses to :TIM:REF? to the fraction of the
+// display to the left of the time reference.
+var referenceFractions = map[string]float64{
+	"LEFT": 0.1,
+	"CENT": 0.5,
+	"RIGH": 0.9,
+}
+
+// FetchWaveform downloads the waveform of the given source, such as CHAN1 or
+// MATH, using the given transfer format. The data is transferred unsigned and
+// most significant byte first, and the raw counts are converted to volts and
+// the x axis to seconds using the scaling in the waveform preamble, returning
+// the same Waveform as reading a binary waveform file. The date and time are
+// when the waveform was fetched in UTC.
+func (inst *Instrument) FetchWaveform(source string, format WaveformFormat) (Waveform, error) {
+	var wfm Waveform
+	if format != FormatByte && format != FormatWord {
+		return wfm, fmt.Errorf("unsupported waveform format %s", format)
+	}
+	for _, cmd := range []string{
+		":WAV:SOUR " + source,
+		":WAV:FORM " + string(format),
+		":WAV:BYT MSBF",
+		":WAV:UNS 1",
+	} {
+		if err := inst.conn.Command("%s", cmd); err != nil {
+			return wfm, err
+		}
+	}
+	now := inst.now().UTC()
+	preamble, err := inst.conn.QueryFloats(":WAV:PRE?")
+	if err != nil {
+		return wfm, err
+	}
+	if len(preamble) != 10 {
+		return wfm, fmt.Errorf("wrong number of preamble values / got %d / expected 10", len(preamble))
+	}
+	xIncrement, xOrigin, xReference := preamble[4], preamble[5], preamble[6]
+	yIncrement, yOrigin, yReference := preamble[7], preamble[8], preamble[9]
+	wfm.Label = strings.TrimPrefix(strings.ToUpper(source), "CHAN")
+	wfm.Type = preambleTypes[int(preamble[1])]
+	wfm.NumPoints = int(preamble[2])
+	wfm.Count = int(preamble[3])
+	wfm.XIncrement = xIncrement
+	wfm.XOrigin = xOrigin - xReference*xIncrement
+	wfm.XUnits = UnitsSeconds
+	wfm.YUnits = UnitsVolts
+	wfm.Date = strings.ToUpper(now.Format("02 Jan 2006"))
+	wfm.Time = now.Format("15:04:05")
+
+	if wfm.XDisplayRange, err = inst.conn.QueryFloat(":TIM:RANG?"); err != nil {
+		return wfm, err
+	}
+	position, err := inst.conn.QueryFloat(":TIM:POS?")
+	if err != nil {
+		return wfm, err
+	}
+	reference, err := inst.conn.Query(":TIM:REF?")
+	if err != nil {
+		return wfm, err
+	}
+	fraction, ok := referenceFractions[strings.ToUpper(reference)]
+	if !ok {
+		fraction = referenceFractions["CENT"]
+	}
+	wfm.XDisplayOrigin = position - fraction*wfm.XDisplayRange
+
+	data, err := inst.conn.QueryBlock(":WAV:DATA?")
+	if err != nil {
+		return wfm, err
+	}
+	counts, err := unsignedCounts(data, format)
+	if err != nil {
+		return wfm, err
+	}
+	if len(counts) != wfm.NumPoints {
+		return wfm, fmt.Errorf("wrong number of points / got %d / expected %d", len(counts), wfm.NumPoints)
+	}
+	values := make([]float32, len(counts))
+	for i, count := range counts {
+		values[i] = float32((count-yReference)*yIncrement + yOrigin)
+	}
+	wfm.Buffers = []Buffer{{Type: BufferNormal, BytesPerPoint: 4, Values: values}}
+	return wfm, nil
+}
+
+// unsignedCounts decodes the unsigned, most significant byte first waveform
+// data.
+func unsignedCounts(data []byte, format WaveformFormat) ([]float64, error) {
+	if format == FormatByte {
+		counts := make([]float64, len(data))
+		for i, b := range data {
+			counts[i] = float64(b)
+		}
+		return counts, nil
+	}
+	words, err := block.Int16s(data, block.Normal)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]float64, len(words))
+	for i, w := range words {
+		counts[i] = float64(uint16(w))
+	}
+	return counts, nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpitest"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+func TestInstrumentFetchWaveform(t *testing.T) {
+	var tests = []struct {
+		name     string
+		format   WaveformFormat
+		preamble string
+		data     []byte
+		wfmType  WaveformType
+		times    [2]float64
+	}{
+		{
+			"word",
+			FormatWord,
+			"+1,+0,+4,+1,+1.00000000E-06,-2.00000000E-06,+0,+1.00000000E-02,+0.00000000E+00,+32768",
+			block.EncodeInt16s([]int16{-32768, -32668, 32668, -31768}, block.Normal),
+			WaveformNormal,
+			[2]float64{-2e-6, 1e-6},
+		},
+		{
+			"byte",
+			FormatByte,
+			"+0,+1,+4,+1,+2.00000000E-06,-1.00000000E-06,+1,+1.00000000E-01,+5.00000000E-01,+128",
+			block.Encode([]byte{123, 133, 113, 223}),
+			WaveformPeakDetect,
+			[2]float64{-3e-6, 3e-6},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := scpitest.New(map[string]string{
+				":WAV:PRE?":  test.preamble,
+				":TIM:RANG?": "+1.00000000E-05",
+				":TIM:POS?":  "+1.00000000E-06",
+				":TIM:REF?":  "LEFT",
+				":WAV:DATA?": string(test.data),
+			})
+			inst := NewInstrument(fake)
+			inst.now = func() time.Time { return time.Date(2023, 3, 16, 10, 42, 17, 0, time.UTC) }
+			wfm, err := inst.FetchWaveform("CHAN1", test.format)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "label", wfm.Label, "1")
+			assert(t, "type", wfm.Type, test.wfmType)
+			assert(t, "points", wfm.NumPoints, 4)
+			assert(t, "x units", wfm.XUnits, UnitsSeconds)
+			assert(t, "y units", wfm.YUnits, UnitsVolts)
+			assert(t, "date", wfm.Date, "16 MAR 2023")
+			assert(t, "time", wfm.Time, "10:42:17")
+			assertFloat64(t, "display range", wfm.XDisplayRange, 1e-5, 1e-15)
+			assertFloat64(t, "display origin", wfm.XDisplayOrigin, 0, 1e-15)
+			times := wfm.Times()
+			assertFloat64(t, "time 0", times[0], test.times[0], 1e-15)
+			assertFloat64(t, "time 3", times[3], test.times[1], 1e-15)
+			samples := wfm.Samples()
+			for i, want := range []float64{0, 1, -1, 10} {
+				assertFloat64(t, fmt.Sprintf("sample %d", i), samples[i], want, 1e-6)
+			}
+			assert(t, "commands", strings.Join(fake.Commands[:4], ";"),
+				":WAV:SOUR CHAN1;:WAV:FORM "+string(test.format)+";:WAV:BYT MSBF;:WAV:UNS 1")
+		})
+	}
+}
+
+func TestInstrumentFetchWaveformErrors(t *testing.T) {
+	preamble := "+1,+0,+4,+1,+1.0E-06,+0,+0,+1.0E-02,+0,+32768"
+	var tests = []struct {
+		name      string
+		format    WaveformFormat
+		responses map[string]string
+	}{
+		{"format", WaveformFormat("ASCII"), nil},
+		{"preamble", FormatWord, map[string]string{":WAV:PRE?": "+1,+0,+4"}},
+		{
+			"points",
+			FormatWord,
+			map[string]string{
+				":WAV:PRE?":  preamble,
+				":TIM:RANG?": "1e-5",
+				":TIM:POS?":  "0",
+				":TIM:REF?":  "CENT",
+				":WAV:DATA?": string(block.EncodeInt16s([]int16{1, 2}, block.Normal)),
+			},
+		},
+	}
+	for _, test := range tests {
+		fake := scpitest.New(test.responses)
+		if _, err := NewInstrument(fake).FetchWaveform("CHAN2", test.format); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// OscFile is the contents of an offline setup (.osc) archive saved by the
+// Infiniium EXR and MXR series oscilloscopes, which is a zip archive of the
+// setup and the saved waveforms.
+type OscFile struct {
+	// SetupName is the name of the setup file in the archive and Setup its
+	// contents, which are returned verbatim since the format isn't
+	// documented, so the setup can be restored to an oscilloscope.
+	SetupName string
+	Setup     []byte
+	// Waveforms contains the waveforms of every waveform file in the archive
+	// in the order they are stored.
+	Waveforms []Waveform
+	// Files contains the names of the other files in the archive, which
+	// aren't decoded.
+	Files []string
+}
+
+// oscSetupExts are the extensions of the setup files in an archive.
+var oscSetupExts = map[string]bool{".set": true, ".setx": true, ".xml": true}
+
+// ReadOscFile reads the offline setup (.osc) archive saved by an Infiniium
+// oscilloscope.
+func ReadOscFile(filename string) (OscFile, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return OscFile{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return OscFile{}, err
+	}
+	return ReadOsc(file, info.Size())
+}
+
+// ReadOsc reads the offline setup archive of the given size from the
+// io.ReaderAt. The waveform files in the archive are decoded according to
+// their extension, which is .bin for binary waveforms, .h5 for HDF5
+// waveforms, or .csv for CSV waveforms, whose channels are converted to
+// waveforms. The setup is the first file with a .set, .setx, or .xml
+// extension.
+func ReadOsc(r io.ReaderAt, size int64) (OscFile, error) {
+	osc := OscFile{}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return osc, fmt.Errorf("error reading archive: %s", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(f.Name))
+		isSetup := oscSetupExts[ext] && osc.SetupName == ""
+		if !isSetup && ext != ".bin" && ext != ".h5" && ext != ".csv" {
+			osc.Files = append(osc.Files, f.Name)
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return osc, fmt.Errorf("error reading %s: %s", f.Name, err)
+		}
+		if isSetup {
+			osc.SetupName, osc.Setup = f.Name, data
+			continue
+		}
+		wfms, err := decodeWaveforms(ext, data)
+		if err != nil {
+			return osc, fmt.Errorf("error decoding %s: %s", f.Name, err)
+		}
+		osc.Waveforms = append(osc.Waveforms, wfms...)
+	}
+	if osc.SetupName == "" && len(osc.Waveforms) == 0 {
+		return osc, fmt.Errorf("archive doesn't contain a setup or waveforms")
+	}
+	return osc, nil
+}
+
+// Waveform returns the waveform with the given label, such as "Channel 1".
+func (osc OscFile) Waveform(label string) (Waveform, bool) {
+	for _, wfm := range osc.Waveforms {
+		if wfm.Label == label {
+			return wfm, true
+		}
+	}
+	return Waveform{}, false
+}
+
+// maxOscFileSize limits the uncompressed size of a file in an offline setup
+// archive so that a small corrupt or malicious archive can't decompress into
+// gigabytes.
+const maxOscFileSize = 1 << 30
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxOscFileSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxOscFileSize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	// The zip reader fails if the data is longer than its size in the header.
+	return io.ReadAll(rc)
+}
+
+// decodeWaveforms decodes the waveform file with the given extension.
+func decodeWaveforms(ext string, data []byte) ([]Waveform, error) {
+	switch ext {
+	case ".bin":
+		bf, err := ReadBin(bytes.NewReader(data))
+		return bf.Waveforms, err
+	case ".h5":
+		return ReadH5(bytes.NewReader(data))
+	}
+	csv, err := ReadCSV(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return csv.Waveforms(), nil
+}
+
+// Waveforms returns the channels of the CSV waveform file as waveforms with
+// a normal data buffer, so they can be used like the waveforms of the
+// binary and HDF5 files. The units names, such as "Volt", are converted to
+// Units.
+func (csv CSVFile) Waveforms() []Waveform {
+	wfms := make([]Waveform, len(csv.Channels))
+	for i, ch := range csv.Channels {
+		values := make([]float32, len(ch.Data))
+		for j, v := range ch.Data {
+			values[j] = float32(v)
+		}
+		wfms[i] = Waveform{
+			Label:      ch.Name,
+			Type:       WaveformNormal,
+			NumPoints:  len(ch.Data),
+			XIncrement: csv.XIncrement,
+			XOrigin:    csv.XOrigin,
+			XUnits:     h5UnitsNames[strings.ToLower(csv.XUnits)],
+			YUnits:     h5UnitsNames[strings.ToLower(ch.Units)],
+			Buffers:    []Buffer{{Type: BufferNormal, BytesPerPoint: 4, Values: values}},
+		}
+	}
+	return wfms
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadOscFile(t *testing.T) {
+	got, err := ReadOscFile("./testdata/mxr058a_setup.osc")
+	if err != nil {
+		t.Fatalf("received error reading osc file: %s", err)
+	}
+	assert(t, "setup name", got.SetupName, "Setup/setup.setx")
+	assert(t, "setup model", strings.Contains(string(got.Setup), `"MXR058A"`), true)
+	assert(t, "num waveforms", len(got.Waveforms), 3)
+	for i, label := range []string{"1", "2", "CH1"} {
+		assert(t, "waveform label", got.Waveforms[i].Label, label)
+	}
+	assert(t, "num files", len(got.Files), 1)
+	assert(t, "file", got.Files[0], "Screenshot/screen.png")
+
+	wfm, ok := got.Waveform("CH1")
+	if !ok {
+		t.Fatalf("missing waveform CH1")
+	}
+	assert(t, "csv num points", wfm.NumPoints, 50)
+	assert(t, "csv y units", wfm.YUnits, UnitsVolts)
+	assert(t, "csv x units", wfm.XUnits, UnitsSeconds)
+	assertFloat64(t, "csv x increment", wfm.XIncrement, 1e-6, 1e-12)
+	assertFloat64(t, "csv x origin", wfm.XOrigin, -25e-6, 1e-12)
+	assert(t, "csv values", len(wfm.Buffers[0].Values), 50)
+	if _, ok := got.Waveform("Channel 4"); ok {
+		t.Errorf("found missing waveform")
+	}
+}
+
+func TestReadOscErrors(t *testing.T) {
+	var empty bytes.Buffer
+	zw := zip.NewWriter(&empty)
+	w, err := zw.Create("notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("no setup"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name string
+		data []byte
+	}{
+		{"not a zip", []byte("Setup,MXR058A\n")},
+		{"no setup or waveforms", empty.Bytes()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bytes.NewReader(test.data)
+			if _, err := ReadOsc(r, int64(len(test.data))); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func FuzzReadOsc(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.osc")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadOsc(bytes.NewReader(data), int64(len(data)))
+	})
+}
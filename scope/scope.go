@@ -0,0 +1,134 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package scope has the ability to parse waveform files saved by the
+// Keysight/Agilent oscilloscopes, such as the InfiniiVision DSO-X 2000, 3000,
+// and 4000 series, and the offline setup (.osc) archives saved by the
+// Infiniium EXR and MXR series. The digital channels of mixed signal
+// oscilloscopes are decoded into bit streams by DigitalChannels, and the
+// spectrum and amplitude histogram of a waveform are computed by FFT and
+// Histogram, with the spectrum returned as an esa.Trace. Waveforms can also
+// be downloaded from a live oscilloscope using an Instrument, which returns
+// the same Waveform as reading a binary waveform file.
+package scope
+
+// Units are the units of the x or y axis of a waveform.
+type Units int
+
+// Available units as enumerated in the binary waveform file format.
+const (
+	UnitsUnknown Units = iota
+	UnitsVolts
+	UnitsSeconds
+	UnitsConstant
+	UnitsAmps
+	UnitsDecibels
+	UnitsHertz
+)
+
+var unitsNames = map[Units]string{
+	UnitsUnknown:  "",
+	UnitsVolts:    "V",
+	UnitsSeconds:  "s",
+	UnitsConstant: "",
+	UnitsAmps:     "A",
+	UnitsDecibels: "dB",
+	UnitsHertz:    "Hz",
+}
+
+// String implements the Stringer interface for Units and returns the unit
+// symbol.
+func (u Units) String() string {
+	return unitsNames[u]
+}
+
+// WaveformType is the acquisition type of a waveform.
+type WaveformType int
+
+// Available waveform types as enumerated in the binary waveform file format.
+const (
+	WaveformUnknown WaveformType = iota
+	WaveformNormal
+	WaveformPeakDetect
+	WaveformAverage
+	WaveformHorizontalHistogram
+	WaveformVerticalHistogram
+	WaveformLogic
+)
+
+// BufferType is the type of data stored in a waveform data buffer.
+type BufferType int
+
+// Available buffer types as enumerated in the binary waveform file format.
+const (
+	BufferUnknown BufferType = iota
+	BufferNormal
+	BufferMaximum
+	BufferMinimum
+	BufferTime
+	BufferCounts
+	BufferLogic
+)
+
+// Buffer is a single data buffer of a waveform. Depending on the buffer type,
+// only one of Values, Counts, or Logic contains data.
+type Buffer struct {
+	Type          BufferType
+	BytesPerPoint int
+	// Values contains the data for the normal, maximum, minimum, and time
+	// buffer types.
+	Values []float32
+	// Counts contains the data for histogram counts buffers.
+	Counts []int32
+	// Logic contains the data for digital channel buffers.
+	Logic []uint8
+}
+
+// Waveform is a single waveform, typically an oscilloscope channel, along with
+// its time base.
+type Waveform struct {
+	Label          string
+	Type           WaveformType
+	NumPoints      int
+	Count          int
+	XDisplayRange  float64
+	XDisplayOrigin float64
+	XIncrement     float64
+	XOrigin        float64
+	XUnits         Units
+	YUnits         Units
+	Date           string
+	Time           string
+	Frame          string
+	TimeTag        float64
+	SegmentIndex   int
+	Buffers        []Buffer
+}
+
+// Samples returns the samples of the first floating point data buffer as
+// float64 values, or nil if the waveform doesn't contain one.
+func (wfm Waveform) Samples() []float64 {
+	for _, buf := range wfm.Buffers {
+		if buf.Values == nil || buf.Type == BufferTime {
+			continue
+		}
+		samples := make([]float64, len(buf.Values))
+		for i, v := range buf.Values {
+			samples[i] = float64(v)
+		}
+		return samples
+	}
+	return nil
+}
+
+// Times returns the x axis value for each point of the waveform computed from
+// the x origin and x increment.
+func (wfm Waveform) Times() []float64 {
+	times := make([]float64, wfm.NumPoints)
+	for i := range times {
+		times[i] = wfm.XOrigin + float64(i)*wfm.XIncrement
+	}
+	return times
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Segment is a single acquisition of a segmented memory waveform. The
+// oscilloscope saves each segment as a separate waveform with the same label,
+// a segment index starting at 1, and a time tag.
+type Segment struct {
+	Index int
+	// TimeTag is the time in seconds of the segment's trigger relative to
+	// the trigger of the first segment.
+	TimeTag float64
+	// Time is the absolute time of the segment's trigger, which is the date
+	// and time saved with the waveform plus the time tag, or the zero time if
+	// the waveform date can't be parsed.
+	Time     time.Time
+	Waveform Waveform
+}
+
+// waveformDateLayouts are the layouts of the date and time saved with the
+// binary and HDF5 waveforms.
+var waveformDateLayouts = []string{
+	"02 Jan 2006 15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// IsSegmented reports whether the file contains a segmented memory
+// acquisition.
+func (bf BinFile) IsSegmented() bool {
+	for _, wfm := range bf.Waveforms {
+		if wfm.SegmentIndex > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Labels returns the distinct waveform labels in the order they are first
+// stored, so each channel of a segmented acquisition is only listed once.
+func (bf BinFile) Labels() []string {
+	var labels []string
+	seen := make(map[string]bool)
+	for _, wfm := range bf.Waveforms {
+		if !seen[wfm.Label] {
+			seen[wfm.Label] = true
+			labels = append(labels, wfm.Label)
+		}
+	}
+	return labels
+}
+
+// Segments returns the segments of the waveforms with the given label in the
+// order they are stored. A waveform that isn't segmented is returned as a
+// single segment with an index of 0.
+func (bf BinFile) Segments(label string) []Segment {
+	var segments []Segment
+	for _, wfm := range bf.Waveforms {
+		if wfm.Label != label {
+			continue
+		}
+		seg := Segment{
+			Index:    wfm.SegmentIndex,
+			TimeTag:  wfm.TimeTag,
+			Waveform: wfm,
+		}
+		if saved, ok := wfm.Timestamp(); ok {
+			nsec := math.Round(wfm.TimeTag * float64(time.Second))
+			seg.Time = saved.Add(time.Duration(nsec))
+		}
+		segments = append(segments, seg)
+	}
+	return segments
+}
+
+// Timestamp returns the date and time the waveform was saved, which is
+// false if the waveform doesn't have a date or it can't be parsed.
+func (wfm Waveform) Timestamp() (time.Time, bool) {
+	saved := strings.TrimSpace(wfm.Date + " " + wfm.Time)
+	for _, layout := range waveformDateLayouts {
+		if ts, err := time.Parse(layout, saved); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package scope
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSegments(t *testing.T) {
+	got, err := ReadBinFile("./testdata/dsox3034t_segmented.bin")
+	if err != nil {
+		t.Fatalf("received error reading bin file: %s", err)
+	}
+	assert(t, "segmented", got.IsSegmented(), true)
+	assert(t, "num waveforms", len(got.Waveforms), 6)
+	labels := got.Labels()
+	assert(t, "num labels", len(labels), 2)
+	assert(t, "label 1", labels[0], "1")
+	assert(t, "label 2", labels[1], "2")
+
+	saved := time.Date(2023, time.March, 16, 10, 42, 17, 0, time.UTC)
+	var tests = []struct {
+		index   int
+		timeTag float64
+		time    time.Time
+		first   float64
+	}{
+		{1, 0, saved, 2},
+		{2, 1.5e-3, saved.Add(1500 * time.Microsecond), 4},
+		{3, 3.25e-3, saved.Add(3250 * time.Microsecond), 6},
+	}
+	segments := got.Segments("2")
+	assert(t, "num segments", len(segments), len(tests))
+	for i, test := range tests {
+		seg := segments[i]
+		assert(t, "index", seg.Index, test.index)
+		assertFloat64(t, "time tag", seg.TimeTag, test.timeTag, 1e-12)
+		assert(t, "time", seg.Time, test.time)
+		samples := seg.Waveform.Samples()
+		assert(t, "num samples", len(samples), 20)
+		assertFloat64(t, "first sample", samples[0], test.first, 1e-6)
+		assertFloat64(t, "last sample", samples[19], test.first+19*0.125, 1e-6)
+	}
+	assert(t, "missing label", len(got.Segments("3")), 0)
+}
+
+func TestSegmentsNotSegmented(t *testing.T) {
+	got, err := ReadBinFile("./testdata/dsox3034t_two_channels.bin")
+	if err != nil {
+		t.Fatalf("received error reading bin file: %s", err)
+	}
+	assert(t, "segmented", got.IsSegmented(), false)
+	segments := got.Segments("1")
+	assert(t, "num segments", len(segments), 1)
+	assert(t, "index", segments[0].Index, 0)
+	assert(t, "time", segments[0].Time, time.Date(2023, time.March, 16, 10, 42, 17, 0, time.UTC))
+}
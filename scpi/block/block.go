@@ -0,0 +1,230 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package block encodes and decodes the IEEE 488.2 arbitrary binary blocks
+// used by SCPI responses and some instrument files to transfer binary data,
+// such as trace or waveform values.
+//
+// A definite length block consists of a #, a digit giving the number of
+// length digits, the length digits, and the data, such as #3012 followed by
+// 12 bytes. An indefinite length block starts with #0 and ends with a
+// newline.
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Byte orders selected using the SCPI :FORMat:BORDer command.
+var (
+	// Normal is the big-endian byte order selected by :FORM:BORD NORM, which
+	// is the default for most instruments.
+	Normal binary.ByteOrder = binary.BigEndian
+	// Swapped is the little-endian byte order selected by :FORM:BORD SWAP.
+	Swapped binary.ByteOrder = binary.LittleEndian
+)
+
+// ErrInvalidHeader is returned when the data doesn't start with a valid
+// block header.
+var ErrInvalidHeader = errors.New("invalid binary block header")
+
+// Encode returns the data as a definite length block.
+func Encode(data []byte) []byte {
+	return Append(nil, data)
+}
+
+// Append appends the data as a definite length block to dst and returns the
+// extended slice.
+func Append(dst, data []byte) []byte {
+	length := strconv.Itoa(len(data))
+	dst = append(dst, '#', byte('0'+len(length)))
+	dst = append(dst, length...)
+	return append(dst, data...)
+}
+
+// Decode decodes the block at the start of b, returning the data and the
+// rest of b following the block. The data of an indefinite length block
+// excludes the terminating newline, which is also removed from the rest.
+func Decode(b []byte) (data, rest []byte, err error) {
+	if len(b) < 2 || b[0] != '#' || b[1] < '0' || b[1] > '9' {
+		return nil, b, ErrInvalidHeader
+	}
+	digits := int(b[1] - '0')
+	b = b[2:]
+	if digits == 0 {
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			return b[:i], b[i+1:], nil
+		}
+		return b, nil, nil
+	}
+	if len(b) < digits {
+		return nil, b, fmt.Errorf("%w: truncated length", ErrInvalidHeader)
+	}
+	n, err := parseLength(b[:digits])
+	if err != nil {
+		return nil, b, err
+	}
+	b = b[digits:]
+	if len(b) < n {
+		return nil, b, fmt.Errorf("truncated block / got %d bytes / expected %d", len(b), n)
+	}
+	return b[:n], b[n:], nil
+}
+
+// Read reads a block from r and returns its data. Only the block is read, so
+// a newline terminating the response following a definite length block is
+// left unread. The data of an indefinite length block excludes the
+// terminating newline.
+func Read(r io.Reader) ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != '#' || header[1] < '0' || header[1] > '9' {
+		return nil, ErrInvalidHeader
+	}
+	digits := int(header[1] - '0')
+	if digits == 0 {
+		return readLine(r)
+	}
+	length := make([]byte, digits)
+	if _, err := io.ReadFull(r, length); err != nil {
+		return nil, err
+	}
+	n, err := parseLength(length)
+	if err != nil {
+		return nil, err
+	}
+	// Grow the buffer as the data is read, so that a corrupt length doesn't
+	// allocate gigabytes before the data runs out.
+	buf := bytes.NewBuffer(make([]byte, 0, min(n, 1<<20)))
+	if _, err := io.CopyN(buf, r, int64(n)); err != nil {
+		if err == io.EOF && buf.Len() > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parseLength(digits []byte) (int, error) {
+	n, err := strconv.Atoi(string(digits))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: length %q", ErrInvalidHeader, digits)
+	}
+	return n, nil
+}
+
+// readLine reads up to and excluding a newline or the end of the data, using
+// single byte reads so that nothing after the newline is consumed.
+func readLine(r io.Reader) ([]byte, error) {
+	var data []byte
+	if br, ok := r.(io.ByteReader); ok {
+		for {
+			c, err := br.ReadByte()
+			if err == io.EOF || c == '\n' {
+				return data, nil
+			}
+			if err != nil {
+				return data, err
+			}
+			data = append(data, c)
+		}
+	}
+	var c [1]byte
+	for {
+		_, err := io.ReadFull(r, c[:])
+		if err == io.EOF || c[0] == '\n' {
+			return data, nil
+		}
+		if err != nil {
+			return data, err
+		}
+		data = append(data, c[0])
+	}
+}
+
+// Float32s decodes the data as float32 values using the byte order.
+func Float32s(data []byte, order binary.ByteOrder) ([]float64, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("data length %d isn't a multiple of 4", len(data))
+	}
+	values := make([]float64, len(data)/4)
+	for i := range values {
+		values[i] = float64(math.Float32frombits(order.Uint32(data[4*i:])))
+	}
+	return values, nil
+}
+
+// Float64s decodes the data as float64 values using the byte order.
+func Float64s(data []byte, order binary.ByteOrder) ([]float64, error) {
+	if len(data)%8 != 0 {
+		return nil, fmt.Errorf("data length %d isn't a multiple of 8", len(data))
+	}
+	values := make([]float64, len(data)/8)
+	for i := range values {
+		values[i] = math.Float64frombits(order.Uint64(data[8*i:]))
+	}
+	return values, nil
+}
+
+// Int16s decodes the data as int16 values using the byte order, such as
+// oscilloscope waveforms transferred using :WAV:FORM WORD.
+func Int16s(data []byte, order binary.ByteOrder) ([]int16, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("data length %d isn't a multiple of 2", len(data))
+	}
+	values := make([]int16, len(data)/2)
+	for i := range values {
+		values[i] = int16(order.Uint16(data[2*i:]))
+	}
+	return values, nil
+}
+
+// Int8s decodes the data as int8 values, such as oscilloscope waveforms
+// transferred using :WAV:FORM BYTE.
+func Int8s(data []byte) []int8 {
+	values := make([]int8, len(data))
+	for i, b := range data {
+		values[i] = int8(b)
+	}
+	return values
+}
+
+// EncodeFloat32s returns the values as a definite length block of float32
+// values using the byte order.
+func EncodeFloat32s(values []float64, order binary.ByteOrder) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		order.PutUint32(data[4*i:], math.Float32bits(float32(v)))
+	}
+	return Encode(data)
+}
+
+// EncodeFloat64s returns the values as a definite length block of float64
+// values using the byte order.
+func EncodeFloat64s(values []float64, order binary.ByteOrder) []byte {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		order.PutUint64(data[8*i:], math.Float64bits(v))
+	}
+	return Encode(data)
+}
+
+// EncodeInt16s returns the values as a definite length block of int16 values
+// using the byte order.
+func EncodeInt16s(values []int16, order binary.ByteOrder) []byte {
+	data := make([]byte, 2*len(values))
+	for i, v := range values {
+		order.PutUint16(data[2*i:], uint16(v))
+	}
+	return Encode(data)
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package block
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncode(t *testing.T) {
+	var tests = []struct {
+		data string
+		want string
+	}{
+		{"", "#10"},
+		{"abc", "#13abc"},
+		{strings.Repeat("x", 12), "#212" + strings.Repeat("x", 12)},
+		{strings.Repeat("x", 1000), "#41000" + strings.Repeat("x", 1000)},
+	}
+	for _, test := range tests {
+		assert(t, "block", string(Encode([]byte(test.data))), test.want)
+	}
+	assert(t, "append", string(Append([]byte("A"), []byte("bc"))), "A#12bc")
+}
+
+func TestDecode(t *testing.T) {
+	var tests = []struct {
+		name string
+		b    string
+		data string
+		rest string
+		err  bool
+	}{
+		{"definite", "#3005hello\n", "hello", "\n", false},
+		{"empty", "#10", "", "", false},
+		{"indefinite", "#0hello\nmore", "hello", "more", false},
+		{"indefinite without newline", "#0hello", "hello", "", false},
+		{"missing hash", "3005hello", "", "", true},
+		{"bad digit", "#x005hello", "", "", true},
+		{"truncated length", "#30", "", "", true},
+		{"bad length", "#2a5hello", "", "", true},
+		{"truncated data", "#210hello", "", "", true},
+		{"huge length", "#9999999999hello", "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, rest, err := Decode([]byte(test.b))
+			assert(t, "error", err != nil, test.err)
+			if test.err {
+				return
+			}
+			assert(t, "data", string(data), test.data)
+			assert(t, "rest", string(rest), test.rest)
+		})
+	}
+	if _, _, err := Decode([]byte("?")); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("got %v, want ErrInvalidHeader", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	var tests = []struct {
+		name string
+		b    string
+		data string
+		rest string
+		err  bool
+	}{
+		{"definite", "#3005hello\n", "hello", "\n", false},
+		{"indefinite", "#0hello\nmore", "hello", "more", false},
+		{"indefinite without newline", "#0hello", "hello", "", false},
+		{"missing hash", "3005hello", "", "", true},
+		{"truncated data", "#210hello", "", "", true},
+		{"empty", "", "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Read from both a plain reader and a byte reader, which take
+			// different paths for indefinite length blocks.
+			readers := map[string]io.Reader{
+				"plain":    iotest.OneByteReader(strings.NewReader(test.b)),
+				"buffered": bufio.NewReader(strings.NewReader(test.b)),
+			}
+			for kind, r := range readers {
+				data, err := Read(r)
+				assert(t, kind+" error", err != nil, test.err)
+				if test.err {
+					continue
+				}
+				assert(t, kind+" data", string(data), test.data)
+				rest, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("received error: %s", err)
+				}
+				assert(t, kind+" rest", string(rest), test.rest)
+			}
+		})
+	}
+}
+
+func TestFloats(t *testing.T) {
+	values := []float64{-80.5, 0, 1e9, 0.25}
+	var tests = []struct {
+		name   string
+		encode func([]float64) []byte
+		decode func([]byte) ([]float64, error)
+		first  string
+	}{
+		{"float32 normal", func(v []float64) []byte { return EncodeFloat32s(v, Normal) },
+			func(b []byte) ([]float64, error) { return Float32s(b, Normal) }, "\xc2\xa1\x00\x00"},
+		{"float32 swapped", func(v []float64) []byte { return EncodeFloat32s(v, Swapped) },
+			func(b []byte) ([]float64, error) { return Float32s(b, Swapped) }, "\x00\x00\xa1\xc2"},
+		{"float64 normal", func(v []float64) []byte { return EncodeFloat64s(v, Normal) },
+			func(b []byte) ([]float64, error) { return Float64s(b, Normal) }, "\xc0\x54\x20\x00\x00\x00\x00\x00"},
+		{"float64 swapped", func(v []float64) []byte { return EncodeFloat64s(v, Swapped) },
+			func(b []byte) ([]float64, error) { return Float64s(b, Swapped) }, "\x00\x00\x00\x00\x00\x20\x54\xc0"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, rest, err := Decode(test.encode(values))
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "rest", len(rest), 0)
+			size := len(test.first)
+			assert(t, "first value", string(data[:size]), test.first)
+			got, err := test.decode(data)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assert(t, "num values", len(got), len(values))
+			for i := range values {
+				assert(t, fmt.Sprintf("value %d", i), got[i], values[i])
+			}
+			if _, err := test.decode(data[:len(data)-1]); err == nil {
+				t.Errorf("expected error for partial value")
+			}
+		})
+	}
+}
+
+func TestInts(t *testing.T) {
+	values := []int16{-32768, -1, 0, 258, 32767}
+	data, _, err := Decode(EncodeInt16s(values, Normal))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "first bytes", string(data[:4]), "\x80\x00\xff\xff")
+	got, err := Int16s(data, Normal)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "int16s", fmt.Sprint(got), fmt.Sprint(values))
+	if got, _ := Int16s(data, Swapped); got[3] != 0x0201 {
+		t.Errorf("got %d for swapped value 3, want %d", got[3], 0x0201)
+	}
+	if _, err := Int16s(data[:3], Normal); err == nil {
+		t.Errorf("expected error for odd length")
+	}
+	assert(t, "int8s", fmt.Sprint(Int8s([]byte{0x80, 0xff, 0, 0x7f})), "[-128 -1 0 127]")
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	f.Add([]byte("#15hello\n"))
+	f.Add([]byte("#0hello\n"))
+	f.Add([]byte("#9000000005hello"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		b, err := Read(bytes.NewReader(data))
+		if err == nil && len(b) > len(data) {
+			t.Errorf("read %d bytes from %d byte block", len(b), len(data))
+		}
+	})
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package series adapts parsed traces and waveforms to gonum, so they can be
+// plotted using gonum.org/v1/plot or used in linear algebra with
+// gonum.org/v1/gonum/mat. The parsing packages don't depend on gonum, so it's
+// only needed by programs importing this package.
+//
+// An XY satisfies plotter.XYer and can be passed directly to plotter.NewLine
+// or converted to plotter.XYs using XYs, while Vectors returns the x and y
+// values as mat.VecDense vectors:
+//
+//	xy, err := series.FromTrace(trace, 1)
+//	if err != nil {
+//		return err
+//	}
+//	line, err := plotter.NewLine(xy)
+//	freq, amplitude := xy.Vectors()
+package series
+
+import (
+	"fmt"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/scope"
+	"github.com/gotmc/keysight/tracemath"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/plot/plotter"
+)
+
+// The series types implement the gonum/plot interfaces.
+var (
+	_ plotter.XYer   = XY{}
+	_ plotter.Valuer = Values{}
+)
+
+// XY is a series of x and y values of the same length.
+type XY struct {
+	X []float64
+	Y []float64
+}
+
+// New returns a series with the given x and y values, which must have the
+// same length.
+func New(x, y []float64) (XY, error) {
+	if len(x) != len(y) {
+		return XY{}, fmt.Errorf("mismatched lengths / x %d / y %d", len(x), len(y))
+	}
+	return XY{X: x, Y: y}, nil
+}
+
+// Len returns the number of points in the series.
+func (s XY) Len() int {
+	return len(s.X)
+}
+
+// XY returns the x and y values of the i-th point.
+func (s XY) XY(i int) (x, y float64) {
+	return s.X[i], s.Y[i]
+}
+
+// Values returns the y values of the series.
+func (s XY) Values() Values {
+	return Values(s.Y)
+}
+
+// XYs returns a copy of the series as gonum/plot points.
+func (s XY) XYs() plotter.XYs {
+	xys := make(plotter.XYs, len(s.X))
+	for i := range xys {
+		xys[i].X, xys[i].Y = s.X[i], s.Y[i]
+	}
+	return xys
+}
+
+// Vectors returns the x and y values as gonum vectors, which share the
+// slices of the series. An empty series returns nil vectors, since gonum
+// doesn't allow vectors of length zero.
+func (s XY) Vectors() (x, y *mat.VecDense) {
+	if len(s.X) == 0 {
+		return nil, nil
+	}
+	return mat.NewVecDense(len(s.X), s.X), mat.NewVecDense(len(s.Y), s.Y)
+}
+
+// Copy returns a copy of the series that doesn't share the underlying
+// slices, which is useful before modifying the values in place, such as with
+// a gonum vector created from the slices.
+func (s XY) Copy() XY {
+	return XY{
+		X: append([]float64(nil), s.X...),
+		Y: append([]float64(nil), s.Y...),
+	}
+}
+
+// Values is a series of values without x values.
+type Values []float64
+
+// Len returns the number of values.
+func (v Values) Len() int {
+	return len(v)
+}
+
+// Value returns the i-th value.
+func (v Values) Value(i int) float64 {
+	return v[i]
+}
+
+// FromTrace returns the frequencies in Hz and the amplitudes of the given
+// trace number, starting at 1. The series shares the slices of the trace.
+func FromTrace(trace esa.Trace, number int) (XY, error) {
+	traces := trace.Traces()
+	if number < 1 || number > len(traces) {
+		return XY{}, fmt.Errorf("invalid trace number: %d", number)
+	}
+	values := traces[number-1].Values
+	if len(values) == 0 {
+		return XY{}, fmt.Errorf("trace %d doesn't contain any data", number)
+	}
+	return New(trace.Frequency, values)
+}
+
+// FromTraces returns a series for each trace containing data, which is
+// useful for plotting every trace of a file at once.
+func FromTraces(trace esa.Trace) ([]XY, error) {
+	var all []XY
+	for i, td := range trace.Traces() {
+		if len(td.Values) == 0 {
+			continue
+		}
+		s, err := New(trace.Frequency, td.Values)
+		if err != nil {
+			return nil, fmt.Errorf("error in trace %d: %s", i+1, err)
+		}
+		all = append(all, s)
+	}
+	if len(all) == 0 {
+		return nil, fmt.Errorf("trace doesn't contain any data")
+	}
+	return all, nil
+}
+
+// FromTracemath returns the frequencies and values of the trace.
+func FromTracemath(t tracemath.Trace) (XY, error) {
+	return New(t.Frequency, t.Values)
+}
+
+// FromWaveform returns the times and samples of the first sample buffer of
+// the waveform.
+func FromWaveform(wfm scope.Waveform) (XY, error) {
+	samples := wfm.Samples()
+	if samples == nil {
+		return XY{}, fmt.Errorf("waveform %s doesn't contain sample data", wfm.Label)
+	}
+	times := make([]float64, len(samples))
+	for i := range times {
+		times[i] = wfm.XOrigin + float64(i)*wfm.XIncrement
+	}
+	return New(times, samples)
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/scope"
+	"github.com/gotmc/keysight/tracemath"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/plot/plotter"
+)
+
+func TestFromTrace(t *testing.T) {
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	s, err := FromTrace(trace, 1)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "len", s.Len(), 401)
+	x, y := s.XY(0)
+	assert(t, "x", x, trace.Frequency[0])
+	assert(t, "y", y, trace.Trace1[0])
+	assert(t, "value", s.Values().Value(400), trace.Trace1[400])
+	c := s.Copy()
+	c.Y[0] = 1234
+	assert(t, "copy is independent", trace.Trace1[0] != 1234, true)
+	all, err := FromTraces(trace)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num series", len(all), 3)
+
+	if _, err := FromTrace(trace, 4); err == nil {
+		t.Errorf("expected error for invalid trace number")
+	}
+	if _, err := FromTrace(esa.Trace{}, 1); err == nil {
+		t.Errorf("expected error for empty trace")
+	}
+	if _, err := FromTraces(esa.Trace{}); err == nil {
+		t.Errorf("expected error for trace without data")
+	}
+	trace.Trace2 = trace.Trace2[:10]
+	if _, err := FromTraces(trace); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+}
+
+func TestGonum(t *testing.T) {
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_trace924.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	s, err := FromTrace(trace, 1)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	line, err := plotter.NewLine(s)
+	if err != nil {
+		t.Fatalf("error creating line: %s", err)
+	}
+	assert(t, "line points", len(line.XYs), 401)
+	if _, err := plotter.NewBarChart(s.Values(), 1); err != nil {
+		t.Fatalf("error creating bar chart: %s", err)
+	}
+	xys := s.XYs()
+	assert(t, "num XYs", len(xys), 401)
+	assert(t, "XYs", xys[400], plotter.XY{X: trace.Frequency[400], Y: trace.Trace1[400]})
+	xys[0].Y = 1234
+	assert(t, "XYs are independent", trace.Trace1[0] != 1234, true)
+
+	freq, amplitude := s.Vectors()
+	assert(t, "vector len", amplitude.Len(), 401)
+	assert(t, "freq", freq.AtVec(400), trace.Frequency[400])
+	ones := mat.NewVecDense(401, nil)
+	for i := 0; i < 401; i++ {
+		ones.SetVec(i, 1)
+	}
+	sum := 0.0
+	for _, v := range trace.Trace1 {
+		sum += v
+	}
+	assertFloat64(t, "sum", mat.Dot(amplitude, ones), sum, 1e-9)
+	amplitude.SetVec(0, 4321)
+	assert(t, "vectors share slices", trace.Trace1[0], 4321.0)
+
+	x, y := XY{}.Vectors()
+	if x != nil || y != nil {
+		t.Errorf("expected nil vectors for empty series")
+	}
+}
+
+func TestFromTraceExtraTraces(t *testing.T) {
+	trace, err := esa.ReadCSVFile("../esa/testdata/e4402b_five_traces.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	s, err := FromTrace(trace, 5)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	_, y := s.XY(0)
+	assert(t, "y", y, 38.123)
+	all, err := FromTraces(trace)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num series", len(all), 5)
+	if _, err := FromTrace(trace, 6); err == nil {
+		t.Errorf("expected error for invalid trace number")
+	}
+}
+
+func TestFromTracemath(t *testing.T) {
+	s, err := FromTracemath(tracemath.Trace{Frequency: []float64{1, 2}, Values: []float64{3, 4}})
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "len", s.Len(), 2)
+	if _, err := FromTracemath(tracemath.Trace{Frequency: []float64{1}}); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+}
+
+func TestFromWaveform(t *testing.T) {
+	wfm := scope.Waveform{
+		Label:      "Channel 1",
+		NumPoints:  3,
+		XIncrement: 1e-9,
+		XOrigin:    -1e-9,
+		Buffers:    []scope.Buffer{{Type: scope.BufferNormal, BytesPerPoint: 4, Values: []float32{0.5, 1, 1.5}}},
+	}
+	s, err := FromWaveform(wfm)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	x, y := s.XY(2)
+	assert(t, "time", x, 1e-9)
+	assert(t, "sample", y, 1.5)
+	if _, err := FromWaveform(scope.Waveform{}); err == nil {
+		t.Errorf("expected error for waveform without samples")
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package server provides an HTTP API for parsing instrument files and
+// browsing an archive of spectrum analyzer traces, so that a lab can run a
+// central service instead of per-user scripts.
+//
+// The API has the following endpoints:
+//
+//	GET  /api/formats         list the names of the supported file formats
+//	POST /api/files           parse the uploaded file and return it as JSON
+//	GET  /api/traces          list the archived traces matching the query
+//	GET  /api/traces/{id}     return an archived trace as JSON or CSV
+//
+// A file is uploaded either as the request body with the filename given by
+// the filename query parameter, or as the "file" field of a multipart form.
+// The filename is used to detect the format, so it should be the name saved
+// by the instrument. The response contains the filename, the detected
+// format, and the parsed data. If the archive query parameter is true,
+// spectrum analyzer traces are also stored in the archive and the response
+// includes the ID of the trace.
+//
+// The traces are listed using the model, title, from, to, minCenter,
+// maxCenter, and limit query parameters, which correspond to the fields of
+// tracedb.Query. The from and to times are in RFC 3339 format and the
+// center frequencies are in Hz. An archived trace is returned as JSON unless
+// the format query parameter is csv.
+//
+// The supported formats include those added using keysight.Register by the
+// packages linked into the program.
+//
+// Errors are returned as a JSON object with an error member.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/tracedb"
+)
+
+// DefaultMaxUploadSize is the default maximum size of an uploaded file in
+// bytes.
+const DefaultMaxUploadSize = 64 << 20
+
+// Archive stores and finds spectrum analyzer traces, such as a *tracedb.DB.
+// Get returns tracedb.ErrNotFound if the trace doesn't exist.
+type Archive interface {
+	Insert(ctx context.Context, trace esa.Trace) (int64, error)
+	Get(ctx context.Context, id int64) (esa.Trace, error)
+	Find(ctx context.Context, q tracedb.Query) ([]tracedb.Record, error)
+}
+
+// Server is an http.Handler serving the API.
+type Server struct {
+	archive       Archive
+	maxUploadSize int64
+	mux           *http.ServeMux
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithArchive attaches the archive browsed by the traces endpoints and used
+// to store uploaded traces. Without an archive, those requests return 404
+// Not Found.
+func WithArchive(archive Archive) Option {
+	return func(s *Server) {
+		s.archive = archive
+	}
+}
+
+// WithMaxUploadSize sets the maximum size of an uploaded file in bytes. The
+// default is DefaultMaxUploadSize.
+func WithMaxUploadSize(n int64) Option {
+	return func(s *Server) {
+		s.maxUploadSize = n
+	}
+}
+
+// New returns a server configured using the options.
+func New(opts ...Option) *Server {
+	s := &Server{maxUploadSize: DefaultMaxUploadSize, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("/api/formats", s.handleFormats)
+	s.mux.HandleFunc("/api/files", s.handleFiles)
+	s.mux.HandleFunc("/api/traces", s.handleTraces)
+	s.mux.HandleFunc("/api/traces/", s.handleTrace)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// parsedFile is the response to an uploaded file.
+type parsedFile struct {
+	Filename string          `json:"filename"`
+	Format   keysight.Format `json:"format"`
+	ID       int64           `json:"id,omitempty"`
+	Data     interface{}     `json:"data"`
+}
+
+// record is the JSON encoding of a tracedb.Record.
+type record struct {
+	ID               int64      `json:"id"`
+	Timestamp        *time.Time `json:"timestamp,omitempty"`
+	OriginalFilename string     `json:"originalFilename"`
+	Title            string     `json:"title"`
+	Model            string     `json:"model"`
+	SerialNum        string     `json:"serialNumber"`
+	CenterFreq       float64    `json:"centerFrequency"`
+	Span             float64    `json:"span"`
+	RBW              float64    `json:"rbw"`
+	VBW              float64    `json:"vbw"`
+	NumPoints        int        `json:"numPoints"`
+}
+
+func (s *Server) handleFormats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, keysight.Formats())
+}
+
+func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	archive, err := parseBool(r.URL.Query().Get("archive"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid archive parameter: %s", err)
+		return
+	}
+	if archive && s.archive == nil {
+		writeError(w, http.StatusNotFound, "no trace archive")
+		return
+	}
+	filename, data, err := s.readUpload(w, r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "file is larger than %d bytes", tooLarge.Limit)
+			return
+		}
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	format, err := keysight.Detect(filename, data)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "error detecting format of %s: %s", filename, err)
+		return
+	}
+	v, err := keysight.Read(filename, data)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "%s", err)
+		return
+	}
+	resp := parsedFile{Filename: filename, Format: format, Data: v}
+	if trace, ok := keysight.SpectrumTrace(v); ok {
+		resp.Data = trace
+		if archive {
+			if trace.OriginalFilename == "" {
+				trace.OriginalFilename = filename
+			}
+			if resp.ID, err = s.archive.Insert(r.Context(), trace); err != nil {
+				writeError(w, http.StatusInternalServerError, "%s", err)
+				return
+			}
+		}
+	} else if archive {
+		writeError(w, http.StatusUnprocessableEntity, "only spectrum analyzer traces can be archived")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// readUpload returns the filename and contents of the uploaded file.
+func (s *Server) readUpload(w http.ResponseWriter, r *http.Request) (string, []byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxUploadSize)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		filename := path.Base(r.URL.Query().Get("filename"))
+		if filename == "." || filename == "/" {
+			return "", nil, fmt.Errorf("missing filename parameter")
+		}
+		data, err := io.ReadAll(r.Body)
+		return filename, data, err
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return "", nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return "", nil, fmt.Errorf("missing file field")
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		filename := part.FileName()
+		if filename == "" {
+			return "", nil, fmt.Errorf("missing filename of file field")
+		}
+		data, err := io.ReadAll(part)
+		return filename, data, err
+	}
+}
+
+func (s *Server) handleTraces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if s.archive == nil {
+		writeError(w, http.StatusNotFound, "no trace archive")
+		return
+	}
+	q, err := parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	records, err := s.archive.Find(r.Context(), q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	resp := make([]record, len(records))
+	for i, rec := range records {
+		resp[i] = record{
+			ID:               rec.ID,
+			OriginalFilename: rec.OriginalFilename,
+			Title:            rec.Title,
+			Model:            rec.Model,
+			SerialNum:        rec.SerialNum,
+			CenterFreq:       rec.CenterFreq,
+			Span:             rec.Span,
+			RBW:              rec.RBW,
+			VBW:              rec.VBW,
+			NumPoints:        rec.NumPoints,
+		}
+		if !rec.Timestamp.IsZero() {
+			resp[i].Timestamp = &records[i].Timestamp
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleTrace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if s.archive == nil {
+		writeError(w, http.StatusNotFound, "no trace archive")
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/traces/"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "invalid trace ID")
+		return
+	}
+	trace, err := s.archive.Get(r.Context(), id)
+	if errors.Is(err, tracedb.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "trace %d not found", id)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		writeJSON(w, http.StatusOK, trace)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		trace.WriteStandardCSV(w, esa.WithMetadata(esa.MetadataComments))
+	default:
+		writeError(w, http.StatusBadRequest, "unknown format %q", format)
+	}
+}
+
+// parseQuery returns the archive query given by the request's query
+// parameters.
+func parseQuery(r *http.Request) (tracedb.Query, error) {
+	params := r.URL.Query()
+	q := tracedb.Query{Model: params.Get("model"), Title: params.Get("title")}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if s := params.Get(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s parameter: %s", p.name, err)
+			}
+			*p.t = t
+		}
+	}
+	for _, p := range []struct {
+		name string
+		v    *float64
+	}{{"minCenter", &q.MinCenterFreq}, {"maxCenter", &q.MaxCenterFreq}} {
+		if s := params.Get(p.name); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s parameter: %s", p.name, err)
+			}
+			*p.v = v
+		}
+	}
+	if s := params.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid limit parameter: %s", s)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+func parseBool(s string) (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "error encoding JSON: %s", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
+
+func writeError(w http.ResponseWriter, status int, format string, a ...interface{}) {
+	body, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{fmt.Sprintf(format, a...)})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight"
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/tracedb"
+)
+
+// fakeArchive stores traces in memory.
+type fakeArchive struct {
+	traces []esa.Trace
+	query  tracedb.Query
+}
+
+func (a *fakeArchive) Insert(ctx context.Context, trace esa.Trace) (int64, error) {
+	a.traces = append(a.traces, trace)
+	return int64(len(a.traces)), nil
+}
+
+func (a *fakeArchive) Get(ctx context.Context, id int64) (esa.Trace, error) {
+	if id < 1 || id > int64(len(a.traces)) {
+		return esa.Trace{}, tracedb.ErrNotFound
+	}
+	return a.traces[id-1], nil
+}
+
+func (a *fakeArchive) Find(ctx context.Context, q tracedb.Query) ([]tracedb.Record, error) {
+	a.query = q
+	records := make([]tracedb.Record, len(a.traces))
+	for i, trace := range a.traces {
+		records[i] = tracedb.Record{
+			ID:               int64(i + 1),
+			Timestamp:        trace.Timestamp,
+			OriginalFilename: trace.OriginalFilename,
+			Model:            trace.Model,
+			NumPoints:        len(trace.Frequency),
+		}
+	}
+	return records, nil
+}
+
+func TestFormats(t *testing.T) {
+	rec := do(New(), http.MethodGet, "/api/formats", "", nil)
+	assert(t, "status", rec.Code, http.StatusOK)
+	var formats []string
+	if err := json.Unmarshal(rec.Body.Bytes(), &formats); err != nil {
+		t.Fatalf("error decoding response: %s", err)
+	}
+	assert(t, "num formats", len(formats), len(keysight.Formats()))
+	assert(t, "first format", formats[0], "ESA trace")
+	rec = do(New(), http.MethodPost, "/api/formats", "", nil)
+	assert(t, "method", rec.Code, http.StatusMethodNotAllowed)
+}
+
+func TestUploadFile(t *testing.T) {
+	var tests = []struct {
+		filename string
+		format   string
+		schema   string
+	}{
+		{"../esa/testdata/e4402b_trace924.csv", "ESA trace", "keysight.esa.trace"},
+		{"../xseries/testdata/n9020a_trace.csv", "X-Series trace", "keysight.esa.trace"},
+		{"../touchstone/testdata/e5071c_filter.s2p", "Touchstone", ""},
+		{"../arb/testdata/sine8.arb", "arbitrary waveform", ""},
+	}
+	s := New()
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			data := readFile(t, test.filename)
+			name := test.filename[strings.LastIndex(test.filename, "/")+1:]
+			rec := do(s, http.MethodPost, "/api/files?filename="+name, "text/csv", data)
+			assert(t, "status", rec.Code, http.StatusOK)
+			var resp struct {
+				Filename string
+				Format   string
+				ID       int64
+				Data     map[string]interface{}
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("error decoding response: %s", err)
+			}
+			assert(t, "filename", resp.Filename, name)
+			assert(t, "format", resp.Format, test.format)
+			assert(t, "id", resp.ID, int64(0))
+			if test.schema != "" {
+				assert(t, "schema", resp.Data["schema"], test.schema)
+			}
+		})
+	}
+}
+
+func TestUploadTestdata(t *testing.T) {
+	filenames, err := filepath.Glob("../*/testdata/*.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	for _, filename := range filenames {
+		data := readFile(t, filename)
+		// Skip the files that are only read by their packages, such as VSA
+		// text recordings.
+		if _, err := keysight.Detect(filename, data); errors.Is(err, keysight.ErrUnknownFormat) {
+			continue
+		}
+		t.Run(filename, func(t *testing.T) {
+			rec := do(s, http.MethodPost, "/api/files?filename="+url.QueryEscape(filepath.Base(filename)),
+				"application/octet-stream", data)
+			assert(t, "status", rec.Code, http.StatusOK)
+			if rec.Code != http.StatusOK {
+				t.Logf("response: %s", rec.Body.String())
+			}
+			if !json.Valid(rec.Body.Bytes()) {
+				t.Errorf("invalid JSON response")
+			}
+		})
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("comment", "ignored")
+	fw, err := mw.CreateFormFile("file", "e4402b_trace924.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(readFile(t, "../esa/testdata/e4402b_trace924.csv"))
+	mw.Close()
+
+	archive := &fakeArchive{}
+	s := New(WithArchive(archive))
+	rec := do(s, http.MethodPost, "/api/files?archive=true", mw.FormDataContentType(), body.Bytes())
+	assert(t, "status", rec.Code, http.StatusOK)
+	var resp parsedFile
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error decoding response: %s", err)
+	}
+	assert(t, "id", resp.ID, int64(1))
+	assert(t, "archived", len(archive.traces), 1)
+	assert(t, "model", archive.traces[0].Model, "E4402B")
+}
+
+func TestUploadErrors(t *testing.T) {
+	trace := readFile(t, "../esa/testdata/e4402b_trace924.csv")
+	var tests = []struct {
+		name   string
+		server *Server
+		method string
+		target string
+		body   []byte
+		status int
+	}{
+		{"wrong method", New(), http.MethodGet, "/api/files", nil, http.StatusMethodNotAllowed},
+		{"missing filename", New(), http.MethodPost, "/api/files", trace, http.StatusBadRequest},
+		{"unknown format", New(), http.MethodPost, "/api/files?filename=notes.txt", []byte("hello\n"), http.StatusUnprocessableEntity},
+		{"too large", New(WithMaxUploadSize(100)), http.MethodPost, "/api/files?filename=t.csv", trace, http.StatusRequestEntityTooLarge},
+		{"no archive", New(), http.MethodPost, "/api/files?filename=t.csv&archive=1", trace, http.StatusNotFound},
+		{"invalid archive", New(), http.MethodPost, "/api/files?filename=t.csv&archive=maybe", trace, http.StatusBadRequest},
+		{
+			"archive non-trace", New(WithArchive(&fakeArchive{})), http.MethodPost, "/api/files?filename=sine8.arb&archive=1",
+			readFile(t, "../arb/testdata/sine8.arb"), http.StatusUnprocessableEntity,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := do(test.server, test.method, test.target, "text/csv", test.body)
+			assert(t, "status", rec.Code, test.status)
+			var resp struct{ Error string }
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error == "" {
+				t.Errorf("expected JSON error, got %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestBrowseTraces(t *testing.T) {
+	ts := time.Date(2021, 11, 16, 10, 50, 45, 0, time.UTC)
+	archive := &fakeArchive{traces: []esa.Trace{
+		{Model: "E4402B", Timestamp: ts, OriginalFilename: "TRACE924.CSV", Frequency: []float64{1, 2}, Trace1: []float64{-10, -20},
+			FreqLabel: "Frequency", FreqUnits: "Hz", Trace1Label: "Trace 1", Trace1Units: "dBm"},
+		{Model: "N9020A"},
+	}}
+	s := New(WithArchive(archive))
+
+	rec := do(s, http.MethodGet, "/api/traces?model=E4402B&from=2021-11-16T00:00:00Z&minCenter=1e6&limit=5", "", nil)
+	assert(t, "status", rec.Code, http.StatusOK)
+	assert(t, "query model", archive.query.Model, "E4402B")
+	assert(t, "query from", archive.query.From.Equal(ts.Truncate(24*time.Hour)), true)
+	assert(t, "query min center", archive.query.MinCenterFreq, 1e6)
+	assert(t, "query limit", archive.query.Limit, 5)
+	var records []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &records); err != nil {
+		t.Fatalf("error decoding records: %s", err)
+	}
+	assert(t, "records", len(records), 2)
+	assert(t, "timestamp", records[0]["timestamp"], "2021-11-16T10:50:45Z")
+	_, ok := records[1]["timestamp"]
+	assert(t, "zero timestamp omitted", ok, false)
+
+	rec = do(s, http.MethodGet, "/api/traces/1", "", nil)
+	assert(t, "status", rec.Code, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), `"model":"E4402B"`) {
+		t.Errorf("unexpected trace JSON: %s", rec.Body.String())
+	}
+	rec = do(s, http.MethodGet, "/api/traces/1?format=csv", "", nil)
+	assert(t, "status", rec.Code, http.StatusOK)
+	assert(t, "content type", rec.Header().Get("Content-Type"), "text/csv")
+	if !strings.Contains(rec.Body.String(), "Frequency (Hz),Trace 1 (dBm)") {
+		t.Errorf("unexpected trace CSV: %s", rec.Body.String())
+	}
+
+	for _, test := range []struct {
+		target string
+		status int
+	}{
+		{"/api/traces/3", http.StatusNotFound},
+		{"/api/traces/abc", http.StatusNotFound},
+		{"/api/traces/1?format=xml", http.StatusBadRequest},
+		{"/api/traces?from=yesterday", http.StatusBadRequest},
+		{"/api/traces?limit=-1", http.StatusBadRequest},
+	} {
+		rec := do(s, http.MethodGet, test.target, "", nil)
+		assert(t, test.target, rec.Code, test.status)
+	}
+	rec = do(New(), http.MethodGet, "/api/traces", "", nil)
+	assert(t, "no archive", rec.Code, http.StatusNotFound)
+}
+
+func do(s *Server, method, target, contentType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func readFile(t *testing.T, filename string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package touchstone has the ability to parse and write Touchstone (.sNp)
+// files, such as those exported by the Keysight ENA and PNA network analyzers.
+package touchstone
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format is the format of the network data pairs.
+type Format string
+
+// Available data formats.
+const (
+	RI Format = "RI" // Real and imaginary
+	MA Format = "MA" // Linear magnitude and angle in degrees
+	DB Format = "DB" // Magnitude in dB and angle in degrees
+)
+
+// NoiseParameter is the two-port noise data for a single frequency.
+type NoiseParameter struct {
+	Frequency float64
+	// MinNoiseFigure is the minimum noise figure in dB.
+	MinNoiseFigure float64
+	// ReflectionCoeff is the source reflection coefficient to realize the
+	// minimum noise figure.
+	ReflectionCoeff complex128
+	// EffectiveNoiseResistance is normalized by the reference resistance.
+	EffectiveNoiseResistance float64
+}
+
+// SParameters contains the network data parsed from a Touchstone file. The
+// network data is stored as complex values regardless of the format used in
+// the file, and the frequencies are in Hz.
+type SParameters struct {
+	Ports int
+	// FreqUnit, Parameter, Format, and R are from the option line.
+	FreqUnit  string
+	Parameter string
+	Format    Format
+	R         float64
+	// Comments contains the comment lines without the leading exclamation
+	// mark.
+	Comments  []string
+	Frequency []float64
+	// Data contains the network data indexed by frequency, row, and column,
+	// so that Data[k][1][0] is S21 at Frequency[k].
+	Data  [][][]complex128
+	Noise []NoiseParameter
+}
+
+// At returns the network parameter for the given one-based port indices at
+// every frequency. For example, At(2, 1) returns S21.
+func (s SParameters) At(i, j int) []complex128 {
+	if i < 1 || j < 1 || i > s.Ports || j > s.Ports {
+		return nil
+	}
+	values := make([]complex128, len(s.Data))
+	for k := range s.Data {
+		values[k] = s.Data[k][i-1][j-1]
+	}
+	return values
+}
+
+var freqMultipliers = map[string]float64{
+	"HZ":  1,
+	"KHZ": 1e3,
+	"MHZ": 1e6,
+	"GHZ": 1e9,
+}
+
+var portsExtension = regexp.MustCompile(`(?i)^\.s(\d+)p$`)
+
+// maxPorts limits the number of ports, which is much larger than any
+// network analyzer has, so that a corrupt [Number of Ports] keyword can't
+// overflow the size of the network data.
+const maxPorts = 1 << 10
+
+// ReadFile reads the Touchstone file with the given filename. The number of
+// ports is determined from the .sNp extension.
+func ReadFile(filename string) (SParameters, error) {
+	m := portsExtension.FindStringSubmatch(filepath.Ext(filename))
+	if m == nil {
+		return SParameters{}, fmt.Errorf("cannot determine number of ports from filename: %s", filename)
+	}
+	ports, _ := strconv.Atoi(m[1])
+	file, err := os.Open(filename)
+	if err != nil {
+		return SParameters{}, err
+	}
+	defer file.Close()
+	return Read(file, ports)
+}
+
+// Read reads Touchstone data with the given number of ports from the
+// io.Reader. If the data contains a [Number of Ports] keyword, it takes
+// precedence over the given number of ports.
+func Read(r io.Reader, ports int) (SParameters, error) {
+	s := SParameters{
+		Ports:     ports,
+		FreqUnit:  "GHz",
+		Parameter: "S",
+		Format:    MA,
+		R:         50,
+	}
+	foundOptions := false
+	order21 := true
+	var values []float64
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if i := strings.Index(line, "!"); i >= 0 {
+			s.Comments = append(s.Comments, strings.TrimSpace(line[i+1:]))
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "#"):
+			if foundOptions {
+				continue // Only the first option line is used.
+			}
+			foundOptions = true
+			if err := s.parseOptions(line[1:]); err != nil {
+				return s, fmt.Errorf("error in option line %d: %s", lineNum, err)
+			}
+		case strings.HasPrefix(line, "["):
+			keyword, value, _ := strings.Cut(line[1:], "]")
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(keyword) {
+			case "number of ports":
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return s, fmt.Errorf("error parsing number of ports in line %d: %s", lineNum, err)
+				}
+				s.Ports = n
+			case "two-port data order":
+				order21 = value == "21_12"
+			}
+		default:
+			for _, field := range strings.Fields(line) {
+				v, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					return s, fmt.Errorf("error parsing value %s in line %d", field, lineNum)
+				}
+				values = append(values, v)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return s, err
+	}
+	if s.Ports < 1 || s.Ports > maxPorts {
+		return s, fmt.Errorf("invalid number of ports: %d", s.Ports)
+	}
+	return s, s.parseData(values, order21)
+}
+
+func (s *SParameters) parseOptions(line string) error {
+	fields := strings.Fields(strings.ToUpper(line))
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		switch field {
+		case "HZ", "KHZ", "MHZ", "GHZ":
+			s.FreqUnit = strings.Replace(strings.Replace(field, "HZ", "Hz", 1), "K", "k", 1)
+		case "S", "Y", "Z", "H", "G":
+			s.Parameter = field
+		case "RI", "MA", "DB":
+			s.Format = Format(field)
+		case "R":
+			if i+1 >= len(fields) {
+				return fmt.Errorf("missing reference resistance")
+			}
+			r, err := strconv.ParseFloat(fields[i+1], 64)
+			if err != nil {
+				return fmt.Errorf("error parsing reference resistance: %s", err)
+			}
+			s.R = r
+			i++
+		default:
+			return fmt.Errorf("unknown option: %s", field)
+		}
+	}
+	return nil
+}
+
+// parseData converts the numeric values into the network data. Two-port
+// files may be followed by noise data, which starts at the first frequency
+// that is not greater than the previous frequency.
+func (s *SParameters) parseData(values []float64, order21 bool) error {
+	mult := freqMultipliers[strings.ToUpper(s.FreqUnit)]
+	n := s.Ports
+	recordLen := 1 + 2*n*n
+	i := 0
+	for i < len(values) {
+		if n == 2 && len(s.Frequency) > 0 && values[i]*mult <= s.Frequency[len(s.Frequency)-1] {
+			return s.parseNoise(values[i:], mult)
+		}
+		if i+recordLen > len(values) {
+			return fmt.Errorf("incomplete network data for frequency %g / got %d values / expected %d",
+				values[i], len(values)-i, recordLen)
+		}
+		record := values[i : i+recordLen]
+		matrix := make([][]complex128, n)
+		for row := range matrix {
+			matrix[row] = make([]complex128, n)
+		}
+		for k := 0; k < n*n; k++ {
+			row, col := k/n, k%n
+			if n == 2 && order21 {
+				// Two-port data is ordered 11, 21, 12, 22.
+				row, col = col, row
+			}
+			matrix[row][col] = s.complex(record[1+2*k], record[2+2*k])
+		}
+		s.Frequency = append(s.Frequency, record[0]*mult)
+		s.Data = append(s.Data, matrix)
+		i += recordLen
+	}
+	return nil
+}
+
+func (s *SParameters) parseNoise(values []float64, mult float64) error {
+	const noiseLen = 5
+	if len(values)%noiseLen != 0 {
+		return fmt.Errorf("incomplete noise data / got %d values", len(values))
+	}
+	for i := 0; i < len(values); i += noiseLen {
+		s.Noise = append(s.Noise, NoiseParameter{
+			Frequency:                values[i] * mult,
+			MinNoiseFigure:           values[i+1],
+			ReflectionCoeff:          polar(values[i+2], values[i+3]),
+			EffectiveNoiseResistance: values[i+4],
+		})
+	}
+	return nil
+}
+
+// complex converts a pair of values in the file's format into a complex
+// value.
+func (s *SParameters) complex(a, b float64) complex128 {
+	switch s.Format {
+	case RI:
+		return complex(a, b)
+	case DB:
+		return polar(math.Pow(10, a/20), b)
+	}
+	return polar(a, b)
+}
+
+// polar returns the complex value for the given magnitude and angle in
+// degrees.
+func polar(mag, deg float64) complex128 {
+	return cmplx.Rect(mag, deg*math.Pi/180)
+}
@@ -0,0 +1,297 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package touchstone
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	var tests = []struct {
+		filename  string
+		ports     int
+		freqUnit  string
+		format    Format
+		r         float64
+		comment   string
+		frequency []float64
+		i, j      int
+		values    []complex128
+	}{
+		{
+			filename:  "./testdata/e5063a_cable.s1p",
+			ports:     1,
+			freqUnit:  "GHz",
+			format:    RI,
+			r:         50,
+			comment:   "Keysight Technologies,E5063A,MY54100456,A.05.00",
+			frequency: []float64{1e9, 2e9},
+			i:         1,
+			j:         1,
+			values:    []complex128{complex(0.1, -0.2), complex(0.15, -0.25)},
+		},
+		{
+			filename:  "./testdata/e5071c_filter.s2p",
+			ports:     2,
+			freqUnit:  "MHz",
+			format:    DB,
+			r:         50,
+			comment:   "Keysight Technologies,E5071C,MY46100123,B.13.10",
+			frequency: []float64{100e6, 200e6, 300e6},
+			i:         2,
+			j:         1,
+			values: []complex128{
+				polar(math.Pow(10, -3.0/20), -90),
+				polar(math.Pow(10, -1.5/20), -120),
+				polar(math.Pow(10, -6.0/20), -150),
+			},
+		},
+		{
+			filename:  "./testdata/n5222b_coupler.s4p",
+			ports:     4,
+			freqUnit:  "Hz",
+			format:    MA,
+			r:         75,
+			comment:   "Keysight Technologies,N5222B,MY59200789,A.13.95.09",
+			frequency: []float64{1e9, 2e9},
+			i:         3,
+			j:         3,
+			values:    []complex128{polar(0.1, 20), polar(0.11, 21)},
+		},
+	}
+	for _, test := range tests {
+		s, err := ReadFile(test.filename)
+		if err != nil {
+			t.Errorf("error reading %s: %s", test.filename, err)
+			continue
+		}
+		assert(t, "ports", s.Ports, test.ports)
+		assert(t, "freq unit", s.FreqUnit, test.freqUnit)
+		assert(t, "parameter", s.Parameter, "S")
+		assert(t, "format", s.Format, test.format)
+		assertFloat64(t, "reference resistance", s.R, test.r, 1e-9)
+		assert(t, "first comment", s.Comments[0], test.comment)
+		assert(t, "num frequencies", len(s.Frequency), len(test.frequency))
+		for k, freq := range test.frequency {
+			assertFloat64(t, "frequency", s.Frequency[k], freq, 1e-3)
+		}
+		values := s.At(test.i, test.j)
+		assert(t, "num values", len(values), len(test.values))
+		for k, want := range test.values {
+			assertComplex(t, "value", values[k], want, 1e-9)
+		}
+	}
+}
+
+func TestTwoPortOrder(t *testing.T) {
+	s, err := ReadFile("./testdata/e5071c_filter.s2p")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	// The S21 and S12 values are the same in the file, so check S11 and S22.
+	assertComplex(t, "S11", s.Data[0][0][0], polar(math.Pow(10, -20.0/20), 45), 1e-9)
+	assertComplex(t, "S22", s.Data[0][1][1], polar(math.Pow(10, -18.0/20), 30), 1e-9)
+
+	data := `# GHz S RI R 50
+1 11 0 21 0 12 0 22 0`
+	s, err = Read(strings.NewReader(data), 2)
+	if err != nil {
+		t.Fatalf("error reading data: %s", err)
+	}
+	assertComplex(t, "S21", s.At(2, 1)[0], 21, 1e-9)
+	assertComplex(t, "S12", s.At(1, 2)[0], 12, 1e-9)
+
+	data = `[Version] 2.0
+# GHz S RI R 50
+[Number of Ports] 2
+[Two-Port Data Order] 12_21
+[Number of Frequencies] 1
+[Network Data]
+1 11 0 12 0 21 0 22 0
+[End]`
+	s, err = Read(strings.NewReader(data), 0)
+	if err != nil {
+		t.Fatalf("error reading version 2 data: %s", err)
+	}
+	assert(t, "v2 ports", s.Ports, 2)
+	assertComplex(t, "v2 S21", s.At(2, 1)[0], 21, 1e-9)
+	assertComplex(t, "v2 S12", s.At(1, 2)[0], 12, 1e-9)
+}
+
+func TestNoiseParameters(t *testing.T) {
+	s, err := ReadFile("./testdata/amplifier_noise.s2p")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	assert(t, "num frequencies", len(s.Frequency), 2)
+	assert(t, "num noise parameters", len(s.Noise), 2)
+	assertFloat64(t, "noise frequency", s.Noise[1].Frequency, 2e9, 1e-3)
+	assertFloat64(t, "min noise figure", s.Noise[1].MinNoiseFigure, 1.5, 1e-9)
+	assertComplex(t, "reflection coefficient", s.Noise[1].ReflectionCoeff, polar(0.35, 60), 1e-9)
+	assertFloat64(t, "noise resistance", s.Noise[1].EffectiveNoiseResistance, 0.3, 1e-9)
+	assertComplex(t, "S21", s.At(2, 1)[1], polar(8, 120), 1e-9)
+}
+
+func TestMarshalJSON(t *testing.T) {
+	s, err := ReadFile("./testdata/e5071c_filter.s2p")
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("error marshaling: %s", err)
+	}
+	var got struct {
+		Ports     int
+		Frequency []float64
+		Data      [][][][2]float64
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("error unmarshaling: %s", err)
+	}
+	assert(t, "ports", got.Ports, 2)
+	assert(t, "num points", len(got.Data), len(s.Frequency))
+	want := s.Data[1][1][0]
+	assert(t, "S21", got.Data[1][1][0], [2]float64{real(want), imag(want)})
+}
+
+func TestReadErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		data  string
+		ports int
+	}{
+		{"unknown option", "# GHz S XY R 50\n1 0 0", 1},
+		{"incomplete data", "# GHz S RI R 50\n1 0 0 0", 2},
+		{"invalid value", "# GHz S RI R 50\n1 a 0", 1},
+		{"invalid ports", "# GHz S RI R 50\n1 0 0", 0},
+		{"too many ports", "# GHz S RI R 50\n[Number of Ports] 3037000500\n1 0 0", 1},
+	}
+	for _, test := range tests {
+		if _, err := Read(strings.NewReader(test.data), test.ports); err == nil {
+			t.Errorf("expected error for %s", test.name)
+		}
+	}
+	if _, err := ReadFile("./testdata/unknown.txt"); err == nil {
+		t.Errorf("expected error for filename without port count")
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func assertComplex(t *testing.T, label string, got, want complex128, tolerance float64) {
+	if diff := cmplx.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %v \t\nwant %s = %v", label, got, label, want)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	for _, filename := range []string{
+		"./testdata/e5063a_cable.s1p",
+		"./testdata/e5071c_filter.s2p",
+		"./testdata/n5222b_coupler.s4p",
+		"./testdata/amplifier_noise.s2p",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			want, err := ReadFile(filename)
+			if err != nil {
+				t.Fatalf("error reading file: %s", err)
+			}
+			for _, format := range []Format{RI, MA, DB} {
+				s := want
+				s.Format = format
+				var buf strings.Builder
+				if err := s.Write(&buf); err != nil {
+					t.Fatalf("error writing %s: %s", format, err)
+				}
+				got, err := Read(strings.NewReader(buf.String()), want.Ports)
+				if err != nil {
+					t.Fatalf("error reading written %s data: %s", format, err)
+				}
+				assert(t, "format", got.Format, format)
+				assert(t, "freq unit", got.FreqUnit, want.FreqUnit)
+				assert(t, "num comments", len(got.Comments), len(want.Comments))
+				assert(t, "num frequencies", len(got.Frequency), len(want.Frequency))
+				assert(t, "num noise", len(got.Noise), len(want.Noise))
+				for k := range want.Data {
+					assertFloat64(t, "frequency", got.Frequency[k], want.Frequency[k], 1e-3)
+					for i := range want.Data[k] {
+						for j := range want.Data[k][i] {
+							if d := cmplx.Abs(got.Data[k][i][j] - want.Data[k][i][j]); d > 1e-12 {
+								t.Errorf("S%d%d[%d] differs by %g in %s", i+1, j+1, k, d, format)
+							}
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	valid := SParameters{Ports: 1, FreqUnit: "GHz", Parameter: "S", Format: MA, R: 50}
+	var tests = []struct {
+		name   string
+		modify func(*SParameters)
+	}{
+		{"unknown freq unit", func(s *SParameters) { s.FreqUnit = "THz" }},
+		{"unknown format", func(s *SParameters) { s.Format = "XY" }},
+		{"invalid ports", func(s *SParameters) { s.Ports = 0 }},
+		{"mismatched lengths", func(s *SParameters) { s.Frequency = []float64{1e9} }},
+		{"wrong matrix size", func(s *SParameters) {
+			s.Frequency = []float64{1e9}
+			s.Data = [][][]complex128{{{1, 0}}}
+		}},
+		{"noise with one port", func(s *SParameters) { s.Noise = []NoiseParameter{{Frequency: 1e9}} }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := valid
+			test.modify(&s)
+			if err := s.Write(&strings.Builder{}); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	filenames, err := filepath.Glob("testdata/*.s*p")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			f.Fatal(err)
+		}
+		ext := filepath.Ext(filename)
+		ports, _ := strconv.Atoi(ext[2 : len(ext)-1])
+		f.Add(data, uint8(ports))
+	}
+	f.Add([]byte("[Version] 2.0\n# GHz S RI R 50\n[Number of Ports] 1\n[Network Data]\n1 0 0\n[End]"), uint8(0))
+	f.Fuzz(func(t *testing.T, data []byte, ports uint8) {
+		Read(bytes.NewReader(data), int(ports))
+	})
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package touchstone
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// pairsPerLine is the maximum number of data pairs written on a line for
+// networks with more than two ports.
+const pairsPerLine = 4
+
+// WriteFile writes the network data as a Touchstone file to the given
+// filename, which should have the .sNp extension for the number of ports.
+func (s SParameters) WriteFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := s.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write writes the network data in Touchstone version 1 format to the
+// io.Writer using the frequency units, parameter, format, and reference
+// resistance of the option line. Each comment is written on its own line
+// before the option line. Two-port data is written in the 11, 21, 12, 22
+// order, followed by any noise data.
+func (s SParameters) Write(w io.Writer) error {
+	mult, ok := freqMultipliers[strings.ToUpper(s.FreqUnit)]
+	if !ok {
+		return fmt.Errorf("unknown frequency units: %s", s.FreqUnit)
+	}
+	switch s.Format {
+	case RI, MA, DB:
+	default:
+		return fmt.Errorf("unknown format: %s", s.Format)
+	}
+	if s.Ports < 1 {
+		return fmt.Errorf("invalid number of ports: %d", s.Ports)
+	}
+	if len(s.Data) != len(s.Frequency) {
+		return fmt.Errorf("mismatched lengths / freq %d / data %d", len(s.Frequency), len(s.Data))
+	}
+	n := s.Ports
+	bw := bufio.NewWriter(w)
+	for _, comment := range s.Comments {
+		fmt.Fprintf(bw, "! %s\n", comment)
+	}
+	fmt.Fprintf(bw, "# %s %s %s R %s\n", s.FreqUnit, s.Parameter, s.Format, formatFloat(s.R))
+	for k, freq := range s.Frequency {
+		matrix := s.Data[k]
+		if len(matrix) != n {
+			return fmt.Errorf("wrong number of rows at frequency %g / got %d / expected %d", freq, len(matrix), n)
+		}
+		for _, row := range matrix {
+			if len(row) != n {
+				return fmt.Errorf("wrong number of columns at frequency %g / got %d / expected %d", freq, len(row), n)
+			}
+		}
+		bw.WriteString(formatFloat(freq / mult))
+		switch n {
+		case 1, 2:
+			for col := 0; col < n; col++ {
+				for row := 0; row < n; row++ {
+					a, b := s.pair(matrix[row][col])
+					fmt.Fprintf(bw, " %s %s", a, b)
+				}
+			}
+		default:
+			for row := 0; row < n; row++ {
+				for col := 0; col < n; col++ {
+					if col > 0 && col%pairsPerLine == 0 || row > 0 && col == 0 {
+						bw.WriteString("\n")
+					}
+					a, b := s.pair(matrix[row][col])
+					fmt.Fprintf(bw, " %s %s", a, b)
+				}
+			}
+		}
+		bw.WriteString("\n")
+	}
+	if len(s.Noise) > 0 {
+		if n != 2 {
+			return fmt.Errorf("noise data requires two ports / got %d", n)
+		}
+		for _, p := range s.Noise {
+			fmt.Fprintf(bw, "%s %s %s %s %s\n",
+				formatFloat(p.Frequency/mult),
+				formatFloat(p.MinNoiseFigure),
+				formatFloat(cmplx.Abs(p.ReflectionCoeff)),
+				formatFloat(degrees(p.ReflectionCoeff)),
+				formatFloat(p.EffectiveNoiseResistance),
+			)
+		}
+	}
+	return bw.Flush()
+}
+
+// pair returns the value formatted as a pair of numbers in the file's format.
+func (s SParameters) pair(v complex128) (string, string) {
+	switch s.Format {
+	case RI:
+		return formatFloat(real(v)), formatFloat(imag(v))
+	case DB:
+		return formatFloat(20 * math.Log10(cmplx.Abs(v))), formatFloat(degrees(v))
+	}
+	return formatFloat(cmplx.Abs(v)), formatFloat(degrees(v))
+}
+
+func degrees(v complex128) float64 {
+	return cmplx.Phase(v) * 180 / math.Pi
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// MarshalJSON implements the json.Marshaler interface. JSON doesn't have
+// complex numbers, so each network data value and noise reflection
+// coefficient is encoded as a [real, imaginary] pair.
+func (s SParameters) MarshalJSON() ([]byte, error) {
+	type noise struct {
+		Frequency                float64
+		MinNoiseFigure           float64
+		ReflectionCoeff          [2]float64
+		EffectiveNoiseResistance float64
+	}
+	v := struct {
+		Ports     int
+		FreqUnit  string
+		Parameter string
+		Format    Format
+		R         float64
+		Comments  []string
+		Frequency []float64
+		Data      [][][][2]float64
+		Noise     []noise `json:",omitempty"`
+	}{
+		Ports:     s.Ports,
+		FreqUnit:  s.FreqUnit,
+		Parameter: s.Parameter,
+		Format:    s.Format,
+		R:         s.R,
+		Comments:  s.Comments,
+		Frequency: s.Frequency,
+		Data:      make([][][][2]float64, len(s.Data)),
+	}
+	for k, matrix := range s.Data {
+		v.Data[k] = make([][][2]float64, len(matrix))
+		for i, row := range matrix {
+			v.Data[k][i] = make([][2]float64, len(row))
+			for j, c := range row {
+				v.Data[k][i][j] = [2]float64{real(c), imag(c)}
+			}
+		}
+	}
+	for _, p := range s.Noise {
+		v.Noise = append(v.Noise, noise{p.Frequency, p.MinNoiseFigure,
+			[2]float64{real(p.ReflectionCoeff), imag(p.ReflectionCoeff)}, p.EffectiveNoiseResistance})
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package keysight
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotmc/keysight/esa"
+	"github.com/gotmc/keysight/fieldfox"
+	"github.com/gotmc/keysight/psa"
+	"github.com/gotmc/keysight/xseries"
+)
+
+// SpectrumTrace returns a spectrum analyzer trace returned by ReadFile as an
+// esa.Trace, which is accepted by the export, plot, tracedb, and emc
+// packages, or false if the value isn't a spectrum analyzer trace. PSA,
+// X-Series, and FieldFox traces are converted, keeping the settings that the
+// ESA trace has fields for.
+func SpectrumTrace(v interface{}) (esa.Trace, bool) {
+	switch t := v.(type) {
+	case esa.Trace:
+		return t, true
+	case psa.Trace:
+		return fromPSA(t), true
+	case xseries.Trace:
+		return fromXSeries(t), true
+	case fieldfox.Trace:
+		return fromFieldFox(t), true
+	}
+	return esa.Trace{}, false
+}
+
+func fromPSA(t psa.Trace) esa.Trace {
+	return esa.Trace{
+		Timestamp:        t.Timestamp,
+		OriginalFilename: t.OriginalFilename,
+		Title:            t.Title,
+		Model:            t.Model,
+		SerialNum:        t.SerialNum,
+		CenterFreq:       t.CenterFreq,
+		CenterFreqUnits:  t.CenterFreqUnits,
+		Span:             t.Span,
+		SpanUnits:        t.SpanUnits,
+		RBW:              t.RBW,
+		RBWUnits:         t.RBWUnits,
+		VBW:              t.VBW,
+		VBWUnits:         t.VBWUnits,
+		RefLevel:         t.RefLevel,
+		RefLevelUnits:    t.RefLevelUnits,
+		SweepTime:        t.SweepTime,
+		SweepTimeUnits:   t.SweepTimeUnits,
+		NumPoints:        t.NumPoints,
+		FreqLabel:        t.FreqLabel,
+		Trace1Label:      t.Trace1Label,
+		Trace2Label:      t.Trace2Label,
+		Trace3Label:      t.Trace3Label,
+		FreqUnits:        t.FreqUnits,
+		Trace1Units:      t.Trace1Units,
+		Trace2Units:      t.Trace2Units,
+		Trace3Units:      t.Trace3Units,
+		Frequency:        t.Frequency,
+		Trace1:           t.Trace1,
+		Trace2:           t.Trace2,
+		Trace3:           t.Trace3,
+	}
+}
+
+func fromXSeries(t xseries.Trace) esa.Trace {
+	trace := esa.Trace{
+		Timestamp:       t.Timestamp,
+		Model:           t.Model,
+		SerialNum:       t.SerialNum,
+		CenterFreq:      t.CenterFreq,
+		CenterFreqUnits: esa.Hertz,
+		Span:            t.Span,
+		SpanUnits:       esa.Hertz,
+		RBW:             t.RBW,
+		RBWUnits:        esa.Hertz,
+		VBW:             t.VBW,
+		VBWUnits:        esa.Hertz,
+		RefLevel:        t.RefLevel,
+		RefLevelUnits:   esa.AmplitudeUnits(t.RefLevelUnits),
+		SweepTime:       t.SweepTime,
+		SweepTimeUnits:  esa.Seconds,
+		NumPoints:       t.NumPoints,
+		FreqLabel:       "Frequency",
+		FreqUnits:       "Hz",
+		Frequency:       t.Frequency,
+	}
+	traces := make([]esa.TraceData, len(t.Traces))
+	for i, td := range t.Traces {
+		label := strings.TrimSpace(td.Type + " " + td.Detector)
+		if label == "" {
+			label = fmt.Sprintf("Trace %d", i+1)
+		}
+		traces[i] = esa.TraceData{Label: label, Units: t.YAxisUnit, Values: td.Values}
+	}
+	trace.SetTraces(traces)
+	return trace
+}
+
+func fromFieldFox(t fieldfox.Trace) esa.Trace {
+	trace := esa.Trace{
+		Timestamp:       t.Timestamp,
+		Model:           t.Model,
+		SerialNum:       t.SerialNum,
+		CenterFreq:      t.CenterFreq,
+		CenterFreqUnits: esa.Hertz,
+		Span:            t.Span,
+		SpanUnits:       esa.Hertz,
+		RBW:             t.RBW,
+		RBWUnits:        esa.Hertz,
+		VBW:             t.VBW,
+		VBWUnits:        esa.Hertz,
+		RefLevel:        t.RefLevel,
+		NumPoints:       len(t.Frequency),
+		FreqLabel:       t.FreqLabel,
+		FreqUnits:       "Hz",
+		Frequency:       t.Frequency,
+	}
+	traces := make([]esa.TraceData, len(t.Traces))
+	for i, values := range t.Traces {
+		label := fmt.Sprintf("Trace %d", i+1)
+		if i < len(t.TraceLabels) && t.TraceLabels[i] != "" {
+			label = t.TraceLabels[i]
+		}
+		traces[i] = esa.TraceData{Label: label, Values: values}
+	}
+	trace.SetTraces(traces)
+	return trace
+}
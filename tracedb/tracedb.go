@@ -0,0 +1,450 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package tracedb archives parsed ESA traces in an SQLite database so that
+// they can be searched by model, date, center frequency, and title.
+//
+// The package uses database/sql and doesn't import an SQLite driver, so the
+// application chooses one, such as modernc.org/sqlite or
+// github.com/mattn/go-sqlite3:
+//
+//	db, err := sql.Open("sqlite", "traces.db")
+//	if err != nil {
+//		return err
+//	}
+//	archive, err := tracedb.New(ctx, db)
+//
+// Each trace is stored as a row of the traces table. The header settings are
+// stored in columns, and the frequency and trace data are stored as blobs of
+// little-endian float64 values. Any trace columns after the third are stored
+// as rows of the extra_traces table. The center frequency, span, and
+// bandwidths are also stored in Hz so they can be queried regardless of the
+// units in the file.
+package tracedb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+// ErrNotFound is returned when a trace with the given ID doesn't exist.
+var ErrNotFound = errors.New("trace not found")
+
+// schema creates the traces and extra_traces tables and the indexes used by
+// Find.
+const schema = `
+CREATE TABLE IF NOT EXISTS traces (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp INTEGER,
+	original_filename TEXT NOT NULL,
+	title TEXT NOT NULL,
+	model TEXT NOT NULL,
+	serial_number TEXT NOT NULL,
+	center_frequency REAL NOT NULL,
+	center_frequency_units TEXT NOT NULL,
+	center_frequency_hz REAL NOT NULL,
+	span REAL NOT NULL,
+	span_units TEXT NOT NULL,
+	span_hz REAL NOT NULL,
+	rbw REAL NOT NULL,
+	rbw_units TEXT NOT NULL,
+	rbw_hz REAL NOT NULL,
+	vbw REAL NOT NULL,
+	vbw_units TEXT NOT NULL,
+	vbw_hz REAL NOT NULL,
+	reference_level REAL NOT NULL,
+	reference_level_units TEXT NOT NULL,
+	sweep_time REAL NOT NULL,
+	sweep_time_units TEXT NOT NULL,
+	num_points INTEGER NOT NULL,
+	frequency_label TEXT NOT NULL,
+	frequency_units TEXT NOT NULL,
+	trace1_label TEXT NOT NULL,
+	trace1_units TEXT NOT NULL,
+	trace2_label TEXT NOT NULL,
+	trace2_units TEXT NOT NULL,
+	trace3_label TEXT NOT NULL,
+	trace3_units TEXT NOT NULL,
+	frequency BLOB,
+	trace1 BLOB,
+	trace2 BLOB,
+	trace3 BLOB
+);
+CREATE INDEX IF NOT EXISTS traces_model ON traces (model);
+CREATE INDEX IF NOT EXISTS traces_timestamp ON traces (timestamp);
+CREATE INDEX IF NOT EXISTS traces_center_frequency_hz ON traces (center_frequency_hz);
+CREATE TABLE IF NOT EXISTS extra_traces (
+	trace_id INTEGER NOT NULL,
+	number INTEGER NOT NULL,
+	label TEXT NOT NULL,
+	units TEXT NOT NULL,
+	data BLOB,
+	PRIMARY KEY (trace_id, number)
+);
+`
+
+// headerColumns are the columns returned in a Record.
+const headerColumns = `id, timestamp, original_filename, title, model, serial_number,
+	center_frequency_hz, span_hz, rbw_hz, vbw_hz, num_points`
+
+var frequencyMultipliers = map[esa.FrequencyUnits]float64{
+	"":            1,
+	esa.Hertz:     1,
+	esa.Kilohertz: 1e3,
+	esa.Megahertz: 1e6,
+	esa.Gigahertz: 1e9,
+}
+
+// DB is an archive of traces stored in an SQLite database.
+type DB struct {
+	db *sql.DB
+}
+
+// New returns an archive using the given SQLite database, creating the
+// traces table if it doesn't exist.
+func New(ctx context.Context, db *sql.DB) (*DB, error) {
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("error creating schema: %s", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Record is the summary of an archived trace returned by Find. Frequencies
+// are in Hz.
+type Record struct {
+	ID int64
+	// Timestamp is in UTC and is zero if unknown.
+	Timestamp        time.Time
+	OriginalFilename string
+	Title            string
+	Model            string
+	SerialNum        string
+	CenterFreq       float64
+	Span             float64
+	RBW              float64
+	VBW              float64
+	NumPoints        int
+}
+
+// Query selects archived traces. Zero values aren't used, so the zero Query
+// matches every trace.
+type Query struct {
+	// Model matches the model exactly.
+	Model string
+	// Title matches titles containing the given text, ignoring the case of
+	// ASCII letters.
+	Title string
+	// From and To match timestamps from From up to but not including To.
+	// Traces without a timestamp don't match if either is set.
+	From time.Time
+	To   time.Time
+	// MinCenterFreq and MaxCenterFreq match center frequencies in Hz within
+	// the inclusive range.
+	MinCenterFreq float64
+	MaxCenterFreq float64
+	// Limit is the maximum number of records returned.
+	Limit int
+}
+
+// Insert stores the trace and returns its ID. The timestamp is stored in UTC
+// with millisecond resolution.
+func (d *DB) Insert(ctx context.Context, trace esa.Trace) (int64, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting trace: %s", err)
+	}
+	defer tx.Rollback()
+	id, err := insert(ctx, tx, trace)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error inserting trace: %s", err)
+	}
+	return id, nil
+}
+
+// insert stores the trace along with its extra trace columns using the
+// transaction.
+func insert(ctx context.Context, tx *sql.Tx, trace esa.Trace) (int64, error) {
+	hz := func(value float64, units esa.FrequencyUnits) (float64, error) {
+		mult, ok := frequencyMultipliers[units]
+		if !ok {
+			return 0, fmt.Errorf("unknown frequency units: %s", units)
+		}
+		return value * mult, nil
+	}
+	center, err := hz(trace.CenterFreq, trace.CenterFreqUnits)
+	if err != nil {
+		return 0, err
+	}
+	span, err := hz(trace.Span, trace.SpanUnits)
+	if err != nil {
+		return 0, err
+	}
+	rbw, err := hz(trace.RBW, trace.RBWUnits)
+	if err != nil {
+		return 0, err
+	}
+	vbw, err := hz(trace.VBW, trace.VBWUnits)
+	if err != nil {
+		return 0, err
+	}
+	var timestamp sql.NullInt64
+	if !trace.Timestamp.IsZero() {
+		timestamp = sql.NullInt64{Int64: trace.Timestamp.UnixMilli(), Valid: true}
+	}
+	result, err := tx.ExecContext(ctx, `INSERT INTO traces (
+	timestamp, original_filename, title, model, serial_number,
+	center_frequency, center_frequency_units, center_frequency_hz,
+	span, span_units, span_hz, rbw, rbw_units, rbw_hz, vbw, vbw_units, vbw_hz,
+	reference_level, reference_level_units, sweep_time, sweep_time_units,
+	num_points, frequency_label, frequency_units,
+	trace1_label, trace1_units, trace2_label, trace2_units, trace3_label, trace3_units,
+	frequency, trace1, trace2, trace3
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		timestamp, trace.OriginalFilename, trace.Title, trace.Model, trace.SerialNum,
+		trace.CenterFreq, string(trace.CenterFreqUnits), center,
+		trace.Span, string(trace.SpanUnits), span,
+		trace.RBW, string(trace.RBWUnits), rbw,
+		trace.VBW, string(trace.VBWUnits), vbw,
+		trace.RefLevel, string(trace.RefLevelUnits), trace.SweepTime, string(trace.SweepTimeUnits),
+		trace.NumPoints, trace.FreqLabel, trace.FreqUnits,
+		trace.Trace1Label, trace.Trace1Units, trace.Trace2Label, trace.Trace2Units,
+		trace.Trace3Label, trace.Trace3Units,
+		encodeValues(trace.Frequency), encodeValues(trace.Trace1),
+		encodeValues(trace.Trace2), encodeValues(trace.Trace3),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting trace: %s", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	for i, td := range trace.ExtraTraces {
+		_, err := tx.ExecContext(ctx, `INSERT INTO extra_traces (
+	trace_id, number, label, units, data
+) VALUES (?, ?, ?, ?, ?)`, id, i+4, td.Label, td.Units, encodeValues(td.Values))
+		if err != nil {
+			return 0, fmt.Errorf("error inserting trace %d: %s", i+4, err)
+		}
+	}
+	return id, nil
+}
+
+// Get returns the trace with the given ID, or ErrNotFound if it doesn't
+// exist.
+func (d *DB) Get(ctx context.Context, id int64) (esa.Trace, error) {
+	var (
+		trace     esa.Trace
+		timestamp sql.NullInt64
+		units     [6]string
+		data      [4][]byte
+	)
+	row := d.db.QueryRowContext(ctx, `SELECT
+	timestamp, original_filename, title, model, serial_number,
+	center_frequency, center_frequency_units, span, span_units,
+	rbw, rbw_units, vbw, vbw_units,
+	reference_level, reference_level_units, sweep_time, sweep_time_units,
+	num_points, frequency_label, frequency_units,
+	trace1_label, trace1_units, trace2_label, trace2_units, trace3_label, trace3_units,
+	frequency, trace1, trace2, trace3
+FROM traces WHERE id = ?`, id)
+	err := row.Scan(
+		&timestamp, &trace.OriginalFilename, &trace.Title, &trace.Model, &trace.SerialNum,
+		&trace.CenterFreq, &units[0], &trace.Span, &units[1],
+		&trace.RBW, &units[2], &trace.VBW, &units[3],
+		&trace.RefLevel, &units[4], &trace.SweepTime, &units[5],
+		&trace.NumPoints, &trace.FreqLabel, &trace.FreqUnits,
+		&trace.Trace1Label, &trace.Trace1Units, &trace.Trace2Label, &trace.Trace2Units,
+		&trace.Trace3Label, &trace.Trace3Units,
+		&data[0], &data[1], &data[2], &data[3],
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return trace, ErrNotFound
+	}
+	if err != nil {
+		return trace, fmt.Errorf("error reading trace %d: %s", id, err)
+	}
+	if timestamp.Valid {
+		trace.Timestamp = time.UnixMilli(timestamp.Int64).UTC()
+	}
+	trace.CenterFreqUnits = esa.FrequencyUnits(units[0])
+	trace.SpanUnits = esa.FrequencyUnits(units[1])
+	trace.RBWUnits = esa.FrequencyUnits(units[2])
+	trace.VBWUnits = esa.FrequencyUnits(units[3])
+	trace.RefLevelUnits = esa.AmplitudeUnits(units[4])
+	trace.SweepTimeUnits = esa.TimeUnits(units[5])
+	values := []*[]float64{&trace.Frequency, &trace.Trace1, &trace.Trace2, &trace.Trace3}
+	for i, b := range data {
+		if *values[i], err = decodeValues(b); err != nil {
+			return trace, fmt.Errorf("error reading trace %d: %s", id, err)
+		}
+	}
+	if trace.ExtraTraces, err = d.extraTraces(ctx, id); err != nil {
+		return trace, fmt.Errorf("error reading trace %d: %s", id, err)
+	}
+	return trace, nil
+}
+
+// extraTraces returns the trace columns after the third of the trace with
+// the given ID.
+func (d *DB) extraTraces(ctx context.Context, id int64) ([]esa.TraceData, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT label, units, data
+FROM extra_traces WHERE trace_id = ? ORDER BY number`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var traces []esa.TraceData
+	for rows.Next() {
+		var td esa.TraceData
+		var data []byte
+		if err := rows.Scan(&td.Label, &td.Units, &data); err != nil {
+			return nil, err
+		}
+		if td.Values, err = decodeValues(data); err != nil {
+			return nil, err
+		}
+		traces = append(traces, td)
+	}
+	return traces, rows.Err()
+}
+
+// Delete removes the trace with the given ID, returning ErrNotFound if it
+// doesn't exist.
+func (d *DB) Delete(ctx context.Context, id int64) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting trace %d: %s", id, err)
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, "DELETE FROM traces WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting trace %d: %s", id, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM extra_traces WHERE trace_id = ?", id); err != nil {
+		return fmt.Errorf("error deleting trace %d: %s", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error deleting trace %d: %s", id, err)
+	}
+	return nil
+}
+
+// Find returns the records of the traces matching the query ordered by
+// timestamp and ID, with traces without a timestamp first.
+func (d *DB) Find(ctx context.Context, q Query) ([]Record, error) {
+	query, args := q.sql()
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding traces: %s", err)
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var r Record
+		var timestamp sql.NullInt64
+		err := rows.Scan(&r.ID, &timestamp, &r.OriginalFilename, &r.Title, &r.Model, &r.SerialNum,
+			&r.CenterFreq, &r.Span, &r.RBW, &r.VBW, &r.NumPoints)
+		if err != nil {
+			return records, fmt.Errorf("error reading record: %s", err)
+		}
+		if timestamp.Valid {
+			r.Timestamp = time.UnixMilli(timestamp.Int64).UTC()
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// sql returns the SELECT statement and its arguments for the query.
+func (q Query) sql() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if q.Model != "" {
+		conditions = append(conditions, "model = ?")
+		args = append(args, q.Model)
+	}
+	if q.Title != "" {
+		conditions = append(conditions, `title LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(q.Title)+"%")
+	}
+	if !q.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, q.From.UnixMilli())
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, q.To.UnixMilli())
+	}
+	if q.MinCenterFreq != 0 {
+		conditions = append(conditions, "center_frequency_hz >= ?")
+		args = append(args, q.MinCenterFreq)
+	}
+	if q.MaxCenterFreq != 0 {
+		conditions = append(conditions, "center_frequency_hz <= ?")
+		args = append(args, q.MaxCenterFreq)
+	}
+	var b strings.Builder
+	b.WriteString("SELECT " + headerColumns + " FROM traces")
+	if len(conditions) > 0 {
+		b.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+	b.WriteString(" ORDER BY timestamp, id")
+	if q.Limit > 0 {
+		b.WriteString(" LIMIT ?")
+		args = append(args, q.Limit)
+	}
+	return b.String(), args
+}
+
+// escapeLike escapes the LIKE wildcards using a backslash.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// encodeValues returns the values as little-endian float64s, or nil if there
+// aren't any values so that they're stored as NULL.
+func encodeValues(values []float64) []byte {
+	if len(values) == 0 {
+		return nil
+	}
+	b := make([]byte, 0, 8*len(values))
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	return b
+}
+
+func decodeValues(b []byte) ([]float64, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if len(b)%8 != 0 {
+		return nil, fmt.Errorf("invalid data length: %d", len(b))
+	}
+	values := make([]float64, len(b)/8)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return values, nil
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package tracedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/esa"
+)
+
+func TestQuerySQL(t *testing.T) {
+	from := time.Date(2021, 11, 16, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	const selectFrom = "SELECT " + headerColumns + " FROM traces"
+	var tests = []struct {
+		name  string
+		query Query
+		sql   string
+		args  []interface{}
+	}{
+		{"all", Query{}, selectFrom + " ORDER BY timestamp, id", nil},
+		{
+			"model and title",
+			Query{Model: "E4402B", Title: "50%_a\\b", Limit: 5},
+			selectFrom + ` WHERE model = ? AND title LIKE ? ESCAPE '\' ORDER BY timestamp, id LIMIT ?`,
+			[]interface{}{"E4402B", `%50\%\_a\\b%`, 5},
+		},
+		{
+			"date and frequency range",
+			Query{From: from, To: to, MinCenterFreq: 1e6, MaxCenterFreq: 2e6},
+			selectFrom + " WHERE timestamp >= ? AND timestamp < ? AND center_frequency_hz >= ? AND center_frequency_hz <= ? ORDER BY timestamp, id",
+			[]interface{}{from.UnixMilli(), to.UnixMilli(), 1e6, 2e6},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, args := test.query.sql()
+			assert(t, "sql", sql, test.sql)
+			assert(t, "args", fmt.Sprint(args), fmt.Sprint(test.args))
+		})
+	}
+}
+
+func TestEncodeValues(t *testing.T) {
+	values := []float64{9e3, -80.25, 1e-300}
+	b := encodeValues(values)
+	assert(t, "length", len(b), 24)
+	got, err := decodeValues(b)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "num values", len(got), len(values))
+	for i := range values {
+		assert(t, fmt.Sprintf("value %d", i), got[i], values[i])
+	}
+	if encodeValues(nil) != nil {
+		t.Errorf("expected nil blob for no values")
+	}
+	if got, err := decodeValues(nil); got != nil || err != nil {
+		t.Errorf("got %v, %v for NULL blob", got, err)
+	}
+	if _, err := decodeValues(b[:20]); err == nil {
+		t.Errorf("expected error for truncated data")
+	}
+}
+
+func TestInsertExtraTraces(t *testing.T) {
+	want, err := esa.ReadCSVFile("../esa/testdata/e4402b_five_traces.csv")
+	if err != nil {
+		t.Fatalf("error reading trace: %s", err)
+	}
+	store := &memStore{tables: map[string][]map[string]driver.Value{}}
+	db := sql.OpenDB(store)
+	defer db.Close()
+	ctx := context.Background()
+	archive, err := New(ctx, db)
+	if err != nil {
+		t.Fatalf("error creating archive: %s", err)
+	}
+	id, err := archive.Insert(ctx, want)
+	if err != nil {
+		t.Fatalf("error inserting trace: %s", err)
+	}
+	assert(t, "extra rows", len(store.tables["extra_traces"]), 2)
+	got, err := archive.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("error getting trace: %s", err)
+	}
+	traces := got.Traces()
+	assert(t, "num traces", len(traces), 5)
+	for i, td := range want.Traces() {
+		if i >= len(traces) {
+			break
+		}
+		assert(t, fmt.Sprintf("trace %d label", i+1), traces[i].Label, td.Label)
+		assert(t, fmt.Sprintf("trace %d units", i+1), traces[i].Units, td.Units)
+		assert(t, fmt.Sprintf("trace %d values", i+1), fmt.Sprint(traces[i].Values), fmt.Sprint(td.Values))
+	}
+	if err := archive.Delete(ctx, id); err != nil {
+		t.Fatalf("error deleting trace: %s", err)
+	}
+	assert(t, "extra rows after delete", len(store.tables["extra_traces"]), 0)
+	if _, err := archive.Get(ctx, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+// memStore is a database/sql connector keeping the rows of each table in
+// memory. It only supports the statements used by DB, so that the archive
+// can be tested without an SQLite driver.
+type memStore struct {
+	mu     sync.Mutex
+	tables map[string][]map[string]driver.Value
+	lastID int64
+}
+
+func (s *memStore) Connect(context.Context) (driver.Conn, error) { return s, nil }
+func (s *memStore) Driver() driver.Driver                        { return s }
+func (s *memStore) Open(string) (driver.Conn, error)             { return s, nil }
+func (s *memStore) Close() error                                 { return nil }
+func (s *memStore) Begin() (driver.Tx, error)                    { return memTx{}, nil }
+
+func (s *memStore) Prepare(query string) (driver.Stmt, error) {
+	return &memStmt{s, strings.Join(strings.Fields(query), " ")}, nil
+}
+
+type memTx struct{}
+
+func (memTx) Commit() error   { return nil }
+func (memTx) Rollback() error { return nil }
+
+type memStmt struct {
+	s     *memStore
+	query string
+}
+
+func (st *memStmt) Close() error  { return nil }
+func (st *memStmt) NumInput() int { return -1 }
+
+// Exec supports CREATE, "INSERT INTO table (columns) VALUES (...)", and
+// "DELETE FROM table WHERE column = ?" statements.
+func (st *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s := st.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch q := st.query; {
+	case strings.HasPrefix(q, "CREATE "):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(q, "INSERT INTO "):
+		table, rest, _ := strings.Cut(strings.TrimPrefix(q, "INSERT INTO "), " (")
+		columns, _, _ := strings.Cut(rest, ") VALUES")
+		row := map[string]driver.Value{}
+		for i, col := range strings.Split(columns, ",") {
+			row[strings.TrimSpace(col)] = args[i]
+		}
+		if table == "traces" {
+			s.lastID++
+			row["id"] = s.lastID
+		}
+		s.tables[table] = append(s.tables[table], row)
+		return memResult(s.lastID), nil
+	case strings.HasPrefix(q, "DELETE FROM "):
+		table, col, _ := strings.Cut(strings.TrimPrefix(q, "DELETE FROM "), " WHERE ")
+		col = strings.TrimSuffix(col, " = ?")
+		var kept []map[string]driver.Value
+		for _, row := range s.tables[table] {
+			if row[col] != args[0] {
+				kept = append(kept, row)
+			}
+		}
+		n := len(s.tables[table]) - len(kept)
+		s.tables[table] = kept
+		return driver.RowsAffected(n), nil
+	}
+	return nil, fmt.Errorf("unsupported statement: %s", st.query)
+}
+
+// Query supports "SELECT columns FROM table WHERE column = ?" statements
+// optionally followed by "ORDER BY column".
+func (st *memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s := st.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	columns, rest, ok := strings.Cut(strings.TrimPrefix(st.query, "SELECT "), " FROM ")
+	table, rest, ok2 := strings.Cut(rest, " WHERE ")
+	col, order, ok3 := strings.Cut(rest, " = ?")
+	if !ok || !ok2 || !ok3 {
+		return nil, fmt.Errorf("unsupported query: %s", st.query)
+	}
+	order = strings.TrimPrefix(order, " ORDER BY ")
+	var matches []map[string]driver.Value
+	for _, row := range s.tables[table] {
+		if row[col] == args[0] {
+			matches = append(matches, row)
+		}
+	}
+	if order != "" {
+		sort.Slice(matches, func(i, j int) bool {
+			return matches[i][order].(int64) < matches[j][order].(int64)
+		})
+	}
+	rows := &memRows{}
+	for _, c := range strings.Split(columns, ",") {
+		rows.columns = append(rows.columns, strings.TrimSpace(c))
+	}
+	for _, row := range matches {
+		values := make([]driver.Value, len(rows.columns))
+		for i, c := range rows.columns {
+			values[i] = row[c]
+		}
+		rows.values = append(rows.values, values)
+	}
+	return rows, nil
+}
+
+type memResult int64
+
+func (r memResult) LastInsertId() (int64, error) { return int64(r), nil }
+func (r memResult) RowsAffected() (int64, error) { return 1, nil }
+
+type memRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *memRows) Columns() []string { return r.columns }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
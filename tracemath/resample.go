@@ -0,0 +1,94 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package tracemath
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gotmc/keysight/internal/interp"
+)
+
+// Interpolation is the method used to interpolate between trace points.
+type Interpolation int
+
+// Available interpolation methods.
+const (
+	// Linear interpolates linearly in frequency.
+	Linear Interpolation = iota
+	// LogFrequency interpolates linearly in the logarithm of the frequency,
+	// which suits traces and limits plotted on a log frequency axis.
+	LogFrequency
+)
+
+// String implements the Stringer interface for Interpolation.
+func (m Interpolation) String() string {
+	switch m {
+	case Linear:
+		return "Linear"
+	case LogFrequency:
+		return "Log Frequency"
+	}
+	return fmt.Sprintf("Interpolation(%d)", int(m))
+}
+
+// WithInterpolation sets the interpolation method used by WithResample. The
+// default is Linear.
+func WithInterpolation(method Interpolation) Option {
+	return func(cfg *config) {
+		cfg.method = method
+	}
+}
+
+// Resample returns the trace interpolated onto the given frequencies in Hz,
+// which must be within the frequency range of the trace.
+func Resample(t Trace, freqs []float64, method Interpolation) (Trace, error) {
+	if len(t.Frequency) != len(t.Values) {
+		return Trace{}, fmt.Errorf("mismatched lengths / freq %d / values %d", len(t.Frequency), len(t.Values))
+	}
+	if method != Linear && method != LogFrequency {
+		return Trace{}, fmt.Errorf("unknown interpolation method: %s", method)
+	}
+	grid := Trace{Frequency: freqs, Values: make([]float64, len(freqs))}
+	if err := covers(t, grid); err != nil {
+		return Trace{}, err
+	}
+	if method == LogFrequency && len(t.Frequency) > 0 && t.Frequency[0] <= 0 {
+		return Trace{}, fmt.Errorf("log frequency interpolation requires positive frequencies")
+	}
+	return Trace{
+		Frequency: append([]float64(nil), freqs...),
+		Values:    interp.Resample(t.Frequency, t.Values, freqs, method == LogFrequency),
+	}, nil
+}
+
+// LinearGrid returns n frequencies evenly spaced from start to stop, which
+// matches the frequencies of a swept trace.
+func LinearGrid(start, stop float64, n int) []float64 {
+	freqs := make([]float64, n)
+	for i := range freqs {
+		if n == 1 {
+			freqs[i] = start
+			break
+		}
+		freqs[i] = start + (stop-start)*float64(i)/float64(n-1)
+	}
+	return freqs
+}
+
+// LogGrid returns n frequencies logarithmically spaced from start to stop,
+// which must both be positive.
+func LogGrid(start, stop float64, n int) []float64 {
+	freqs := make([]float64, n)
+	for i := range freqs {
+		if n == 1 {
+			freqs[i] = start
+			break
+		}
+		freqs[i] = start * math.Pow(stop/start, float64(i)/float64(n-1))
+	}
+	return freqs
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package tracemath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResample(t *testing.T) {
+	tr := Trace{Frequency: []float64{1e6, 100e6}, Values: []float64{0, 20}}
+	var tests = []struct {
+		method Interpolation
+		freqs  []float64
+		want   []float64
+	}{
+		{Linear, []float64{1e6, 50.5e6, 100e6}, []float64{0, 10, 20}},
+		{LogFrequency, []float64{1e6, 10e6, 100e6}, []float64{0, 10, 20}},
+		{LogFrequency, LogGrid(1e6, 100e6, 5), []float64{0, 5, 10, 15, 20}},
+	}
+	for _, test := range tests {
+		t.Run(test.method.String(), func(t *testing.T) {
+			got, err := Resample(tr, test.freqs, test.method)
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assertValues(t, "values", got.Values, test.want)
+			assertValues(t, "freqs", got.Frequency, test.freqs)
+		})
+	}
+
+	if _, err := Resample(tr, []float64{0.5e6}, Linear); err == nil {
+		t.Errorf("expected error resampling outside the trace")
+	}
+	if _, err := Resample(tr, []float64{2e6}, Interpolation(7)); err == nil {
+		t.Errorf("expected error for unknown method")
+	}
+	zero := Trace{Frequency: []float64{0, 1e6}, Values: []float64{0, 1}}
+	if _, err := Resample(zero, []float64{0.5e6}, LogFrequency); err == nil {
+		t.Errorf("expected error for log interpolation from 0 Hz")
+	}
+}
+
+func TestSubtractWithLogResample(t *testing.T) {
+	a := Trace{Frequency: []float64{1e6, 10e6}, Values: []float64{-10, -10}}
+	b := Trace{Frequency: []float64{0.1e6, 100e6}, Values: []float64{0, 30}}
+	got, err := Subtract(a, b, WithResample(), WithInterpolation(LogFrequency))
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assertValues(t, "values", got.Values, []float64{-20, -30})
+}
+
+func TestGrids(t *testing.T) {
+	assertValues(t, "linear grid", LinearGrid(0, 10, 3), []float64{0, 5, 10})
+	assertValues(t, "single point", LinearGrid(3, 10, 1), []float64{3})
+	got := LogGrid(1, 1000, 4)
+	for i, want := range []float64{1, 10, 100, 1000} {
+		assertFloat64(t, "log grid", got[i], want, 1e-9*math.Max(1, want))
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package tracemath performs math on spectrum analyzer traces, such as
+// subtracting a background trace, adding an offset, max/min hold, and
+// averaging.
+//
+// Operations combining several traces require the traces to share the same
+// frequency grid unless WithResample is given, in which case the other traces
+// are linearly interpolated onto the grid of the first trace. Amplitudes are
+// assumed to be in a logarithmic unit, such as dBm or dBuV, for operations
+// that work on power.
+package tracemath
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultTolerance is the relative tolerance used when comparing the
+// frequencies of two traces.
+const defaultTolerance = 1e-9
+
+// Trace is the amplitude of a trace at each frequency in Hz.
+type Trace struct {
+	Frequency []float64
+	Values    []float64
+}
+
+// New returns a trace with the given frequencies and values, which must have
+// the same length.
+func New(freqs, values []float64) (Trace, error) {
+	if len(freqs) != len(values) {
+		return Trace{}, fmt.Errorf("mismatched lengths / freq %d / values %d", len(freqs), len(values))
+	}
+	return Trace{Frequency: freqs, Values: values}, nil
+}
+
+// Len returns the number of points in the trace.
+func (t Trace) Len() int {
+	return len(t.Frequency)
+}
+
+// Option configures how traces with different frequency grids are combined.
+type Option func(*config)
+
+type config struct {
+	resample  bool
+	method    Interpolation
+	tolerance float64
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{tolerance: defaultTolerance}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithResample interpolates traces whose frequency grid differs from the
+// first trace onto its grid instead of returning an error, using the method
+// set by WithInterpolation. The resampled traces must cover the frequency
+// range of the first trace.
+func WithResample() Option {
+	return func(cfg *config) {
+		cfg.resample = true
+	}
+}
+
+// WithTolerance sets the relative tolerance used to decide whether two
+// frequencies are the same. The default is 1e-9.
+func WithTolerance(tolerance float64) Option {
+	return func(cfg *config) {
+		if tolerance >= 0 {
+			cfg.tolerance = tolerance
+		}
+	}
+}
+
+// SameGrid reports whether the traces have the same frequencies within the
+// given relative tolerance.
+func SameGrid(a, b Trace, tolerance float64) bool {
+	if len(a.Frequency) != len(b.Frequency) {
+		return false
+	}
+	for i, f := range a.Frequency {
+		scale := math.Max(math.Abs(f), math.Abs(b.Frequency[i]))
+		if math.Abs(f-b.Frequency[i]) > tolerance*scale {
+			return false
+		}
+	}
+	return true
+}
+
+// align returns the values of each trace on the grid of the first trace.
+func align(traces []Trace, cfg config) ([][]float64, error) {
+	if len(traces) == 0 {
+		return nil, fmt.Errorf("no traces given")
+	}
+	ref := traces[0]
+	values := make([][]float64, len(traces))
+	for i, t := range traces {
+		if len(t.Frequency) != len(t.Values) {
+			return nil, fmt.Errorf("mismatched lengths in trace %d / freq %d / values %d", i, len(t.Frequency), len(t.Values))
+		}
+		if SameGrid(ref, t, cfg.tolerance) {
+			values[i] = t.Values
+			continue
+		}
+		if !cfg.resample {
+			return nil, fmt.Errorf("frequency grid of trace %d differs from trace 0", i)
+		}
+		resampled, err := Resample(t, ref.Frequency, cfg.method)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resample trace %d: %s", i, err)
+		}
+		values[i] = resampled.Values
+	}
+	return values, nil
+}
+
+// covers returns an error unless the frequency range of t covers ref.
+func covers(t, ref Trace) error {
+	if len(ref.Frequency) == 0 {
+		return nil
+	}
+	if len(t.Frequency) == 0 {
+		return fmt.Errorf("trace is empty")
+	}
+	for i := 1; i < len(t.Frequency); i++ {
+		if t.Frequency[i] < t.Frequency[i-1] {
+			return fmt.Errorf("frequencies aren't increasing")
+		}
+	}
+	lo, hi := ref.Frequency[0], ref.Frequency[len(ref.Frequency)-1]
+	if t.Frequency[0] > lo || t.Frequency[len(t.Frequency)-1] < hi {
+		return fmt.Errorf("range %g to %g Hz doesn't cover %g to %g Hz",
+			t.Frequency[0], t.Frequency[len(t.Frequency)-1], lo, hi)
+	}
+	return nil
+}
+
+// combine applies fn to the aligned values at each frequency of the first
+// trace.
+func combine(traces []Trace, opts []Option, fn func([]float64) float64) (Trace, error) {
+	values, err := align(traces, newConfig(opts))
+	if err != nil {
+		return Trace{}, err
+	}
+	ref := traces[0]
+	result := Trace{
+		Frequency: append([]float64(nil), ref.Frequency...),
+		Values:    make([]float64, len(ref.Frequency)),
+	}
+	column := make([]float64, len(values))
+	for i := range result.Values {
+		for j := range values {
+			column[j] = values[j][i]
+		}
+		result.Values[i] = fn(column)
+	}
+	return result, nil
+}
+
+// Map returns a copy of the trace with fn applied to each value, such as to
+// convert units.
+func Map(t Trace, fn func(float64) float64) Trace {
+	result := Trace{
+		Frequency: append([]float64(nil), t.Frequency...),
+		Values:    make([]float64, len(t.Values)),
+	}
+	for i, v := range t.Values {
+		result.Values[i] = fn(v)
+	}
+	return result
+}
+
+// Offset returns a copy of the trace with the offset added to each value.
+func Offset(t Trace, offset float64) Trace {
+	return Map(t, func(v float64) float64 { return v + offset })
+}
+
+// Scale returns a copy of the trace with each value multiplied by the factor.
+func Scale(t Trace, factor float64) Trace {
+	return Map(t, func(v float64) float64 { return v * factor })
+}
+
+// Add returns the sum of the values of the two traces, such as to apply a
+// trace of corrections in dB.
+func Add(a, b Trace, opts ...Option) (Trace, error) {
+	return combine([]Trace{a, b}, opts, func(v []float64) float64 { return v[0] + v[1] })
+}
+
+// Subtract returns the difference of the values of the two traces, which for
+// logarithmic units is the ratio of the traces in dB.
+func Subtract(a, b Trace, opts ...Option) (Trace, error) {
+	return combine([]Trace{a, b}, opts, func(v []float64) float64 { return v[0] - v[1] })
+}
+
+// SubtractPower subtracts the background trace from the trace as linear
+// power, which removes the contribution of the background noise from a
+// measurement. Both traces must be in dB units. Points where the trace
+// doesn't exceed the background are set to negative infinity.
+func SubtractPower(t, background Trace, opts ...Option) (Trace, error) {
+	return combine([]Trace{t, background}, opts, func(v []float64) float64 {
+		return toDB(fromDB(v[0]) - fromDB(v[1]))
+	})
+}
+
+// MaxHold returns the maximum value of the traces at each frequency.
+func MaxHold(traces []Trace, opts ...Option) (Trace, error) {
+	return combine(traces, opts, func(v []float64) float64 {
+		hi := v[0]
+		for _, x := range v[1:] {
+			hi = math.Max(hi, x)
+		}
+		return hi
+	})
+}
+
+// MinHold returns the minimum value of the traces at each frequency.
+func MinHold(traces []Trace, opts ...Option) (Trace, error) {
+	return combine(traces, opts, func(v []float64) float64 {
+		lo := v[0]
+		for _, x := range v[1:] {
+			lo = math.Min(lo, x)
+		}
+		return lo
+	})
+}
+
+// Average returns the linear power average of the traces, which must be in
+// dB units. The values are converted to linear power, averaged, and
+// converted back to dB, which matches the power averaging of the analyzers.
+func Average(traces []Trace, opts ...Option) (Trace, error) {
+	return combine(traces, opts, func(v []float64) float64 {
+		sum := 0.0
+		for _, x := range v {
+			sum += fromDB(x)
+		}
+		return toDB(sum / float64(len(v)))
+	})
+}
+
+// Mean returns the arithmetic mean of the trace values at each frequency,
+// which is the log average for traces in dB units.
+func Mean(traces []Trace, opts ...Option) (Trace, error) {
+	return combine(traces, opts, func(v []float64) float64 {
+		sum := 0.0
+		for _, x := range v {
+			sum += x
+		}
+		return sum / float64(len(v))
+	})
+}
+
+func fromDB(db float64) float64 {
+	return math.Pow(10, db/10)
+}
+
+func toDB(power float64) float64 {
+	if power <= 0 {
+		return math.Inf(-1)
+	}
+	return 10 * math.Log10(power)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package tracemath
+
+import (
+	"math"
+	"testing"
+)
+
+var grid = []float64{1e6, 2e6, 3e6}
+
+func TestOffsetAndScale(t *testing.T) {
+	tr := Trace{Frequency: grid, Values: []float64{-10, -20, -30}}
+	got := Offset(tr, 3)
+	assertValues(t, "offset", got.Values, []float64{-7, -17, -27})
+	got = Scale(tr, 0.5)
+	assertValues(t, "scale", got.Values, []float64{-5, -10, -15})
+	assertFloat64(t, "original unchanged", tr.Values[0], -10, 1e-12)
+}
+
+func TestCombine(t *testing.T) {
+	a := Trace{Frequency: grid, Values: []float64{-10, -20, -30}}
+	b := Trace{Frequency: grid, Values: []float64{-13, -10, -30}}
+	var tests = []struct {
+		name string
+		fn   func() (Trace, error)
+		want []float64
+	}{
+		{"add", func() (Trace, error) { return Add(a, b) }, []float64{-23, -30, -60}},
+		{"subtract", func() (Trace, error) { return Subtract(a, b) }, []float64{3, -10, 0}},
+		{"max hold", func() (Trace, error) { return MaxHold([]Trace{a, b}) }, []float64{-10, -10, -30}},
+		{"min hold", func() (Trace, error) { return MinHold([]Trace{a, b}) }, []float64{-13, -20, -30}},
+		{"mean", func() (Trace, error) { return Mean([]Trace{a, b}) }, []float64{-11.5, -15, -30}},
+		{"average", func() (Trace, error) { return Average([]Trace{a, b}) }, []float64{
+			10 * math.Log10((0.1+math.Pow(10, -1.3))/2),
+			10 * math.Log10((0.01+0.1)/2),
+			-30,
+		}},
+		{"subtract power", func() (Trace, error) { return SubtractPower(a, b) }, []float64{
+			10 * math.Log10(0.1-math.Pow(10, -1.3)),
+			math.Inf(-1),
+			math.Inf(-1),
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.fn()
+			if err != nil {
+				t.Fatalf("received error: %s", err)
+			}
+			assertValues(t, test.name, got.Values, test.want)
+			assert(t, "num freqs", len(got.Frequency), len(grid))
+		})
+	}
+}
+
+func TestCombineResample(t *testing.T) {
+	a := Trace{Frequency: grid, Values: []float64{-10, -20, -30}}
+	b := Trace{Frequency: []float64{0, 2e6, 4e6}, Values: []float64{0, 2, 4}}
+	if _, err := Subtract(a, b); err == nil {
+		t.Errorf("expected error for different frequency grids")
+	}
+	got, err := Subtract(a, b, WithResample())
+	if err != nil {
+		t.Fatalf("received error resampling: %s", err)
+	}
+	assertValues(t, "resampled", got.Values, []float64{-11, -22, -33})
+
+	short := Trace{Frequency: []float64{2e6, 3e6}, Values: []float64{0, 0}}
+	if _, err := Subtract(a, short, WithResample()); err == nil {
+		t.Errorf("expected error resampling trace not covering the grid")
+	}
+
+	nearby := Trace{Frequency: []float64{1e6 + 1, 2e6, 3e6}, Values: []float64{0, 0, 0}}
+	if _, err := Add(a, nearby); err == nil {
+		t.Errorf("expected error for frequency beyond default tolerance")
+	}
+	if _, err := Add(a, nearby, WithTolerance(1e-5)); err != nil {
+		t.Errorf("received error within tolerance: %s", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := New(grid, []float64{1}); err == nil {
+		t.Errorf("expected error for mismatched lengths")
+	}
+	if _, err := MaxHold(nil); err == nil {
+		t.Errorf("expected error for no traces")
+	}
+	bad := Trace{Frequency: grid, Values: []float64{1}}
+	if _, err := Average([]Trace{bad}); err == nil {
+		t.Errorf("expected error for mismatched trace lengths")
+	}
+}
+
+func assertValues(t *testing.T, label string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s length / got %d / want %d", label, len(got), len(want))
+	}
+	for i := range want {
+		if math.IsInf(want[i], 0) {
+			assert(t, label, got[i], want[i])
+			continue
+		}
+		assertFloat64(t, label, got[i], want[i], 1e-9)
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
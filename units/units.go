@@ -0,0 +1,201 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package units converts amplitude values between the units used by spectrum
+// analyzers, such as dBm, dBµV, dBmV, watts, and volts.
+//
+// Conversions between power and voltage units require the reference
+// impedance of the measurement, which is typically 50 Ω, or 75 Ω for cable
+// TV measurements. Linear values that are zero or negative convert to
+// negative infinity in dB units.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultImpedance is the reference impedance in ohms of most RF
+// measurements.
+const DefaultImpedance = 50.0
+
+// Unit is an amplitude unit.
+type Unit string
+
+// Available amplitude units.
+const (
+	DBm        Unit = "dBm"
+	DBuV       Unit = "dBuV"
+	DBmV       Unit = "dBmV"
+	Watts      Unit = "W"
+	Milliwatts Unit = "mW"
+	Volts      Unit = "V"
+	Millivolts Unit = "mV"
+	Microvolts Unit = "uV"
+)
+
+var unitNames = map[string]Unit{
+	"dbm":  DBm,
+	"dbuv": DBuV,
+	"dbµv": DBuV,
+	"dbμv": DBuV,
+	"dbmv": DBmV,
+	"w":    Watts,
+	"mw":   Milliwatts,
+	"v":    Volts,
+	"mv":   Millivolts,
+	"uv":   Microvolts,
+	"µv":   Microvolts,
+	"μv":   Microvolts,
+}
+
+// Parse parses the amplitude units, ignoring case and accepting both µ and u
+// for micro.
+func Parse(s string) (Unit, error) {
+	u, ok := unitNames[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return "", fmt.Errorf("unknown amplitude units: %s", s)
+	}
+	return u, nil
+}
+
+// toWatts returns the power in watts of the value in the given units.
+func toWatts(value float64, from Unit, impedance float64) float64 {
+	switch from {
+	case DBm:
+		return math.Pow(10, (value-30)/10)
+	case DBuV:
+		return voltsToWatts(math.Pow(10, value/20)*1e-6, impedance)
+	case DBmV:
+		return voltsToWatts(math.Pow(10, value/20)*1e-3, impedance)
+	case Watts:
+		return value
+	case Milliwatts:
+		return value * 1e-3
+	case Volts:
+		return voltsToWatts(value, impedance)
+	case Millivolts:
+		return voltsToWatts(value*1e-3, impedance)
+	case Microvolts:
+		return voltsToWatts(value*1e-6, impedance)
+	}
+	return math.NaN()
+}
+
+// fromWatts returns the power in watts in the given units.
+func fromWatts(watts float64, to Unit, impedance float64) float64 {
+	volts := math.Sqrt(watts * impedance)
+	switch to {
+	case DBm:
+		return toDB(watts*1e3, 10)
+	case DBuV:
+		return toDB(volts*1e6, 20)
+	case DBmV:
+		return toDB(volts*1e3, 20)
+	case Watts:
+		return watts
+	case Milliwatts:
+		return watts * 1e3
+	case Volts:
+		return volts
+	case Millivolts:
+		return volts * 1e3
+	case Microvolts:
+		return volts * 1e6
+	}
+	return math.NaN()
+}
+
+// voltsToWatts returns the power of the RMS voltage across the impedance. The
+// sign of the voltage is kept so that negative values remain invalid.
+func voltsToWatts(volts, impedance float64) float64 {
+	if volts < 0 {
+		return -volts * volts / impedance
+	}
+	return volts * volts / impedance
+}
+
+func toDB(ratio, factor float64) float64 {
+	if ratio <= 0 || math.IsNaN(ratio) {
+		return math.Inf(-1)
+	}
+	return factor * math.Log10(ratio)
+}
+
+// Convert converts the value between the given units using the reference
+// impedance in ohms.
+func Convert(value float64, from, to Unit, impedance float64) (float64, error) {
+	if err := check(from, to, impedance); err != nil {
+		return 0, err
+	}
+	return convert(value, from, to, impedance), nil
+}
+
+// ConvertSlice returns a new slice with the values converted between the
+// given units using the reference impedance in ohms.
+func ConvertSlice(values []float64, from, to Unit, impedance float64) ([]float64, error) {
+	if err := check(from, to, impedance); err != nil {
+		return nil, err
+	}
+	converted := make([]float64, len(values))
+	for i, v := range values {
+		converted[i] = convert(v, from, to, impedance)
+	}
+	return converted, nil
+}
+
+func convert(value float64, from, to Unit, impedance float64) float64 {
+	switch {
+	case from == to:
+		return value
+	case isDB(from) && isDB(to) && math.IsInf(value, -1):
+		return value
+	}
+	// Conversions between dB units are a fixed offset, which avoids the
+	// rounding error of converting through watts.
+	if offset, ok := dbOffset(from, to, impedance); ok {
+		return value + offset
+	}
+	return fromWatts(toWatts(value, from, impedance), to, impedance)
+}
+
+func isDB(u Unit) bool {
+	return u == DBm || u == DBuV || u == DBmV
+}
+
+// dbOffset returns the offset to add to a value in the from units to get the
+// to units, if both units are in dB.
+func dbOffset(from, to Unit, impedance float64) (float64, bool) {
+	if !isDB(from) || !isDB(to) {
+		return 0, false
+	}
+	// The offset of each unit from dBuV.
+	ref := func(u Unit) float64 {
+		switch u {
+		case DBm:
+			// 0 dBm is sqrt(1e-3 * R) volts.
+			return 10*math.Log10(impedance*1e-3) + 120
+		case DBmV:
+			return 60
+		}
+		return 0
+	}
+	return ref(from) - ref(to), true
+}
+
+func check(from, to Unit, impedance float64) error {
+	for _, u := range []Unit{from, to} {
+		switch u {
+		case DBm, DBuV, DBmV, Watts, Milliwatts, Volts, Millivolts, Microvolts:
+		default:
+			return fmt.Errorf("unknown amplitude units: %s", u)
+		}
+	}
+	if impedance <= 0 {
+		return fmt.Errorf("invalid impedance: %g", impedance)
+	}
+	return nil
+}
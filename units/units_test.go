@@ -0,0 +1,102 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	var tests = []struct {
+		value     float64
+		from      Unit
+		to        Unit
+		impedance float64
+		want      float64
+	}{
+		{0, DBm, DBuV, 50, 106.98970004336019},
+		{0, DBm, DBmV, 50, 46.98970004336019},
+		{0, DBm, DBuV, 75, 108.75061263391701},
+		{60, DBuV, DBmV, 50, 0},
+		{106.98970004336019, DBuV, DBm, 50, 0},
+		{0, DBm, Watts, 50, 1e-3},
+		{30, DBm, Milliwatts, 50, 1000},
+		{0, DBm, Volts, 50, math.Sqrt(0.05)},
+		{1, Volts, DBm, 50, 10 * math.Log10(20)},
+		{1, Volts, DBuV, 50, 120},
+		{1, Millivolts, DBmV, 75, 0},
+		{1, Microvolts, DBuV, 50, 0},
+		{1, Watts, Volts, 50, math.Sqrt(50)},
+		{0, Watts, DBm, 50, math.Inf(-1)},
+		{-1, Volts, DBuV, 50, math.Inf(-1)},
+		{math.Inf(-1), DBm, DBuV, 50, math.Inf(-1)},
+		{-20, DBm, DBm, 50, -20},
+	}
+	for _, test := range tests {
+		got, err := Convert(test.value, test.from, test.to, test.impedance)
+		if err != nil {
+			t.Errorf("received error converting %g %s to %s: %s", test.value, test.from, test.to, err)
+			continue
+		}
+		if math.IsInf(test.want, 0) {
+			assert(t, string(test.from)+" to "+string(test.to), got, test.want)
+			continue
+		}
+		assertFloat64(t, string(test.from)+" to "+string(test.to), got, test.want, 1e-9)
+	}
+}
+
+func TestConvertSlice(t *testing.T) {
+	got, err := ConvertSlice([]float64{-107, -47}, DBm, DBuV, DefaultImpedance)
+	if err != nil {
+		t.Fatalf("received error converting slice: %s", err)
+	}
+	assertFloat64(t, "value 0", got[0], -0.01029995663981, 1e-9)
+	assertFloat64(t, "value 1", got[1], 59.98970004336019, 1e-9)
+	if _, err := ConvertSlice(nil, DBm, "furlongs", DefaultImpedance); err == nil {
+		t.Errorf("expected error for unknown units")
+	}
+	if _, err := Convert(0, DBm, DBuV, 0); err == nil {
+		t.Errorf("expected error for invalid impedance")
+	}
+}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		given string
+		want  Unit
+	}{
+		{"dBm", DBm},
+		{"dBµV", DBuV},
+		{"DBUV", DBuV},
+		{"dBmV", DBmV},
+		{" W ", Watts},
+		{"µV", Microvolts},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.given)
+		if err != nil {
+			t.Errorf("received error parsing %s: %s", test.given, err)
+		}
+		assert(t, test.given, got, test.want)
+	}
+	if _, err := Parse("dBfoo"); err == nil {
+		t.Errorf("expected error parsing unknown units")
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
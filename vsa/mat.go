@@ -0,0 +1,50 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package vsa
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gotmc/keysight/internal/mat"
+)
+
+// ReadMAT reads a recording saved as a Level 5 MATLAB MAT-file from the
+// given io.Reader. The samples are the Y variable, and the settings are the
+// other scalar and character array variables, such as XDelta and
+// InputCenter. MAT-files saved using the MATLAB 7.3 (HDF5) format aren't
+// supported.
+func ReadMAT(r io.Reader, opts ...Option) (Recording, error) {
+	cfg := newConfig(opts)
+	rec := Recording{Metadata: make(map[string]string)}
+	vars, err := mat.Read(r)
+	if err != nil {
+		return rec, fmt.Errorf("error reading MAT-file: %s", err)
+	}
+	for _, v := range vars {
+		rows, cols := v.Value.Dims()
+		switch {
+		case v.Value.IsChar():
+			rec.Metadata[v.Name] = v.Value.Text()
+		case v.Value.IsNumeric() && !v.Value.IsComplex() && rows*cols == 1:
+			rec.Metadata[v.Name] = strconv.FormatFloat(v.Value.Real()[0], 'g', -1, 64)
+		}
+	}
+	y, ok := mat.Lookup(vars, "Y")
+	if !ok || !y.IsNumeric() {
+		return rec, errors.New("missing Y samples")
+	}
+	if rows, cols := y.Dims(); rows > 1 && cols > 1 {
+		return rec, fmt.Errorf("expected Y to be a vector / got %d-by-%d", rows, cols)
+	}
+	samples := y.Complex()
+	if !cfg.withoutSamples {
+		rec.Samples = samples
+	}
+	return rec, rec.setSettings(len(samples))
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package vsa reads the time data recordings saved by the Keysight 89600
+// VSA software, returning the capture settings, such as the center
+// frequency, sample rate, span, and capture length, along with the IQ
+// samples, so that captures can be post-processed in Go. Recordings saved
+// as Level 5 MATLAB MAT-files (.mat) and as text (.txt or .csv) are
+// supported. The binary Standard Data Format (.sdf) isn't documented well
+// enough to be parsed, so recordings in that format must be saved again in
+// one of the others.
+package vsa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrSDF is returned when attempting to read a recording saved in the
+// Standard Data Format.
+var ErrSDF = errors.New("SDF recordings aren't supported; save the recording as a MAT-file or text instead")
+
+// Recording is a time data recording saved by the 89600 VSA. Frequencies
+// are in Hz and times in seconds.
+type Recording struct {
+	// CenterFreq is the input center frequency, which is zero for baseband
+	// recordings.
+	CenterFreq float64
+	// SampleRate is the reciprocal of the time between samples.
+	SampleRate float64
+	// Span is the width of the valid frequency range, or zero if unknown.
+	Span float64
+	// NumSamples is the number of samples and Duration is the capture length.
+	NumSamples int
+	Duration   float64
+	// StartTime is the time of the first sample.
+	StartTime float64
+	// Zoom is true for IQ (zoomed) recordings, whose samples are complex, and
+	// false for baseband recordings, whose samples are real.
+	Zoom bool
+	// InputRange is the input range and RefImpedance the reference impedance
+	// in ohms.
+	InputRange   float64
+	RefImpedance float64
+	// Units are the units of the samples, such as "V".
+	Units string
+	// Metadata contains all of the scalar and text values saved in the
+	// recording, including those above, keyed by their names, such as
+	// "InputCenter" or "XDelta".
+	Metadata map[string]string
+	// Samples are the IQ samples, which are nil when read using
+	// WithoutSamples.
+	Samples []complex128
+}
+
+// MarshalJSON implements the json.Marshaler interface. JSON doesn't have
+// complex numbers, so each sample is encoded as an [I, Q] pair, whose Q
+// value is zero for baseband recordings.
+func (r Recording) MarshalJSON() ([]byte, error) {
+	type recording Recording
+	v := struct {
+		recording
+		Samples [][2]float64
+	}{recording: recording(r)}
+	if r.Samples != nil {
+		v.Samples = make([][2]float64, len(r.Samples))
+		for i, s := range r.Samples {
+			v.Samples[i] = [2]float64{real(s), imag(s)}
+		}
+	}
+	return json.Marshal(v)
+}
+
+// Option configures the reading of a recording.
+type Option func(*readConfig)
+
+type readConfig struct {
+	withoutSamples bool
+}
+
+// WithoutSamples only reads the settings of the recording, which skips
+// parsing the samples of text recordings.
+func WithoutSamples() Option {
+	return func(cfg *readConfig) {
+		cfg.withoutSamples = true
+	}
+}
+
+// ReadFile reads the recording with the given filename, using the extension
+// to determine the format.
+func ReadFile(filename string, opts ...Option) (Recording, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".sdf" {
+		return Recording{}, ErrSDF
+	}
+	if ext != ".mat" && ext != ".txt" && ext != ".csv" {
+		return Recording{}, fmt.Errorf("unknown recording extension: %s", ext)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return Recording{}, err
+	}
+	defer file.Close()
+	if ext == ".mat" {
+		return ReadMAT(file, opts...)
+	}
+	return ReadText(file, opts...)
+}
+
+// newConfig returns the configuration given by the options.
+func newConfig(opts []Option) readConfig {
+	var cfg readConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// setSettings sets the settings of the recording from its metadata and the
+// number of samples.
+func (rec *Recording) setSettings(numSamples int) error {
+	number := func(name string) (float64, error) {
+		s, ok := rec.Metadata[name]
+		if !ok {
+			return 0, nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing %s: %s", name, err)
+		}
+		return v, nil
+	}
+	if _, ok := rec.Metadata["XDelta"]; !ok {
+		return errors.New("missing XDelta")
+	}
+	delta, err := number("XDelta")
+	if err != nil {
+		return err
+	}
+	if delta <= 0 || math.IsInf(delta, 0) || math.IsNaN(delta) {
+		return fmt.Errorf("invalid XDelta: %g", delta)
+	}
+	var zoom, validMin, validMax float64
+	for _, s := range []struct {
+		name  string
+		value *float64
+	}{
+		{"InputCenter", &rec.CenterFreq},
+		{"XStart", &rec.StartTime},
+		{"InputRange", &rec.InputRange},
+		{"InputRefImped", &rec.RefImpedance},
+		{"InputZoom", &zoom},
+		{"FreqValidMin", &validMin},
+		{"FreqValidMax", &validMax},
+	} {
+		if *s.value, err = number(s.name); err != nil {
+			return err
+		}
+	}
+	rec.SampleRate = 1 / delta
+	rec.Span = validMax - validMin
+	rec.Zoom = zoom != 0
+	rec.Units = rec.Metadata["YUnit"]
+	rec.NumSamples = numSamples
+	rec.Duration = float64(numSamples) * delta
+	return nil
+}
+
+// ReadText reads a recording saved as text from the given io.Reader. Each
+// line of the header contains a name and value separated by a tab or comma,
+// such as "XDelta\t1.5625E-08". The header is followed by a line containing
+// Y and then one line per sample containing the real and, for IQ
+// recordings, imaginary parts.
+func ReadText(r io.Reader, opts ...Option) (Recording, error) {
+	cfg := newConfig(opts)
+	rec := Recording{Metadata: make(map[string]string)}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return rec, err
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	numSamples := -1
+	for i, line := range lines {
+		fields := splitText(line)
+		if len(fields) == 0 || fields[0] == "" {
+			continue
+		}
+		if fields[0] == "Y" && len(fields) == 1 {
+			samples, err := parseSamples(lines[i+1:], i+2)
+			if err != nil {
+				return rec, err
+			}
+			numSamples = len(samples)
+			if !cfg.withoutSamples {
+				rec.Samples = samples
+			}
+			break
+		}
+		if len(fields) != 2 {
+			return rec, fmt.Errorf("line %d: expected name and value / got %d fields", i+1, len(fields))
+		}
+		rec.Metadata[fields[0]] = strings.Trim(fields[1], `"`)
+	}
+	if numSamples < 0 {
+		return rec, errors.New("missing Y samples")
+	}
+	return rec, rec.setSettings(numSamples)
+}
+
+// parseSamples parses the sample lines, where lineNum is the line number of
+// the first line.
+func parseSamples(lines []string, lineNum int) ([]complex128, error) {
+	samples := make([]complex128, 0, len(lines))
+	for i, line := range lines {
+		fields := splitText(line)
+		if len(fields) == 0 || fields[0] == "" {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected real and imaginary parts / got %d fields", lineNum+i, len(fields))
+		}
+		var parts [2]float64
+		for j, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: error parsing sample: %s", lineNum+i, err)
+			}
+			parts[j] = v
+		}
+		samples = append(samples, complex(parts[0], parts[1]))
+	}
+	return samples, nil
+}
+
+// splitText splits a line of a text recording into its trimmed fields,
+// which are separated by tabs or commas.
+func splitText(line string) []string {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == '\t' || r == ','
+	})
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
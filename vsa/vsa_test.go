@@ -0,0 +1,126 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package vsa
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/cmplx"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadFile(t *testing.T) {
+	for _, filename := range []string{"./testdata/n9030a_iq.mat", "./testdata/n9030a_iq.txt"} {
+		t.Run(filename, func(t *testing.T) {
+			rec, err := ReadFile(filename)
+			if err != nil {
+				t.Fatalf("error reading recording: %s", err)
+			}
+			assertFloat64(t, "center freq", rec.CenterFreq, 1e9, 1e-9)
+			assertFloat64(t, "sample rate", rec.SampleRate, 12.8e6, 1e-6)
+			assertFloat64(t, "span", rec.Span, 10e6, 1e-9)
+			assert(t, "num samples", rec.NumSamples, 64)
+			assertFloat64(t, "duration", rec.Duration, 5e-6, 1e-15)
+			assertFloat64(t, "start time", rec.StartTime, 0, 1e-9)
+			assert(t, "zoom", rec.Zoom, true)
+			assertFloat64(t, "input range", rec.InputRange, 0.1, 1e-9)
+			assertFloat64(t, "ref impedance", rec.RefImpedance, 50, 1e-9)
+			assert(t, "units", rec.Units, "V")
+			assert(t, "x units", rec.Metadata["XUnit"], "Sec")
+			assert(t, "num samples", len(rec.Samples), 64)
+			// The samples are a tone at 1/16 of the sample rate.
+			assertFloat64(t, "sample[4] real", real(rec.Samples[4]), 0, 1e-7)
+			assertFloat64(t, "sample[4] imag", imag(rec.Samples[4]), 0.01, 1e-7)
+			assertFloat64(t, "sample[63] magnitude", cmplx.Abs(rec.Samples[63]), 0.01, 1e-7)
+
+			rec, err = ReadFile(filename, WithoutSamples())
+			if err != nil {
+				t.Fatalf("error reading settings: %s", err)
+			}
+			assert(t, "num samples without samples", rec.NumSamples, 64)
+			assert(t, "samples", rec.Samples == nil, true)
+		})
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	if _, err := ReadFile("./testdata/recording.sdf"); !errors.Is(err, ErrSDF) {
+		t.Errorf("got %v for SDF recording", err)
+	}
+	if _, err := ReadFile("./testdata/recording.wav"); err == nil {
+		t.Errorf("expected error for unknown extension")
+	}
+}
+
+func TestReadText(t *testing.T) {
+	rec, err := ReadText(strings.NewReader("XStart,1e-3\nXDelta,1e-6\nY\n0.5\n-0.5\n0.25\n"))
+	if err != nil {
+		t.Fatalf("error reading baseband recording: %s", err)
+	}
+	assert(t, "zoom", rec.Zoom, false)
+	assertFloat64(t, "center freq", rec.CenterFreq, 0, 1e-9)
+	assertFloat64(t, "sample rate", rec.SampleRate, 1e6, 1e-6)
+	assertFloat64(t, "start time", rec.StartTime, 1e-3, 1e-9)
+	assertFloat64(t, "span", rec.Span, 0, 1e-9)
+	assert(t, "samples", len(rec.Samples), 3)
+	assert(t, "sample[1]", rec.Samples[1], complex(-0.5, 0))
+
+	var tests = []struct {
+		name string
+		text string
+	}{
+		{"missing Y", "XDelta\t1e-6\n"},
+		{"missing XDelta", "XStart\t0\nY\n1\t2\n"},
+		{"invalid XDelta", "XDelta\t0\nY\n1\t2\n"},
+		{"invalid setting", "XDelta\t1e-6\nInputCenter\tabc\nY\n1\t2\n"},
+		{"invalid header line", "XDelta\t1e-6\tHz\nY\n1\t2\n"},
+		{"invalid sample", "XDelta\t1e-6\nY\n1\tx\n"},
+		{"too many parts", "XDelta\t1e-6\nY\n1\t2\t3\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadText(strings.NewReader(test.text)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestReadMATErrors(t *testing.T) {
+	if _, err := ReadMAT(strings.NewReader("XDelta\t1e-6\n")); err == nil {
+		t.Errorf("expected error for text file")
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadMAT(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.mat")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadMAT(bytes.NewReader(data))
+	})
+}
+
+func FuzzReadText(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/*.txt")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadText(bytes.NewReader(data))
+	})
+}
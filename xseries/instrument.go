@@ -0,0 +1,187 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package xseries
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpi"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+// Instrument is a live X-Series signal analyzer controlled using SCPI
+// commands sent over an io.ReadWriter, such as a connection from the gotmc
+// visa, usbtmc, or vxi11 packages.
+type Instrument struct {
+	conn *scpi.Conn
+	// now returns the time used as the trace timestamp.
+	now func() time.Time
+}
+
+// NewInstrument returns an X-Series analyzer using the given connection,
+// which must terminate each response with a newline.
+func NewInstrument(rw io.ReadWriter) *Instrument {
+	return &Instrument{conn: scpi.New(rw), now: time.Now}
+}
+
+// traceTypes maps the responses to :TRAC<n>:TYPE? to the names written in
+// CSV files.
+var traceTypes = map[string]string{
+	"WRIT": "Clear Write",
+	"AVER": "Trace Average",
+	"MAXH": "Max Hold",
+	"MINH": "Min Hold",
+}
+
+// detectors maps the responses to :DET:TRAC<n>? to the names written in CSV
+// files.
+var detectors = map[string]string{
+	"AVER": "Average",
+	"NEG":  "Negative Peak",
+	"NORM": "Normal",
+	"POS":  "Peak",
+	"SAMP": "Sample",
+	"QPE":  "Quasi Peak",
+	"EAV":  "EMI Average",
+	"RAV":  "RMS Average",
+}
+
+// powerUnits maps the responses to :UNIT:POW? to the units written in CSV
+// files.
+var powerUnits = map[string]string{
+	"DBM":  "dBm",
+	"DBMV": "dBmV",
+	"DBMA": "dBmA",
+	"DBUV": "dBuV",
+	"DBUA": "dBuA",
+	"V":    "V",
+	"W":    "W",
+	"A":    "A",
+}
+
+// FetchTrace queries the sweep settings and the data of the given traces,
+// which are numbered 1 to 6 and default to trace 1, returning the same Trace
+// as reading a CSV file saved by the instrument. The trace data is
+// transferred as big-endian float32 binary blocks using :FORM REAL,32,
+// unlike the ESA, which only supports ASCII transfers of its traces. The
+// timestamp is the time the trace was fetched in UTC.
+func (inst *Instrument) FetchTrace(traces ...int) (Trace, error) {
+	if len(traces) == 0 {
+		traces = []int{1}
+	}
+	trace := Trace{Header: make(map[string][]string)}
+	for _, n := range traces {
+		if n < 1 || n > 6 {
+			return trace, fmt.Errorf("invalid trace %d", n)
+		}
+	}
+	id, err := inst.conn.Identify()
+	if err != nil {
+		return trace, err
+	}
+	trace.Timestamp = inst.now().UTC().Truncate(time.Second)
+	trace.InstrumentVersion = id.Firmware
+	trace.Model = id.Model
+	trace.SerialNum = id.SerialNum
+	if trace.Mode, err = inst.conn.Query(":INST?"); err != nil {
+		return trace, err
+	}
+	trace.Mode = strings.Trim(trace.Mode, `"`)
+
+	for _, q := range []struct {
+		query string
+		value *float64
+	}{
+		{":FREQ:CENT?", &trace.CenterFreq},
+		{":FREQ:SPAN?", &trace.Span},
+		{":FREQ:STAR?", &trace.StartFreq},
+		{":FREQ:STOP?", &trace.StopFreq},
+		{":BAND?", &trace.RBW},
+		{":BAND:VID?", &trace.VBW},
+		{":DISP:WIND:TRAC:Y:RLEV?", &trace.RefLevel},
+		{":POW:ATT?", &trace.Attenuation},
+		{":SWE:TIME?", &trace.SweepTime},
+	} {
+		if *q.value, err = inst.conn.QueryFloat(q.query); err != nil {
+			return trace, err
+		}
+	}
+	units, err := inst.conn.Query(":UNIT:POW?")
+	if err != nil {
+		return trace, err
+	}
+	trace.RefLevelUnits = lookup(powerUnits, units)
+	trace.YAxisUnit = trace.RefLevelUnits
+	if trace.NumPoints, err = inst.conn.QueryInt(":SWE:POIN?"); err != nil {
+		return trace, err
+	}
+
+	trace.Frequency = make([]float64, trace.NumPoints)
+	for i := range trace.Frequency {
+		trace.Frequency[i] = trace.StartFreq
+		if trace.NumPoints > 1 {
+			step := (trace.StopFreq - trace.StartFreq) / float64(trace.NumPoints-1)
+			trace.Frequency[i] += float64(i) * step
+		}
+	}
+	if err := inst.conn.Command(":FORM:DATA REAL,32"); err != nil {
+		return trace, err
+	}
+	if err := inst.conn.Command(":FORM:BORD NORM"); err != nil {
+		return trace, err
+	}
+	for _, n := range traces {
+		var td TraceData
+		typ, err := inst.conn.Query(fmt.Sprintf(":TRAC%d:TYPE?", n))
+		if err != nil {
+			return trace, err
+		}
+		td.Type = lookup(traceTypes, typ)
+		detector, err := inst.conn.Query(fmt.Sprintf(":DET:TRAC%d?", n))
+		if err != nil {
+			return trace, err
+		}
+		td.Detector = lookup(detectors, detector)
+		data, err := inst.conn.QueryBlock(fmt.Sprintf(":TRAC:DATA? TRACE%d", n))
+		if err != nil {
+			return trace, err
+		}
+		if len(data) != 4*trace.NumPoints {
+			return trace, fmt.Errorf("wrong number of trace %d points / got %d bytes / expected %d",
+				n, len(data), 4*trace.NumPoints)
+		}
+		td.Values, err = block.Float32s(data, block.Normal)
+		if err != nil {
+			return trace, err
+		}
+		trace.Traces = append(trace.Traces, td)
+	}
+	return trace, nil
+}
+
+// lookup returns the name for the SCPI mnemonic, or the mnemonic if it isn't
+// known.
+func lookup(names map[string]string, mnemonic string) string {
+	mnemonic = strings.Trim(strings.TrimSpace(mnemonic), `"`)
+	if name, ok := names[strings.ToUpper(mnemonic)]; ok {
+		return name
+	}
+	return mnemonic
+}
+
+// screenFile is the file on the analyzer's D: drive used to transfer screen
+// captures.
+const screenFile = `D:\SCREEN.PNG`
+
+// Screen returns a capture of the instrument display, which the X-Series
+// saves as a PNG image.
+func (inst *Instrument) Screen() (image.Image, error) {
+	return inst.conn.Screen(screenFile)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package xseries
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/scpitest"
+	"github.com/gotmc/keysight/scpi/block"
+)
+
+// float32Block returns the values as an IEEE 488.2 definite length block of
+// big-endian float32 values.
+func float32Block(values ...float64) string {
+	return string(block.EncodeFloat32s(values, block.Normal))
+}
+
+func newFakeXSeries() *scpitest.Instrument {
+	return scpitest.New(map[string]string{
+		"*IDN?":                   "Keysight Technologies,N9020A,MY49100744,A.23.05",
+		":INST?":                  "SA",
+		":FREQ:CENT?":             "1.000000000E+09",
+		":FREQ:SPAN?":             "1.000000000E+07",
+		":FREQ:STAR?":             "9.950000000E+08",
+		":FREQ:STOP?":             "1.005000000E+09",
+		":BAND?":                  "1.000000000E+05",
+		":BAND:VID?":              "1.000000000E+05",
+		":DISP:WIND:TRAC:Y:RLEV?": "0.00000000E+00",
+		":POW:ATT?":               "1.00000000E+01",
+		":SWE:TIME?":              "1.200000000E-03",
+		":UNIT:POW?":              "DBM",
+		":SWE:POIN?":              "5",
+		":TRAC1:TYPE?":            "WRIT",
+		":TRAC2:TYPE?":            "MAXH",
+		":DET:TRAC1?":             "POS",
+		":DET:TRAC2?":             "AVER",
+		":TRAC:DATA? TRACE1":      float32Block(-80, -78.5, -70, -78.5, -80),
+		":TRAC:DATA? TRACE2":      float32Block(-78.5, -77, -68.5, -77, -78.5),
+	})
+}
+
+func TestInstrumentFetchTrace(t *testing.T) {
+	fake := newFakeXSeries()
+	inst := NewInstrument(fake)
+	inst.now = func() time.Time { return time.Date(2023, 10, 18, 15, 32, 43, 0, time.UTC) }
+	trace, err := inst.FetchTrace(1, 2)
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "timestamp", trace.Timestamp, time.Date(2023, 10, 18, 15, 32, 43, 0, time.UTC))
+	assert(t, "version", trace.InstrumentVersion, "A.23.05")
+	assert(t, "model", trace.Model, "N9020A")
+	assert(t, "mode", trace.Mode, "SA")
+	assertFloat64(t, "span", trace.Span, 10e6, 1e-6)
+	assertFloat64(t, "attenuation", trace.Attenuation, 10, 1e-9)
+	assertFloat64(t, "sweep time", trace.SweepTime, 1.2e-3, 1e-12)
+	assert(t, "ref level units", trace.RefLevelUnits, "dBm")
+	assert(t, "num points", trace.NumPoints, 5)
+	assertFloat64(t, "freq 1", trace.Frequency[1], 997.5e6, 1e-3)
+	assertFloat64(t, "freq 4", trace.Frequency[4], 1005e6, 1e-3)
+	assert(t, "num traces", len(trace.Traces), 2)
+	assert(t, "trace 2 type", trace.Traces[1].Type, "Max Hold")
+	assert(t, "trace 2 detector", trace.Traces[1].Detector, "Average")
+	assertFloat64(t, "trace 1 value", trace.Traces[0].Values[1], -78.5, 1e-6)
+	assertFloat64(t, "trace 2 value", trace.Traces[1].Values[2], -68.5, 1e-6)
+	if !strings.Contains(strings.Join(fake.Commands, "\n"), ":FORM:DATA REAL,32") {
+		t.Errorf("expected binary format command")
+	}
+
+	fake = newFakeXSeries()
+	fake.Responses[":TRAC:DATA? TRACE1"] = float32Block(1, 2)
+	if _, err := NewInstrument(fake).FetchTrace(); err == nil {
+		t.Errorf("expected error for wrong number of points")
+	}
+	if _, err := NewInstrument(newFakeXSeries()).FetchTrace(7); err == nil {
+		t.Errorf("expected error for invalid trace")
+	}
+}
+
+func TestInstrumentScreen(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	src.Set(3, 2, color.RGBA{0, 0xff, 0, 0xff})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeXSeries()
+	fake.Responses["*OPC?"] = "1"
+	fake.Responses[`:MMEM:DATA? 'D:\SCREEN.PNG'`] = string(block.Encode(buf.Bytes()))
+	img, err := NewInstrument(fake).Screen()
+	if err != nil {
+		t.Fatalf("received error: %s", err)
+	}
+	assert(t, "bounds", img.Bounds(), src.Bounds())
+	assert(t, "pixel", color.RGBAModel.Convert(img.At(3, 2)), color.Color(color.RGBA{0, 0xff, 0, 0xff}))
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package xseries
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+	"github.com/gotmc/keysight/internal/limit"
+)
+
+// LimitType determines whether a limit line is an upper or a lower limit.
+type LimitType string
+
+// Available limit line types.
+const (
+	UpperLimit LimitType = "Upper"
+	LowerLimit LimitType = "Lower"
+)
+
+// LimitPoint is a frequency and amplitude breakpoint of a limit line. A
+// Disconnected point starts a new segment that isn't joined to the previous
+// point.
+type LimitPoint struct {
+	Frequency    float64
+	Amplitude    float64
+	Disconnected bool
+}
+
+// LimitLine is a limit line saved by an X-Series signal analyzer. The
+// frequencies are in Hz.
+type LimitLine struct {
+	Number      int
+	Type        LimitType
+	Description string
+	YAxisUnit   string
+	// LogFrequency interpolates between breakpoints using a logarithmic
+	// frequency axis instead of a linear one.
+	LogFrequency bool
+	// Margin is the offset in dB of the margin line from the limit line, which
+	// is only used if MarginOn is true.
+	Margin   float64
+	MarginOn bool
+	Points   []LimitPoint
+}
+
+// LimitResult is the result of evaluating a trace against a limit line.
+type LimitResult struct {
+	// Pass reports whether every tested point is within the limit.
+	Pass bool
+	// MarginPass reports whether every tested point is within the margin
+	// line. It's the same as Pass if the margin is off.
+	MarginPass bool
+	// WorstMargin is the smallest distance in dB between the trace and the
+	// limit line, which is negative if the trace fails the limit.
+	WorstMargin    float64
+	WorstFrequency float64
+	// Failures contains the indices of the trace points that fail the
+	// limit.
+	Failures []int
+	// Tested is the number of trace points within the frequency range of the
+	// limit line.
+	Tested int
+}
+
+// ReadLimitLineFile reads the X-Series limit line saved in CSV format.
+func ReadLimitLineFile(filename string) (LimitLine, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return LimitLine{}, err
+	}
+	defer file.Close()
+	return ReadLimitLine(file)
+}
+
+// ReadLimitLine reads an X-Series limit line in CSV format from the given
+// io.Reader. As with trace files, the header consists of label/value/units
+// lines terminated by a DATA line, which is followed by frequency, amplitude,
+// and connected columns.
+func ReadLimitLine(r io.Reader) (LimitLine, error) {
+	line := LimitLine{Type: UpperLimit}
+	scanner := bufio.NewScanner(r)
+	freqMult := 1.0
+	lineNum := 0
+	foundData := false
+	for scanner.Scan() {
+		lineNum++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		columns := csvrow.Columns(text)
+		if foundData {
+			point, err := parseLimitPoint(columns, freqMult)
+			if err != nil {
+				return line, fmt.Errorf("error parsing limit point in line %d: %s", lineNum, err)
+			}
+			line.Points = append(line.Points, point)
+			continue
+		}
+		if strings.EqualFold(columns[0], dataMarker) {
+			foundData = true
+			continue
+		}
+		if err := line.parseHeader(columns[0], columns[1:], &freqMult); err != nil {
+			return line, fmt.Errorf("error in limit header line %d (%s): %s", lineNum, columns[0], err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return line, err
+	}
+	if !foundData {
+		return line, fmt.Errorf("missing %s line", dataMarker)
+	}
+	return line, nil
+}
+
+func (line *LimitLine) parseHeader(label string, values []string, freqMult *float64) error {
+	value, units := "", ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	if len(values) > 1 {
+		units = values[1]
+	}
+	var err error
+	switch strings.ToLower(label) {
+	case "limit", "limit line":
+		line.Number, err = strconv.Atoi(value)
+	case "type":
+		switch {
+		case strings.EqualFold(value, string(UpperLimit)):
+			line.Type = UpperLimit
+		case strings.EqualFold(value, string(LowerLimit)):
+			line.Type = LowerLimit
+		default:
+			err = fmt.Errorf("unknown limit type: %s", value)
+		}
+	case "description":
+		line.Description = strings.Join(values, ",")
+	case "x axis unit", "x axis units":
+		mult, ok := frequencyMultipliers[strings.ToLower(value)]
+		if !ok {
+			return fmt.Errorf("unknown frequency units: %s", value)
+		}
+		*freqMult = mult
+	case "y axis unit", "y axis units":
+		line.YAxisUnit = value
+	case "frequency interpolation":
+		switch strings.ToLower(value) {
+		case "logarithmic", "log":
+			line.LogFrequency = true
+		case "linear", "lin":
+			line.LogFrequency = false
+		default:
+			err = fmt.Errorf("unknown frequency interpolation: %s", value)
+		}
+	case "margin":
+		line.Margin, err = strconv.ParseFloat(value, 64)
+		if err == nil && units != "" && !strings.EqualFold(units, "dB") {
+			err = fmt.Errorf("unknown margin units: %s", units)
+		}
+	case "margin state":
+		switch strings.ToLower(value) {
+		case "on", "1":
+			line.MarginOn = true
+		case "off", "0":
+			line.MarginOn = false
+		default:
+			err = fmt.Errorf("expected on or off: %s", value)
+		}
+	}
+	return err
+}
+
+func parseLimitPoint(columns []string, freqMult float64) (LimitPoint, error) {
+	if len(columns) < 2 || len(columns) > 3 {
+		return LimitPoint{}, fmt.Errorf("wrong number of columns / got %d / expected 2 or 3", len(columns))
+	}
+	freq, err := strconv.ParseFloat(columns[0], 64)
+	if err != nil {
+		return LimitPoint{}, err
+	}
+	amp, err := strconv.ParseFloat(columns[1], 64)
+	if err != nil {
+		return LimitPoint{}, err
+	}
+	point := LimitPoint{Frequency: freq * freqMult, Amplitude: amp}
+	if len(columns) == 3 {
+		switch columns[2] {
+		case "1":
+		case "0":
+			point.Disconnected = true
+		default:
+			return point, fmt.Errorf("invalid connected value: %s", columns[2])
+		}
+	}
+	return point, nil
+}
+
+// WriteCSVFile writes the limit line to the given filename.
+func (line LimitLine) WriteCSVFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := line.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSV writes the limit line in CSV format to the given io.Writer using
+// the layout read by ReadLimitLine. Frequencies are written in Hz.
+func (line LimitLine) WriteCSV(w io.Writer) error {
+	if line.Type != UpperLimit && line.Type != LowerLimit {
+		return fmt.Errorf("unknown limit type: %s", line.Type)
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "Limit,%d,\n", line.Number)
+	fmt.Fprintf(bw, "Type,%s,\n", line.Type)
+	if line.Description != "" {
+		fmt.Fprintf(bw, "Description,%s,\n", line.Description)
+	}
+	fmt.Fprint(bw, "X Axis Unit,Hz,\n")
+	if line.YAxisUnit != "" {
+		fmt.Fprintf(bw, "Y Axis Unit,%s,\n", line.YAxisUnit)
+	}
+	interp := "Linear"
+	if line.LogFrequency {
+		interp = "Logarithmic"
+	}
+	fmt.Fprintf(bw, "Frequency Interpolation,%s,\n", interp)
+	fmt.Fprintf(bw, "Margin,%s,dB\n", strconv.FormatFloat(line.Margin, 'f', -1, 64))
+	state := "Off"
+	if line.MarginOn {
+		state = "On"
+	}
+	fmt.Fprintf(bw, "Margin State,%s,\n", state)
+	fmt.Fprintln(bw, dataMarker)
+	for _, p := range line.Points {
+		connected := 1
+		if p.Disconnected {
+			connected = 0
+		}
+		fmt.Fprintf(bw, "%s,%s,%d\n",
+			strconv.FormatFloat(p.Frequency, 'f', -1, 64),
+			strconv.FormatFloat(p.Amplitude, 'f', -1, 64),
+			connected,
+		)
+	}
+	return bw.Flush()
+}
+
+// Evaluate tests the first trace of the given trace file against the limit
+// line.
+func (line LimitLine) Evaluate(trace Trace) (LimitResult, error) {
+	if len(trace.Traces) == 0 {
+		return LimitResult{}, fmt.Errorf("trace doesn't contain any trace data")
+	}
+	return line.EvaluateValues(trace.Frequency, trace.Traces[0].Values)
+}
+
+// EvaluateValues tests the amplitude values at the given frequencies in Hz
+// against the limit line. Points outside the frequency range of the limit
+// line aren't tested.
+func (line LimitLine) EvaluateValues(freqs, values []float64) (LimitResult, error) {
+	result, err := line.limit().Evaluate(freqs, values)
+	return LimitResult(result), err
+}
+
+// At returns the limit at the given frequency in Hz. It returns false if the
+// frequency isn't covered by the limit line.
+func (line LimitLine) At(freq float64) (float64, bool) {
+	return line.limit().At(freq)
+}
+
+func (line LimitLine) limit() limit.Line {
+	l := limit.Line{
+		Upper:        line.Type != LowerLimit,
+		LogFrequency: line.LogFrequency,
+		Margin:       line.Margin,
+		MarginOn:     line.MarginOn,
+		Points:       make([]limit.Point, len(line.Points)),
+	}
+	for i, p := range line.Points {
+		l.Points[i] = limit.Point(p)
+	}
+	return l
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package xseries
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadLimitLineFile(t *testing.T) {
+	line, err := ReadLimitLineFile("./testdata/n9020a_limit.csv")
+	if err != nil {
+		t.Fatalf("received error reading limit line: %s", err)
+	}
+	assert(t, "number", line.Number, 2)
+	assert(t, "type", line.Type, LowerLimit)
+	assert(t, "description", line.Description, "Carrier floor")
+	assert(t, "y axis unit", line.YAxisUnit, "dBm")
+	assert(t, "log frequency", line.LogFrequency, false)
+	assert(t, "margin on", line.MarginOn, true)
+	assertFloat64(t, "margin", line.Margin, 2, 1e-12)
+	assert(t, "num points", len(line.Points), 3)
+	assertFloat64(t, "point 1 freq", line.Points[1].Frequency, 1e9, 0.01)
+	assertFloat64(t, "point 1 amp", line.Points[1].Amplitude, -70, 1e-9)
+
+	var buf bytes.Buffer
+	if err := line.WriteCSV(&buf); err != nil {
+		t.Fatalf("received error writing limit line: %s", err)
+	}
+	got, err := ReadLimitLine(&buf)
+	if err != nil {
+		t.Fatalf("received error reading written limit line: %s", err)
+	}
+	assert(t, "round trip type", got.Type, line.Type)
+	assert(t, "round trip description", got.Description, line.Description)
+	assert(t, "round trip margin on", got.MarginOn, line.MarginOn)
+	assert(t, "round trip num points", len(got.Points), len(line.Points))
+	for i := range got.Points {
+		assert(t, "round trip point", got.Points[i], line.Points[i])
+	}
+}
+
+func TestLimitLineEvaluate(t *testing.T) {
+	line, err := ReadLimitLineFile("./testdata/n9020a_limit.csv")
+	if err != nil {
+		t.Fatalf("received error reading limit line: %s", err)
+	}
+	trace, err := ReadCSVFile("./testdata/n9020a_trace.csv")
+	if err != nil {
+		t.Fatalf("received error reading trace: %s", err)
+	}
+	result, err := line.Evaluate(trace)
+	if err != nil {
+		t.Fatalf("received error evaluating trace: %s", err)
+	}
+	assert(t, "pass", result.Pass, false)
+	assert(t, "margin pass", result.MarginPass, false)
+	assert(t, "tested", result.Tested, 11)
+	assert(t, "num failures", len(result.Failures), 8)
+	assertFloat64(t, "worst margin", result.WorstMargin, -4.6466, 1e-9)
+	assertFloat64(t, "worst freq", result.WorstFrequency, 998e6, 0.01)
+
+	// Only the peak is above the lower limit, but not by the 2 dB margin.
+	result, err = line.EvaluateValues([]float64{1e9, 2e9}, []float64{-69, -100})
+	if err != nil {
+		t.Fatalf("received error evaluating values: %s", err)
+	}
+	assert(t, "peak pass", result.Pass, true)
+	assert(t, "peak margin pass", result.MarginPass, false)
+	assert(t, "peak tested", result.Tested, 1)
+
+	if _, err := line.Evaluate(Trace{}); err == nil {
+		t.Errorf("expected error evaluating trace without data")
+	}
+}
+
+func TestReadLimitLineErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"missing data", "Limit,1,\nType,Upper,\n"},
+		{"unknown type", "Type,Sideways,\nDATA\n"},
+		{"bad frequency units", "X Axis Unit,furlongs,\nDATA\n"},
+		{"bad interpolation", "Frequency Interpolation,Cubic,\nDATA\n"},
+		{"bad margin units", "Margin,2,V\nDATA\n"},
+		{"bad point", "DATA\n1,abc,1\n"},
+		{"too many columns", "DATA\n1,2,1,4\n"},
+		{"bad connected", "DATA\n1,2,yes\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadLimitLine(strings.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func FuzzReadLimitLine(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/n9020a_limit.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadLimitLine(bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,297 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package xseries has the ability to parse files from the Keysight X-Series
+// signal analyzers, such as the N9010A EXA, N9020A MXA, and N9030A PXA.
+// Traces can also be fetched from a live analyzer using an Instrument.
+package xseries
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotmc/keysight/internal/csvrow"
+)
+
+// TraceData contains the settings and amplitude data for a single trace
+// column.
+type TraceData struct {
+	Type     string
+	Detector string
+	Values   []float64
+}
+
+// Trace contains the header and trace data saved by an X-Series signal
+// analyzer. Frequencies are in Hz, the sweep time is in seconds, and the
+// attenuation is in dB.
+type Trace struct {
+	Timestamp         time.Time
+	InstrumentVersion string
+	Model             string
+	SerialNum         string
+	Mode              string
+	CenterFreq        float64
+	Span              float64
+	StartFreq         float64
+	StopFreq          float64
+	RBW               float64
+	VBW               float64
+	RefLevel          float64
+	RefLevelUnits     string
+	Attenuation       float64
+	SweepTime         float64
+	NumPoints         int
+	YAxisUnit         string
+	Frequency         []float64
+	Traces            []TraceData
+	// Header contains the values for every header line keyed by the header
+	// label, including those that are also parsed into the fields above.
+	Header map[string][]string
+}
+
+// dataMarker is the line separating the header from the trace data.
+const dataMarker = "DATA"
+
+var frequencyMultipliers = map[string]float64{
+	"":    1,
+	"hz":  1,
+	"khz": 1e3,
+	"mhz": 1e6,
+	"ghz": 1e9,
+}
+
+var timeMultipliers = map[string]float64{
+	"":    1,
+	"s":   1,
+	"sec": 1,
+	"ms":  1e-3,
+	"us":  1e-6,
+	"µs":  1e-6,
+	"ns":  1e-9,
+}
+
+var dateLayouts = []string{
+	"01/02/2006",
+	"2006-01-02",
+	"02 Jan 2006",
+}
+
+// ReadCSVFile reads the X-Series trace data saved in CSV format.
+func ReadCSVFile(filename string) (Trace, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Trace{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file)
+}
+
+// ReadCSV reads the X-Series trace data in CSV format from the given
+// io.Reader. The header consists of label/value/units lines terminated by a
+// DATA line, which is followed by rows containing the frequency and one
+// column per saved trace.
+func ReadCSV(r io.Reader) (Trace, error) {
+	trace := Trace{Header: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+
+	var date, clock string
+	lineNum := 0
+	foundData := false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSuffix(line, ","), dataMarker) {
+			foundData = true
+			break
+		}
+		columns := csvrow.Columns(line)
+		label := columns[0]
+		values := columns[1:]
+		trace.Header[label] = append(trace.Header[label], values...)
+		if err := trace.parseHeader(label, values, &date, &clock); err != nil {
+			return trace, fmt.Errorf("error in header line %d (%s): %s", lineNum, label, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return trace, err
+	}
+	if !foundData {
+		return trace, fmt.Errorf("missing %s line", dataMarker)
+	}
+	if date != "" {
+		ts, err := parseTimestamp(date, clock)
+		if err != nil {
+			return trace, fmt.Errorf("error parsing timestamp: %s", err)
+		}
+		trace.Timestamp = ts
+	}
+
+	// Parse the data rows. The number of trace columns is determined by the
+	// first data row, since the header may describe more traces than were
+	// saved. The rows are split without allocating and the columns are
+	// preallocated using the number of points in the header.
+	n := csvrow.Capacity(trace.NumPoints)
+	if n > 0 {
+		trace.Frequency = make([]float64, 0, n)
+	}
+	var splitter csvrow.Splitter
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		columns := splitter.Columns(line)
+		if len(columns) < 2 {
+			return trace, fmt.Errorf("error in trace data line %d: %s", lineNum, line)
+		}
+		if len(trace.Frequency) == 0 {
+			trace.growTraces(len(columns) - 1)
+			trace.Traces = trace.Traces[:len(columns)-1]
+			for i := range trace.Traces {
+				trace.Traces[i].Values = make([]float64, 0, n)
+			}
+		}
+		if len(columns)-1 != len(trace.Traces) {
+			return trace, fmt.Errorf(
+				"wrong number of trace columns in line %d / got %d / expected %d",
+				lineNum, len(columns)-1, len(trace.Traces),
+			)
+		}
+		freq, err := csvrow.ParseFloat(columns[0])
+		if err != nil {
+			return trace, fmt.Errorf("error parsing frequency %s in line %d", columns[0], lineNum)
+		}
+		trace.Frequency = append(trace.Frequency, freq)
+		for i, col := range columns[1:] {
+			v, err := csvrow.ParseFloat(col)
+			if err != nil {
+				return trace, fmt.Errorf("error parsing trace %d value %s in line %d", i+1, col, lineNum)
+			}
+			trace.Traces[i].Values = append(trace.Traces[i].Values, v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return trace, err
+	}
+	if trace.NumPoints != 0 && len(trace.Frequency) != trace.NumPoints {
+		return trace, fmt.Errorf(
+			"wrong number of data points / got %d / expected %d",
+			len(trace.Frequency), trace.NumPoints,
+		)
+	}
+	if trace.NumPoints == 0 {
+		trace.NumPoints = len(trace.Frequency)
+	}
+	return trace, nil
+}
+
+// parseHeader parses the known header labels into the trace fields.
+func (trace *Trace) parseHeader(label string, values []string, date, clock *string) error {
+	value := ""
+	units := ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	if len(values) > 1 {
+		units = values[1]
+	}
+	var err error
+	switch strings.ToLower(label) {
+	case "instrument version":
+		trace.InstrumentVersion = value
+	case "model":
+		trace.Model = value
+	case "serial number":
+		trace.SerialNum = value
+	case "date":
+		*date = value
+	case "time":
+		*clock = value
+	case "mode":
+		trace.Mode = value
+	case "center frequency", "center freq":
+		trace.CenterFreq, err = parseScaled(value, units, frequencyMultipliers)
+	case "span":
+		trace.Span, err = parseScaled(value, units, frequencyMultipliers)
+	case "start frequency", "start freq":
+		trace.StartFreq, err = parseScaled(value, units, frequencyMultipliers)
+	case "stop frequency", "stop freq":
+		trace.StopFreq, err = parseScaled(value, units, frequencyMultipliers)
+	case "resolution bandwidth", "rbw":
+		trace.RBW, err = parseScaled(value, units, frequencyMultipliers)
+	case "video bandwidth", "vbw":
+		trace.VBW, err = parseScaled(value, units, frequencyMultipliers)
+	case "reference level", "ref level":
+		trace.RefLevel, err = strconv.ParseFloat(value, 64)
+		trace.RefLevelUnits = units
+	case "attenuation":
+		trace.Attenuation, err = strconv.ParseFloat(value, 64)
+	case "sweep time":
+		trace.SweepTime, err = parseScaled(value, units, timeMultipliers)
+	case "number of points", "num points":
+		trace.NumPoints, err = strconv.Atoi(value)
+	case "y axis unit", "y axis units":
+		trace.YAxisUnit = value
+	case "trace type":
+		trace.growTraces(len(values))
+		for i, v := range values {
+			trace.Traces[i].Type = v
+		}
+	case "detector":
+		trace.growTraces(len(values))
+		for i, v := range values {
+			trace.Traces[i].Detector = v
+		}
+	}
+	return err
+}
+
+// growTraces ensures there are at least n traces.
+func (trace *Trace) growTraces(n int) {
+	for len(trace.Traces) < n {
+		trace.Traces = append(trace.Traces, TraceData{})
+	}
+}
+
+func parseScaled(value, units string, multipliers map[string]float64) (float64, error) {
+	mult, ok := multipliers[strings.ToLower(units)]
+	if !ok {
+		return 0, fmt.Errorf("unknown units: %s", units)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return f * mult, nil
+}
+
+func parseTimestamp(date, clock string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		d, err := time.Parse(layout, date)
+		if err != nil {
+			continue
+		}
+		if clock == "" {
+			return d, nil
+		}
+		c, err := time.Parse("15:04:05", clock)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Date(d.Year(), d.Month(), d.Day(),
+			c.Hour(), c.Minute(), c.Second(), 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format: %s", date)
+}
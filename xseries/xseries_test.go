@@ -0,0 +1,133 @@
+// Copyright (c) 2021-2024 The keysight developers. All rights reserved.
+// Project site: https://github.com/gotmc/keysight
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package xseries
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotmc/keysight/internal/fuzztest"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	got, err := ReadCSVFile("./testdata/n9020a_trace.csv")
+	if err != nil {
+		t.Fatalf("received error reading CSV file: %s", err)
+	}
+	assert(t, "timestamp", got.Timestamp, time.Date(2023, time.October, 18, 15, 32, 43, 0, time.UTC))
+	assert(t, "instrument version", got.InstrumentVersion, "A.23.05")
+	assert(t, "model", got.Model, "N9020A")
+	assert(t, "s/n", got.SerialNum, "MY49100744")
+	assert(t, "mode", got.Mode, "SA")
+	assertFloat64(t, "center freq", got.CenterFreq, 1e9, 0.01)
+	assertFloat64(t, "span", got.Span, 10e6, 0.01)
+	assertFloat64(t, "start freq", got.StartFreq, 995e6, 0.01)
+	assertFloat64(t, "stop freq", got.StopFreq, 1005e6, 0.01)
+	assertFloat64(t, "rbw", got.RBW, 100e3, 0.01)
+	assertFloat64(t, "vbw", got.VBW, 100e3, 0.01)
+	assertFloat64(t, "ref level", got.RefLevel, 0, 0.0001)
+	assert(t, "ref level units", got.RefLevelUnits, "dBm")
+	assertFloat64(t, "attenuation", got.Attenuation, 10, 0.0001)
+	assertFloat64(t, "sweep time", got.SweepTime, 0.0012, 1e-9)
+	assert(t, "num points", got.NumPoints, 11)
+	assert(t, "y axis unit", got.YAxisUnit, "dBm")
+	assert(t, "num traces", len(got.Traces), 2)
+	assert(t, "trace 1 type", got.Traces[0].Type, "Clear Write")
+	assert(t, "trace 2 type", got.Traces[1].Type, "Max Hold")
+	assert(t, "trace 2 detector", got.Traces[1].Detector, "Peak")
+	assert(t, "freq len", len(got.Frequency), 11)
+	assert(t, "trace 1 len", len(got.Traces[0].Values), 11)
+	assertFloat64(t, "freq[5]", got.Frequency[5], 1e9, 0.01)
+	assertFloat64(t, "t1[5]", got.Traces[0].Values[5], -70.0, 0.00001)
+	assertFloat64(t, "t2[4]", got.Traces[1].Values[4], -72.4347, 0.00001)
+	assert(t, "header mode", got.Header["Mode"][0], "SA")
+}
+
+func TestReadCSVSingleTrace(t *testing.T) {
+	data := "Model,N9010A,\nNumber of Points,3,\nTrace Type,Average,Clear Write,Clear Write\nDATA\n1,-10\n2,-11\n3,-12\n"
+	got, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("received error reading CSV: %s", err)
+	}
+	assert(t, "num traces", len(got.Traces), 1)
+	assert(t, "trace 1 type", got.Traces[0].Type, "Average")
+	assertFloat64(t, "t1[2]", got.Traces[0].Values[2], -12, 0.00001)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"missing data", "Model,N9010A,\n"},
+		{"bad units", "Span,10,furlongs\nDATA\n"},
+		{"wrong num points", "Number of Points,3,\nDATA\n1,-10\n"},
+		{"huge num points", "Number of Points,2000000000,\nDATA\n1,-10\n"},
+		{"ragged columns", "DATA\n1,-10,-11\n2,-10\n"},
+		{"bad value", "DATA\n1,abc\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func assert(t *testing.T, label string, got, want interface{}) {
+	if got != want {
+		t.Errorf("\ngot  = `%#v` for %s\nwant = `%#v`", got, label, want)
+	}
+}
+
+func assertFloat64(t *testing.T, label string, got, want, tolerance float64) {
+	if diff := math.Abs(want - got); diff >= tolerance {
+		t.Errorf("\ngot %s  = %#v \t\nwant %s = %#v", label, got, label, want)
+	}
+}
+
+func FuzzReadCSV(f *testing.F) {
+	fuzztest.AddSeeds(f, "testdata/n9020a_trace.csv")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadCSV(bytes.NewReader(data))
+	})
+}
+
+func BenchmarkReadCSV(b *testing.B) {
+	data := benchmarkCSV(b, 40001)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadCSV(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkCSV returns the header of the test file followed by the given
+// number of data rows with two traces, like a full X-Series sweep.
+func benchmarkCSV(b *testing.B, points int) []byte {
+	data, err := os.ReadFile("./testdata/n9020a_trace.csv")
+	if err != nil {
+		b.Fatal(err)
+	}
+	header, _, _ := bytes.Cut(data, []byte("DATA\r\n"))
+	header = bytes.Replace(header, []byte("Number of Points,11,"), []byte(fmt.Sprintf("Number of Points,%d,", points)), 1)
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteString("DATA\r\n")
+	for i := 0; i < points; i++ {
+		fmt.Fprintf(&buf, "%d,%.4f,%.4f\r\n", 995000000+i*250, -80+10*math.Sin(float64(i)), -78.5+10*math.Cos(float64(i)))
+	}
+	return buf.Bytes()
+}